@@ -155,8 +155,69 @@ local function insert_header_to_payload(conf, result)
   return result
 end
 
+local function to_array(value)
+  if type(value) == "table" then
+    return value
+  elseif type(value) == "boolean" then
+    -- a query arg without value, e.g. ?verbose
+    return { "" }
+  end
+  return { value }
+end
+
+-- the path parameters of the longest matching URI template
+local function match_params(conf, path)
+  local params, longest = {}, -1
+  for _, pattern in ipairs(conf.uri_templates) do
+    local m = ngx.re.match(path, pattern, "ajo")
+    if m and #m[0] > longest then
+      longest = #m[0]
+      params = {}
+      for name, value in pairs(m) do
+        if type(name) == "string" then
+          params[name] = value
+        end
+      end
+    end
+  end
+  return params
+end
+
+-- the original HTTP request, before it is transformed, credentials are hidden from functions
+local function get_http_request(conf)
+  local authenticated = ngx.ctx.authenticated_credential ~= nil
+
+  local query = {}
+  for name, value in pairs(ngx.req.get_uri_args()) do
+    if not (authenticated and (name == "apikey" or name == "jwt")) then
+      query[name] = to_array(value)
+    end
+  end
+  local headers = {}
+  for name, value in pairs(ngx.req.get_headers()) do
+    if not (authenticated and (name == "apikey" or name == "authorization")) then
+      headers[name] = to_array(value)
+    end
+  end
+
+  return {
+    method = ngx.req.get_method(),
+    host = ngx.var.host,
+    path = ngx.var.uri,
+    params = match_params(conf, ngx.var.uri),
+    query = query,
+    headers = headers,
+    remoteAddr = ngx.var.remote_addr,
+  }
+end
+
 local function tranform_request(conf)
 
+  local http_request
+  if conf.enable.http_request then
+    http_request = get_http_request(conf)
+  end
+
   transform_header(conf)
   transform_querystrings(conf)
 
@@ -176,6 +237,7 @@ local function tranform_request(conf)
 
   -- insert special prefixed headers into payload
   result = insert_header_to_payload(conf, result)
+  result.httpRequest = http_request
 
   -- set the transformed data to payload
   result = cjson.encode(result)
@@ -196,13 +258,53 @@ end
 -- transform response
 ----------------------------------------------------------
 
+-- set by the function manager when the function returns an HTTP response, which is passed as is
+local http_response_header = "X-Dispatch-Http-Response"
+
+-- the lengths of the rate limiting windows, aligned in UTC by the rate-limiting plugin
+local window_seconds = { second = 1, minute = 60, hour = 3600, day = 86400 }
+
+-- tell throttled clients when to retry, i.e. at the end of the longest exceeded window
+local function set_retry_after()
+  if ngx.status ~= 429 or ngx.header["Retry-After"] then
+    return
+  end
+
+  local now = ngx.time()
+  local retry_after
+  for period, seconds in pairs(window_seconds) do
+    if ngx.header["X-RateLimit-Remaining-" .. period] == "0" then
+      local wait = seconds - now % seconds
+      if retry_after == nil or wait > retry_after then
+        retry_after = wait
+      end
+    end
+  end
+  if ngx.header["X-RateLimit-Remaining-month"] == "0" then
+    local date = os.date("!*t", now)
+    local next_month = os.time({ year = date.year, month = date.month + 1, day = 1, hour = 0 })
+    retry_after = next_month - os.time(os.date("!*t", now))
+  end
+  if retry_after then
+    ngx.header["Retry-After"] = retry_after
+  end
+end
+
 function DispatchTransformerHandler:header_filter(conf)
   DispatchTransformerHandler.super.header_filter(self)
 
+  set_retry_after()
+
   local ctx = ngx.ctx
   ctx.rt_body_chunks = {}
   ctx.rt_body_chunk_number = 1
 
+  if ngx.header[http_response_header] then
+    ctx.rt_http_response = true
+    ngx.header[http_response_header] = nil
+    return
+  end
+
   -- make changes only if the response is json
   if conf.enable.output and is_json_body(ngx.header) then
     -- clear content-length header as the body content changed
@@ -250,8 +352,8 @@ end
 function DispatchTransformerHandler:body_filter(conf)
   DispatchTransformerHandler.super.body_filter(self)
 
-  -- make changes only if the response is json
-  if conf.enable.output and is_json_body(ngx.header) then
+  -- make changes only if the response is json, and not an HTTP response of the function
+  if conf.enable.output and not ngx.ctx.rt_http_response and is_json_body(ngx.header) then
     substitute_response(conf)
   end
 end
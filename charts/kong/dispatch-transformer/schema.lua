@@ -25,6 +25,8 @@ return {
   fields = {
     http_method = {type = "string", default = "POST", func = check_method},
     header_prefix_for_insertion = {type = "string", default = "x-dispatch-"},
+    -- the regular expressions of the API URI templates, with a named group for each path parameter
+    uri_templates = {type = "array", default = {}},
     add = {
       type = "table",
       schema = {
@@ -65,6 +67,8 @@ return {
         fields = {
           input  = { type = "boolean", default = true },
           output = { type = "boolean", default = true },
+          -- insert the original HTTP request into request body
+          http_request = { type = "boolean", default = true },
         }
       }
     }
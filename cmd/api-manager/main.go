@@ -6,6 +6,7 @@
 package main
 
 import (
+	"net/http"
 	"os"
 	"time"
 
@@ -17,9 +18,12 @@ import (
 	log "github.com/sirupsen/logrus"
 
 	apimanager "github.com/vmware/dispatch/pkg/api-manager"
+	"github.com/vmware/dispatch/pkg/api-manager/gateway"
+	"github.com/vmware/dispatch/pkg/api-manager/gateway/builtin"
 	"github.com/vmware/dispatch/pkg/api-manager/gateway/kong"
 	"github.com/vmware/dispatch/pkg/api-manager/gen/restapi"
 	"github.com/vmware/dispatch/pkg/api-manager/gen/restapi/operations"
+	"github.com/vmware/dispatch/pkg/controller"
 	"github.com/vmware/dispatch/pkg/entity-store"
 	"github.com/vmware/dispatch/pkg/middleware"
 	"github.com/vmware/dispatch/pkg/trace"
@@ -41,6 +45,11 @@ func configureFlags() []swag.CommandLineOptionsGroup {
 			LongDescription:  "",
 			Options:          &apimanager.APIManagerFlags,
 		},
+		swag.CommandLineOptionsGroup{
+			ShortDescription: "Leader election options",
+			LongDescription:  "",
+			Options:          &controller.LeaderElectionFlags,
+		},
 		swag.CommandLineOptionsGroup{
 			ShortDescription: "Debug options",
 			LongDescription:  "",
@@ -49,6 +58,26 @@ func configureFlags() []swag.CommandLineOptionsGroup {
 	}
 }
 
+// serveBuiltinGateway starts the http and https listeners of the built-in gateway
+func serveBuiltinGateway(gw *builtin.Gateway) {
+	go func() {
+		log.Infof("Serving the built-in api gateway at http://%s", apimanager.APIManagerFlags.GatewayHTTP)
+		log.Fatalln(http.ListenAndServe(apimanager.APIManagerFlags.GatewayHTTP, gw))
+	}()
+	if apimanager.APIManagerFlags.GatewayHTTPS == "" {
+		return
+	}
+	go func() {
+		s := &http.Server{
+			Addr:      apimanager.APIManagerFlags.GatewayHTTPS,
+			Handler:   gw,
+			TLSConfig: gw.TLSConfig(),
+		}
+		log.Infof("Serving the built-in api gateway at https://%s", s.Addr)
+		log.Fatalln(s.ListenAndServeTLS("", ""))
+	}()
+}
+
 func main() {
 
 	swaggerSpec, err := loads.Analyzed(restapi.SwaggerJSON, "2.0")
@@ -103,30 +132,61 @@ func main() {
 	}
 
 	// api gateway
-	gateway, err := kong.NewClient(&kong.Config{
-		Host:     apimanager.APIManagerFlags.GatewayHost,
-		Upstream: apimanager.APIManagerFlags.FunctionManager,
-	})
+	var gw gateway.Gateway
+	var builtinGateway *builtin.Gateway
+	switch apimanager.APIManagerFlags.Gateway {
+	case "kong":
+		gw, err = kong.NewClient(&kong.Config{
+			Host:     apimanager.APIManagerFlags.GatewayHost,
+			Upstream: apimanager.APIManagerFlags.FunctionManager,
+		})
+	case "builtin":
+		builtinGateway, err = builtin.NewGateway(&builtin.Config{
+			FunctionManager: apimanager.APIManagerFlags.FunctionManager,
+			Store:           es,
+			OrganizationID:  apimanager.APIManagerFlags.OrgID,
+		})
+		gw = builtinGateway
+	default:
+		log.Fatalf("Unknown api gateway %s, it is either kong or builtin", apimanager.APIManagerFlags.Gateway)
+	}
 	if err != nil {
 		log.Fatalf("Error creating an api gateway client: %v", err)
 	}
 	log.Debugf("initialize api gateway")
-	err = gateway.Initialize()
+	err = gw.Initialize()
 	if err != nil {
 		log.Fatalf("Error initialize the gateway: %v", err)
 	}
 
 	// controller
+	elector, err := controller.NewLeaderElector("api-manager", es, apimanager.APIManagerFlags.OrgID)
+	if err != nil {
+		log.Fatalf("Error creating the leader election: %v", err)
+	}
+	if elector != nil && builtinGateway != nil {
+		// each replica serves the routes, which only the leader would restore
+		log.Fatalln("The built-in api gateway does not support leader election, use kong instead")
+	}
 	config := &apimanager.ControllerConfig{
 		ResyncPeriod:   time.Duration(apimanager.APIManagerFlags.ResyncPeriod) * time.Second,
 		OrganizationID: apimanager.APIManagerFlags.OrgID,
+		Elector:        elector,
 	}
-	controller := apimanager.NewController(config, es, gateway)
+	controller := apimanager.NewController(config, es, gw, apimanager.SecretStoreClient())
 	defer controller.Shutdown()
 	controller.Start()
 
+	if builtinGateway != nil {
+		serveBuiltinGateway(builtinGateway)
+		// the built-in gateway keeps the routes in memory only
+		if err := apimanager.RestoreGateway(controller.Watcher(), es, apimanager.APIManagerFlags.OrgID); err != nil {
+			log.Fatalf("Error restoring the gateway: %v", err)
+		}
+	}
+
 	// handlers
-	handlers := apimanager.NewHandlers(controller.Watcher(), es)
+	handlers := apimanager.NewHandlers(controller.Watcher(), es, gw)
 	handlers.ConfigureHandlers(api)
 
 	healthChecker := func() error {
@@ -17,12 +17,15 @@ import (
 
 	"github.com/vmware/dispatch/pkg/client"
 	"github.com/vmware/dispatch/pkg/config"
+	"github.com/vmware/dispatch/pkg/controller"
 	"github.com/vmware/dispatch/pkg/entity-store"
 	"github.com/vmware/dispatch/pkg/event-manager"
 	"github.com/vmware/dispatch/pkg/event-manager/drivers"
 	"github.com/vmware/dispatch/pkg/event-manager/gen/restapi"
 	"github.com/vmware/dispatch/pkg/event-manager/gen/restapi/operations"
+	"github.com/vmware/dispatch/pkg/event-manager/history"
 	"github.com/vmware/dispatch/pkg/event-manager/subscriptions"
+	"github.com/vmware/dispatch/pkg/events"
 	"github.com/vmware/dispatch/pkg/events/transport"
 	"github.com/vmware/dispatch/pkg/middleware"
 	"github.com/vmware/dispatch/pkg/trace"
@@ -44,6 +47,11 @@ func configureFlags() []swag.CommandLineOptionsGroup {
 			LongDescription:  "",
 			Options:          &eventmanager.Flags,
 		},
+		{
+			ShortDescription: "Leader election options",
+			LongDescription:  "",
+			Options:          &controller.LeaderElectionFlags,
+		},
 		{
 			ShortDescription: "Debug options",
 			LongDescription:  "",
@@ -106,21 +114,39 @@ func main() {
 	}
 
 	// TODO: add more parameters to be customizable via flags
-	queue, err := transport.NewRabbitMQ(
-		eventmanager.Flags.RabbitMQURL,
-		eventmanager.Flags.OrgID,
-	)
-	if err != nil {
-		log.Fatalf("Error creating RabbitMQ connection: %+v", err)
+	var queue events.Transport
+	switch eventmanager.Flags.Transport {
+	case "rabbitmq":
+		queue, err = transport.NewRabbitMQ(
+			eventmanager.Flags.RabbitMQURL,
+			eventmanager.Flags.OrgID,
+		)
+		if err != nil {
+			log.Fatalf("Error creating RabbitMQ connection: %+v", err)
+		}
+	case "kafka":
+		queue, err = transport.NewKafka(
+			eventmanager.Flags.KafkaBrokers,
+			eventmanager.Flags.OrgID,
+		)
+		if err != nil {
+			log.Fatalf("Error creating Kafka connection: %+v", err)
+		}
+	default:
+		log.Fatalf("Transport %s is not supported. pick one of [rabbitmq,kafka]", eventmanager.Flags.Transport)
 	}
 	defer queue.Close()
 
 	fnClient := client.NewFunctionsClient(eventmanager.Flags.FunctionManager, client.AuthWithToken("cookie"))
 
-	subManager, err := subscriptions.NewManager(queue, fnClient)
+	recorder := history.NewStoreRecorder(store, eventmanager.Flags.OrgID)
+
+	subManager, err := subscriptions.NewManager(queue, fnClient, store, recorder)
 	if err != nil {
 		log.Fatalf("Error creating SubscriptionManager: %v", err)
 	}
+	// deferred first, to wait for the retried deliveries once the controller is shut down
+	defer subManager.Shutdown()
 
 	k8sBackend, err := drivers.NewK8sBackend(
 		drivers.ConfigOpts{
@@ -128,6 +154,7 @@ func main() {
 			SidecarImage:    eventmanager.Flags.EventSidecarImage,
 			TransportType:   eventmanager.Flags.Transport,
 			RabbitMQURL:     eventmanager.Flags.RabbitMQURL,
+			KafkaBrokers:    eventmanager.Flags.KafkaBrokers,
 			TracerURL:       eventmanager.Flags.TracerURL,
 			K8sConfig:       eventmanager.Flags.K8sConfig,
 			DriverNamespace: eventmanager.Flags.K8sNamespace,
@@ -139,11 +166,15 @@ func main() {
 		log.Fatalf("Error creating k8sBackend: %v", err)
 	}
 	// event controller
+	elector, err := controller.NewLeaderElector("event-manager", store, eventmanager.Flags.OrgID)
+	if err != nil {
+		log.Fatalf("Error creating the leader election: %v", err)
+	}
 	eventController := eventmanager.NewEventController(
 		subManager,
 		k8sBackend,
 		store,
-		eventmanager.EventControllerConfig{OrganizationID: eventmanager.Flags.OrgID},
+		eventmanager.EventControllerConfig{OrganizationID: eventmanager.Flags.OrgID, Elector: elector},
 	)
 
 	defer eventController.Shutdown()
@@ -151,9 +182,11 @@ func main() {
 
 	// handler
 	handlers := &eventmanager.Handlers{
-		Store:   store,
-		EQ:      queue,
-		Watcher: eventController.Watcher(),
+		Store:      store,
+		EQ:         queue,
+		Watcher:    eventController.Watcher(),
+		SubManager: subManager,
+		Recorder:   recorder,
 	}
 
 	handlers.ConfigureHandlers(api)
@@ -7,8 +7,10 @@ package main
 
 import (
 	"os"
+	"strings"
 	"time"
 
+	docker "github.com/docker/docker/client"
 	"github.com/go-openapi/loads"
 	"github.com/go-openapi/loads/fmts"
 	"github.com/go-openapi/swag"
@@ -18,6 +20,7 @@ import (
 	"github.com/vmware/dispatch/pkg/utils"
 
 	"github.com/vmware/dispatch/pkg/config"
+	"github.com/vmware/dispatch/pkg/controller"
 	"github.com/vmware/dispatch/pkg/entity-store"
 	"github.com/vmware/dispatch/pkg/function-manager"
 	"github.com/vmware/dispatch/pkg/function-manager/gen/restapi"
@@ -101,6 +104,10 @@ func configureFlags() []swag.CommandLineOptionsGroup {
 		ShortDescription: "Function manager Flags",
 		LongDescription:  "",
 		Options:          &functionmanager.FunctionManagerFlags,
+	}, {
+		ShortDescription: "Leader election options",
+		LongDescription:  "",
+		Options:          &controller.LeaderElectionFlags,
 	}, {
 		ShortDescription: "Debug options",
 		LongDescription:  "",
@@ -171,9 +178,14 @@ func main() {
 	faas := drivers[config.Global.Function.Faas](registryAuth)
 	defer utils.Close(faas)
 
+	elector, err := controller.NewLeaderElector("function-manager", es, functionmanager.FunctionManagerFlags.OrgID)
+	if err != nil {
+		log.Fatalf("Error creating the leader election: %v", err)
+	}
 	c := &functionmanager.ControllerConfig{
 		ResyncPeriod:   time.Duration(config.Global.Function.ResyncPeriod) * time.Second,
 		OrganizationID: config.Global.OrganizationID,
+		Elector:        elector,
 	}
 	r := runner.New(&runner.Config{
 		Faas:           faas,
@@ -185,11 +197,56 @@ func main() {
 	if config.Global.Function.FileImageManager != "" {
 		imc = functionmanager.FileImageManagerClient()
 	}
-	controller := functionmanager.NewController(c, es, faas, r, imc)
+	queue := functionmanager.NewRunQueue(&functionmanager.RunQueueConfig{
+		MaxRuns:            functionmanager.FunctionManagerFlags.MaxRuns,
+		MaxRunsPerFunction: functionmanager.FunctionManagerFlags.MaxRunsPerFunction,
+		QueueSize:          functionmanager.FunctionManagerFlags.RunQueueSize,
+	}, es)
+	dc, err := docker.NewEnvClient()
+	if err != nil {
+		log.Fatalf("Error creating docker client: %+v", err)
+	}
+	imageGC := functionmanager.NewImageGC(&functionmanager.ImageGCConfig{
+		Keep:   functionmanager.FunctionManagerFlags.ImageGCKeep,
+		MaxAge: time.Duration(functionmanager.FunctionManagerFlags.ImageGCMaxAge) * time.Hour,
+		Period: time.Duration(functionmanager.FunctionManagerFlags.ImageGCPeriod) * time.Minute,
+	}, es, functions.NewDockerImageCollector(registryAuth, config.Global.Registry.Insecure, dc))
+	defer imageGC.Shutdown()
+
+	var runArchive functionmanager.RunArchive
+	if functionmanager.FunctionManagerFlags.RunArchiveFile != "" {
+		runArchive = functionmanager.NewFileRunArchive(functionmanager.FunctionManagerFlags.RunArchiveFile)
+	}
+	runRetention := functionmanager.RunRetention{
+		MaxAge: time.Duration(functionmanager.FunctionManagerFlags.RunRetentionMaxAge) * time.Hour,
+		Keep:   functionmanager.FunctionManagerFlags.RunRetentionKeep,
+	}
+	for _, status := range functionmanager.FunctionManagerFlags.RunRetentionStatus {
+		runRetention.Statuses = append(runRetention.Statuses, entitystore.Status(strings.ToUpper(status)))
+	}
+	if err := runRetention.Validate(); err != nil {
+		log.Fatalf("Error configuring function run retention: %+v", err)
+	}
+	runPruner := functionmanager.NewRunPruner(&functionmanager.RunPrunerConfig{
+		Retention: runRetention,
+		Period:    time.Duration(functionmanager.FunctionManagerFlags.RunRetentionPeriod) * time.Minute,
+	}, es, runArchive)
+	defer runPruner.Shutdown()
+
+	controller := functionmanager.NewController(c, es, faas, r, imc, queue, imageGC, runPruner)
 	defer controller.Shutdown()
 	controller.Start()
 
-	handlers := functionmanager.NewHandlers(controller.Watcher(), es)
+	// with a leader election, the leader resumes the queued runs and collects the images and runs
+	if elector == nil {
+		imageGC.Start()
+		runPruner.Start()
+		if err := queue.Resume(functionmanager.FunctionManagerFlags.OrgID); err != nil {
+			log.Fatalln(err)
+		}
+	}
+
+	handlers := functionmanager.NewHandlers(controller.Watcher(), es, queue, imageGC, runPruner)
 	handlers.ConfigureHandlers(api)
 
 	healthChecker := func() error {
@@ -7,6 +7,7 @@ package main
 
 import (
 	"os"
+	"time"
 
 	"github.com/go-openapi/loads"
 	"github.com/go-openapi/swag"
@@ -14,6 +15,7 @@ import (
 	"github.com/justinas/alice"
 	log "github.com/sirupsen/logrus"
 
+	"github.com/vmware/dispatch/pkg/controller"
 	"github.com/vmware/dispatch/pkg/entity-store"
 	"github.com/vmware/dispatch/pkg/identity-manager"
 	iam "github.com/vmware/dispatch/pkg/identity-manager"
@@ -35,6 +37,11 @@ func configureFlags() []swag.CommandLineOptionsGroup {
 			LongDescription:  "",
 			Options:          &iam.IdentityManagerFlags,
 		},
+		swag.CommandLineOptionsGroup{
+			ShortDescription: "Leader election options",
+			LongDescription:  "",
+			Options:          &controller.LeaderElectionFlags,
+		},
 		swag.CommandLineOptionsGroup{
 			ShortDescription: "Debug options",
 			LongDescription:  "",
@@ -100,8 +107,18 @@ func main() {
 	// Setup the policy enforcer
 	enforcer := identitymanager.SetupEnforcer(es)
 
+	elector, err := controller.NewLeaderElector("identity-manager", es, identitymanager.IdentityManagerFlags.OrgID)
+	if err != nil {
+		log.Fatalf("Error creating the leader election: %v", err)
+	}
+	if elector != nil {
+		// the policies are reloaded by the controller of the leader only
+		enforcer.StartAutoLoadPolicy(time.Duration(identitymanager.IdentityManagerFlags.ResyncPeriod) * time.Second)
+		defer enforcer.StopAutoLoadPolicy()
+	}
+
 	// Create the identity controller
-	controller := identitymanager.NewIdentityController(es, enforcer)
+	controller := identitymanager.NewIdentityController(es, enforcer, elector)
 	defer controller.Shutdown()
 	controller.Start()
 
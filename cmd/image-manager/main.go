@@ -17,6 +17,7 @@ import (
 	log "github.com/sirupsen/logrus"
 
 	"github.com/vmware/dispatch/pkg/config"
+	"github.com/vmware/dispatch/pkg/controller"
 	"github.com/vmware/dispatch/pkg/entity-store"
 	"github.com/vmware/dispatch/pkg/image-manager"
 	"github.com/vmware/dispatch/pkg/image-manager/gen/restapi"
@@ -44,6 +45,11 @@ func configureFlags() []swag.CommandLineOptionsGroup {
 			LongDescription:  "",
 			Options:          &imagemanager.ImageManagerFlags,
 		},
+		swag.CommandLineOptionsGroup{
+			ShortDescription: "Leader election options",
+			LongDescription:  "",
+			Options:          &controller.LeaderElectionFlags,
+		},
 		swag.CommandLineOptionsGroup{
 			ShortDescription: "Debug options",
 			LongDescription:  "",
@@ -107,9 +113,14 @@ func main() {
 		log.Fatalln(err)
 	}
 
+	elector, err := controller.NewLeaderElector("image-manager", es, imagemanager.ImageManagerFlags.OrgID)
+	if err != nil {
+		log.Fatalf("Error creating the leader election: %v", err)
+	}
 	c := &imagemanager.ControllerConfig{
 		ResyncPeriod:   time.Duration(imagemanager.ImageManagerFlags.ResyncPeriod) * time.Second,
 		OrganizationID: imagemanager.ImageManagerFlags.OrgID,
+		Elector:        elector,
 	}
 
 	registryAuth := config.Global.Registry.RegistryAuth
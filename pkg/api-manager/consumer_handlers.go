@@ -0,0 +1,309 @@
+///////////////////////////////////////////////////////////////////////
+// Copyright (c) 2018 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+///////////////////////////////////////////////////////////////////////
+
+package apimanager
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/go-openapi/runtime/middleware"
+	"github.com/go-openapi/strfmt"
+	"github.com/go-openapi/swag"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/vmware/dispatch/pkg/api-manager/gateway"
+	"github.com/vmware/dispatch/pkg/api-manager/gen/models"
+	"github.com/vmware/dispatch/pkg/api-manager/gen/restapi/operations/consumer"
+	entitystore "github.com/vmware/dispatch/pkg/entity-store"
+	"github.com/vmware/dispatch/pkg/errors"
+	"github.com/vmware/dispatch/pkg/trace"
+	"github.com/vmware/dispatch/pkg/utils"
+)
+
+func consumerModelOntoEntity(m *models.Consumer) *Consumer {
+	defer trace.Tracef("name '%s'", *m.Name)()
+	tags := make(map[string]string)
+	for _, t := range m.Tags {
+		tags[t.Key] = t.Value
+	}
+	e := Consumer{
+		BaseEntity: entitystore.BaseEntity{
+			OrganizationID: APIManagerFlags.OrgID,
+			Name:           *m.Name,
+			Tags:           tags,
+		},
+		Consumer: gateway.Consumer{
+			Name:      *m.Name,
+			RateLimit: rateLimitModelToGateway(m.RateLimit),
+		},
+	}
+	return &e
+}
+
+func consumerEntityToModel(e *Consumer) *models.Consumer {
+	defer trace.Tracef("name '%s'", e.Name)()
+	var tags []*models.Tag
+	for k, v := range e.Tags {
+		tags = append(tags, &models.Tag{Key: k, Value: v})
+	}
+	m := models.Consumer{
+		ID:          strfmt.UUID(e.ID),
+		Name:        swag.String(e.Name),
+		Kind:        utils.ConsumerKind,
+		Status:      models.Status(e.Status),
+		CreatedTime: e.CreatedTime.Unix(),
+		RateLimit:   rateLimitGatewayToModel(e.Consumer.RateLimit),
+		Tags:        tags,
+	}
+	return &m
+}
+
+func credentialModelToGateway(m *models.Credential) *gateway.Credential {
+	return &gateway.Credential{
+		Type:        *m.Type,
+		Username:    m.Username,
+		Password:    m.Password,
+		Key:         m.Key,
+		Secret:      m.Secret,
+		RedirectURI: m.RedirectURI,
+	}
+}
+
+func credentialGatewayToModel(c *gateway.Credential) *models.Credential {
+	return &models.Credential{
+		ID:          c.ID,
+		Type:        swag.String(c.Type),
+		Username:    c.Username,
+		Password:    c.Password,
+		Key:         c.Key,
+		Secret:      c.Secret,
+		RedirectURI: c.RedirectURI,
+		CreatedTime: int64(c.CreatedAt),
+	}
+}
+
+func (h *Handlers) addConsumer(params consumer.AddConsumerParams, principal interface{}) middleware.Responder {
+	defer trace.Tracef("name '%s'", *params.Body.Name)()
+	e := consumerModelOntoEntity(params.Body)
+
+	e.Status = entitystore.StatusCREATING
+	if _, err := h.Store.Add(e); err != nil {
+		if entitystore.IsUniqueViolation(err) {
+			return consumer.NewAddConsumerConflict().WithPayload(&models.Error{
+				Code:    http.StatusConflict,
+				Message: swag.String("error creating consumer: non-unique name"),
+			})
+		}
+		log.Errorf("store error when adding a new consumer %s: %+v", e.Name, err)
+		return consumer.NewAddConsumerInternalServerError().WithPayload(&models.Error{
+			Code:    http.StatusInternalServerError,
+			Message: swag.String("internal server error when storing a new consumer"),
+		})
+	}
+	if h.watcher != nil {
+		h.watcher.OnAction(e)
+	} else {
+		log.Debugf("note: the watcher is nil")
+	}
+	return consumer.NewAddConsumerOK().WithPayload(consumerEntityToModel(e))
+}
+
+func (h *Handlers) deleteConsumer(params consumer.DeleteConsumerParams, principal interface{}) middleware.Responder {
+	defer trace.Tracef("name '%s'", params.Consumer)()
+
+	opts := entitystore.Options{
+		Filter: entitystore.FilterExists(),
+	}
+	var e Consumer
+	if err := h.Store.Get(APIManagerFlags.OrgID, params.Consumer, opts, &e); err != nil {
+		log.Errorf("store error when getting consumer: %+v", err)
+		return consumer.NewDeleteConsumerNotFound().WithPayload(
+			&models.Error{
+				Code:    http.StatusNotFound,
+				Message: swag.String("consumer not found"),
+			})
+	}
+	e.Status = entitystore.StatusDELETING
+	if _, err := h.Store.Update(e.Revision, &e); err != nil {
+		log.Errorf("store error when deleting the consumer %s: %+v", e.Name, err)
+		return consumer.NewDeleteConsumerInternalServerError().WithPayload(&models.Error{
+			Code:    http.StatusInternalServerError,
+			Message: swag.String("internal server error when deleting a consumer"),
+		})
+	}
+	if h.watcher != nil {
+		h.watcher.OnAction(&e)
+	} else {
+		log.Debugf("note: the watcher is nil")
+	}
+	return consumer.NewDeleteConsumerOK().WithPayload(consumerEntityToModel(&e))
+}
+
+func (h *Handlers) getConsumer(params consumer.GetConsumerParams, principal interface{}) middleware.Responder {
+	defer trace.Tracef("name '%s'", params.Consumer)()
+
+	opts := entitystore.Options{
+		Filter: entitystore.FilterExists(),
+	}
+	var e Consumer
+	if err := h.Store.Get(APIManagerFlags.OrgID, params.Consumer, opts, &e); err != nil {
+		log.Errorf("store error when getting consumer: %+v", err)
+		return consumer.NewGetConsumerNotFound().WithPayload(
+			&models.Error{
+				Code:    http.StatusNotFound,
+				Message: swag.String("consumer not found"),
+			})
+	}
+	return consumer.NewGetConsumerOK().WithPayload(consumerEntityToModel(&e))
+}
+
+func (h *Handlers) getConsumers(params consumer.GetConsumersParams, principal interface{}) middleware.Responder {
+	defer trace.Trace("")()
+	var consumers []*Consumer
+
+	var err error
+	opts := entitystore.Options{
+		Filter: entitystore.FilterExists(),
+	}
+	opts.Filter, err = utils.ParseTags(opts.Filter, params.Tags)
+	if err == nil {
+		err = utils.ParsePage(&opts, params.Limit, params.Continue, params.SortBy)
+	}
+	if err != nil {
+		log.Error(err)
+		return consumer.NewGetConsumersBadRequest().WithPayload(
+			&models.Error{
+				Code:    http.StatusBadRequest,
+				Message: swag.String(err.Error()),
+			})
+	}
+
+	next, err := utils.ListPage(h.Store, APIManagerFlags.OrgID, opts, &consumers)
+	if err != nil {
+		log.Errorf("store error when listing consumers: %+v", err)
+		return consumer.NewGetConsumersDefault(http.StatusInternalServerError).WithPayload(
+			&models.Error{
+				Code:    http.StatusInternalServerError,
+				Message: swag.String("internal server error when getting consumers"),
+			})
+	}
+	var consumerModels []*models.Consumer
+	for _, c := range consumers {
+		consumerModels = append(consumerModels, consumerEntityToModel(c))
+	}
+	return consumer.NewGetConsumersOK().WithXContinue(next).WithPayload(consumerModels)
+}
+
+// getReadyConsumer gets a consumer, the credentials of which can be managed, or an error message and code
+func (h *Handlers) getReadyConsumer(name string) (*Consumer, int, string) {
+	opts := entitystore.Options{
+		Filter: entitystore.FilterExists(),
+	}
+	var e Consumer
+	if err := h.Store.Get(APIManagerFlags.OrgID, name, opts, &e); err != nil {
+		log.Errorf("store error when getting consumer: %+v", err)
+		return nil, http.StatusNotFound, "consumer not found"
+	}
+	if e.Status != entitystore.StatusREADY {
+		return nil, http.StatusConflict, fmt.Sprintf("consumer is %s, not READY", e.Status)
+	}
+	return &e, 0, ""
+}
+
+func (h *Handlers) addCredential(params consumer.AddCredentialParams, principal interface{}) middleware.Responder {
+	defer trace.Tracef("consumer '%s'", params.Consumer)()
+
+	c := credentialModelToGateway(params.Body)
+	if c.Type == gateway.AuthOAuth2 && c.RedirectURI == "" {
+		return consumer.NewAddCredentialBadRequest().WithPayload(&models.Error{
+			Code:    http.StatusBadRequest,
+			Message: swag.String("oauth2 credentials require a redirect uri"),
+		})
+	}
+
+	e, code, msg := h.getReadyConsumer(params.Consumer)
+	switch code {
+	case http.StatusNotFound:
+		return consumer.NewAddCredentialNotFound().WithPayload(&models.Error{Code: int64(code), Message: swag.String(msg)})
+	case http.StatusConflict:
+		return consumer.NewAddCredentialConflict().WithPayload(&models.Error{Code: int64(code), Message: swag.String(msg)})
+	}
+
+	credential, err := h.gw.AddCredential(e.Name, c)
+	if err != nil {
+		log.Errorf("gateway error when adding a credential to consumer %s: %+v", e.Name, err)
+		return consumer.NewAddCredentialInternalServerError().WithPayload(&models.Error{
+			Code:    http.StatusInternalServerError,
+			Message: swag.String("internal server error when issuing a credential"),
+		})
+	}
+	return consumer.NewAddCredentialOK().WithPayload(credentialGatewayToModel(credential))
+}
+
+func (h *Handlers) getCredentials(params consumer.GetCredentialsParams, principal interface{}) middleware.Responder {
+	defer trace.Tracef("consumer '%s'", params.Consumer)()
+
+	e, code, msg := h.getReadyConsumer(params.Consumer)
+	if e == nil {
+		// a consumer, which is not READY, has no credentials yet
+		if code == http.StatusConflict {
+			return consumer.NewGetCredentialsOK().WithPayload([]*models.Credential{})
+		}
+		return consumer.NewGetCredentialsNotFound().WithPayload(&models.Error{Code: int64(code), Message: swag.String(msg)})
+	}
+
+	credentials, err := h.gw.GetCredentials(e.Name)
+	if err != nil {
+		log.Errorf("gateway error when getting credentials of consumer %s: %+v", e.Name, err)
+		return consumer.NewGetCredentialsInternalServerError().WithPayload(&models.Error{
+			Code:    http.StatusInternalServerError,
+			Message: swag.String("internal server error when getting credentials"),
+		})
+	}
+	credentialModels := []*models.Credential{}
+	for _, c := range credentials {
+		m := credentialGatewayToModel(c)
+		// secrets are only returned when issued
+		m.Password = ""
+		m.Secret = ""
+		if c.Type != gateway.AuthOAuth2 && c.Type != gateway.AuthJWT {
+			m.Key = ""
+		}
+		credentialModels = append(credentialModels, m)
+	}
+	return consumer.NewGetCredentialsOK().WithPayload(credentialModels)
+}
+
+func (h *Handlers) deleteCredential(params consumer.DeleteCredentialParams, principal interface{}) middleware.Responder {
+	defer trace.Tracef("consumer '%s', credential '%s'", params.Consumer, params.Credential)()
+
+	opts := entitystore.Options{
+		Filter: entitystore.FilterExists(),
+	}
+	var e Consumer
+	if err := h.Store.Get(APIManagerFlags.OrgID, params.Consumer, opts, &e); err != nil {
+		log.Errorf("store error when getting consumer: %+v", err)
+		return consumer.NewDeleteCredentialNotFound().WithPayload(&models.Error{
+			Code:    http.StatusNotFound,
+			Message: swag.String("consumer not found"),
+		})
+	}
+
+	if err := h.gw.DeleteCredential(e.Name, params.Credential); err != nil {
+		if _, ok := err.(*errors.ObjectNotFoundError); ok {
+			return consumer.NewDeleteCredentialNotFound().WithPayload(&models.Error{
+				Code:    http.StatusNotFound,
+				Message: swag.String("credential not found"),
+			})
+		}
+		log.Errorf("gateway error when deleting credential %s of consumer %s: %+v", params.Credential, e.Name, err)
+		return consumer.NewDeleteCredentialInternalServerError().WithPayload(&models.Error{
+			Code:    http.StatusInternalServerError,
+			Message: swag.String("internal server error when revoking a credential"),
+		})
+	}
+	return consumer.NewDeleteCredentialOK()
+}
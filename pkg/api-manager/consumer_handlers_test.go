@@ -0,0 +1,170 @@
+///////////////////////////////////////////////////////////////////////
+// Copyright (c) 2018 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+///////////////////////////////////////////////////////////////////////
+
+package apimanager
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-openapi/swag"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/vmware/dispatch/pkg/api-manager/gateway"
+	"github.com/vmware/dispatch/pkg/api-manager/gateway/mocks"
+	"github.com/vmware/dispatch/pkg/api-manager/gen/models"
+	"github.com/vmware/dispatch/pkg/api-manager/gen/restapi/operations"
+	"github.com/vmware/dispatch/pkg/api-manager/gen/restapi/operations/consumer"
+	entitystore "github.com/vmware/dispatch/pkg/entity-store"
+	"github.com/vmware/dispatch/pkg/errors"
+	helpers "github.com/vmware/dispatch/pkg/testing/api"
+)
+
+func addConsumer(t *testing.T, a *operations.APIManagerAPI, name string) {
+
+	params := consumer.AddConsumerParams{
+		HTTPRequest: httptest.NewRequest("POST", "/v1/api/consumers", nil),
+		Body:        &models.Consumer{Name: swag.String(name)},
+	}
+	responder := a.ConsumerAddConsumerHandler.Handle(params, "cookie")
+	var respBody models.Consumer
+	helpers.HandlerRequest(t, responder, &respBody, 200)
+
+	assert.Equal(t, name, *respBody.Name)
+	assert.Equal(t, models.StatusCREATING, respBody.Status)
+}
+
+func setConsumerReady(t *testing.T, es entitystore.EntityStore, name string) {
+
+	var e Consumer
+	assert.Nil(t, es.Get(APIManagerFlags.OrgID, name, entitystore.Options{}, &e))
+	e.Status = entitystore.StatusREADY
+	_, err := es.Update(e.Revision, &e)
+	assert.Nil(t, err)
+}
+
+func TestConsumerAddGetConsumers(t *testing.T) {
+
+	a := operations.NewAPIManagerAPI(nil)
+	es := helpers.MakeEntityStore(t)
+	h := NewHandlers(nil, es, &mocks.Gateway{})
+	helpers.MakeAPI(t, h.ConfigureHandlers, a)
+
+	addConsumer(t, a, "alice")
+	addConsumer(t, a, "bob")
+
+	params := consumer.AddConsumerParams{
+		HTTPRequest: httptest.NewRequest("POST", "/v1/api/consumers", nil),
+		Body:        &models.Consumer{Name: swag.String("bob")},
+	}
+	responder := a.ConsumerAddConsumerHandler.Handle(params, "cookie")
+	var errBody models.Error
+	helpers.HandlerRequest(t, responder, &errBody, 409)
+
+	responder = a.ConsumerGetConsumersHandler.Handle(consumer.GetConsumersParams{
+		HTTPRequest: httptest.NewRequest("GET", "/v1/api/consumers", nil),
+	}, "cookie")
+	var respBody []*models.Consumer
+	helpers.HandlerRequest(t, responder, &respBody, 200)
+	assert.Len(t, respBody, 2)
+
+	responder = a.ConsumerGetConsumerHandler.Handle(consumer.GetConsumerParams{
+		HTTPRequest: httptest.NewRequest("GET", "/v1/api/consumers/carol", nil),
+		Consumer:    "carol",
+	}, "cookie")
+	helpers.HandlerRequest(t, responder, &errBody, 404)
+}
+
+func TestConsumerAddCredential(t *testing.T) {
+
+	a := operations.NewAPIManagerAPI(nil)
+	es := helpers.MakeEntityStore(t)
+	gw := &mocks.Gateway{}
+	h := NewHandlers(nil, es, gw)
+	helpers.MakeAPI(t, h.ConfigureHandlers, a)
+
+	addConsumer(t, a, "alice")
+
+	params := consumer.AddCredentialParams{
+		HTTPRequest: httptest.NewRequest("POST", "/v1/api/consumers/alice/credentials", nil),
+		Consumer:    "alice",
+		Body:        &models.Credential{Type: swag.String(gateway.AuthKey)},
+	}
+
+	// the consumer is not added by the gateway yet
+	responder := a.ConsumerAddCredentialHandler.Handle(params, "cookie")
+	var errBody models.Error
+	helpers.HandlerRequest(t, responder, &errBody, 409)
+
+	setConsumerReady(t, es, "alice")
+	gw.On("AddCredential", "alice", &gateway.Credential{Type: gateway.AuthKey}).Return(
+		&gateway.Credential{ID: "123", Type: gateway.AuthKey, Key: "secretkey"}, nil)
+
+	responder = a.ConsumerAddCredentialHandler.Handle(params, "cookie")
+	var respBody models.Credential
+	helpers.HandlerRequest(t, responder, &respBody, 200)
+	assert.Equal(t, "123", respBody.ID)
+	assert.Equal(t, "secretkey", respBody.Key)
+
+	params.Body = &models.Credential{Type: swag.String(gateway.AuthOAuth2)}
+	responder = a.ConsumerAddCredentialHandler.Handle(params, "cookie")
+	helpers.HandlerRequest(t, responder, &errBody, 400)
+}
+
+func TestConsumerGetCredentials(t *testing.T) {
+
+	a := operations.NewAPIManagerAPI(nil)
+	es := helpers.MakeEntityStore(t)
+	gw := &mocks.Gateway{}
+	h := NewHandlers(nil, es, gw)
+	helpers.MakeAPI(t, h.ConfigureHandlers, a)
+
+	addConsumer(t, a, "alice")
+	setConsumerReady(t, es, "alice")
+	gw.On("GetCredentials", "alice").Return([]*gateway.Credential{
+		{ID: "1", Type: gateway.AuthKey, Key: "secretkey"},
+		{ID: "2", Type: gateway.AuthJWT, Key: "issuer", Secret: "jwtsecret"},
+	}, nil)
+
+	responder := a.ConsumerGetCredentialsHandler.Handle(consumer.GetCredentialsParams{
+		HTTPRequest: httptest.NewRequest("GET", "/v1/api/consumers/alice/credentials", nil),
+		Consumer:    "alice",
+	}, "cookie")
+	var respBody []*models.Credential
+	helpers.HandlerRequest(t, responder, &respBody, 200)
+
+	// secrets are not returned
+	assert.Len(t, respBody, 2)
+	assert.Equal(t, "", respBody[0].Key)
+	assert.Equal(t, "issuer", respBody[1].Key)
+	assert.Equal(t, "", respBody[1].Secret)
+}
+
+func TestConsumerDeleteCredential(t *testing.T) {
+
+	a := operations.NewAPIManagerAPI(nil)
+	es := helpers.MakeEntityStore(t)
+	gw := &mocks.Gateway{}
+	h := NewHandlers(nil, es, gw)
+	helpers.MakeAPI(t, h.ConfigureHandlers, a)
+
+	addConsumer(t, a, "alice")
+	gw.On("DeleteCredential", "alice", "1").Return(nil)
+	gw.On("DeleteCredential", "alice", mock.Anything).Return(&errors.ObjectNotFoundError{})
+
+	params := consumer.DeleteCredentialParams{
+		HTTPRequest: httptest.NewRequest("DELETE", "/v1/api/consumers/alice/credentials/1", nil),
+		Consumer:    "alice",
+		Credential:  "1",
+	}
+	responder := a.ConsumerDeleteCredentialHandler.Handle(params, "cookie")
+	helpers.HandlerRequest(t, responder, nil, 200)
+
+	params.Credential = "2"
+	responder = a.ConsumerDeleteCredentialHandler.Handle(params, "cookie")
+	var errBody models.Error
+	helpers.HandlerRequest(t, responder, &errBody, 404)
+}
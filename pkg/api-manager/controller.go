@@ -7,6 +7,7 @@ package apimanager
 
 import (
 	"reflect"
+	"strings"
 	"time"
 
 	ewrapper "github.com/pkg/errors"
@@ -17,6 +18,7 @@ import (
 	"github.com/vmware/dispatch/pkg/errors"
 
 	"github.com/vmware/dispatch/pkg/entity-store"
+	secretclient "github.com/vmware/dispatch/pkg/secret-store/gen/client"
 	"github.com/vmware/dispatch/pkg/trace"
 )
 
@@ -24,11 +26,13 @@ import (
 type ControllerConfig struct {
 	ResyncPeriod   time.Duration
 	OrganizationID string
+	Elector        controller.LeaderElector
 }
 
 type apiEntityHandler struct {
-	store entitystore.EntityStore
-	gw    gateway.Gateway
+	store   entitystore.EntityStore
+	gw      gateway.Gateway
+	secrets *secretclient.SecretStore
 }
 
 func (h *apiEntityHandler) Type() reflect.Type {
@@ -50,10 +54,60 @@ func (h *apiEntityHandler) Add(obj entitystore.Entity) (err error) {
 		return ewrapper.Wrap(err, "gateway error when adding api")
 	}
 	log.Infof("api %s added by gateway", api.Name)
-	api.Status = entitystore.StatusREADY
 	api.API.ID = gwAPI.ID
 	api.API.CreatedAt = gwAPI.CreatedAt
 
+	if err = h.updateCertificate(api); err != nil {
+		return err
+	}
+	api.Status = entitystore.StatusREADY
+	api.Reason = certificateReason(api.TLS, time.Now())
+
+	return nil
+}
+
+// updateCertificate registers the certificate of the API TLS secret with the gateway, for the API hosts
+func (h *apiEntityHandler) updateCertificate(api *API) error {
+	if api.API.TLS == "" {
+		if api.TLS == nil {
+			return nil
+		}
+		if err := h.gw.DeleteCertificate(&gateway.Certificate{ID: api.TLS.ID}); err != nil {
+			if _, ok := err.(*errors.ObjectNotFoundError); !ok {
+				return ewrapper.Wrap(err, "gateway error when deleting certificate")
+			}
+		}
+		log.Infof("certificate of api %s deleted by gateway", api.Name)
+		api.TLS = nil
+		return nil
+	}
+
+	if len(api.API.Hosts) == 0 {
+		return ewrapper.New("tls certificates are served for the api domains, at least one domain is required")
+	}
+	secret, err := getTLSSecret(h.secrets, api.API.TLS)
+	if err != nil {
+		return err
+	}
+	cert := &gateway.Certificate{
+		Cert: secret.Cert,
+		Key:  secret.Key,
+		SNIs: api.API.Hosts,
+	}
+	if api.TLS != nil {
+		cert.ID = api.TLS.ID
+	}
+	gwCert, err := h.gw.UpdateCertificate(cert)
+	if err != nil {
+		return ewrapper.Wrap(err, "gateway error when updating certificate")
+	}
+	log.Infof("certificate of api %s updated by gateway", api.Name)
+	api.TLS = &TLSCertificate{
+		ID:          gwCert.ID,
+		Fingerprint: secret.Fingerprint,
+		Hosts:       api.API.Hosts,
+		Expiration:  secret.Expiration,
+	}
 	return nil
 }
 
@@ -72,6 +126,13 @@ func (h *apiEntityHandler) Delete(obj entitystore.Entity) error {
 	if !ok {
 		return ewrapper.New("type assertion error")
 	}
+	if api.TLS != nil {
+		if err := h.gw.DeleteCertificate(&gateway.Certificate{ID: api.TLS.ID}); err != nil {
+			if _, ok := err.(*errors.ObjectNotFoundError); !ok {
+				return ewrapper.Wrap(err, "gateway error when deleting certificate")
+			}
+		}
+	}
 	if err := h.gw.DeleteAPI(&api.API); err != nil {
 		if _, ok := err.(*errors.ObjectNotFoundError); !ok {
 			return ewrapper.Wrap(err, "gateway error when deleting api")
@@ -94,11 +155,53 @@ func (h *apiEntityHandler) Delete(obj entitystore.Entity) error {
 	return nil
 }
 
-// Sync polls the actual state and returns the list of entites which need to be resolved
+// Sync polls the actual state and returns the list of entites which need to be resolved. APIs, the TLS secrets of
+// which changed, are updated.
 func (h *apiEntityHandler) Sync(organizationID string, resyncPeriod time.Duration) ([]entitystore.Entity, error) {
 	defer trace.Trace("")()
 
-	return controller.DefaultSync(h.store, h.Type(), organizationID, resyncPeriod, nil)
+	entities, err := controller.DefaultSync(h.store, h.Type(), organizationID, resyncPeriod, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var apis []*API
+	opts := entitystore.Options{
+		Filter: entitystore.FilterEverything().Add(
+			entitystore.FilterStat{
+				Scope:   entitystore.FilterScopeField,
+				Subject: "Status",
+				Verb:    entitystore.FilterVerbEqual,
+				Object:  entitystore.StatusREADY,
+			}),
+	}
+	if err := h.store.List(organizationID, opts, &apis); err != nil {
+		return nil, ewrapper.Wrap(err, "store error when listing apis")
+	}
+	for _, api := range apis {
+		if api.API.TLS == "" || api.TLS == nil || api.Delete {
+			continue
+		}
+		secret, err := getTLSSecret(h.secrets, api.API.TLS)
+		if err != nil {
+			// the secret may be updated on purpose, the api is kept as is
+			log.Warnf("error checking the tls secret of api %s: %v", api.Name, err)
+			continue
+		}
+		if secret.Fingerprint != api.TLS.Fingerprint {
+			log.Infof("tls secret %s of api %s changed", api.API.TLS, api.Name)
+			entities = append(entities, api)
+			continue
+		}
+		reason := certificateReason(api.TLS, time.Now())
+		if strings.Join(reason, "\n") != strings.Join(api.Reason, "\n") {
+			api.Reason = reason
+			if _, err := h.store.Update(api.Revision, api); err != nil {
+				log.Errorf("store error when updating api %s: %+v", api.Name, err)
+			}
+		}
+	}
+	return entities, nil
 }
 
 // Error handles errors while modifying API endpoints
@@ -120,15 +223,119 @@ func (h *apiEntityHandler) Error(obj entitystore.Entity) error {
 	return h.Update(api)
 }
 
+type consumerEntityHandler struct {
+	store entitystore.EntityStore
+	gw    gateway.Gateway
+}
+
+func (h *consumerEntityHandler) Type() reflect.Type {
+	defer trace.Trace("")()
+
+	return reflect.TypeOf(&Consumer{})
+}
+
+// Add is the handler for creating API consumers
+func (h *consumerEntityHandler) Add(obj entitystore.Entity) (err error) {
+	defer trace.Tracef("name %s", obj.GetName())()
+
+	consumer := obj.(*Consumer)
+
+	defer func() { h.store.UpdateWithError(consumer, err) }()
+
+	gwConsumer, err := h.gw.AddConsumer(&consumer.Consumer)
+	if err != nil {
+		return ewrapper.Wrap(err, "gateway error when adding consumer")
+	}
+	log.Infof("consumer %s added by gateway", consumer.Name)
+	consumer.Status = entitystore.StatusREADY
+	consumer.Consumer.ID = gwConsumer.ID
+	consumer.Consumer.CreatedAt = gwConsumer.CreatedAt
+
+	return nil
+}
+
+// Update is the handler for updating API consumers
+func (h *consumerEntityHandler) Update(obj entitystore.Entity) error {
+	defer trace.Trace("")()
+
+	return h.Add(obj)
+}
+
+// Delete is the handler for deleting API consumers
+func (h *consumerEntityHandler) Delete(obj entitystore.Entity) error {
+	defer trace.Tracef("name '%s'", obj.GetName())()
+
+	consumer, ok := obj.(*Consumer)
+	if !ok {
+		return ewrapper.New("type assertion error")
+	}
+	if err := h.gw.DeleteConsumer(&consumer.Consumer); err != nil {
+		if _, ok := err.(*errors.ObjectNotFoundError); !ok {
+			return ewrapper.Wrap(err, "gateway error when deleting consumer")
+		}
+		// object not found, continue to delete from entity store
+	}
+
+	if err := h.store.Delete(consumer.OrganizationID, consumer.Name, consumer); err != nil {
+		return ewrapper.Wrap(err, "store error when deleting consumer")
+	}
+	log.Infof("consumer %s deleted by gateway and store", consumer.Name)
+	return nil
+}
+
+// Sync polls the actual state and returns the list of entites which need to be resolved
+func (h *consumerEntityHandler) Sync(organizationID string, resyncPeriod time.Duration) ([]entitystore.Entity, error) {
+	defer trace.Trace("")()
+
+	return controller.DefaultSync(h.store, h.Type(), organizationID, resyncPeriod, nil)
+}
+
+// Error handles errors while modifying API consumers
+func (h *consumerEntityHandler) Error(obj entitystore.Entity) error {
+	defer trace.Tracef("")()
+
+	// adding a consumer is idempotent, so just try again
+	return h.Update(obj)
+}
+
 // NewController creates a new controller
-func NewController(config *ControllerConfig, store entitystore.EntityStore, gw gateway.Gateway) controller.Controller {
+func NewController(config *ControllerConfig, store entitystore.EntityStore, gw gateway.Gateway, secrets *secretclient.SecretStore) controller.Controller {
 	defer trace.Trace("")()
 
 	c := controller.NewController(controller.Options{
 		OrganizationID: config.OrganizationID,
 		ResyncPeriod:   config.ResyncPeriod,
+		Store:          store,
+		Elector:        config.Elector,
 	})
 
-	c.AddEntityHandler(&apiEntityHandler{store: store, gw: gw})
+	c.AddEntityHandler(&apiEntityHandler{store: store, gw: gw, secrets: secrets})
+	c.AddEntityHandler(&consumerEntityHandler{store: store, gw: gw})
 	return c
 }
+
+// RestoreGateway hands all the APIs and consumers over to the controller, to restore the state of a gateway, which
+// does not persist it (i.e. the built-in gateway)
+func RestoreGateway(watcher controller.Watcher, store entitystore.EntityStore, organizationID string) error {
+	defer trace.Trace("")()
+
+	opts := entitystore.Options{
+		Filter: entitystore.FilterExists(),
+	}
+	var apis []*API
+	if err := store.List(organizationID, opts, &apis); err != nil {
+		return ewrapper.Wrap(err, "store error when listing apis")
+	}
+	var consumers []*Consumer
+	if err := store.List(organizationID, opts, &consumers); err != nil {
+		return ewrapper.Wrap(err, "store error when listing consumers")
+	}
+	for _, consumer := range consumers {
+		watcher.OnAction(consumer)
+	}
+	for _, api := range apis {
+		watcher.OnAction(api)
+	}
+	log.Infof("%d apis and %d consumers restored", len(apis), len(consumers))
+	return nil
+}
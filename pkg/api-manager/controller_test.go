@@ -31,7 +31,7 @@ func getTestController(t *testing.T, es entitystore.EntityStore, gw gateway.Gate
 		ResyncPeriod:   testResyncPeriod,
 		OrganizationID: testOrgID,
 	}
-	ctrl := NewController(config, es, gw)
+	ctrl := NewController(config, es, gw, nil)
 	return ctrl, ctrl.Watcher()
 }
 
@@ -210,3 +210,69 @@ func TestCtrlDeleteAPIAsync(t *testing.T) {
 	err = es.Get(testOrgID, testDelAPIAsync.Name, entitystore.Options{}, &entity)
 	assert.NotNil(t, err)
 }
+
+func TestCtrlAddConsumer(t *testing.T) {
+
+	testAddConsumer := &Consumer{
+		BaseEntity: entitystore.BaseEntity{
+			OrganizationID: testOrgID,
+			Name:           "testAddConsumer",
+			Status:         entitystore.StatusCREATING,
+		},
+		Consumer: gateway.Consumer{
+			Name: "testAddConsumer",
+		},
+	}
+
+	mockedGateway := &mocks.Gateway{}
+	mockedGateway.On("AddConsumer", mock.Anything).Return(&gateway.Consumer{ID: "123", CreatedAt: 123, Name: "testAddConsumer"}, nil)
+	es := helpers.MakeEntityStore(t)
+
+	ctrl, watcher := getTestController(t, es, mockedGateway)
+	ctrl.Start()
+	defer ctrl.Shutdown()
+
+	_, err := es.Add(testAddConsumer)
+	assert.Nil(t, err)
+	watcher.OnAction(testAddConsumer)
+
+	time.Sleep(testSleepDuration)
+
+	var actual Consumer
+	es.Get(testOrgID, testAddConsumer.Name, entitystore.Options{}, &actual)
+	assert.Equal(t, entitystore.StatusREADY, actual.Status)
+	assert.Equal(t, "123", actual.Consumer.ID)
+}
+
+func TestCtrlDeleteConsumer(t *testing.T) {
+
+	mockedGateway := &mocks.Gateway{}
+	mockedGateway.On("DeleteConsumer", mock.Anything).Return(nil)
+	es := helpers.MakeEntityStore(t)
+
+	ctrl, watcher := getTestController(t, es, mockedGateway)
+	ctrl.Start()
+	defer ctrl.Shutdown()
+
+	testDelConsumer := &Consumer{
+		BaseEntity: entitystore.BaseEntity{
+			OrganizationID: testOrgID,
+			Name:           "testDelConsumer",
+			Status:         entitystore.StatusDELETING,
+		},
+		Consumer: gateway.Consumer{
+			Name: "testDelConsumer",
+		},
+	}
+
+	_, err := es.Add(testDelConsumer)
+	assert.Nil(t, err)
+	watcher.OnAction(testDelConsumer)
+
+	time.Sleep(testSleepDuration)
+
+	var entity Consumer
+	err = es.Get(testOrgID, testDelConsumer.Name, entitystore.Options{}, &entity)
+	assert.NotNil(t, err)
+	mockedGateway.AssertCalled(t, "DeleteConsumer", mock.Anything)
+}
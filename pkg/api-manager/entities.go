@@ -8,6 +8,8 @@ package apimanager
 // NO TEST
 
 import (
+	"time"
+
 	"github.com/vmware/dispatch/pkg/api-manager/gateway"
 	entitystore "github.com/vmware/dispatch/pkg/entity-store"
 )
@@ -16,4 +18,21 @@ import (
 type API struct {
 	entitystore.BaseEntity
 	API gateway.API `db:"api"`
+	// TLS is the certificate registered with the gateway for the API hosts, nil if there is none
+	TLS *TLSCertificate `db:"tls"`
+}
+
+// TLSCertificate is a certificate, resolved from a secret, registered with the gateway
+type TLSCertificate struct {
+	ID          string    `json:"id"`
+	Fingerprint string    `json:"fingerprint"`
+	Hosts       []string  `json:"hosts"`
+	Expiration  time.Time `json:"expiration"`
+}
+
+// Consumer is a data struct used to store api consumer information into entity store, the consumer credentials are
+// only kept by the gateway
+type Consumer struct {
+	entitystore.BaseEntity
+	Consumer gateway.Consumer `db:"consumer"`
 }
@@ -0,0 +1,308 @@
+///////////////////////////////////////////////////////////////////////
+// Copyright (c) 2018 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+///////////////////////////////////////////////////////////////////////
+
+package builtin
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/satori/go.uuid"
+
+	"github.com/vmware/dispatch/pkg/api-manager/gateway"
+	entitystore "github.com/vmware/dispatch/pkg/entity-store"
+	"github.com/vmware/dispatch/pkg/errors"
+	"github.com/vmware/dispatch/pkg/trace"
+)
+
+const (
+	// keyName is the header or query parameter carrying an API key, the same as Kong
+	keyName = "apikey"
+	// jwtName is the query parameter carrying a JWT, the same as Kong
+	jwtName = "jwt"
+)
+
+// Credential is a data struct used to store consumer credentials into entity store, named by the credential ID.
+// Passwords are only stored as salted hashes.
+type Credential struct {
+	entitystore.BaseEntity
+	Consumer     string             `json:"consumer"`
+	Credential   gateway.Credential `json:"credential"`
+	PasswordSalt string             `json:"passwordSalt,omitempty"`
+	PasswordHash string             `json:"passwordHash,omitempty"`
+}
+
+func generateSecret() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+func hashPassword(salt, password string) string {
+	sum := sha256.Sum256([]byte(salt + password))
+	return hex.EncodeToString(sum[:])
+}
+
+func secureEqual(a, b string) bool {
+	return subtle.ConstantTimeCompare([]byte(a), []byte(b)) == 1
+}
+
+// findCredential looks up a credential by type and its username or key, the caller holds the lock
+func (g *Gateway) findCredential(authentication, name string) *Credential {
+	for _, c := range g.credentials {
+		if c.Credential.Type != authentication {
+			continue
+		}
+		if authentication == gateway.AuthBasic && c.Credential.Username == name {
+			return c
+		}
+		if authentication != gateway.AuthBasic && secureEqual(c.Credential.Key, name) {
+			return c
+		}
+	}
+	return nil
+}
+
+// AddCredential issues a credential to a consumer, the secrets which are not set are generated
+func (g *Gateway) AddCredential(consumer string, entity *gateway.Credential) (*gateway.Credential, error) {
+	defer trace.Tracef("consumer '%s', type '%s'", consumer, entity.Type)()
+
+	credential := gateway.Credential{
+		ID:        uuid.NewV4().String(),
+		CreatedAt: now(),
+		Type:      entity.Type,
+	}
+	var err error
+	switch entity.Type {
+	case gateway.AuthBasic:
+		credential.Username, credential.Password = entity.Username, entity.Password
+		if credential.Username == "" {
+			credential.Username = consumer
+		}
+		if credential.Password == "" {
+			credential.Password, err = generateSecret()
+		}
+	case gateway.AuthKey:
+		credential.Key = entity.Key
+		if credential.Key == "" {
+			credential.Key, err = generateSecret()
+		}
+	case gateway.AuthJWT:
+		credential.Key, credential.Secret = entity.Key, entity.Secret
+		if credential.Key == "" {
+			credential.Key, err = generateSecret()
+		}
+		if err == nil && credential.Secret == "" {
+			credential.Secret, err = generateSecret()
+		}
+	default:
+		return nil, &errors.DriverError{
+			Err: fmt.Errorf("credential type %s is not supported by the built-in gateway", entity.Type),
+		}
+	}
+	if err != nil {
+		return nil, &errors.DriverError{Err: err}
+	}
+
+	g.lock.Lock()
+	defer g.lock.Unlock()
+
+	if _, ok := g.consumers[consumer]; !ok {
+		return nil, &errors.ObjectNotFoundError{Err: fmt.Errorf("consumer not found")}
+	}
+	name := credential.Key
+	if credential.Type == gateway.AuthBasic {
+		name = credential.Username
+	}
+	if g.findCredential(credential.Type, name) != nil {
+		return nil, &errors.DriverError{Err: fmt.Errorf("a %s credential with the same name already exists", credential.Type)}
+	}
+
+	e := &Credential{
+		BaseEntity: entitystore.BaseEntity{
+			OrganizationID: g.organizationID,
+			Name:           credential.ID,
+			Status:         entitystore.StatusREADY,
+		},
+		Consumer:   consumer,
+		Credential: credential,
+	}
+	if credential.Type == gateway.AuthBasic {
+		e.PasswordSalt, err = generateSecret()
+		if err != nil {
+			return nil, &errors.DriverError{Err: err}
+		}
+		e.PasswordHash = hashPassword(e.PasswordSalt, credential.Password)
+		e.Credential.Password = ""
+	}
+	if _, err := g.store.Add(e); err != nil {
+		return nil, &errors.DriverError{Err: err}
+	}
+	g.credentials[credential.ID] = e
+	return &credential, nil
+}
+
+// GetCredentials gets the credentials issued to a consumer
+func (g *Gateway) GetCredentials(consumer string) ([]*gateway.Credential, error) {
+	defer trace.Tracef("consumer '%s'", consumer)()
+
+	g.lock.RLock()
+	defer g.lock.RUnlock()
+
+	if _, ok := g.consumers[consumer]; !ok {
+		return nil, &errors.ObjectNotFoundError{Err: fmt.Errorf("consumer not found")}
+	}
+	var credentials []*gateway.Credential
+	for _, c := range g.credentials {
+		if c.Consumer == consumer {
+			credential := c.Credential
+			credentials = append(credentials, &credential)
+		}
+	}
+	sort.Slice(credentials, func(i, j int) bool {
+		if credentials[i].CreatedAt != credentials[j].CreatedAt {
+			return credentials[i].CreatedAt < credentials[j].CreatedAt
+		}
+		return credentials[i].ID < credentials[j].ID
+	})
+	return credentials, nil
+}
+
+// DeleteCredential revokes a credential of a consumer
+func (g *Gateway) DeleteCredential(consumer string, id string) error {
+	defer trace.Tracef("consumer '%s', id '%s'", consumer, id)()
+
+	g.lock.Lock()
+	defer g.lock.Unlock()
+
+	c, ok := g.credentials[id]
+	if !ok || c.Consumer != consumer {
+		return &errors.ObjectNotFoundError{Err: fmt.Errorf("credential not found")}
+	}
+	if err := g.store.Delete(g.organizationID, c.Name, c); err != nil {
+		return &errors.DriverError{Err: err}
+	}
+	delete(g.credentials, id)
+	return nil
+}
+
+// authenticate checks the credential of a request against the API authentication method, and returns the consumer,
+// which is empty for public APIs
+func (g *Gateway) authenticate(api *gateway.API, r *http.Request) (string, error) {
+
+	switch api.Authentication {
+	case gateway.AuthBasic:
+		return g.authenticateBasic(r)
+	case gateway.AuthKey:
+		return g.authenticateKey(r)
+	case gateway.AuthJWT:
+		return g.authenticateJWT(r)
+	}
+	return "", nil
+}
+
+func (g *Gateway) authenticateBasic(r *http.Request) (string, error) {
+	username, password, ok := r.BasicAuth()
+	if !ok {
+		return "", fmt.Errorf("no credentials found")
+	}
+
+	g.lock.RLock()
+	defer g.lock.RUnlock()
+
+	c := g.findCredential(gateway.AuthBasic, username)
+	if c == nil || !secureEqual(c.PasswordHash, hashPassword(c.PasswordSalt, password)) {
+		return "", fmt.Errorf("invalid authentication credentials")
+	}
+	return c.Consumer, nil
+}
+
+func (g *Gateway) authenticateKey(r *http.Request) (string, error) {
+	key := r.Header.Get(keyName)
+	if key == "" {
+		key = r.URL.Query().Get(keyName)
+	}
+	if key == "" {
+		return "", fmt.Errorf("no API key found in request")
+	}
+
+	g.lock.RLock()
+	defer g.lock.RUnlock()
+
+	c := g.findCredential(gateway.AuthKey, key)
+	if c == nil {
+		return "", fmt.Errorf("invalid authentication credentials")
+	}
+	return c.Consumer, nil
+}
+
+// authenticateJWT verifies a HS256 signed token, the iss claim of which is the key of a jwt credential
+func (g *Gateway) authenticateJWT(r *http.Request) (string, error) {
+	token := r.URL.Query().Get(jwtName)
+	if auth := r.Header.Get("Authorization"); strings.HasPrefix(auth, "Bearer ") {
+		token = strings.TrimPrefix(auth, "Bearer ")
+	}
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return "", fmt.Errorf("unauthorized")
+	}
+
+	var header struct {
+		Alg string `json:"alg"`
+	}
+	var claims struct {
+		Iss string   `json:"iss"`
+		Exp *float64 `json:"exp"`
+	}
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return "", fmt.Errorf("bad token; %v", err)
+	}
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return "", fmt.Errorf("bad token; %v", err)
+	}
+	if header.Alg != "HS256" {
+		return "", fmt.Errorf("invalid algorithm")
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return "", fmt.Errorf("bad token; %v", err)
+	}
+
+	g.lock.RLock()
+	c := g.findCredential(gateway.AuthJWT, claims.Iss)
+	g.lock.RUnlock()
+	if c == nil {
+		return "", fmt.Errorf("no credentials found for given 'iss'")
+	}
+	mac := hmac.New(sha256.New, []byte(c.Credential.Secret))
+	mac.Write([]byte(parts[0] + "." + parts[1]))
+	if !hmac.Equal(signature, mac.Sum(nil)) {
+		return "", fmt.Errorf("invalid signature")
+	}
+	if claims.Exp != nil && time.Now().Unix() >= int64(*claims.Exp) {
+		return "", fmt.Errorf("token expired")
+	}
+	return c.Consumer, nil
+}
+
+func decodeSegment(segment string, v interface{}) error {
+	b, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(segment, "="))
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(b, v)
+}
@@ -0,0 +1,301 @@
+///////////////////////////////////////////////////////////////////////
+// Copyright (c) 2018 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+///////////////////////////////////////////////////////////////////////
+
+package builtin
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/satori/go.uuid"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/vmware/dispatch/pkg/api-manager/gateway"
+	entitystore "github.com/vmware/dispatch/pkg/entity-store"
+	"github.com/vmware/dispatch/pkg/errors"
+	"github.com/vmware/dispatch/pkg/trace"
+)
+
+// Config represents a configure for the built-in gateway
+type Config struct {
+	// FunctionManager is the host of the function manager, which runs the functions behind the APIs
+	FunctionManager string
+	// Store persists the consumer credentials, the APIs and consumers are restored by the api-manager controller
+	Store          entitystore.EntityStore
+	OrganizationID string
+}
+
+// certificate is a parsed TLS certificate and the host names it is served for
+type certificate struct {
+	id        string
+	createdAt int
+	pair      *tls.Certificate
+	snis      []string
+}
+
+// Gateway is an API gateway, which routes requests straight to the function manager, without any dependency
+type Gateway struct {
+	functionManager string
+	store           entitystore.EntityStore
+	organizationID  string
+	httpClient      *http.Client
+
+	lock         sync.RWMutex
+	apis         map[string]*gateway.API
+	consumers    map[string]*gateway.Consumer
+	credentials  map[string]*Credential
+	certificates map[string]*certificate
+	snis         map[string]*certificate
+
+	limiter *rateLimiter
+}
+
+// NewGateway creates a new built-in gateway
+func NewGateway(config *Config) (*Gateway, error) {
+
+	if config.Store == nil {
+		return nil, fmt.Errorf("the built-in gateway requires an entity store")
+	}
+	return &Gateway{
+		functionManager: config.FunctionManager,
+		store:           config.Store,
+		organizationID:  config.OrganizationID,
+		httpClient:      http.DefaultClient,
+		apis:            make(map[string]*gateway.API),
+		consumers:       make(map[string]*gateway.Consumer),
+		credentials:     make(map[string]*Credential),
+		certificates:    make(map[string]*certificate),
+		snis:            make(map[string]*certificate),
+		limiter:         newRateLimiter(),
+	}, nil
+}
+
+// Initialize loads the consumer credentials from the entity store
+func (g *Gateway) Initialize() error {
+	defer trace.Trace("")()
+
+	var credentials []*Credential
+	opts := entitystore.Options{
+		Filter: entitystore.FilterExists(),
+	}
+	if err := g.store.List(g.organizationID, opts, &credentials); err != nil {
+		return &errors.DriverError{Err: err}
+	}
+
+	g.lock.Lock()
+	defer g.lock.Unlock()
+	for _, c := range credentials {
+		g.credentials[c.Credential.ID] = c
+	}
+	log.Debugf("builtin.initialize: %d credentials loaded", len(credentials))
+	return nil
+}
+
+func now() int {
+	return int(time.Now().Unix())
+}
+
+func copyAPI(api *gateway.API) *gateway.API {
+	c := *api
+	return &c
+}
+
+// AddAPI adds an API to the routes of the gateway
+func (g *Gateway) AddAPI(entity *gateway.API) (*gateway.API, error) {
+	defer trace.Tracef("name '%s'", entity.Name)()
+
+	return g.UpdateAPI(entity.Name, entity)
+}
+
+// GetAPI gets an API from the routes of the gateway
+func (g *Gateway) GetAPI(name string) (*gateway.API, error) {
+	defer trace.Tracef("name '%s'", name)()
+
+	g.lock.RLock()
+	defer g.lock.RUnlock()
+
+	api, ok := g.apis[name]
+	if !ok {
+		return nil, &errors.ObjectNotFoundError{Err: fmt.Errorf("api not found")}
+	}
+	return copyAPI(api), nil
+}
+
+// UpdateAPI adds or updates an API in the routes of the gateway
+func (g *Gateway) UpdateAPI(name string, entity *gateway.API) (*gateway.API, error) {
+	defer trace.Tracef("name '%s'", name)()
+
+	switch entity.Authentication {
+	case "", gateway.AuthPublic, gateway.AuthBasic, gateway.AuthKey, gateway.AuthJWT:
+	default:
+		return nil, &errors.DriverError{
+			Err: fmt.Errorf("authentication method %s is not supported by the built-in gateway", entity.Authentication),
+		}
+	}
+
+	api := copyAPI(entity)
+	api.Name = name
+	if api.ID == "" {
+		api.ID = uuid.NewV4().String()
+	}
+	if api.CreatedAt == 0 {
+		api.CreatedAt = now()
+	}
+
+	g.lock.Lock()
+	defer g.lock.Unlock()
+	g.apis[name] = api
+	log.Debugf("builtin.updateAPI.%s: routed to function %s", name, api.Function)
+	return copyAPI(api), nil
+}
+
+// DeleteAPI deletes an API from the routes of the gateway
+func (g *Gateway) DeleteAPI(api *gateway.API) error {
+	defer trace.Tracef("name '%s'", api.Name)()
+
+	g.lock.Lock()
+	defer g.lock.Unlock()
+
+	if _, ok := g.apis[api.Name]; !ok {
+		return &errors.ObjectNotFoundError{Err: fmt.Errorf("api not found")}
+	}
+	delete(g.apis, api.Name)
+	return nil
+}
+
+// AddConsumer adds a consumer to the gateway, an existing consumer is returned as is, with updated rate limits
+func (g *Gateway) AddConsumer(entity *gateway.Consumer) (*gateway.Consumer, error) {
+	defer trace.Tracef("name '%s'", entity.Name)()
+
+	g.lock.Lock()
+	defer g.lock.Unlock()
+
+	c, ok := g.consumers[entity.Name]
+	if !ok {
+		c = &gateway.Consumer{
+			ID:        entity.ID,
+			CreatedAt: entity.CreatedAt,
+			Name:      entity.Name,
+		}
+		if c.ID == "" {
+			c.ID = uuid.NewV4().String()
+		}
+		if c.CreatedAt == 0 {
+			c.CreatedAt = now()
+		}
+		g.consumers[c.Name] = c
+	}
+	c.RateLimit = entity.RateLimit
+	result := *c
+	return &result, nil
+}
+
+// DeleteConsumer deletes a consumer, along with its credentials, from the gateway
+func (g *Gateway) DeleteConsumer(entity *gateway.Consumer) error {
+	defer trace.Tracef("name '%s'", entity.Name)()
+
+	g.lock.Lock()
+	defer g.lock.Unlock()
+
+	// the credentials are deleted even if the consumer is not restored yet
+	for id, c := range g.credentials {
+		if c.Consumer != entity.Name {
+			continue
+		}
+		if err := g.store.Delete(g.organizationID, c.Name, c); err != nil {
+			return &errors.DriverError{Err: err}
+		}
+		delete(g.credentials, id)
+	}
+
+	if _, ok := g.consumers[entity.Name]; !ok {
+		return &errors.ObjectNotFoundError{Err: fmt.Errorf("consumer not found")}
+	}
+	delete(g.consumers, entity.Name)
+	return nil
+}
+
+// UpdateCertificate adds or updates a certificate, and serves it for the SNIs
+func (g *Gateway) UpdateCertificate(entity *gateway.Certificate) (*gateway.Certificate, error) {
+	defer trace.Tracef("id '%s'", entity.ID)()
+
+	pair, err := tls.X509KeyPair([]byte(entity.Cert), []byte(entity.Key))
+	if err != nil {
+		return nil, &errors.DriverError{Err: err}
+	}
+	c := &certificate{
+		id:        entity.ID,
+		createdAt: now(),
+		pair:      &pair,
+		snis:      entity.SNIs,
+	}
+	if c.id == "" {
+		c.id = uuid.NewV4().String()
+	}
+
+	g.lock.Lock()
+	defer g.lock.Unlock()
+
+	if old, ok := g.certificates[c.id]; ok {
+		c.createdAt = old.createdAt
+		g.deleteSNIs(old)
+	}
+	g.certificates[c.id] = c
+	for _, name := range c.snis {
+		g.snis[strings.ToLower(name)] = c
+	}
+	return &gateway.Certificate{
+		ID:        c.id,
+		CreatedAt: c.createdAt,
+		SNIs:      entity.SNIs,
+	}, nil
+}
+
+// deleteSNIs stops serving a certificate, the caller holds the lock
+func (g *Gateway) deleteSNIs(c *certificate) {
+	for _, name := range c.snis {
+		if g.snis[strings.ToLower(name)] == c {
+			delete(g.snis, strings.ToLower(name))
+		}
+	}
+}
+
+// DeleteCertificate deletes a certificate, along with its SNIs
+func (g *Gateway) DeleteCertificate(entity *gateway.Certificate) error {
+	defer trace.Tracef("id '%s'", entity.ID)()
+
+	g.lock.Lock()
+	defer g.lock.Unlock()
+
+	c, ok := g.certificates[entity.ID]
+	if !ok {
+		return &errors.ObjectNotFoundError{Err: fmt.Errorf("certificate not found")}
+	}
+	g.deleteSNIs(c)
+	delete(g.certificates, entity.ID)
+	return nil
+}
+
+// TLSConfig returns the configuration of the https listener, serving the certificate of each SNI
+func (g *Gateway) TLSConfig() *tls.Config {
+	return &tls.Config{
+		GetCertificate: g.getCertificate,
+	}
+}
+
+func (g *Gateway) getCertificate(hello *tls.ClientHelloInfo) (*tls.Certificate, error) {
+	g.lock.RLock()
+	defer g.lock.RUnlock()
+
+	c, ok := g.snis[strings.ToLower(hello.ServerName)]
+	if !ok {
+		return nil, fmt.Errorf("no certificate for server name '%s'", hello.ServerName)
+	}
+	return c.pair, nil
+}
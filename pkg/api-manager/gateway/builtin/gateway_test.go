@@ -0,0 +1,359 @@
+///////////////////////////////////////////////////////////////////////
+// Copyright (c) 2018 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+///////////////////////////////////////////////////////////////////////
+
+package builtin
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/vmware/dispatch/pkg/api-manager/gateway"
+	entitystore "github.com/vmware/dispatch/pkg/entity-store"
+	"github.com/vmware/dispatch/pkg/errors"
+	helpers "github.com/vmware/dispatch/pkg/testing/api"
+)
+
+const testOrgID = "testOrg"
+
+// makeFunctionManager fakes the function manager, the runs echo their function name, input and HTTP request. The
+// function named "respond" returns an HTTP response instead.
+func makeFunctionManager(t *testing.T) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "POST", r.Method)
+		assert.Equal(t, "/v1/runs", r.URL.Path)
+		assert.Equal(t, "cookie", r.Header.Get("Cookie"))
+
+		var run map[string]interface{}
+		assert.Nil(t, json.NewDecoder(r.Body).Decode(&run))
+		if r.URL.Query().Get("functionName") == "respond" {
+			w.Header().Set(httpResponseHeader, "true")
+			w.Header().Set("Content-Type", "text/html")
+			w.WriteHeader(http.StatusCreated)
+			w.Write([]byte(`<p>{"output": "hello"}</p>`))
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"functionName": r.URL.Query().Get("functionName"),
+			"output": map[string]interface{}{
+				"function":    r.URL.Query().Get("functionName"),
+				"input":       run["input"],
+				"blocking":    run["blocking"],
+				"httpRequest": run["httpRequest"],
+			},
+		})
+	}))
+}
+
+func makeTestGateway(t *testing.T, es entitystore.EntityStore, functionManager string) *Gateway {
+	g, err := NewGateway(&Config{
+		FunctionManager: strings.TrimPrefix(functionManager, "http://"),
+		Store:           es,
+		OrganizationID:  testOrgID,
+	})
+	assert.Nil(t, err)
+	assert.Nil(t, g.Initialize())
+	return g
+}
+
+func serve(g *Gateway, r *http.Request) (int, map[string]interface{}) {
+	w := httptest.NewRecorder()
+	g.ServeHTTP(w, r)
+	var body map[string]interface{}
+	json.Unmarshal(w.Body.Bytes(), &body)
+	return w.Code, body
+}
+
+func TestGatewayRoute(t *testing.T) {
+	fm := makeFunctionManager(t)
+	defer fm.Close()
+	g := makeTestGateway(t, helpers.MakeEntityStore(t), fm.URL)
+
+	_, err := g.AddAPI(&gateway.API{Name: "hello", Function: "hello", URIs: []string{"/hello"}, Methods: []string{"GET"}, Enabled: true})
+	assert.Nil(t, err)
+	_, err = g.AddAPI(&gateway.API{Name: "world", Function: "world", URIs: []string{"/hello/world"}, Enabled: true})
+	assert.Nil(t, err)
+	_, err = g.AddAPI(&gateway.API{Name: "host", Function: "host", Hosts: []string{"*.example.com"}, Enabled: true})
+	assert.Nil(t, err)
+	_, err = g.AddAPI(&gateway.API{Name: "disabled", Function: "disabled", URIs: []string{"/disabled"}})
+	assert.Nil(t, err)
+
+	code, body := serve(g, httptest.NewRequest("GET", "/hello?name=vmware&place=a&place=b", nil))
+	assert.Equal(t, 200, code)
+	assert.Equal(t, "hello", body["function"])
+	assert.Equal(t, map[string]interface{}{"name": "vmware", "place": []interface{}{"a", "b"}}, body["input"])
+	assert.Equal(t, true, body["blocking"])
+
+	// the longest uri wins
+	r := httptest.NewRequest("POST", "/hello/world/", strings.NewReader(`{"name": "vmware"}`))
+	r.Header.Set(blockingHeader, "false")
+	code, body = serve(g, r)
+	assert.Equal(t, 200, code)
+	assert.Equal(t, "world", body["function"])
+	assert.Equal(t, map[string]interface{}{"name": "vmware"}, body["input"])
+	assert.Equal(t, false, body["blocking"])
+
+	// the method does not match
+	code, _ = serve(g, httptest.NewRequest("POST", "/hello", nil))
+	assert.Equal(t, 404, code)
+
+	// the host wins
+	r = httptest.NewRequest("POST", "/hello/world", strings.NewReader("plain text"))
+	r.Host = "api.example.com:8081"
+	code, body = serve(g, r)
+	assert.Equal(t, 200, code)
+	assert.Equal(t, "host", body["function"])
+	assert.Equal(t, "plain text", body["input"])
+
+	code, _ = serve(g, httptest.NewRequest("GET", "/disabled", nil))
+	assert.Equal(t, 404, code)
+
+	assert.Nil(t, g.DeleteAPI(&gateway.API{Name: "hello"}))
+	_, err = g.GetAPI("hello")
+	assert.IsType(t, &errors.ObjectNotFoundError{}, err)
+	code, _ = serve(g, httptest.NewRequest("GET", "/hello", nil))
+	assert.Equal(t, 404, code)
+}
+
+func TestGatewayHTTPRequest(t *testing.T) {
+	fm := makeFunctionManager(t)
+	defer fm.Close()
+	g := makeTestGateway(t, helpers.MakeEntityStore(t), fm.URL)
+
+	_, err := g.AddAPI(&gateway.API{Name: "users", Function: "users", URIs: []string{"/users/{id}"}, Enabled: true})
+	assert.Nil(t, err)
+	_, err = g.AddAPI(&gateway.API{Name: "posts", Function: "posts", URIs: []string{"/users/{id}/posts/{post}"}, Enabled: true})
+	assert.Nil(t, err)
+	_, err = g.AddAPI(&gateway.API{Name: "respond", Function: "respond", URIs: []string{"/respond"}, Authentication: gateway.AuthKey, Enabled: true})
+	assert.Nil(t, err)
+	_, err = g.AddConsumer(&gateway.Consumer{Name: "consumer"})
+	assert.Nil(t, err)
+	_, err = g.AddCredential("consumer", &gateway.Credential{Type: gateway.AuthKey, Key: "secret"})
+	assert.Nil(t, err)
+
+	r := httptest.NewRequest("PUT", "/users/42?verbose=true", strings.NewReader(`{"name": "vmware"}`))
+	r.Header.Set("X-Custom", "custom")
+	r.RemoteAddr = "10.0.0.1:1234"
+	code, body := serve(g, r)
+	assert.Equal(t, 200, code)
+	assert.Equal(t, "users", body["function"])
+	assert.Equal(t, map[string]interface{}{"name": "vmware"}, body["input"])
+	httpRequest := body["httpRequest"].(map[string]interface{})
+	assert.Equal(t, "PUT", httpRequest["method"])
+	assert.Equal(t, "/users/42", httpRequest["path"])
+	assert.Equal(t, "10.0.0.1", httpRequest["remoteAddr"])
+	assert.Equal(t, map[string]interface{}{"id": "42"}, httpRequest["params"])
+	assert.Equal(t, map[string]interface{}{"verbose": []interface{}{"true"}}, httpRequest["query"])
+	assert.Equal(t, []interface{}{"custom"}, httpRequest["headers"].(map[string]interface{})["X-Custom"])
+
+	// the longest match wins
+	code, body = serve(g, httptest.NewRequest("GET", "/users/42/posts/7/comments", nil))
+	assert.Equal(t, 200, code)
+	assert.Equal(t, "posts", body["function"])
+	httpRequest = body["httpRequest"].(map[string]interface{})
+	assert.Equal(t, map[string]interface{}{"id": "42", "post": "7"}, httpRequest["params"])
+
+	code, _ = serve(g, httptest.NewRequest("GET", "/users/", nil))
+	assert.Equal(t, 404, code)
+
+	// HTTP responses are passed as is, and credentials are hidden from functions
+	r = httptest.NewRequest("GET", "/respond", nil)
+	r.Header.Set(keyName, "secret")
+	w := httptest.NewRecorder()
+	g.ServeHTTP(w, r)
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Equal(t, "text/html", w.Header().Get("Content-Type"))
+	assert.Empty(t, w.Header().Get(httpResponseHeader))
+	assert.Equal(t, `<p>{"output": "hello"}</p>`, w.Body.String())
+
+	req := httptest.NewRequest("GET", "/respond?apikey=secret", nil)
+	req.Header.Set(keyName, "secret")
+	data, err := runRequestBody(&gateway.API{Authentication: gateway.AuthKey}, nil, req)
+	assert.Nil(t, err)
+	assert.NotContains(t, string(data), "secret")
+}
+
+func TestGatewayHTTPSAndCORS(t *testing.T) {
+	fm := makeFunctionManager(t)
+	defer fm.Close()
+	g := makeTestGateway(t, helpers.MakeEntityStore(t), fm.URL)
+
+	_, err := g.AddAPI(&gateway.API{
+		Name: "hello", Function: "hello", URIs: []string{"/hello"}, Methods: []string{"GET", "POST"},
+		Protocols: []string{"https"}, CORS: true, Enabled: true,
+	})
+	assert.Nil(t, err)
+
+	code, _ := serve(g, httptest.NewRequest("GET", "/hello", nil))
+	assert.Equal(t, http.StatusUpgradeRequired, code)
+
+	r := httptest.NewRequest("OPTIONS", "https://localhost/hello", nil)
+	r.Header.Set("Access-Control-Request-Method", "POST")
+	w := httptest.NewRecorder()
+	g.ServeHTTP(w, r)
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	assert.Equal(t, "*", w.Header().Get("Access-Control-Allow-Origin"))
+	assert.Equal(t, "GET,POST", w.Header().Get("Access-Control-Allow-Methods"))
+}
+
+func makeJWT(key, secret string) string {
+	header := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"HS256","typ":"JWT"}`))
+	claims := base64.RawURLEncoding.EncodeToString([]byte(`{"iss":"` + key + `"}`))
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(header + "." + claims))
+	return header + "." + claims + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func TestGatewayAuthentication(t *testing.T) {
+	fm := makeFunctionManager(t)
+	defer fm.Close()
+	es := helpers.MakeEntityStore(t)
+	g := makeTestGateway(t, es, fm.URL)
+
+	_, err := g.AddCredential("alice", &gateway.Credential{Type: gateway.AuthKey})
+	assert.IsType(t, &errors.ObjectNotFoundError{}, err)
+
+	_, err = g.AddConsumer(&gateway.Consumer{Name: "alice"})
+	assert.Nil(t, err)
+	basic, err := g.AddCredential("alice", &gateway.Credential{Type: gateway.AuthBasic})
+	assert.Nil(t, err)
+	assert.Equal(t, "alice", basic.Username)
+	assert.NotEmpty(t, basic.Password)
+	key, err := g.AddCredential("alice", &gateway.Credential{Type: gateway.AuthKey})
+	assert.Nil(t, err)
+	jwt, err := g.AddCredential("alice", &gateway.Credential{Type: gateway.AuthJWT})
+	assert.Nil(t, err)
+	_, err = g.AddCredential("alice", &gateway.Credential{Type: gateway.AuthOAuth2, RedirectURI: "http://localhost"})
+	assert.NotNil(t, err)
+
+	_, err = g.AddAPI(&gateway.API{Name: "oauth2", Function: "hello", Authentication: gateway.AuthOAuth2})
+	assert.NotNil(t, err)
+
+	// the credentials are persisted, the passwords as hashes only
+	g = makeTestGateway(t, es, fm.URL)
+	_, err = g.AddConsumer(&gateway.Consumer{Name: "alice"})
+	assert.Nil(t, err)
+	credentials, err := g.GetCredentials("alice")
+	assert.Nil(t, err)
+	assert.Len(t, credentials, 3)
+	for _, c := range credentials {
+		assert.Empty(t, c.Password)
+	}
+
+	for _, auth := range []string{gateway.AuthBasic, gateway.AuthKey, gateway.AuthJWT} {
+		_, err = g.AddAPI(&gateway.API{Name: auth, Function: auth, URIs: []string{"/" + auth}, Authentication: auth, Enabled: true})
+		assert.Nil(t, err)
+
+		code, _ := serve(g, httptest.NewRequest("GET", "/"+auth, nil))
+		assert.Equal(t, 401, code, auth)
+	}
+
+	r := httptest.NewRequest("GET", "/basic", nil)
+	r.SetBasicAuth("alice", "wrong")
+	code, _ := serve(g, r)
+	assert.Equal(t, 401, code)
+	r.SetBasicAuth("alice", basic.Password)
+	code, _ = serve(g, r)
+	assert.Equal(t, 200, code)
+
+	// the key is hidden from the function
+	code, body := serve(g, httptest.NewRequest("GET", "/key?apikey="+key.Key+"&name=vmware", nil))
+	assert.Equal(t, 200, code)
+	assert.Equal(t, map[string]interface{}{"name": "vmware"}, body["input"])
+
+	r = httptest.NewRequest("GET", "/jwt", nil)
+	r.Header.Set("Authorization", "Bearer "+makeJWT(jwt.Key, "wrong"))
+	code, _ = serve(g, r)
+	assert.Equal(t, 401, code)
+	r.Header.Set("Authorization", "Bearer "+makeJWT(jwt.Key, jwt.Secret))
+	code, _ = serve(g, r)
+	assert.Equal(t, 200, code)
+
+	assert.Nil(t, g.DeleteCredential("alice", key.ID))
+	code, _ = serve(g, httptest.NewRequest("GET", "/key?apikey="+key.Key, nil))
+	assert.Equal(t, 401, code)
+
+	assert.Nil(t, g.DeleteConsumer(&gateway.Consumer{Name: "alice"}))
+	var stored []*Credential
+	assert.Nil(t, es.List(testOrgID, entitystore.Options{}, &stored))
+	assert.Len(t, stored, 0)
+}
+
+func TestRunResponseOutput(t *testing.T) {
+	assert.Equal(t, `{"hello":"world"}`, string(runResponseOutput([]byte(`{"name":"run","output":{"hello":"world"}}`))))
+	assert.Equal(t, `{"message":"error"}`, string(runResponseOutput([]byte(`{"message":"error"}`))))
+	assert.Equal(t, "not json", string(runResponseOutput([]byte("not json"))))
+}
+
+func TestGatewayRateLimit(t *testing.T) {
+	fm := makeFunctionManager(t)
+	defer fm.Close()
+	g := makeTestGateway(t, helpers.MakeEntityStore(t), fm.URL)
+
+	_, err := g.AddAPI(&gateway.API{
+		Name: "hello", Function: "hello", URIs: []string{"/hello"}, Authentication: gateway.AuthKey, Enabled: true,
+		RateLimit: &gateway.RateLimit{Hour: 2},
+	})
+	assert.Nil(t, err)
+	_, err = g.AddConsumer(&gateway.Consumer{Name: "alice"})
+	assert.Nil(t, err)
+	_, err = g.AddConsumer(&gateway.Consumer{Name: "bob", RateLimit: &gateway.RateLimit{Day: 3}})
+	assert.Nil(t, err)
+	alice, err := g.AddCredential("alice", &gateway.Credential{Type: gateway.AuthKey})
+	assert.Nil(t, err)
+	bob, err := g.AddCredential("bob", &gateway.Credential{Type: gateway.AuthKey})
+	assert.Nil(t, err)
+
+	request := func(key string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		g.ServeHTTP(w, httptest.NewRequest("GET", "/hello?apikey="+key, nil))
+		return w
+	}
+	for i := 0; i < 2; i++ {
+		w := request(alice.Key)
+		assert.Equal(t, 200, w.Code)
+		assert.Equal(t, "2", w.Header().Get("X-RateLimit-Limit-hour"))
+	}
+	w := request(alice.Key)
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, "0", w.Header().Get("X-RateLimit-Remaining-hour"))
+	assert.NotEmpty(t, w.Header().Get("Retry-After"))
+
+	// the limits of the consumer override the ones of the api
+	for i := 0; i < 3; i++ {
+		assert.Equal(t, 200, request(bob.Key).Code)
+	}
+	assert.Equal(t, http.StatusTooManyRequests, request(bob.Key).Code)
+}
+
+func TestRateLimiterWindows(t *testing.T) {
+	l := newRateLimiter()
+	now := time.Date(2018, 1, 15, 23, 59, 59, 500000000, time.UTC)
+	limit := &gateway.RateLimit{Second: 1, Month: 2}
+
+	_, ok := l.allow("api", "client", limit, now)
+	assert.True(t, ok)
+	headers, ok := l.allow("api", "client", limit, now)
+	assert.False(t, ok)
+	assert.Equal(t, "1", headers.Get("Retry-After"))
+
+	// a new second
+	_, ok = l.allow("api", "client", limit, now.Add(time.Second))
+	assert.True(t, ok)
+	// the monthly quota is exceeded, until the next month
+	headers, ok = l.allow("api", "client", limit, now.Add(2*time.Second))
+	assert.False(t, ok)
+	assert.Equal(t, "1382399", headers.Get("Retry-After"))
+	assert.Equal(t, "0", headers.Get("X-RateLimit-Remaining-month"))
+}
@@ -0,0 +1,296 @@
+///////////////////////////////////////////////////////////////////////
+// Copyright (c) 2018 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+///////////////////////////////////////////////////////////////////////
+
+package builtin
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/vmware/dispatch/pkg/api-manager/gateway"
+)
+
+const (
+	jsonContentType = "application/json"
+
+	// blockingHeader sets whether the function run is blocking, it is true by default
+	blockingHeader = "x-dispatch-blocking"
+
+	// httpResponseHeader is set by the function manager when the function returns an HTTP response
+	httpResponseHeader = "X-Dispatch-Http-Response"
+)
+
+// writeError writes an error message, formatted as Kong does
+func writeError(w http.ResponseWriter, code int, message string) {
+	w.Header().Set("Content-Type", jsonContentType)
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(map[string]string{"message": message})
+}
+
+func hostMatches(patterns []string, host string) bool {
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	host = strings.ToLower(host)
+	for _, p := range patterns {
+		p = strings.ToLower(p)
+		switch {
+		case p == host:
+			return true
+		case strings.HasPrefix(p, "*.") && strings.HasSuffix(host, p[1:]):
+			return true
+		case strings.HasSuffix(p, ".*") && strings.HasPrefix(host, p[:len(p)-1]):
+			return true
+		}
+	}
+	return false
+}
+
+// uriMatch returns the length of the longest URI prefix of the path, or -1, and the path parameters of the URI
+func uriMatch(uris []string, path string) (int, map[string]string) {
+	if len(uris) == 0 {
+		return 0, nil
+	}
+	match := -1
+	var params map[string]string
+	for _, uri := range uris {
+		t, err := gateway.ParseURITemplate(uri)
+		if err != nil {
+			continue
+		}
+		if n, p := t.Match(path); n > match {
+			match, params = n, p
+		}
+	}
+	return match, params
+}
+
+func clientIP(r *http.Request) string {
+	if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+		return host
+	}
+	return r.RemoteAddr
+}
+
+func methodMatches(api *gateway.API, method string) bool {
+	if len(api.Methods) == 0 || (api.CORS && method == http.MethodOptions) {
+		return true
+	}
+	for _, m := range api.Methods {
+		if strings.EqualFold(m, method) {
+			return true
+		}
+	}
+	return false
+}
+
+// route finds the API of a request, and the path parameters. Like Kong, APIs with hosts take precedence, then APIs
+// with longer URIs.
+func (g *Gateway) route(r *http.Request) (*gateway.API, map[string]string) {
+	g.lock.RLock()
+	defer g.lock.RUnlock()
+
+	var result *gateway.API
+	var resultParams map[string]string
+	resultHost, resultURI := false, -1
+	for _, api := range g.apis {
+		if !api.Enabled {
+			continue
+		}
+		if len(api.Hosts) > 0 && !hostMatches(api.Hosts, r.Host) {
+			continue
+		}
+		uri, params := uriMatch(api.URIs, r.URL.Path)
+		if uri < 0 || !methodMatches(api, r.Method) {
+			continue
+		}
+		host := len(api.Hosts) > 0
+		if result == nil || (host && !resultHost) || (host == resultHost && uri > resultURI) {
+			result, resultHost, resultURI, resultParams = api, host, uri, params
+		}
+	}
+	if result == nil {
+		return nil, nil
+	}
+	return copyAPI(result), resultParams
+}
+
+// ServeHTTP routes a request to the function of the matching API, the same way as Kong and the dispatch-transformer
+// plugin do
+func (g *Gateway) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+
+	api, params := g.route(r)
+	if api == nil {
+		writeError(w, http.StatusNotFound, "no API found with those values")
+		return
+	}
+	if len(api.Protocols) == 1 && api.Protocols[0] == "https" && r.TLS == nil {
+		writeError(w, http.StatusUpgradeRequired, "Please use HTTPS protocol")
+		return
+	}
+	if api.CORS {
+		w.Header().Set("Access-Control-Allow-Origin", "*")
+		if r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != "" {
+			w.Header().Set("Access-Control-Allow-Methods", strings.Join(api.Methods, ","))
+			if headers := r.Header.Get("Access-Control-Request-Headers"); headers != "" {
+				w.Header().Set("Access-Control-Allow-Headers", headers)
+			}
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+	}
+	consumer, err := g.authenticate(api, r)
+	if err != nil {
+		log.Debugf("builtin.serve.%s: %v", api.Name, err)
+		writeError(w, http.StatusUnauthorized, err.Error())
+		return
+	}
+	headers, ok := g.rateLimit(api, consumer, r)
+	for k, v := range headers {
+		w.Header()[k] = v
+	}
+	if !ok {
+		writeError(w, http.StatusTooManyRequests, "API rate limit exceeded")
+		return
+	}
+
+	body, err := runRequestBody(api, params, r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	u := fmt.Sprintf("http://%s/v1/runs?functionName=%s", g.functionManager, url.QueryEscape(api.Function))
+	req, err := http.NewRequest(http.MethodPost, u, bytes.NewReader(body))
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	req = req.WithContext(r.Context())
+	req.Header.Set("Content-Type", jsonContentType)
+	req.Header.Set("Cookie", "cookie")
+
+	resp, err := g.httpClient.Do(req)
+	if err != nil {
+		log.Errorf("builtin.serve.%s: error running function %s: %v", api.Name, api.Function, err)
+		writeError(w, http.StatusBadGateway, "An invalid response was received from the upstream server")
+		return
+	}
+	defer resp.Body.Close()
+
+	output, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		writeError(w, http.StatusBadGateway, "An invalid response was received from the upstream server")
+		return
+	}
+	contentType := resp.Header.Get("Content-Type")
+	if resp.Header.Get(httpResponseHeader) != "" {
+		// the function returned an HTTP response, which is passed as is
+		for k, v := range resp.Header {
+			if k != httpResponseHeader && k != "Content-Length" && k != "Date" {
+				w.Header()[k] = v
+			}
+		}
+		w.WriteHeader(resp.StatusCode)
+		w.Write(output)
+		return
+	}
+	if strings.Contains(strings.ToLower(contentType), jsonContentType) {
+		output = runResponseOutput(output)
+	}
+	if contentType != "" {
+		w.Header().Set("Content-Type", contentType)
+	}
+	w.WriteHeader(resp.StatusCode)
+	w.Write(output)
+}
+
+// runRequestBody wraps the query parameters of GET (and OPTIONS) requests, or the body of other requests, as the
+// function run input, along with the HTTP request
+func runRequestBody(api *gateway.API, params map[string]string, r *http.Request) ([]byte, error) {
+	run := make(map[string]interface{})
+
+	// credentials are hidden from functions
+	query := r.URL.Query()
+	headers := make(http.Header)
+	for k, v := range r.Header {
+		headers[k] = v
+	}
+	switch api.Authentication {
+	case gateway.AuthKey:
+		query.Del(keyName)
+		headers.Del(keyName)
+	case gateway.AuthJWT:
+		query.Del(jwtName)
+		headers.Del("Authorization")
+	case gateway.AuthBasic:
+		headers.Del("Authorization")
+	}
+
+	if r.Method == http.MethodGet || r.Method == http.MethodOptions {
+		input := make(map[string]interface{})
+		for k, v := range query {
+			if len(v) == 1 {
+				input[k] = v[0]
+			} else {
+				input[k] = v
+			}
+		}
+		run["input"] = input
+	} else {
+		data, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			return nil, err
+		}
+		if len(data) == 0 {
+			data = []byte("{}")
+		}
+		var input interface{}
+		if err := json.Unmarshal(data, &input); err == nil {
+			run["input"] = input
+		} else {
+			run["input"] = string(data)
+		}
+	}
+
+	run["httpRequest"] = map[string]interface{}{
+		"method":     r.Method,
+		"host":       r.Host,
+		"path":       r.URL.Path,
+		"params":     params,
+		"query":      query,
+		"headers":    headers,
+		"remoteAddr": clientIP(r),
+	}
+
+	switch blocking := r.Header.Get(blockingHeader); blocking {
+	case "", "true":
+		run["blocking"] = true
+	case "false":
+		run["blocking"] = false
+	default:
+		run["blocking"] = blocking
+	}
+	return json.Marshal(run)
+}
+
+// runResponseOutput returns the output of a function run, or the response as is
+func runResponseOutput(data []byte) []byte {
+	var run map[string]json.RawMessage
+	if err := json.Unmarshal(data, &run); err != nil {
+		return data
+	}
+	if output, ok := run["output"]; ok && string(output) != "null" {
+		return output
+	}
+	return data
+}
@@ -0,0 +1,154 @@
+///////////////////////////////////////////////////////////////////////
+// Copyright (c) 2018 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+///////////////////////////////////////////////////////////////////////
+
+package builtin
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/vmware/dispatch/pkg/api-manager/gateway"
+)
+
+// sweepPeriod is how often the counters of past time windows are removed
+const sweepPeriod = time.Minute
+
+// window returns the time window of a period, aligned in UTC, as Kong does
+func window(period string, now time.Time) (time.Time, time.Time) {
+	now = now.UTC()
+	var start time.Time
+	switch period {
+	case "second":
+		start = now.Truncate(time.Second)
+		return start, start.Add(time.Second)
+	case "minute":
+		start = now.Truncate(time.Minute)
+		return start, start.Add(time.Minute)
+	case "hour":
+		start = now.Truncate(time.Hour)
+		return start, start.Add(time.Hour)
+	case "day":
+		start = time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+		return start, start.AddDate(0, 0, 1)
+	default:
+		start = time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+		return start, start.AddDate(0, 1, 0)
+	}
+}
+
+type limitPeriod struct {
+	name  string
+	limit int64
+}
+
+func limitPeriods(limit *gateway.RateLimit) []limitPeriod {
+	var periods []limitPeriod
+	for _, p := range []limitPeriod{
+		{"second", limit.Second},
+		{"minute", limit.Minute},
+		{"hour", limit.Hour},
+		{"day", limit.Day},
+		{"month", limit.Month},
+	} {
+		if p.limit > 0 {
+			periods = append(periods, p)
+		}
+	}
+	return periods
+}
+
+type counter struct {
+	count int64
+	end   time.Time
+}
+
+// rateLimiter counts the requests of the clients in fixed time windows
+type rateLimiter struct {
+	lock      sync.Mutex
+	counters  map[string]*counter
+	lastSweep time.Time
+}
+
+func newRateLimiter() *rateLimiter {
+	return &rateLimiter{
+		counters: make(map[string]*counter),
+	}
+}
+
+// allow counts a request of a client, unless one of the limits is exceeded. The returned headers report the limits,
+// the remaining requests and, when throttled, when to retry.
+func (l *rateLimiter) allow(scope, client string, limit *gateway.RateLimit, now time.Time) (http.Header, bool) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	if now.Sub(l.lastSweep) > sweepPeriod {
+		for key, c := range l.counters {
+			if !now.Before(c.end) {
+				delete(l.counters, key)
+			}
+		}
+		l.lastSweep = now
+	}
+
+	periods := limitPeriods(limit)
+	counters := make([]*counter, len(periods))
+	var retryAfter time.Duration
+	for i, p := range periods {
+		start, end := window(p.name, now)
+		key := fmt.Sprintf("%s/%s/%s/%d", scope, client, p.name, start.Unix())
+		c, ok := l.counters[key]
+		if !ok {
+			c = &counter{end: end}
+			l.counters[key] = c
+		}
+		counters[i] = c
+		if c.count >= p.limit && end.Sub(now) > retryAfter {
+			retryAfter = end.Sub(now)
+		}
+	}
+
+	headers := http.Header{}
+	for i, p := range periods {
+		if retryAfter == 0 {
+			counters[i].count++
+		}
+		remaining := p.limit - counters[i].count
+		if remaining < 0 {
+			remaining = 0
+		}
+		headers.Set("X-RateLimit-Limit-"+p.name, strconv.FormatInt(p.limit, 10))
+		headers.Set("X-RateLimit-Remaining-"+p.name, strconv.FormatInt(remaining, 10))
+	}
+	if retryAfter > 0 {
+		// rounded up to whole seconds
+		headers.Set("Retry-After", strconv.FormatInt(int64((retryAfter+time.Second-1)/time.Second), 10))
+		return headers, false
+	}
+	return headers, true
+}
+
+// rateLimit applies the rate limits of the consumer, or else of the API, to a request. The clients are the consumers,
+// or the IP addresses of anonymous clients.
+func (g *Gateway) rateLimit(api *gateway.API, consumer string, r *http.Request) (http.Header, bool) {
+	scope, limit := "api:"+api.Name, api.RateLimit
+
+	g.lock.RLock()
+	if c, ok := g.consumers[consumer]; ok && c.RateLimit != nil {
+		scope, limit = "consumer:"+consumer, c.RateLimit
+	}
+	g.lock.RUnlock()
+
+	if limit == nil {
+		return nil, true
+	}
+	client := "consumer:" + consumer
+	if consumer == "" {
+		client = "ip:" + clientIP(r)
+	}
+	return g.limiter.allow(scope, client, limit, time.Now())
+}
@@ -7,6 +7,15 @@ package gateway
 
 // NO TEST
 
+// Authentication methods of APIs
+const (
+	AuthPublic = "public"
+	AuthBasic  = "basic"
+	AuthKey    = "key"
+	AuthJWT    = "jwt"
+	AuthOAuth2 = "oauth2"
+)
+
 // API represents the metadata of an API
 type API struct {
 	ID        string `json:"id,omitempty"`
@@ -26,11 +35,68 @@ type API struct {
 	// i.e. http https
 	Protocols []string `json:"protocols,omitempty"`
 
-	// reference to tls certificates (a dispatch secret name)
-	// TODO: will be replaced by SNI objects
+	// reference to tls certificates (a dispatch secret name), served for the hosts (SNIs)
 	TLS string `json:"tls,omitempty"`
 
 	CORS bool `json:"cors,omitempty"`
+
+	// nil if the API is not rate limited
+	RateLimit *RateLimit `json:"rate_limit,omitempty"`
+}
+
+// RateLimit represents the maximum number of requests per time window, for each client (the consumer, or the IP
+// address of anonymous clients). Zero means no limit.
+type RateLimit struct {
+	Second int64 `json:"second,omitempty"`
+	Minute int64 `json:"minute,omitempty"`
+	Hour   int64 `json:"hour,omitempty"`
+
+	// quotas
+	Day   int64 `json:"day,omitempty"`
+	Month int64 `json:"month,omitempty"`
+}
+
+// Consumer represents an end-user of APIs
+type Consumer struct {
+	ID        string `json:"id,omitempty"`
+	CreatedAt int    `json:"created_at,omitempty"`
+
+	Name string `json:"name,omitempty"`
+
+	// overrides the rate limits of the APIs, nil if there is none
+	RateLimit *RateLimit `json:"rate_limit,omitempty"`
+}
+
+// Credential represents a credential issued to a consumer, its type is one of the authentication methods
+type Credential struct {
+	ID        string `json:"id,omitempty"`
+	CreatedAt int    `json:"created_at,omitempty"`
+
+	Type string `json:"type,omitempty"`
+
+	// basic
+	Username string `json:"username,omitempty"`
+	Password string `json:"password,omitempty"`
+
+	// key, jwt (key) and oauth2 (client id)
+	Key string `json:"key,omitempty"`
+	// jwt (secret) and oauth2 (client secret)
+	Secret string `json:"secret,omitempty"`
+
+	// oauth2
+	RedirectURI string `json:"redirect_uri,omitempty"`
+}
+
+// Certificate represents a TLS certificate and the host names (SNIs) it is served for
+type Certificate struct {
+	ID        string `json:"id,omitempty"`
+	CreatedAt int    `json:"created_at,omitempty"`
+
+	// PEM encoded
+	Cert string `json:"cert,omitempty"`
+	Key  string `json:"key,omitempty"`
+
+	SNIs []string `json:"snis,omitempty"`
 }
 
 // Gateway defines interfaces the underlying API Gateway provides
@@ -40,4 +106,13 @@ type Gateway interface {
 	GetAPI(name string) (*API, error)
 	UpdateAPI(name string, api *API) (*API, error)
 	DeleteAPI(api *API) error
+
+	AddConsumer(consumer *Consumer) (*Consumer, error)
+	DeleteConsumer(consumer *Consumer) error
+	AddCredential(consumer string, credential *Credential) (*Credential, error)
+	GetCredentials(consumer string) ([]*Credential, error)
+	DeleteCredential(consumer string, id string) error
+
+	UpdateCertificate(cert *Certificate) (*Certificate, error)
+	DeleteCertificate(cert *Certificate) error
 }
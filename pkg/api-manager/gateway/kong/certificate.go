@@ -0,0 +1,194 @@
+///////////////////////////////////////////////////////////////////////
+// Copyright (c) 2018 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+///////////////////////////////////////////////////////////////////////
+
+package kong
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/vmware/dispatch/pkg/api-manager/gateway"
+	"github.com/vmware/dispatch/pkg/errors"
+	"github.com/vmware/dispatch/pkg/trace"
+)
+
+// Certificate is a struct for Kong Certificate
+type Certificate struct {
+	ID        string `json:"id,omitempty"`
+	CreatedAt int    `json:"created_at,omitempty"`
+
+	Cert string `json:"cert"`
+	Key  string `json:"key"`
+}
+
+// SNI is a struct for Kong SNI, mapping a host name to a certificate
+type SNI struct {
+	Name             string `json:"name"`
+	SSLCertificateID string `json:"ssl_certificate_id"`
+	CreatedAt        int    `json:"created_at,omitempty"`
+}
+
+// UpdateCertificate adds or updates a certificate in Kong, and maps the SNIs to it
+func (k *Client) UpdateCertificate(entity *gateway.Certificate) (*gateway.Certificate, error) {
+	defer trace.Tracef("id '%s'", entity.ID)()
+
+	body, err := json.Marshal(&Certificate{Cert: entity.Cert, Key: entity.Key})
+	if err != nil {
+		return nil, &errors.ObjectMarshalError{Err: err}
+	}
+
+	var c *Certificate
+	if entity.ID != "" {
+		c, err = k.sendCertificate("PATCH", fmt.Sprintf("%s/certificates/%s", k.host, entity.ID), body)
+		if _, ok := err.(*errors.ObjectNotFoundError); ok {
+			log.Debugf("kong.updateCertificate.%s: no such certificate, try to add", entity.ID)
+			c, err = nil, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	if c == nil {
+		c, err = k.sendCertificate("POST", fmt.Sprintf("%s/certificates/", k.host), body)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if err := k.updateSNIs(c.ID, entity.SNIs); err != nil {
+		return nil, err
+	}
+	return &gateway.Certificate{
+		ID:        c.ID,
+		CreatedAt: c.CreatedAt,
+		SNIs:      entity.SNIs,
+	}, nil
+}
+
+func (k *Client) sendCertificate(method, url string, body []byte) (*Certificate, error) {
+
+	resp, err := k.request(method, url, jsonContentType, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	log.Debugf("kong.updateCertificate.[%s %s]: status code: %v", method, url, resp.StatusCode)
+	switch resp.StatusCode {
+	case 200, 201:
+		var c Certificate
+		if err := k.getResponse(resp, &c); err != nil {
+			return nil, err
+		}
+		return &c, nil
+	case 404:
+		return nil, &errors.ObjectNotFoundError{Err: fmt.Errorf("certificate not found")}
+	default:
+		err = getKongError("updateCertificate", resp)
+		return nil, &errors.DriverError{Err: err}
+	}
+}
+
+// updateSNIs maps the names to the certificate, and removes the other names mapped to it
+func (k *Client) updateSNIs(certificateID string, names []string) error {
+
+	resp, err := k.request("GET", fmt.Sprintf("%s/snis/", k.host), jsonContentType, nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	log.Debugf("kong.getSNIs: status code: %v", resp.StatusCode)
+	if resp.StatusCode != 200 {
+		err = getKongError("getSNIs", resp)
+		return &errors.DriverError{Err: err}
+	}
+	respObject := struct {
+		Total int   `json:"total"`
+		Data  []SNI `json:"data"`
+	}{}
+	if err := k.getResponse(resp, &respObject); err != nil {
+		return err
+	}
+
+	for _, sni := range respObject.Data {
+		if sni.SSLCertificateID == certificateID && !stringContains(names, sni.Name) {
+			if err := k.deleteSNI(sni.Name); err != nil {
+				return err
+			}
+		}
+	}
+	for _, name := range names {
+		if err := k.putSNI(&SNI{Name: name, SSLCertificateID: certificateID}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (k *Client) putSNI(sni *SNI) error {
+
+	body, err := json.Marshal(sni)
+	if err != nil {
+		return &errors.ObjectMarshalError{Err: err}
+	}
+	resp, err := k.request("PUT", fmt.Sprintf("%s/snis/", k.host), jsonContentType, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	log.Debugf("kong.putSNI.%s: status code: %v", sni.Name, resp.StatusCode)
+	switch resp.StatusCode {
+	case 200, 201:
+		return nil
+	default:
+		err = getKongError("putSNI", resp)
+		return &errors.DriverError{Err: err}
+	}
+}
+
+func (k *Client) deleteSNI(name string) error {
+
+	resp, err := k.request("DELETE", fmt.Sprintf("%s/snis/%s", k.host, name), jsonContentType, nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	log.Debugf("kong.deleteSNI.%s: status code: %v", name, resp.StatusCode)
+	switch resp.StatusCode {
+	case 204, 404:
+		return nil
+	default:
+		err = getKongError("deleteSNI", resp)
+		return &errors.DriverError{Err: err}
+	}
+}
+
+// DeleteCertificate deletes a certificate, along with its SNIs, from Kong
+func (k *Client) DeleteCertificate(entity *gateway.Certificate) error {
+	defer trace.Tracef("id '%s'", entity.ID)()
+
+	resp, err := k.request("DELETE", fmt.Sprintf("%s/certificates/%s", k.host, entity.ID), jsonContentType, nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	log.Debugf("kong.deleteCertificate.%s: status code: %v", entity.ID, resp.StatusCode)
+	switch resp.StatusCode {
+	case 204:
+		return nil
+	case 404:
+		return &errors.ObjectNotFoundError{Err: fmt.Errorf("certificate not found")}
+	default:
+		err = getKongError("deleteCertificate", resp)
+		return &errors.DriverError{Err: err}
+	}
+}
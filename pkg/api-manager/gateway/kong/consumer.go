@@ -0,0 +1,387 @@
+///////////////////////////////////////////////////////////////////////
+// Copyright (c) 2018 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+///////////////////////////////////////////////////////////////////////
+
+package kong
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/vmware/dispatch/pkg/api-manager/gateway"
+	"github.com/vmware/dispatch/pkg/errors"
+	"github.com/vmware/dispatch/pkg/trace"
+)
+
+// authPlugins maps the authentication methods to the Kong plugins enforcing them, the plugins of the same names
+// manage the consumer credentials
+var authPlugins = map[string]Plugin{
+	gateway.AuthBasic: {
+		Name: "basic-auth",
+		Config: map[string]interface{}{
+			"config.hide_credentials": true,
+		},
+	},
+	gateway.AuthKey: {
+		Name: "key-auth",
+		Config: map[string]interface{}{
+			"config.hide_credentials": true,
+		},
+	},
+	gateway.AuthJWT: {
+		Name: "jwt",
+	},
+	gateway.AuthOAuth2: {
+		Name: "oauth2",
+		Config: map[string]interface{}{
+			"config.enable_client_credentials": true,
+		},
+	},
+}
+
+// Consumer is a struct for Kong Consumer
+type Consumer struct {
+	ID        string `json:"id,omitempty"`
+	CreatedAt int    `json:"created_at,omitempty"`
+
+	Username string `json:"username"`
+}
+
+// Credential is a struct for the credentials of all Kong authentication plugins
+type Credential struct {
+	ID        string `json:"id,omitempty"`
+	CreatedAt int    `json:"created_at,omitempty"`
+
+	// basic-auth
+	Username string `json:"username,omitempty"`
+	Password string `json:"password,omitempty"`
+
+	// key-auth and jwt
+	Key string `json:"key,omitempty"`
+	// jwt
+	Secret string `json:"secret,omitempty"`
+
+	// oauth2
+	Name         string   `json:"name,omitempty"`
+	ClientID     string   `json:"client_id,omitempty"`
+	ClientSecret string   `json:"client_secret,omitempty"`
+	RedirectURI  []string `json:"redirect_uri,omitempty"`
+}
+
+// updateAuthPlugins enables the plugin of the API authentication method and removes the others
+func (k *Client) updateAuthPlugins(apiName, authentication string) error {
+
+	if _, ok := authPlugins[authentication]; !ok && authentication != "" && authentication != gateway.AuthPublic {
+		return &errors.DriverError{Err: fmt.Errorf("unsupported authentication method %s", authentication)}
+	}
+	for method, plugin := range authPlugins {
+		var err error
+		if method == authentication {
+			p := plugin
+			err = k.updatePluginByName(apiName, p.Name, &p)
+		} else {
+			err = k.deletePluginByName(apiName, plugin.Name)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func credentialEntityToKong(entity *gateway.Credential) *Credential {
+
+	c := Credential{}
+	switch entity.Type {
+	case gateway.AuthBasic:
+		c.Username = entity.Username
+		c.Password = entity.Password
+	case gateway.AuthKey:
+		c.Key = entity.Key
+	case gateway.AuthJWT:
+		c.Key = entity.Key
+		c.Secret = entity.Secret
+	case gateway.AuthOAuth2:
+		c.Name = entity.Username
+		c.ClientID = entity.Key
+		c.ClientSecret = entity.Secret
+		if entity.RedirectURI != "" {
+			c.RedirectURI = []string{entity.RedirectURI}
+		}
+	}
+	return &c
+}
+
+func credentialKongToEntity(authentication string, c *Credential) *gateway.Credential {
+
+	entity := gateway.Credential{
+		ID:        c.ID,
+		CreatedAt: c.CreatedAt,
+		Type:      authentication,
+	}
+	switch authentication {
+	case gateway.AuthBasic:
+		// Kong only returns the hash of the password
+		entity.Username = c.Username
+	case gateway.AuthKey:
+		entity.Key = c.Key
+	case gateway.AuthJWT:
+		entity.Key = c.Key
+		entity.Secret = c.Secret
+	case gateway.AuthOAuth2:
+		entity.Username = c.Name
+		entity.Key = c.ClientID
+		entity.Secret = c.ClientSecret
+		if len(c.RedirectURI) > 0 {
+			entity.RedirectURI = c.RedirectURI[0]
+		}
+	}
+	return &entity
+}
+
+func generateSecret() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// AddConsumer adds a consumer in Kong, an existing consumer is returned as is, with updated rate limits
+func (k *Client) AddConsumer(entity *gateway.Consumer) (*gateway.Consumer, error) {
+	defer trace.Tracef("name '%s'", entity.Name)()
+
+	resp, err := k.request("GET", fmt.Sprintf("%s/consumers/%s", k.host, entity.Name), jsonContentType, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	log.Debugf("kong.getConsumer.%s: status code: %v", entity.Name, resp.StatusCode)
+	var c Consumer
+	switch resp.StatusCode {
+	case 200:
+		if err := k.getResponse(resp, &c); err != nil {
+			return nil, err
+		}
+		return k.consumerKongToEntity(&c, entity.RateLimit)
+	case 404:
+		// continue
+	default:
+		err = getKongError("getConsumer", resp)
+		return nil, &errors.DriverError{Err: err}
+	}
+
+	body, err := json.Marshal(&Consumer{Username: entity.Name})
+	if err != nil {
+		return nil, &errors.ObjectMarshalError{Err: err}
+	}
+	resp, err = k.request("POST", fmt.Sprintf("%s/consumers/", k.host), jsonContentType, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	log.Debugf("kong.addConsumer.%s: status code: %v", entity.Name, resp.StatusCode)
+	switch resp.StatusCode {
+	case 201:
+		if err := k.getResponse(resp, &c); err != nil {
+			return nil, err
+		}
+		return k.consumerKongToEntity(&c, entity.RateLimit)
+	default:
+		err = getKongError("addConsumer", resp)
+		return nil, &errors.DriverError{Err: err}
+	}
+}
+
+// consumerKongToEntity applies the rate limits of a consumer, once it exists in Kong
+func (k *Client) consumerKongToEntity(c *Consumer, limit *gateway.RateLimit) (*gateway.Consumer, error) {
+
+	if err := k.updateConsumerRateLimit(c.ID, limit); err != nil {
+		return nil, err
+	}
+	return &gateway.Consumer{ID: c.ID, CreatedAt: c.CreatedAt, Name: c.Username, RateLimit: limit}, nil
+}
+
+// DeleteConsumer deletes a consumer, along with its credentials, from Kong
+func (k *Client) DeleteConsumer(entity *gateway.Consumer) error {
+	defer trace.Tracef("name '%s'", entity.Name)()
+
+	resp, err := k.request("DELETE", fmt.Sprintf("%s/consumers/%s", k.host, entity.Name), jsonContentType, nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	log.Debugf("kong.deleteConsumer.%s: status code: %v", entity.Name, resp.StatusCode)
+	switch resp.StatusCode {
+	case 204:
+		return nil
+	case 404:
+		return &errors.ObjectNotFoundError{Err: fmt.Errorf("consumer not found")}
+	default:
+		err = getKongError("deleteConsumer", resp)
+		return &errors.DriverError{Err: err}
+	}
+}
+
+func (k *Client) getCredentialURL(consumer, authentication, id string) (string, error) {
+
+	plugin, ok := authPlugins[authentication]
+	if !ok {
+		return "", &errors.DriverError{Err: fmt.Errorf("unsupported credential type %s", authentication)}
+	}
+	url := fmt.Sprintf("%s/consumers/%s/%s", k.host, consumer, plugin.Name)
+	if id != "" {
+		url = fmt.Sprintf("%s/%s", url, id)
+	}
+	return url, nil
+}
+
+// AddCredential issues a credential to a consumer, Kong generates the secrets which are not set
+func (k *Client) AddCredential(consumer string, entity *gateway.Credential) (*gateway.Credential, error) {
+	defer trace.Tracef("consumer '%s', type '%s'", consumer, entity.Type)()
+
+	url, err := k.getCredentialURL(consumer, entity.Type, "")
+	if err != nil {
+		return nil, err
+	}
+	credential := *entity
+	if credential.Username == "" && (entity.Type == gateway.AuthBasic || entity.Type == gateway.AuthOAuth2) {
+		credential.Username = consumer
+	}
+	if credential.Password == "" && entity.Type == gateway.AuthBasic {
+		// unlike other secrets, Kong does not generate passwords
+		credential.Password, err = generateSecret()
+		if err != nil {
+			return nil, &errors.DriverError{Err: err}
+		}
+	}
+	entity = &credential
+	body, err := json.Marshal(credentialEntityToKong(entity))
+	if err != nil {
+		return nil, &errors.ObjectMarshalError{Err: err}
+	}
+	resp, err := k.request("POST", url, jsonContentType, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	log.Debugf("kong.addCredential.%s: status code: %v", consumer, resp.StatusCode)
+	switch resp.StatusCode {
+	case 201:
+		var c Credential
+		if err := k.getResponse(resp, &c); err != nil {
+			return nil, err
+		}
+		result := credentialKongToEntity(entity.Type, &c)
+		if entity.Type == gateway.AuthBasic {
+			result.Password = entity.Password
+		}
+		return result, nil
+	case 404:
+		return nil, &errors.ObjectNotFoundError{Err: fmt.Errorf("consumer not found")}
+	default:
+		err = getKongError("addCredential", resp)
+		return nil, &errors.DriverError{Err: err}
+	}
+}
+
+func (k *Client) getCredentialsByType(consumer, authentication string) ([]*gateway.Credential, error) {
+
+	url, err := k.getCredentialURL(consumer, authentication, "")
+	if err != nil {
+		return nil, err
+	}
+	resp, err := k.request("GET", url, jsonContentType, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	log.Debugf("kong.getCredentials.%s.%s: status code: %v", consumer, authentication, resp.StatusCode)
+	switch resp.StatusCode {
+	case 200:
+		respObject := struct {
+			Total int          `json:"total"`
+			Data  []Credential `json:"data"`
+		}{}
+		if err := k.getResponse(resp, &respObject); err != nil {
+			return nil, err
+		}
+		var credentials []*gateway.Credential
+		for i := range respObject.Data {
+			credentials = append(credentials, credentialKongToEntity(authentication, &respObject.Data[i]))
+		}
+		return credentials, nil
+	case 404:
+		return nil, &errors.ObjectNotFoundError{Err: fmt.Errorf("consumer not found")}
+	default:
+		err = getKongError("getCredentials", resp)
+		return nil, &errors.DriverError{Err: err}
+	}
+}
+
+// GetCredentials gets the credentials of all types issued to a consumer
+func (k *Client) GetCredentials(consumer string) ([]*gateway.Credential, error) {
+	defer trace.Tracef("consumer '%s'", consumer)()
+
+	var credentials []*gateway.Credential
+	for _, authentication := range []string{gateway.AuthBasic, gateway.AuthKey, gateway.AuthJWT, gateway.AuthOAuth2} {
+		c, err := k.getCredentialsByType(consumer, authentication)
+		if err != nil {
+			return nil, err
+		}
+		credentials = append(credentials, c...)
+	}
+	return credentials, nil
+}
+
+// DeleteCredential revokes a credential of a consumer
+func (k *Client) DeleteCredential(consumer string, id string) error {
+	defer trace.Tracef("consumer '%s', id '%s'", consumer, id)()
+
+	// the credential type is part of the Kong URL, so it has to be looked up first
+	credentials, err := k.GetCredentials(consumer)
+	if err != nil {
+		return err
+	}
+	var credential *gateway.Credential
+	for _, c := range credentials {
+		if c.ID == id {
+			credential = c
+		}
+	}
+	if credential == nil {
+		return &errors.ObjectNotFoundError{Err: fmt.Errorf("credential not found")}
+	}
+
+	url, err := k.getCredentialURL(consumer, credential.Type, id)
+	if err != nil {
+		return err
+	}
+	resp, err := k.request("DELETE", url, jsonContentType, nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	log.Debugf("kong.deleteCredential.%s.%s: status code: %v", consumer, id, resp.StatusCode)
+	switch resp.StatusCode {
+	case 204:
+		return nil
+	case 404:
+		return &errors.ObjectNotFoundError{Err: fmt.Errorf("credential not found")}
+	default:
+		err = getKongError("deleteCredential", resp)
+		return &errors.DriverError{Err: err}
+	}
+}
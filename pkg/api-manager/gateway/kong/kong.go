@@ -59,10 +59,11 @@ type API struct {
 
 // Plugin is a struct for Kong Plugin
 type Plugin struct {
-	Name    string                 `json:"name"`
-	ID      string                 `json:"id,omitempty"`
-	Config  map[string]interface{} `json:"config,omitempty"`
-	Enabled bool                   `json:"enabled,omitempty"`
+	Name       string                 `json:"name"`
+	ID         string                 `json:"id,omitempty"`
+	ConsumerID string                 `json:"consumer_id,omitempty"`
+	Config     map[string]interface{} `json:"config,omitempty"`
+	Enabled    bool                   `json:"enabled,omitempty"`
 }
 
 // NewClient creates a new Kong Client
@@ -76,45 +77,80 @@ func NewClient(config *Config) (*Client, error) {
 	return client, nil
 }
 
-// Initialize install neccessary plugins into kong at the begining
-func (k *Client) Initialize() error {
-	defer trace.Trace("")()
+const dispatchTransformerName = "dispatch-transformer"
 
-	dispatchTransformer := Plugin{
-		Name: "dispatch-transformer",
+// dispatchTransformer returns the dispatch-transformer plugin, which turns the requests of an API into runs of its
+// function. Kong ignores query params in upstream url so the function name is added by the plugin.
+func dispatchTransformer(function string, templates []string) *Plugin {
+	plugin := &Plugin{
+		Name: dispatchTransformerName,
 		Config: map[string]interface{}{
 			"config.substitute.input":            "input",
 			"config.substitute.output":           "output",
 			"config.enable.input":                true,
 			"config.enable.output":               true,
+			"config.enable.http_request":         true,
 			"config.http_method":                 "POST",
 			"config.add.header":                  "cookie:cookie",
 			"config.header_prefix_for_insertion": "x-dispatch-",
 			"config.insert_to_body.header":       "blocking:true",
 		},
 	}
-	err := k.updatePluginByName("", dispatchTransformer.Name, &dispatchTransformer)
+	if function != "" {
+		plugin.Config["config.append.querystring"] = fmt.Sprintf("functionName:%s", function)
+	}
+	if len(templates) > 0 {
+		plugin.Config["config.uri_templates"] = strings.Join(templates, ",")
+	}
+	return plugin
+}
+
+// Initialize install neccessary plugins into kong at the begining
+func (k *Client) Initialize() error {
+	defer trace.Trace("")()
+
+	err := k.updatePluginByName("", dispatchTransformerName, dispatchTransformer("", nil))
 	if err != nil {
 		return err
 	}
 	return nil
 }
 
+// updateAPITransformer replaces the dispatch-transformer plugin of an API, which overrides the global one
+func (k *Client) updateAPITransformer(apiName string, entity *gateway.API) error {
+
+	var templates []string
+	for _, uri := range entity.URIs {
+		t, err := gateway.ParseURITemplate(uri)
+		if err != nil {
+			return &errors.DriverError{Err: err}
+		}
+		if t.IsTemplate() {
+			templates = append(templates, t.Pattern)
+		}
+	}
+	if err := k.deletePluginByName(apiName, dispatchTransformerName); err != nil {
+		return err
+	}
+	return k.updatePluginByID(apiName, "", dispatchTransformer(entity.Function, templates))
+}
+
 func (k *Client) apiEntityToKong(entity *gateway.API) (*API, error) {
 
-	// Kong ignores query params in upstream url so have to add query params to dispatchTransformer
 	upstream := fmt.Sprintf("http://%s/v1/runs", k.upstream)
-	querystring := fmt.Sprintf("functionName:%s", entity.Function)
-	dispatchTransformer := Plugin{
-		Name: "dispatch-transformer",
-		Config: map[string]interface{}{
-			"config.append.querystring": querystring,
-		},
-	}
-	// Plugin should already exist so just update
-	err := k.updatePluginByName("", dispatchTransformer.Name, &dispatchTransformer)
-	if err != nil {
-		return nil, err
+
+	// the URI templates are matched as regular expressions
+	uris := make([]string, 0, len(entity.URIs))
+	for _, uri := range entity.URIs {
+		t, err := gateway.ParseURITemplate(uri)
+		if err != nil {
+			return nil, &errors.DriverError{Err: err}
+		}
+		if t.IsTemplate() {
+			uris = append(uris, t.Pattern)
+		} else {
+			uris = append(uris, uri)
+		}
 	}
 
 	a := API{
@@ -123,7 +159,7 @@ func (k *Client) apiEntityToKong(entity *gateway.API) (*API, error) {
 		Name:        entity.Name,
 		UpstreamURL: upstream,
 		Hosts:       entity.Hosts,
-		URIs:        entity.URIs,
+		URIs:        uris,
 		Methods:     entity.Methods,
 	}
 	if len(entity.Protocols) == 1 && entity.Protocols[0] == "https" {
@@ -272,6 +308,17 @@ func (k *Client) AddAPI(entity *gateway.API) (*gateway.API, error) {
 		}
 	}
 
+	if err := k.updateAuthPlugins(a.Name, entity.Authentication); err != nil {
+		return nil, err
+	}
+	if err := k.updateAPITransformer(a.Name, entity); err != nil {
+		return nil, err
+	}
+	if err := k.updateAPIRateLimit(a.Name, entity.RateLimit); err != nil {
+		return nil, err
+	}
+	result.URIs = entity.URIs
+	result.RateLimit = entity.RateLimit
 	return result, nil
 }
 
@@ -335,6 +382,18 @@ func (k *Client) UpdateAPI(name string, entity *gateway.API) (*gateway.API, erro
 			return nil, err
 		}
 	}
+
+	if err := k.updateAuthPlugins(name, entity.Authentication); err != nil {
+		return nil, err
+	}
+	if err := k.updateAPITransformer(name, entity); err != nil {
+		return nil, err
+	}
+	if err := k.updateAPIRateLimit(name, entity.RateLimit); err != nil {
+		return nil, err
+	}
+	result.URIs = entity.URIs
+	result.RateLimit = entity.RateLimit
 	return result, nil
 }
 
@@ -458,6 +517,9 @@ func (k *Client) updatePluginByID(apiName, pluginID string, plugin *Plugin) erro
 		body.Add(k, fmt.Sprintf("%v", v))
 	}
 	body.Add("name", plugin.Name)
+	if plugin.ConsumerID != "" {
+		body.Add("consumer_id", plugin.ConsumerID)
+	}
 
 	// TODO: test if json request also works
 	// body, err := json.Marshal(plugin)
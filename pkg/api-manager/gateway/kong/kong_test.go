@@ -141,3 +141,59 @@ func TestDeleteAPI(t *testing.T) {
 	err := client.DeleteAPI(noSuchAPI)
 	assert.NotNil(t, err)
 }
+
+func TestConsumerCredentials(t *testing.T) {
+
+	dev.EnsureLocal(t)
+	client := getTestKongInstance(t)
+
+	consumer := &gateway.Consumer{Name: "testConsumer"}
+
+	// clear
+	client.DeleteConsumer(consumer)
+
+	real, err := client.AddConsumer(consumer)
+	assert.Nil(t, err)
+	assert.Equal(t, consumer.Name, real.Name)
+
+	basic, err := client.AddCredential(consumer.Name, &gateway.Credential{Type: gateway.AuthBasic})
+	assert.Nil(t, err)
+	assert.Equal(t, consumer.Name, basic.Username)
+	assert.NotEmpty(t, basic.Password)
+
+	key, err := client.AddCredential(consumer.Name, &gateway.Credential{Type: gateway.AuthKey})
+	assert.Nil(t, err)
+	assert.NotEmpty(t, key.Key)
+
+	credentials, err := client.GetCredentials(consumer.Name)
+	assert.Nil(t, err)
+	assert.Len(t, credentials, 2)
+
+	err = client.DeleteCredential(consumer.Name, key.ID)
+	assert.Nil(t, err)
+
+	err = client.DeleteConsumer(consumer)
+	assert.Nil(t, err)
+}
+
+func TestAPIEntityToKongTemplates(t *testing.T) {
+
+	client, err := NewClient(&Config{Upstream: "function-manager"})
+	assert.Nil(t, err)
+
+	a, err := client.apiEntityToKong(&gateway.API{
+		Name:     "testTemplates",
+		Function: "testFunction",
+		URIs:     []string{"/hello", "/users/{id}"},
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, "http://function-manager/v1/runs", a.UpstreamURL)
+	assert.Equal(t, []string{"/hello", "/users/(?P<id>[^/]+)"}, a.URIs)
+
+	plugin := dispatchTransformer("testFunction", []string{"/users/(?P<id>[^/]+)"})
+	assert.Equal(t, "functionName:testFunction", plugin.Config["config.append.querystring"])
+	assert.Equal(t, "/users/(?P<id>[^/]+)", plugin.Config["config.uri_templates"])
+
+	_, err = client.apiEntityToKong(&gateway.API{Name: "testTemplates", URIs: []string{"/users/{id"}})
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,89 @@
+///////////////////////////////////////////////////////////////////////
+// Copyright (c) 2018 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+///////////////////////////////////////////////////////////////////////
+
+package kong
+
+import (
+	"fmt"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/vmware/dispatch/pkg/api-manager/gateway"
+	"github.com/vmware/dispatch/pkg/errors"
+)
+
+const rateLimitPluginName = "rate-limiting"
+
+func rateLimitPlugin(limit *gateway.RateLimit) *Plugin {
+
+	config := map[string]interface{}{
+		// counted for each consumer, or for the IP address of anonymous clients
+		"config.limit_by": "consumer",
+		// requests are not blocked if the counters are not available
+		"config.fault_tolerant": true,
+	}
+	limits := map[string]int64{
+		"second": limit.Second,
+		"minute": limit.Minute,
+		"hour":   limit.Hour,
+		"day":    limit.Day,
+		"month":  limit.Month,
+	}
+	for period, value := range limits {
+		if value > 0 {
+			config["config."+period] = value
+		}
+	}
+	return &Plugin{Name: rateLimitPluginName, Config: config}
+}
+
+// updateAPIRateLimit replaces the rate limiting plugin of an API. A patched plugin would keep the limits which are
+// not set anymore, so it is added again. The counters are kept by Kong.
+func (k *Client) updateAPIRateLimit(apiName string, limit *gateway.RateLimit) error {
+
+	if err := k.deletePluginByName(apiName, rateLimitPluginName); err != nil {
+		return err
+	}
+	if limit == nil {
+		return nil
+	}
+	return k.updatePluginByID(apiName, "", rateLimitPlugin(limit))
+}
+
+// updateConsumerRateLimit replaces the rate limiting plugin of a consumer, which applies to all APIs
+func (k *Client) updateConsumerRateLimit(consumerID string, limit *gateway.RateLimit) error {
+
+	url := fmt.Sprintf("%s?name=%s&consumer_id=%s", k.getPluginURL("", ""), rateLimitPluginName, consumerID)
+	resp, err := k.request("GET", url, urlencodedContentType, nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	log.Debugf("kong.getConsumerPlugins.%s: status code: %v", consumerID, resp.StatusCode)
+	if resp.StatusCode != 200 {
+		err = getKongError("getConsumerPlugins", resp)
+		return &errors.DriverError{Err: err}
+	}
+	respObject := struct {
+		Total int      `json:"total"`
+		Data  []Plugin `json:"data"`
+	}{}
+	if err := k.getResponse(resp, &respObject); err != nil {
+		return err
+	}
+	for _, p := range respObject.Data {
+		if err := k.deletePluginByID("", p.ID); err != nil {
+			return err
+		}
+	}
+
+	if limit == nil {
+		return nil
+	}
+	plugin := rateLimitPlugin(limit)
+	plugin.ConsumerID = consumerID
+	return k.updatePluginByID("", "", plugin)
+}
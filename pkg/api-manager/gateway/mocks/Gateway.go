@@ -33,6 +33,52 @@ func (_m *Gateway) AddAPI(api *gateway.API) (*gateway.API, error) {
 	return r0, r1
 }
 
+// AddConsumer provides a mock function with given fields: consumer
+func (_m *Gateway) AddConsumer(consumer *gateway.Consumer) (*gateway.Consumer, error) {
+	ret := _m.Called(consumer)
+
+	var r0 *gateway.Consumer
+	if rf, ok := ret.Get(0).(func(*gateway.Consumer) *gateway.Consumer); ok {
+		r0 = rf(consumer)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*gateway.Consumer)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(*gateway.Consumer) error); ok {
+		r1 = rf(consumer)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// AddCredential provides a mock function with given fields: consumer, credential
+func (_m *Gateway) AddCredential(consumer string, credential *gateway.Credential) (*gateway.Credential, error) {
+	ret := _m.Called(consumer, credential)
+
+	var r0 *gateway.Credential
+	if rf, ok := ret.Get(0).(func(string, *gateway.Credential) *gateway.Credential); ok {
+		r0 = rf(consumer, credential)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*gateway.Credential)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string, *gateway.Credential) error); ok {
+		r1 = rf(consumer, credential)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // DeleteAPI provides a mock function with given fields: api
 func (_m *Gateway) DeleteAPI(api *gateway.API) error {
 	ret := _m.Called(api)
@@ -47,6 +93,48 @@ func (_m *Gateway) DeleteAPI(api *gateway.API) error {
 	return r0
 }
 
+// DeleteCertificate provides a mock function with given fields: cert
+func (_m *Gateway) DeleteCertificate(cert *gateway.Certificate) error {
+	ret := _m.Called(cert)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(*gateway.Certificate) error); ok {
+		r0 = rf(cert)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// DeleteConsumer provides a mock function with given fields: consumer
+func (_m *Gateway) DeleteConsumer(consumer *gateway.Consumer) error {
+	ret := _m.Called(consumer)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(*gateway.Consumer) error); ok {
+		r0 = rf(consumer)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// DeleteCredential provides a mock function with given fields: consumer, id
+func (_m *Gateway) DeleteCredential(consumer string, id string) error {
+	ret := _m.Called(consumer, id)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string, string) error); ok {
+		r0 = rf(consumer, id)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // GetAPI provides a mock function with given fields: name
 func (_m *Gateway) GetAPI(name string) (*gateway.API, error) {
 	ret := _m.Called(name)
@@ -70,6 +158,29 @@ func (_m *Gateway) GetAPI(name string) (*gateway.API, error) {
 	return r0, r1
 }
 
+// GetCredentials provides a mock function with given fields: consumer
+func (_m *Gateway) GetCredentials(consumer string) ([]*gateway.Credential, error) {
+	ret := _m.Called(consumer)
+
+	var r0 []*gateway.Credential
+	if rf, ok := ret.Get(0).(func(string) []*gateway.Credential); ok {
+		r0 = rf(consumer)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*gateway.Credential)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(consumer)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // Initialize provides a mock function with given fields:
 func (_m *Gateway) Initialize() error {
 	ret := _m.Called()
@@ -106,3 +217,26 @@ func (_m *Gateway) UpdateAPI(name string, api *gateway.API) (*gateway.API, error
 
 	return r0, r1
 }
+
+// UpdateCertificate provides a mock function with given fields: cert
+func (_m *Gateway) UpdateCertificate(cert *gateway.Certificate) (*gateway.Certificate, error) {
+	ret := _m.Called(cert)
+
+	var r0 *gateway.Certificate
+	if rf, ok := ret.Get(0).(func(*gateway.Certificate) *gateway.Certificate); ok {
+		r0 = rf(cert)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*gateway.Certificate)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(*gateway.Certificate) error); ok {
+		r1 = rf(cert)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
@@ -0,0 +1,96 @@
+///////////////////////////////////////////////////////////////////////
+// Copyright (c) 2018 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+///////////////////////////////////////////////////////////////////////
+
+package gateway
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+var paramName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// URITemplate is an API URI, the path parameters of which are whole segments, e.g. /users/{id}. Like plain URIs,
+// templates match the prefixes of the request paths.
+type URITemplate struct {
+	URI    string
+	Params []string
+
+	// Pattern is the regular expression of the template, a named group for each parameter, which is understood by
+	// both Go and PCRE (the Kong router)
+	Pattern string
+
+	regexp *regexp.Regexp
+}
+
+// ParseURITemplate parses an API URI, a URI without parameters is a plain prefix
+func ParseURITemplate(uri string) (*URITemplate, error) {
+	t := &URITemplate{URI: uri}
+	if !strings.ContainsAny(uri, "{}") {
+		return t, nil
+	}
+
+	segments := strings.Split(uri, "/")
+	for i, s := range segments {
+		if !strings.HasPrefix(s, "{") || !strings.HasSuffix(s, "}") {
+			if strings.ContainsAny(s, "{}") {
+				return nil, fmt.Errorf("invalid uri %s: parameters must be whole path segments", uri)
+			}
+			segments[i] = regexp.QuoteMeta(s)
+			continue
+		}
+		name := s[1 : len(s)-1]
+		if !paramName.MatchString(name) {
+			return nil, fmt.Errorf("invalid uri %s: invalid parameter name '%s'", uri, name)
+		}
+		for _, p := range t.Params {
+			if p == name {
+				return nil, fmt.Errorf("invalid uri %s: duplicate parameter '%s'", uri, name)
+			}
+		}
+		t.Params = append(t.Params, name)
+		segments[i] = fmt.Sprintf("(?P<%s>[^/]+)", name)
+	}
+	t.Pattern = strings.Join(segments, "/")
+	t.regexp = regexp.MustCompile("^" + t.Pattern)
+	return t, nil
+}
+
+// IsTemplate returns whether the URI has path parameters
+func (t *URITemplate) IsTemplate() bool {
+	return len(t.Params) > 0
+}
+
+// Match returns the length of the prefix of the path matching the URI, or -1, and the values of the path parameters
+func (t *URITemplate) Match(path string) (int, map[string]string) {
+	if !t.IsTemplate() {
+		if strings.HasPrefix(path, t.URI) {
+			return len(t.URI), nil
+		}
+		return -1, nil
+	}
+	m := t.regexp.FindStringSubmatch(path)
+	if m == nil {
+		return -1, nil
+	}
+	params := make(map[string]string)
+	for i, name := range t.regexp.SubexpNames() {
+		if name != "" {
+			params[name] = m[i]
+		}
+	}
+	return len(m[0]), params
+}
+
+// ValidateURIs checks that the URIs of an API are valid templates
+func ValidateURIs(uris []string) error {
+	for _, uri := range uris {
+		if _, err := ParseURITemplate(uri); err != nil {
+			return err
+		}
+	}
+	return nil
+}
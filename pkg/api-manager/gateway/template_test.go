@@ -0,0 +1,54 @@
+///////////////////////////////////////////////////////////////////////
+// Copyright (c) 2018 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+///////////////////////////////////////////////////////////////////////
+
+package gateway
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseURITemplate(t *testing.T) {
+	plain, err := ParseURITemplate("/hello.world")
+	assert.NoError(t, err)
+	assert.False(t, plain.IsTemplate())
+	assert.Empty(t, plain.Pattern)
+
+	_, err = ParseURITemplate("/users/{id}/posts.{format}")
+	assert.Error(t, err)
+
+	tmpl, err := ParseURITemplate("/users.v1/{id}/posts/{post_id}")
+	assert.NoError(t, err)
+	assert.True(t, tmpl.IsTemplate())
+	assert.Equal(t, []string{"id", "post_id"}, tmpl.Params)
+	assert.Equal(t, `/users\.v1/(?P<id>[^/]+)/posts/(?P<post_id>[^/]+)`, tmpl.Pattern)
+
+	for _, uri := range []string{"/users/{}", "/users/{1d}", "/users/{id", "/users/{id}/{id}"} {
+		_, err := ParseURITemplate(uri)
+		assert.Error(t, err, uri)
+	}
+	assert.NoError(t, ValidateURIs([]string{"/hello", "/users/{id}"}))
+	assert.Error(t, ValidateURIs([]string{"/hello", "/users/{id"}))
+}
+
+func TestURITemplateMatch(t *testing.T) {
+	plain, _ := ParseURITemplate("/hello")
+	n, params := plain.Match("/hello/world")
+	assert.Equal(t, 6, n)
+	assert.Nil(t, params)
+	n, _ = plain.Match("/world")
+	assert.Equal(t, -1, n)
+
+	tmpl, _ := ParseURITemplate("/users/{id}/posts/{post}")
+	n, params = tmpl.Match("/users/42/posts/7/comments")
+	assert.Equal(t, len("/users/42/posts/7"), n)
+	assert.Equal(t, map[string]string{"id": "42", "post": "7"}, params)
+
+	n, _ = tmpl.Match("/users//posts/7")
+	assert.Equal(t, -1, n)
+	n, _ = tmpl.Match("/users.v1/42/posts/7")
+	assert.Equal(t, -1, n)
+}
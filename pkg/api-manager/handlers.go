@@ -16,6 +16,7 @@ import (
 	"github.com/vmware/dispatch/pkg/api-manager/gateway"
 	"github.com/vmware/dispatch/pkg/api-manager/gen/models"
 	"github.com/vmware/dispatch/pkg/api-manager/gen/restapi/operations"
+	"github.com/vmware/dispatch/pkg/api-manager/gen/restapi/operations/consumer"
 	"github.com/vmware/dispatch/pkg/api-manager/gen/restapi/operations/endpoint"
 	"github.com/vmware/dispatch/pkg/controller"
 	entitystore "github.com/vmware/dispatch/pkg/entity-store"
@@ -26,29 +27,70 @@ import (
 // APIManagerFlags are configuration flags for the function manager
 var APIManagerFlags = struct {
 	Config          string `long:"config" description:"Path to Config file" default:"./config.dev.json"`
-	DbFile          string `long:"db-file" description:"Backend DB URL/Path" default:"./db.bolt"`
-	DbBackend       string `long:"db-backend" description:"Backend DB Name" default:"boltdb"`
+	DbFile          string `long:"db-file" description:"Backend DB URL/Path (comma separated endpoints for etcd and consul)" default:"./db.bolt"`
+	DbBackend       string `long:"db-backend" description:"Backend DB Name (boltdb, postgres, etcd or consul)" default:"boltdb"`
 	DbUser          string `long:"db-username" description:"Backend DB Username" default:"dispatch"`
 	DbPassword      string `long:"db-password" description:"Backend DB Password" default:"dispatch"`
 	DbDatabase      string `long:"db-database" description:"Backend DB Name" default:"dispatch"`
 	OrgID           string `long:"organization" description:"(temporary) Static organization id" default:"dispatch"`
 	GatewayHost     string `long:"gateway-host" description:"API Gateway server host" default:"gateway-kong"`
-	Gateway         string `long:"gateway" description:"API Gateway Implementation" default:"kong"`
+	Gateway         string `long:"gateway" description:"API Gateway Implementation (kong or builtin)" default:"kong"`
+	GatewayHTTP     string `long:"gateway-http" description:"Address the built-in API Gateway listens on for http" default:":8081"`
+	GatewayHTTPS    string `long:"gateway-https" description:"Address the built-in API Gateway listens on for https, empty to disable" default:":8443"`
 	FunctionManager string `long:"function-manager" description:"Function Manager Host" default:"function-manager"`
 	ResyncPeriod    int    `long:"resync-period" description:"The time period (in seconds) to sync with api gateway" default:"10"`
+	SecretStore     string `long:"secret-store" description:"Secret store endpoint" default:"localhost:8003"`
 }{}
 
 // Handlers define a set of handlers for API Manager
 type Handlers struct {
 	Store   entitystore.EntityStore
 	watcher controller.Watcher
+	gw      gateway.Gateway
 }
 
 // NewHandlers create a new API Manager Handler
-func NewHandlers(watcher controller.Watcher, store entitystore.EntityStore) *Handlers {
+func NewHandlers(watcher controller.Watcher, store entitystore.EntityStore, gw gateway.Gateway) *Handlers {
 	return &Handlers{
 		Store:   store,
 		watcher: watcher,
+		gw:      gw,
+	}
+}
+
+func rateLimitModelToGateway(m *models.RateLimit) *gateway.RateLimit {
+	if m == nil {
+		return nil
+	}
+	r := gateway.RateLimit{
+		Second: swag.Int64Value(m.Second),
+		Minute: swag.Int64Value(m.Minute),
+		Hour:   swag.Int64Value(m.Hour),
+		Day:    swag.Int64Value(m.Day),
+		Month:  swag.Int64Value(m.Month),
+	}
+	if r == (gateway.RateLimit{}) {
+		return nil
+	}
+	return &r
+}
+
+func rateLimitGatewayToModel(r *gateway.RateLimit) *models.RateLimit {
+	if r == nil {
+		return nil
+	}
+	limit := func(v int64) *int64 {
+		if v == 0 {
+			return nil
+		}
+		return swag.Int64(v)
+	}
+	return &models.RateLimit{
+		Second: limit(r.Second),
+		Minute: limit(r.Minute),
+		Hour:   limit(r.Hour),
+		Day:    limit(r.Day),
+		Month:  limit(r.Month),
 	}
 }
 
@@ -75,6 +117,7 @@ func apiModelOntoEntity(m *models.API) *API {
 			Protocols:      m.Protocols,
 			URIs:           m.Uris,
 			CORS:           m.Cors,
+			RateLimit:      rateLimitModelToGateway(m.RateLimit),
 		},
 	}
 	return &e
@@ -101,6 +144,11 @@ func apiEntityToModel(e *API) *models.API {
 		Status:         models.Status(e.Status),
 		Cors:           e.API.CORS,
 		Tags:           tags,
+		Reason:         e.Reason,
+		RateLimit:      rateLimitGatewayToModel(e.API.RateLimit),
+	}
+	if e.TLS != nil {
+		m.TLSExpiration = e.TLS.Expiration.Unix()
 	}
 	return &m
 }
@@ -126,10 +174,23 @@ func (h *Handlers) ConfigureHandlers(routableAPI middleware.RoutableAPI) {
 	a.EndpointGetAPIHandler = endpoint.GetAPIHandlerFunc(h.getAPI)
 	a.EndpointGetApisHandler = endpoint.GetApisHandlerFunc(h.getAPIs)
 	a.EndpointUpdateAPIHandler = endpoint.UpdateAPIHandlerFunc(h.updateAPI)
+	a.ConsumerAddConsumerHandler = consumer.AddConsumerHandlerFunc(h.addConsumer)
+	a.ConsumerDeleteConsumerHandler = consumer.DeleteConsumerHandlerFunc(h.deleteConsumer)
+	a.ConsumerGetConsumerHandler = consumer.GetConsumerHandlerFunc(h.getConsumer)
+	a.ConsumerGetConsumersHandler = consumer.GetConsumersHandlerFunc(h.getConsumers)
+	a.ConsumerAddCredentialHandler = consumer.AddCredentialHandlerFunc(h.addCredential)
+	a.ConsumerGetCredentialsHandler = consumer.GetCredentialsHandlerFunc(h.getCredentials)
+	a.ConsumerDeleteCredentialHandler = consumer.DeleteCredentialHandlerFunc(h.deleteCredential)
 }
 
 func (h *Handlers) addAPI(params endpoint.AddAPIParams, principal interface{}) middleware.Responder {
 	defer trace.Tracef("name '%s'", *params.Body.Name)()
+	if err := gateway.ValidateURIs(params.Body.Uris); err != nil {
+		return endpoint.NewAddAPIBadRequest().WithPayload(&models.Error{
+			Code:    http.StatusBadRequest,
+			Message: swag.String(err.Error()),
+		})
+	}
 	e := apiModelOntoEntity(params.Body)
 
 	e.Status = entitystore.StatusCREATING
@@ -236,6 +297,9 @@ func (h *Handlers) getAPIs(params endpoint.GetApisParams, principal interface{})
 		Filter: entitystore.FilterExists(),
 	}
 	opts.Filter, err = utils.ParseTags(opts.Filter, params.Tags)
+	if err == nil {
+		err = utils.ParsePage(&opts, params.Limit, params.Continue, params.SortBy)
+	}
 	if err != nil {
 		log.Errorf(err.Error())
 		return endpoint.NewGetAPIBadRequest().WithPayload(
@@ -245,7 +309,7 @@ func (h *Handlers) getAPIs(params endpoint.GetApisParams, principal interface{})
 			})
 	}
 
-	err = h.Store.List(APIManagerFlags.OrgID, opts, &apis)
+	next, err := utils.ListPage(h.Store, APIManagerFlags.OrgID, opts, &apis)
 	if err != nil {
 		log.Errorf("store error when listing apis: %+v", err)
 		return endpoint.NewGetApisDefault(http.StatusInternalServerError).WithPayload(
@@ -258,7 +322,7 @@ func (h *Handlers) getAPIs(params endpoint.GetApisParams, principal interface{})
 	for _, api := range apis {
 		apiModels = append(apiModels, apiEntityToModel(api))
 	}
-	return endpoint.NewGetApisOK().WithPayload(apiModels)
+	return endpoint.NewGetApisOK().WithXContinue(next).WithPayload(apiModels)
 }
 
 func (h *Handlers) updateAPI(params endpoint.UpdateAPIParams, principal interface{}) middleware.Responder {
@@ -288,11 +352,18 @@ func (h *Handlers) updateAPI(params endpoint.UpdateAPIParams, principal interfac
 				Message: swag.String("api not found"),
 			})
 	}
+	if err := gateway.ValidateURIs(params.Body.Uris); err != nil {
+		return endpoint.NewUpdateAPIBadRequest().WithPayload(&models.Error{
+			Code:    http.StatusBadRequest,
+			Message: swag.String(err.Error()),
+		})
+	}
 
 	updatedEntity := apiModelOntoEntity(params.Body)
 	updatedEntity.Status = entitystore.StatusUPDATING
 	updatedEntity.API.ID = e.API.ID
 	updatedEntity.API.CreatedAt = e.API.CreatedAt
+	updatedEntity.TLS = e.TLS
 	if _, err := h.Store.Update(e.Revision, updatedEntity); err != nil {
 		log.Errorf("store error when updating api: %+v", err)
 		return endpoint.NewUpdateAPIInternalServerError().WithPayload(
@@ -12,6 +12,7 @@ import (
 	"github.com/stretchr/testify/assert"
 
 	"github.com/go-openapi/swag"
+	"github.com/vmware/dispatch/pkg/api-manager/gateway/mocks"
 	"github.com/vmware/dispatch/pkg/api-manager/gen/models"
 	"github.com/vmware/dispatch/pkg/api-manager/gen/restapi/operations"
 	apihandler "github.com/vmware/dispatch/pkg/api-manager/gen/restapi/operations/endpoint"
@@ -29,6 +30,7 @@ func assertAPIEqual(t *testing.T, expected *models.API, real *models.API) {
 	assert.Equal(t, expected.Methods, real.Methods)
 	assert.Equal(t, expected.Protocols, real.Protocols)
 	assert.Equal(t, expected.TLS, real.TLS)
+	assert.Equal(t, expected.RateLimit, real.RateLimit)
 }
 
 func addAPI(t *testing.T, a *operations.APIManagerAPI, apiModel *models.API) {
@@ -49,7 +51,7 @@ func TestAPIAddAPI(t *testing.T) {
 
 	a := operations.NewAPIManagerAPI(nil)
 	es := helpers.MakeEntityStore(t)
-	h := NewHandlers(nil, es)
+	h := NewHandlers(nil, es, &mocks.Gateway{})
 
 	helpers.MakeAPI(t, h.ConfigureHandlers, a)
 
@@ -63,15 +65,30 @@ func TestAPIAddAPI(t *testing.T) {
 		Methods:        []string{"GET", "POST"},
 		Protocols:      []string{"http", "https"},
 		TLS:            "testtls",
+		RateLimit:      &models.RateLimit{Minute: swag.Int64(10), Day: swag.Int64(1000)},
 	}
 	addAPI(t, a, reqBody)
+
+	reqBody.Name = swag.String("testTemplate")
+	reqBody.Uris = []string{"/users/{id}"}
+	addAPI(t, a, reqBody)
+
+	reqBody.Name = swag.String("testBadTemplate")
+	reqBody.Uris = []string{"/users/{id"}
+	params := apihandler.AddAPIParams{
+		HTTPRequest: httptest.NewRequest("POST", "/v1/api", nil),
+		Body:        reqBody,
+	}
+	responder := a.EndpointAddAPIHandler.Handle(params, "cookie")
+	var respBody models.Error
+	helpers.HandlerRequest(t, responder, &respBody, 400)
 }
 
 func TestAPIGetAPIs(t *testing.T) {
 
 	a := operations.NewAPIManagerAPI(nil)
 	es := helpers.MakeEntityStore(t)
-	h := NewHandlers(nil, es)
+	h := NewHandlers(nil, es, &mocks.Gateway{})
 
 	helpers.MakeAPI(t, h.ConfigureHandlers, a)
 
@@ -121,7 +138,7 @@ func TestAPIGetAPI(t *testing.T) {
 
 	a := operations.NewAPIManagerAPI(nil)
 	es := helpers.MakeEntityStore(t)
-	h := NewHandlers(nil, es)
+	h := NewHandlers(nil, es, &mocks.Gateway{})
 
 	helpers.MakeAPI(t, h.ConfigureHandlers, a)
 
@@ -152,7 +169,7 @@ func TestAPIDeleteAPI(t *testing.T) {
 
 	a := operations.NewAPIManagerAPI(nil)
 	es := helpers.MakeEntityStore(t)
-	h := NewHandlers(nil, es)
+	h := NewHandlers(nil, es, &mocks.Gateway{})
 
 	helpers.MakeAPI(t, h.ConfigureHandlers, a)
 
@@ -183,7 +200,7 @@ func TestAPIUpdateAPI(t *testing.T) {
 
 	a := operations.NewAPIManagerAPI(nil)
 	es := helpers.MakeEntityStore(t)
-	h := NewHandlers(nil, es)
+	h := NewHandlers(nil, es, &mocks.Gateway{})
 
 	helpers.MakeAPI(t, h.ConfigureHandlers, a)
 
@@ -0,0 +1,97 @@
+///////////////////////////////////////////////////////////////////////
+// Copyright (c) 2018 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+///////////////////////////////////////////////////////////////////////
+
+package apimanager
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	httptransport "github.com/go-openapi/runtime/client"
+	"github.com/go-openapi/strfmt"
+	ewrapper "github.com/pkg/errors"
+
+	secretclient "github.com/vmware/dispatch/pkg/secret-store/gen/client"
+	"github.com/vmware/dispatch/pkg/secret-store/gen/client/secret"
+	"github.com/vmware/dispatch/pkg/trace"
+)
+
+const (
+	// tlsCertKey and tlsKeyKey are the names of the values in a TLS secret, the same as in kubernetes TLS secrets
+	tlsCertKey = "tls.crt"
+	tlsKeyKey  = "tls.key"
+
+	// certificateExpiryWarning is how long before the expiration of a certificate it is reported
+	certificateExpiryWarning = 30 * 24 * time.Hour
+)
+
+// SecretStoreClient returns a client to the secret store
+func SecretStoreClient() *secretclient.SecretStore {
+	defer trace.Trace("SecretStoreClient")()
+	transport := httptransport.New(APIManagerFlags.SecretStore, secretclient.DefaultBasePath, []string{"http"})
+	return secretclient.New(transport, strfmt.Default)
+}
+
+// tlsSecret is the content of a TLS secret
+type tlsSecret struct {
+	Cert        string
+	Key         string
+	Fingerprint string
+	Expiration  time.Time
+}
+
+// getTLSSecret gets a certificate and its key from the secret store, and validates them
+func getTLSSecret(client *secretclient.SecretStore, name string) (*tlsSecret, error) {
+	defer trace.Tracef("secret %s", name)()
+
+	// TODO: use a service account, once identity manager supports them
+	apiKeyAuth := httptransport.APIKeyAuth("cookie", "header", "cookie")
+	resp, err := client.Secret.GetSecret(&secret.GetSecretParams{
+		SecretName: name,
+		Context:    context.Background(),
+	}, apiKeyAuth)
+	if err != nil {
+		return nil, ewrapper.Wrapf(err, "failed to get secret %s from secret store", name)
+	}
+	cert, key := resp.Payload.Secrets[tlsCertKey], resp.Payload.Secrets[tlsKeyKey]
+	if cert == "" || key == "" {
+		return nil, ewrapper.Errorf("secret %s has no %s or %s value", name, tlsCertKey, tlsKeyKey)
+	}
+
+	pair, err := tls.X509KeyPair([]byte(cert), []byte(key))
+	if err != nil {
+		return nil, ewrapper.Wrapf(err, "invalid certificate or key in secret %s", name)
+	}
+	leaf, err := x509.ParseCertificate(pair.Certificate[0])
+	if err != nil {
+		return nil, ewrapper.Wrapf(err, "invalid certificate in secret %s", name)
+	}
+	sum := sha256.Sum256([]byte(cert + key))
+	return &tlsSecret{
+		Cert:        cert,
+		Key:         key,
+		Fingerprint: hex.EncodeToString(sum[:]),
+		Expiration:  leaf.NotAfter,
+	}, nil
+}
+
+// certificateReason reports the expiration of a certificate, when it is near
+func certificateReason(c *TLSCertificate, now time.Time) []string {
+	if c == nil {
+		return nil
+	}
+	if now.After(c.Expiration) {
+		return []string{fmt.Sprintf("tls certificate expired at %s", c.Expiration.UTC().Format(time.RFC3339))}
+	}
+	if now.Add(certificateExpiryWarning).After(c.Expiration) {
+		return []string{fmt.Sprintf("tls certificate expires at %s", c.Expiration.UTC().Format(time.RFC3339))}
+	}
+	return nil
+}
@@ -0,0 +1,138 @@
+///////////////////////////////////////////////////////////////////////
+// Copyright (c) 2018 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+///////////////////////////////////////////////////////////////////////
+
+package apimanager
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/go-openapi/strfmt"
+	"github.com/go-openapi/swag"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/vmware/dispatch/pkg/api-manager/gateway"
+	"github.com/vmware/dispatch/pkg/api-manager/gateway/mocks"
+	entitystore "github.com/vmware/dispatch/pkg/entity-store"
+	fnmocks "github.com/vmware/dispatch/pkg/functions/mocks"
+	secretclient "github.com/vmware/dispatch/pkg/secret-store/gen/client"
+	"github.com/vmware/dispatch/pkg/secret-store/gen/client/secret"
+	secretmodels "github.com/vmware/dispatch/pkg/secret-store/gen/models"
+	helpers "github.com/vmware/dispatch/pkg/testing/api"
+)
+
+func makeTestCertificate(t *testing.T, expiration time.Time) (string, string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.Nil(t, err)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "test.com"},
+		NotBefore:    expiration.Add(-time.Hour),
+		NotAfter:     expiration,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	assert.Nil(t, err)
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	assert.Nil(t, err)
+	cert := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	keyPem := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer})
+	return string(cert), string(keyPem)
+}
+
+func makeTestSecretsClient(values map[string]string) *secretclient.SecretStore {
+	transport := &fnmocks.ClientTransport{}
+	transport.On("Submit", mock.Anything).Return(
+		&secret.GetSecretOK{
+			Payload: &secretmodels.Secret{
+				Name:    swag.String("testtls"),
+				Secrets: values,
+			},
+		}, nil)
+	return secretclient.New(transport, strfmt.Default)
+}
+
+func TestGetTLSSecret(t *testing.T) {
+	expiration := time.Now().Add(time.Hour).Truncate(time.Second)
+	cert, key := makeTestCertificate(t, expiration)
+
+	s, err := getTLSSecret(makeTestSecretsClient(map[string]string{tlsCertKey: cert, tlsKeyKey: key}), "testtls")
+	assert.Nil(t, err)
+	assert.Equal(t, cert, s.Cert)
+	assert.Equal(t, key, s.Key)
+	assert.NotEmpty(t, s.Fingerprint)
+	assert.True(t, expiration.Equal(s.Expiration))
+
+	_, err = getTLSSecret(makeTestSecretsClient(map[string]string{tlsCertKey: cert}), "testtls")
+	assert.NotNil(t, err)
+
+	_, otherKey := makeTestCertificate(t, expiration)
+	_, err = getTLSSecret(makeTestSecretsClient(map[string]string{tlsCertKey: cert, tlsKeyKey: otherKey}), "testtls")
+	assert.NotNil(t, err)
+}
+
+func TestCertificateReason(t *testing.T) {
+	now := time.Now()
+
+	assert.Nil(t, certificateReason(nil, now))
+	assert.Nil(t, certificateReason(&TLSCertificate{Expiration: now.Add(2 * certificateExpiryWarning)}, now))
+	assert.Len(t, certificateReason(&TLSCertificate{Expiration: now.Add(time.Hour)}, now), 1)
+	assert.Len(t, certificateReason(&TLSCertificate{Expiration: now.Add(-time.Hour)}, now), 1)
+}
+
+func TestCtrlUpdateAPITLS(t *testing.T) {
+	expiration := time.Now().Add(time.Hour).Truncate(time.Second)
+	cert, key := makeTestCertificate(t, expiration)
+
+	testAPI := &API{
+		BaseEntity: entitystore.BaseEntity{
+			OrganizationID: testOrgID,
+			Name:           "testTLSAPI",
+			Status:         entitystore.StatusCREATING,
+		},
+		API: gateway.API{
+			Name:     "testTLSAPI",
+			Function: "testTLSAPIFunc",
+			Hosts:    []string{"test.com"},
+			TLS:      "testtls",
+		},
+	}
+
+	mockedGateway := &mocks.Gateway{}
+	mockedGateway.On("UpdateAPI", "testTLSAPI", mock.Anything).Return(&testAPI.API, nil)
+	mockedGateway.On("UpdateCertificate", &gateway.Certificate{Cert: cert, Key: key, SNIs: []string{"test.com"}}).Return(
+		&gateway.Certificate{ID: "cert-id", SNIs: []string{"test.com"}}, nil)
+	es := helpers.MakeEntityStore(t)
+
+	config := &ControllerConfig{
+		ResyncPeriod:   testResyncPeriod,
+		OrganizationID: testOrgID,
+	}
+	ctrl := NewController(config, es, mockedGateway, makeTestSecretsClient(map[string]string{tlsCertKey: cert, tlsKeyKey: key}))
+	watcher := ctrl.Watcher()
+	ctrl.Start()
+	defer ctrl.Shutdown()
+
+	_, err := es.Add(testAPI)
+	assert.Nil(t, err)
+	watcher.OnAction(testAPI)
+
+	time.Sleep(testSleepDuration)
+
+	var actual API
+	es.Get(testOrgID, testAPI.Name, entitystore.Options{}, &actual)
+	assert.Equal(t, entitystore.StatusREADY, actual.Status)
+	assert.Equal(t, "cert-id", actual.TLS.ID)
+	assert.True(t, expiration.Equal(actual.TLS.Expiration))
+	// the certificate expires soon
+	assert.Len(t, actual.Reason, 1)
+}
@@ -28,8 +28,8 @@ import (
 // ApplicationManagerFlags are configuration flags for the function manager
 var ApplicationManagerFlags = struct {
 	Config       string `long:"config" description:"Path to Config file" default:"./config.dev.json"`
-	DbFile       string `long:"db-file" description:"Backend DB URL/Path" default:"./db.bolt"`
-	DbBackend    string `long:"db-backend" description:"Backend DB Name" default:"boltdb"`
+	DbFile       string `long:"db-file" description:"Backend DB URL/Path (comma separated endpoints for etcd and consul)" default:"./db.bolt"`
+	DbBackend    string `long:"db-backend" description:"Backend DB Name (boltdb, postgres, etcd or consul)" default:"boltdb"`
 	DbUser       string `long:"db-username" description:"Backend DB Username" default:"dispatch"`
 	DbPassword   string `long:"db-password" description:"Backend DB Password" default:"dispatch"`
 	DbDatabase   string `long:"db-database" description:"Backend DB Name" default:"dispatch"`
@@ -178,7 +178,15 @@ func (h *Handlers) getApps(params application.GetAppsParams, principal interface
 	opts := entitystore.Options{
 		Filter: entitystore.FilterExists(),
 	}
-	err := h.store.List(ApplicationManagerFlags.OrgID, opts, &apps)
+	if err := utils.ParsePage(&opts, params.Limit, params.Continue, params.SortBy); err != nil {
+		log.Error(err)
+		return application.NewGetAppsDefault(http.StatusBadRequest).WithPayload(
+			&models.Error{
+				Code:    http.StatusBadRequest,
+				Message: swag.String(err.Error()),
+			})
+	}
+	next, err := utils.ListPage(h.store, ApplicationManagerFlags.OrgID, opts, &apps)
 	if err != nil {
 		log.Errorf("store error when listing applications: %+v", err)
 		return application.NewGetAppsDefault(http.StatusInternalServerError).WithPayload(
@@ -191,7 +199,7 @@ func (h *Handlers) getApps(params application.GetAppsParams, principal interface
 	for _, app := range apps {
 		appModels = append(appModels, applicationEntityToModel(app))
 	}
-	return application.NewGetAppsOK().WithPayload(appModels)
+	return application.NewGetAppsOK().WithXContinue(next).WithPayload(appModels)
 }
 
 func (h *Handlers) updateApp(params application.UpdateAppParams, principal interface{}) middleware.Responder {
@@ -64,6 +64,7 @@ type Function struct {
 type Registry struct {
 	RegistryURI  string `json:"uri"`
 	RegistryAuth string `json:"auth"`
+	Insecure     bool   `json:"insecure"`
 }
 
 // Config defines global configurations used in Dispatch
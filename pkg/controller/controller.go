@@ -8,6 +8,7 @@ package controller
 import (
 	"context"
 	"reflect"
+	"sync"
 	"time"
 
 	"github.com/pkg/errors"
@@ -30,8 +31,31 @@ type EntityHandler interface {
 	Sync(organizationID string, resyncPeriod time.Duration) ([]entitystore.Entity, error)
 }
 
+// WatchFilterer is implemented by entity handlers which watch the entity store with their own filter. A nil filter
+// disables the watch, the entities being then only processed through the watcher and the periodic sync.
+type WatchFilterer interface {
+	WatchFilter() entitystore.Filter
+}
+
+// LeadershipHandler is implemented by entity handlers keeping state on the leader only, e.g. the subscriptions to
+// the event queue. With a leader election, the handler is notified when the replica starts leading, before the
+// reconcile loop starts, and when it stops leading, once the reconcile loop stopped.
+type LeadershipHandler interface {
+	LeadershipChanged(organizationID string, leading bool) error
+}
+
 const defaultWorkers = 1
 
+// watchRetryPeriod is the time to wait before watching the entity store again, after the watch failed
+const watchRetryPeriod = 5 * time.Second
+
+const (
+	// claimOwnerKey is the spec key of the replica which claimed an entity being processed
+	claimOwnerKey = "dispatch.claim.owner"
+	// claimStatusKey is the spec key of the status of an entity before it was claimed
+	claimStatusKey = "dispatch.claim.status"
+)
+
 // Options defines controller configuration
 type Options struct {
 	OrganizationID string
@@ -39,6 +63,15 @@ type Options struct {
 
 	ResyncPeriod time.Duration
 	Workers      int
+
+	// Store is watched for the changes of the entities, which are processed right away instead of at the next
+	// periodic sync. The changes made by other processes are then noticed as well.
+	Store entitystore.EntityStore
+
+	// Elector elects the replica running the reconcile loop, i.e. the store watch and the periodic sync. The entities
+	// of the handlers watching the store are then only processed by the leader, which claims them in the store while
+	// processing, for another replica to take them over if it crashes. The controller always leads if nil.
+	Elector LeaderElector
 }
 
 // Watcher channel type
@@ -65,13 +98,42 @@ type Controller interface {
 	AddEntityHandler(h EntityHandler)
 }
 
+// entityKey identifies an entity across its revisions
+type entityKey struct {
+	entityType     reflect.Type
+	organizationID string
+	name           string
+}
+
+func keyOf(e entitystore.Entity) entityKey {
+	return entityKey{reflect.TypeOf(e), e.GetOrganizationID(), e.GetName()}
+}
+
+// inFlight is an entity being processed
+type inFlight struct {
+	count    int
+	revision uint64
+	status   entitystore.Status
+}
+
 // DefaultController defines a struct for a generic controller
 type DefaultController struct {
 	done    chan bool
 	watcher chan entitystore.Entity
+	watched chan entitystore.Entity
 	options Options
 
 	entityHandlers map[reflect.Type]EntityHandler
+
+	// the entities being processed, and the revisions of the entities processed. Both are used to skip the changes
+	// notified by the store watch which are already (being) processed, including the changes made by the handlers.
+	mu        sync.Mutex
+	inFlight  map[entityKey]*inFlight
+	revisions map[entityKey]uint64
+	leading   bool
+	// claiming is the number of entities claimed in the store being processed, drained is signaled when it drops to 0
+	claiming int
+	drained  *sync.Cond
 }
 
 // NewController creates a new controller
@@ -82,13 +144,19 @@ func NewController(options Options) Controller {
 		options.Workers = defaultWorkers
 	}
 
-	return &DefaultController{
+	dc := &DefaultController{
 		done:    make(chan bool),
 		watcher: make(chan entitystore.Entity),
+		watched: make(chan entitystore.Entity),
 		options: options,
 
 		entityHandlers: map[reflect.Type]EntityHandler{},
+
+		inFlight:  map[entityKey]*inFlight{},
+		revisions: map[entityKey]uint64{},
 	}
+	dc.drained = sync.NewCond(&dc.mu)
+	return dc
 }
 
 // Start starts the controller watch loop
@@ -147,6 +215,344 @@ func (dc *DefaultController) processItem(e entitystore.Entity) error {
 	return err
 }
 
+// claim marks an entity as being processed. It returns false if the entity must be skipped: a watched entity is
+// skipped if it is being processed or if its revision was already processed, other entities only if the same revision
+// and status are being processed.
+func (dc *DefaultController) claim(e entitystore.Entity, watched bool) bool {
+	dc.mu.Lock()
+	defer dc.mu.Unlock()
+
+	key := keyOf(e)
+	if f, ok := dc.inFlight[key]; ok {
+		if watched || f.revision == e.GetRevision() && f.status == e.GetStatus() {
+			return false
+		}
+	}
+	if revision, ok := dc.revisions[key]; ok && watched && e.GetRevision() <= revision {
+		return false
+	}
+	f, ok := dc.inFlight[key]
+	if !ok {
+		f = &inFlight{}
+		dc.inFlight[key] = f
+	}
+	f.count++
+	f.revision = e.GetRevision()
+	f.status = e.GetStatus()
+	return true
+}
+
+// release marks an entity as processed. The handlers update the entity they process, so revision is the one of
+// their last change.
+func (dc *DefaultController) release(key entityKey, revision uint64, deleted bool) {
+	dc.mu.Lock()
+	defer dc.mu.Unlock()
+
+	if f, ok := dc.inFlight[key]; ok {
+		f.count--
+		if f.count == 0 {
+			delete(dc.inFlight, key)
+		}
+	}
+	if deleted {
+		// the entity may be created again, starting over its revisions
+		delete(dc.revisions, key)
+		return
+	}
+	dc.revisions[key] = revision
+}
+
+// forget drops the processed revision of a deleted entity
+func (dc *DefaultController) forget(e entitystore.Entity) {
+	dc.mu.Lock()
+	defer dc.mu.Unlock()
+
+	delete(dc.revisions, keyOf(e))
+}
+
+// processing reports whether an entity is being processed by this replica
+func (dc *DefaultController) processing(e entitystore.Entity) bool {
+	dc.mu.Lock()
+	defer dc.mu.Unlock()
+
+	_, ok := dc.inFlight[keyOf(e)]
+	return ok
+}
+
+func (dc *DefaultController) setLeading(leading bool) {
+	dc.mu.Lock()
+	defer dc.mu.Unlock()
+
+	dc.leading = leading
+}
+
+// startClaim counts an entity to claim in the store, it returns false if this replica does not lead anymore
+func (dc *DefaultController) startClaim() bool {
+	dc.mu.Lock()
+	defer dc.mu.Unlock()
+
+	if !dc.leading {
+		return false
+	}
+	dc.claiming++
+	return true
+}
+
+// endClaim counts an entity claimed in the store as processed
+func (dc *DefaultController) endClaim() {
+	dc.mu.Lock()
+	defer dc.mu.Unlock()
+
+	dc.claiming--
+	if dc.claiming == 0 {
+		dc.drained.Broadcast()
+	}
+}
+
+// drainClaims waits for the entities claimed in the store to be processed, once this replica stopped leading
+func (dc *DefaultController) drainClaims() {
+	dc.mu.Lock()
+	defer dc.mu.Unlock()
+
+	for dc.claiming > 0 {
+		dc.drained.Wait()
+	}
+}
+
+// accepts reports whether an entity is processed by this replica: the entities of the handlers claiming them are
+// only processed by the leader, which is notified by the store watch whichever replica changed them
+func (dc *DefaultController) accepts(e entitystore.Entity) bool {
+	dc.mu.Lock()
+	leading := dc.leading
+	dc.mu.Unlock()
+
+	h, ok := dc.entityHandlers[reflect.TypeOf(e)]
+	return leading || !ok || !dc.claims(h)
+}
+
+// claims reports whether the entities of a handler are claimed in the store while processed, i.e. if there is a
+// leader election and the handler watches the store
+func (dc *DefaultController) claims(h EntityHandler) bool {
+	return dc.options.Elector != nil && dc.options.Store != nil && watchFilter(h) != nil
+}
+
+// restoreClaim restores the status of an entity before it was claimed, and removes the claim from its spec
+func restoreClaim(e entitystore.Entity) {
+	spec := entitystore.Spec{}
+	for k, v := range e.GetSpec() {
+		if k != claimOwnerKey && k != claimStatusKey {
+			spec[k] = v
+		}
+	}
+	if status, ok := e.GetSpec()[claimStatusKey]; ok {
+		e.SetStatus(entitystore.Status(status))
+	}
+	e.SetSpec(spec)
+}
+
+// claimInStore marks an entity as in transit, owned by this replica. It fails if the entity was changed since, e.g.
+// claimed by another replica. Only the revision of the entity is changed, for the handler to update it.
+func (dc *DefaultController) claimInStore(e entitystore.Entity) error {
+	status, spec := e.GetStatus(), e.GetSpec()
+	claim := entitystore.Spec{}
+	for k, v := range spec {
+		claim[k] = v
+	}
+	claim[claimOwnerKey] = dc.options.Elector.ID()
+	claim[claimStatusKey] = string(status)
+	e.SetSpec(claim)
+	e.SetStatus(entitystore.StatusINTRANSIT)
+
+	_, err := dc.options.Store.Update(e.GetRevision(), e)
+	e.SetStatus(status)
+	e.SetSpec(spec)
+	return err
+}
+
+// unclaimInStore restores the status of an entity still claimed by this replica, which the handler did not update.
+// It returns the revision of the entity.
+func (dc *DefaultController) unclaimInStore(e entitystore.Entity) uint64 {
+	filter := entitystore.FilterEverything().Add(
+		entitystore.FilterStat{
+			Scope:   entitystore.FilterScopeField,
+			Subject: "Name",
+			Verb:    entitystore.FilterVerbEqual,
+			Object:  e.GetName(),
+		})
+	entities, err := DefaultSync(dc.options.Store, reflect.TypeOf(e), e.GetOrganizationID(), 0, filter)
+	if err != nil {
+		log.Errorf("error getting entity %s to remove its claim: %v", e.GetName(), err)
+		return e.GetRevision()
+	}
+	if len(entities) == 0 {
+		return e.GetRevision()
+	}
+	current := entities[0]
+	if current.GetStatus() != entitystore.StatusINTRANSIT || current.GetSpec()[claimOwnerKey] != dc.options.Elector.ID() {
+		return e.GetRevision()
+	}
+	restoreClaim(current)
+	if _, err := dc.options.Store.Update(current.GetRevision(), current); err != nil {
+		log.Errorf("error removing the claim of entity %s: %v", e.GetName(), err)
+		return e.GetRevision()
+	}
+	return current.GetRevision()
+}
+
+// claimed returns the entities in transit which were claimed by replicas not processing them anymore, e.g. a previous
+// leader which crashed, with the status they had before being claimed. It is only called by the leader: the previous
+// leaders processed their claims before giving the lease up, or stopped leading before their lease expired and their
+// changes to the entities taken over are rejected as stale.
+func (dc *DefaultController) claimed(h EntityHandler) ([]entitystore.Entity, error) {
+	filter := entitystore.FilterEverything().Add(
+		entitystore.FilterStat{
+			Scope:   entitystore.FilterScopeField,
+			Subject: "Status",
+			Verb:    entitystore.FilterVerbIn,
+			Object:  []entitystore.Status{entitystore.StatusINTRANSIT},
+		})
+	entities, err := DefaultSync(dc.options.Store, h.Type(), dc.options.OrganizationID, 0, filter)
+	if err != nil {
+		return nil, errors.Wrapf(err, "error listing %v entities in transit", h.Type())
+	}
+	var claimed []entitystore.Entity
+	for _, e := range entities {
+		owner, ok := e.GetSpec()[claimOwnerKey]
+		if !ok || dc.processing(e) {
+			continue
+		}
+		log.Infof("taking over entity %s claimed by %s", e.GetName(), owner)
+		restoreClaim(e)
+		claimed = append(claimed, e)
+	}
+	return claimed, nil
+}
+
+// process processes an entity, unless it is skipped (see claim). With a leader election, the entity is claimed in
+// the store while processed.
+func (dc *DefaultController) process(e entitystore.Entity, watched bool) {
+	if !dc.claim(e, watched) {
+		log.Debugf("skipping entity %s, already processed", e.GetName())
+		return
+	}
+	key := keyOf(e)
+	deleted := e.GetDelete() || e.GetStatus() == entitystore.StatusDELETING
+	revision := e.GetRevision()
+	defer func() { dc.release(key, revision, deleted) }()
+
+	h, ok := dc.entityHandlers[reflect.TypeOf(e)]
+	claimed := ok && dc.claims(h)
+	if claimed {
+		if !dc.startClaim() {
+			log.Debugf("skipping entity %s, processed by the leader", e.GetName())
+			return
+		}
+		defer dc.endClaim()
+		if err := dc.claimInStore(e); err != nil {
+			log.Debugf("skipping entity %s, changed or claimed by another replica: %v", e.GetName(), err)
+			return
+		}
+	}
+	claimRevision := e.GetRevision()
+
+	if err := dc.processItem(e); err != nil {
+		log.Error(err)
+	}
+	revision = e.GetRevision()
+	if claimed && revision == claimRevision {
+		revision = dc.unclaimInStore(e)
+	}
+}
+
+func defaultWatchFilter() entitystore.Filter {
+	return entitystore.FilterEverything().Add(
+		entitystore.FilterStat{
+			Scope:   entitystore.FilterScopeField,
+			Subject: "Status",
+			Verb:    entitystore.FilterVerbIn,
+			Object: []entitystore.Status{
+				entitystore.StatusINITIALIZED, entitystore.StatusUPDATING, entitystore.StatusDELETING,
+			},
+		})
+}
+
+// watchFilter returns the filter of the entities watched by a handler, nil if it does not watch the store
+func watchFilter(h EntityHandler) entitystore.Filter {
+	if f, ok := h.(WatchFilterer); ok {
+		return f.WatchFilter()
+	}
+	return defaultWatchFilter()
+}
+
+// watch hands over the pending entities and the entities changed in the store to the workers, until stopped. The
+// watch starts over when it ends, e.g. when the connection to the store was lost, right away unless it ended or
+// failed within watchRetryPeriod. It does not if the store does not support it.
+func (dc *DefaultController) watch(h EntityHandler, stopChan <-chan struct{}) {
+	defer trace.Trace("")()
+
+	filter := watchFilter(h)
+	if filter == nil {
+		return
+	}
+
+	for {
+		events, err := dc.options.Store.Watch(dc.options.OrganizationID, h.Type(), filter, stopChan)
+		if err == entitystore.ErrWatchNotSupported {
+			log.Infof("entity store watch not supported, %v entities are processed at the periodic sync", h.Type())
+			return
+		}
+		if err != nil {
+			log.Errorf("error watching %v entities: %v", h.Type(), err)
+		} else {
+			started := time.Now()
+			if !dc.handleEvents(h, filter, events, stopChan) {
+				return
+			}
+			if time.Since(started) >= watchRetryPeriod {
+				continue
+			}
+		}
+		select {
+		case <-stopChan:
+			return
+		case <-time.After(watchRetryPeriod):
+		}
+	}
+}
+
+// handleEvents hands over the entities to the workers until the watch ends. It returns false if stopped.
+func (dc *DefaultController) handleEvents(h EntityHandler, filter entitystore.Filter, events <-chan entitystore.Event, stopChan <-chan struct{}) bool {
+	handOver := func(e entitystore.Entity) bool {
+		select {
+		case dc.watched <- e:
+			return true
+		case <-stopChan:
+			return false
+		}
+	}
+
+	// the entities changed before the watch started are not notified, so they are listed once watching
+	entities, err := DefaultSync(dc.options.Store, h.Type(), dc.options.OrganizationID, 0, filter)
+	if err != nil {
+		log.Errorf("error listing %v entities: %v", h.Type(), err)
+	}
+	for _, e := range entities {
+		if !handOver(e) {
+			return false
+		}
+	}
+	for event := range events {
+		if event.Type == entitystore.EventDeleted {
+			dc.forget(event.Entity)
+			continue
+		}
+		if !handOver(event.Entity) {
+			return false
+		}
+	}
+	return true
+}
+
 func defaultSyncFilter(resyncPeriod time.Duration) entitystore.Filter {
 	defer trace.Trace("")()
 
@@ -199,6 +605,13 @@ func (dc *DefaultController) sync() error {
 		if err != nil {
 			return err
 		}
+		if dc.claims(handler) {
+			claimed, err := dc.claimed(handler)
+			if err != nil {
+				return err
+			}
+			entities = append(entities, claimed...)
+		}
 		for _, e := range entities {
 			if err := sem.Acquire(ctx, 1); err != nil {
 				log.Printf("Failed to acquire semaphore: %v", err)
@@ -207,22 +620,82 @@ func (dc *DefaultController) sync() error {
 			go func(e entitystore.Entity) {
 				defer sem.Release(1)
 				log.Printf("sync: processing entity %s", e.GetName())
-				if err := dc.processItem(e); err != nil {
-					log.Error(err)
-				}
+				dc.process(e, false)
 			}(e)
 		}
 	}
 	return nil
 }
 
+// notifyLeadership notifies the handlers of a change of the leadership of this replica
+func (dc *DefaultController) notifyLeadership(leading bool) {
+	if dc.options.Elector == nil {
+		return
+	}
+	for _, handler := range dc.entityHandlers {
+		if h, ok := handler.(LeadershipHandler); ok {
+			if err := h.LeadershipChanged(dc.options.OrganizationID, leading); err != nil {
+				log.Error(err)
+			}
+		}
+	}
+}
+
+// lead runs the reconcile loop of the leader, i.e. the store watches and the periodic sync, until the returned
+// function is called
+func (dc *DefaultController) lead() func() {
+	defer trace.Trace("")()
+
+	stop := make(chan struct{})
+	var wg sync.WaitGroup
+	if dc.options.Store != nil {
+		for _, handler := range dc.entityHandlers {
+			wg.Add(1)
+			go func(h EntityHandler) {
+				defer wg.Done()
+				dc.watch(h, stop)
+			}(handler)
+		}
+	}
+
+	resync := func() {
+		defer trace.Trace("")()
+
+		log.Printf("periodic syncing with the underlying driver")
+		if err := dc.sync(); err != nil {
+			log.Error(err)
+		}
+	}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+
+		if dc.options.Elector != nil {
+			// take over the entities claimed by the previous leader right away
+			resync()
+		}
+		resyncTicker := time.NewTicker(dc.options.ResyncPeriod)
+		defer resyncTicker.Stop()
+		for {
+			select {
+			case <-resyncTicker.C:
+				resync()
+			case <-stop:
+				return
+			}
+		}
+	}()
+
+	return func() {
+		close(stop)
+		wg.Wait()
+	}
+}
+
 // run runs the control loop
 func (dc *DefaultController) run(stopChan <-chan bool) {
 	defer trace.Trace("")()
 
-	resyncTicker := time.NewTicker(dc.options.ResyncPeriod)
-	defer resyncTicker.Stop()
-
 	defer close(dc.watcher)
 
 	// Start a worker pool.  The pool scales up to dc.options.Workers.
@@ -231,33 +704,72 @@ func (dc *DefaultController) run(stopChan <-chan bool) {
 		sem := semaphore.NewWeighted(int64(dc.options.Workers))
 		ctx := context.Background()
 
-		for entity := range dc.watcher {
+		for {
+			var entity entitystore.Entity
+			var watched, ok bool
+			select {
+			case entity, ok = <-dc.watcher:
+				if !ok {
+					return
+				}
+			case entity = <-dc.watched:
+				watched = true
+			}
+			if !dc.accepts(entity) {
+				log.Debugf("skipping entity %s, processed by the leader", entity.GetName())
+				continue
+			}
 			if err := sem.Acquire(ctx, 1); err != nil {
 				log.Printf("Failed to acquire semaphore: %v", err)
 				break
 			}
-			go func(e entitystore.Entity) {
+			go func(e entitystore.Entity, watched bool) {
 				defer sem.Release(1)
-				log.Printf("received event=%s entity=%s", e.GetStatus(), e.GetName())
-				if err := dc.processItem(e); err != nil {
-					log.Error(err)
-				}
-			}(entity)
+				log.Printf("received event=%s entity=%s watched=%t", e.GetStatus(), e.GetName(), watched)
+				dc.process(e, watched)
+			}(entity, watched)
 		}
 	}()
 
-	go func() {
-		for range resyncTicker.C {
-			func() {
-				defer trace.Trace("")()
-
-				log.Printf("periodic syncing with the underlying driver")
-				if err := dc.sync(); err != nil {
-					log.Error(err)
-				}
-			}()
+	var elector LeaderElector = standalone{}
+	if dc.options.Elector != nil {
+		elector = dc.options.Elector
+	}
+	stopElection := make(chan struct{})
+	leadership := elector.Run(stopElection)
+
+	// The reconcile loop is stopped before the watcher channel is closed. A replica which stops leading waits for
+	// the entities it claimed to be processed, so that the next leader does not take them over meanwhile.
+	var stopLeading func()
+	handleLeadership := func(leading bool) {
+		dc.setLeading(leading)
+		if leading && stopLeading == nil {
+			dc.notifyLeadership(true)
+			stopLeading = dc.lead()
+		} else if !leading && stopLeading != nil {
+			stopLeading()
+			stopLeading = nil
+			dc.drainClaims()
+			dc.notifyLeadership(false)
 		}
-	}()
-
-	<-stopChan
+	}
+	for {
+		select {
+		case leading, ok := <-leadership:
+			if !ok {
+				// the election ended before being stopped
+				handleLeadership(false)
+				<-stopChan
+				return
+			}
+			handleLeadership(leading)
+		case <-stopChan:
+			// the lease is released once the claimed entities are processed
+			handleLeadership(false)
+			close(stopElection)
+			for range leadership {
+			}
+			return
+		}
+	}
 }
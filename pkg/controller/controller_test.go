@@ -11,9 +11,11 @@ import (
 	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	"github.com/vmware/dispatch/pkg/entity-store"
 	helpers "github.com/vmware/dispatch/pkg/testing/api"
+	"github.com/vmware/dispatch/pkg/testing/kv"
 	"github.com/vmware/dispatch/pkg/trace"
 )
 
@@ -113,3 +115,334 @@ func TestController(t *testing.T) {
 		t.Logf("deleted %s", name)
 	}
 }
+
+func TestControllerWatch(t *testing.T) {
+	server := kv.NewEtcdServer()
+	defer server.Close()
+	store, err := entitystore.NewFromBackend(entitystore.BackendConfig{
+		Backend: "etcd",
+		Address: server.URL,
+		Bucket:  "test",
+	})
+	require.NoError(t, err)
+
+	deleteCounter := make(chan string, 100)
+	addCounter := make(chan string, 100)
+
+	controller := NewController(Options{
+		OrganizationID: testOrgID,
+		// the entities must be processed by the watch, long before the periodic sync
+		ResyncPeriod: time.Hour,
+		Store:        store,
+	})
+	controller.AddEntityHandler(&testEntityHandler{t: t, store: store, addCounter: addCounter, deleteCounter: deleteCounter})
+	controller.Start()
+	defer controller.Shutdown()
+
+	// the entity is changed by another process, the watcher is not notified
+	ent := &testEntity{entitystore.BaseEntity{
+		OrganizationID: testOrgID,
+		Name:           "test-watched",
+		Status:         entitystore.StatusINITIALIZED,
+	}}
+	_, err = store.Add(ent)
+	require.NoError(t, err)
+
+	select {
+	case name := <-addCounter:
+		assert.Equal(t, ent.Name, name)
+	case <-time.After(testSleepDuration):
+		t.Fatal("the added entity was not processed")
+	}
+
+	ent.Status = entitystore.StatusDELETING
+	_, err = store.Update(ent.Revision, ent)
+	require.NoError(t, err)
+
+	select {
+	case name := <-deleteCounter:
+		assert.Equal(t, ent.Name, name)
+	case <-time.After(testSleepDuration):
+		t.Fatal("the updated entity was not processed")
+	}
+
+	// each change is processed once
+	time.Sleep(testResyncPeriod)
+	assert.Len(t, addCounter, 0)
+	assert.Len(t, deleteCounter, 0)
+}
+
+// testElector is a leader election which always or never elects the replica
+type testElector struct {
+	id      string
+	leading bool
+}
+
+func (e *testElector) ID() string {
+	return e.id
+}
+
+func (e *testElector) Run(stopCh <-chan struct{}) <-chan bool {
+	leadership := make(chan bool, 1)
+	leadership <- e.leading
+	go func() {
+		defer close(leadership)
+		<-stopCh
+	}()
+	return leadership
+}
+
+// claimCheckingHandler reports the stored status of the entities being added, and the leadership changes
+type claimCheckingHandler struct {
+	*testEntityHandler
+	claims     chan entitystore.Spec
+	leadership chan bool
+}
+
+func (h *claimCheckingHandler) LeadershipChanged(organizationID string, leading bool) error {
+	h.leadership <- leading
+	return nil
+}
+
+func (h *claimCheckingHandler) Add(obj entitystore.Entity) error {
+	stored := &testEntity{}
+	if err := h.store.Get(obj.GetOrganizationID(), obj.GetName(), entitystore.Options{}, stored); err != nil {
+		return err
+	}
+	assert.Equal(h.t, entitystore.StatusINTRANSIT, stored.Status)
+	assert.NotEqual(h.t, entitystore.StatusINTRANSIT, obj.GetStatus())
+	h.claims <- stored.Spec
+	return h.testEntityHandler.Add(obj)
+}
+
+func getTestEntity(t *testing.T, store entitystore.EntityStore, name string) *testEntity {
+	ent := &testEntity{}
+	require.NoError(t, store.Get(testOrgID, name, entitystore.Options{}, ent))
+	return ent
+}
+
+func TestControllerClaims(t *testing.T) {
+	store := helpers.MakeEntityStore(t)
+
+	// claimed by a replica which crashed while processing it
+	crashed := &testEntity{entitystore.BaseEntity{
+		OrganizationID: testOrgID,
+		Name:           "test-crashed",
+		Status:         entitystore.StatusINTRANSIT,
+		Spec:           entitystore.Spec{claimOwnerKey: "replica-a", claimStatusKey: string(entitystore.StatusCREATING)},
+	}}
+	_, err := store.Add(crashed)
+	require.NoError(t, err)
+
+	addCounter := make(chan string, 100)
+	claims := make(chan entitystore.Spec, 100)
+	leadership := make(chan bool, 2)
+	controller := NewController(Options{
+		OrganizationID: testOrgID,
+		ResyncPeriod:   time.Hour,
+		Store:          store,
+		Elector:        &testElector{id: "replica-b", leading: true},
+	})
+	controller.AddEntityHandler(&claimCheckingHandler{
+		testEntityHandler: &testEntityHandler{t: t, store: store, addCounter: addCounter, deleteCounter: make(chan string, 100)},
+		claims:            claims,
+		leadership:        leadership,
+	})
+	watcher := controller.Watcher()
+	controller.Start()
+
+	select {
+	case name := <-addCounter:
+		assert.Equal(t, crashed.Name, name)
+	case <-time.After(testSleepDuration):
+		t.Fatal("the claimed entity was not taken over")
+	}
+	claim := <-claims
+	assert.Equal(t, "replica-b", claim[claimOwnerKey])
+	assert.Equal(t, string(entitystore.StatusCREATING), claim[claimStatusKey])
+
+	ent := &testEntity{entitystore.BaseEntity{
+		OrganizationID: testOrgID,
+		Name:           "test-claimed",
+		Status:         entitystore.StatusCREATING,
+	}}
+	_, err = store.Add(ent)
+	require.NoError(t, err)
+	watcher.OnAction(ent)
+	select {
+	case name := <-addCounter:
+		assert.Equal(t, ent.Name, name)
+	case <-time.After(testSleepDuration):
+		t.Fatal("the entity was not processed")
+	}
+	claim = <-claims
+	assert.Equal(t, "replica-b", claim[claimOwnerKey])
+
+	// the handler did not update the entities, their status is restored once processed
+	for _, name := range []string{crashed.Name, ent.Name} {
+		var stored *testEntity
+		for i := 0; i < 50; i++ {
+			stored = getTestEntity(t, store, name)
+			if stored.Status != entitystore.StatusINTRANSIT {
+				break
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+		assert.Equal(t, entitystore.StatusCREATING, stored.Status)
+		assert.NotContains(t, stored.Spec, claimOwnerKey)
+		assert.NotContains(t, stored.Spec, claimStatusKey)
+	}
+
+	controller.Shutdown()
+	for _, expected := range []bool{true, false} {
+		select {
+		case leading := <-leadership:
+			assert.Equal(t, expected, leading)
+		case <-time.After(testSleepDuration):
+			t.Fatalf("the handler was not notified of the leadership %t", expected)
+		}
+	}
+}
+
+func TestControllerFollower(t *testing.T) {
+	store := helpers.MakeEntityStore(t)
+
+	addCounter := make(chan string, 100)
+	controller := NewController(Options{
+		OrganizationID: testOrgID,
+		ResyncPeriod:   testResyncPeriod,
+		Store:          store,
+		Elector:        &testElector{id: "replica-b"},
+	})
+	controller.AddEntityHandler(&testEntityHandler{t: t, store: store, addCounter: addCounter, deleteCounter: make(chan string, 100)})
+	watcher := controller.Watcher()
+	controller.Start()
+	defer controller.Shutdown()
+
+	// the entities are processed by the leader, neither notified nor synced on followers
+	ent := &testEntity{entitystore.BaseEntity{
+		OrganizationID: testOrgID,
+		Name:           "test-follower",
+		Status:         entitystore.StatusCREATING,
+	}}
+	_, err := store.Add(ent)
+	require.NoError(t, err)
+	watcher.OnAction(ent)
+
+	time.Sleep(testSleepDuration)
+	assert.Len(t, addCounter, 0)
+	assert.Equal(t, entitystore.StatusCREATING, getTestEntity(t, store, ent.Name).Status)
+}
+
+// switchElector is a leader election whose leadership changes are sent by the test
+type switchElector struct {
+	id      string
+	changes chan bool
+}
+
+func (e *switchElector) ID() string {
+	return e.id
+}
+
+func (e *switchElector) Run(stopCh <-chan struct{}) <-chan bool {
+	leadership := make(chan bool, 1)
+	go func() {
+		defer close(leadership)
+		for {
+			select {
+			case leading := <-e.changes:
+				leadership <- leading
+			case <-stopCh:
+				return
+			}
+		}
+	}()
+	return leadership
+}
+
+// blockingHandler blocks the entities being added until unblocked, and reports the leadership changes
+type blockingHandler struct {
+	*testEntityHandler
+	started    chan string
+	unblock    chan struct{}
+	leadership chan bool
+}
+
+func (h *blockingHandler) LeadershipChanged(organizationID string, leading bool) error {
+	h.leadership <- leading
+	return nil
+}
+
+func (h *blockingHandler) Add(obj entitystore.Entity) error {
+	h.started <- obj.GetName()
+	<-h.unblock
+	return nil
+}
+
+func TestControllerStepDown(t *testing.T) {
+	store := helpers.MakeEntityStore(t)
+
+	elector := &switchElector{id: "replica-b", changes: make(chan bool)}
+	handler := &blockingHandler{
+		testEntityHandler: &testEntityHandler{t: t, store: store},
+		started:           make(chan string, 10),
+		unblock:           make(chan struct{}),
+		leadership:        make(chan bool, 10),
+	}
+	controller := NewController(Options{
+		OrganizationID: testOrgID,
+		ResyncPeriod:   time.Hour,
+		Store:          store,
+		Elector:        elector,
+	})
+	controller.AddEntityHandler(handler)
+	watcher := controller.Watcher()
+	controller.Start()
+	defer controller.Shutdown()
+
+	elector.changes <- true
+	assert.True(t, <-handler.leadership)
+
+	ent := &testEntity{entitystore.BaseEntity{
+		OrganizationID: testOrgID,
+		Name:           "test-step-down",
+		Status:         entitystore.StatusCREATING,
+	}}
+	_, err := store.Add(ent)
+	require.NoError(t, err)
+	watcher.OnAction(ent)
+	select {
+	case name := <-handler.started:
+		assert.Equal(t, ent.Name, name)
+	case <-time.After(testSleepDuration):
+		t.Fatal("the entity was not processed")
+	}
+
+	// stepping down waits for the claimed entity to be processed
+	elector.changes <- false
+	select {
+	case <-handler.leadership:
+		t.Fatal("stepped down while processing a claimed entity")
+	case <-time.After(100 * time.Millisecond):
+	}
+	close(handler.unblock)
+	select {
+	case leading := <-handler.leadership:
+		assert.False(t, leading)
+	case <-time.After(testSleepDuration):
+		t.Fatal("the handler was not notified of the leadership false")
+	}
+	assert.Equal(t, entitystore.StatusCREATING, getTestEntity(t, store, ent.Name).Status)
+
+	// entities are not claimed anymore
+	other := &testEntity{entitystore.BaseEntity{
+		OrganizationID: testOrgID,
+		Name:           "test-follower",
+		Status:         entitystore.StatusCREATING,
+	}}
+	_, err = store.Add(other)
+	require.NoError(t, err)
+	watcher.OnAction(other)
+	time.Sleep(100 * time.Millisecond)
+	assert.Len(t, handler.started, 0)
+}
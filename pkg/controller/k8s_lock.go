@@ -0,0 +1,141 @@
+///////////////////////////////////////////////////////////////////////
+// Copyright (c) 2018 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+///////////////////////////////////////////////////////////////////////
+
+package controller
+
+import (
+	"encoding/json"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+
+	"github.com/vmware/dispatch/pkg/trace"
+)
+
+// leaderAnnotation is the annotation of the config map holding the lease, as used by the kubernetes controllers
+const leaderAnnotation = "control-plane.alpha.kubernetes.io/leader"
+
+// configMaps is the subset of the config maps client used by the lock
+type configMaps interface {
+	Get(name string, options metav1.GetOptions) (*corev1.ConfigMap, error)
+	Create(*corev1.ConfigMap) (*corev1.ConfigMap, error)
+	Update(*corev1.ConfigMap) (*corev1.ConfigMap, error)
+}
+
+// KubernetesLeaseLock is a lease lock stored in an annotation of a kubernetes config map. The config map is replaced
+// with its resource version, so that a single replica acquires the lease.
+type KubernetesLeaseLock struct {
+	configMaps configMaps
+	name       string
+}
+
+// NewKubernetesLeaseLock creates a lease lock stored in the config map name of namespace. The in-cluster config is
+// used if k8sConfig is empty.
+func NewKubernetesLeaseLock(k8sConfig, namespace, name string) (*KubernetesLeaseLock, error) {
+	var err error
+	var config *rest.Config
+	if k8sConfig == "" {
+		config, err = rest.InClusterConfig()
+	} else {
+		config, err = clientcmd.BuildConfigFromFlags("", k8sConfig)
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "error getting kubernetes config")
+	}
+	clientset, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return nil, errors.Wrap(err, "error getting kubernetes clientset")
+	}
+	return &KubernetesLeaseLock{configMaps: clientset.CoreV1().ConfigMaps(namespace), name: name + "-leader"}, nil
+}
+
+// get returns the config map and its lease, or nil if there is none
+func (l *KubernetesLeaseLock) get() (*corev1.ConfigMap, *Lease, error) {
+	cm, err := l.configMaps.Get(l.name, metav1.GetOptions{})
+	if k8serrors.IsNotFound(err) {
+		return nil, nil, nil
+	}
+	if err != nil {
+		return nil, nil, errors.Wrapf(err, "error getting the leader lease %s", l.name)
+	}
+	value, ok := cm.Annotations[leaderAnnotation]
+	if !ok {
+		return cm, nil, nil
+	}
+	lease := &Lease{}
+	if err := json.Unmarshal([]byte(value), lease); err != nil {
+		return nil, nil, errors.Wrapf(err, "error parsing the leader lease %s", l.name)
+	}
+	return cm, lease, nil
+}
+
+func setLease(cm *corev1.ConfigMap, lease *Lease) error {
+	value, err := json.Marshal(lease)
+	if err != nil {
+		return errors.Wrap(err, "error serializing the leader lease")
+	}
+	if cm.Annotations == nil {
+		cm.Annotations = map[string]string{}
+	}
+	cm.Annotations[leaderAnnotation] = string(value)
+	return nil
+}
+
+// Get returns the lease, its version is the resource version of the config map
+func (l *KubernetesLeaseLock) Get() (*Lease, string, error) {
+	defer trace.Trace("")()
+
+	cm, lease, err := l.get()
+	if err != nil || cm == nil {
+		return nil, "", err
+	}
+	return lease, cm.ResourceVersion, nil
+}
+
+// Put replaces the lease of version
+func (l *KubernetesLeaseLock) Put(lease *Lease, version string) (bool, error) {
+	defer trace.Trace("")()
+
+	if version == "" {
+		cm := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: l.name}}
+		if err := setLease(cm, lease); err != nil {
+			return false, err
+		}
+		_, err := l.configMaps.Create(cm)
+		if k8serrors.IsAlreadyExists(err) {
+			// another replica created it first
+			return false, nil
+		}
+		if err != nil {
+			return false, errors.Wrapf(err, "error creating the leader lease %s", l.name)
+		}
+		return true, nil
+	}
+
+	cm, _, err := l.get()
+	if err != nil {
+		return false, err
+	}
+	if cm == nil || cm.ResourceVersion != version {
+		return false, nil
+	}
+	if err := setLease(cm, lease); err != nil {
+		return false, err
+	}
+	_, err = l.configMaps.Update(cm)
+	if k8serrors.IsConflict(err) {
+		// another replica updated it first
+		return false, nil
+	}
+	if err != nil {
+		return false, errors.Wrapf(err, "error updating the leader lease %s", l.name)
+	}
+	return true, nil
+}
@@ -0,0 +1,94 @@
+///////////////////////////////////////////////////////////////////////
+// Copyright (c) 2018 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+///////////////////////////////////////////////////////////////////////
+
+package controller
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// fakeConfigMaps stores config maps in memory, and rejects the updates of stale resource versions
+type fakeConfigMaps struct {
+	configMaps map[string]*corev1.ConfigMap
+}
+
+var configMapsResource = schema.GroupResource{Resource: "configmaps"}
+
+func (f *fakeConfigMaps) Get(name string, options metav1.GetOptions) (*corev1.ConfigMap, error) {
+	cm, ok := f.configMaps[name]
+	if !ok {
+		return nil, k8serrors.NewNotFound(configMapsResource, name)
+	}
+	return cm.DeepCopy(), nil
+}
+
+func (f *fakeConfigMaps) Create(cm *corev1.ConfigMap) (*corev1.ConfigMap, error) {
+	if _, ok := f.configMaps[cm.Name]; ok {
+		return nil, k8serrors.NewAlreadyExists(configMapsResource, cm.Name)
+	}
+	cm = cm.DeepCopy()
+	cm.ResourceVersion = "1"
+	f.configMaps[cm.Name] = cm
+	return cm.DeepCopy(), nil
+}
+
+func (f *fakeConfigMaps) Update(cm *corev1.ConfigMap) (*corev1.ConfigMap, error) {
+	current, ok := f.configMaps[cm.Name]
+	if !ok {
+		return nil, k8serrors.NewNotFound(configMapsResource, cm.Name)
+	}
+	if current.ResourceVersion != cm.ResourceVersion {
+		return nil, k8serrors.NewConflict(configMapsResource, cm.Name, nil)
+	}
+	version, _ := strconv.Atoi(current.ResourceVersion)
+	cm = cm.DeepCopy()
+	cm.ResourceVersion = strconv.Itoa(version + 1)
+	f.configMaps[cm.Name] = cm
+	return cm.DeepCopy(), nil
+}
+
+func TestKubernetesLeaseLock(t *testing.T) {
+	configMaps := &fakeConfigMaps{configMaps: map[string]*corev1.ConfigMap{}}
+	lock := &KubernetesLeaseLock{configMaps: configMaps, name: "test-manager-leader"}
+
+	lease, version, err := lock.Get()
+	require.NoError(t, err)
+	assert.Nil(t, lease)
+	assert.Empty(t, version)
+
+	stored, err := lock.Put(&Lease{HolderID: "a", LeaseDuration: time.Minute}, version)
+	require.NoError(t, err)
+	assert.True(t, stored)
+	assert.Contains(t, configMaps.configMaps["test-manager-leader"].Annotations[leaderAnnotation], `"holderID":"a"`)
+	// another replica read the lease before it was stored
+	stored, err = lock.Put(&Lease{HolderID: "b", LeaseDuration: time.Minute}, version)
+	require.NoError(t, err)
+	assert.False(t, stored)
+
+	lease, version, err = lock.Get()
+	require.NoError(t, err)
+	assert.Equal(t, "a", lease.HolderID)
+	assert.Equal(t, "1", version)
+	stored, err = lock.Put(&Lease{HolderID: "b", LeaseDuration: time.Minute}, version)
+	require.NoError(t, err)
+	assert.True(t, stored)
+	assert.Equal(t, "2", configMaps.configMaps["test-manager-leader"].ResourceVersion)
+	stored, err = lock.Put(&Lease{HolderID: "a", LeaseDuration: time.Minute}, version)
+	require.NoError(t, err)
+	assert.False(t, stored)
+
+	lease, _, err = lock.Get()
+	require.NoError(t, err)
+	assert.Equal(t, "b", lease.HolderID)
+}
@@ -0,0 +1,235 @@
+///////////////////////////////////////////////////////////////////////
+// Copyright (c) 2018 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+///////////////////////////////////////////////////////////////////////
+
+package controller
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/satori/go.uuid"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/vmware/dispatch/pkg/entity-store"
+	"github.com/vmware/dispatch/pkg/trace"
+)
+
+// LeaderElectionFlags are the leader election flags, shared by the managers running a controller
+var LeaderElectionFlags = struct {
+	LeaderElect   string `long:"leader-elect" description:"Lock electing the replica which runs the controller (none, store or kubernetes)" default:"none"`
+	LeaderID      string `long:"leader-id" description:"Identity of this replica in the election (the hostname with a random suffix by default)"`
+	LeaseDuration int    `long:"leader-lease-duration" description:"Seconds after which the leadership may be taken over, if not renewed" default:"15"`
+	RenewPeriod   int    `long:"leader-renew-period" description:"Seconds between renewals of the leadership, and attempts to acquire it" default:"5"`
+	K8sNamespace  string `long:"leader-namespace" description:"Kubernetes namespace of the leader lease (kubernetes lock)" default:"dispatch"`
+	K8sConfig     string `long:"leader-kubeconfig" description:"Path to kubernetes config file, in-cluster config by default (kubernetes lock)" default:""`
+}{}
+
+// LeaderElector elects the leader among the replicas of a manager. Only the leader runs the reconcile loop of the
+// controller, i.e. the store watch and the periodic sync, while the API handlers are active on all replicas.
+type LeaderElector interface {
+	// ID identifies this replica. It is also the owner of the entities claimed by its controller.
+	ID() string
+	// Run campaigns for the leadership until stopCh is closed. Each change of the leadership of this replica is sent
+	// on the returned channel, which is closed once stopped and the leadership released. It must be drained.
+	Run(stopCh <-chan struct{}) <-chan bool
+}
+
+// Lease is the record of the leadership, held by a replica until it is not renewed for the lease duration. The times
+// are those of the clock of the holder, the other replicas only compare them to notice renewals.
+type Lease struct {
+	HolderID      string        `json:"holderID"`
+	AcquireTime   time.Time     `json:"acquireTime"`
+	RenewTime     time.Time     `json:"renewTime"`
+	LeaseDuration time.Duration `json:"leaseDuration"`
+}
+
+// equal reports whether two leases are the same record
+func (l *Lease) equal(other *Lease) bool {
+	if l == nil || other == nil {
+		return l == other
+	}
+	return l.HolderID == other.HolderID && l.AcquireTime.Equal(other.AcquireTime) &&
+		l.RenewTime.Equal(other.RenewTime) && l.LeaseDuration == other.LeaseDuration
+}
+
+// LeaseLock stores the lease. The implementations must replace it atomically, so that a single replica acquires it.
+type LeaseLock interface {
+	// Get returns the lease and its version, the lease is nil if there is none. The version is empty if the lease
+	// was never stored.
+	Get() (*Lease, string, error)
+	// Put replaces the lease of version, it returns false if the lease was replaced since
+	Put(lease *Lease, version string) (bool, error)
+}
+
+// LeaseElectorConfig is the configuration of a lease based leader election
+type LeaseElectorConfig struct {
+	// ID identifies the replica, the hostname with a random suffix by default
+	ID string
+	// LeaseDuration is the time after which the leadership may be taken over, if not renewed
+	LeaseDuration time.Duration
+	// RenewPeriod is the period of the renewals of the leadership, and of the attempts to acquire it. It must be
+	// shorter than the lease duration.
+	RenewPeriod time.Duration
+}
+
+type leaseElector struct {
+	lock   LeaseLock
+	config LeaseElectorConfig
+
+	// observed is the last lease read, and observedTime the time it was first read at on the local clock. A lease
+	// held by another replica expires once it was observed unchanged for its duration, so that the clocks of the
+	// replicas are never compared.
+	observed     *Lease
+	observedTime time.Time
+}
+
+// NewLeaseElector creates a leader elector, the leader being the replica holding the lease of lock
+func NewLeaseElector(lock LeaseLock, config LeaseElectorConfig) (LeaderElector, error) {
+	if config.ID == "" {
+		hostname, err := os.Hostname()
+		if err != nil {
+			return nil, errors.Wrap(err, "error getting the hostname for the leader election ID")
+		}
+		config.ID = fmt.Sprintf("%s-%s", hostname, uuid.NewV4().String()[:8])
+	}
+	if config.RenewPeriod <= 0 || config.LeaseDuration <= config.RenewPeriod {
+		return nil, errors.Errorf("invalid leader election periods, the lease duration %s must be longer than the renew period %s",
+			config.LeaseDuration, config.RenewPeriod)
+	}
+	return &leaseElector{lock: lock, config: config}, nil
+}
+
+// ID returns the ID of this replica
+func (e *leaseElector) ID() string {
+	return e.config.ID
+}
+
+// tryAcquire acquires or renews the lease, it returns true if this replica holds it
+func (e *leaseElector) tryAcquire(now time.Time) (bool, error) {
+	current, version, err := e.lock.Get()
+	if err != nil {
+		return false, err
+	}
+	if !current.equal(e.observed) {
+		e.observed = current
+		e.observedTime = now
+	}
+	held := current != nil && current.HolderID != ""
+	if held && current.HolderID != e.config.ID && now.Sub(e.observedTime) < current.LeaseDuration {
+		return false, nil
+	}
+
+	next := &Lease{HolderID: e.config.ID, AcquireTime: now, RenewTime: now, LeaseDuration: e.config.LeaseDuration}
+	if held && current.HolderID == e.config.ID {
+		next.AcquireTime = current.AcquireTime
+	}
+	acquired, err := e.lock.Put(next, version)
+	if err != nil || !acquired {
+		return false, err
+	}
+	e.observed = next
+	e.observedTime = now
+	return true, nil
+}
+
+// release gives the lease up if held by this replica, so that another replica acquires it without waiting for it to
+// expire
+func (e *leaseElector) release() error {
+	current, version, err := e.lock.Get()
+	if err != nil || current == nil || current.HolderID != e.config.ID {
+		return err
+	}
+	_, err = e.lock.Put(&Lease{}, version)
+	return err
+}
+
+// Run campaigns for the lease until stopCh is closed
+func (e *leaseElector) Run(stopCh <-chan struct{}) <-chan bool {
+	leadership := make(chan bool, 1)
+	go func() {
+		defer close(leadership)
+
+		ticker := time.NewTicker(e.config.RenewPeriod)
+		defer ticker.Stop()
+		leading := false
+		var renewed time.Time
+		for {
+			now := time.Now()
+			acquired, err := e.tryAcquire(now)
+			if err != nil {
+				log.Warnf("Error acquiring the leader lease for %s: %v", e.config.ID, err)
+				// the lease is still ours until it expires, but it is given up one renew period before
+				acquired = leading && now.Sub(renewed) < e.config.LeaseDuration-e.config.RenewPeriod
+			}
+			if acquired && err == nil {
+				renewed = now
+			}
+			if acquired != leading {
+				leading = acquired
+				log.Infof("Leadership of %s: %t", e.config.ID, leading)
+				leadership <- leading
+			}
+			select {
+			case <-stopCh:
+				if leading {
+					leadership <- false
+					if err := e.release(); err != nil {
+						log.Warnf("Error releasing the leader lease of %s: %v", e.config.ID, err)
+					}
+				}
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+	return leadership
+}
+
+// standalone is the elector of a controller without leader election, which always leads
+type standalone struct{}
+
+func (standalone) ID() string {
+	return ""
+}
+
+func (standalone) Run(stopCh <-chan struct{}) <-chan bool {
+	leadership := make(chan bool, 1)
+	leadership <- true
+	go func() {
+		defer close(leadership)
+		<-stopCh
+		leadership <- false
+	}()
+	return leadership
+}
+
+// NewLeaderElector creates the leader elector configured by LeaderElectionFlags, electing the leader among the
+// replicas of the manager name. It returns nil without leader election.
+func NewLeaderElector(name string, store entitystore.EntityStore, organizationID string) (LeaderElector, error) {
+	defer trace.Tracef("lock: %s", LeaderElectionFlags.LeaderElect)()
+
+	var lock LeaseLock
+	switch LeaderElectionFlags.LeaderElect {
+	case "", "none":
+		return nil, nil
+	case "store":
+		lock = NewEntityStoreLock(store, organizationID, name)
+	case "kubernetes":
+		var err error
+		lock, err = NewKubernetesLeaseLock(LeaderElectionFlags.K8sConfig, LeaderElectionFlags.K8sNamespace, name)
+		if err != nil {
+			return nil, err
+		}
+	default:
+		return nil, errors.Errorf("invalid leader election lock %s", LeaderElectionFlags.LeaderElect)
+	}
+	return NewLeaseElector(lock, LeaseElectorConfig{
+		ID:            LeaderElectionFlags.LeaderID,
+		LeaseDuration: time.Duration(LeaderElectionFlags.LeaseDuration) * time.Second,
+		RenewPeriod:   time.Duration(LeaderElectionFlags.RenewPeriod) * time.Second,
+	})
+}
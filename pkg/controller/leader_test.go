@@ -0,0 +1,157 @@
+///////////////////////////////////////////////////////////////////////
+// Copyright (c) 2018 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+///////////////////////////////////////////////////////////////////////
+
+package controller
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	helpers "github.com/vmware/dispatch/pkg/testing/api"
+)
+
+// memoryLock stores the lease in memory
+type memoryLock struct {
+	lease   *Lease
+	version int
+}
+
+func (l *memoryLock) Get() (*Lease, string, error) {
+	if l.version == 0 {
+		return nil, "", nil
+	}
+	return l.lease, strconv.Itoa(l.version), nil
+}
+
+func (l *memoryLock) Put(lease *Lease, version string) (bool, error) {
+	if version != "" && version != strconv.Itoa(l.version) || version == "" && l.version != 0 {
+		return false, nil
+	}
+	l.lease = lease
+	l.version++
+	return true, nil
+}
+
+func TestLeaseElectorExpiry(t *testing.T) {
+	lock := &memoryLock{}
+	config := LeaseElectorConfig{LeaseDuration: time.Minute, RenewPeriod: 10 * time.Second}
+	a := &leaseElector{lock: lock, config: LeaseElectorConfig{ID: "a", LeaseDuration: config.LeaseDuration, RenewPeriod: config.RenewPeriod}}
+	b := &leaseElector{lock: lock, config: LeaseElectorConfig{ID: "b", LeaseDuration: config.LeaseDuration, RenewPeriod: config.RenewPeriod}}
+	now := time.Now()
+
+	acquired, err := a.tryAcquire(now)
+	require.NoError(t, err)
+	assert.True(t, acquired)
+	assert.Equal(t, "a", lock.lease.HolderID)
+
+	// the clock of a is an hour late, its renewals still keep the lease
+	for i := 1; i <= 3; i++ {
+		acquired, err = a.tryAcquire(now.Add(time.Duration(i)*50*time.Second - time.Hour))
+		require.NoError(t, err)
+		assert.True(t, acquired)
+		acquired, err = b.tryAcquire(now.Add(time.Duration(i) * 50 * time.Second))
+		require.NoError(t, err)
+		assert.False(t, acquired)
+	}
+	assert.Equal(t, now, lock.lease.AcquireTime)
+
+	// the lease expires once b observed it unchanged for its duration, whatever its renew time
+	acquired, err = b.tryAcquire(now.Add(150*time.Second + 59*time.Second))
+	require.NoError(t, err)
+	assert.False(t, acquired)
+	acquired, err = b.tryAcquire(now.Add(150*time.Second + time.Minute))
+	require.NoError(t, err)
+	assert.True(t, acquired)
+	assert.Equal(t, "b", lock.lease.HolderID)
+
+	// a released lease is acquired right away
+	require.NoError(t, b.release())
+	require.NoError(t, a.release())
+	acquired, err = a.tryAcquire(now.Add(4 * time.Minute))
+	require.NoError(t, err)
+	assert.True(t, acquired)
+}
+
+func TestEntityStoreLock(t *testing.T) {
+	store := helpers.MakeEntityStore(t)
+	lock := NewEntityStoreLock(store, testOrgID, "test-manager")
+
+	lease, version, err := lock.Get()
+	require.NoError(t, err)
+	assert.Nil(t, lease)
+	assert.Empty(t, version)
+
+	stored, err := lock.Put(&Lease{HolderID: "a", LeaseDuration: time.Minute}, version)
+	require.NoError(t, err)
+	assert.True(t, stored)
+	// another replica read the lease before it was stored
+	stored, err = lock.Put(&Lease{HolderID: "b", LeaseDuration: time.Minute}, version)
+	require.NoError(t, err)
+	assert.False(t, stored)
+
+	lease, version, err = lock.Get()
+	require.NoError(t, err)
+	assert.Equal(t, "a", lease.HolderID)
+	stored, err = lock.Put(&Lease{HolderID: "b", LeaseDuration: time.Minute}, version)
+	require.NoError(t, err)
+	assert.True(t, stored)
+	stored, err = lock.Put(&Lease{HolderID: "a", LeaseDuration: time.Minute}, version)
+	require.NoError(t, err)
+	assert.False(t, stored)
+
+	lease, _, err = lock.Get()
+	require.NoError(t, err)
+	assert.Equal(t, "b", lease.HolderID)
+}
+
+func expectLeadership(t *testing.T, leadership <-chan bool, expected bool) {
+	select {
+	case leading := <-leadership:
+		assert.Equal(t, expected, leading)
+	case <-time.After(time.Second):
+		t.Fatalf("the leadership did not change to %t", expected)
+	}
+}
+
+func TestLeaseElector(t *testing.T) {
+	store := helpers.MakeEntityStore(t)
+	config := LeaseElectorConfig{LeaseDuration: 200 * time.Millisecond, RenewPeriod: 50 * time.Millisecond}
+
+	_, err := NewLeaseElector(NewEntityStoreLock(store, testOrgID, "test-manager"), LeaseElectorConfig{
+		LeaseDuration: time.Second,
+		RenewPeriod:   time.Second,
+	})
+	assert.Error(t, err)
+
+	a, err := NewLeaseElector(NewEntityStoreLock(store, testOrgID, "test-manager"), config)
+	require.NoError(t, err)
+	b, err := NewLeaseElector(NewEntityStoreLock(store, testOrgID, "test-manager"), config)
+	require.NoError(t, err)
+	assert.NotEqual(t, a.ID(), b.ID())
+
+	stopA := make(chan struct{})
+	leadershipA := a.Run(stopA)
+	expectLeadership(t, leadershipA, true)
+
+	stopB := make(chan struct{})
+	defer close(stopB)
+	leadershipB := b.Run(stopB)
+	select {
+	case <-leadershipB:
+		t.Fatal("both replicas lead")
+	case <-time.After(2 * config.LeaseDuration):
+	}
+
+	// the lease is released once stopped, and acquired by the other replica
+	close(stopA)
+	expectLeadership(t, leadershipA, false)
+	_, ok := <-leadershipA
+	assert.False(t, ok)
+	expectLeadership(t, leadershipB, true)
+}
@@ -0,0 +1,105 @@
+///////////////////////////////////////////////////////////////////////
+// Copyright (c) 2018 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+///////////////////////////////////////////////////////////////////////
+
+package controller
+
+import (
+	"strconv"
+
+	"github.com/pkg/errors"
+
+	"github.com/vmware/dispatch/pkg/entity-store"
+	"github.com/vmware/dispatch/pkg/trace"
+)
+
+// LeaderLease is the entity storing the lease of a leader election
+type LeaderLease struct {
+	entitystore.BaseEntity
+	Lease Lease `json:"lease"`
+}
+
+// EntityStoreLock is a lease lock stored in the entity store, which the replicas share. The lease is replaced with
+// compare-and-swap updates, so that a single replica acquires it.
+type EntityStoreLock struct {
+	store          entitystore.EntityStore
+	organizationID string
+	name           string
+}
+
+// NewEntityStoreLock creates a lease lock stored in the entity store, under name
+func NewEntityStoreLock(store entitystore.EntityStore, organizationID, name string) *EntityStoreLock {
+	return &EntityStoreLock{store: store, organizationID: organizationID, name: name}
+}
+
+// get returns the stored lease entity, or nil if there is none
+func (l *EntityStoreLock) get() (*LeaderLease, error) {
+	var leases []*LeaderLease
+	opts := entitystore.Options{
+		Filter: entitystore.FilterEverything().Add(
+			entitystore.FilterStat{
+				Scope:   entitystore.FilterScopeField,
+				Subject: "Name",
+				Verb:    entitystore.FilterVerbEqual,
+				Object:  l.name,
+			}),
+	}
+	if err := l.store.List(l.organizationID, opts, &leases); err != nil {
+		return nil, errors.Wrapf(err, "error getting the leader lease %s", l.name)
+	}
+	if len(leases) == 0 {
+		return nil, nil
+	}
+	return leases[0], nil
+}
+
+// Get returns the lease, its version is the revision of the entity
+func (l *EntityStoreLock) Get() (*Lease, string, error) {
+	defer trace.Trace("")()
+
+	current, err := l.get()
+	if err != nil || current == nil {
+		return nil, "", err
+	}
+	lease := current.Lease
+	return &lease, strconv.FormatUint(current.Revision, 10), nil
+}
+
+// Put replaces the lease of version
+func (l *EntityStoreLock) Put(lease *Lease, version string) (bool, error) {
+	defer trace.Trace("")()
+
+	if version == "" {
+		entity := &LeaderLease{
+			BaseEntity: entitystore.BaseEntity{
+				OrganizationID: l.organizationID,
+				Name:           l.name,
+				Status:         entitystore.StatusREADY,
+			},
+			Lease: *lease,
+		}
+		if _, err := l.store.Add(entity); err != nil {
+			if entitystore.IsUniqueViolation(err) {
+				// another replica stored it first
+				return false, nil
+			}
+			return false, errors.Wrapf(err, "error creating the leader lease %s", l.name)
+		}
+		return true, nil
+	}
+
+	current, err := l.get()
+	if err != nil {
+		return false, err
+	}
+	if current == nil || strconv.FormatUint(current.Revision, 10) != version {
+		return false, nil
+	}
+	current.Lease = *lease
+	if _, err := l.store.Update(current.Revision, current); err != nil {
+		// the lease was replaced in the meantime, the update is retried at the next attempt
+		return false, errors.Wrapf(err, "error updating the leader lease %s", l.name)
+	}
+	return true, nil
+}
@@ -0,0 +1,620 @@
+///////////////////////////////////////////////////////////////////////
+// Copyright (c) 2018 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+///////////////////////////////////////////////////////////////////////
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"path"
+	"reflect"
+	"sort"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"golang.org/x/net/context"
+
+	endpoint "github.com/vmware/dispatch/pkg/api-manager/gen/client/endpoint"
+	application "github.com/vmware/dispatch/pkg/application-manager/gen/client/application"
+	cmdutils "github.com/vmware/dispatch/pkg/dispatchcli/cmd/utils"
+	"github.com/vmware/dispatch/pkg/dispatchcli/i18n"
+	drivers "github.com/vmware/dispatch/pkg/event-manager/gen/client/drivers"
+	subscriptions "github.com/vmware/dispatch/pkg/event-manager/gen/client/subscriptions"
+	fnstore "github.com/vmware/dispatch/pkg/function-manager/gen/client/store"
+	policy "github.com/vmware/dispatch/pkg/identity-manager/gen/client/policy"
+	baseimage "github.com/vmware/dispatch/pkg/image-manager/gen/client/base_image"
+	image "github.com/vmware/dispatch/pkg/image-manager/gen/client/image"
+	secret "github.com/vmware/dispatch/pkg/secret-store/gen/client/secret"
+	"github.com/vmware/dispatch/pkg/utils"
+)
+
+var (
+	applyLong = i18n.T(`Create or update the resources of a YAML file.
+
+Resources which exist and match the file are left unchanged, so the file can be applied any number of times. With
+--prune, the resources of the application (--application) which are not in the file are deleted. Policies and other
+applications are not part of an application, so files containing them cannot be applied with --prune.`)
+
+	applyExample = i18n.T(`
+		# Apply the resources of seed.yaml, the function code paths are relative to examples/
+		dispatch apply -f seed.yaml -w examples/
+		# Apply the resources of an application, and delete the ones removed from the file
+		dispatch apply -f app.yaml -a my-app --prune`)
+
+	applyPrune = false
+
+	// applyDeleteTimeout is how long a resource which cannot be updated is waited for to be deleted, before it is
+	// created again
+	applyDeleteTimeout = 2 * time.Minute
+)
+
+// The actions of a change
+const (
+	changeCreate    = "create"
+	changeUpdate    = "update"
+	changeDelete    = "delete"
+	changeUnchanged = "unchanged"
+)
+
+// resourceKinds are the kinds of resources in dependency order, they are applied in this order and pruned in the
+// reverse one
+var resourceKinds = []string{
+	utils.ApplicationKind,
+	utils.SecretKind,
+	utils.PolicyKind,
+	utils.BaseImageKind,
+	utils.ImageKind,
+	utils.FunctionKind,
+	utils.DriverTypeKind,
+	utils.DriverKind,
+	utils.SubscriptionKind,
+	utils.APIKind,
+}
+
+// readOnlyFields are the fields of the models set by the services, they are ignored when comparing resources
+var readOnlyFields = map[string]bool{
+	"id":             true,
+	"kind":           true,
+	"status":         true,
+	"reason":         true,
+	"createdTime":    true,
+	"modifiedTime":   true,
+	"created-time":   true,
+	"modified-time":  true,
+	"tls-expiration": true,
+	"built-in":       true,
+}
+
+// resourceClient reads and writes the resources of a kind
+type resourceClient struct {
+	// get returns the resource, nil if it does not exist
+	get func(name string) (interface{}, error)
+	// list returns the resources of an application, nil if the resources of the kind are not tagged, e.g. policies,
+	// which cannot be pruned then
+	list   func(app string) ([]interface{}, error)
+	create modelAction
+	// update is nil if the resources of the kind are deleted and created again
+	update modelAction
+	delete modelAction
+}
+
+// change is a pending change of a resource
+type change struct {
+	Action string   `json:"action"`
+	Kind   string   `json:"kind"`
+	Name   string   `json:"name"`
+	Fields []string `json:"fields,omitempty"`
+
+	desired interface{}
+	current interface{}
+}
+
+func applicationTags(app string) []string {
+	tags := []string{}
+	cmdutils.AppendApplication(&tags, app)
+	return tags
+}
+
+func resourceClients() map[string]*resourceClient {
+	return map[string]*resourceClient{
+		utils.ApplicationKind: {
+			get: func(name string) (interface{}, error) {
+				params := &application.GetAppParams{Application: name, Context: context.Background()}
+				resp, err := applicationManagerClient().Application.GetApp(params, GetAuthInfoWriter())
+				if _, ok := err.(*application.GetAppNotFound); ok {
+					return nil, nil
+				}
+				if err != nil {
+					return nil, formatAPIError(err, params)
+				}
+				return resp.Payload, nil
+			},
+			create: CallCreateApplication,
+			update: CallUpdateApplication,
+			delete: CallDeleteApplication,
+		},
+		utils.SecretKind: {
+			get: func(name string) (interface{}, error) {
+				params := &secret.GetSecretParams{SecretName: name, Context: context.Background()}
+				resp, err := secretStoreClient().Secret.GetSecret(params, GetAuthInfoWriter())
+				if _, ok := err.(*secret.GetSecretNotFound); ok {
+					return nil, nil
+				}
+				if err != nil {
+					return nil, formatAPIError(err, params)
+				}
+				return resp.Payload, nil
+			},
+			list: func(app string) ([]interface{}, error) {
+				params := &secret.GetSecretsParams{Tags: applicationTags(app), Context: context.Background()}
+				resp, err := secretStoreClient().Secret.GetSecrets(params, GetAuthInfoWriter())
+				if err != nil {
+					return nil, formatAPIError(err, params)
+				}
+				var result []interface{}
+				for _, m := range resp.Payload {
+					result = append(result, m)
+				}
+				return result, nil
+			},
+			create: CallCreateSecret,
+			update: CallUpdateSecret,
+			delete: CallDeleteSecret,
+		},
+		utils.PolicyKind: {
+			get: func(name string) (interface{}, error) {
+				params := &policy.GetPolicyParams{PolicyName: name, Context: context.Background()}
+				resp, err := identityManagerClient().Policy.GetPolicy(params, GetAuthInfoWriter())
+				if _, ok := err.(*policy.GetPolicyNotFound); ok {
+					return nil, nil
+				}
+				if err != nil {
+					return nil, formatAPIError(err, params)
+				}
+				return resp.Payload, nil
+			},
+			create: CallCreatePolicy,
+			update: CallUpdatePolicy,
+			delete: CallDeletePolicy,
+		},
+		utils.BaseImageKind: {
+			get: func(name string) (interface{}, error) {
+				params := &baseimage.GetBaseImageByNameParams{BaseImageName: name, Context: context.Background()}
+				resp, err := imageManagerClient().BaseImage.GetBaseImageByName(params, GetAuthInfoWriter())
+				if _, ok := err.(*baseimage.GetBaseImageByNameNotFound); ok {
+					return nil, nil
+				}
+				if err != nil {
+					return nil, formatAPIError(err, params)
+				}
+				return resp.Payload, nil
+			},
+			list: func(app string) ([]interface{}, error) {
+				params := &baseimage.GetBaseImagesParams{Tags: applicationTags(app), Context: context.Background()}
+				resp, err := imageManagerClient().BaseImage.GetBaseImages(params, GetAuthInfoWriter())
+				if err != nil {
+					return nil, formatAPIError(err, params)
+				}
+				var result []interface{}
+				for _, m := range resp.Payload {
+					result = append(result, m)
+				}
+				return result, nil
+			},
+			create: CallCreateBaseImage,
+			update: CallUpdateBaseImage,
+			delete: CallDeleteBaseImage,
+		},
+		utils.ImageKind: {
+			get: func(name string) (interface{}, error) {
+				params := &image.GetImageByNameParams{ImageName: name, Context: context.Background()}
+				resp, err := imageManagerClient().Image.GetImageByName(params, GetAuthInfoWriter())
+				if _, ok := err.(*image.GetImageByNameNotFound); ok {
+					return nil, nil
+				}
+				if err != nil {
+					return nil, formatAPIError(err, params)
+				}
+				return resp.Payload, nil
+			},
+			list: func(app string) ([]interface{}, error) {
+				params := &image.GetImagesParams{Tags: applicationTags(app), Context: context.Background()}
+				resp, err := imageManagerClient().Image.GetImages(params, GetAuthInfoWriter())
+				if err != nil {
+					return nil, formatAPIError(err, params)
+				}
+				var result []interface{}
+				for _, m := range resp.Payload {
+					result = append(result, m)
+				}
+				return result, nil
+			},
+			create: CallCreateImage,
+			update: CallUpdateImage,
+			delete: CallDeleteImage,
+		},
+		utils.FunctionKind: {
+			get: func(name string) (interface{}, error) {
+				params := &fnstore.GetFunctionParams{FunctionName: name, Context: context.Background()}
+				resp, err := functionManagerClient().Store.GetFunction(params, GetAuthInfoWriter())
+				if _, ok := err.(*fnstore.GetFunctionNotFound); ok {
+					return nil, nil
+				}
+				if err != nil {
+					return nil, formatAPIError(err, params)
+				}
+				return resp.Payload, nil
+			},
+			list: func(app string) ([]interface{}, error) {
+				params := &fnstore.GetFunctionsParams{Tags: applicationTags(app), Context: context.Background()}
+				resp, err := functionManagerClient().Store.GetFunctions(params, GetAuthInfoWriter())
+				if err != nil {
+					return nil, formatAPIError(err, params)
+				}
+				var result []interface{}
+				for _, m := range resp.Payload {
+					result = append(result, m)
+				}
+				return result, nil
+			},
+			create: CallCreateFunction,
+			update: CallUpdateFunction,
+			delete: CallDeleteFunction,
+		},
+		utils.DriverTypeKind: {
+			get: func(name string) (interface{}, error) {
+				params := &drivers.GetDriverTypeParams{DriverTypeName: name, Context: context.Background()}
+				resp, err := eventManagerClient().Drivers.GetDriverType(params, GetAuthInfoWriter())
+				if _, ok := err.(*drivers.GetDriverTypeNotFound); ok {
+					return nil, nil
+				}
+				if err != nil {
+					return nil, formatAPIError(err, params)
+				}
+				return resp.Payload, nil
+			},
+			list: func(app string) ([]interface{}, error) {
+				params := &drivers.GetDriverTypesParams{Tags: applicationTags(app), Context: context.Background()}
+				resp, err := eventManagerClient().Drivers.GetDriverTypes(params, GetAuthInfoWriter())
+				if err != nil {
+					return nil, formatAPIError(err, params)
+				}
+				var result []interface{}
+				for _, m := range resp.Payload {
+					result = append(result, m)
+				}
+				return result, nil
+			},
+			create: CallCreateEventDriverType,
+			delete: CallDeleteEventDriverType,
+		},
+		utils.DriverKind: {
+			get: func(name string) (interface{}, error) {
+				params := &drivers.GetDriverParams{DriverName: name, Context: context.Background()}
+				resp, err := eventManagerClient().Drivers.GetDriver(params, GetAuthInfoWriter())
+				if _, ok := err.(*drivers.GetDriverNotFound); ok {
+					return nil, nil
+				}
+				if err != nil {
+					return nil, formatAPIError(err, params)
+				}
+				return resp.Payload, nil
+			},
+			list: func(app string) ([]interface{}, error) {
+				params := &drivers.GetDriversParams{Tags: applicationTags(app), Context: context.Background()}
+				resp, err := eventManagerClient().Drivers.GetDrivers(params, GetAuthInfoWriter())
+				if err != nil {
+					return nil, formatAPIError(err, params)
+				}
+				var result []interface{}
+				for _, m := range resp.Payload {
+					result = append(result, m)
+				}
+				return result, nil
+			},
+			create: CallCreateEventDriver,
+			update: CallUpdateEventDriver,
+			delete: CallDeleteEventDriver,
+		},
+		utils.SubscriptionKind: {
+			get: func(name string) (interface{}, error) {
+				params := &subscriptions.GetSubscriptionParams{SubscriptionName: name, Context: context.Background()}
+				resp, err := eventManagerClient().Subscriptions.GetSubscription(params, GetAuthInfoWriter())
+				if _, ok := err.(*subscriptions.GetSubscriptionNotFound); ok {
+					return nil, nil
+				}
+				if err != nil {
+					return nil, formatAPIError(err, params)
+				}
+				return resp.Payload, nil
+			},
+			list: func(app string) ([]interface{}, error) {
+				params := &subscriptions.GetSubscriptionsParams{Tags: applicationTags(app), Context: context.Background()}
+				resp, err := eventManagerClient().Subscriptions.GetSubscriptions(params, GetAuthInfoWriter())
+				if err != nil {
+					return nil, formatAPIError(err, params)
+				}
+				var result []interface{}
+				for _, m := range resp.Payload {
+					result = append(result, m)
+				}
+				return result, nil
+			},
+			create: CallCreateSubscription,
+			delete: CallDeleteSubscription,
+		},
+		utils.APIKind: {
+			get: func(name string) (interface{}, error) {
+				params := &endpoint.GetAPIParams{API: name, Context: context.Background()}
+				resp, err := apiManagerClient().Endpoint.GetAPI(params, GetAuthInfoWriter())
+				if _, ok := err.(*endpoint.GetAPINotFound); ok {
+					return nil, nil
+				}
+				if err != nil {
+					return nil, formatAPIError(err, params)
+				}
+				return resp.Payload, nil
+			},
+			list: func(app string) ([]interface{}, error) {
+				params := &endpoint.GetApisParams{Tags: applicationTags(app), Context: context.Background()}
+				resp, err := apiManagerClient().Endpoint.GetApis(params, GetAuthInfoWriter())
+				if err != nil {
+					return nil, formatAPIError(err, params)
+				}
+				var result []interface{}
+				for _, m := range resp.Payload {
+					result = append(result, m)
+				}
+				return result, nil
+			},
+			create: CallCreateAPI,
+			update: CallUpdateAPI,
+			delete: CallDeleteAPI,
+		},
+	}
+}
+
+// modelMap returns the JSON fields of a model
+func modelMap(m interface{}) (map[string]interface{}, error) {
+	b, err := json.Marshal(m)
+	if err != nil {
+		return nil, err
+	}
+	result := make(map[string]interface{})
+	if err := json.Unmarshal(b, &result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// diffModels returns the fields of the desired model which differ from the current one. The fields which are not set
+// in the desired model, and the read-only ones, are ignored.
+func diffModels(desired, current interface{}) ([]string, error) {
+	d, err := modelMap(desired)
+	if err != nil {
+		return nil, err
+	}
+	c, err := modelMap(current)
+	if err != nil {
+		return nil, err
+	}
+	var fields []string
+	for k, v := range d {
+		if v == nil || readOnlyFields[k] {
+			continue
+		}
+		if !reflect.DeepEqual(v, c[k]) {
+			fields = append(fields, k)
+		}
+	}
+	sort.Strings(fields)
+	return fields, nil
+}
+
+// tagApplication adds the application tag to a model, unless it has it already
+func tagApplication(m interface{}, app string) error {
+	fields, err := modelMap(m)
+	if err != nil {
+		return err
+	}
+	tags, _ := fields["tags"].([]interface{})
+	for _, t := range tags {
+		if tag, ok := t.(map[string]interface{}); ok && tag["key"] == "Application" && tag["value"] == app {
+			return nil
+		}
+	}
+	fields["tags"] = append(tags, map[string]interface{}{"key": "Application", "value": app})
+	b, err := json.Marshal(fields)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(b, m)
+}
+
+// sortResources sorts resources in dependency order, resources of the same kind are kept in the file order
+func sortResources(resources []*resource) {
+	order := make(map[string]int)
+	for i, kind := range resourceKinds {
+		order[kind] = i
+	}
+	sort.SliceStable(resources, func(i, j int) bool {
+		return order[resources[i].Kind] < order[resources[j].Kind]
+	})
+}
+
+// planChanges compares the resources of a file with the current ones, and returns the changes needed to apply the file
+func planChanges(resources []*resource, clients map[string]*resourceClient, app string, prune bool) ([]*change, error) {
+	sortResources(resources)
+
+	var changes []*change
+	names := make(map[string]map[string]bool)
+	for _, r := range resources {
+		// the application itself is the scope of the pruning
+		if prune && clients[r.Kind].list == nil && !(r.Kind == utils.ApplicationKind && r.Name == app) {
+			return nil, errors.Errorf("--prune cannot delete the %s resources removed from the file, apply %s %s without --prune", r.Kind, r.Kind, r.Name)
+		}
+		if names[r.Kind] == nil {
+			names[r.Kind] = make(map[string]bool)
+		}
+		if names[r.Kind][r.Name] {
+			return nil, errors.Errorf("duplicate %s %s", r.Kind, r.Name)
+		}
+		names[r.Kind][r.Name] = true
+
+		if app != "" && r.Kind != utils.ApplicationKind {
+			if err := tagApplication(r.Model, app); err != nil {
+				return nil, errors.Wrapf(err, "Error tagging %s %s", r.Kind, r.Name)
+			}
+		}
+		current, err := clients[r.Kind].get(r.Name)
+		if err != nil {
+			return nil, err
+		}
+		c := &change{Action: changeCreate, Kind: r.Kind, Name: r.Name, desired: r.Model, current: current}
+		if current != nil {
+			c.Fields, err = diffModels(r.Model, current)
+			if err != nil {
+				return nil, errors.Wrapf(err, "Error comparing %s %s", r.Kind, r.Name)
+			}
+			c.Action = changeUpdate
+			if len(c.Fields) == 0 {
+				c.Action = changeUnchanged
+			}
+		}
+		changes = append(changes, c)
+	}
+
+	if !prune {
+		return changes, nil
+	}
+	for i := len(resourceKinds) - 1; i >= 0; i-- {
+		kind := resourceKinds[i]
+		if clients[kind].list == nil {
+			continue
+		}
+		current, err := clients[kind].list(app)
+		if err != nil {
+			return nil, err
+		}
+		for _, m := range current {
+			fields, err := modelMap(m)
+			if err != nil {
+				return nil, err
+			}
+			name, _ := fields["name"].(string)
+			if names[kind][name] {
+				continue
+			}
+			changes = append(changes, &change{Action: changeDelete, Kind: kind, Name: name, current: m})
+		}
+	}
+	return changes, nil
+}
+
+// readChanges reads the resource file, and returns the changes needed to apply it
+func readChanges() ([]*change, error) {
+	if applyPrune && cmdFlagApplication == "" {
+		return nil, errors.New("--prune requires an application (--application)")
+	}
+	resources, err := readResources(path.Join(workDir, file))
+	if err != nil {
+		return nil, err
+	}
+	return planChanges(resources, resourceClients(), cmdFlagApplication, applyPrune)
+}
+
+// applyChange makes the API calls of a change, resources of kinds which cannot be updated are deleted and created again
+func applyChange(client *resourceClient, c *change) error {
+	switch c.Action {
+	case changeCreate:
+		return client.create(c.desired)
+	case changeUpdate:
+		if client.update != nil {
+			return client.update(c.desired)
+		}
+		if err := client.delete(c.current); err != nil {
+			return err
+		}
+		if err := waitDeleted(client, c.Kind, c.Name); err != nil {
+			return err
+		}
+		return client.create(c.desired)
+	case changeDelete:
+		return client.delete(c.current)
+	}
+	return nil
+}
+
+// waitDeleted waits for a resource to be gone, as the services only mark resources for deletion and delete them
+// in the background
+func waitDeleted(client *resourceClient, kind, name string) error {
+	return utils.Backoff(applyDeleteTimeout, func() error {
+		current, err := client.get(name)
+		if err != nil {
+			return err
+		}
+		if current != nil {
+			return errors.Errorf("Timed out waiting for %s %s to be deleted", kind, name)
+		}
+		return nil
+	})
+}
+
+// NewCmdApply creates a command object for the "apply" action, which creates or updates the resources of a file.
+func NewCmdApply(out io.Writer, errOut io.Writer) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "apply -f FILE [-w WORK_DIR] [-a APPLICATION] [--prune]",
+		Short:   i18n.T("Create or update the resources of a file."),
+		Long:    applyLong,
+		Example: applyExample,
+		Args:    cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			if file == "" {
+				runHelp(cmd, args)
+				return
+			}
+			err := apply(out, errOut, cmd, args)
+			CheckErr(err)
+		},
+	}
+	cmd.Flags().StringVarP(&cmdFlagApplication, "application", "a", "", "associate with an application")
+	cmd.Flags().StringVarP(&file, "file", "f", "", "Path to YAML file")
+	cmd.Flags().StringVarP(&workDir, "work-dir", "w", "", "Working directory relative paths are based on")
+	cmd.Flags().BoolVar(&applyPrune, "prune", false, "delete the resources of the application which are not in the file")
+	return cmd
+}
+
+func apply(out, errOut io.Writer, cmd *cobra.Command, args []string) error {
+	changes, err := readChanges()
+	if err != nil {
+		return err
+	}
+	clients := resourceClients()
+	for _, c := range changes {
+		if err := applyChange(clients[c.Kind], c); err != nil {
+			return err
+		}
+		if !dispatchConfig.JSON {
+			switch c.Action {
+			case changeCreate:
+				fmt.Fprintf(out, "Created %s: %s\n", c.Kind, c.Name)
+			case changeUpdate:
+				fmt.Fprintf(out, "Updated %s: %s\n", c.Kind, c.Name)
+			case changeDelete:
+				fmt.Fprintf(out, "Deleted %s: %s\n", c.Kind, c.Name)
+			default:
+				fmt.Fprintf(out, "Unchanged %s: %s\n", c.Kind, c.Name)
+			}
+		}
+	}
+	if dispatchConfig.JSON {
+		encoder := json.NewEncoder(out)
+		encoder.SetIndent("", "    ")
+		return encoder.Encode(changes)
+	}
+	return nil
+}
@@ -0,0 +1,273 @@
+///////////////////////////////////////////////////////////////////////
+// Copyright (c) 2018 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+///////////////////////////////////////////////////////////////////////
+
+package cmd
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/go-openapi/swag"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/pkg/errors"
+
+	applicationModels "github.com/vmware/dispatch/pkg/application-manager/gen/models"
+	eventModels "github.com/vmware/dispatch/pkg/event-manager/gen/models"
+	functionModels "github.com/vmware/dispatch/pkg/function-manager/gen/models"
+	identityModels "github.com/vmware/dispatch/pkg/identity-manager/gen/models"
+	secretModels "github.com/vmware/dispatch/pkg/secret-store/gen/models"
+	"github.com/vmware/dispatch/pkg/utils"
+)
+
+const testResourceFile = `
+kind: Subscription
+name: hello-sub
+event-type: test.event
+source-type: test
+function: hello
+---
+kind: Function
+name: hello
+image: python3
+code: "@hello.py"
+environment:
+  DEBUG: "true"
+limits:
+  memory: 128Mi
+scaling:
+  maxReplicas: 5
+---
+kind: Unknown
+name: skipped
+---
+kind: Application
+name: my-app
+`
+
+func TestReadResources(t *testing.T) {
+	dir, err := ioutil.TempDir("", "apply")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+	assert.Nil(t, ioutil.WriteFile(filepath.Join(dir, "app.yaml"), []byte(testResourceFile), 0644))
+	assert.Nil(t, ioutil.WriteFile(filepath.Join(dir, "hello.py"), []byte("def handle(ctx, payload): pass"), 0644))
+
+	workDir = dir
+	defer func() { workDir = "" }()
+	resources, err := readResources(filepath.Join(dir, "app.yaml"))
+	assert.Nil(t, err)
+	assert.Len(t, resources, 3)
+
+	assert.Equal(t, utils.SubscriptionKind, resources[0].Kind)
+	assert.Equal(t, "hello-sub", resources[0].Name)
+	assert.Equal(t, "test.event", *resources[0].Model.(*eventModels.Subscription).EventType)
+	function := resources[1].Model.(*functionModels.Function)
+	assert.Equal(t, "def handle(ctx, payload): pass", *function.Code)
+	assert.Equal(t, map[string]string{"DEBUG": "true"}, function.Environment)
+	assert.Equal(t, "128Mi", function.Limits.Memory)
+	assert.EqualValues(t, 5, *function.Scaling.MaxReplicas)
+	assert.Equal(t, utils.ApplicationKind, resources[2].Kind)
+
+	sortResources(resources)
+	assert.Equal(t, utils.ApplicationKind, resources[0].Kind)
+	assert.Equal(t, utils.FunctionKind, resources[1].Kind)
+	assert.Equal(t, utils.SubscriptionKind, resources[2].Kind)
+}
+
+func TestDiffModels(t *testing.T) {
+	desired := &functionModels.Function{
+		Name:  swag.String("hello"),
+		Image: swag.String("python3"),
+		Code:  swag.String("new code"),
+	}
+	current := &functionModels.Function{
+		ID:     "2b4b8b7e-3c8e-4a6a-9d5e-5f3c1b0d3a0e",
+		Name:   swag.String("hello"),
+		Image:  swag.String("python3"),
+		Code:   swag.String("old code"),
+		Status: functionModels.StatusREADY,
+		Tags:   []*functionModels.Tag{{Key: "Application", Value: "my-app"}},
+	}
+	fields, err := diffModels(desired, current)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"code"}, fields)
+
+	desired.Code = current.Code
+	fields, err = diffModels(desired, current)
+	assert.Nil(t, err)
+	assert.Empty(t, fields)
+
+	assert.Nil(t, tagApplication(desired, "my-app"))
+	assert.Nil(t, tagApplication(desired, "my-app"))
+	assert.Equal(t, current.Tags, desired.Tags)
+	fields, err = diffModels(desired, current)
+	assert.Nil(t, err)
+	assert.Empty(t, fields)
+}
+
+type fakeResources map[string]interface{}
+
+func (f fakeResources) client(calls *[]string) *resourceClient {
+	record := func(action string) modelAction {
+		return func(m interface{}) error {
+			fields, _ := modelMap(m)
+			*calls = append(*calls, action+" "+fields["name"].(string))
+			return nil
+		}
+	}
+	return &resourceClient{
+		get: func(name string) (interface{}, error) {
+			return f[name], nil
+		},
+		list: func(app string) ([]interface{}, error) {
+			var result []interface{}
+			for _, m := range f {
+				result = append(result, m)
+			}
+			return result, nil
+		},
+		create: record("create"),
+		delete: func(m interface{}) error {
+			fields, _ := modelMap(m)
+			delete(f, fields["name"].(string))
+			return record("delete")(m)
+		},
+	}
+}
+
+func TestPlanChanges(t *testing.T) {
+	var calls []string
+	functions := fakeResources{
+		"unchanged": &functionModels.Function{Name: swag.String("unchanged"), Image: swag.String("python3")},
+		"updated":   &functionModels.Function{Name: swag.String("updated"), Image: swag.String("nodejs6")},
+		"removed":   &functionModels.Function{Name: swag.String("removed"), Image: swag.String("python3")},
+	}
+	clients := map[string]*resourceClient{}
+	for _, kind := range resourceKinds {
+		clients[kind] = fakeResources{}.client(&calls)
+	}
+	clients[utils.FunctionKind] = functions.client(&calls)
+
+	resources := []*resource{
+		{Kind: utils.FunctionKind, Name: "unchanged", Model: &functionModels.Function{Name: swag.String("unchanged"), Image: swag.String("python3")}},
+		{Kind: utils.FunctionKind, Name: "updated", Model: &functionModels.Function{Name: swag.String("updated"), Image: swag.String("python3")}},
+		{Kind: utils.FunctionKind, Name: "created", Model: &functionModels.Function{Name: swag.String("created"), Image: swag.String("python3")}},
+	}
+	changes, err := planChanges(resources, clients, "", false)
+	assert.Nil(t, err)
+	assert.Len(t, changes, 3)
+	assert.Equal(t, changeUnchanged, changes[0].Action)
+	assert.Equal(t, changeUpdate, changes[1].Action)
+	assert.Equal(t, []string{"image"}, changes[1].Fields)
+	assert.Equal(t, changeCreate, changes[2].Action)
+
+	changes, err = planChanges(resources, clients, "my-app", true)
+	assert.Nil(t, err)
+	assert.Len(t, changes, 4)
+	// resources are added to the application
+	assert.Equal(t, []string{"tags"}, changes[0].Fields)
+	assert.Equal(t, []string{"image", "tags"}, changes[1].Fields)
+	assert.Equal(t, changeDelete, changes[3].Action)
+	assert.Equal(t, "removed", changes[3].Name)
+
+	// functions cannot be updated by the fake client, they are created again
+	for _, c := range changes {
+		assert.Nil(t, applyChange(clients[c.Kind], c))
+	}
+	assert.Equal(t, []string{"delete unchanged", "create unchanged", "delete updated", "create updated", "create created", "delete removed"}, calls)
+
+	resources = append(resources, resources[0])
+	_, err = planChanges(resources, clients, "", false)
+	assert.Error(t, err)
+}
+
+func TestPlanChangesPrune(t *testing.T) {
+	var calls []string
+	secrets := fakeResources{
+		"removed": &secretModels.Secret{Name: swag.String("removed")},
+	}
+	clients := map[string]*resourceClient{}
+	for _, kind := range resourceKinds {
+		clients[kind] = fakeResources{}.client(&calls)
+	}
+	clients[utils.SecretKind] = secrets.client(&calls)
+	// applications and policies are not tagged
+	clients[utils.ApplicationKind].list = nil
+	clients[utils.PolicyKind].list = nil
+
+	// the secrets are removed from the file altogether
+	resources := []*resource{
+		{Kind: utils.ApplicationKind, Name: "my-app", Model: &applicationModels.Application{Name: swag.String("my-app")}},
+		{Kind: utils.FunctionKind, Name: "hello", Model: &functionModels.Function{Name: swag.String("hello"), Image: swag.String("python3")}},
+	}
+	changes, err := planChanges(resources, clients, "my-app", true)
+	assert.Nil(t, err)
+	assert.Len(t, changes, 3)
+	assert.Equal(t, changeDelete, changes[2].Action)
+	assert.Equal(t, utils.SecretKind, changes[2].Kind)
+	assert.Equal(t, "removed", changes[2].Name)
+
+	// resources which cannot be pruned are rejected
+	for _, r := range []*resource{
+		{Kind: utils.PolicyKind, Name: "my-policy", Model: &identityModels.Policy{Name: swag.String("my-policy")}},
+		{Kind: utils.ApplicationKind, Name: "other-app", Model: &applicationModels.Application{Name: swag.String("other-app")}},
+	} {
+		_, err = planChanges(append(resources, r), clients, "my-app", true)
+		assert.Error(t, err)
+		_, err = planChanges(append(resources, r), clients, "my-app", false)
+		assert.Nil(t, err)
+	}
+}
+
+func TestApplySubscriptionChange(t *testing.T) {
+	var calls []string
+	subscriptions := fakeResources{
+		"hello-sub": &eventModels.Subscription{Name: swag.String("hello-sub"), EventType: swag.String("test.event"), Function: swag.String("hello")},
+	}
+	client := subscriptions.client(&calls)
+	// the subscription is deleted in the background, once read again, and cannot be created until then
+	deleting := false
+	get := client.get
+	client.get = func(name string) (interface{}, error) {
+		current, err := get(name)
+		if deleting {
+			deleting = false
+			delete(subscriptions, name)
+		}
+		return current, err
+	}
+	client.delete = func(m interface{}) error {
+		calls = append(calls, "delete hello-sub")
+		deleting = true
+		return nil
+	}
+	create := client.create
+	client.create = func(m interface{}) error {
+		fields, _ := modelMap(m)
+		if _, ok := subscriptions[fields["name"].(string)]; ok {
+			return errors.New("conflict")
+		}
+		return create(m)
+	}
+	clients := map[string]*resourceClient{}
+	for _, kind := range resourceKinds {
+		clients[kind] = fakeResources{}.client(&calls)
+	}
+	clients[utils.SubscriptionKind] = client
+
+	resources := []*resource{
+		{Kind: utils.SubscriptionKind, Name: "hello-sub", Model: &eventModels.Subscription{Name: swag.String("hello-sub"), EventType: swag.String("other.event"), Function: swag.String("hello")}},
+	}
+	changes, err := planChanges(resources, clients, "", false)
+	assert.Nil(t, err)
+	assert.Len(t, changes, 1)
+	assert.Equal(t, changeUpdate, changes[0].Action)
+	assert.Equal(t, []string{"event-type"}, changes[0].Fields)
+
+	assert.Nil(t, applyChange(clients[utils.SubscriptionKind], changes[0]))
+	assert.Equal(t, []string{"delete hello-sub", "create hello-sub"}, calls)
+}
@@ -35,8 +35,10 @@ var validResources = i18n.T(`Valid resource types include:
 	* apis
 	* applications
 	* base-images
+	* deadletters
 	* eventdrivers
 	* eventdrivertypes
+	* events
 	* functions
 	* images
 	* secrets
@@ -98,9 +100,15 @@ func NewCLI(in io.Reader, out, errOut io.Writer) *cobra.Command {
 	cmds.AddCommand(NewCmdUpdate(out, errOut))
 	cmds.AddCommand(NewCmdExec(out, errOut))
 	cmds.AddCommand(NewCmdDelete(out, errOut))
+	cmds.AddCommand(NewCmdApply(out, errOut))
+	cmds.AddCommand(NewCmdDiff(out, errOut))
+	cmds.AddCommand(NewCmdPublish(out, errOut))
+	cmds.AddCommand(NewCmdRollback(out, errOut))
+	cmds.AddCommand(NewCmdGC(out, errOut))
 	cmds.AddCommand(NewCmdLogin(in, out, errOut))
 	cmds.AddCommand(NewCmdLogout(in, out, errOut))
 	cmds.AddCommand(NewCmdEmit(out, errOut))
+	cmds.AddCommand(NewCmdReplay(out, errOut))
 	cmds.AddCommand(NewCmdInstall(out, errOut))
 	cmds.AddCommand(NewCmdUninstall(out, errOut))
 	cmds.AddCommand(NewCmdVersion(out))
@@ -19,7 +19,9 @@ import (
 	"github.com/spf13/cobra"
 
 	apiModels "github.com/vmware/dispatch/pkg/api-manager/gen/models"
+	applicationModels "github.com/vmware/dispatch/pkg/application-manager/gen/models"
 	"github.com/vmware/dispatch/pkg/dispatchcli/i18n"
+	eventModels "github.com/vmware/dispatch/pkg/event-manager/gen/models"
 	functionModels "github.com/vmware/dispatch/pkg/function-manager/gen/models"
 	policyModels "github.com/vmware/dispatch/pkg/identity-manager/gen/models"
 	imageModels "github.com/vmware/dispatch/pkg/image-manager/gen/models"
@@ -38,15 +40,45 @@ var (
 
 type modelAction func(interface{}) error
 
-type importFunction struct {
-	functionModels.Function
+// resource is a document of a resource file
+type resource struct {
+	Kind  string
+	Name  string
+	Model interface{}
 }
 
-func importFile(out io.Writer, errOut io.Writer, cmd *cobra.Command, args []string, actionMap map[string]modelAction) error {
-	fullPath := path.Join(workDir, file)
+// newResourceModel returns an empty model of a kind of resource, nil if the kind is unknown
+func newResourceModel(kind string) interface{} {
+	switch kind {
+	case utils.APIKind:
+		return &apiModels.API{}
+	case utils.ApplicationKind:
+		return &applicationModels.Application{}
+	case utils.BaseImageKind:
+		return &imageModels.BaseImage{}
+	case utils.ImageKind:
+		return &imageModels.Image{}
+	case utils.FunctionKind:
+		return &functionModels.Function{}
+	case utils.SecretKind:
+		return &secretModels.Secret{}
+	case utils.PolicyKind:
+		return &policyModels.Policy{}
+	case utils.DriverTypeKind:
+		return &eventModels.DriverType{}
+	case utils.DriverKind:
+		return &eventModels.Driver{}
+	case utils.SubscriptionKind:
+		return &eventModels.Subscription{}
+	}
+	return nil
+}
+
+// readResources reads the resources of a YAML file, documents of unknown kinds are skipped
+func readResources(fullPath string) ([]*resource, error) {
 	b, err := ioutil.ReadFile(fullPath)
 	if err != nil {
-		return errors.Wrapf(err, "Error reading file %s", fullPath)
+		return nil, errors.Wrapf(err, "Error reading file %s", fullPath)
 	}
 
 	// Manually split up the yaml doc.  This is NOT a streaming parser.
@@ -56,108 +88,94 @@ func importFile(out io.Writer, errOut io.Writer, cmd *cobra.Command, args []stri
 		Kind string `json:"kind"`
 	}
 
-	type output struct {
-		APIs       []*apiModels.API           `json:"api"`
-		BaseImages []*imageModels.BaseImage   `json:"baseImages"`
-		Images     []*imageModels.Image       `json:"images"`
-		Functions  []*functionModels.Function `json:"functions"`
-		Secrets    []*secretModels.Secret     `json:"secrets"`
-		Policies   []*policyModels.Policy     `json:"policies"`
-	}
-
-	o := output{}
-
+	var resources []*resource
 	for _, doc := range docs {
 		k := kind{}
 		err = yaml.Unmarshal(doc, &k)
 		if err != nil {
-			return errors.Wrapf(err, "Error decoding document %s", string(doc))
+			return nil, errors.Wrapf(err, "Error decoding document %s", string(doc))
 		}
-		switch docKind := k.Kind; docKind {
-		case utils.APIKind:
-			m := &apiModels.API{}
-			err = yaml.Unmarshal(doc, &m)
-			if err != nil {
-				return errors.Wrapf(err, "Error decoding api document %s", string(doc))
-			}
-			err = actionMap[docKind](m)
+		m := newResourceModel(k.Kind)
+		if m == nil {
+			continue
+		}
+		err = yaml.Unmarshal(doc, m)
+		if err != nil {
+			return nil, errors.Wrapf(err, "Error decoding %s document %s", k.Kind, string(doc))
+		}
+		if f, ok := m.(*functionModels.Function); ok && f.Code != nil && strings.HasPrefix(*f.Code, "@") {
+			functionPath := path.Join(workDir, (*f.Code)[1:])
+			codeFileContent, err := ioutil.ReadFile(functionPath)
 			if err != nil {
-				return err
+				return nil, errors.Wrapf(err, "Error when reading content of %s", functionPath)
 			}
+			codeEncoded := string(codeFileContent)
+			f.Code = &codeEncoded
+		}
+		name := struct {
+			Name string `json:"name"`
+		}{}
+		if err := yaml.Unmarshal(doc, &name); err != nil || name.Name == "" {
+			return nil, errors.Errorf("Error decoding %s document %s: missing name", k.Kind, string(doc))
+		}
+		resources = append(resources, &resource{Kind: k.Kind, Name: name.Name, Model: m})
+	}
+	return resources, nil
+}
+
+func importFile(out io.Writer, errOut io.Writer, cmd *cobra.Command, args []string, actionMap map[string]modelAction) error {
+	resources, err := readResources(path.Join(workDir, file))
+	if err != nil {
+		return err
+	}
+
+	type output struct {
+		APIs          []*apiModels.API                 `json:"api"`
+		Applications  []*applicationModels.Application `json:"applications"`
+		BaseImages    []*imageModels.BaseImage         `json:"baseImages"`
+		Images        []*imageModels.Image             `json:"images"`
+		Functions     []*functionModels.Function       `json:"functions"`
+		Secrets       []*secretModels.Secret           `json:"secrets"`
+		Policies      []*policyModels.Policy           `json:"policies"`
+		DriverTypes   []*eventModels.DriverType        `json:"driverTypes"`
+		Drivers       []*eventModels.Driver            `json:"drivers"`
+		Subscriptions []*eventModels.Subscription      `json:"subscriptions"`
+	}
+
+	o := output{}
+
+	for _, r := range resources {
+		action, ok := actionMap[r.Kind]
+		if !ok {
+			continue
+		}
+		err = action(r.Model)
+		if err != nil {
+			return err
+		}
+		switch m := r.Model.(type) {
+		case *apiModels.API:
 			o.APIs = append(o.APIs, m)
-			fmt.Fprintf(out, "Created %s: %s\n", docKind, *m.Name)
-		case utils.BaseImageKind:
-			m := &imageModels.BaseImage{}
-			err = yaml.Unmarshal(doc, &m)
-			if err != nil {
-				return errors.Wrapf(err, "Error decoding base image document %s", string(doc))
-			}
-			err = actionMap[docKind](m)
-			if err != nil {
-				return err
-			}
+		case *applicationModels.Application:
+			o.Applications = append(o.Applications, m)
+		case *imageModels.BaseImage:
 			o.BaseImages = append(o.BaseImages, m)
-			fmt.Fprintf(out, "Created %s: %s\n", docKind, *m.Name)
-		case utils.ImageKind:
-			m := &imageModels.Image{}
-			err = yaml.Unmarshal(doc, &m)
-			if err != nil {
-				return errors.Wrapf(err, "Error decoding image document %s", string(doc))
-			}
-			err = actionMap[docKind](m)
-			if err != nil {
-				return err
-			}
+		case *imageModels.Image:
 			o.Images = append(o.Images, m)
-			fmt.Fprintf(out, "Created %s: %s\n", docKind, *m.Name)
-		case utils.FunctionKind:
-			m := &functionModels.Function{}
-			err = yaml.Unmarshal(doc, &m)
-			if err != nil {
-				return errors.Wrapf(err, "Error decoding function document %s", string(doc))
-			}
-			if strings.HasPrefix(*m.Code, "@") {
-				functionPath := path.Join(workDir, (*m.Code)[1:])
-				codeFileContent, err := ioutil.ReadFile(functionPath)
-				if err != nil {
-					return errors.Wrapf(err, "Error when reading content of %s", functionPath)
-				}
-				codeEncoded := string(codeFileContent)
-				m.Code = &codeEncoded
-			}
-			err = actionMap[docKind](m)
-			if err != nil {
-				return err
-			}
+		case *functionModels.Function:
 			o.Functions = append(o.Functions, m)
-			fmt.Fprintf(out, "Created %s: %s\n", docKind, *m.Name)
-		case utils.SecretKind:
-			m := &secretModels.Secret{}
-			err = yaml.Unmarshal(doc, &m)
-			if err != nil {
-				return errors.Wrapf(err, "Error decoding secret document %s", string(doc))
-			}
-			err = actionMap[docKind](m)
-			if err != nil {
-				return err
-			}
+		case *secretModels.Secret:
 			o.Secrets = append(o.Secrets, m)
-			fmt.Fprintf(out, "Created %s: %s\n", docKind, *m.Name)
-		case utils.PolicyKind:
-			m := &policyModels.Policy{}
-			err := yaml.Unmarshal(doc, &m)
-			if err != nil {
-				return errors.Wrapf(err, "Error decoding policy document &s", string(doc))
-			}
-			err = actionMap[docKind](m)
-			if err != nil {
-				return err
-			}
+		case *policyModels.Policy:
 			o.Policies = append(o.Policies, m)
-			fmt.Fprintf(out, "Created %s: %s\n", docKind, *m.Name)
-		default:
-			continue
+		case *eventModels.DriverType:
+			o.DriverTypes = append(o.DriverTypes, m)
+		case *eventModels.Driver:
+			o.Drivers = append(o.Drivers, m)
+		case *eventModels.Subscription:
+			o.Subscriptions = append(o.Subscriptions, m)
 		}
+		fmt.Fprintf(out, "Created %s: %s\n", r.Kind, r.Name)
 	}
 	if dispatchConfig.JSON {
 		encoder := json.NewEncoder(out)
@@ -184,11 +202,16 @@ func NewCmdCreate(out io.Writer, errOut io.Writer) *cobra.Command {
 			}
 
 			createMap := map[string]modelAction{
-				utils.ImageKind:     CallCreateImage,
-				utils.BaseImageKind: CallCreateBaseImage,
-				utils.FunctionKind:  CallCreateFunction,
-				utils.SecretKind:    CallCreateSecret,
-				utils.PolicyKind:    CallCreatePolicy,
+				utils.APIKind:          CallCreateAPI,
+				utils.ApplicationKind:  CallCreateApplication,
+				utils.ImageKind:        CallCreateImage,
+				utils.BaseImageKind:    CallCreateBaseImage,
+				utils.FunctionKind:     CallCreateFunction,
+				utils.SecretKind:       CallCreateSecret,
+				utils.PolicyKind:       CallCreatePolicy,
+				utils.DriverTypeKind:   CallCreateEventDriverType,
+				utils.DriverKind:       CallCreateEventDriver,
+				utils.SubscriptionKind: CallCreateSubscription,
 			}
 
 			err := importFile(out, errOut, cmd, args, createMap)
@@ -205,6 +228,8 @@ func NewCmdCreate(out io.Writer, errOut io.Writer) *cobra.Command {
 	cmd.AddCommand(NewCmdCreateFunction(out, errOut))
 	cmd.AddCommand(NewCmdCreateSecret(out, errOut))
 	cmd.AddCommand(NewCmdCreateAPI(out, errOut))
+	cmd.AddCommand(NewCmdCreateConsumer(out, errOut))
+	cmd.AddCommand(NewCmdCreateCredential(out, errOut))
 	cmd.AddCommand(NewCmdCreateSubscription(out, errOut))
 	cmd.AddCommand(NewCmdCreateEventDriver(out, errOut))
 	cmd.AddCommand(NewCmdCreateEventDriverType(out, errOut))
@@ -9,6 +9,8 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"strconv"
+	"strings"
 
 	"github.com/go-openapi/swag"
 	"github.com/spf13/cobra"
@@ -24,7 +26,16 @@ var (
 		`Create dispatch function api.
 
 Note:
-  Import your own tls certificates if you want to use your own domain name with HTTPS secure connection
+  Import your own tls certificates if you want to use your own domain name with HTTPS secure connection. Create a
+  secret with the certificate (tls.crt) and key (tls.key) in PEM format, and reference it with --tls-secret.
+
+  Limit the requests of each client (the consumer, or the IP address of anonymous clients) with --rate-limit, as
+  requests per second, minute or hour, or as daily or monthly quotas, e.g. --rate-limit 10/second --rate-limit 1000/day.
+  Throttled requests get a 429 (Too Many Requests) response.
+
+  Create the APIs of an OpenAPI (swagger 2.0) spec with --from-openapi. Each operation with an x-dispatch-function
+  extension becomes an API, the path parameters, hosts, schemes and security definitions of the spec are kept. The
+  input and output schemas of the functions are set from the body (or query) parameters and success responses.
 		`)
 	// TODO: add examples
 	createAPIExample = i18n.T(``)
@@ -36,34 +47,102 @@ Note:
 	paths                = []string{"/"}
 	methods              = []string{"GET"}
 	auth                 = "public"
+	tlsSecret            = ""
+	rateLimits           = []string{}
+	fromOpenAPI          = ""
 	createAPIApplication = i18n.T(``)
 )
 
 // NewCmdCreateAPI creates command responsible for dispatch function api creation.
 func NewCmdCreateAPI(out io.Writer, errOut io.Writer) *cobra.Command {
 	cmd := &cobra.Command{
-		Use:     "api API_NAME FUNCTION_NAME [--auth AUTH_METHOD] [--domain DOMAINNAME...] [--method METHOD...] [--path PATH...] [--disable] [--cors] [--https-only]",
+		Use:     "api [API_NAME FUNCTION_NAME | --from-openapi FILE] [--auth AUTH_METHOD] [--domain DOMAINNAME...] [--method METHOD...] [--path PATH...] [--disable] [--cors] [--https-only] [--tls-secret SECRET_NAME] [--rate-limit LIMIT/PERIOD...]",
 		Short:   i18n.T("Create api"),
 		Long:    createAPILong,
 		Example: createAPIExample,
-		Args:    cobra.ExactArgs(2),
+		Args: func(cmd *cobra.Command, args []string) error {
+			if fromOpenAPI != "" {
+				return cobra.NoArgs(cmd, args)
+			}
+			return cobra.ExactArgs(2)(cmd, args)
+		},
 		Run: func(cmd *cobra.Command, args []string) {
-			err := createAPI(out, errOut, cmd, args)
+			var err error
+			if fromOpenAPI != "" {
+				err = createAPIsFromOpenAPI(out, fromOpenAPI)
+			} else {
+				err = createAPI(out, errOut, cmd, args)
+			}
 			CheckErr(err)
 		},
 	}
 
 	cmd.Flags().StringVarP(&cmdFlagApplication, "application", "a", "", "associate with an application")
 	cmd.Flags().StringArrayVarP(&hosts, "domain", "d", []string{}, "domain names that point to your API (multi-values), default: empty")
-	cmd.Flags().StringArrayVarP(&paths, "path", "p", []string{"/"}, "paths that point to your API (multi-values), default: /")
+	cmd.Flags().StringArrayVarP(&paths, "path", "p", []string{"/"}, "paths that point to your API (multi-values), with parameters in braces, e.g. /users/{id}, default: /")
 	cmd.Flags().StringArrayVarP(&methods, "method", "m", []string{"GET"}, "methods that point to your API, default: GET")
 	cmd.Flags().BoolVar(&httpsOnly, "https-only", false, "only support https connections, default: false")
 	cmd.Flags().BoolVar(&disable, "disable", false, "disable the api, default: false")
 	cmd.Flags().BoolVar(&cors, "cors", false, "enable CORS, default: false")
-	cmd.Flags().StringVar(&auth, "auth", "public", "specify end-user authentication method, (e.g. public, basic, oauth2), default: public")
+	cmd.Flags().StringVar(&auth, "auth", "public", "specify end-user authentication method (public, basic, key, jwt or oauth2), default: public")
+	cmd.Flags().StringVar(&tlsSecret, "tls-secret", "", "secret holding the tls certificate (tls.crt) and key (tls.key) of the domains")
+	cmd.Flags().StringArrayVar(&rateLimits, "rate-limit", []string{}, "maximum requests of each client per period (second, minute, hour, day or month), e.g. 10/second (multi-values)")
+	cmd.Flags().StringVar(&fromOpenAPI, "from-openapi", "", "create the APIs of the operations of an OpenAPI (swagger 2.0) spec, in JSON or YAML")
 	return cmd
 }
 
+// parseRateLimit parses limits like 10/second or 1000/day, nil means no limit
+func parseRateLimit(limits []string) (*models.RateLimit, error) {
+	var rateLimit *models.RateLimit
+	for _, l := range limits {
+		if l == "" {
+			continue
+		}
+		parts := strings.Split(l, "/")
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("invalid rate limit %s, expected LIMIT/PERIOD", l)
+		}
+		value, err := strconv.ParseInt(parts[0], 10, 64)
+		if err != nil || value < 0 {
+			return nil, fmt.Errorf("invalid rate limit %s, the limit is not a positive number", l)
+		}
+		if rateLimit == nil {
+			rateLimit = &models.RateLimit{}
+		}
+		switch strings.ToLower(parts[1]) {
+		case "s", "sec", "second":
+			rateLimit.Second = swag.Int64(value)
+		case "m", "min", "minute":
+			rateLimit.Minute = swag.Int64(value)
+		case "h", "hour":
+			rateLimit.Hour = swag.Int64(value)
+		case "d", "day":
+			rateLimit.Day = swag.Int64(value)
+		case "month":
+			rateLimit.Month = swag.Int64(value)
+		default:
+			return nil, fmt.Errorf("invalid rate limit %s, the period is one of second, minute, hour, day or month", l)
+		}
+	}
+	return rateLimit, nil
+}
+
+// CallCreateAPI makes the API call to create an api
+func CallCreateAPI(input interface{}) error {
+	api := input.(*models.API)
+	params := &apiclient.AddAPIParams{
+		Body:    api,
+		Context: context.Background(),
+	}
+
+	created, err := apiManagerClient().Endpoint.AddAPI(params, GetAuthInfoWriter())
+	if err != nil {
+		return formatAPIError(err, params)
+	}
+	*api = *created.Payload
+	return nil
+}
+
 func createAPI(out, errOut io.Writer, cmd *cobra.Command, args []string) error {
 
 	apiName := args[0]
@@ -73,6 +152,10 @@ func createAPI(out, errOut io.Writer, cmd *cobra.Command, args []string) error {
 	if httpsOnly {
 		protocols = []string{"https"}
 	}
+	rateLimit, err := parseRateLimit(rateLimits)
+	if err != nil {
+		return formatCliError(err, fmt.Sprintf("Failed parsing rate-limit values: %v", err))
+	}
 
 	api := &models.API{
 		Name:           swag.String(apiName),
@@ -84,6 +167,8 @@ func createAPI(out, errOut io.Writer, cmd *cobra.Command, args []string) error {
 		Authentication: auth,
 		Enabled:        !disable,
 		Cors:           cors,
+		TLS:            tlsSecret,
+		RateLimit:      rateLimit,
 		Tags:           []*models.Tag{},
 	}
 	if cmdFlagApplication != "" {
@@ -93,21 +178,15 @@ func createAPI(out, errOut io.Writer, cmd *cobra.Command, args []string) error {
 		})
 	}
 
-	params := &apiclient.AddAPIParams{
-		Body:    api,
-		Context: context.Background(),
-	}
-	client := apiManagerClient()
-
-	created, err := client.Endpoint.AddAPI(params, GetAuthInfoWriter())
+	err = CallCreateAPI(api)
 	if err != nil {
-		return formatAPIError(err, params)
+		return err
 	}
 	if dispatchConfig.JSON {
 		encoder := json.NewEncoder(out)
 		encoder.SetIndent("", "    ")
-		return encoder.Encode(*created.Payload)
+		return encoder.Encode(*api)
 	}
-	fmt.Fprintf(out, "Created api: %s\n", *created.Payload.Name)
+	fmt.Fprintf(out, "Created api: %s\n", *api.Name)
 	return nil
 }
@@ -24,3 +24,21 @@ func TestCmdCreateAPI(t *testing.T) {
 	assert.True(t, strings.Contains(buf.String(), "Create dispatch function api."))
 
 }
+
+func TestParseRateLimit(t *testing.T) {
+	rateLimit, err := parseRateLimit([]string{"10/second", "100/min", "1000/day"})
+	assert.Nil(t, err)
+	assert.Equal(t, int64(10), *rateLimit.Second)
+	assert.Equal(t, int64(100), *rateLimit.Minute)
+	assert.Nil(t, rateLimit.Hour)
+	assert.Equal(t, int64(1000), *rateLimit.Day)
+
+	rateLimit, err = parseRateLimit([]string{""})
+	assert.Nil(t, err)
+	assert.Nil(t, rateLimit)
+
+	_, err = parseRateLimit([]string{"10"})
+	assert.NotNil(t, err)
+	_, err = parseRateLimit([]string{"10/year"})
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,86 @@
+///////////////////////////////////////////////////////////////////////
+// Copyright (c) 2018 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+///////////////////////////////////////////////////////////////////////
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/go-openapi/swag"
+	"github.com/spf13/cobra"
+	"golang.org/x/net/context"
+
+	consumerclient "github.com/vmware/dispatch/pkg/api-manager/gen/client/consumer"
+	"github.com/vmware/dispatch/pkg/api-manager/gen/models"
+	"github.com/vmware/dispatch/pkg/dispatchcli/i18n"
+)
+
+var (
+	createConsumerLong = i18n.T(
+		`Create an api consumer, an end-user of apis which require authentication.
+
+Issue credentials to the consumer with "dispatch create credential" once it is READY. The rate limits of a consumer
+(--rate-limit) apply to all apis, instead of the rate limits of the apis.`)
+
+	// TODO: add examples
+	createConsumerExample = i18n.T(``)
+)
+
+// NewCmdCreateConsumer creates command responsible for api consumer creation.
+func NewCmdCreateConsumer(out io.Writer, errOut io.Writer) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "consumer CONSUMER_NAME [--rate-limit LIMIT/PERIOD...]",
+		Short:   i18n.T("Create api consumer"),
+		Long:    createConsumerLong,
+		Example: createConsumerExample,
+		Args:    cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			err := createConsumer(out, errOut, cmd, args)
+			CheckErr(err)
+		},
+	}
+	cmd.Flags().StringVarP(&cmdFlagApplication, "application", "a", "", "associate with an application")
+	cmd.Flags().StringArrayVar(&rateLimits, "rate-limit", []string{}, "maximum requests per period (second, minute, hour, day or month), e.g. 10/second (multi-values)")
+	return cmd
+}
+
+func createConsumer(out, errOut io.Writer, cmd *cobra.Command, args []string) error {
+
+	rateLimit, err := parseRateLimit(rateLimits)
+	if err != nil {
+		return formatCliError(err, fmt.Sprintf("Failed parsing rate-limit values: %v", err))
+	}
+	consumer := &models.Consumer{
+		Name:      swag.String(args[0]),
+		RateLimit: rateLimit,
+		Tags:      []*models.Tag{},
+	}
+	if cmdFlagApplication != "" {
+		consumer.Tags = append(consumer.Tags, &models.Tag{
+			Key:   "Application",
+			Value: cmdFlagApplication,
+		})
+	}
+
+	params := &consumerclient.AddConsumerParams{
+		Body:    consumer,
+		Context: context.Background(),
+	}
+	client := apiManagerClient()
+
+	created, err := client.Consumer.AddConsumer(params, GetAuthInfoWriter())
+	if err != nil {
+		return formatAPIError(err, params)
+	}
+	if dispatchConfig.JSON {
+		encoder := json.NewEncoder(out)
+		encoder.SetIndent("", "    ")
+		return encoder.Encode(*created.Payload)
+	}
+	fmt.Fprintf(out, "Created consumer: %s\n", *created.Payload.Name)
+	return nil
+}
@@ -0,0 +1,114 @@
+///////////////////////////////////////////////////////////////////////
+// Copyright (c) 2018 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+///////////////////////////////////////////////////////////////////////
+
+package cmd
+
+import (
+	"encoding/json"
+	"io"
+
+	"github.com/go-openapi/swag"
+	"github.com/olekukonko/tablewriter"
+	"github.com/spf13/cobra"
+	"golang.org/x/net/context"
+
+	consumerclient "github.com/vmware/dispatch/pkg/api-manager/gen/client/consumer"
+	"github.com/vmware/dispatch/pkg/api-manager/gen/models"
+	"github.com/vmware/dispatch/pkg/dispatchcli/i18n"
+)
+
+var (
+	createCredentialLong = i18n.T(
+		`Issue a credential to an api consumer.
+
+The credential type matches the authentication method of the apis (basic, key, jwt or oauth2). Secrets, which are not
+specified, are generated. They are only shown once, when the credential is issued.`)
+
+	createCredentialExample = i18n.T(`
+# Issue an api key
+dispatch create credential my-consumer --type key
+
+# Issue a basic authentication credential with a given password
+dispatch create credential my-consumer --type basic --username my-user --password my-password
+
+# Issue oauth2 client credentials
+dispatch create credential my-consumer --type oauth2 --redirect-uri https://example.com/callback`)
+
+	credentialType        = ""
+	credentialUsername    = ""
+	credentialPassword    = ""
+	credentialKey         = ""
+	credentialSecret      = ""
+	credentialRedirectURI = ""
+)
+
+// NewCmdCreateCredential creates command responsible for issuing credentials to api consumers.
+func NewCmdCreateCredential(out io.Writer, errOut io.Writer) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "credential CONSUMER_NAME --type TYPE [--username USERNAME] [--password PASSWORD] [--key KEY] [--secret SECRET] [--redirect-uri URI]",
+		Short:   i18n.T("Issue a credential to an api consumer"),
+		Long:    createCredentialLong,
+		Example: createCredentialExample,
+		Args:    cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			err := createCredential(out, errOut, cmd, args)
+			CheckErr(err)
+		},
+	}
+	cmd.Flags().StringVar(&credentialType, "type", "", "credential type (basic, key, jwt or oauth2)")
+	cmd.Flags().StringVar(&credentialUsername, "username", "", "user name (basic) or application name (oauth2), default: the consumer name")
+	cmd.Flags().StringVar(&credentialPassword, "password", "", "password (basic)")
+	cmd.Flags().StringVar(&credentialKey, "key", "", "api key (key), key (jwt) or client id (oauth2)")
+	cmd.Flags().StringVar(&credentialSecret, "secret", "", "secret (jwt) or client secret (oauth2)")
+	cmd.Flags().StringVar(&credentialRedirectURI, "redirect-uri", "", "redirect uri of the client application (oauth2)")
+	return cmd
+}
+
+func createCredential(out, errOut io.Writer, cmd *cobra.Command, args []string) error {
+
+	if credentialType == "" {
+		return formatCliError(nil, "credential type is required")
+	}
+	params := &consumerclient.AddCredentialParams{
+		Consumer: args[0],
+		Body: &models.Credential{
+			Type:        swag.String(credentialType),
+			Username:    credentialUsername,
+			Password:    credentialPassword,
+			Key:         credentialKey,
+			Secret:      credentialSecret,
+			RedirectURI: credentialRedirectURI,
+		},
+		Context: context.Background(),
+	}
+	client := apiManagerClient()
+
+	created, err := client.Consumer.AddCredential(params, GetAuthInfoWriter())
+	if err != nil {
+		return formatAPIError(err, params)
+	}
+	return formatCredentialOutput(out, false, []*models.Credential{created.Payload})
+}
+
+func formatCredentialOutput(out io.Writer, list bool, credentials []*models.Credential) error {
+
+	if dispatchConfig.JSON {
+		encoder := json.NewEncoder(out)
+		encoder.SetIndent("", "    ")
+		if list {
+			return encoder.Encode(credentials)
+		}
+		return encoder.Encode(credentials[0])
+	}
+	table := tablewriter.NewWriter(out)
+	table.SetHeader([]string{"ID", "Type", "Username", "Password", "Key", "Secret"})
+	table.SetBorders(tablewriter.Border{Left: false, Top: false, Right: false, Bottom: false})
+	table.SetCenterSeparator("")
+	for _, c := range credentials {
+		table.Append([]string{c.ID, *c.Type, c.Username, c.Password, c.Key, c.Secret})
+	}
+	table.Render()
+	return nil
+}
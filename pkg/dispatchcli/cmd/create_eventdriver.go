@@ -57,6 +57,22 @@ func NewCmdCreateEventDriver(out io.Writer, errOut io.Writer) *cobra.Command {
 	return cmd
 }
 
+// CallCreateEventDriver makes the API call to create an event driver
+func CallCreateEventDriver(input interface{}) error {
+	eventDriver := input.(*models.Driver)
+	params := &client.AddDriverParams{
+		Body:    eventDriver,
+		Context: context.Background(),
+	}
+
+	created, err := eventManagerClient().Drivers.AddDriver(params, GetAuthInfoWriter())
+	if err != nil {
+		return formatAPIError(err, params)
+	}
+	*eventDriver = *created.Payload
+	return nil
+}
+
 func createEventDriver(out, errOut io.Writer, cmd *cobra.Command, args []string) error {
 
 	driverType := args[0]
@@ -87,20 +103,15 @@ func createEventDriver(out, errOut io.Writer, cmd *cobra.Command, args []string)
 		})
 	}
 
-	params := &client.AddDriverParams{
-		Body:    eventDriver,
-		Context: context.Background(),
-	}
-
-	created, err := eventManagerClient().Drivers.AddDriver(params, GetAuthInfoWriter())
+	err := CallCreateEventDriver(eventDriver)
 	if err != nil {
-		return formatAPIError(err, params)
+		return err
 	}
 	if dispatchConfig.JSON {
 		encoder := json.NewEncoder(out)
 		encoder.SetIndent("", "    ")
-		return encoder.Encode(*created.Payload)
+		return encoder.Encode(*eventDriver)
 	}
-	fmt.Fprintf(out, "Created event driver: %s\n", *created.Payload.Name)
+	fmt.Fprintf(out, "Created event driver: %s\n", *eventDriver.Name)
 	return nil
 }
@@ -43,6 +43,22 @@ func NewCmdCreateEventDriverType(out io.Writer, errOut io.Writer) *cobra.Command
 	return cmd
 }
 
+// CallCreateEventDriverType makes the API call to create an event driver type
+func CallCreateEventDriverType(input interface{}) error {
+	eventDriverType := input.(*models.DriverType)
+	params := &client.AddDriverTypeParams{
+		Body:    eventDriverType,
+		Context: context.Background(),
+	}
+
+	created, err := eventManagerClient().Drivers.AddDriverType(params, GetAuthInfoWriter())
+	if err != nil {
+		return formatAPIError(err, params)
+	}
+	*eventDriverType = *created.Payload
+	return nil
+}
+
 func createEventDriverType(out, errOut io.Writer, cmd *cobra.Command, args []string) error {
 
 	typeName := args[0]
@@ -60,21 +76,15 @@ func createEventDriverType(out, errOut io.Writer, cmd *cobra.Command, args []str
 		})
 	}
 
-	params := &client.AddDriverTypeParams{
-		Body:    eventDriverType,
-		Context: context.Background(),
-	}
-	client := eventManagerClient()
-
-	created, err := client.Drivers.AddDriverType(params, GetAuthInfoWriter())
+	err := CallCreateEventDriverType(eventDriverType)
 	if err != nil {
-		return formatAPIError(err, params)
+		return err
 	}
 	if dispatchConfig.JSON {
 		encoder := json.NewEncoder(out)
 		encoder.SetIndent("", "    ")
-		return encoder.Encode(*created.Payload)
+		return encoder.Encode(*eventDriverType)
 	}
-	fmt.Fprintf(out, "Created event driver type: %s\n", *created.Payload.Name)
+	fmt.Fprintf(out, "Created event driver type: %s\n", *eventDriverType.Name)
 	return nil
 }
@@ -11,8 +11,10 @@ import (
 	"io"
 	"io/ioutil"
 	"path"
+	"strings"
 
 	"github.com/go-openapi/spec"
+	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
 	"golang.org/x/net/context"
 
@@ -28,6 +30,13 @@ var (
 	schemaInFile          = ""
 	schemaOutFile         = ""
 	fnSecrets             = []string{}
+	fnTimeout             = int64(0)
+	fnEnv                 = []string{}
+	fnMemory              = ""
+	fnCPU                 = ""
+	fnMinReplicas         = int64(0)
+	fnMaxReplicas         = int64(0)
+	fnConcurrency         = int64(0)
 )
 
 // NewCmdCreateFunction creates command responsible for dispatch function creation.
@@ -47,9 +56,32 @@ func NewCmdCreateFunction(out io.Writer, errOut io.Writer) *cobra.Command {
 	cmd.Flags().StringVar(&schemaInFile, "schema-in", "", "path to file with input validation schema")
 	cmd.Flags().StringVar(&schemaOutFile, "schema-out", "", "path to file with output validation schema")
 	cmd.Flags().StringArrayVar(&fnSecrets, "secret", []string{}, "Function secrets, can be specified multiple times or a comma-delimited string")
+	cmd.Flags().Int64Var(&fnTimeout, "timeout", 0, "Time in seconds after which runs of the function are cancelled, 0 means no timeout")
+	cmd.Flags().StringArrayVar(&fnEnv, "env", []string{}, "Function environment variables, e.g. DEBUG=true, can be specified multiple times")
+	cmd.Flags().StringVar(&fnMemory, "memory", "", "Memory limit of each function instance, e.g. 128Mi")
+	cmd.Flags().StringVar(&fnCPU, "cpu", "", "CPU limit of each function instance, e.g. 500m")
+	cmd.Flags().Int64Var(&fnMinReplicas, "min-replicas", 0, "Minimum number of function instances, 0 means the FaaS default")
+	cmd.Flags().Int64Var(&fnMaxReplicas, "max-replicas", 0, "Maximum number of function instances, 0 means the FaaS default")
+	cmd.Flags().Int64Var(&fnConcurrency, "concurrency", 0, "Maximum number of runs executed at the same time by a function instance, 0 means the FaaS default")
 	return cmd
 }
 
+// parseEnvironment parses environment variables, e.g. DEBUG=true
+func parseEnvironment(values []string) (map[string]string, error) {
+	if len(values) == 0 {
+		return nil, nil
+	}
+	env := make(map[string]string)
+	for _, value := range values {
+		parts := strings.SplitN(value, "=", 2)
+		if len(parts) != 2 || parts[0] == "" {
+			return nil, errors.Errorf("invalid environment variable %s, expected NAME=VALUE", value)
+		}
+		env[parts[0]] = parts[1]
+	}
+	return env, nil
+}
+
 type cliFunction struct {
 	models.Function
 	FunctionPath  string `json:"functionPath"`
@@ -81,6 +113,7 @@ func createFunction(out, errOut io.Writer, cmd *cobra.Command, args []string) er
 		Image:   &args[0],
 		Name:    &args[1],
 		Secrets: fnSecrets,
+		Timeout: &fnTimeout,
 		Tags:    []*models.Tag{},
 	}
 	if cmdFlagApplication != "" {
@@ -90,6 +123,22 @@ func createFunction(out, errOut io.Writer, cmd *cobra.Command, args []string) er
 		})
 	}
 
+	env, err := parseEnvironment(fnEnv)
+	if err != nil {
+		return formatCliError(err, fmt.Sprintf("Failed parsing env values: %v", err))
+	}
+	function.Environment = env
+	if fnMemory != "" || fnCPU != "" {
+		function.Limits = &models.FunctionLimits{Memory: fnMemory, CPU: fnCPU}
+	}
+	if fnMinReplicas != 0 || fnMaxReplicas != 0 || fnConcurrency != 0 {
+		function.Scaling = &models.FunctionScaling{
+			MinReplicas: &fnMinReplicas,
+			MaxReplicas: &fnMaxReplicas,
+			Concurrency: &fnConcurrency,
+		}
+	}
+
 	codeFileContent, err := ioutil.ReadFile(functionPath)
 	if err != nil {
 		message := fmt.Sprintf("Error when reading content of %s", functionPath)
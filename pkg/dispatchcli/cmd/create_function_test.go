@@ -23,3 +23,18 @@ func TestCmdCreateFunction(t *testing.T) {
 	assert.Nil(t, err)
 	assert.True(t, strings.Contains(buf.String(), "Create dispatch function"))
 }
+
+func TestParseEnvironment(t *testing.T) {
+	env, err := parseEnvironment([]string{"DEBUG=true", "URL=http://host/?a=b", "EMPTY="})
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]string{"DEBUG": "true", "URL": "http://host/?a=b", "EMPTY": ""}, env)
+
+	env, err = parseEnvironment(nil)
+	assert.Nil(t, err)
+	assert.Nil(t, env)
+
+	_, err = parseEnvironment([]string{"DEBUG"})
+	assert.Error(t, err)
+	_, err = parseEnvironment([]string{"=true"})
+	assert.Error(t, err)
+}
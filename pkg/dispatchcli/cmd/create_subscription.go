@@ -28,12 +28,17 @@ var (
 	createSubscriptionEventType  string
 	createSubscriptionSourceType string
 	createSubscriptionName       string
+	createSubscriptionFilter     string
+
+	createSubscriptionMaxAttempts     int64
+	createSubscriptionRetryTimeout    int64
+	createSubscriptionDeadLetterTopic string
 )
 
 // NewCmdCreateSubscription creates command responsible for subscription creation.
 func NewCmdCreateSubscription(out io.Writer, errOut io.Writer) *cobra.Command {
 	cmd := &cobra.Command{
-		Use:     "subscription FUNCTION_NAME [--name SUBSCRIPTION_NAME] [--event-type EVENT.TYPE] [--source-type SOURCE-TYPE] [--secret SECRET1,SECRET2...]",
+		Use:     "subscription FUNCTION_NAME [--name SUBSCRIPTION_NAME] [--event-type EVENT.TYPE] [--source-type SOURCE-TYPE] [--secret SECRET1,SECRET2...] [--filter EXPRESSION] [--max-attempts N] [--retry-timeout SECONDS] [--dead-letter-topic TOPIC]",
 		Short:   i18n.T("Create subscription"),
 		Long:    createSubscriptionLong,
 		Example: createSubscriptionExample,
@@ -49,37 +54,59 @@ func NewCmdCreateSubscription(out io.Writer, errOut io.Writer) *cobra.Command {
 	cmd.Flags().StringVar(&createSubscriptionName, "name", "", "Subscription name. If not specified, will be randomly generated.")
 	cmd.Flags().StringVar(&createSubscriptionEventType, "event-type", "*", "Event Type to filter on.")
 	cmd.Flags().StringVar(&createSubscriptionSourceType, "source-type", "*", "Source type to filter on. Most often it will be your event driver type.")
+	cmd.Flags().StringVar(&createSubscriptionFilter, "filter", "", "Expression on event attributes, extensions and data, only matching events are delivered, e.g. 'data.vm.name startsWith \"prod-\"'.")
+	cmd.Flags().Int64Var(&createSubscriptionMaxAttempts, "max-attempts", 0, "Maximum number of function runs for a single event. If not specified, server default is used.")
+	cmd.Flags().Int64Var(&createSubscriptionRetryTimeout, "retry-timeout", 0, "Time in seconds after which retries of failed function runs are abandoned. If not specified, server default is used.")
+	cmd.Flags().StringVar(&createSubscriptionDeadLetterTopic, "dead-letter-topic", "", "Topic to which events are published when all function runs failed. If not specified, server default is used.")
 
 	return cmd
 }
 
-func createSubscription(out, errOut io.Writer, cmd *cobra.Command, args []string) error {
+// CallCreateSubscription makes the API call to create a subscription
+func CallCreateSubscription(input interface{}) error {
+	subscription := input.(*models.Subscription)
 	params := &subscriptions.AddSubscriptionParams{
+		Body:    subscription,
 		Context: context.Background(),
-		Body: &models.Subscription{
-			Name:       swag.String(resourceName(createSubscriptionName)),
-			EventType:  &createSubscriptionEventType,
-			SourceType: &createSubscriptionSourceType,
-			Function:   &args[0],
-			Secrets:    createSubscriptionSecrets,
+	}
+
+	created, err := eventManagerClient().Subscriptions.AddSubscription(params, GetAuthInfoWriter())
+	if err != nil {
+		return formatAPIError(err, params)
+	}
+	*subscription = *created.Payload
+	return nil
+}
+
+func createSubscription(out, errOut io.Writer, cmd *cobra.Command, args []string) error {
+	subscription := &models.Subscription{
+		Name:       swag.String(resourceName(createSubscriptionName)),
+		EventType:  &createSubscriptionEventType,
+		SourceType: &createSubscriptionSourceType,
+		Function:   &args[0],
+		Secrets:    createSubscriptionSecrets,
+		Filter:     createSubscriptionFilter,
+		DeliveryPolicy: &models.DeliveryPolicy{
+			MaxAttempts:     createSubscriptionMaxAttempts,
+			RetryTimeout:    createSubscriptionRetryTimeout,
+			DeadLetterTopic: createSubscriptionDeadLetterTopic,
 		},
 	}
 	if cmdFlagApplication != "" {
-		params.Body.Tags = append(params.Body.Tags, &models.Tag{
+		subscription.Tags = append(subscription.Tags, &models.Tag{
 			Key:   "Application",
 			Value: cmdFlagApplication,
 		})
 	}
-	client := eventManagerClient()
-	created, err := client.Subscriptions.AddSubscription(params, GetAuthInfoWriter())
+	err := CallCreateSubscription(subscription)
 	if err != nil {
-		return formatAPIError(err, params)
+		return err
 	}
 	if dispatchConfig.JSON {
 		encoder := json.NewEncoder(out)
 		encoder.SetIndent("", "    ")
-		return encoder.Encode(*created.Payload)
+		return encoder.Encode(*subscription)
 	}
-	fmt.Printf("created subscription: %s\n", *created.Payload.Name)
+	fmt.Printf("created subscription: %s\n", *subscription.Name)
 	return nil
 }
@@ -39,11 +39,16 @@ func NewCmdDelete(out io.Writer, errOut io.Writer) *cobra.Command {
 			}
 
 			deleteMap := map[string]modelAction{
-				utils.ImageKind:     CallDeleteImage,
-				utils.BaseImageKind: CallDeleteBaseImage,
-				utils.FunctionKind:  CallDeleteFunction,
-				utils.SecretKind:    CallDeleteSecret,
-				utils.PolicyKind:    CallDeletePolicy,
+				utils.APIKind:          CallDeleteAPI,
+				utils.ApplicationKind:  CallDeleteApplication,
+				utils.ImageKind:        CallDeleteImage,
+				utils.BaseImageKind:    CallDeleteBaseImage,
+				utils.FunctionKind:     CallDeleteFunction,
+				utils.SecretKind:       CallDeleteSecret,
+				utils.PolicyKind:       CallDeletePolicy,
+				utils.DriverTypeKind:   CallDeleteEventDriverType,
+				utils.DriverKind:       CallDeleteEventDriver,
+				utils.SubscriptionKind: CallDeleteSubscription,
 			}
 
 			err := importFile(out, errOut, cmd, args, deleteMap)
@@ -54,9 +59,14 @@ func NewCmdDelete(out io.Writer, errOut io.Writer) *cobra.Command {
 	cmd.AddCommand(NewCmdDeleteBaseImage(out, errOut))
 	cmd.AddCommand(NewCmdDeleteImage(out, errOut))
 	cmd.AddCommand(NewCmdDeleteFunction(out, errOut))
+	cmd.AddCommand(NewCmdDeleteAlias(out, errOut))
 	cmd.AddCommand(NewCmdDeleteSecret(out, errOut))
 	cmd.AddCommand(NewCmdDeleteAPI(out, errOut))
+	cmd.AddCommand(NewCmdDeleteConsumer(out, errOut))
+	cmd.AddCommand(NewCmdDeleteCredential(out, errOut))
 	cmd.AddCommand(NewCmdDeleteSubscription(out, errOut))
+	cmd.AddCommand(NewCmdDeleteDeadLetter(out, errOut))
+	cmd.AddCommand(NewCmdDeleteRun(out, errOut))
 	cmd.AddCommand(NewCmdDeleteEventDriver(out, errOut))
 	cmd.AddCommand(NewCmdDeleteEventDriverType(out, errOut))
 	cmd.AddCommand(NewCmdDeleteApplication(out, errOut))
@@ -0,0 +1,54 @@
+///////////////////////////////////////////////////////////////////////
+// Copyright (c) 2018 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+///////////////////////////////////////////////////////////////////////
+
+package cmd
+
+import (
+	"io"
+
+	"github.com/spf13/cobra"
+	"golang.org/x/net/context"
+
+	"github.com/vmware/dispatch/pkg/dispatchcli/i18n"
+	fnstore "github.com/vmware/dispatch/pkg/function-manager/gen/client/store"
+)
+
+var (
+	deleteAliasLong = i18n.T(`Delete an alias of a function. The versions are kept.`)
+
+	// TODO: add examples
+	deleteAliasExample = i18n.T(``)
+)
+
+// NewCmdDeleteAlias creates command responsible for deleting function aliases.
+func NewCmdDeleteAlias(out io.Writer, errOut io.Writer) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "alias FUNCTION_NAME ALIAS_NAME",
+		Short:   i18n.T("Delete an alias of a function"),
+		Long:    deleteAliasLong,
+		Example: deleteAliasExample,
+		Args:    cobra.ExactArgs(2),
+		Aliases: []string{"aliases"},
+		Run: func(cmd *cobra.Command, args []string) {
+			err := deleteAlias(out, errOut, cmd, args)
+			CheckErr(err)
+		},
+	}
+	return cmd
+}
+
+func deleteAlias(out, errOut io.Writer, cmd *cobra.Command, args []string) error {
+	client := functionManagerClient()
+	params := &fnstore.DeleteAliasParams{
+		FunctionName: args[0],
+		AliasName:    args[1],
+		Context:      context.Background(),
+	}
+	deleted, err := client.Store.DeleteAlias(params, GetAuthInfoWriter())
+	if err != nil {
+		return formatAPIError(err, params)
+	}
+	return formatAliasOutput(out, "Deleted", deleted.Payload, args[1])
+}
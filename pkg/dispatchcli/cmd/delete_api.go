@@ -45,20 +45,33 @@ func NewCmdDeleteAPI(out io.Writer, errOut io.Writer) *cobra.Command {
 	return cmd
 }
 
-func deleteAPI(out, errOut io.Writer, cmd *cobra.Command, args []string) error {
-	client := apiManagerClient()
+// CallDeleteAPI makes the API call to delete an api
+func CallDeleteAPI(i interface{}) error {
+	apiModel := i.(*models.API)
 	params := &apiclient.DeleteAPIParams{
 		Context: context.Background(),
-		API:     args[0],
+		API:     *apiModel.Name,
 		Tags:    []string{},
 	}
 	utils.AppendApplication(&params.Tags, cmdFlagApplication)
 
-	resp, err := client.Endpoint.DeleteAPI(params, GetAuthInfoWriter())
+	deleted, err := apiManagerClient().Endpoint.DeleteAPI(params, GetAuthInfoWriter())
 	if err != nil {
 		return formatAPIError(err, params)
 	}
-	return formatDeleteAPIOutput(out, false, []*models.API{resp.Payload})
+	*apiModel = *deleted.Payload
+	return nil
+}
+
+func deleteAPI(out, errOut io.Writer, cmd *cobra.Command, args []string) error {
+	apiModel := models.API{
+		Name: &args[0],
+	}
+	err := CallDeleteAPI(&apiModel)
+	if err != nil {
+		return err
+	}
+	return formatDeleteAPIOutput(out, false, []*models.API{&apiModel})
 }
 
 func formatDeleteAPIOutput(out io.Writer, list bool, apis []*models.API) error {
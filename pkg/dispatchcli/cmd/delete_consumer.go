@@ -0,0 +1,61 @@
+///////////////////////////////////////////////////////////////////////
+// Copyright (c) 2018 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+///////////////////////////////////////////////////////////////////////
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/spf13/cobra"
+	"golang.org/x/net/context"
+
+	consumerclient "github.com/vmware/dispatch/pkg/api-manager/gen/client/consumer"
+	"github.com/vmware/dispatch/pkg/dispatchcli/i18n"
+)
+
+var (
+	deleteConsumerLong = i18n.T(`Delete an api consumer, its credentials are revoked.`)
+
+	// TODO: add examples
+	deleteConsumerExample = i18n.T(``)
+)
+
+// NewCmdDeleteConsumer creates command responsible for deleting api consumers.
+func NewCmdDeleteConsumer(out io.Writer, errOut io.Writer) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "consumer CONSUMER_NAME",
+		Short:   i18n.T("Delete api consumer"),
+		Long:    deleteConsumerLong,
+		Example: deleteConsumerExample,
+		Args:    cobra.ExactArgs(1),
+		Aliases: []string{"consumers"},
+		Run: func(cmd *cobra.Command, args []string) {
+			err := deleteConsumer(out, errOut, cmd, args)
+			CheckErr(err)
+		},
+	}
+	return cmd
+}
+
+func deleteConsumer(out, errOut io.Writer, cmd *cobra.Command, args []string) error {
+	client := apiManagerClient()
+	params := &consumerclient.DeleteConsumerParams{
+		Context:  context.Background(),
+		Consumer: args[0],
+	}
+	resp, err := client.Consumer.DeleteConsumer(params, GetAuthInfoWriter())
+	if err != nil {
+		return formatAPIError(err, params)
+	}
+	if dispatchConfig.JSON {
+		encoder := json.NewEncoder(out)
+		encoder.SetIndent("", "    ")
+		return encoder.Encode(*resp.Payload)
+	}
+	fmt.Fprintf(out, "Deleted consumer: %s\n", *resp.Payload.Name)
+	return nil
+}
@@ -0,0 +1,58 @@
+///////////////////////////////////////////////////////////////////////
+// Copyright (c) 2018 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+///////////////////////////////////////////////////////////////////////
+
+package cmd
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/spf13/cobra"
+	"golang.org/x/net/context"
+
+	consumerclient "github.com/vmware/dispatch/pkg/api-manager/gen/client/consumer"
+	"github.com/vmware/dispatch/pkg/dispatchcli/i18n"
+)
+
+var (
+	deleteCredentialLong = i18n.T(`Revoke a credential of an api consumer.`)
+
+	// TODO: add examples
+	deleteCredentialExample = i18n.T(``)
+)
+
+// NewCmdDeleteCredential creates command responsible for revoking the credentials of api consumers.
+func NewCmdDeleteCredential(out io.Writer, errOut io.Writer) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "credential CONSUMER_NAME CREDENTIAL_ID",
+		Short:   i18n.T("Revoke a credential of an api consumer"),
+		Long:    deleteCredentialLong,
+		Example: deleteCredentialExample,
+		Args:    cobra.ExactArgs(2),
+		Aliases: []string{"credentials"},
+		Run: func(cmd *cobra.Command, args []string) {
+			err := deleteCredential(out, errOut, cmd, args)
+			CheckErr(err)
+		},
+	}
+	return cmd
+}
+
+func deleteCredential(out, errOut io.Writer, cmd *cobra.Command, args []string) error {
+	client := apiManagerClient()
+	params := &consumerclient.DeleteCredentialParams{
+		Context:    context.Background(),
+		Consumer:   args[0],
+		Credential: args[1],
+	}
+	if _, err := client.Consumer.DeleteCredential(params, GetAuthInfoWriter()); err != nil {
+		return formatAPIError(err, params)
+	}
+	if dispatchConfig.JSON {
+		return nil
+	}
+	fmt.Fprintf(out, "Revoked credential: %s\n", args[1])
+	return nil
+}
@@ -0,0 +1,66 @@
+///////////////////////////////////////////////////////////////////////
+// Copyright (c) 2018 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+///////////////////////////////////////////////////////////////////////
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/spf13/cobra"
+	"golang.org/x/net/context"
+
+	"github.com/vmware/dispatch/pkg/dispatchcli/cmd/utils"
+	"github.com/vmware/dispatch/pkg/dispatchcli/i18n"
+	"github.com/vmware/dispatch/pkg/event-manager/gen/client/deadletters"
+)
+
+var (
+	deleteDeadLetterLong = i18n.T(`Delete dead letters without replaying them.`)
+
+	// TODO: add examples
+	deleteDeadLetterExample = i18n.T(``)
+)
+
+// NewCmdDeleteDeadLetter creates command responsible for deleting dead letters.
+func NewCmdDeleteDeadLetter(out io.Writer, errOut io.Writer) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "deadletter DEAD_LETTER_NAME",
+		Short:   i18n.T("Delete dead letter"),
+		Long:    deleteDeadLetterLong,
+		Example: deleteDeadLetterExample,
+		Args:    cobra.ExactArgs(1),
+		Aliases: []string{"deadletters"},
+		Run: func(cmd *cobra.Command, args []string) {
+			err := deleteDeadLetter(out, errOut, cmd, args)
+			CheckErr(err)
+		},
+	}
+	cmd.Flags().StringVarP(&cmdFlagApplication, "application", "a", "", "filter by application")
+	return cmd
+}
+
+func deleteDeadLetter(out, errOut io.Writer, cmd *cobra.Command, args []string) error {
+	client := eventManagerClient()
+	params := &deadletters.DeleteDeadLetterParams{
+		Context:        context.Background(),
+		DeadLetterName: args[0],
+		Tags:           []string{},
+	}
+	utils.AppendApplication(&params.Tags, cmdFlagApplication)
+
+	resp, err := client.Deadletters.DeleteDeadLetter(params, GetAuthInfoWriter())
+	if err != nil {
+		return formatAPIError(err, params)
+	}
+	if dispatchConfig.JSON {
+		encoder := json.NewEncoder(out)
+		encoder.SetIndent("", "    ")
+		return encoder.Encode(resp.Payload)
+	}
+	_, err = fmt.Fprintf(out, "Deleted dead letter: %s\n", resp.Payload.Name)
+	return err
+}
@@ -45,20 +45,33 @@ func NewCmdDeleteEventDriver(out io.Writer, errOut io.Writer) *cobra.Command {
 	return cmd
 }
 
-func deleteEventDriver(out, errOut io.Writer, cmd *cobra.Command, args []string) error {
-
+// CallDeleteEventDriver makes the API call to delete an event driver
+func CallDeleteEventDriver(i interface{}) error {
+	driverModel := i.(*models.Driver)
 	params := &client.DeleteDriverParams{
 		Context:    context.Background(),
-		DriverName: args[0],
+		DriverName: *driverModel.Name,
 		Tags:       []string{},
 	}
 	utils.AppendApplication(&params.Tags, cmdFlagApplication)
 
-	resp, err := eventManagerClient().Drivers.DeleteDriver(params, GetAuthInfoWriter())
+	deleted, err := eventManagerClient().Drivers.DeleteDriver(params, GetAuthInfoWriter())
 	if err != nil {
 		return formatAPIError(err, params)
 	}
-	return formatDeleteEventDriverOutput(out, false, []*models.Driver{resp.Payload})
+	*driverModel = *deleted.Payload
+	return nil
+}
+
+func deleteEventDriver(out, errOut io.Writer, cmd *cobra.Command, args []string) error {
+	driverModel := models.Driver{
+		Name: &args[0],
+	}
+	err := CallDeleteEventDriver(&driverModel)
+	if err != nil {
+		return err
+	}
+	return formatDeleteEventDriverOutput(out, false, []*models.Driver{&driverModel})
 }
 
 func formatDeleteEventDriverOutput(out io.Writer, list bool, drivers []*models.Driver) error {
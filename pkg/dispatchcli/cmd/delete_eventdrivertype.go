@@ -45,19 +45,32 @@ func NewCmdDeleteEventDriverType(out io.Writer, errOut io.Writer) *cobra.Command
 	return cmd
 }
 
-func deleteEventDriverType(out, errOut io.Writer, cmd *cobra.Command, args []string) error {
-
+// CallDeleteEventDriverType makes the API call to delete an event driver type
+func CallDeleteEventDriverType(i interface{}) error {
+	driverTypeModel := i.(*models.DriverType)
 	params := &client.DeleteDriverTypeParams{
 		Context:        context.Background(),
-		DriverTypeName: args[0],
+		DriverTypeName: *driverTypeModel.Name,
 	}
 	utils.AppendApplication(&params.Tags, cmdFlagApplication)
 
-	resp, err := eventManagerClient().Drivers.DeleteDriverType(params, GetAuthInfoWriter())
+	deleted, err := eventManagerClient().Drivers.DeleteDriverType(params, GetAuthInfoWriter())
 	if err != nil {
 		return formatAPIError(err, params)
 	}
-	return formatDeleteEventDriverTypeOutput(out, false, []*models.DriverType{resp.Payload})
+	*driverTypeModel = *deleted.Payload
+	return nil
+}
+
+func deleteEventDriverType(out, errOut io.Writer, cmd *cobra.Command, args []string) error {
+	driverTypeModel := models.DriverType{
+		Name: &args[0],
+	}
+	err := CallDeleteEventDriverType(&driverTypeModel)
+	if err != nil {
+		return err
+	}
+	return formatDeleteEventDriverTypeOutput(out, false, []*models.DriverType{&driverTypeModel})
 }
 
 func formatDeleteEventDriverTypeOutput(out io.Writer, list bool, driverTypes []*models.DriverType) error {
@@ -0,0 +1,151 @@
+///////////////////////////////////////////////////////////////////////
+// Copyright (c) 2018 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+///////////////////////////////////////////////////////////////////////
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/go-openapi/strfmt"
+	"github.com/go-openapi/swag"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"golang.org/x/net/context"
+
+	"github.com/vmware/dispatch/pkg/dispatchcli/cmd/utils"
+	"github.com/vmware/dispatch/pkg/dispatchcli/i18n"
+	fnrunner "github.com/vmware/dispatch/pkg/function-manager/gen/client/runner"
+	models "github.com/vmware/dispatch/pkg/function-manager/gen/models"
+)
+
+var (
+	deleteRunLong = i18n.T(`Cancel a function run which is queued or being executed, or delete a finished one.
+Without a run ID, delete the finished runs of all functions or of a function, selected by age, count or status.
+Runs in progress are never deleted. Runs are also pruned periodically over the retention of the function manager.`)
+
+	deleteRunExample = i18n.T(`
+		# Cancel a run in progress, or delete a finished run
+		dispatch delete run hello 0f2bc55b-f7b1-4a4c-8a68-b87d5d8d9e2f
+		# Report the runs of hello which would be deleted, keeping the 10 most recent ones
+		dispatch delete run hello --keep 10 --dry-run
+		# Delete the failed runs finished for more than a day
+		dispatch delete runs --status ERROR --status TIMEDOUT --older-than 24h
+		# Delete all the finished runs
+		dispatch delete runs --all`)
+
+	deleteRunOlderThan = ""
+	deleteRunKeep      = int64(0)
+	deleteRunStatus    []string
+	deleteRunDryRun    = false
+	deleteRunAll       = false
+)
+
+// NewCmdDeleteRun creates command responsible for cancelling and deleting function runs.
+func NewCmdDeleteRun(out io.Writer, errOut io.Writer) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "run [FUNCTION_NAME [RUN_ID]]",
+		Short:   i18n.T("Cancel or delete function runs"),
+		Long:    deleteRunLong,
+		Example: deleteRunExample,
+		Args:    cobra.MaximumNArgs(2),
+		Aliases: []string{"runs"},
+		Run: func(cmd *cobra.Command, args []string) {
+			var err error
+			if len(args) == 2 {
+				err = deleteRun(out, errOut, cmd, args)
+			} else {
+				err = deleteRuns(out, errOut, cmd, args)
+			}
+			CheckErr(err)
+		},
+	}
+	cmd.Flags().StringVarP(&cmdFlagApplication, "application", "a", "", "filter by application")
+	cmd.Flags().StringVar(&deleteRunOlderThan, "older-than", "", "only delete the runs finished for longer than this duration, e.g. 24h")
+	cmd.Flags().Int64Var(&deleteRunKeep, "keep", 0, "number of most recent runs kept per function")
+	cmd.Flags().StringArrayVar(&deleteRunStatus, "status", []string{}, "status of the runs to delete, can be repeated (all finished runs by default)")
+	cmd.Flags().BoolVar(&deleteRunDryRun, "dry-run", false, "only report the runs which would be deleted")
+	cmd.Flags().BoolVar(&deleteRunAll, "all", false, "delete all the finished runs when no other criteria is given")
+	return cmd
+}
+
+func deleteRun(out, errOut io.Writer, cmd *cobra.Command, args []string) error {
+	client := functionManagerClient()
+	params := &fnrunner.CancelRunParams{
+		FunctionName: &args[0],
+		RunName:      strfmt.UUID(args[1]),
+		Context:      context.Background(),
+		Tags:         []string{},
+	}
+	utils.AppendApplication(&params.Tags, cmdFlagApplication)
+
+	resp, err := client.Runner.CancelRun(params, GetAuthInfoWriter())
+	if _, finished := err.(*fnrunner.CancelRunConflict); finished {
+		// the run is not in progress anymore, delete it
+		deleteParams := &fnrunner.DeleteRunsParams{
+			FunctionName: &args[0],
+			Name:         []string{args[1]},
+			Context:      context.Background(),
+			Tags:         params.Tags,
+		}
+		deleted, err := client.Runner.DeleteRuns(deleteParams, GetAuthInfoWriter())
+		if err != nil {
+			return formatAPIError(err, deleteParams)
+		}
+		return formatDeleteRunsOutput(out, deleted.Payload)
+	}
+	if err != nil {
+		return formatAPIError(err, params)
+	}
+	if dispatchConfig.JSON {
+		encoder := json.NewEncoder(out)
+		encoder.SetIndent("", "    ")
+		return encoder.Encode(resp.Payload)
+	}
+	_, err = fmt.Fprintf(out, "Cancelled run: %s\n", resp.Payload.Name)
+	return err
+}
+
+func deleteRuns(out, errOut io.Writer, cmd *cobra.Command, args []string) error {
+	if deleteRunOlderThan == "" && deleteRunKeep == 0 && len(deleteRunStatus) == 0 && !deleteRunAll {
+		return errors.New("select the runs to delete with --older-than, --keep or --status, or delete all of them with --all")
+	}
+	client := functionManagerClient()
+	params := &fnrunner.DeleteRunsParams{
+		Status:  deleteRunStatus,
+		DryRun:  swag.Bool(deleteRunDryRun),
+		Context: context.Background(),
+		Tags:    []string{},
+	}
+	if len(args) == 1 {
+		params.FunctionName = &args[0]
+	}
+	if deleteRunOlderThan != "" {
+		params.OlderThan = &deleteRunOlderThan
+	}
+	if deleteRunKeep != 0 {
+		params.Keep = &deleteRunKeep
+	}
+	utils.AppendApplication(&params.Tags, cmdFlagApplication)
+
+	deleted, err := client.Runner.DeleteRuns(params, GetAuthInfoWriter())
+	if err != nil {
+		return formatAPIError(err, params)
+	}
+	return formatDeleteRunsOutput(out, deleted.Payload)
+}
+
+func formatDeleteRunsOutput(out io.Writer, runs []*models.Run) error {
+	if err := formatRunOutput(out, true, runs); err != nil || dispatchConfig.JSON {
+		return err
+	}
+	verb := "Deleted"
+	if deleteRunDryRun {
+		verb = "Would delete"
+	}
+	_, err := fmt.Fprintf(out, "%s %d runs\n", verb, len(runs))
+	return err
+}
@@ -45,20 +45,33 @@ func NewCmdDeleteSubscription(out io.Writer, errOut io.Writer) *cobra.Command {
 	return cmd
 }
 
-func deleteSubscription(out, errOut io.Writer, cmd *cobra.Command, args []string) error {
-	client := eventManagerClient()
+// CallDeleteSubscription makes the API call to delete a subscription
+func CallDeleteSubscription(i interface{}) error {
+	subscriptionModel := i.(*models.Subscription)
 	params := &subscription.DeleteSubscriptionParams{
 		Context:          context.Background(),
-		SubscriptionName: args[0],
+		SubscriptionName: *subscriptionModel.Name,
 		Tags:             []string{},
 	}
 	utils.AppendApplication(&params.Tags, cmdFlagApplication)
 
-	resp, err := client.Subscriptions.DeleteSubscription(params, GetAuthInfoWriter())
+	deleted, err := eventManagerClient().Subscriptions.DeleteSubscription(params, GetAuthInfoWriter())
 	if err != nil {
 		return formatAPIError(err, params)
 	}
-	return formatDeleteSubscriptionOutput(out, false, []*models.Subscription{resp.Payload})
+	*subscriptionModel = *deleted.Payload
+	return nil
+}
+
+func deleteSubscription(out, errOut io.Writer, cmd *cobra.Command, args []string) error {
+	subscriptionModel := models.Subscription{
+		Name: &args[0],
+	}
+	err := CallDeleteSubscription(&subscriptionModel)
+	if err != nil {
+		return err
+	}
+	return formatDeleteSubscriptionOutput(out, false, []*models.Subscription{&subscriptionModel})
 }
 
 func formatDeleteSubscriptionOutput(out io.Writer, list bool, subscriptions []*models.Subscription) error {
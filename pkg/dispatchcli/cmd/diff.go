@@ -0,0 +1,91 @@
+///////////////////////////////////////////////////////////////////////
+// Copyright (c) 2018 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+///////////////////////////////////////////////////////////////////////
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/vmware/dispatch/pkg/dispatchcli/i18n"
+)
+
+var (
+	diffLong = i18n.T(`Show the changes which applying the resources of a YAML file would make.
+
+Resources to create are marked with +, resources to update with ~ followed by the fields which change, and resources
+to delete with --prune with -.`)
+
+	diffExample = i18n.T(`
+		# Show the changes of seed.yaml
+		dispatch diff -f seed.yaml -w examples/
+		# Show the changes of an application, including the resources removed from the file
+		dispatch diff -f app.yaml -a my-app --prune`)
+)
+
+// NewCmdDiff creates a command object for the "diff" action, which shows the pending changes of a file.
+func NewCmdDiff(out io.Writer, errOut io.Writer) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "diff -f FILE [-w WORK_DIR] [-a APPLICATION] [--prune]",
+		Short:   i18n.T("Show the changes of applying the resources of a file."),
+		Long:    diffLong,
+		Example: diffExample,
+		Args:    cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			if file == "" {
+				runHelp(cmd, args)
+				return
+			}
+			err := diff(out, errOut, cmd, args)
+			CheckErr(err)
+		},
+	}
+	cmd.Flags().StringVarP(&cmdFlagApplication, "application", "a", "", "associate with an application")
+	cmd.Flags().StringVarP(&file, "file", "f", "", "Path to YAML file")
+	cmd.Flags().StringVarP(&workDir, "work-dir", "w", "", "Working directory relative paths are based on")
+	cmd.Flags().BoolVar(&applyPrune, "prune", false, "show the resources of the application which are not in the file")
+	return cmd
+}
+
+func diff(out, errOut io.Writer, cmd *cobra.Command, args []string) error {
+	changes, err := readChanges()
+	if err != nil {
+		return err
+	}
+	return formatDiffOutput(out, changes)
+}
+
+func formatDiffOutput(out io.Writer, changes []*change) error {
+	pending := []*change{}
+	for _, c := range changes {
+		if c.Action != changeUnchanged {
+			pending = append(pending, c)
+		}
+	}
+	if dispatchConfig.JSON {
+		encoder := json.NewEncoder(out)
+		encoder.SetIndent("", "    ")
+		return encoder.Encode(pending)
+	}
+	if len(pending) == 0 {
+		fmt.Fprintln(out, "No changes")
+		return nil
+	}
+	for _, c := range pending {
+		switch c.Action {
+		case changeCreate:
+			fmt.Fprintf(out, "+ %s: %s\n", c.Kind, c.Name)
+		case changeUpdate:
+			fmt.Fprintf(out, "~ %s: %s (%s)\n", c.Kind, c.Name, strings.Join(c.Fields, ", "))
+		case changeDelete:
+			fmt.Fprintf(out, "- %s: %s\n", c.Kind, c.Name)
+		}
+	}
+	return nil
+}
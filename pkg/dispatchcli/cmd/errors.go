@@ -6,6 +6,7 @@
 package cmd
 
 import (
+	consumer "github.com/vmware/dispatch/pkg/api-manager/gen/client/consumer"
 	endpoint "github.com/vmware/dispatch/pkg/api-manager/gen/client/endpoint"
 	"github.com/vmware/dispatch/pkg/dispatchcli/i18n"
 	runner "github.com/vmware/dispatch/pkg/function-manager/gen/client/runner"
@@ -121,6 +122,46 @@ func formatAPIError(err error, params interface{}) error {
 	case *function.GetFunctionNotFound:
 		p := params.(*function.GetFunctionParams)
 		return i18n.Errorf("[Code: %d] Function not found: %s", v.Payload.Code, p.FunctionName)
+	// Update
+	case *function.UpdateFunctionBadRequest:
+		return i18n.Errorf("[Code: %d] Bad request: %s", v.Payload.Code, msg(v.Payload.Message))
+	case *function.UpdateFunctionNotFound:
+		p := params.(*function.UpdateFunctionParams)
+		return i18n.Errorf("[Code: %d] Function not found: %s", v.Payload.Code, p.FunctionName)
+	case *function.UpdateFunctionInternalServerError:
+		return i18n.Errorf("[Code: %d] Error: %s", v.Payload.Code, msg(v.Payload.Message))
+	// Versions
+	case *function.PublishVersionNotFound:
+		p := params.(*function.PublishVersionParams)
+		return i18n.Errorf("[Code: %d] Function not found: %s", v.Payload.Code, p.FunctionName)
+	case *function.PublishVersionConflict:
+		return i18n.Errorf("[Code: %d] Conflict: %s", v.Payload.Code, msg(v.Payload.Message))
+	case *function.PublishVersionInternalServerError:
+		return i18n.Errorf("[Code: %d] Error: %s", v.Payload.Code, msg(v.Payload.Message))
+	case *function.GetVersionsNotFound:
+		p := params.(*function.GetVersionsParams)
+		return i18n.Errorf("[Code: %d] Function not found: %s", v.Payload.Code, p.FunctionName)
+	case *function.GetVersionsInternalServerError:
+		return i18n.Errorf("[Code: %d] Error: %s", v.Payload.Code, msg(v.Payload.Message))
+	// Aliases
+	case *function.SetAliasBadRequest:
+		return i18n.Errorf("[Code: %d] Bad request: %s", v.Payload.Code, msg(v.Payload.Message))
+	case *function.SetAliasNotFound:
+		p := params.(*function.SetAliasParams)
+		return i18n.Errorf("[Code: %d] Function not found: %s", v.Payload.Code, p.FunctionName)
+	case *function.SetAliasConflict:
+		return i18n.Errorf("[Code: %d] Conflict: %s", v.Payload.Code, msg(v.Payload.Message))
+	case *function.SetAliasInternalServerError:
+		return i18n.Errorf("[Code: %d] Error: %s", v.Payload.Code, msg(v.Payload.Message))
+	case *function.DeleteAliasNotFound:
+		return i18n.Errorf("[Code: %d] Not found: %s", v.Payload.Code, msg(v.Payload.Message))
+	case *function.DeleteAliasConflict:
+		return i18n.Errorf("[Code: %d] Conflict: %s", v.Payload.Code, msg(v.Payload.Message))
+	case *function.DeleteAliasInternalServerError:
+		return i18n.Errorf("[Code: %d] Error: %s", v.Payload.Code, msg(v.Payload.Message))
+	// Images
+	case *function.CollectImagesInternalServerError:
+		return i18n.Errorf("[Code: %d] Error: %s", v.Payload.Code, msg(v.Payload.Message))
 	// List
 	case *function.GetFunctionsDefault:
 		return i18n.Errorf("[Code: %d] Error: %s", v.Payload.Code, msg(v.Payload.Message))
@@ -135,10 +176,27 @@ func formatAPIError(err error, params interface{}) error {
 	case *runner.RunFunctionNotFound:
 		p := params.(*runner.RunFunctionParams)
 		return i18n.Errorf("[Code: %d] Function execution not found: %s", v.Payload.Code, *p.FunctionName)
+	case *runner.RunFunctionTooManyRequests:
+		return i18n.Errorf("[Code: %d] Too many requests: %s", v.Payload.Code, msg(v.Payload.Message))
 	case *runner.RunFunctionInternalServerError:
 		return i18n.Errorf("[Code: %d] Error: %s", v.Payload.Code, msg(v.Payload.Message))
 	case *runner.RunFunctionBadGateway:
 		return i18n.Errorf("[Code: %d] Error: %s", v.Payload.Code, msg(v.Payload.Message))
+	// Cancel
+	case *runner.CancelRunBadRequest:
+		return i18n.Errorf("[Code: %d] Bad request: %s", v.Payload.Code, msg(v.Payload.Message))
+	case *runner.CancelRunNotFound:
+		p := params.(*runner.CancelRunParams)
+		return i18n.Errorf("[Code: %d] Function execution not found: %s", v.Payload.Code, p.RunName)
+	case *runner.CancelRunConflict:
+		return i18n.Errorf("[Code: %d] Conflict: %s", v.Payload.Code, msg(v.Payload.Message))
+	case *runner.CancelRunInternalServerError:
+		return i18n.Errorf("[Code: %d] Error: %s", v.Payload.Code, msg(v.Payload.Message))
+	// Delete
+	case *runner.DeleteRunsBadRequest:
+		return i18n.Errorf("[Code: %d] Bad request: %s", v.Payload.Code, msg(v.Payload.Message))
+	case *runner.DeleteRunsInternalServerError:
+		return i18n.Errorf("[Code: %d] Error: %s", v.Payload.Code, msg(v.Payload.Message))
 	// List
 	case *runner.GetRunsNotFound:
 		p := params.(*runner.GetRunsParams)
@@ -197,6 +255,49 @@ func formatAPIError(err error, params interface{}) error {
 	case *endpoint.DeleteAPIInternalServerError:
 		return i18n.Errorf("[Code: %d] delete api error: %s", v.Payload.Code, msg(v.Payload.Message))
 
+	// Consumer
+	// List
+	case *consumer.GetConsumersBadRequest:
+		return i18n.Errorf("[Code: %d] get consumers error: %s", v.Payload.Code, msg(v.Payload.Message))
+	case *consumer.GetConsumersInternalServerError:
+		return i18n.Errorf("[Code: %d] get consumers error: %s", v.Payload.Code, msg(v.Payload.Message))
+	case *consumer.GetConsumersDefault:
+		return i18n.Errorf("[Code: %d] get consumers error: %s", v.Payload.Code, msg(v.Payload.Message))
+	// Get
+	case *consumer.GetConsumerNotFound:
+		return i18n.Errorf("[Code: %d] get consumer error: %s", v.Payload.Code, msg(v.Payload.Message))
+	case *consumer.GetConsumerInternalServerError:
+		return i18n.Errorf("[Code: %d] get consumer error: %s", v.Payload.Code, msg(v.Payload.Message))
+	// Create
+	case *consumer.AddConsumerBadRequest:
+		return i18n.Errorf("[Code: %d] create consumer error: %s", v.Payload.Code, msg(v.Payload.Message))
+	case *consumer.AddConsumerConflict:
+		return i18n.Errorf("[Code: %d] Conflict: %s", v.Payload.Code, msg(v.Payload.Message))
+	case *consumer.AddConsumerInternalServerError:
+		return i18n.Errorf("[Code: %d] create consumer error: %s", v.Payload.Code, msg(v.Payload.Message))
+	// Delete
+	case *consumer.DeleteConsumerNotFound:
+		return i18n.Errorf("[Code: %d] delete consumer error: %s", v.Payload.Code, msg(v.Payload.Message))
+	case *consumer.DeleteConsumerInternalServerError:
+		return i18n.Errorf("[Code: %d] delete consumer error: %s", v.Payload.Code, msg(v.Payload.Message))
+	// Credentials
+	case *consumer.AddCredentialBadRequest:
+		return i18n.Errorf("[Code: %d] create credential error: %s", v.Payload.Code, msg(v.Payload.Message))
+	case *consumer.AddCredentialNotFound:
+		return i18n.Errorf("[Code: %d] create credential error: %s", v.Payload.Code, msg(v.Payload.Message))
+	case *consumer.AddCredentialConflict:
+		return i18n.Errorf("[Code: %d] create credential error: %s", v.Payload.Code, msg(v.Payload.Message))
+	case *consumer.AddCredentialInternalServerError:
+		return i18n.Errorf("[Code: %d] create credential error: %s", v.Payload.Code, msg(v.Payload.Message))
+	case *consumer.GetCredentialsNotFound:
+		return i18n.Errorf("[Code: %d] get credentials error: %s", v.Payload.Code, msg(v.Payload.Message))
+	case *consumer.GetCredentialsInternalServerError:
+		return i18n.Errorf("[Code: %d] get credentials error: %s", v.Payload.Code, msg(v.Payload.Message))
+	case *consumer.DeleteCredentialNotFound:
+		return i18n.Errorf("[Code: %d] delete credential error: %s", v.Payload.Code, msg(v.Payload.Message))
+	case *consumer.DeleteCredentialInternalServerError:
+		return i18n.Errorf("[Code: %d] delete credential error: %s", v.Payload.Code, msg(v.Payload.Message))
+
 	// Policy
 	// Add
 	case *policy.AddPolicyConflict:
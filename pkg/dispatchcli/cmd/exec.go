@@ -29,6 +29,7 @@ var (
 	execAllOutput = false
 	execInput     = "{}"
 	execSecrets   = []string{}
+	execTimeout   = int64(0)
 )
 
 // NewCmdExec creates a command to execute a dispatch function.
@@ -50,6 +51,7 @@ func NewCmdExec(out io.Writer, errOut io.Writer) *cobra.Command {
 	cmd.Flags().StringVar(&execInput, "input", "{}", "Function input JSON object")
 	cmd.Flags().StringArrayVar(&execSecrets, "secret", []string{}, "Function secrets, can be specified multiple times or a comma-delimited string")
 	cmd.Flags().BoolVar(&execAllOutput, "all", false, "Also print metadata along with json output, ONLY with --json")
+	cmd.Flags().Int64Var(&execTimeout, "timeout", 0, "Time in seconds after which the run is cancelled, overrides the timeout of the function")
 	return cmd
 }
 
@@ -72,6 +74,9 @@ func runExec(out, errOut io.Writer, cmd *cobra.Command, args []string) error {
 		Input:    input,
 		Secrets:  execSecrets,
 	}
+	if cmd.Flags().Changed("timeout") {
+		run.Timeout = &execTimeout
+	}
 
 	params := &fnrunner.RunFunctionParams{
 		Body:         run,
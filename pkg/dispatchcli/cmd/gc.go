@@ -0,0 +1,90 @@
+///////////////////////////////////////////////////////////////////////
+// Copyright (c) 2018 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+///////////////////////////////////////////////////////////////////////
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/go-openapi/swag"
+	"github.com/olekukonko/tablewriter"
+	"github.com/spf13/cobra"
+	"golang.org/x/net/context"
+
+	"github.com/vmware/dispatch/pkg/dispatchcli/i18n"
+	fnstore "github.com/vmware/dispatch/pkg/function-manager/gen/client/store"
+	models "github.com/vmware/dispatch/pkg/function-manager/gen/models"
+)
+
+var (
+	gcLong = i18n.T(`Remove the images of deleted functions, and the superseded images of functions over the retention
+of the function manager. Images are also collected periodically, and when functions are updated or deleted.`)
+
+	gcExample = i18n.T(`
+		# Report the images which would be removed
+		dispatch gc --dry-run
+		# Remove them
+		dispatch gc`)
+
+	gcDryRun = false
+)
+
+// NewCmdGC creates command responsible for the garbage collection of function images.
+func NewCmdGC(out io.Writer, errOut io.Writer) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "gc",
+		Short:   i18n.T("Remove unused function images"),
+		Long:    gcLong,
+		Example: gcExample,
+		Args:    cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			err := collectImages(out, errOut, cmd, args)
+			CheckErr(err)
+		},
+	}
+	cmd.Flags().BoolVar(&gcDryRun, "dry-run", false, "only report the images which would be removed")
+	return cmd
+}
+
+func collectImages(out, errOut io.Writer, cmd *cobra.Command, args []string) error {
+	client := functionManagerClient()
+	params := &fnstore.CollectImagesParams{
+		DryRun:  swag.Bool(gcDryRun),
+		Context: context.Background(),
+	}
+	collected, err := client.Store.CollectImages(params, GetAuthInfoWriter())
+	if err != nil {
+		return formatAPIError(err, params)
+	}
+	return formatGCOutput(out, collected.Payload)
+}
+
+func formatGCOutput(out io.Writer, images []*models.FunctionImage) error {
+	if dispatchConfig.JSON {
+		encoder := json.NewEncoder(out)
+		encoder.SetIndent("", "    ")
+		return encoder.Encode(images)
+	}
+	var size int64
+	table := tablewriter.NewWriter(out)
+	table.SetHeader([]string{"Image", "Function", "Names", "Created Date", "Reason"})
+	table.SetBorders(tablewriter.Border{Left: false, Top: false, Right: false, Bottom: false})
+	table.SetCenterSeparator("")
+	for _, image := range images {
+		size += image.Size
+		table.Append([]string{image.ID, image.FunctionID, strings.Join(image.Names, ", "), time.Unix(image.CreatedTime, 0).Local().Format(time.UnixDate), image.Reason})
+	}
+	table.Render()
+	verb := "Removed"
+	if gcDryRun {
+		verb = "Would remove"
+	}
+	_, err := fmt.Fprintf(out, "%s %d images, %d MB\n", verb, len(images), size/(1<<20))
+	return err
+}
@@ -6,6 +6,7 @@
 package cmd
 
 import (
+	"fmt"
 	"io"
 
 	"github.com/spf13/cobra"
@@ -22,7 +23,14 @@ var (
 		# List a single image with name "demo-python3-runtime"
 		dispatch get image demo-python3-runtime
 		# List a single function with name "open-sesame"
-		dispatch get function open-sesame`)
+		dispatch get function open-sesame
+		# List the 10 most recent functions, then the following 10
+		dispatch get functions --limit 10 --sort-by -createdTime
+		dispatch get functions --limit 10 --sort-by -createdTime --continue <token>`)
+
+	getLimit    int64
+	getSortBy   []string
+	getContinue string
 )
 
 // NewCmdGet creates a command object for the generic "get" action, which
@@ -39,13 +47,21 @@ func NewCmdGet(out io.Writer, errOut io.Writer) *cobra.Command {
 		},
 		SuggestFor: []string{"list"},
 	}
+	cmd.PersistentFlags().Int64Var(&getLimit, "limit", 0, "maximum number of resources listed, all of them if 0")
+	cmd.PersistentFlags().StringSliceVar(&getSortBy, "sort-by", []string{}, "sort the resources listed by fields, e.g. name, or -createdTime for the descending order")
+	cmd.PersistentFlags().StringVar(&getContinue, "continue", "", "list the resources following a limited list, with the token printed after it")
 	cmd.AddCommand(NewCmdGetBaseImage(out, errOut))
 	cmd.AddCommand(NewCmdGetImage(out, errOut))
 	cmd.AddCommand(NewCmdGetFunction(out, errOut))
+	cmd.AddCommand(NewCmdGetVersion(out, errOut))
 	cmd.AddCommand(NewCmdGetRun(out, errOut))
 	cmd.AddCommand(NewCmdGetSecret(out, errOut))
 	cmd.AddCommand(NewCmdGetAPI(out, errOut))
+	cmd.AddCommand(NewCmdGetConsumer(out, errOut))
+	cmd.AddCommand(NewCmdGetCredential(out, errOut))
 	cmd.AddCommand(NewCmdGetSubscription(out, errOut))
+	cmd.AddCommand(NewCmdGetDeadLetter(out, errOut))
+	cmd.AddCommand(NewCmdGetEvent(out, errOut))
 	cmd.AddCommand(NewCmdGetEventDriver(out, errOut))
 	cmd.AddCommand(NewCmdGetEventDriverType(out, errOut))
 	cmd.AddCommand(NewCmdGetApplication(out, errOut))
@@ -57,3 +73,23 @@ func runGet(out, errOut io.Writer, cmd *cobra.Command, args []string) error {
 	runHelp(cmd, args)
 	return nil
 }
+
+// listPage returns the limit, continue and sortBy parameters of list requests, set with the flags of get
+func listPage() (*int64, *string, []string) {
+	var limit *int64
+	if getLimit > 0 {
+		limit = &getLimit
+	}
+	var continueToken *string
+	if getContinue != "" {
+		continueToken = &getContinue
+	}
+	return limit, continueToken, getSortBy
+}
+
+// printContinue tells how to list the resources following a limited list
+func printContinue(errOut io.Writer, token string) {
+	if token != "" {
+		fmt.Fprintf(errOut, "More resources available, list them with --continue %s\n", token)
+	}
+}
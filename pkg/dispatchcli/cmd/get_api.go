@@ -30,19 +30,22 @@ var (
 	getAPIExample = i18n.T(``)
 
 	functionName = ""
+	exportFormat = ""
 )
 
 // NewCmdGetAPI gets command responsible for dispatch function api creation.
 func NewCmdGetAPI(out io.Writer, errOut io.Writer) *cobra.Command {
 	cmd := &cobra.Command{
-		Use:     "api [API_NAME] [--func FUNC_NAME]",
+		Use:     "api [API_NAME] [--func FUNC_NAME] [--export openapi]",
 		Short:   i18n.T("Get API"),
 		Long:    getAPILong,
 		Example: getAPIExample,
 		Args:    cobra.MaximumNArgs(1),
 		Run: func(cmd *cobra.Command, args []string) {
 			var err error
-			if len(args) == 1 {
+			if exportFormat != "" && exportFormat != "openapi" {
+				err = formatCliError(nil, fmt.Sprintf("Unsupported export format %s, expected openapi", exportFormat))
+			} else if len(args) == 1 {
 				err = getAPI(out, errOut, cmd, args)
 			} else {
 				err = getAPIs(out, errOut, cmd)
@@ -52,6 +55,7 @@ func NewCmdGetAPI(out io.Writer, errOut io.Writer) *cobra.Command {
 	}
 	cmd.Flags().StringVarP(&cmdFlagApplication, "application", "a", "", "filter by application")
 	cmd.Flags().StringVarP(&functionName, "func", "f", "", "get all apis for specified function")
+	cmd.Flags().StringVar(&exportFormat, "export", "", "export the apis as a spec in the format (openapi), along with the function schemas")
 	return cmd
 }
 
@@ -67,11 +71,19 @@ func getAPIs(out, errOut io.Writer, cmd *cobra.Command) error {
 	if functionName != "" {
 		params.Function = swag.String(functionName)
 	}
+	params.Limit, params.Continue, params.SortBy = listPage()
 	get, err := client.Endpoint.GetApis(params, GetAuthInfoWriter())
 	if err != nil {
 		return formatAPIError(err, params)
 	}
-	return formatAPIOutput(out, true, get.Payload)
+	if exportFormat != "" {
+		return exportOpenAPI(out, get.Payload)
+	}
+	if err := formatAPIOutput(out, true, get.Payload); err != nil {
+		return err
+	}
+	printContinue(errOut, get.XContinue)
+	return nil
 }
 
 func getAPI(out, errOut io.Writer, cmd *cobra.Command, args []string) error {
@@ -90,7 +102,9 @@ func getAPI(out, errOut io.Writer, cmd *cobra.Command, args []string) error {
 	if err != nil {
 		return formatAPIError(err, params)
 	}
-
+	if exportFormat != "" {
+		return exportOpenAPI(out, []*models.API{get.Payload})
+	}
 	return formatAPIOutput(out, false, []*models.API{get.Payload})
 }
 
@@ -67,11 +67,16 @@ func getApplications(out, errOut io.Writer, cmd *cobra.Command) error {
 	params := &application.GetAppsParams{
 		Context: context.Background(),
 	}
+	params.Limit, params.Continue, params.SortBy = listPage()
 	resp, err := client.Application.GetApps(params, GetAuthInfoWriter())
 	if err != nil {
 		return formatAPIError(err, params)
 	}
-	return formatApplicationOutput(out, true, resp.Payload)
+	if err := formatApplicationOutput(out, true, resp.Payload); err != nil {
+		return err
+	}
+	printContinue(errOut, resp.XContinue)
+	return nil
 }
 
 func formatApplicationOutput(out io.Writer, list bool, applications []*models.Application) error {
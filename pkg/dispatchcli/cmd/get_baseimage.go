@@ -67,11 +67,16 @@ func getBaseImages(out, errOut io.Writer, cmd *cobra.Command) error {
 	params := &baseimage.GetBaseImagesParams{
 		Context: context.Background(),
 	}
+	params.Limit, params.Continue, params.SortBy = listPage()
 	resp, err := client.BaseImage.GetBaseImages(params, GetAuthInfoWriter())
 	if err != nil {
 		return formatAPIError(err, params)
 	}
-	return formatBaseImageOutput(out, true, resp.Payload)
+	if err := formatBaseImageOutput(out, true, resp.Payload); err != nil {
+		return err
+	}
+	printContinue(errOut, resp.XContinue)
+	return nil
 }
 
 func formatBaseImageOutput(out io.Writer, list bool, images []*models.BaseImage) error {
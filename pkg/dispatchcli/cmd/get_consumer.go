@@ -0,0 +1,108 @@
+///////////////////////////////////////////////////////////////////////
+// Copyright (c) 2018 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+///////////////////////////////////////////////////////////////////////
+
+package cmd
+
+import (
+	"encoding/json"
+	"io"
+	"time"
+
+	"github.com/olekukonko/tablewriter"
+	"github.com/spf13/cobra"
+	"golang.org/x/net/context"
+
+	consumerclient "github.com/vmware/dispatch/pkg/api-manager/gen/client/consumer"
+	"github.com/vmware/dispatch/pkg/api-manager/gen/models"
+	"github.com/vmware/dispatch/pkg/dispatchcli/cmd/utils"
+	"github.com/vmware/dispatch/pkg/dispatchcli/i18n"
+)
+
+var (
+	getConsumerLong = i18n.T(`Get api consumers.`)
+
+	// TODO: add examples
+	getConsumerExample = i18n.T(``)
+)
+
+// NewCmdGetConsumer creates command responsible for getting api consumers.
+func NewCmdGetConsumer(out io.Writer, errOut io.Writer) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "consumer [CONSUMER_NAME]",
+		Short:   i18n.T("Get api consumers"),
+		Long:    getConsumerLong,
+		Example: getConsumerExample,
+		Args:    cobra.MaximumNArgs(1),
+		Aliases: []string{"consumers"},
+		Run: func(cmd *cobra.Command, args []string) {
+			var err error
+			if len(args) == 1 {
+				err = getConsumer(out, errOut, cmd, args)
+			} else {
+				err = getConsumers(out, errOut, cmd)
+			}
+			CheckErr(err)
+		},
+	}
+	cmd.Flags().StringVarP(&cmdFlagApplication, "application", "a", "", "filter by application")
+	return cmd
+}
+
+func getConsumers(out, errOut io.Writer, cmd *cobra.Command) error {
+
+	client := apiManagerClient()
+
+	params := &consumerclient.GetConsumersParams{
+		Context: context.Background(),
+		Tags:    []string{},
+	}
+	utils.AppendApplication(&params.Tags, cmdFlagApplication)
+	params.Limit, params.Continue, params.SortBy = listPage()
+	get, err := client.Consumer.GetConsumers(params, GetAuthInfoWriter())
+	if err != nil {
+		return formatAPIError(err, params)
+	}
+	if err := formatConsumerOutput(out, true, get.Payload); err != nil {
+		return err
+	}
+	printContinue(errOut, get.XContinue)
+	return nil
+}
+
+func getConsumer(out, errOut io.Writer, cmd *cobra.Command, args []string) error {
+
+	client := apiManagerClient()
+
+	params := &consumerclient.GetConsumerParams{
+		Consumer: args[0],
+		Context:  context.Background(),
+	}
+	get, err := client.Consumer.GetConsumer(params, GetAuthInfoWriter())
+	if err != nil {
+		return formatAPIError(err, params)
+	}
+	return formatConsumerOutput(out, false, []*models.Consumer{get.Payload})
+}
+
+func formatConsumerOutput(out io.Writer, list bool, consumers []*models.Consumer) error {
+
+	if dispatchConfig.JSON {
+		encoder := json.NewEncoder(out)
+		encoder.SetIndent("", "    ")
+		if list {
+			return encoder.Encode(consumers)
+		}
+		return encoder.Encode(consumers[0])
+	}
+	table := tablewriter.NewWriter(out)
+	table.SetHeader([]string{"Name", "Status", "Created Date"})
+	table.SetBorders(tablewriter.Border{Left: false, Top: false, Right: false, Bottom: false})
+	table.SetCenterSeparator("")
+	for _, c := range consumers {
+		table.Append([]string{*c.Name, string(c.Status), time.Unix(c.CreatedTime, 0).Local().Format(time.UnixDate)})
+	}
+	table.Render()
+	return nil
+}
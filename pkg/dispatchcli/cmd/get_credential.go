@@ -0,0 +1,55 @@
+///////////////////////////////////////////////////////////////////////
+// Copyright (c) 2018 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+///////////////////////////////////////////////////////////////////////
+
+package cmd
+
+import (
+	"io"
+
+	"github.com/spf13/cobra"
+	"golang.org/x/net/context"
+
+	consumerclient "github.com/vmware/dispatch/pkg/api-manager/gen/client/consumer"
+	"github.com/vmware/dispatch/pkg/dispatchcli/i18n"
+)
+
+var (
+	getCredentialLong = i18n.T(`Get the credentials of an api consumer. Secrets are not shown.`)
+
+	// TODO: add examples
+	getCredentialExample = i18n.T(``)
+)
+
+// NewCmdGetCredential creates command responsible for getting the credentials of api consumers.
+func NewCmdGetCredential(out io.Writer, errOut io.Writer) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "credential CONSUMER_NAME",
+		Short:   i18n.T("Get the credentials of an api consumer"),
+		Long:    getCredentialLong,
+		Example: getCredentialExample,
+		Args:    cobra.ExactArgs(1),
+		Aliases: []string{"credentials"},
+		Run: func(cmd *cobra.Command, args []string) {
+			err := getCredentials(out, errOut, cmd, args)
+			CheckErr(err)
+		},
+	}
+	return cmd
+}
+
+func getCredentials(out, errOut io.Writer, cmd *cobra.Command, args []string) error {
+
+	client := apiManagerClient()
+
+	params := &consumerclient.GetCredentialsParams{
+		Consumer: args[0],
+		Context:  context.Background(),
+	}
+	get, err := client.Consumer.GetCredentials(params, GetAuthInfoWriter())
+	if err != nil {
+		return formatAPIError(err, params)
+	}
+	return formatCredentialOutput(out, true, get.Payload)
+}
@@ -0,0 +1,121 @@
+///////////////////////////////////////////////////////////////////////
+// Copyright (c) 2018 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+///////////////////////////////////////////////////////////////////////
+
+package cmd
+
+import (
+	"encoding/json"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/olekukonko/tablewriter"
+	"github.com/spf13/cobra"
+	"golang.org/x/net/context"
+
+	"github.com/vmware/dispatch/pkg/dispatchcli/cmd/utils"
+	"github.com/vmware/dispatch/pkg/dispatchcli/i18n"
+	"github.com/vmware/dispatch/pkg/event-manager/gen/client/deadletters"
+	models "github.com/vmware/dispatch/pkg/event-manager/gen/models"
+)
+
+var (
+	getDeadLettersLong = i18n.T(`Get dead letters, i.e. events which could not be delivered to the function of a subscription.`)
+
+	getDeadLettersExample = i18n.T(`
+		# List all dead letters
+		dispatch get deadletters
+		# List dead letters of subscription "my-subscription"
+		dispatch get deadletters --subscription my-subscription`)
+
+	getDeadLettersSubscription = ""
+)
+
+// NewCmdGetDeadLetter creates command responsible for getting dead letters.
+func NewCmdGetDeadLetter(out io.Writer, errOut io.Writer) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "deadletter [DEAD_LETTER] [--subscription SUBSCRIPTION]",
+		Short:   i18n.T("Get dead letters"),
+		Long:    getDeadLettersLong,
+		Example: getDeadLettersExample,
+		Args:    cobra.MaximumNArgs(1),
+		Aliases: []string{"deadletters"},
+		Run: func(cmd *cobra.Command, args []string) {
+			var err error
+			if len(args) > 0 {
+				err = getDeadLetter(out, errOut, cmd, args)
+			} else {
+				err = getDeadLetters(out, errOut, cmd)
+			}
+			CheckErr(err)
+		},
+	}
+	cmd.Flags().StringVarP(&cmdFlagApplication, "application", "a", "", "filter by application")
+	cmd.Flags().StringVar(&getDeadLettersSubscription, "subscription", "", "filter by subscription")
+	return cmd
+}
+
+func getDeadLetter(out, errOut io.Writer, cmd *cobra.Command, args []string) error {
+	client := eventManagerClient()
+	params := &deadletters.GetDeadLetterParams{
+		Context:        context.Background(),
+		DeadLetterName: args[0],
+		Tags:           []string{},
+	}
+	utils.AppendApplication(&params.Tags, cmdFlagApplication)
+
+	resp, err := client.Deadletters.GetDeadLetter(params, GetAuthInfoWriter())
+	if err != nil {
+		return formatAPIError(err, params)
+	}
+	return formatDeadLetterOutput(out, false, []*models.DeadLetter{resp.Payload})
+}
+
+func getDeadLetters(out, errOut io.Writer, cmd *cobra.Command) error {
+	client := eventManagerClient()
+	params := &deadletters.GetDeadLettersParams{
+		Context: context.Background(),
+		Tags:    []string{},
+	}
+	if getDeadLettersSubscription != "" {
+		params.Subscription = &getDeadLettersSubscription
+	}
+	utils.AppendApplication(&params.Tags, cmdFlagApplication)
+
+	params.Limit, params.Continue, params.SortBy = listPage()
+	resp, err := client.Deadletters.GetDeadLetters(params, GetAuthInfoWriter())
+	if err != nil {
+		return formatAPIError(err, params)
+	}
+	if err := formatDeadLetterOutput(out, true, resp.Payload); err != nil {
+		return err
+	}
+	printContinue(errOut, resp.XContinue)
+	return nil
+}
+
+func formatDeadLetterOutput(out io.Writer, list bool, deadLetters []*models.DeadLetter) error {
+	if dispatchConfig.JSON {
+		encoder := json.NewEncoder(out)
+		encoder.SetIndent("", "    ")
+		if list {
+			return encoder.Encode(deadLetters)
+		}
+		return encoder.Encode(deadLetters[0])
+	}
+	table := tablewriter.NewWriter(out)
+	table.SetHeader([]string{"Name", "Subscription", "Function", "Event ID", "Attempts", "Reason", "Created date"})
+	table.SetBorders(tablewriter.Border{Left: false, Top: false, Right: false, Bottom: false})
+	table.SetCenterSeparator("")
+	for _, dl := range deadLetters {
+		var eventID string
+		if dl.Event != nil && dl.Event.EventID != nil {
+			eventID = *dl.Event.EventID
+		}
+		table.Append([]string{dl.Name, dl.Subscription, dl.Function, eventID, strconv.FormatInt(dl.Attempts, 10), dl.Reason, time.Unix(dl.CreatedTime, 0).Local().Format(time.UnixDate)})
+	}
+	table.Render()
+	return nil
+}
@@ -0,0 +1,147 @@
+///////////////////////////////////////////////////////////////////////
+// Copyright (c) 2018 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+///////////////////////////////////////////////////////////////////////
+
+package cmd
+
+import (
+	"encoding/json"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/olekukonko/tablewriter"
+	"github.com/spf13/cobra"
+	"golang.org/x/net/context"
+
+	"github.com/vmware/dispatch/pkg/dispatchcli/cmd/utils"
+	"github.com/vmware/dispatch/pkg/dispatchcli/i18n"
+	"github.com/vmware/dispatch/pkg/event-manager/gen/client/events"
+	models "github.com/vmware/dispatch/pkg/event-manager/gen/models"
+)
+
+var (
+	getEventsLong = i18n.T(`Get events emitted or received by Dispatch, along with the function runs they triggered.`)
+
+	getEventsExample = i18n.T(`
+		# List events of type "vm.being.created" from the last hour
+		dispatch get events --event-type vm.being.created --since 1h
+		# Get a single event, with the runs it triggered
+		dispatch get event c2a1fd4a-01a8-4dd8-ae6e-bf1bc8e0e5d9 --json`)
+
+	getEventsEventType  = ""
+	getEventsSourceType = ""
+	getEventsSince      time.Duration
+)
+
+// NewCmdGetEvent creates command responsible for getting recorded events.
+func NewCmdGetEvent(out io.Writer, errOut io.Writer) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "event [EVENT_ID] [--event-type EVENT.TYPE] [--source-type SOURCE-TYPE] [--since DURATION]",
+		Short:   i18n.T("Get events"),
+		Long:    getEventsLong,
+		Example: getEventsExample,
+		Args:    cobra.MaximumNArgs(1),
+		Aliases: []string{"events"},
+		Run: func(cmd *cobra.Command, args []string) {
+			var err error
+			if len(args) > 0 {
+				err = getEvent(out, errOut, cmd, args)
+			} else {
+				err = getEvents(out, errOut, cmd)
+			}
+			CheckErr(err)
+		},
+	}
+	cmd.Flags().StringVarP(&cmdFlagApplication, "application", "a", "", "filter by application")
+	cmd.Flags().StringVar(&getEventsEventType, "event-type", "", "filter by event type")
+	cmd.Flags().StringVar(&getEventsSourceType, "source-type", "", "filter by source type")
+	cmd.Flags().DurationVar(&getEventsSince, "since", 0, "only events recorded within the duration, e.g. 1h")
+	return cmd
+}
+
+func getEvent(out, errOut io.Writer, cmd *cobra.Command, args []string) error {
+	client := eventManagerClient()
+	params := &events.GetEventParams{
+		Context:   context.Background(),
+		EventName: args[0],
+		Tags:      []string{},
+	}
+	utils.AppendApplication(&params.Tags, cmdFlagApplication)
+
+	resp, err := client.Events.GetEvent(params, GetAuthInfoWriter())
+	if err != nil {
+		return formatAPIError(err, params)
+	}
+	return formatEventOutput(out, false, []*models.EventRecord{resp.Payload})
+}
+
+func getEvents(out, errOut io.Writer, cmd *cobra.Command) error {
+	client := eventManagerClient()
+	params := &events.GetEventsParams{
+		Context: context.Background(),
+		Tags:    []string{},
+	}
+	if getEventsEventType != "" {
+		params.EventType = &getEventsEventType
+	}
+	if getEventsSourceType != "" {
+		params.SourceType = &getEventsSourceType
+	}
+	if getEventsSince > 0 {
+		since := time.Now().Add(-getEventsSince).Unix()
+		params.Since = &since
+	}
+	utils.AppendApplication(&params.Tags, cmdFlagApplication)
+
+	params.Limit, params.Continue, params.SortBy = listPage()
+	resp, err := client.Events.GetEvents(params, GetAuthInfoWriter())
+	if err != nil {
+		return formatAPIError(err, params)
+	}
+	if err := formatEventOutput(out, true, resp.Payload); err != nil {
+		return err
+	}
+	printContinue(errOut, resp.XContinue)
+	return nil
+}
+
+func formatEventOutput(out io.Writer, list bool, records []*models.EventRecord) error {
+	if dispatchConfig.JSON {
+		encoder := json.NewEncoder(out)
+		encoder.SetIndent("", "    ")
+		if list {
+			return encoder.Encode(records)
+		}
+		return encoder.Encode(records[0])
+	}
+	table := tablewriter.NewWriter(out)
+	table.SetHeader([]string{"Name", "Event Type", "Source Type", "Emitted", "Runs", "Created date"})
+	table.SetBorders(tablewriter.Border{Left: false, Top: false, Right: false, Bottom: false})
+	table.SetCenterSeparator("")
+	for _, r := range records {
+		var eventType, sourceType string
+		if r.Event != nil && r.Event.EventType != nil {
+			eventType = *r.Event.EventType
+		}
+		if r.Event != nil && r.Event.SourceType != nil {
+			sourceType = *r.Event.SourceType
+		}
+		var runs []string
+		for _, run := range r.Runs {
+			if run.Error != "" {
+				runs = append(runs, run.Function+" (failed)")
+			} else {
+				runs = append(runs, run.Function)
+			}
+		}
+		emitted := "no"
+		if r.Emitted {
+			emitted = "yes"
+		}
+		table.Append([]string{r.Name, eventType, sourceType, emitted, strings.Join(runs, ", "), time.Unix(r.CreatedTime, 0).Local().Format(time.UnixDate)})
+	}
+	table.Render()
+	return nil
+}
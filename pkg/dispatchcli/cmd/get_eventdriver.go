@@ -59,11 +59,16 @@ func getEventDrivers(out, errOut io.Writer, cmd *cobra.Command) error {
 	}
 	utils.AppendApplication(&params.Tags, cmdFlagApplication)
 
+	params.Limit, params.Continue, params.SortBy = listPage()
 	get, err := eventManagerClient().Drivers.GetDrivers(params, GetAuthInfoWriter())
 	if err != nil {
 		return formatAPIError(err, params)
 	}
-	return formatEventDriverOutput(out, true, get.Payload)
+	if err := formatEventDriverOutput(out, true, get.Payload); err != nil {
+		return err
+	}
+	printContinue(errOut, get.XContinue)
+	return nil
 }
 
 func getEventDriver(out, errOut io.Writer, cmd *cobra.Command, args []string) error {
@@ -95,7 +100,7 @@ func formatEventDriverOutput(out io.Writer, list bool, drivers []*models.Driver)
 		return encoder.Encode(drivers[0])
 	}
 	table := tablewriter.NewWriter(out)
-	table.SetHeader([]string{"Name", "Type", "Status", "Secrets", "Config"})
+	table.SetHeader([]string{"Name", "Type", "Status", "Reason", "Secrets", "Config"})
 	table.SetBorders(tablewriter.Border{Left: false, Top: false, Right: false, Bottom: false})
 	table.SetCenterSeparator("-")
 	table.SetRowLine(true)
@@ -106,6 +111,7 @@ func formatEventDriverOutput(out io.Writer, list bool, drivers []*models.Driver)
 		}
 		table.Append([]string{
 			*d.Name, *d.Type, fmt.Sprintf("%s", d.Status),
+			strings.Join(d.Reason, "\n"),
 			strings.Join(d.Secrets, ","),
 			strings.Join(configs, "\n"),
 		})
@@ -58,6 +58,7 @@ func getEventDriverTypes(out, errOut io.Writer, cmd *cobra.Command) error {
 	}
 	utils.AppendApplication(&params.Tags, cmdFlagApplication)
 
+	params.Limit, params.Continue, params.SortBy = listPage()
 	get, err := eventManagerClient().Drivers.GetDriverTypes(params, GetAuthInfoWriter())
 	if err != nil {
 		return formatAPIError(err, params)
@@ -73,7 +74,11 @@ func getEventDriverTypes(out, errOut io.Writer, cmd *cobra.Command) error {
 		}
 	}
 
-	return formatEventDriverTypeOutput(out, true, filtered)
+	if err := formatEventDriverTypeOutput(out, true, filtered); err != nil {
+		return err
+	}
+	printContinue(errOut, get.XContinue)
+	return nil
 }
 
 func getEventDriverType(out, errOut io.Writer, cmd *cobra.Command, args []string) error {
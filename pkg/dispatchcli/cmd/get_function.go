@@ -74,11 +74,16 @@ func getFunctions(out, errOut io.Writer, cmd *cobra.Command) error {
 	}
 	utils.AppendApplication(&params.Tags, cmdFlagApplication)
 
+	params.Limit, params.Continue, params.SortBy = listPage()
 	resp, err := client.Store.GetFunctions(params, GetAuthInfoWriter())
 	if err != nil {
 		return formatAPIError(err, params)
 	}
-	return formatFunctionOutput(out, true, resp.Payload)
+	if err := formatFunctionOutput(out, true, resp.Payload); err != nil {
+		return err
+	}
+	printContinue(errOut, resp.XContinue)
+	return nil
 }
 
 func formatFunctionOutput(out io.Writer, list bool, functions []*models.Function) error {
@@ -74,11 +74,16 @@ func getImages(out, errOut io.Writer, cmd *cobra.Command) error {
 	}
 	utils.AppendApplication(&params.Tags, cmdFlagApplication)
 
+	params.Limit, params.Continue, params.SortBy = listPage()
 	resp, err := client.Image.GetImages(params, GetAuthInfoWriter())
 	if err != nil {
 		return formatAPIError(err, params)
 	}
-	return formatImageOutput(out, true, resp.Payload)
+	if err := formatImageOutput(out, true, resp.Payload); err != nil {
+		return err
+	}
+	printContinue(errOut, resp.XContinue)
+	return nil
 }
 
 func formatImageOutput(out io.Writer, list bool, images []*models.Image) error {
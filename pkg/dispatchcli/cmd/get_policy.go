@@ -80,11 +80,16 @@ func getPolicies(out, errOut io.Writer, cmd *cobra.Command) error {
 		Context: context.Background(),
 	}
 
+	params.Limit, params.Continue, params.SortBy = listPage()
 	resp, err := client.Policy.GetPolicies(params, GetAuthInfoWriter())
 	if err != nil {
 		return formatAPIError(err, params)
 	}
-	return formatPolicyOutput(out, true, resp.Payload)
+	if err := formatPolicyOutput(out, true, resp.Payload); err != nil {
+		return err
+	}
+	printContinue(errOut, resp.XContinue)
+	return nil
 }
 
 func formatPolicyOutput(out io.Writer, list bool, policies []*models.Policy) error {
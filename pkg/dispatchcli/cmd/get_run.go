@@ -78,11 +78,16 @@ func getRuns(out, errOut io.Writer, cmd *cobra.Command, args []string) error {
 	}
 	utils.AppendApplication(&params.Tags, cmdFlagApplication)
 
+	params.Limit, params.Continue, params.SortBy = listPage()
 	resp, err := client.Runner.GetRuns(params, GetAuthInfoWriter())
 	if err != nil {
 		return formatAPIError(err, params)
 	}
-	return formatRunOutput(out, true, resp.Payload)
+	if err := formatRunOutput(out, true, resp.Payload); err != nil {
+		return err
+	}
+	printContinue(errOut, resp.XContinue)
+	return nil
 }
 
 func getFunctionRuns(out, errOut io.Writer, cmd *cobra.Command, args []string) error {
@@ -96,11 +101,16 @@ func getFunctionRuns(out, errOut io.Writer, cmd *cobra.Command, args []string) e
 	if len(args) > 0 {
 		params.FunctionName = &args[0]
 	}
+	params.Limit, params.Continue, params.SortBy = listPage()
 	resp, err := client.Runner.GetRuns(params, GetAuthInfoWriter())
 	if err != nil {
 		return formatAPIError(err, params)
 	}
-	return formatRunOutput(out, true, resp.Payload)
+	if err := formatRunOutput(out, true, resp.Payload); err != nil {
+		return err
+	}
+	printContinue(errOut, resp.XContinue)
+	return nil
 }
 
 func formatRunOutput(out io.Writer, list bool, runs []*models.Run) error {
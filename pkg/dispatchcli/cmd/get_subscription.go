@@ -74,11 +74,16 @@ func getSubscriptions(out, errOut io.Writer, cmd *cobra.Command) error {
 	}
 	utils.AppendApplication(&params.Tags, cmdFlagApplication)
 
+	params.Limit, params.Continue, params.SortBy = listPage()
 	resp, err := client.Subscriptions.GetSubscriptions(params, GetAuthInfoWriter())
 	if err != nil {
 		return formatAPIError(err, params)
 	}
-	return formatSubscriptionOutput(out, true, resp.Payload)
+	if err := formatSubscriptionOutput(out, true, resp.Payload); err != nil {
+		return err
+	}
+	printContinue(errOut, resp.XContinue)
+	return nil
 }
 
 func formatSubscriptionOutput(out io.Writer, list bool, subscriptions []*models.Subscription) error {
@@ -0,0 +1,80 @@
+///////////////////////////////////////////////////////////////////////
+// Copyright (c) 2018 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+///////////////////////////////////////////////////////////////////////
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/olekukonko/tablewriter"
+	"github.com/spf13/cobra"
+	"golang.org/x/net/context"
+
+	"github.com/vmware/dispatch/pkg/dispatchcli/i18n"
+	fnstore "github.com/vmware/dispatch/pkg/function-manager/gen/client/store"
+	models "github.com/vmware/dispatch/pkg/function-manager/gen/models"
+)
+
+var (
+	getVersionLong = i18n.T(`Get the published versions of a function.`)
+
+	// TODO: add examples
+	getVersionExample = i18n.T(``)
+)
+
+// NewCmdGetVersion creates command responsible for getting function versions.
+func NewCmdGetVersion(out io.Writer, errOut io.Writer) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "version FUNCTION_NAME",
+		Short:   i18n.T("Get the versions of a function"),
+		Long:    getVersionLong,
+		Example: getVersionExample,
+		Args:    cobra.ExactArgs(1),
+		Aliases: []string{"versions"},
+		Run: func(cmd *cobra.Command, args []string) {
+			err := getVersions(out, errOut, cmd, args)
+			CheckErr(err)
+		},
+	}
+	return cmd
+}
+
+func getVersions(out, errOut io.Writer, cmd *cobra.Command, args []string) error {
+	client := functionManagerClient()
+	params := &fnstore.GetVersionsParams{
+		FunctionName: args[0],
+		Context:      context.Background(),
+	}
+	params.Limit, params.Continue, params.SortBy = listPage()
+	get, err := client.Store.GetVersions(params, GetAuthInfoWriter())
+	if err != nil {
+		return formatAPIError(err, params)
+	}
+	if err := formatVersionOutput(out, get.Payload); err != nil {
+		return err
+	}
+	printContinue(errOut, get.XContinue)
+	return nil
+}
+
+func formatVersionOutput(out io.Writer, versions []*models.FunctionVersion) error {
+	if dispatchConfig.JSON {
+		encoder := json.NewEncoder(out)
+		encoder.SetIndent("", "    ")
+		return encoder.Encode(versions)
+	}
+	table := tablewriter.NewWriter(out)
+	table.SetHeader([]string{"Name", "Image", "Status", "Created Date"})
+	table.SetBorders(tablewriter.Border{Left: false, Top: false, Right: false, Bottom: false})
+	table.SetCenterSeparator("")
+	for _, v := range versions {
+		table.Append([]string{fmt.Sprintf("%s:%d", v.FunctionName, v.Version), v.Image, string(v.Status), time.Unix(v.CreatedTime, 0).Local().Format(time.UnixDate)})
+	}
+	table.Render()
+	return nil
+}
@@ -0,0 +1,588 @@
+///////////////////////////////////////////////////////////////////////
+// Copyright (c) 2018 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+///////////////////////////////////////////////////////////////////////
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/ghodss/yaml"
+	"github.com/go-openapi/loads"
+	"github.com/go-openapi/spec"
+	"github.com/go-openapi/swag"
+	"golang.org/x/net/context"
+
+	"github.com/vmware/dispatch/pkg/api-manager/gateway"
+	apiclient "github.com/vmware/dispatch/pkg/api-manager/gen/client/endpoint"
+	apimodels "github.com/vmware/dispatch/pkg/api-manager/gen/models"
+	fnstore "github.com/vmware/dispatch/pkg/function-manager/gen/client/store"
+	fnmodels "github.com/vmware/dispatch/pkg/function-manager/gen/models"
+)
+
+// OpenAPI (swagger 2.0) vendor extensions, mapping operations to dispatch APIs
+const (
+	// the function run by an operation, operations without functions are skipped
+	openAPIFunction = "x-dispatch-function"
+	// the API of an operation, the operations of the same API are merged
+	openAPIName = "x-dispatch-api"
+	// the authentication method of a security definition, which has no OpenAPI type (i.e. jwt)
+	openAPIAuthentication = "x-dispatch-authentication"
+	// the domain names of an operation, if they differ from the host of the spec
+	openAPIHosts = "x-dispatch-hosts"
+	openAPICORS  = "x-dispatch-cors"
+)
+
+var (
+	openAPIMethods = []string{"GET", "PUT", "POST", "DELETE", "OPTIONS", "HEAD", "PATCH"}
+	invalidAPIName = regexp.MustCompile(`[^\w\d\-]+`)
+)
+
+// loadOpenAPI loads a swagger 2.0 spec, in JSON or YAML, with the references expanded
+func loadOpenAPI(file string) (*spec.Swagger, error) {
+	doc, err := loads.Spec(file)
+	if err != nil {
+		return nil, err
+	}
+	doc, err = doc.Expanded()
+	if err != nil {
+		return nil, err
+	}
+	return doc.Spec(), nil
+}
+
+func pathOperation(item *spec.PathItem, method string) *spec.Operation {
+	switch method {
+	case "GET":
+		return item.Get
+	case "PUT":
+		return item.Put
+	case "POST":
+		return item.Post
+	case "DELETE":
+		return item.Delete
+	case "OPTIONS":
+		return item.Options
+	case "HEAD":
+		return item.Head
+	case "PATCH":
+		return item.Patch
+	}
+	return nil
+}
+
+func setPathOperation(item *spec.PathItem, method string, op *spec.Operation) {
+	switch method {
+	case "GET":
+		item.Get = op
+	case "PUT":
+		item.Put = op
+	case "POST":
+		item.Post = op
+	case "DELETE":
+		item.Delete = op
+	case "OPTIONS":
+		item.Options = op
+	case "HEAD":
+		item.Head = op
+	case "PATCH":
+		item.Patch = op
+	}
+}
+
+// openAPIAuth returns the authentication method of the security requirements
+func openAPIAuth(doc *spec.Swagger, security []map[string][]string) (string, error) {
+	for _, requirement := range security {
+		for name := range requirement {
+			definition, ok := doc.SecurityDefinitions[name]
+			if !ok {
+				return "", fmt.Errorf("security definition %s not found", name)
+			}
+			if auth, ok := definition.Extensions.GetString(openAPIAuthentication); ok {
+				return auth, nil
+			}
+			switch definition.Type {
+			case "basic":
+				return gateway.AuthBasic, nil
+			case "apiKey":
+				return gateway.AuthKey, nil
+			case "oauth2":
+				return gateway.AuthOAuth2, nil
+			}
+			return "", fmt.Errorf("security definition %s has an unsupported type %s", name, definition.Type)
+		}
+	}
+	return gateway.AuthPublic, nil
+}
+
+// openAPIInput returns the schema of the function input, the body of the operation, or else its query parameters
+func openAPIInput(params []spec.Parameter) *spec.Schema {
+	var query *spec.Schema
+	for _, p := range params {
+		switch p.In {
+		case "body":
+			return p.Schema
+		case "query":
+			if query == nil {
+				query = new(spec.Schema).Typed("object", "")
+			}
+			property := new(spec.Schema).Typed(p.Type, p.Format)
+			property.Description = p.Description
+			if p.Items != nil {
+				property.Items = &spec.SchemaOrArray{Schema: new(spec.Schema).Typed(p.Items.Type, p.Items.Format)}
+			}
+			query.SetProperty(p.Name, *property)
+			if p.Required {
+				query.AddRequired(p.Name)
+			}
+		}
+	}
+	return query
+}
+
+// openAPIOutput returns the schema of the function output, the schema of the success response
+func openAPIOutput(op *spec.Operation) *spec.Schema {
+	if op.Responses == nil {
+		return nil
+	}
+	var codes []int
+	for code := range op.Responses.StatusCodeResponses {
+		if code/100 == 2 {
+			codes = append(codes, code)
+		}
+	}
+	sort.Ints(codes)
+	if len(codes) > 0 {
+		return op.Responses.StatusCodeResponses[codes[0]].Schema
+	}
+	if op.Responses.Default != nil {
+		return op.Responses.Default.Schema
+	}
+	return nil
+}
+
+func schemaModel(schema *spec.Schema) (interface{}, error) {
+	if schema == nil {
+		return nil, nil
+	}
+	b, err := json.Marshal(schema)
+	if err != nil {
+		return nil, err
+	}
+	var m map[string]interface{}
+	err = json.Unmarshal(b, &m)
+	return m, err
+}
+
+// apisFromOpenAPI maps each operation of a spec, which runs a function, to an API. The schemas of the functions are
+// the ones of the first operation running them.
+func apisFromOpenAPI(doc *spec.Swagger) ([]*apimodels.API, map[string]*fnmodels.Schema, error) {
+	var apis []*apimodels.API
+	byName := make(map[string]*apimodels.API)
+	schemas := make(map[string]*fnmodels.Schema)
+	if doc.Paths == nil {
+		return apis, schemas, nil
+	}
+
+	var hosts []string
+	if doc.Host != "" {
+		host := doc.Host
+		if h, _, err := net.SplitHostPort(host); err == nil {
+			host = h
+		}
+		hosts = []string{host}
+	}
+	basePath := doc.BasePath
+	if basePath == "" {
+		basePath = "/"
+	}
+
+	var uris []string
+	for uri := range doc.Paths.Paths {
+		uris = append(uris, uri)
+	}
+	sort.Strings(uris)
+	for _, uri := range uris {
+		item := doc.Paths.Paths[uri]
+		for _, method := range openAPIMethods {
+			op := pathOperation(&item, method)
+			if op == nil {
+				continue
+			}
+			function, ok := op.Extensions.GetString(openAPIFunction)
+			if !ok {
+				function, ok = item.Extensions.GetString(openAPIFunction)
+			}
+			if !ok || function == "" {
+				continue
+			}
+
+			apiURI := path.Join(basePath, uri)
+			name, ok := op.Extensions.GetString(openAPIName)
+			if !ok {
+				name = op.ID
+			}
+			if name == "" || invalidAPIName.MatchString(name) {
+				name = strings.Trim(invalidAPIName.ReplaceAllString(apiURI, "-"), "-")
+				name = strings.Trim(strings.ToLower(method)+"-"+name, "-")
+			}
+			if api, ok := byName[name]; ok {
+				if *api.Function != function {
+					return nil, nil, fmt.Errorf("operation %s: the API already runs function %s", name, *api.Function)
+				}
+				if !stringInSlice(api.Methods, method) {
+					api.Methods = append(api.Methods, method)
+				}
+				if !stringInSlice(api.Uris, apiURI) {
+					api.Uris = append(api.Uris, apiURI)
+				}
+				continue
+			}
+			security := doc.Security
+			if op.Security != nil {
+				security = op.Security
+			}
+			auth, err := openAPIAuth(doc, security)
+			if err != nil {
+				return nil, nil, fmt.Errorf("operation %s: %v", name, err)
+			}
+			schemes := doc.Schemes
+			if len(op.Schemes) > 0 {
+				schemes = op.Schemes
+			}
+			protocols := []string{}
+			for _, s := range schemes {
+				if s == "http" || s == "https" {
+					protocols = append(protocols, s)
+				}
+			}
+			if len(protocols) == 0 {
+				protocols = []string{"http", "https"}
+			}
+			apiHosts := hosts
+			if h, ok := op.Extensions.GetStringSlice(openAPIHosts); ok {
+				apiHosts = h
+			}
+			cors, _ := op.Extensions.GetBool(openAPICORS)
+
+			api := &apimodels.API{
+				Name:           swag.String(name),
+				Function:       swag.String(function),
+				Uris:           []string{apiURI},
+				Methods:        []string{method},
+				Hosts:          apiHosts,
+				Protocols:      protocols,
+				Authentication: auth,
+				Cors:           cors,
+				Enabled:        true,
+				Tags:           []*apimodels.Tag{},
+			}
+			if err := gateway.ValidateURIs(api.Uris); err != nil {
+				return nil, nil, fmt.Errorf("operation %s: %v", name, err)
+			}
+			apis = append(apis, api)
+			byName[name] = api
+
+			if _, ok := schemas[function]; ok {
+				continue
+			}
+			params := append(append([]spec.Parameter{}, item.Parameters...), op.Parameters...)
+			in, err := schemaModel(openAPIInput(params))
+			if err != nil {
+				return nil, nil, err
+			}
+			out, err := schemaModel(openAPIOutput(op))
+			if err != nil {
+				return nil, nil, err
+			}
+			if in != nil || out != nil {
+				schemas[function] = &fnmodels.Schema{In: in, Out: out}
+			}
+		}
+	}
+	return apis, schemas, nil
+}
+
+func stringInSlice(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func schemaFromModel(schema interface{}) (*spec.Schema, error) {
+	if schema == nil {
+		return nil, nil
+	}
+	b, err := json.Marshal(schema)
+	if err != nil {
+		return nil, err
+	}
+	s := new(spec.Schema)
+	err = json.Unmarshal(b, s)
+	return s, err
+}
+
+func openAPISecurity(auth string) *spec.SecurityScheme {
+	switch auth {
+	case gateway.AuthBasic:
+		return spec.BasicAuth()
+	case gateway.AuthKey:
+		return spec.APIKeyAuth("apikey", "header")
+	case gateway.AuthJWT:
+		s := spec.APIKeyAuth("Authorization", "header")
+		s.Description = "Bearer JSON web token"
+		s.AddExtension(openAPIAuthentication, gateway.AuthJWT)
+		return s
+	case gateway.AuthOAuth2:
+		return spec.OAuth2Application("/oauth2/token")
+	}
+	return nil
+}
+
+// openAPIFromAPIs generates a spec from APIs and the schemas of their functions, an operation for each URI and method
+func openAPIFromAPIs(apis []*apimodels.API, schemas map[string]*fnmodels.Schema) (*spec.Swagger, error) {
+	doc := &spec.Swagger{
+		SwaggerProps: spec.SwaggerProps{
+			Swagger: "2.0",
+			Info: &spec.Info{
+				InfoProps: spec.InfoProps{Title: "Dispatch APIs", Version: "1.0.0"},
+			},
+			Schemes:  []string{"http", "https"},
+			Consumes: []string{"application/json"},
+			Produces: []string{"application/json"},
+			Paths:    &spec.Paths{Paths: make(map[string]spec.PathItem)},
+		},
+	}
+
+	sorted := append([]*apimodels.API{}, apis...)
+	sort.Slice(sorted, func(i, j int) bool { return *sorted[i].Name < *sorted[j].Name })
+	for _, api := range sorted {
+		methods := api.Methods
+		if len(methods) == 0 {
+			methods = []string{"GET", "PUT", "POST", "DELETE", "PATCH"}
+		}
+		var in, out *spec.Schema
+		if schema, ok := schemas[*api.Function]; ok {
+			var err error
+			if in, err = schemaFromModel(schema.In); err != nil {
+				return nil, err
+			}
+			if out, err = schemaFromModel(schema.Out); err != nil {
+				return nil, err
+			}
+		}
+		security := openAPISecurity(api.Authentication)
+		if security != nil {
+			if doc.SecurityDefinitions == nil {
+				doc.SecurityDefinitions = make(spec.SecurityDefinitions)
+			}
+			doc.SecurityDefinitions[api.Authentication] = security
+		}
+
+		for i, uri := range api.Uris {
+			template, err := gateway.ParseURITemplate(uri)
+			if err != nil {
+				return nil, err
+			}
+			item := doc.Paths.Paths[uri]
+			for _, method := range methods {
+				method = strings.ToUpper(method)
+				id := *api.Name
+				if len(methods) > 1 {
+					id = fmt.Sprintf("%s-%s", id, strings.ToLower(method))
+				}
+				if len(api.Uris) > 1 {
+					id = fmt.Sprintf("%s-%d", id, i)
+				}
+				op := spec.NewOperation(id)
+				op.AddExtension(openAPIName, *api.Name)
+				op.AddExtension(openAPIFunction, *api.Function)
+				if len(api.Hosts) > 0 {
+					op.AddExtension(openAPIHosts, api.Hosts)
+				}
+				if api.Cors {
+					op.AddExtension(openAPICORS, true)
+				}
+				if len(api.Protocols) == 1 && api.Protocols[0] == "https" {
+					op.Schemes = []string{"https"}
+				}
+				if security != nil {
+					op.Security = []map[string][]string{{api.Authentication: {}}}
+				}
+				for _, name := range template.Params {
+					op.AddParam(spec.PathParam(name).Typed("string", ""))
+				}
+				if in != nil {
+					if method == "GET" || method == "OPTIONS" {
+						// the query parameters are the input of the function
+						var names []string
+						for name := range in.Properties {
+							names = append(names, name)
+						}
+						sort.Strings(names)
+						for _, name := range names {
+							property := in.Properties[name]
+							tpe := "string"
+							if len(property.Type) > 0 {
+								tpe = property.Type[0]
+							}
+							param := spec.QueryParam(name).Typed(tpe, property.Format)
+							param.Description = property.Description
+							param.Required = stringInSlice(in.Required, name)
+							op.AddParam(param)
+						}
+					} else {
+						op.AddParam(spec.BodyParam("input", in).AsRequired())
+					}
+				}
+				response := spec.NewResponse().WithDescription("the output of the function")
+				if out != nil {
+					response.WithSchema(out)
+				}
+				op.RespondsWith(200, response)
+				setPathOperation(&item, method, op)
+			}
+			doc.Paths.Paths[uri] = item
+		}
+	}
+	return doc, nil
+}
+
+func getFunctionModel(name string) (*fnmodels.Function, error) {
+	params := &fnstore.GetFunctionParams{
+		FunctionName: name,
+		Context:      context.Background(),
+		Tags:         []string{},
+	}
+	resp, err := functionManagerClient().Store.GetFunction(params, GetAuthInfoWriter())
+	if err != nil {
+		return nil, formatAPIError(err, params)
+	}
+	return resp.Payload, nil
+}
+
+// updateFunctionSchema sets the input and output schemas of a function, the ones which are nil are kept
+func updateFunctionSchema(name string, schema *fnmodels.Schema) error {
+	function, err := getFunctionModel(name)
+	if err != nil {
+		return err
+	}
+	if function.Schema == nil {
+		function.Schema = &fnmodels.Schema{}
+	}
+	if schema.In != nil {
+		function.Schema.In = schema.In
+	}
+	if schema.Out != nil {
+		function.Schema.Out = schema.Out
+	}
+	return CallUpdateFunction(function)
+}
+
+// createAPIsFromOpenAPI creates the APIs of the operations of a spec, and updates the schemas of their functions
+func createAPIsFromOpenAPI(out io.Writer, file string) error {
+	doc, err := loadOpenAPI(file)
+	if err != nil {
+		return formatCliError(err, fmt.Sprintf("Error when loading OpenAPI spec %s: %v", file, err))
+	}
+	apis, schemas, err := apisFromOpenAPI(doc)
+	if err != nil {
+		return formatCliError(err, fmt.Sprintf("Error when mapping OpenAPI spec %s: %v", file, err))
+	}
+	if len(apis) == 0 {
+		return formatCliError(nil, fmt.Sprintf("No operation of OpenAPI spec %s has a %s extension", file, openAPIFunction))
+	}
+
+	rateLimit, err := parseRateLimit(rateLimits)
+	if err != nil {
+		return formatCliError(err, fmt.Sprintf("Failed parsing rate-limit values: %v", err))
+	}
+	client := apiManagerClient()
+	var created []*apimodels.API
+	for _, api := range apis {
+		api.Enabled = !disable
+		api.RateLimit = rateLimit
+		api.TLS = tlsSecret
+		if cmdFlagApplication != "" {
+			api.Tags = append(api.Tags, &apimodels.Tag{
+				Key:   "Application",
+				Value: cmdFlagApplication,
+			})
+		}
+		params := &apiclient.AddAPIParams{
+			Body:    api,
+			Context: context.Background(),
+		}
+		resp, err := client.Endpoint.AddAPI(params, GetAuthInfoWriter())
+		if err != nil {
+			return formatAPIError(err, params)
+		}
+		created = append(created, resp.Payload)
+		if !dispatchConfig.JSON {
+			fmt.Fprintf(out, "Created api: %s\n", *resp.Payload.Name)
+		}
+	}
+
+	var functions []string
+	for name := range schemas {
+		functions = append(functions, name)
+	}
+	sort.Strings(functions)
+	for _, name := range functions {
+		if err := updateFunctionSchema(name, schemas[name]); err != nil {
+			return err
+		}
+		if !dispatchConfig.JSON {
+			fmt.Fprintf(out, "Updated function schema: %s\n", name)
+		}
+	}
+
+	if dispatchConfig.JSON {
+		encoder := json.NewEncoder(out)
+		encoder.SetIndent("", "    ")
+		return encoder.Encode(created)
+	}
+	return nil
+}
+
+// exportOpenAPI writes the spec of APIs in YAML, or in JSON with --json
+func exportOpenAPI(out io.Writer, apis []*apimodels.API) error {
+	schemas := make(map[string]*fnmodels.Schema)
+	for _, api := range apis {
+		if _, ok := schemas[*api.Function]; ok {
+			continue
+		}
+		function, err := getFunctionModel(*api.Function)
+		if err != nil {
+			return err
+		}
+		schemas[*api.Function] = function.Schema
+	}
+	doc, err := openAPIFromAPIs(apis, schemas)
+	if err != nil {
+		return formatCliError(err, fmt.Sprintf("Error when generating OpenAPI spec: %v", err))
+	}
+
+	b, err := json.MarshalIndent(doc, "", "    ")
+	if err != nil {
+		return formatCliError(err, fmt.Sprintf("Error when encoding OpenAPI spec: %v", err))
+	}
+	if !dispatchConfig.JSON {
+		if b, err = yaml.JSONToYAML(b); err != nil {
+			return formatCliError(err, fmt.Sprintf("Error when encoding OpenAPI spec: %v", err))
+		}
+	}
+	_, err = fmt.Fprintln(out, string(b))
+	return err
+}
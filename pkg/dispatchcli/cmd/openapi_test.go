@@ -0,0 +1,215 @@
+///////////////////////////////////////////////////////////////////////
+// Copyright (c) 2018 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+///////////////////////////////////////////////////////////////////////
+
+package cmd
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	"github.com/go-openapi/spec"
+	"github.com/go-openapi/swag"
+	"github.com/stretchr/testify/assert"
+
+	apimodels "github.com/vmware/dispatch/pkg/api-manager/gen/models"
+	fnmodels "github.com/vmware/dispatch/pkg/function-manager/gen/models"
+)
+
+const testOpenAPISpec = `
+swagger: "2.0"
+info:
+  title: users
+  version: "1.0"
+host: api.example.com:443
+basePath: /v1
+schemes:
+- https
+securityDefinitions:
+  key:
+    type: apiKey
+    name: apikey
+    in: header
+paths:
+  /users:
+    get:
+      operationId: list-users
+      x-dispatch-function: list-users
+      parameters:
+      - name: limit
+        in: query
+        type: integer
+        required: true
+      responses:
+        200:
+          description: users
+          schema:
+            type: array
+            items:
+              $ref: '#/definitions/User'
+    post:
+      operationId: add-user
+      x-dispatch-function: add-user
+      security:
+      - key: []
+      parameters:
+      - name: user
+        in: body
+        schema:
+          $ref: '#/definitions/User'
+      responses:
+        201:
+          description: user
+          schema:
+            $ref: '#/definitions/User'
+  /users/{id}:
+    x-dispatch-function: get-user
+    parameters:
+    - name: id
+      in: path
+      type: string
+      required: true
+    get:
+      responses:
+        200:
+          description: user
+    delete:
+      operationId: not a function
+      responses:
+        204:
+          description: deleted
+  /health:
+    get:
+      responses:
+        200:
+          description: not a function
+definitions:
+  User:
+    type: object
+    properties:
+      name:
+        type: string
+`
+
+func TestAPIsFromOpenAPI(t *testing.T) {
+	dir, err := ioutil.TempDir("", "openapi")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "spec.yaml")
+	assert.Nil(t, ioutil.WriteFile(file, []byte(testOpenAPISpec), 0644))
+
+	doc, err := loadOpenAPI(file)
+	assert.Nil(t, err)
+	apis, schemas, err := apisFromOpenAPI(doc)
+	assert.Nil(t, err)
+	assert.Len(t, apis, 4)
+
+	byName := make(map[string]*apimodels.API)
+	for _, api := range apis {
+		byName[*api.Name] = api
+	}
+	list := byName["list-users"]
+	assert.Equal(t, "list-users", *list.Function)
+	assert.Equal(t, []string{"/v1/users"}, list.Uris)
+	assert.Equal(t, []string{"GET"}, list.Methods)
+	assert.Equal(t, []string{"api.example.com"}, list.Hosts)
+	assert.Equal(t, []string{"https"}, list.Protocols)
+	assert.Equal(t, "public", list.Authentication)
+	assert.Equal(t, "key", byName["add-user"].Authentication)
+
+	// operations without ids are named after their method and path
+	get := byName["get-v1-users-id"]
+	assert.Equal(t, "get-user", *get.Function)
+	assert.Equal(t, []string{"/v1/users/{id}"}, get.Uris)
+	assert.Equal(t, "get-user", *byName["delete-v1-users-id"].Function)
+
+	in := schemas["list-users"].In.(map[string]interface{})
+	assert.Equal(t, "object", in["type"])
+	assert.Equal(t, []interface{}{"limit"}, in["required"])
+	out := schemas["list-users"].Out.(map[string]interface{})
+	assert.Equal(t, "array", out["type"])
+	assert.Equal(t, "object", schemas["add-user"].In.(map[string]interface{})["type"])
+	assert.Equal(t, "object", schemas["add-user"].Out.(map[string]interface{})["type"])
+	assert.Nil(t, schemas["get-user"])
+}
+
+func sorted(values []string) []string {
+	result := append([]string{}, values...)
+	sort.Strings(result)
+	return result
+}
+
+func TestOpenAPIFromAPIs(t *testing.T) {
+	apis := []*apimodels.API{
+		{
+			Name:           swag.String("users"),
+			Function:       swag.String("users"),
+			Uris:           []string{"/users/{id}", "/people/{id}"},
+			Methods:        []string{"GET", "PUT"},
+			Hosts:          []string{"api.example.com"},
+			Protocols:      []string{"https"},
+			Authentication: "jwt",
+			Cors:           true,
+		},
+		{
+			Name:           swag.String("hello"),
+			Function:       swag.String("hello"),
+			Uris:           []string{"/hello"},
+			Methods:        []string{"GET"},
+			Protocols:      []string{"http", "https"},
+			Authentication: "public",
+		},
+	}
+	schemas := map[string]*fnmodels.Schema{
+		"hello": {
+			In: map[string]interface{}{
+				"type":     "object",
+				"required": []string{"name"},
+				"properties": map[string]interface{}{
+					"name": map[string]interface{}{"type": "string"},
+				},
+			},
+			Out: map[string]interface{}{"type": "string"},
+		},
+	}
+	doc, err := openAPIFromAPIs(apis, schemas)
+	assert.Nil(t, err)
+
+	hello := doc.Paths.Paths["/hello"].Get
+	assert.Equal(t, "hello", hello.ID)
+	assert.Len(t, hello.Parameters, 1)
+	assert.Equal(t, "query", hello.Parameters[0].In)
+	assert.True(t, hello.Parameters[0].Required)
+	assert.Equal(t, spec.StringOrArray{"string"}, hello.Responses.StatusCodeResponses[200].Schema.Type)
+
+	users := doc.Paths.Paths["/people/{id}"].Put
+	assert.Equal(t, "users-put-1", users.ID)
+	assert.Equal(t, "id", users.Parameters[0].Name)
+	assert.Equal(t, []map[string][]string{{"jwt": {}}}, users.Security)
+	assert.Equal(t, "apiKey", doc.SecurityDefinitions["jwt"].Type)
+
+	// the exported spec is imported back as the same APIs
+	b, err := json.Marshal(doc)
+	assert.Nil(t, err)
+	imported := new(spec.Swagger)
+	assert.Nil(t, json.Unmarshal(b, imported))
+	result, resultSchemas, err := apisFromOpenAPI(imported)
+	assert.Nil(t, err)
+	assert.Len(t, result, 2)
+	for i, api := range []*apimodels.API{apis[1], apis[0]} {
+		assert.Equal(t, *api.Name, *result[i].Name)
+		assert.Equal(t, *api.Function, *result[i].Function)
+		assert.Equal(t, sorted(api.Uris), sorted(result[i].Uris))
+		assert.Equal(t, sorted(api.Methods), sorted(result[i].Methods))
+		assert.Equal(t, api.Hosts, result[i].Hosts)
+		assert.Equal(t, api.Protocols, result[i].Protocols)
+		assert.Equal(t, api.Authentication, result[i].Authentication)
+		assert.Equal(t, api.Cors, result[i].Cors)
+	}
+	assert.Equal(t, "object", resultSchemas["hello"].In.(map[string]interface{})["type"])
+}
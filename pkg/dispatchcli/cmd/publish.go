@@ -0,0 +1,69 @@
+///////////////////////////////////////////////////////////////////////
+// Copyright (c) 2018 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+///////////////////////////////////////////////////////////////////////
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/spf13/cobra"
+	"golang.org/x/net/context"
+
+	"github.com/vmware/dispatch/pkg/dispatchcli/i18n"
+	fnstore "github.com/vmware/dispatch/pkg/function-manager/gen/client/store"
+	models "github.com/vmware/dispatch/pkg/function-manager/gen/models"
+)
+
+var (
+	publishLong = i18n.T(`Publish an immutable version of a function, numbered 1, 2, 3... Versions are executed with a
+qualified function name, e.g. hello:2, and aliases route runs to them, e.g. hello:prod.`)
+
+	publishExample = i18n.T(`
+		# Publish the current code of function "hello"
+		dispatch publish hello
+		# Execute the published version
+		dispatch exec hello:1`)
+)
+
+// NewCmdPublish creates command responsible for publishing function versions.
+func NewCmdPublish(out io.Writer, errOut io.Writer) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "publish FUNCTION_NAME",
+		Short:   i18n.T("Publish a version of a function"),
+		Long:    publishLong,
+		Example: publishExample,
+		Args:    cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			err := publishVersion(out, errOut, cmd, args)
+			CheckErr(err)
+		},
+	}
+	return cmd
+}
+
+func publishVersion(out, errOut io.Writer, cmd *cobra.Command, args []string) error {
+	client := functionManagerClient()
+	params := &fnstore.PublishVersionParams{
+		FunctionName: args[0],
+		Context:      context.Background(),
+	}
+	created, err := client.Store.PublishVersion(params, GetAuthInfoWriter())
+	if err != nil {
+		return formatAPIError(err, params)
+	}
+	return formatPublishOutput(out, created.Payload)
+}
+
+func formatPublishOutput(out io.Writer, version *models.FunctionVersion) error {
+	if dispatchConfig.JSON {
+		encoder := json.NewEncoder(out)
+		encoder.SetIndent("", "    ")
+		return encoder.Encode(version)
+	}
+	_, err := fmt.Fprintf(out, "Published function version: %s:%d\n", version.FunctionName, version.Version)
+	return err
+}
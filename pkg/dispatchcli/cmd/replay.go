@@ -0,0 +1,40 @@
+///////////////////////////////////////////////////////////////////////
+// Copyright (c) 2018 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+///////////////////////////////////////////////////////////////////////
+
+package cmd
+
+import (
+	"io"
+
+	"github.com/spf13/cobra"
+
+	"github.com/vmware/dispatch/pkg/dispatchcli/i18n"
+)
+
+var (
+	replayLong = i18n.T(`Replay recorded events, or events which were not delivered.`)
+
+	replayExample = i18n.T(`
+		# Replay a dead letter with name "a3d2e8b9-2f1c-4c4b-9f0e-0d6e1b1c6a59"
+		dispatch replay deadletter a3d2e8b9-2f1c-4c4b-9f0e-0d6e1b1c6a59
+		# Publish the recorded event with ID "c2a1fd4a-01a8-4dd8-ae6e-bf1bc8e0e5d9" again
+		dispatch replay event c2a1fd4a-01a8-4dd8-ae6e-bf1bc8e0e5d9`)
+)
+
+// NewCmdReplay creates a command object for the generic "replay" action.
+func NewCmdReplay(out io.Writer, errOut io.Writer) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "replay TYPE NAME",
+		Short:   i18n.T("Replay events"),
+		Long:    replayLong,
+		Example: replayExample,
+		Run: func(cmd *cobra.Command, args []string) {
+			runHelp(cmd, args)
+		},
+	}
+	cmd.AddCommand(NewCmdReplayDeadLetter(out, errOut))
+	cmd.AddCommand(NewCmdReplayEvent(out, errOut))
+	return cmd
+}
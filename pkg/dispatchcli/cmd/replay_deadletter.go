@@ -0,0 +1,73 @@
+///////////////////////////////////////////////////////////////////////
+// Copyright (c) 2018 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+///////////////////////////////////////////////////////////////////////
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/spf13/cobra"
+	"golang.org/x/net/context"
+
+	"github.com/vmware/dispatch/pkg/dispatchcli/cmd/utils"
+	"github.com/vmware/dispatch/pkg/dispatchcli/i18n"
+	"github.com/vmware/dispatch/pkg/event-manager/gen/client/deadletters"
+)
+
+var (
+	replayDeadLetterLong = i18n.T(`Replay a dead letter. The function of the subscription is run again with the event, and the dead letter is removed if the run succeeds.`)
+
+	// TODO: add examples
+	replayDeadLetterExample = i18n.T(``)
+)
+
+// NewCmdReplayDeadLetter creates command responsible for replaying dead letters.
+func NewCmdReplayDeadLetter(out io.Writer, errOut io.Writer) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "deadletter DEAD_LETTER_NAME [DEAD_LETTER_NAME...]",
+		Short:   i18n.T("Replay dead letter"),
+		Long:    replayDeadLetterLong,
+		Example: replayDeadLetterExample,
+		Args:    cobra.MinimumNArgs(1),
+		Aliases: []string{"deadletters"},
+		Run: func(cmd *cobra.Command, args []string) {
+			err := replayDeadLetter(out, errOut, cmd, args)
+			CheckErr(err)
+		},
+	}
+	cmd.Flags().StringVarP(&cmdFlagApplication, "application", "a", "", "filter by application")
+	return cmd
+}
+
+func replayDeadLetter(out, errOut io.Writer, cmd *cobra.Command, args []string) error {
+	client := eventManagerClient()
+	for _, name := range args {
+		params := &deadletters.ReplayDeadLetterParams{
+			Context:        context.Background(),
+			DeadLetterName: name,
+			Tags:           []string{},
+		}
+		utils.AppendApplication(&params.Tags, cmdFlagApplication)
+
+		resp, err := client.Deadletters.ReplayDeadLetter(params, GetAuthInfoWriter())
+		if err != nil {
+			return formatAPIError(err, params)
+		}
+		if dispatchConfig.JSON {
+			encoder := json.NewEncoder(out)
+			encoder.SetIndent("", "    ")
+			if err := encoder.Encode(resp.Payload); err != nil {
+				return err
+			}
+			continue
+		}
+		if _, err := fmt.Fprintf(out, "Replayed dead letter: %s\n", resp.Payload.Name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,73 @@
+///////////////////////////////////////////////////////////////////////
+// Copyright (c) 2018 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+///////////////////////////////////////////////////////////////////////
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/spf13/cobra"
+	"golang.org/x/net/context"
+
+	"github.com/vmware/dispatch/pkg/dispatchcli/cmd/utils"
+	"github.com/vmware/dispatch/pkg/dispatchcli/i18n"
+	"github.com/vmware/dispatch/pkg/event-manager/gen/client/events"
+)
+
+var (
+	replayEventLong = i18n.T(`Replay a recorded event. The event is published again to the topic it was recorded on, so all matching subscriptions receive it.`)
+
+	// TODO: add examples
+	replayEventExample = i18n.T(``)
+)
+
+// NewCmdReplayEvent creates command responsible for replaying recorded events.
+func NewCmdReplayEvent(out io.Writer, errOut io.Writer) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "event EVENT_ID [EVENT_ID...]",
+		Short:   i18n.T("Replay event"),
+		Long:    replayEventLong,
+		Example: replayEventExample,
+		Args:    cobra.MinimumNArgs(1),
+		Aliases: []string{"events"},
+		Run: func(cmd *cobra.Command, args []string) {
+			err := replayEvent(out, errOut, cmd, args)
+			CheckErr(err)
+		},
+	}
+	cmd.Flags().StringVarP(&cmdFlagApplication, "application", "a", "", "filter by application")
+	return cmd
+}
+
+func replayEvent(out, errOut io.Writer, cmd *cobra.Command, args []string) error {
+	client := eventManagerClient()
+	for _, name := range args {
+		params := &events.ReplayEventParams{
+			Context:   context.Background(),
+			EventName: name,
+			Tags:      []string{},
+		}
+		utils.AppendApplication(&params.Tags, cmdFlagApplication)
+
+		resp, err := client.Events.ReplayEvent(params, GetAuthInfoWriter())
+		if err != nil {
+			return formatAPIError(err, params)
+		}
+		if dispatchConfig.JSON {
+			encoder := json.NewEncoder(out)
+			encoder.SetIndent("", "    ")
+			if err := encoder.Encode(resp.Payload); err != nil {
+				return err
+			}
+			continue
+		}
+		if _, err := fmt.Fprintf(out, "Replayed event: %s\n", resp.Payload.Name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,67 @@
+///////////////////////////////////////////////////////////////////////
+// Copyright (c) 2018 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+///////////////////////////////////////////////////////////////////////
+
+package cmd
+
+import (
+	"io"
+
+	"github.com/spf13/cobra"
+	"golang.org/x/net/context"
+
+	"github.com/vmware/dispatch/pkg/dispatchcli/i18n"
+	fnstore "github.com/vmware/dispatch/pkg/function-manager/gen/client/store"
+)
+
+var (
+	rollbackLong = i18n.T(`Move an alias of a function back to the versions it routed to before it was last updated.`)
+
+	rollbackExample = i18n.T(`
+		# Undo the last update of alias "prod" of function "hello"
+		dispatch rollback hello prod`)
+)
+
+// NewCmdRollback creates command responsible for rolling function aliases back.
+func NewCmdRollback(out io.Writer, errOut io.Writer) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "rollback FUNCTION_NAME ALIAS_NAME",
+		Short:   i18n.T("Roll an alias of a function back"),
+		Long:    rollbackLong,
+		Example: rollbackExample,
+		Args:    cobra.ExactArgs(2),
+		Run: func(cmd *cobra.Command, args []string) {
+			err := rollbackAlias(out, errOut, cmd, args)
+			CheckErr(err)
+		},
+	}
+	return cmd
+}
+
+func rollbackAlias(out, errOut io.Writer, cmd *cobra.Command, args []string) error {
+	client := functionManagerClient()
+	params := &fnstore.GetFunctionParams{
+		FunctionName: args[0],
+		Context:      context.Background(),
+		Tags:         []string{},
+	}
+	get, err := client.Store.GetFunction(params, GetAuthInfoWriter())
+	if err != nil {
+		return formatAPIError(err, params)
+	}
+	for _, a := range get.Payload.Aliases {
+		if a.Name != args[1] {
+			continue
+		}
+		if len(a.PreviousRoutes) == 0 {
+			return i18n.Errorf("Alias %s of function %s has no previous versions", args[1], args[0])
+		}
+		function, err := CallSetAlias(args[0], args[1], a.PreviousRoutes)
+		if err != nil {
+			return err
+		}
+		return formatAliasOutput(out, "Rolled back", function, args[1])
+	}
+	return i18n.Errorf("Alias %s of function %s not found", args[1], args[0])
+}
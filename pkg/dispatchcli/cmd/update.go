@@ -38,8 +38,10 @@ func NewCmdUpdate(out io.Writer, errOut io.Writer) *cobra.Command {
 				utils.ApplicationKind: CallUpdateApplication,
 				utils.BaseImageKind:   CallUpdateBaseImage,
 				utils.ImageKind:       CallUpdateImage,
+				utils.FunctionKind:    CallUpdateFunction,
 				utils.SecretKind:      CallUpdateSecret,
 				utils.PolicyKind:      CallUpdatePolicy,
+				utils.DriverKind:      CallUpdateEventDriver,
 			}
 
 			err := importFile(out, errOut, cmd, args, updateMap)
@@ -54,6 +56,8 @@ func NewCmdUpdate(out io.Writer, errOut io.Writer) *cobra.Command {
 	cmd.AddCommand(NewCmdUpdateApplication(out, errOut))
 	cmd.AddCommand(NewCmdUpdateBaseImage(out, errOut))
 	cmd.AddCommand(NewCmdUpdateImage(out, errOut))
+	cmd.AddCommand(NewCmdUpdateAlias(out, errOut))
 	cmd.AddCommand(NewCmdUpdatePolicy(out, errOut))
+	cmd.AddCommand(NewCmdUpdateEventDriver(out, errOut))
 	return cmd
 }
@@ -0,0 +1,131 @@
+///////////////////////////////////////////////////////////////////////
+// Copyright (c) 2018 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+///////////////////////////////////////////////////////////////////////
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/go-openapi/swag"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"golang.org/x/net/context"
+
+	"github.com/vmware/dispatch/pkg/dispatchcli/i18n"
+	fnstore "github.com/vmware/dispatch/pkg/function-manager/gen/client/store"
+	models "github.com/vmware/dispatch/pkg/function-manager/gen/models"
+)
+
+const defaultRouteWeight = 100
+
+var (
+	updateAliasLong = i18n.T(`Create or move an alias of a function. The runs of the alias are split between its versions,
+in proportion to their weights. The previous versions of the alias are kept for a rollback.`)
+
+	updateAliasExample = i18n.T(`
+		# Route the runs of hello:prod to version 2 of function "hello"
+		dispatch update alias hello prod --version 2
+		# Route 10 percent of the runs of hello:prod to version 3
+		dispatch update alias hello prod --version 2=90 --version 3=10`)
+)
+
+var aliasVersions []string
+
+// NewCmdUpdateAlias creates command responsible for setting function aliases.
+func NewCmdUpdateAlias(out io.Writer, errOut io.Writer) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "alias FUNCTION_NAME ALIAS_NAME --version VERSION[=WEIGHT]",
+		Short:   i18n.T("Create or move an alias of a function"),
+		Long:    updateAliasLong,
+		Example: updateAliasExample,
+		Args:    cobra.ExactArgs(2),
+		Aliases: []string{"aliases"},
+		Run: func(cmd *cobra.Command, args []string) {
+			err := updateAlias(out, errOut, cmd, args)
+			CheckErr(err)
+		},
+	}
+	cmd.Flags().StringArrayVar(&aliasVersions, "version", []string{}, fmt.Sprintf("version of the function and weight of its runs, default weight: %d (multi-values)", defaultRouteWeight))
+	return cmd
+}
+
+// parseRoutes parses the routes of an alias, e.g. 2=90
+func parseRoutes(values []string) ([]*models.Route, error) {
+	if len(values) == 0 {
+		return nil, errors.New("at least one version is required")
+	}
+	var routes []*models.Route
+	for _, value := range values {
+		parts := strings.SplitN(value, "=", 2)
+		version, err := strconv.ParseInt(parts[0], 10, 64)
+		if err != nil {
+			return nil, errors.Errorf("invalid version %s", value)
+		}
+		weight := int64(defaultRouteWeight)
+		if len(parts) == 2 {
+			if weight, err = strconv.ParseInt(parts[1], 10, 64); err != nil {
+				return nil, errors.Errorf("invalid weight %s", value)
+			}
+		}
+		routes = append(routes, &models.Route{Version: swag.Int64(version), Weight: swag.Int64(weight)})
+	}
+	return routes, nil
+}
+
+// CallSetAlias makes the API call to set the routes of an alias
+func CallSetAlias(functionName, aliasName string, routes []*models.Route) (*models.Function, error) {
+	client := functionManagerClient()
+	params := &fnstore.SetAliasParams{
+		FunctionName: functionName,
+		AliasName:    aliasName,
+		Body:         &models.Alias{Routes: routes},
+		Context:      context.Background(),
+	}
+	updated, err := client.Store.SetAlias(params, GetAuthInfoWriter())
+	if err != nil {
+		return nil, formatAPIError(err, params)
+	}
+	return updated.Payload, nil
+}
+
+func updateAlias(out, errOut io.Writer, cmd *cobra.Command, args []string) error {
+	routes, err := parseRoutes(aliasVersions)
+	if err != nil {
+		return formatCliError(err, fmt.Sprintf("Failed parsing version values: %v", err))
+	}
+	function, err := CallSetAlias(args[0], args[1], routes)
+	if err != nil {
+		return err
+	}
+	return formatAliasOutput(out, "Updated", function, args[1])
+}
+
+func formatRoutes(routes []*models.Route) string {
+	var result []string
+	for _, r := range routes {
+		result = append(result, fmt.Sprintf("%d=%d", *r.Version, *r.Weight))
+	}
+	return strings.Join(result, ", ")
+}
+
+func formatAliasOutput(out io.Writer, action string, function *models.Function, aliasName string) error {
+	if dispatchConfig.JSON {
+		encoder := json.NewEncoder(out)
+		encoder.SetIndent("", "    ")
+		return encoder.Encode(function)
+	}
+	for _, a := range function.Aliases {
+		if a.Name == aliasName {
+			_, err := fmt.Fprintf(out, "%s alias: %s:%s (%s)\n", action, *function.Name, aliasName, formatRoutes(a.Routes))
+			return err
+		}
+	}
+	_, err := fmt.Fprintf(out, "%s alias: %s:%s\n", action, *function.Name, aliasName)
+	return err
+}
@@ -0,0 +1,30 @@
+///////////////////////////////////////////////////////////////////////
+// Copyright (c) 2018 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+///////////////////////////////////////////////////////////////////////
+
+package cmd
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseRoutes(t *testing.T) {
+	routes, err := parseRoutes([]string{"2", "3=10"})
+	assert.Nil(t, err)
+	assert.Len(t, routes, 2)
+	assert.EqualValues(t, 2, *routes[0].Version)
+	assert.EqualValues(t, defaultRouteWeight, *routes[0].Weight)
+	assert.EqualValues(t, 3, *routes[1].Version)
+	assert.EqualValues(t, 10, *routes[1].Weight)
+	assert.Equal(t, "2=100, 3=10", formatRoutes(routes))
+
+	_, err = parseRoutes(nil)
+	assert.Error(t, err)
+	_, err = parseRoutes([]string{"prod"})
+	assert.Error(t, err)
+	_, err = parseRoutes([]string{"2=all"})
+	assert.Error(t, err)
+}
@@ -44,7 +44,7 @@ func CallUpdateAPI(input interface{}) error {
 // NewCmdUpdateAPI creates command responsible for updating an api
 func NewCmdUpdateAPI(out io.Writer, errOut io.Writer) *cobra.Command {
 	cmd := &cobra.Command{
-		Use:     "api API_NAME [--function FUNCTION_NAME] [--auth AUTH_METHOD] [--domain DOMAINNAME...] [--method METHOD...] [--path PATH...] [--disable] [--cors] [--https-only]",
+		Use:     "api API_NAME [--function FUNCTION_NAME] [--auth AUTH_METHOD] [--domain DOMAINNAME...] [--method METHOD...] [--path PATH...] [--disable] [--cors] [--https-only] [--tls-secret SECRET_NAME]",
 		Short:   i18n.T("Update api"),
 		Long:    updateAPILong,
 		Example: updateAPIExample,
@@ -58,12 +58,14 @@ func NewCmdUpdateAPI(out io.Writer, errOut io.Writer) *cobra.Command {
 	cmd.Flags().StringVar(&functionName, "function", "", "associate api with a function")
 	cmd.Flags().StringVarP(&cmdFlagApplication, "application", "a", "", "associate with an application")
 	cmd.Flags().StringArrayVarP(&hosts, "domain", "d", []string{}, "domain names that point to your API (multi-values)")
-	cmd.Flags().StringArrayVarP(&paths, "path", "p", []string{"/"}, "paths that point to your API (multi-values)")
+	cmd.Flags().StringArrayVarP(&paths, "path", "p", []string{"/"}, "paths that point to your API (multi-values), with parameters in braces, e.g. /users/{id}")
 	cmd.Flags().StringArrayVarP(&methods, "method", "m", []string{"GET"}, "methods that point to your API")
 	cmd.Flags().StringVar(&httpsOnlyStr, "https-only", "false", "only support https connections")
 	cmd.Flags().StringVar(&disableStr, "disable", "false", "disable the api")
 	cmd.Flags().StringVar(&corsStr, "cors", "false", "enable CORS")
-	cmd.Flags().StringVar(&auth, "auth", "public", "specify end-user authentication method, (e.g. public, basic, oauth2)")
+	cmd.Flags().StringVar(&auth, "auth", "public", "specify end-user authentication method (public, basic, key, jwt or oauth2)")
+	cmd.Flags().StringVar(&tlsSecret, "tls-secret", "", "secret holding the tls certificate (tls.crt) and key (tls.key) of the domains, empty to remove it")
+	cmd.Flags().StringArrayVar(&rateLimits, "rate-limit", []string{}, "maximum requests of each client per period (second, minute, hour, day or month), e.g. 10/second (multi-values), empty to remove the limits")
 	return cmd
 }
 
@@ -149,6 +151,19 @@ func updateAPI(out io.Writer, errOut io.Writer, cmd *cobra.Command, args []strin
 		changed = true
 	}
 
+	if cmd.Flags().Changed("tls-secret") {
+		api.TLS = tlsSecret
+		changed = true
+	}
+
+	if cmd.Flags().Changed("rate-limit") {
+		api.RateLimit, err = parseRateLimit(rateLimits)
+		if err != nil {
+			return formatCliError(err, fmt.Sprintf("Failed parsing rate-limit values: %v", err))
+		}
+		changed = true
+	}
+
 	if !changed {
 		fmt.Fprintf(out, "No fields changed\n")
 		return nil
@@ -0,0 +1,124 @@
+///////////////////////////////////////////////////////////////////////
+// Copyright (c) 2018 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+///////////////////////////////////////////////////////////////////////
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"golang.org/x/net/context"
+
+	"github.com/vmware/dispatch/pkg/dispatchcli/cmd/utils"
+	"github.com/vmware/dispatch/pkg/dispatchcli/i18n"
+	"github.com/vmware/dispatch/pkg/event-manager/gen/client/drivers"
+	models "github.com/vmware/dispatch/pkg/event-manager/gen/models"
+)
+
+var (
+	updateEventDriverLong = i18n.T(`Update config and secrets of a dispatch event driver.
+The driver is redeployed without downtime, and always reads its secrets again. After rotating a password
+stored in a secret, run the command without flags to pick up the new value.`)
+
+	updateEventDriverExample = i18n.T(`
+		# Change the vCenter URL of driver "my-vcenter"
+		dispatch update eventdriver my-vcenter --set vcenterurl=admin:password@vcenter.corp.local:443
+		# Reload the secrets of driver "my-vcenter"
+		dispatch update eventdriver my-vcenter`)
+
+	updateEventDriverConfig  []string
+	updateEventDriverUnset   []string
+	updateEventDriverSecrets []string
+)
+
+// CallUpdateEventDriver makes the API call to update an event driver
+func CallUpdateEventDriver(input interface{}) error {
+	driverBody := input.(*models.Driver)
+
+	params := &drivers.UpdateDriverParams{
+		Body:       driverBody,
+		DriverName: *driverBody.Name,
+		Context:    context.Background(),
+		Tags:       []string{},
+	}
+	utils.AppendApplication(&params.Tags, cmdFlagApplication)
+
+	_, err := eventManagerClient().Drivers.UpdateDriver(params, GetAuthInfoWriter())
+	if err != nil {
+		return formatAPIError(err, params)
+	}
+	return nil
+}
+
+// NewCmdUpdateEventDriver creates command responsible for updating an event driver
+func NewCmdUpdateEventDriver(out io.Writer, errOut io.Writer) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "eventdriver DRIVER_NAME [--set KEY=VALUE] [--unset KEY] [--secret SECRET_NAME]",
+		Short:   i18n.T("Update event driver"),
+		Long:    updateEventDriverLong,
+		Example: updateEventDriverExample,
+		Args:    cobra.ExactArgs(1),
+		Aliases: []string{"eventdrivers", "event-driver", "event-drivers"},
+		Run: func(cmd *cobra.Command, args []string) {
+			err := updateEventDriver(out, errOut, cmd, args)
+			CheckErr(err)
+		},
+	}
+	cmd.Flags().StringVarP(&cmdFlagApplication, "application", "a", "", "filter by application")
+	cmd.Flags().StringArrayVarP(&updateEventDriverConfig, "set", "s", []string{}, "set event driver configurations, other configurations are kept")
+	cmd.Flags().StringArrayVar(&updateEventDriverUnset, "unset", []string{}, "remove event driver configurations")
+	cmd.Flags().StringArrayVar(&updateEventDriverSecrets, "secret", []string{}, "Configuration passed via secrets, replaces the current secrets, can be specified multiple times or a comma-delimited string")
+	return cmd
+}
+
+func updateEventDriver(out, errOut io.Writer, cmd *cobra.Command, args []string) error {
+	getParams := &drivers.GetDriverParams{
+		DriverName: args[0],
+		Context:    context.Background(),
+		Tags:       []string{},
+	}
+	utils.AppendApplication(&getParams.Tags, cmdFlagApplication)
+	resp, err := eventManagerClient().Drivers.GetDriver(getParams, GetAuthInfoWriter())
+	if err != nil {
+		return formatAPIError(err, getParams)
+	}
+	driver := resp.Payload
+
+	config := make(map[string]string)
+	for _, c := range driver.Config {
+		config[c.Key] = c.Value
+	}
+	for _, conf := range updateEventDriverConfig {
+		result := strings.SplitN(conf, "=", 2)
+		if len(result) != 2 {
+			return formatCliError(nil, fmt.Sprintf("Invalid configuration format %s, should be --set key=value", conf))
+		}
+		config[result[0]] = result[1]
+	}
+	for _, key := range updateEventDriverUnset {
+		delete(config, key)
+	}
+	driver.Config = nil
+	for key, value := range config {
+		driver.Config = append(driver.Config, &models.Config{Key: key, Value: value})
+	}
+	if cmd.Flags().Changed("secret") {
+		driver.Secrets = updateEventDriverSecrets
+	}
+
+	if err := CallUpdateEventDriver(driver); err != nil {
+		return err
+	}
+	if dispatchConfig.JSON {
+		encoder := json.NewEncoder(out)
+		encoder.SetIndent("", "    ")
+		return encoder.Encode(*driver)
+	}
+	fmt.Fprintf(out, "Updated event driver: %s\n", *driver.Name)
+	return nil
+}
@@ -0,0 +1,31 @@
+///////////////////////////////////////////////////////////////////////
+// Copyright (c) 2018 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+///////////////////////////////////////////////////////////////////////
+
+package cmd
+
+import (
+	"golang.org/x/net/context"
+
+	fnstore "github.com/vmware/dispatch/pkg/function-manager/gen/client/store"
+	"github.com/vmware/dispatch/pkg/function-manager/gen/models"
+)
+
+// CallUpdateFunction makes the API call to update a function
+func CallUpdateFunction(input interface{}) error {
+	function := input.(*models.Function)
+	params := &fnstore.UpdateFunctionParams{
+		Body:         function,
+		FunctionName: *function.Name,
+		Context:      context.Background(),
+		Tags:         []string{},
+	}
+
+	updated, err := functionManagerClient().Store.UpdateFunction(params, GetAuthInfoWriter())
+	if err != nil {
+		return formatAPIError(err, params)
+	}
+	*function = *updated.Payload
+	return nil
+}
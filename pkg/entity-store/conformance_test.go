@@ -0,0 +1,374 @@
+///////////////////////////////////////////////////////////////////////
+// Copyright (c) 2018 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+///////////////////////////////////////////////////////////////////////
+
+package entitystore
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/vmware/dispatch/pkg/testing/dev"
+	"github.com/vmware/dispatch/pkg/testing/kv"
+)
+
+// testConformance is the test suite every EntityStore implementation runs against
+func testConformance(t *testing.T, es EntityStore) {
+	tests := []struct {
+		name string
+		test func(*testing.T, EntityStore)
+	}{
+		{"Get", testGet},
+		{"Add", testAdd},
+		{"Put", testPut},
+		{"List", testList},
+		{"ListWithFilter", testListWithFilter},
+		{"ListWithFilterOnTags", testListWithFilterOnTags},
+		{"Delete", testDelete},
+		{"InvalidNames", testInvalidNames},
+		{"MixedTypes", testMixedTypes},
+		{"AddExisting", testAddExisting},
+		{"ListEmpty", testListEmpty},
+		{"ConcurrentUpdates", testConcurrentUpdates},
+		{"Watch", testWatch},
+		{"ListPaged", testListPaged},
+		{"ListWithFilterVerbs", testListWithFilterVerbs},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			test.test(t, es)
+		})
+	}
+}
+
+func testAddExisting(t *testing.T, es EntityStore) {
+	e := &testEntity{
+		BaseEntity: BaseEntity{
+			OrganizationID: "testOrg",
+			Name:           "testEntityAddExisting",
+		},
+		Value: "testValue",
+	}
+	_, err := es.Add(e)
+	require.NoError(t, err, "Error adding entity")
+	defer es.Delete("testOrg", e.Name, e)
+
+	_, err = es.Add(&testEntity{BaseEntity: BaseEntity{OrganizationID: "testOrg", Name: e.Name}})
+	assert.True(t, IsUniqueViolation(err), "expected a unique violation, got %v", err)
+}
+
+func testListEmpty(t *testing.T, es EntityStore) {
+	var entities []*testEntity
+	assert.NoError(t, es.List("emptyOrg", Options{}, &entities))
+	assert.NotNil(t, entities)
+	assert.Len(t, entities, 0)
+}
+
+// testConcurrentUpdates checks that updates are compare-and-swap operations on the revision: of concurrent updates
+// from the same revision, exactly one succeeds
+func testConcurrentUpdates(t *testing.T, es EntityStore) {
+	e := &testEntity{
+		BaseEntity: BaseEntity{
+			OrganizationID: "testOrg",
+			Name:           "testEntityConcurrentUpdates",
+		},
+		Value: "testValue",
+	}
+	_, err := es.Add(e)
+	require.NoError(t, err, "Error adding entity")
+	defer es.Delete("testOrg", e.Name, e)
+
+	var original testEntity
+	require.NoError(t, es.Get("testOrg", e.Name, Options{}, &original))
+
+	const writers = 5
+	var wg sync.WaitGroup
+	errs := make(chan error, writers)
+	for i := 0; i < writers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			update := original
+			update.Value = fmt.Sprintf("value%d", i)
+			_, err := es.Update(original.Revision, &update)
+			errs <- err
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+
+	succeeded := 0
+	for err := range errs {
+		if err == nil {
+			succeeded++
+		}
+	}
+	assert.Equal(t, 1, succeeded)
+
+	var updated testEntity
+	require.NoError(t, es.Get("testOrg", e.Name, Options{}, &updated))
+	assert.True(t, strings.HasPrefix(updated.Value, "value"))
+	assert.NotEqual(t, original.Revision, updated.Revision)
+
+	// a stale revision is rejected
+	stale := original
+	stale.Value = "staleValue"
+	_, err = es.Update(original.Revision, &stale)
+	assert.Error(t, err)
+
+	// the latest revision is accepted
+	updated.Value = "lastValue"
+	_, err = es.Update(updated.Revision, &updated)
+	assert.NoError(t, err)
+}
+
+func nextEvent(t *testing.T, events <-chan Event) Event {
+	select {
+	case event, ok := <-events:
+		require.True(t, ok, "watch ended")
+		return event
+	case <-time.After(5 * time.Second):
+		require.FailNow(t, "no event received")
+	}
+	return Event{}
+}
+
+func testWatch(t *testing.T, es EntityStore) {
+	stop := make(chan struct{})
+	defer close(stop)
+
+	filter := FilterEverything().Add(FilterStat{
+		Scope:   FilterScopeTag,
+		Subject: "role",
+		Verb:    FilterVerbEqual,
+		Object:  "watched",
+	})
+	events, err := es.Watch("testOrg", reflect.TypeOf(&testEntity{}), filter, stop)
+	if err == ErrWatchNotSupported {
+		t.Skip(err.Error())
+	}
+	require.NoError(t, err)
+
+	// neither the entities of other types, nor the entities which do not satisfy the filter are watched
+	other := &otherEntity{BaseEntity: BaseEntity{OrganizationID: "testOrg", Name: "otherEntityWatch"}}
+	_, err = es.Add(other)
+	require.NoError(t, err)
+	defer es.Delete("testOrg", other.Name, other)
+	unwatched := &testEntity{BaseEntity: BaseEntity{OrganizationID: "testOrg", Name: "testEntityUnwatched"}}
+	_, err = es.Add(unwatched)
+	require.NoError(t, err)
+	defer es.Delete("testOrg", unwatched.Name, unwatched)
+
+	e := &testEntity{
+		BaseEntity: BaseEntity{
+			OrganizationID: "testOrg",
+			Name:           "testEntityWatch",
+			Tags:           Tags{"role": "watched"},
+		},
+		Value: "testValue",
+	}
+	_, err = es.Add(e)
+	require.NoError(t, err)
+	event := nextEvent(t, events)
+	assert.Equal(t, EventAdded, event.Type)
+	assert.Equal(t, e.Name, event.Entity.GetName())
+	assert.Equal(t, "testValue", event.Entity.(*testEntity).Value)
+
+	var retrieved testEntity
+	require.NoError(t, es.Get("testOrg", e.Name, Options{}, &retrieved))
+	retrieved.Value = "updatedValue"
+	_, err = es.Update(retrieved.Revision, &retrieved)
+	require.NoError(t, err)
+	event = nextEvent(t, events)
+	assert.Equal(t, EventUpdated, event.Type)
+	assert.Equal(t, "updatedValue", event.Entity.(*testEntity).Value)
+	assert.Equal(t, retrieved.Revision, event.Entity.GetRevision())
+
+	require.NoError(t, es.Delete("testOrg", e.Name, e))
+	event = nextEvent(t, events)
+	assert.Equal(t, EventDeleted, event.Type)
+	assert.Equal(t, e.Name, event.Entity.GetName())
+}
+
+func names(entities []*testEntity) []string {
+	var names []string
+	for _, e := range entities {
+		names = append(names, e.Name)
+	}
+	return names
+}
+
+func addTestEntities(t *testing.T, es EntityStore, organizationID string, entities ...*testEntity) {
+	for _, e := range entities {
+		e.OrganizationID = organizationID
+		_, err := es.Add(e)
+		require.NoError(t, err, "Error adding entity")
+	}
+}
+
+func deleteTestEntities(es EntityStore, entities ...*testEntity) {
+	for _, e := range entities {
+		es.Delete(e.OrganizationID, e.Name, e)
+	}
+}
+
+func testListPaged(t *testing.T, es EntityStore) {
+	entities := []*testEntity{
+		{BaseEntity: BaseEntity{Name: "paged-a", Tags: Tags{"rank": "2"}}, Value: "y"},
+		{BaseEntity: BaseEntity{Name: "paged-b", Tags: Tags{"rank": "1"}}, Value: "x"},
+		{BaseEntity: BaseEntity{Name: "paged-c", Tags: Tags{"rank": "3"}}, Value: "y"},
+		{BaseEntity: BaseEntity{Name: "paged-d"}, Value: "z"},
+		{BaseEntity: BaseEntity{Name: "paged-e", Tags: Tags{"rank": "2"}}, Value: "x"},
+	}
+	addTestEntities(t, es, "pagedOrg", entities...)
+	defer deleteTestEntities(es, entities...)
+
+	list := func(opts Options) []string {
+		var result []*testEntity
+		require.NoError(t, es.List("pagedOrg", opts, &result))
+		return names(result)
+	}
+	listAll := func(opts Options) [][]string {
+		var pages [][]string
+		for {
+			var result []*testEntity
+			require.NoError(t, es.List("pagedOrg", opts, &result))
+			if len(result) == 0 {
+				// a full page may be followed by an empty one
+				return pages
+			}
+			pages = append(pages, names(result))
+			token, err := ContinueToken(opts, result)
+			require.NoError(t, err)
+			if token == "" {
+				return pages
+			}
+			opts.Continue = token
+		}
+	}
+
+	// by name by default
+	assert.Equal(t, []string{"paged-a", "paged-b", "paged-c", "paged-d", "paged-e"}, list(Options{}))
+	assert.Equal(t, [][]string{{"paged-a", "paged-b"}, {"paged-c", "paged-d"}, {"paged-e"}}, listAll(Options{Limit: 2}))
+
+	sortBy, err := ParseSortKeys([]string{"-value"})
+	require.NoError(t, err)
+	assert.Equal(t, []SortKey{{Scope: FilterScopeExtra, Subject: "Value", Descending: true}}, sortBy)
+	assert.Equal(t, []string{"paged-d", "paged-a", "paged-c", "paged-b", "paged-e"}, list(Options{SortBy: sortBy}))
+	assert.Equal(t, [][]string{{"paged-d", "paged-a"}, {"paged-c", "paged-b"}, {"paged-e"}},
+		listAll(Options{SortBy: sortBy, Limit: 2}))
+
+	// a missing tag sorts first
+	sortBy, err = ParseSortKeys([]string{"tags.rank", "-name"})
+	require.NoError(t, err)
+	assert.Equal(t, [][]string{{"paged-d", "paged-b", "paged-e"}, {"paged-a", "paged-c"}},
+		listAll(Options{SortBy: sortBy, Limit: 3}))
+
+	// the page follows the filter
+	filter := FilterEverything().Add(FilterStat{Scope: FilterScopeExtra, Subject: "Value", Verb: FilterVerbEqual, Object: "y"})
+	assert.Equal(t, [][]string{{"paged-a"}, {"paged-c"}}, listAll(Options{Filter: filter, Limit: 1}))
+
+	var result []*testEntity
+	assert.Error(t, es.List("pagedOrg", Options{Continue: "invalid"}, &result))
+}
+
+func testListWithFilterVerbs(t *testing.T, es EntityStore) {
+	entities := []*testEntity{
+		{BaseEntity: BaseEntity{Name: "verbs-a", Status: StatusREADY, Tags: Tags{"app": "shop"}}, Value: "shop-cart"},
+		{BaseEntity: BaseEntity{Name: "verbs-b", Status: StatusERROR, Tags: Tags{"app": ""}}, Value: "shop-order"},
+		{BaseEntity: BaseEntity{Name: "verbs-c", Status: StatusREADY}, Value: "blog_post"},
+		{BaseEntity: BaseEntity{Name: "other-d", Status: StatusCREATING}},
+	}
+	addTestEntities(t, es, "verbsOrg", entities...)
+	defer deleteTestEntities(es, entities...)
+
+	list := func(filter Filter) []string {
+		var result []*testEntity
+		require.NoError(t, es.List("verbsOrg", Options{Filter: filter}, &result))
+		return names(result)
+	}
+
+	assert.Equal(t, []string{"other-d", "verbs-b"}, list(FilterEverything().Add(
+		FilterStat{Scope: FilterScopeField, Subject: "Status", Verb: FilterVerbNotEqual, Object: StatusREADY})))
+	assert.Equal(t, []string{"other-d", "verbs-c"}, list(FilterEverything().Add(
+		FilterStat{Scope: FilterScopeTag, Subject: "app", Verb: FilterVerbNotEqual, Object: "shop"},
+		FilterStat{Scope: FilterScopeTag, Subject: "app", Verb: FilterVerbExists, Object: false})))
+	assert.Equal(t, []string{"verbs-a", "verbs-b"}, list(FilterEverything().Add(
+		FilterStat{Scope: FilterScopeTag, Subject: "app", Verb: FilterVerbExists, Object: true})))
+	assert.Equal(t, []string{"verbs-a", "verbs-b", "verbs-c"}, list(FilterEverything().Add(
+		FilterStat{Scope: FilterScopeExtra, Subject: "Value", Verb: FilterVerbExists, Object: true})))
+	assert.Equal(t, []string{"verbs-a", "verbs-b", "verbs-c"}, list(FilterEverything().Add(
+		FilterStat{Scope: FilterScopeField, Subject: "Name", Verb: FilterVerbPrefix, Object: "verbs-"})))
+	// the wildcards of SQL are matched as such
+	assert.Equal(t, []string{"verbs-c"}, list(FilterEverything().Add(
+		FilterStat{Scope: FilterScopeExtra, Subject: "Value", Verb: FilterVerbPrefix, Object: "blog_"})))
+	assert.Empty(t, list(FilterEverything().Add(
+		FilterStat{Scope: FilterScopeExtra, Subject: "Value", Verb: FilterVerbPrefix, Object: "blog%"})))
+
+	// (status = ERROR or value starts with blog) and name starts with verbs
+	assert.Equal(t, []string{"verbs-b", "verbs-c"}, list(FilterEverything().Add(
+		FilterStat{Scope: FilterScopeField, Subject: "Name", Verb: FilterVerbPrefix, Object: "verbs"},
+	).Or(
+		FilterEverything().Add(FilterStat{Scope: FilterScopeField, Subject: "Status", Verb: FilterVerbEqual, Object: StatusERROR}),
+		FilterEverything().Add(FilterStat{Scope: FilterScopeExtra, Subject: "Value", Verb: FilterVerbPrefix, Object: "blog"}),
+	)))
+}
+
+func TestEtcdEntityStore(t *testing.T) {
+	server := kv.NewEtcdServer()
+	defer server.Close()
+
+	es, err := NewFromBackend(BackendConfig{
+		Backend: "etcd",
+		Address: server.URL,
+		Bucket:  "test",
+	})
+	require.NoError(t, err, "Cannot create store")
+	testConformance(t, es)
+}
+
+func TestConsulEntityStore(t *testing.T) {
+	server := kv.NewConsulServer()
+	defer server.Close()
+
+	es, err := NewFromBackend(BackendConfig{
+		Backend: "consul",
+		Address: server.URL,
+		Bucket:  "test",
+	})
+	require.NoError(t, err, "Cannot create store")
+	testConformance(t, es)
+}
+
+func TestEtcdClusterEntityStore(t *testing.T) {
+	dev.EnsureLocal(t)
+
+	// the first endpoint is down, the client fails over to the next one
+	es, err := NewFromBackend(BackendConfig{
+		Backend: "etcd",
+		Address: "127.0.0.1:1,127.0.0.1:2379",
+		Bucket:  "dispatch-test",
+	})
+	require.NoError(t, err, "Cannot connect to etcd")
+	testConformance(t, es)
+}
+
+func TestConsulAgentEntityStore(t *testing.T) {
+	dev.EnsureLocal(t)
+
+	es, err := NewFromBackend(BackendConfig{
+		Backend: "consul",
+		Address: "127.0.0.1:8500",
+		Bucket:  "dispatch-test",
+	})
+	require.NoError(t, err, "Cannot connect to consul")
+	testConformance(t, es)
+}
@@ -0,0 +1,414 @@
+///////////////////////////////////////////////////////////////////////
+// Copyright (c) 2018 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+///////////////////////////////////////////////////////////////////////
+
+// Package consul is a libkv store backed by the key/value store of Consul, through its HTTP API
+package consul
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/docker/libkv"
+	"github.com/docker/libkv/store"
+	"github.com/pkg/errors"
+)
+
+// blockingWait is the maximum duration of the blocking queries of watches
+const blockingWait = "5m"
+
+// Consul is a libkv store backed by Consul. The revision of a key (LastIndex) is its Consul modify index, atomic
+// operations are check-and-set transactions on it.
+type Consul struct {
+	endpoints []string
+	prefix    string
+	token     string
+	client    *http.Client
+}
+
+// Register registers Consul to libkv
+func Register() {
+	libkv.AddStore(store.CONSUL, New)
+}
+
+// New creates a new Consul client. Addresses are tried in order, the bucket of the config prefixes all keys and the
+// password, if any, is the ACL token.
+func New(addrs []string, options *store.Config) (store.Store, error) {
+	if len(addrs) == 0 {
+		return nil, errors.New("consul: no endpoint")
+	}
+	s := &Consul{client: &http.Client{}}
+	for _, addr := range addrs {
+		if !strings.Contains(addr, "://") {
+			scheme := "http"
+			if options != nil && (options.TLS != nil || options.ClientTLS != nil) {
+				scheme = "https"
+			}
+			addr = scheme + "://" + addr
+		}
+		s.endpoints = append(s.endpoints, strings.TrimSuffix(addr, "/"))
+	}
+	if options != nil {
+		if options.Bucket != "" {
+			s.prefix = strings.Trim(options.Bucket, "/") + "/"
+		}
+		s.token = options.Password
+		s.client.Timeout = options.ConnectionTimeout
+		if options.TLS != nil {
+			s.client.Transport = &http.Transport{TLSClientConfig: options.TLS}
+		}
+	}
+	return s, nil
+}
+
+type kvEntry struct {
+	Key         string
+	Value       []byte
+	ModifyIndex uint64
+}
+
+type txnKVOp struct {
+	Verb  string
+	Key   string
+	Value []byte `json:",omitempty"`
+	Index uint64
+}
+
+type txnOp struct {
+	KV *txnKVOp
+}
+
+type txnResponse struct {
+	Results []struct {
+		KV *kvEntry
+	}
+}
+
+func (s *Consul) key(key string) string {
+	return s.prefix + strings.TrimPrefix(key, "/")
+}
+
+// do sends a request to the first reachable endpoint. It returns the response, the body of which must be closed, for
+// the status codes in expected.
+func (s *Consul) do(method, path string, query url.Values, body []byte, expected ...int) (*http.Response, error) {
+	var lastErr error
+	for _, endpoint := range s.endpoints {
+		u := endpoint + "/v1/" + path
+		if len(query) > 0 {
+			u += "?" + query.Encode()
+		}
+		var reader io.Reader
+		if body != nil {
+			reader = bytes.NewReader(body)
+		}
+		req, err := http.NewRequest(method, u, reader)
+		if err != nil {
+			return nil, errors.Wrap(err, "consul: invalid request")
+		}
+		if s.token != "" {
+			req.Header.Set("X-Consul-Token", s.token)
+		}
+		resp, err := s.client.Do(req)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		for _, code := range expected {
+			if resp.StatusCode == code {
+				return resp, nil
+			}
+		}
+		msg, _ := ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+		return nil, errors.Errorf("consul: %s: %s", resp.Status, strings.TrimSpace(string(msg)))
+	}
+	return nil, errors.Wrapf(store.ErrNotReachable, "consul: %v", lastErr)
+}
+
+// get gets the entries of a key, or of a prefix with recurse
+func (s *Consul) get(key string, query url.Values) ([]*kvEntry, error) {
+	resp, err := s.do(http.MethodGet, "kv/"+key, query, nil, http.StatusOK, http.StatusNotFound)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, store.ErrKeyNotFound
+	}
+	var entries []*kvEntry
+	if err := json.NewDecoder(resp.Body).Decode(&entries); err != nil {
+		return nil, errors.Wrap(err, "consul: deserialization error")
+	}
+	return entries, nil
+}
+
+func (s *Consul) kvPair(e *kvEntry) *store.KVPair {
+	return &store.KVPair{
+		Key:       strings.TrimPrefix(e.Key, s.prefix),
+		Value:     e.Value,
+		LastIndex: e.ModifyIndex,
+	}
+}
+
+// Get gets the value of a key
+func (s *Consul) Get(key string) (*store.KVPair, error) {
+	entries, err := s.get(s.key(key), nil)
+	if err != nil {
+		return nil, err
+	}
+	if len(entries) == 0 {
+		return nil, store.ErrKeyNotFound
+	}
+	return s.kvPair(entries[0]), nil
+}
+
+// Put sets the value of a key
+func (s *Consul) Put(key string, value []byte, options *store.WriteOptions) error {
+	if options != nil && options.TTL > 0 {
+		return store.ErrCallNotSupported
+	}
+	if value == nil {
+		value = []byte{}
+	}
+	resp, err := s.do(http.MethodPut, "kv/"+s.key(key), nil, value, http.StatusOK)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// Delete deletes a key, it is not an error if the key does not exist
+func (s *Consul) Delete(key string) error {
+	resp, err := s.do(http.MethodDelete, "kv/"+s.key(key), nil, nil, http.StatusOK)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// Exists checks whether a key exists
+func (s *Consul) Exists(key string) (bool, error) {
+	_, err := s.get(s.key(key), nil)
+	if err == store.ErrKeyNotFound {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// List lists the keys with the given prefix, it returns store.ErrKeyNotFound if there are none
+func (s *Consul) List(directory string) ([]*store.KVPair, error) {
+	entries, err := s.get(s.key(directory), url.Values{"recurse": {""}})
+	if err != nil {
+		return nil, err
+	}
+	kvs := make([]*store.KVPair, 0, len(entries))
+	for _, e := range entries {
+		kvs = append(kvs, s.kvPair(e))
+	}
+	if len(kvs) == 0 {
+		return nil, store.ErrKeyNotFound
+	}
+	return kvs, nil
+}
+
+// DeleteTree deletes the keys with the given prefix
+func (s *Consul) DeleteTree(directory string) error {
+	resp, err := s.do(http.MethodDelete, "kv/"+s.key(directory), url.Values{"recurse": {""}}, nil, http.StatusOK)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// txn runs a single check-and-set operation, it returns the resulting entry and whether the check passed
+func (s *Consul) txn(op *txnKVOp) (*kvEntry, bool, error) {
+	body, err := json.Marshal([]*txnOp{{KV: op}})
+	if err != nil {
+		return nil, false, errors.Wrap(err, "consul: serialization error")
+	}
+	resp, err := s.do(http.MethodPut, "txn", nil, body, http.StatusOK, http.StatusConflict)
+	if err != nil {
+		return nil, false, err
+	}
+	defer resp.Body.Close()
+	var result txnResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, false, errors.Wrap(err, "consul: deserialization error")
+	}
+	if resp.StatusCode == http.StatusConflict {
+		return nil, false, nil
+	}
+	if len(result.Results) == 0 {
+		return nil, true, nil
+	}
+	return result.Results[0].KV, true, nil
+}
+
+// AtomicPut sets the value of a key, if it was not modified since the previous pair. With no previous pair, the key
+// must not exist.
+func (s *Consul) AtomicPut(key string, value []byte, previous *store.KVPair, options *store.WriteOptions) (bool, *store.KVPair, error) {
+	if options != nil && options.TTL > 0 {
+		return false, nil, store.ErrCallNotSupported
+	}
+	// a check-and-set on index 0 only creates the key
+	var index uint64
+	if previous != nil {
+		if previous.LastIndex == 0 {
+			return false, nil, store.ErrKeyModified
+		}
+		index = previous.LastIndex
+	}
+	if value == nil {
+		value = []byte{}
+	}
+	entry, ok, err := s.txn(&txnKVOp{Verb: "cas", Key: s.key(key), Value: value, Index: index})
+	if err != nil {
+		return false, nil, err
+	}
+	if !ok {
+		if previous == nil {
+			return false, nil, store.ErrKeyExists
+		}
+		return false, nil, store.ErrKeyModified
+	}
+	if entry == nil {
+		return false, nil, errors.Errorf("consul: no result for key %s", key)
+	}
+	return true, &store.KVPair{Key: key, Value: value, LastIndex: entry.ModifyIndex}, nil
+}
+
+// AtomicDelete deletes a key, if it was not modified since the previous pair
+func (s *Consul) AtomicDelete(key string, previous *store.KVPair) (bool, error) {
+	if previous == nil {
+		return false, store.ErrPreviousNotSpecified
+	}
+	_, ok, err := s.txn(&txnKVOp{Verb: "delete-cas", Key: s.key(key), Index: previous.LastIndex})
+	if err != nil {
+		return false, err
+	}
+	if !ok {
+		return false, store.ErrKeyModified
+	}
+	return true, nil
+}
+
+// Watch is not supported
+func (s *Consul) Watch(key string, stopCh <-chan struct{}) (<-chan *store.KVPair, error) {
+	return nil, store.ErrCallNotSupported
+}
+
+// WatchTree sends the keys with the given prefix, initially and after each change, until stopCh is closed. Changes
+// are waited for with blocking queries. The channel is closed when the watch ends, e.g. when Consul cannot be reached
+// anymore.
+func (s *Consul) WatchTree(directory string, stopCh <-chan struct{}) (<-chan []*store.KVPair, error) {
+	prefix := s.key(directory)
+	kvs, index, err := s.list(context.Background(), prefix, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	ch := make(chan []*store.KVPair)
+	go func() {
+		<-stopCh
+		cancel()
+	}()
+	go func() {
+		defer close(ch)
+		defer cancel()
+		for {
+			select {
+			case ch <- kvs:
+			case <-stopCh:
+				return
+			}
+			for {
+				var next uint64
+				kvs, next, err = s.list(ctx, prefix, index)
+				if err != nil {
+					return
+				}
+				// the index may go backwards, e.g. when Consul is restored from a snapshot
+				changed := next != index
+				if next < index {
+					next = 0
+				}
+				index = next
+				if changed {
+					break
+				}
+			}
+		}
+	}()
+	return ch, nil
+}
+
+// list lists the keys with the given prefix, and returns the Consul index. With a non zero index, it blocks until
+// the index changes, or the wait time of Consul elapses.
+func (s *Consul) list(ctx context.Context, prefix string, index uint64) ([]*store.KVPair, uint64, error) {
+	query := url.Values{"recurse": {""}}
+	client := s.client
+	if index > 0 {
+		query.Set("index", strconv.FormatUint(index, 10))
+		query.Set("wait", blockingWait)
+		// the client timeout would end blocking queries
+		client = &http.Client{Transport: s.client.Transport}
+	}
+	var lastErr error
+	for _, endpoint := range s.endpoints {
+		req, err := http.NewRequest(http.MethodGet, endpoint+"/v1/kv/"+prefix+"?"+query.Encode(), nil)
+		if err != nil {
+			return nil, 0, errors.Wrap(err, "consul: invalid request")
+		}
+		req = req.WithContext(ctx)
+		if s.token != "" {
+			req.Header.Set("X-Consul-Token", s.token)
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		defer resp.Body.Close()
+		next, _ := strconv.ParseUint(resp.Header.Get("X-Consul-Index"), 10, 64)
+		var entries []*kvEntry
+		switch resp.StatusCode {
+		case http.StatusOK:
+			if err := json.NewDecoder(resp.Body).Decode(&entries); err != nil {
+				return nil, 0, errors.Wrap(err, "consul: deserialization error")
+			}
+		case http.StatusNotFound:
+		default:
+			return nil, 0, errors.Errorf("consul: %s", resp.Status)
+		}
+		kvs := make([]*store.KVPair, 0, len(entries))
+		for _, e := range entries {
+			kvs = append(kvs, s.kvPair(e))
+		}
+		return kvs, next, nil
+	}
+	return nil, 0, errors.Wrapf(store.ErrNotReachable, "consul: %v", lastErr)
+}
+
+// NewLock is not supported
+func (s *Consul) NewLock(key string, options *store.LockOptions) (store.Locker, error) {
+	return nil, store.ErrCallNotSupported
+}
+
+// Close closes the idle connections of the client
+func (s *Consul) Close() {
+	if t, ok := s.client.Transport.(*http.Transport); ok {
+		t.CloseIdleConnections()
+	}
+}
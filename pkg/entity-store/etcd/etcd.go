@@ -0,0 +1,491 @@
+///////////////////////////////////////////////////////////////////////
+// Copyright (c) 2018 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+///////////////////////////////////////////////////////////////////////
+
+// Package etcd is a libkv store backed by etcd v3, through the JSON gateway of its gRPC API
+package etcd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/docker/libkv"
+	"github.com/docker/libkv/store"
+	"github.com/pkg/errors"
+)
+
+const apiPrefix = "/v3"
+
+// Etcd is a libkv store backed by etcd v3. The revision of a key (LastIndex) is its etcd mod revision, atomic
+// operations are etcd transactions comparing it.
+type Etcd struct {
+	endpoints []string
+	prefix    string
+	username  string
+	password  string
+	client    *http.Client
+
+	sync.Mutex
+	token string
+}
+
+// Register registers etcd to libkv
+func Register() {
+	libkv.AddStore(store.ETCD, New)
+}
+
+// New creates a new etcd client. Addresses are tried in order, the bucket of the config prefixes all keys.
+func New(addrs []string, options *store.Config) (store.Store, error) {
+	if len(addrs) == 0 {
+		return nil, errors.New("etcd: no endpoint")
+	}
+	s := &Etcd{client: &http.Client{}}
+	for _, addr := range addrs {
+		if !strings.Contains(addr, "://") {
+			scheme := "http"
+			if options != nil && (options.TLS != nil || options.ClientTLS != nil) {
+				scheme = "https"
+			}
+			addr = scheme + "://" + addr
+		}
+		s.endpoints = append(s.endpoints, strings.TrimSuffix(addr, "/"))
+	}
+	if options != nil {
+		if options.Bucket != "" {
+			s.prefix = strings.Trim(options.Bucket, "/") + "/"
+		}
+		s.username = options.Username
+		s.password = options.Password
+		s.client.Timeout = options.ConnectionTimeout
+		if options.TLS != nil {
+			s.client.Transport = &http.Transport{TLSClientConfig: options.TLS}
+		}
+	}
+	return s, nil
+}
+
+type header struct {
+	Revision int64 `json:"revision,string"`
+}
+
+type keyValue struct {
+	Key         []byte `json:"key"`
+	Value       []byte `json:"value"`
+	ModRevision int64  `json:"mod_revision,string"`
+}
+
+type rangeRequest struct {
+	Key      []byte `json:"key"`
+	RangeEnd []byte `json:"range_end,omitempty"`
+	KeysOnly bool   `json:"keys_only,omitempty"`
+}
+
+type rangeResponse struct {
+	Header header      `json:"header"`
+	Kvs    []*keyValue `json:"kvs"`
+}
+
+type putRequest struct {
+	Key   []byte `json:"key"`
+	Value []byte `json:"value"`
+}
+
+type deleteRangeRequest struct {
+	Key      []byte `json:"key"`
+	RangeEnd []byte `json:"range_end,omitempty"`
+}
+
+type deleteRangeResponse struct {
+	Header  header `json:"header"`
+	Deleted int64  `json:"deleted,string"`
+}
+
+type compare struct {
+	Target         string `json:"target"`
+	Result         string `json:"result"`
+	Key            []byte `json:"key"`
+	ModRevision    int64  `json:"mod_revision,string"`
+	CreateRevision int64  `json:"create_revision,string"`
+}
+
+type requestOp struct {
+	RequestPut         *putRequest         `json:"request_put,omitempty"`
+	RequestDeleteRange *deleteRangeRequest `json:"request_delete_range,omitempty"`
+}
+
+type txnRequest struct {
+	Compare []*compare   `json:"compare"`
+	Success []*requestOp `json:"success"`
+}
+
+type txnResponse struct {
+	Header    header `json:"header"`
+	Succeeded bool   `json:"succeeded"`
+}
+
+type watchCreateRequest struct {
+	Key           []byte `json:"key"`
+	RangeEnd      []byte `json:"range_end,omitempty"`
+	StartRevision int64  `json:"start_revision,string"`
+}
+
+type watchRequest struct {
+	CreateRequest *watchCreateRequest `json:"create_request"`
+}
+
+type watchResponse struct {
+	Result struct {
+		Header   header            `json:"header"`
+		Canceled bool              `json:"canceled"`
+		Events   []json.RawMessage `json:"events"`
+	} `json:"result"`
+}
+
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+// errUnauthenticated is returned when the token of the client is missing or expired
+var errUnauthenticated = errors.New("etcd: unauthenticated")
+
+func (s *Etcd) key(key string) []byte {
+	return []byte(s.prefix + strings.TrimPrefix(key, "/"))
+}
+
+// rangeEnd returns the end of the range of keys with the given prefix
+func rangeEnd(prefix []byte) []byte {
+	end := append([]byte{}, prefix...)
+	for i := len(end) - 1; i >= 0; i-- {
+		if end[i] < 0xff {
+			end[i]++
+			return end[:i+1]
+		}
+	}
+	// all keys
+	return []byte{0}
+}
+
+// post sends a request to etcd, authenticating first if needed
+func (s *Etcd) post(path string, request, response interface{}) error {
+	token, err := s.authenticate()
+	if err != nil {
+		return err
+	}
+	err = s.do(path, token, request, response)
+	if err == errUnauthenticated && token != "" {
+		// the token expired
+		s.Lock()
+		s.token = ""
+		s.Unlock()
+		if token, err = s.authenticate(); err != nil {
+			return err
+		}
+		err = s.do(path, token, request, response)
+	}
+	return err
+}
+
+// do sends a request to the first reachable endpoint
+func (s *Etcd) do(path, token string, request, response interface{}) error {
+	body, err := json.Marshal(request)
+	if err != nil {
+		return errors.Wrap(err, "etcd: serialization error")
+	}
+	var lastErr error
+	for _, endpoint := range s.endpoints {
+		req, err := http.NewRequest(http.MethodPost, endpoint+apiPrefix+path, bytes.NewReader(body))
+		if err != nil {
+			return errors.Wrap(err, "etcd: invalid request")
+		}
+		req.Header.Set("Content-Type", "application/json")
+		if token != "" {
+			req.Header.Set("Authorization", token)
+		}
+		resp, err := s.client.Do(req)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			var e errorResponse
+			json.NewDecoder(resp.Body).Decode(&e)
+			if resp.StatusCode == http.StatusUnauthorized || strings.Contains(e.Error, "invalid auth token") {
+				return errUnauthenticated
+			}
+			return errors.Errorf("etcd: %s: %s", resp.Status, e.Error)
+		}
+		if err := json.NewDecoder(resp.Body).Decode(response); err != nil {
+			return errors.Wrap(err, "etcd: deserialization error")
+		}
+		return nil
+	}
+	return errors.Wrapf(store.ErrNotReachable, "etcd: %v", lastErr)
+}
+
+// authenticate returns the token of the client, if etcd authentication is enabled
+func (s *Etcd) authenticate() (string, error) {
+	if s.username == "" {
+		return "", nil
+	}
+	s.Lock()
+	defer s.Unlock()
+	if s.token != "" {
+		return s.token, nil
+	}
+	var resp struct {
+		Token string `json:"token"`
+	}
+	if err := s.do("/auth/authenticate", "", map[string]string{"name": s.username, "password": s.password}, &resp); err != nil {
+		return "", errors.Wrap(err, "etcd: authentication failed")
+	}
+	s.token = resp.Token
+	return s.token, nil
+}
+
+func (s *Etcd) kvPair(kv *keyValue) *store.KVPair {
+	return &store.KVPair{
+		Key:       strings.TrimPrefix(string(kv.Key), s.prefix),
+		Value:     kv.Value,
+		LastIndex: uint64(kv.ModRevision),
+	}
+}
+
+// Get gets the value of a key
+func (s *Etcd) Get(key string) (*store.KVPair, error) {
+	var resp rangeResponse
+	if err := s.post("/kv/range", &rangeRequest{Key: s.key(key)}, &resp); err != nil {
+		return nil, err
+	}
+	if len(resp.Kvs) == 0 {
+		return nil, store.ErrKeyNotFound
+	}
+	return s.kvPair(resp.Kvs[0]), nil
+}
+
+// Put sets the value of a key
+func (s *Etcd) Put(key string, value []byte, options *store.WriteOptions) error {
+	if options != nil && options.TTL > 0 {
+		return store.ErrCallNotSupported
+	}
+	var resp struct {
+		Header header `json:"header"`
+	}
+	return s.post("/kv/put", &putRequest{Key: s.key(key), Value: value}, &resp)
+}
+
+// Delete deletes a key, it is not an error if the key does not exist
+func (s *Etcd) Delete(key string) error {
+	var resp deleteRangeResponse
+	return s.post("/kv/deleterange", &deleteRangeRequest{Key: s.key(key)}, &resp)
+}
+
+// Exists checks whether a key exists
+func (s *Etcd) Exists(key string) (bool, error) {
+	var resp rangeResponse
+	if err := s.post("/kv/range", &rangeRequest{Key: s.key(key), KeysOnly: true}, &resp); err != nil {
+		return false, err
+	}
+	return len(resp.Kvs) > 0, nil
+}
+
+// List lists the keys with the given prefix, it returns store.ErrKeyNotFound if there are none
+func (s *Etcd) List(directory string) ([]*store.KVPair, error) {
+	kvs, _, err := s.list(s.key(directory))
+	if err != nil {
+		return nil, err
+	}
+	if len(kvs) == 0 {
+		return nil, store.ErrKeyNotFound
+	}
+	return kvs, nil
+}
+
+// list lists the keys with the given prefix, and returns the revision of etcd
+func (s *Etcd) list(prefix []byte) ([]*store.KVPair, int64, error) {
+	var resp rangeResponse
+	if err := s.post("/kv/range", &rangeRequest{Key: prefix, RangeEnd: rangeEnd(prefix)}, &resp); err != nil {
+		return nil, 0, err
+	}
+	kvs := make([]*store.KVPair, 0, len(resp.Kvs))
+	for _, kv := range resp.Kvs {
+		kvs = append(kvs, s.kvPair(kv))
+	}
+	return kvs, resp.Header.Revision, nil
+}
+
+// DeleteTree deletes the keys with the given prefix
+func (s *Etcd) DeleteTree(directory string) error {
+	prefix := s.key(directory)
+	var resp deleteRangeResponse
+	return s.post("/kv/deleterange", &deleteRangeRequest{Key: prefix, RangeEnd: rangeEnd(prefix)}, &resp)
+}
+
+// condition compares the mod revision of a key with the previous pair, or checks that the key does not exist
+func (s *Etcd) condition(key string, previous *store.KVPair) *compare {
+	if previous == nil {
+		return &compare{Target: "CREATE", Result: "EQUAL", Key: s.key(key)}
+	}
+	return &compare{Target: "MOD", Result: "EQUAL", Key: s.key(key), ModRevision: int64(previous.LastIndex)}
+}
+
+// AtomicPut sets the value of a key, if it was not modified since the previous pair. With no previous pair, the key
+// must not exist.
+func (s *Etcd) AtomicPut(key string, value []byte, previous *store.KVPair, options *store.WriteOptions) (bool, *store.KVPair, error) {
+	if options != nil && options.TTL > 0 {
+		return false, nil, store.ErrCallNotSupported
+	}
+	req := &txnRequest{
+		Compare: []*compare{s.condition(key, previous)},
+		Success: []*requestOp{{RequestPut: &putRequest{Key: s.key(key), Value: value}}},
+	}
+	var resp txnResponse
+	if err := s.post("/kv/txn", req, &resp); err != nil {
+		return false, nil, err
+	}
+	if !resp.Succeeded {
+		if previous == nil {
+			return false, nil, store.ErrKeyExists
+		}
+		return false, nil, store.ErrKeyModified
+	}
+	// the put is the only change of the transaction
+	return true, &store.KVPair{Key: key, Value: value, LastIndex: uint64(resp.Header.Revision)}, nil
+}
+
+// AtomicDelete deletes a key, if it was not modified since the previous pair
+func (s *Etcd) AtomicDelete(key string, previous *store.KVPair) (bool, error) {
+	if previous == nil {
+		return false, store.ErrPreviousNotSpecified
+	}
+	req := &txnRequest{
+		Compare: []*compare{s.condition(key, previous)},
+		Success: []*requestOp{{RequestDeleteRange: &deleteRangeRequest{Key: s.key(key)}}},
+	}
+	var resp txnResponse
+	if err := s.post("/kv/txn", req, &resp); err != nil {
+		return false, err
+	}
+	if !resp.Succeeded {
+		return false, store.ErrKeyModified
+	}
+	return true, nil
+}
+
+// Watch is not supported
+func (s *Etcd) Watch(key string, stopCh <-chan struct{}) (<-chan *store.KVPair, error) {
+	return nil, store.ErrCallNotSupported
+}
+
+// WatchTree sends the keys with the given prefix, initially and after each change, until stopCh is closed. The
+// channel is closed when the watch ends, e.g. when etcd cannot be reached anymore.
+func (s *Etcd) WatchTree(directory string, stopCh <-chan struct{}) (<-chan []*store.KVPair, error) {
+	prefix := s.key(directory)
+	kvs, revision, err := s.list(prefix)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	req := &watchRequest{CreateRequest: &watchCreateRequest{Key: prefix, RangeEnd: rangeEnd(prefix), StartRevision: revision + 1}}
+	body, err := s.stream(ctx, "/watch", req)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+
+	ch := make(chan []*store.KVPair)
+	go func() {
+		<-stopCh
+		cancel()
+	}()
+	go func() {
+		defer close(ch)
+		defer body.Close()
+		defer cancel()
+
+		select {
+		case ch <- kvs:
+		case <-stopCh:
+			return
+		}
+		decoder := json.NewDecoder(body)
+		for {
+			var resp watchResponse
+			if err := decoder.Decode(&resp); err != nil {
+				return
+			}
+			if resp.Result.Canceled {
+				return
+			}
+			if len(resp.Result.Events) == 0 {
+				continue
+			}
+			kvs, _, err := s.list(prefix)
+			if err != nil {
+				return
+			}
+			select {
+			case ch <- kvs:
+			case <-stopCh:
+				return
+			}
+		}
+	}()
+	return ch, nil
+}
+
+// stream sends a request to the first reachable endpoint, the response of which is streamed until ctx is done
+func (s *Etcd) stream(ctx context.Context, path string, request interface{}) (io.ReadCloser, error) {
+	token, err := s.authenticate()
+	if err != nil {
+		return nil, err
+	}
+	body, err := json.Marshal(request)
+	if err != nil {
+		return nil, errors.Wrap(err, "etcd: serialization error")
+	}
+	// the client timeout would end the stream
+	client := &http.Client{Transport: s.client.Transport}
+	var lastErr error
+	for _, endpoint := range s.endpoints {
+		req, err := http.NewRequest(http.MethodPost, endpoint+apiPrefix+path, bytes.NewReader(body))
+		if err != nil {
+			return nil, errors.Wrap(err, "etcd: invalid request")
+		}
+		req = req.WithContext(ctx)
+		req.Header.Set("Content-Type", "application/json")
+		if token != "" {
+			req.Header.Set("Authorization", token)
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		if resp.StatusCode != http.StatusOK {
+			resp.Body.Close()
+			return nil, errors.Errorf("etcd: %s", resp.Status)
+		}
+		return resp.Body, nil
+	}
+	return nil, errors.Wrapf(store.ErrNotReachable, "etcd: %v", lastErr)
+}
+
+// NewLock is not supported
+func (s *Etcd) NewLock(key string, options *store.LockOptions) (store.Locker, error) {
+	return nil, store.ErrCallNotSupported
+}
+
+// Close closes the idle connections of the client
+func (s *Etcd) Close() {
+	if t, ok := s.client.Transport.(*http.Transport); ok {
+		t.CloseIdleConnections()
+	}
+}
@@ -18,6 +18,16 @@ const (
 	// FilterVerbAfter tests two time.Time
 	FilterVerbAfter Verb = "after"
 
+	// FilterVerbNotEqual tests inequality
+	FilterVerbNotEqual Verb = "notEqual"
+
+	// FilterVerbPrefix tests that a string starts with the object
+	FilterVerbPrefix Verb = "prefix"
+
+	// FilterVerbExists tests that a tag is set, or that a field has a non-zero value. The object is a bool, false tests
+	// the opposite.
+	FilterVerbExists Verb = "exists"
+
 	// FilterScopeField defines that the subject is a BaseEntity field
 	FilterScopeField Scope = "field"
 
@@ -34,10 +44,14 @@ type Verb string
 // Scope describes which scope this filter is applied on
 type Scope string
 
-// Filter defines a set of criteria to filter entities when listing
+// Filter defines a set of criteria to filter entities when listing. Entities satisfy a filter if they satisfy all of
+// its statements and at least one filter of each of its OR groups.
 type Filter interface {
 	Add(...FilterStat) Filter
+	// Or adds an OR group, satisfied if any of the filters is satisfied
+	Or(...Filter) Filter
 	FilterStats() []FilterStat
+	OrGroups() [][]Filter
 }
 
 // FilterStat (Filter Statement) defines one filter criterion
@@ -60,6 +74,7 @@ func FilterStatByApplication(name string) FilterStat {
 
 type filter struct {
 	statements []FilterStat
+	orGroups   [][]Filter
 }
 
 // FilterEverything creates a filter, which will matches all entities
@@ -93,6 +108,15 @@ func (f *filter) Add(stats ...FilterStat) Filter {
 	return f
 }
 
+func (f *filter) Or(filters ...Filter) Filter {
+	f.orGroups = append(f.orGroups, filters)
+	return f
+}
+
 func (f filter) FilterStats() []FilterStat {
 	return f.statements
 }
+
+func (f filter) OrGroups() [][]Filter {
+	return f.orGroups
+}
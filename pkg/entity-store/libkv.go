@@ -8,6 +8,8 @@ package entitystore
 import (
 	"encoding/json"
 	"reflect"
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/docker/libkv/store"
@@ -169,22 +171,45 @@ func doFilterStat(fs FilterStat, entity Entity) (bool, error) {
 			return false, errors.Errorf("unexpected error: should be the an instance of type Tags")
 		}
 		subjectValue = tags[fs.Subject]
+		if fs.Verb == FilterVerbExists {
+			// a tag exists if it is set, even to an empty value
+			_, set := tags[fs.Subject]
+			subjectValue = set
+		}
 	}
 
 	switch fs.Verb {
 	case FilterVerbEqual:
-		return reflect.DeepEqual(subjectValue, fs.Object), nil
+		return sameValue(subjectValue, fs.Object), nil
+	case FilterVerbNotEqual:
+		return !sameValue(subjectValue, fs.Object), nil
 	case FilterVerbIn:
 		objects := reflect.ValueOf(fs.Object)
 		if objects.Kind() != reflect.Slice {
 			return false, errors.Errorf("error filtering: object of a 'in' operator must be a slice")
 		}
 		for i := 0; i < objects.Len(); i++ {
-			if reflect.DeepEqual(subjectValue, objects.Index(i).Interface()) {
+			if sameValue(subjectValue, objects.Index(i).Interface()) {
 				return true, nil
 			}
 		}
 		return false, nil
+	case FilterVerbPrefix:
+		prefix, ok := fs.Object.(string)
+		if !ok {
+			return false, errors.Errorf("error filtering: object of a 'prefix' verb must be a string")
+		}
+		subject := reflect.ValueOf(subjectValue)
+		if subject.Kind() != reflect.String {
+			return false, errors.Errorf("error filtering: subject of a 'prefix' verb must be a string")
+		}
+		return strings.HasPrefix(subject.String(), prefix), nil
+	case FilterVerbExists:
+		exists, ok := fs.Object.(bool)
+		if !ok {
+			return false, errors.Errorf("error filtering: object of an 'exists' verb must be a bool")
+		}
+		return isZero(subjectValue) != exists, nil
 	case FilterVerbBefore, FilterVerbAfter:
 		// must be time.Time
 		object, ok := fs.Object.(time.Time)
@@ -204,6 +229,36 @@ func doFilterStat(fs FilterStat, entity Entity) (bool, error) {
 	}
 }
 
+// sameValue compares a subject to an object, which may be of another string or number type, e.g. a Status to a string
+func sameValue(subject, object interface{}) bool {
+	sv, ov := reflect.ValueOf(subject), reflect.ValueOf(object)
+	if sv.IsValid() && ov.IsValid() && sv.Type() != ov.Type() && kindClass(sv.Kind()) != "" &&
+		kindClass(sv.Kind()) == kindClass(ov.Kind()) {
+		return reflect.DeepEqual(subject, ov.Convert(sv.Type()).Interface())
+	}
+	return reflect.DeepEqual(subject, object)
+}
+
+// kindClass returns the class of the kinds which convert to each other without changing meaning
+func kindClass(kind reflect.Kind) string {
+	switch kind {
+	case reflect.String:
+		return "string"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Float32, reflect.Float64:
+		return "number"
+	}
+	return ""
+}
+
+func isZero(v interface{}) bool {
+	if t, ok := v.(time.Time); ok {
+		return t.IsZero()
+	}
+	rv := reflect.ValueOf(v)
+	return !rv.IsValid() || reflect.DeepEqual(v, reflect.Zero(rv.Type()).Interface())
+}
+
 func doFilter(filter Filter, entity Entity) (bool, error) {
 	for _, fs := range filter.FilterStats() {
 		ok, err := doFilterStat(fs, entity)
@@ -215,6 +270,22 @@ func doFilter(filter Filter, entity Entity) (bool, error) {
 			return false, nil
 		}
 	}
+	for _, group := range filter.OrGroups() {
+		satisfied := false
+		for _, f := range group {
+			ok, err := doFilter(f, entity)
+			if err != nil {
+				return false, err
+			}
+			if ok {
+				satisfied = true
+				break
+			}
+		}
+		if !satisfied {
+			return false, nil
+		}
+	}
 	return true, nil
 }
 
@@ -242,7 +313,30 @@ func (es *libkvEntityStore) List(organizationID string, opts Options, entities i
 		}
 		return err
 	}
+	// without sort keys entities are listed by name, i.e. by key: the keys are compared before decoding, so that
+	// the entities up to the continue token and past the limit are not decoded
+	byName := len(opts.SortBy) == 0
+	var continueName string
+	if byName {
+		sort.Slice(kvs, func(i, j int) bool { return kvs[i].Key < kvs[j].Key })
+		if opts.Continue != "" {
+			token, err := parseContinueToken(opts)
+			if err != nil {
+				return errors.Wrap(err, "error listing")
+			}
+			continueName = token.Name
+		}
+	}
+	var listed []Entity
 	for _, kv := range kvs {
+		if byName {
+			if opts.Limit > 0 && len(listed) >= opts.Limit {
+				break
+			}
+			if opts.Continue != "" && keyName(key, kv.Key) <= continueName {
+				continue
+			}
+		}
 		obj := reflect.New(elemType.Elem())
 		entity := obj.Interface().(Entity)
 		err = json.Unmarshal(kv.Value, entity)
@@ -261,9 +355,91 @@ func (es *libkvEntityStore) List(organizationID string, opts Options, entities i
 		}
 		entity.setRevision(kv.LastIndex)
 
-		slice = reflect.Append(slice, obj)
+		listed = append(listed, entity)
+	}
+	// the key/value stores list by key, entities are sorted and paged once listed
+	listed, err = paginate(opts, elemType.Elem(), listed)
+	if err != nil {
+		return errors.Wrap(err, "error listing")
+	}
+	for _, entity := range listed {
+		slice = reflect.Append(slice, reflect.ValueOf(entity))
 	}
 	rv.Elem().Set(slice)
 
 	return nil
 }
+
+// keyName returns the name of the entity stored at key, listed under prefix
+func keyName(prefix, key string) string {
+	// some backends normalize the keys with a leading slash
+	prefix = strings.TrimPrefix(prefix, "/")
+	if i := strings.Index(key, prefix); i >= 0 {
+		return key[i+len(prefix):]
+	}
+	return key
+}
+
+// Watch streams the changes of the entities of a single data type, computed from the snapshots of the libkv tree
+// of the data type
+func (es *libkvEntityStore) Watch(organizationID string, entityType reflect.Type, filter Filter, stopCh <-chan struct{}) (<-chan Event, error) {
+	if _, err := newEntity(entityType); err != nil {
+		return nil, err
+	}
+	key := buildKey(organizationID, dataType(entityType.Elem().Name()))
+	snapshots, err := es.kv.WatchTree(key, stopCh)
+	if err == store.ErrCallNotSupported {
+		return nil, ErrWatchNotSupported
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "error watching entities")
+	}
+
+	events := make(chan Event)
+	go func() {
+		defer close(events)
+		// the first snapshot is the initial state, which is not reported
+		var known map[string]Entity
+		for kvs := range snapshots {
+			current := make(map[string]Entity, len(kvs))
+			var changes []Event
+			for _, kv := range kvs {
+				entity, _ := newEntity(entityType)
+				if err := json.Unmarshal(kv.Value, entity); err != nil {
+					log.Errorf("deserialization error, while watching %s: %v", kv.Key, err)
+					continue
+				}
+				entity.setRevision(kv.LastIndex)
+				current[kv.Key] = entity
+				if known == nil {
+					continue
+				}
+				if previous, ok := known[kv.Key]; !ok {
+					changes = append(changes, Event{Type: EventAdded, Entity: entity})
+				} else if previous.GetRevision() != entity.GetRevision() {
+					changes = append(changes, Event{Type: EventUpdated, Entity: entity})
+				}
+			}
+			for k, previous := range known {
+				if _, ok := current[k]; !ok {
+					changes = append(changes, Event{Type: EventDeleted, Entity: previous})
+				}
+			}
+			known = current
+
+			for _, event := range changes {
+				if filter != nil {
+					if ok, err := doFilter(filter, event.Entity); err != nil || !ok {
+						continue
+					}
+				}
+				select {
+				case events <- event:
+				case <-stopCh:
+					return
+				}
+			}
+		}
+	}()
+	return events, nil
+}
@@ -5,7 +5,254 @@
 
 package entitystore
 
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/pkg/errors"
+)
+
 // Options defines a set of query options for list and get
 type Options struct {
 	Filter Filter
+
+	// Limit is the maximum number of entities listed, all of them are listed if 0. With sort keys, the libkv backends
+	// still load all the entities of the data type to sort them
+	Limit int
+	// Continue lists the entities following a previous page, it is the token returned by ContinueToken for that page
+	Continue string
+	// SortBy are the keys entities are listed by, entities are then sorted by name
+	SortBy []SortKey
+}
+
+// SortKey is a key entities are sorted by
+type SortKey struct {
+	Scope      Scope
+	Subject    string
+	Descending bool
+}
+
+// continueToken is the position of the last entity of a page: the values of its sort keys and its name
+type continueToken struct {
+	Values []json.RawMessage `json:"values,omitempty"`
+	Name   string            `json:"name"`
+}
+
+// ParseSortKeys parses sort keys. A key is a field of the entities in lower camel case, e.g. createdTime, or a tag as
+// tags.<name>. Keys prefixed with "-" sort in the descending order.
+func ParseSortKeys(keys []string) ([]SortKey, error) {
+	var sortKeys []SortKey
+	for _, key := range keys {
+		sortKey := SortKey{Scope: FilterScopeField}
+		subject := strings.TrimPrefix(key, "-")
+		sortKey.Descending = subject != key
+		if strings.HasPrefix(subject, "tags.") {
+			sortKey.Scope = FilterScopeTag
+			sortKey.Subject = strings.TrimPrefix(subject, "tags.")
+		} else if subject != "" {
+			// the fields of the entities are in upper camel case
+			r, size := utf8.DecodeRuneInString(subject)
+			sortKey.Subject = string(unicode.ToUpper(r)) + subject[size:]
+			if _, ok := reflect.TypeOf(BaseEntity{}).FieldByName(sortKey.Subject); !ok {
+				sortKey.Scope = FilterScopeExtra
+			}
+		}
+		if sortKey.Subject == "" {
+			return nil, errors.Errorf("invalid sort key '%s'", key)
+		}
+		sortKeys = append(sortKeys, sortKey)
+	}
+	return sortKeys, nil
+}
+
+// ContinueToken returns the token to list the page following the entities listed with opts, entities being a slice
+// of entities or a pointer to it. There is no following page if the token is empty, whereas a full page always gets a
+// token, even if no entities follow it.
+func ContinueToken(opts Options, entities interface{}) (string, error) {
+	rv := reflect.Indirect(reflect.ValueOf(entities))
+	if rv.Kind() != reflect.Slice {
+		return "", errors.New("need an entity slice")
+	}
+	if opts.Limit <= 0 || rv.Len() < opts.Limit {
+		return "", nil
+	}
+	last, ok := rv.Index(rv.Len() - 1).Interface().(Entity)
+	if !ok {
+		return "", errors.New("non-entity element type: maybe use pointers")
+	}
+
+	token := continueToken{Name: last.GetName()}
+	for _, key := range opts.SortBy {
+		v, err := sortValue(key, last)
+		if err != nil {
+			return "", err
+		}
+		raw, err := json.Marshal(v)
+		if err != nil {
+			return "", errors.Wrap(err, "error making the continue token")
+		}
+		token.Values = append(token.Values, raw)
+	}
+	b, err := json.Marshal(token)
+	if err != nil {
+		return "", errors.Wrap(err, "error making the continue token")
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// parseContinueToken parses a continue token made with the same sort keys
+func parseContinueToken(opts Options) (*continueToken, error) {
+	b, err := base64.RawURLEncoding.DecodeString(opts.Continue)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid continue token")
+	}
+	var token continueToken
+	if err := json.Unmarshal(b, &token); err != nil {
+		return nil, errors.Wrap(err, "invalid continue token")
+	}
+	if len(token.Values) != len(opts.SortBy) {
+		return nil, errors.New("invalid continue token: the sort keys changed")
+	}
+	return &token, nil
+}
+
+// sortType returns the type of the values of a sort key, entityType being the struct type of the entities
+func sortType(key SortKey, entityType reflect.Type) (reflect.Type, error) {
+	if key.Scope == FilterScopeTag {
+		return reflect.TypeOf(""), nil
+	}
+	field, ok := entityType.FieldByName(key.Subject)
+	if !ok {
+		return nil, errors.Errorf("error sorting: invalid field %s", key.Subject)
+	}
+	return field.Type, nil
+}
+
+// sortValue returns the value of a sort key of an entity
+func sortValue(key SortKey, entity Entity) (interface{}, error) {
+	if key.Scope == FilterScopeTag {
+		return entity.GetTags()[key.Subject], nil
+	}
+	field := reflect.ValueOf(entity).Elem().FieldByName(key.Subject)
+	if !field.IsValid() {
+		return nil, errors.Errorf("error sorting: invalid field %s", key.Subject)
+	}
+	return field.Interface(), nil
+}
+
+// compareValues compares two values of the same type, returning -1, 0 or 1
+func compareValues(a, b interface{}) int {
+	if at, ok := a.(time.Time); ok {
+		bt, _ := b.(time.Time)
+		switch {
+		case at.Before(bt):
+			return -1
+		case at.After(bt):
+			return 1
+		}
+		return 0
+	}
+	av, bv := reflect.ValueOf(a), reflect.ValueOf(b)
+	if !av.IsValid() || !bv.IsValid() || av.Kind() != bv.Kind() {
+		return strings.Compare(fmt.Sprint(a), fmt.Sprint(b))
+	}
+	var less, greater bool
+	switch av.Kind() {
+	case reflect.String:
+		return strings.Compare(av.String(), bv.String())
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		less, greater = av.Int() < bv.Int(), av.Int() > bv.Int()
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		less, greater = av.Uint() < bv.Uint(), av.Uint() > bv.Uint()
+	case reflect.Float32, reflect.Float64:
+		less, greater = av.Float() < bv.Float(), av.Float() > bv.Float()
+	case reflect.Bool:
+		less, greater = !av.Bool() && bv.Bool(), av.Bool() && !bv.Bool()
+	default:
+		return strings.Compare(fmt.Sprint(a), fmt.Sprint(b))
+	}
+	switch {
+	case less:
+		return -1
+	case greater:
+		return 1
+	}
+	return 0
+}
+
+// compareEntity compares an entity to a position, made of the values of the sort keys and a name
+func compareEntity(keys []SortKey, entity Entity, values []interface{}, name string) (int, error) {
+	for i, key := range keys {
+		v, err := sortValue(key, entity)
+		if err != nil {
+			return 0, err
+		}
+		if c := compareValues(v, values[i]); c != 0 {
+			if key.Descending {
+				return -c, nil
+			}
+			return c, nil
+		}
+	}
+	return strings.Compare(entity.GetName(), name), nil
+}
+
+// paginate sorts entities and returns the page listed with opts, for the stores which cannot sort nor page entities
+// themselves. entityType is the struct type of the entities.
+func paginate(opts Options, entityType reflect.Type, entities []Entity) ([]Entity, error) {
+	var sortErr error
+	sort.SliceStable(entities, func(i, j int) bool {
+		values := make([]interface{}, len(opts.SortBy))
+		for k, key := range opts.SortBy {
+			v, err := sortValue(key, entities[j])
+			if err != nil {
+				sortErr = err
+			}
+			values[k] = v
+		}
+		c, err := compareEntity(opts.SortBy, entities[i], values, entities[j].GetName())
+		if err != nil {
+			sortErr = err
+		}
+		return c < 0
+	})
+	if sortErr != nil {
+		return nil, sortErr
+	}
+
+	if opts.Continue != "" {
+		token, err := parseContinueToken(opts)
+		if err != nil {
+			return nil, err
+		}
+		values := make([]interface{}, len(opts.SortBy))
+		for i, key := range opts.SortBy {
+			t, err := sortType(key, entityType)
+			if err != nil {
+				return nil, err
+			}
+			v := reflect.New(t)
+			if err := json.Unmarshal(token.Values[i], v.Interface()); err != nil {
+				return nil, errors.Wrap(err, "invalid continue token")
+			}
+			values[i] = v.Elem().Interface()
+		}
+		start := sort.Search(len(entities), func(i int) bool {
+			c, _ := compareEntity(opts.SortBy, entities[i], values, token.Name)
+			return c > 0
+		})
+		entities = entities[start:]
+	}
+
+	if opts.Limit > 0 && len(entities) > opts.Limit {
+		entities = entities[:opts.Limit]
+	}
+	return entities, nil
 }
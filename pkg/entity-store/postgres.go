@@ -23,6 +23,8 @@ import (
 
 type postgresEntityStore struct {
 	db *sqlx.DB
+	// connInfo is the connection string, with which watches open their own connections
+	connInfo string
 }
 
 type dbEntity struct {
@@ -122,6 +124,47 @@ func (p *postgresEntityStore) createTable() error {
 	return nil
 }
 
+// notifyChannel is the channel of the notifications of the changes of entities
+const notifyChannel = "entity_changes"
+
+// createNotifyTrigger creates the trigger notifying the changes of entities to watches. Notifications are limited to
+// 8000 bytes: the payload has the key fields of the entity, and its value only if it is deleted and small enough.
+func (p *postgresEntityStore) createNotifyTrigger() error {
+
+	sql := `
+	CREATE OR REPLACE FUNCTION notify_entity_change() RETURNS trigger AS $$
+	DECLARE
+		changed entity%ROWTYPE;
+	BEGIN
+		IF TG_OP = 'DELETE' THEN
+			changed := OLD;
+		ELSE
+			changed := NEW;
+		END IF;
+		PERFORM pg_notify('` + notifyChannel + `', json_build_object(
+			'op', TG_OP, 'id', changed.id, 'name', changed.name, 'type', changed.type,
+			'organization_id', changed.organization_id, 'revision', changed.revision,
+			'status', changed.status, 'tags', changed.tags,
+			'value', CASE WHEN TG_OP = 'DELETE' AND octet_length(changed.value::text) < 6000 THEN changed.value END)::text);
+		RETURN NULL;
+	END;
+	$$ LANGUAGE plpgsql;
+
+	DO $$
+	BEGIN
+		IF NOT EXISTS (SELECT 1 FROM pg_trigger WHERE tgname = 'entity_notify_change') THEN
+			CREATE TRIGGER entity_notify_change AFTER INSERT OR UPDATE OR DELETE ON entity
+			FOR EACH ROW EXECUTE PROCEDURE notify_entity_change();
+		END IF;
+	END;
+	$$`
+	_, err := p.db.Exec(sql)
+	if err != nil {
+		return errors.Wrap(err, "fail to create the entity notification trigger")
+	}
+	return nil
+}
+
 func (p *postgresEntityStore) dropTable() error {
 	sql := `
 	DROP TABLE IF EXISTS entity`
@@ -151,13 +194,17 @@ func newPostgres(config BackendConfig) (EntityStore, error) {
 		log.Debugf("error connecting to postgresql DB")
 		return nil, errors.Wrap(err, "Unable to connect to the postgres db server")
 	}
-	store := &postgresEntityStore{db: db}
+	store := &postgresEntityStore{db: db, connInfo: opts}
 
 	// create tables if not exists
 	err = store.createTable()
 	if err != nil {
 		return nil, err
 	}
+	err = store.createNotifyTrigger()
+	if err != nil {
+		return nil, err
+	}
 	return store, nil
 }
 
@@ -304,7 +351,7 @@ func (p *postgresEntityStore) Get(organizationID string, name string, opts Optio
 		Object:  key,
 	})
 
-	sql, args, err := makeListQuery(organizationID, opts.Filter, reflect.TypeOf(entity).Elem())
+	sql, args, err := makeListQuery(organizationID, Options{Filter: opts.Filter}, reflect.TypeOf(entity).Elem())
 	if err != nil {
 		return errors.Wrap(err, "error makeListQuery")
 	}
@@ -328,64 +375,237 @@ func (p *postgresEntityStore) Get(organizationID string, name string, opts Optio
 	return dbToEntity(row, entity)
 }
 
-func makeListQuery(organizationID string, filter Filter, entityType reflect.Type) (sql string, args []interface{}, err error) {
+// listQuery builds the conditions and the sort order of a list query
+type listQuery struct {
+	entityType reflect.Type
+	args       map[string]interface{}
+	argCount   int
+}
+
+// arg adds an argument to the query, returning its placeholder
+func (q *listQuery) arg(v interface{}) string {
+	name := fmt.Sprintf("arg%d", q.argCount)
+	q.argCount++
+	q.args[name] = v
+	return ":" + name
+}
+
+// column returns the column (or the JSON value) of a subject and the type of its values
+func (q *listQuery) column(scope Scope, subject string) (string, reflect.Type, error) {
+	switch scope {
+	case FilterScopeField:
+		field, ok := reflect.TypeOf(dbEntity{}).FieldByName(subject)
+		if !ok {
+			return "", nil, errors.Errorf("error listing: no such field: %s", subject)
+		}
+		// find the column name by struct tag
+		return field.Tag.Get("db"), field.Type, nil
+	case FilterScopeTag:
+		// the type of the key is explicit, the ->> operator also takes an array index
+		return fmt.Sprintf("tags->>CAST(%s AS text)", q.arg(subject)), reflect.TypeOf(""), nil
+	case FilterScopeExtra:
+		field, ok := q.entityType.FieldByName(subject)
+		if !ok {
+			return "", nil, errors.Errorf("error listing: no such extra field: %s", subject)
+		}
+		// remove the "omitempty", the value is inside the JSONB field 'value'
+		return fmt.Sprintf("value->>'%s'", strings.Split(field.Tag.Get("json"), ",")[0]), field.Type, nil
+	}
+	return "", nil, errors.Errorf("error listing: invalid scope: %s", scope)
+}
+
+// jsonText returns the text of a JSON value, as returned by the ->> operator
+func jsonText(raw []byte) string {
+	var s string
+	if err := json.Unmarshal(raw, &s); err == nil {
+		return s
+	}
+	return string(raw)
+}
+
+func isNumber(t reflect.Type) bool {
+	return kindClass(t.Kind()) == "number"
+}
+
+func (q *listQuery) statement(fs FilterStat) (string, error) {
+	column, fieldType, err := q.column(fs.Scope, fs.Subject)
+	if err != nil {
+		return "", err
+	}
+	switch fs.Verb {
+	case FilterVerbEqual:
+		return fmt.Sprintf("%s = %s", column, q.arg(fs.Object)), nil
+	case FilterVerbNotEqual:
+		return fmt.Sprintf("%s IS DISTINCT FROM %s", column, q.arg(fs.Object)), nil
+	case FilterVerbIn:
+		return fmt.Sprintf("%s IN (%s)", column, q.arg(fs.Object)), nil
+	case FilterVerbBefore:
+		return fmt.Sprintf("%s < %s", column, q.arg(fs.Object)), nil
+	case FilterVerbAfter:
+		return fmt.Sprintf("%s > %s", column, q.arg(fs.Object)), nil
+	case FilterVerbPrefix:
+		prefix, ok := fs.Object.(string)
+		if !ok {
+			return "", errors.Errorf("error listing: object of a 'prefix' verb must be a string")
+		}
+		escaped := strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(prefix)
+		return fmt.Sprintf("%s LIKE %s", column, q.arg(escaped+"%")), nil
+	case FilterVerbExists:
+		exists, ok := fs.Object.(bool)
+		if !ok {
+			return "", errors.Errorf("error listing: object of an 'exists' verb must be a bool")
+		}
+		var condition string
+		switch fs.Scope {
+		case FilterScopeTag:
+			condition = fmt.Sprintf("%s IS NOT NULL", column)
+		case FilterScopeField:
+			condition = fmt.Sprintf("(%s IS NOT NULL AND %s <> %s)", column, column, q.arg(reflect.Zero(fieldType).Interface()))
+		default:
+			// the zero values of extra fields are either missing or set
+			zero, _ := json.Marshal(reflect.Zero(fieldType).Interface())
+			condition = fmt.Sprintf("COALESCE(%s, %s) <> %s", column, q.arg(jsonText(zero)), q.arg(jsonText(zero)))
+		}
+		if !exists {
+			condition = fmt.Sprintf("NOT (%s)", condition)
+		}
+		return condition, nil
+	}
+	return "", errors.Errorf("error listing: invalid filter")
+}
+
+// condition returns the condition of a filter, the statements and the OR groups of which are all satisfied
+func (q *listQuery) condition(filter Filter) (string, error) {
+	var conditions []string
+	for _, fs := range filter.FilterStats() {
+		c, err := q.statement(fs)
+		if err != nil {
+			return "", err
+		}
+		conditions = append(conditions, c)
+	}
+	for _, group := range filter.OrGroups() {
+		alternatives := []string{"FALSE"}
+		for _, f := range group {
+			c, err := q.condition(f)
+			if err != nil {
+				return "", err
+			}
+			alternatives = append(alternatives, c)
+		}
+		conditions = append(conditions, fmt.Sprintf("(%s)", strings.Join(alternatives, " OR ")))
+	}
+	if len(conditions) == 0 {
+		return "TRUE", nil
+	}
+	return fmt.Sprintf("(%s)", strings.Join(conditions, " AND ")), nil
+}
+
+// sortColumn returns the expression of a sort key, missing values being sorted as zero values
+func (q *listQuery) sortColumn(key SortKey) (string, reflect.Type, error) {
+	column, fieldType, err := q.column(key.Scope, key.Subject)
+	if err != nil {
+		return "", nil, err
+	}
+	switch {
+	case key.Scope == FilterScopeField:
+		return column, fieldType, nil
+	case isNumber(fieldType):
+		return fmt.Sprintf("COALESCE(CAST(%s AS numeric), 0)", column), fieldType, nil
+	}
+	return fmt.Sprintf("COALESCE(%s, '')", column), fieldType, nil
+}
+
+// sortValue returns the argument of a value of a sort key, from a continue token
+func (q *listQuery) sortValue(key SortKey, fieldType reflect.Type, raw json.RawMessage) (string, error) {
+	switch {
+	case key.Scope == FilterScopeField:
+		v := reflect.New(fieldType)
+		if err := json.Unmarshal(raw, v.Interface()); err != nil {
+			return "", errors.Wrap(err, "invalid continue token")
+		}
+		return q.arg(v.Elem().Interface()), nil
+	case isNumber(fieldType):
+		var n json.Number
+		if err := json.Unmarshal(raw, &n); err != nil {
+			return "", errors.Wrap(err, "invalid continue token")
+		}
+		return q.arg(n.String()), nil
+	}
+	return q.arg(jsonText(raw)), nil
+}
+
+// order returns the sort order and the condition of the entities following the continue token, if any
+func (q *listQuery) order(opts Options) (orderBy string, after string, err error) {
+	var token *continueToken
+	if opts.Continue != "" {
+		if token, err = parseContinueToken(opts); err != nil {
+			return "", "", err
+		}
+	}
+
+	var columns, equals, alternatives []string
+	for i, key := range opts.SortBy {
+		column, fieldType, err := q.sortColumn(key)
+		if err != nil {
+			return "", "", err
+		}
+		direction, operator := "ASC", ">"
+		if key.Descending {
+			direction, operator = "DESC", "<"
+		}
+		columns = append(columns, fmt.Sprintf("%s %s", column, direction))
+		if token == nil {
+			continue
+		}
+		value, err := q.sortValue(key, fieldType, token.Values[i])
+		if err != nil {
+			return "", "", err
+		}
+		alternatives = append(alternatives, strings.Join(append(equals, fmt.Sprintf("%s %s %s", column, operator, value)), " AND "))
+		equals = append(equals, fmt.Sprintf("%s = %s", column, value))
+	}
+	columns = append(columns, "name ASC")
+	orderBy = strings.Join(columns, ", ")
+	if token != nil {
+		alternatives = append(alternatives, strings.Join(append(equals, fmt.Sprintf("name > %s", q.arg(token.Name))), " AND "))
+		after = fmt.Sprintf("((%s))", strings.Join(alternatives, ") OR ("))
+	}
+	return orderBy, after, nil
+}
+
+func makeListQuery(organizationID string, opts Options, entityType reflect.Type) (sql string, args []interface{}, err error) {
 
-	sql = ""
-	argsMap := map[string]interface{}{
-		"organization_id": organizationID,
-		"type":            dataType(entityType.Name()),
+	q := &listQuery{
+		entityType: entityType,
+		args: map[string]interface{}{
+			"organization_id": organizationID,
+			"type":            dataType(entityType.Name()),
+		},
 	}
 	where := []string{
 		"organization_id = :organization_id",
 		"type = :type",
 	}
-	if filter != nil {
-		for _, fs := range filter.FilterStats() {
-			column := ""
-			object := ""
-			switch fs.Scope {
-			case FilterScopeField:
-				field, ok := reflect.TypeOf(dbEntity{}).FieldByName(fs.Subject)
-				if !ok {
-					err = errors.Errorf("error listing: no such field: %s", fs.Subject)
-					return
-				}
-				// find the column name by struct tag
-				column = field.Tag.Get("db")
-				object = column
-			case FilterScopeTag:
-				object = fmt.Sprintf("tag_%s", fs.Subject)
-				column = fmt.Sprintf("tags->>'%s'", fs.Subject)
-			case FilterScopeExtra:
-				field, ok := entityType.FieldByName(fs.Subject)
-				if !ok {
-					err = errors.Errorf("error listing: no such extra field: %s", fs.Subject)
-					return
-				}
-				// remove the "omitempty"
-				object = strings.Split(field.Tag.Get("json"), ",")[0]
-				// the value is inside the JSONB field 'value'
-				column = fmt.Sprintf("value->>'%s'", object)
-			}
-			argsMap[object] = fs.Object
-
-			switch fs.Verb {
-			case FilterVerbEqual:
-				where = append(where, fmt.Sprintf("%s = :%s", column, object))
-			case FilterVerbIn:
-				where = append(where, fmt.Sprintf("%s IN (:%s)", column, object))
-			case FilterVerbBefore:
-				where = append(where, fmt.Sprintf("%s < :%s", column, object))
-			case FilterVerbAfter:
-				where = append(where, fmt.Sprintf("%s > :%s", column, object))
-			default:
-				err = errors.Errorf("error listing: invalid filter")
-				return
-			}
+	if opts.Filter != nil {
+		condition, err := q.condition(opts.Filter)
+		if err != nil {
+			return "", nil, err
 		}
+		where = append(where, condition)
 	}
-	sql = fmt.Sprintf("SELECT * FROM entity WHERE %s", strings.Join(where, " AND "))
-	sql, args, err = sqlx.Named(sql, argsMap)
+	orderBy, after, err := q.order(opts)
+	if err != nil {
+		return "", nil, err
+	}
+	if after != "" {
+		where = append(where, after)
+	}
+	sql = fmt.Sprintf("SELECT * FROM entity WHERE %s ORDER BY %s", strings.Join(where, " AND "), orderBy)
+	if opts.Limit > 0 {
+		sql = fmt.Sprintf("%s LIMIT %d", sql, opts.Limit)
+	}
+	sql, args, err = sqlx.Named(sql, q.args)
 	if err != nil {
 		err = errors.Wrap(err, "error making sql query: sqlx.Named")
 		return
@@ -412,7 +632,7 @@ func (p *postgresEntityStore) List(organizationID string, opts Options, entities
 		return errors.New("non-entity element type: maybe use pointers")
 	}
 
-	sql, args, err := makeListQuery(organizationID, opts.Filter, entityPtrType.Elem())
+	sql, args, err := makeListQuery(organizationID, opts, entityPtrType.Elem())
 	if err != nil {
 		return errors.Wrap(err, "error makeListQuery")
 	}
@@ -477,3 +697,111 @@ func (p *postgresEntityStore) UpdateWithError(e Entity, err error) {
 	}
 	return
 }
+
+// entityChange is the payload of the notification of a change of an entity
+type entityChange struct {
+	Op             string         `json:"op"`
+	ID             string         `json:"id"`
+	Name           string         `json:"name"`
+	Type           string         `json:"type"`
+	OrganizationID string         `json:"organization_id"`
+	Revision       uint64         `json:"revision"`
+	Status         Status         `json:"status"`
+	Tags           Tags           `json:"tags"`
+	Value          types.JSONText `json:"value"`
+}
+
+// Watch streams the changes of the entities of a single data type, notified by the trigger of the entity table
+func (p *postgresEntityStore) Watch(organizationID string, entityType reflect.Type, filter Filter, stopCh <-chan struct{}) (<-chan Event, error) {
+	if _, err := newEntity(entityType); err != nil {
+		return nil, err
+	}
+	listener := pq.NewListener(p.connInfo, 10*time.Second, time.Minute, func(ev pq.ListenerEventType, err error) {
+		if err != nil {
+			log.Warnf("postgres entity watch: %v", err)
+		}
+	})
+	if err := listener.Listen(notifyChannel); err != nil {
+		listener.Close()
+		return nil, errors.Wrap(err, "error listening to entity changes")
+	}
+
+	events := make(chan Event)
+	go func() {
+		defer close(events)
+		defer listener.Close()
+		p.forwardChanges(listener.Notify, events, organizationID, entityType, filter, stopCh)
+	}()
+	return events, nil
+}
+
+// forwardChanges sends the events of the notified changes of the watched entities, until stopCh is closed. It returns
+// when the connection is lost, even if the listener re-establishes it, as changes may have been missed meanwhile.
+func (p *postgresEntityStore) forwardChanges(notify <-chan *pq.Notification, events chan<- Event, organizationID string, entityType reflect.Type, filter Filter, stopCh <-chan struct{}) {
+	dt := entityType.Elem().Name()
+	for {
+		var n *pq.Notification
+		select {
+		case <-stopCh:
+			return
+		case n = <-notify:
+		}
+		if n == nil {
+			log.Warnf("postgres entity watch: connection lost, changes may have been missed")
+			return
+		}
+		var change entityChange
+		if err := json.Unmarshal([]byte(n.Extra), &change); err != nil {
+			log.Errorf("postgres entity watch: invalid notification %s: %v", n.Extra, err)
+			continue
+		}
+		if change.Type != dt || change.OrganizationID != organizationID {
+			continue
+		}
+		event, ok := p.changeEvent(&change, entityType)
+		if !ok {
+			continue
+		}
+		if filter != nil {
+			if ok, err := doFilter(filter, event.Entity); err != nil || !ok {
+				continue
+			}
+		}
+		select {
+		case events <- event:
+		case <-stopCh:
+			return
+		}
+	}
+}
+
+// changeEvent returns the event of a notified change. It returns false if the entity changed again meanwhile, the
+// later change being notified as well.
+func (p *postgresEntityStore) changeEvent(change *entityChange, entityType reflect.Type) (Event, bool) {
+	entity, _ := newEntity(entityType)
+	if change.Op == "DELETE" {
+		// the last state of the entity, which is missing if the value was too large to be notified
+		if len(change.Value) > 0 && string(change.Value) != "null" {
+			if err := change.Value.Unmarshal(entity); err != nil {
+				log.Errorf("postgres entity watch: invalid value of %s: %v", change.Name, err)
+			}
+		}
+		entity.SetStatus(change.Status)
+		entity.SetTags(change.Tags)
+		entity.setID(change.ID)
+		entity.setName(change.Name)
+		entity.setOrganizationID(change.OrganizationID)
+		entity.setRevision(change.Revision)
+		return Event{Type: EventDeleted, Entity: entity}, true
+	}
+	if err := p.Get(change.OrganizationID, change.Name, Options{}, entity); err != nil {
+		return Event{}, false
+	}
+	if entity.GetRevision() != change.Revision {
+		return Event{}, false
+	}
+	if change.Op == "INSERT" {
+		return Event{Type: EventAdded, Entity: entity}, true
+	}
+	return Event{Type: EventUpdated, Entity: entity}, true
+}
@@ -0,0 +1,36 @@
+///////////////////////////////////////////////////////////////////////
+// Copyright (c) 2018 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+///////////////////////////////////////////////////////////////////////
+
+package entitystore
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+func TestPostgresForwardChangesConnectionLost(t *testing.T) {
+	p := &postgresEntityStore{}
+	notify := make(chan *pq.Notification, 1)
+	events := make(chan Event)
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		p.forwardChanges(notify, events, "testOrg", reflect.TypeOf(&BaseEntity{}), nil, stopCh)
+	}()
+
+	// the listener sends nil once it re-established the connection
+	notify <- nil
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("the watch did not end when the connection was lost")
+	}
+}
@@ -0,0 +1,111 @@
+///////////////////////////////////////////////////////////////////////
+// Copyright (c) 2018 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+///////////////////////////////////////////////////////////////////////
+
+// +build integration
+
+package entitystore
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"os"
+	"os/exec"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// The conformance tests of the etcd and Consul backends against real servers, started from the etcd and consul
+// binaries found in the PATH. Run them with "go test -tags integration".
+
+// freePort returns a local port nobody listens to
+func freePort(t *testing.T) int {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer l.Close()
+	return l.Addr().(*net.TCPAddr).Port
+}
+
+// startServer runs a server until the returned function is called, once ready answers with 200 at readyURL
+func startServer(t *testing.T, readyURL string, name string, args ...string) func() {
+	path, err := exec.LookPath(name)
+	if err != nil {
+		t.Skipf("%s not found in PATH", name)
+	}
+	cmd := exec.Command(path, args...)
+	require.NoError(t, cmd.Start(), "Cannot start %s", name)
+	stop := func() {
+		cmd.Process.Kill()
+		cmd.Wait()
+	}
+
+	deadline := time.Now().Add(30 * time.Second)
+	for time.Now().Before(deadline) {
+		resp, err := http.Get(readyURL)
+		if err == nil {
+			body, _ := ioutil.ReadAll(resp.Body)
+			resp.Body.Close()
+			// the consul leader is empty until elected
+			if resp.StatusCode == http.StatusOK && string(body) != `""` {
+				return stop
+			}
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	stop()
+	t.Fatalf("%s is not ready", name)
+	return nil
+}
+
+func TestEtcdServerEntityStore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "etcd")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	clientURL := fmt.Sprintf("http://127.0.0.1:%d", freePort(t))
+	peerURL := fmt.Sprintf("http://127.0.0.1:%d", freePort(t))
+	stop := startServer(t, clientURL+"/health", "etcd",
+		"--data-dir", dir,
+		"--listen-client-urls", clientURL,
+		"--advertise-client-urls", clientURL,
+		"--listen-peer-urls", peerURL,
+		"--initial-advertise-peer-urls", peerURL,
+		"--initial-cluster", "default="+peerURL,
+	)
+	defer stop()
+
+	es, err := NewFromBackend(BackendConfig{
+		Backend: "etcd",
+		Address: clientURL,
+		Bucket:  "test",
+	})
+	require.NoError(t, err, "Cannot create store")
+	testConformance(t, es)
+}
+
+func TestConsulServerEntityStore(t *testing.T) {
+	httpPort := freePort(t)
+	stop := startServer(t, fmt.Sprintf("http://127.0.0.1:%d/v1/status/leader", httpPort), "consul",
+		"agent", "-dev",
+		"-bind", "127.0.0.1",
+		"-http-port", fmt.Sprint(httpPort),
+		"-dns-port", "-1",
+		"-server-port", fmt.Sprint(freePort(t)),
+		"-serf-lan-port", fmt.Sprint(freePort(t)),
+		"-serf-wan-port", fmt.Sprint(freePort(t)),
+	)
+	defer stop()
+
+	es, err := NewFromBackend(BackendConfig{
+		Backend: "consul",
+		Address: fmt.Sprintf("127.0.0.1:%d", httpPort),
+		Bucket:  "test",
+	})
+	require.NoError(t, err, "Cannot create store")
+	testConformance(t, es)
+}
@@ -16,6 +16,9 @@ import (
 	"github.com/docker/libkv/store"
 	"github.com/docker/libkv/store/boltdb"
 	"github.com/pkg/errors"
+
+	"github.com/vmware/dispatch/pkg/entity-store/consul"
+	"github.com/vmware/dispatch/pkg/entity-store/etcd"
 )
 
 const (
@@ -31,7 +34,8 @@ const (
 
 	// StatusINTRANSIT object is currently being processed
 	// and should NOT be picked up by periodic sync
-	// TODO(imikushin) GC stale inflight objects - in case responsible process terminates (e.g. using process UUIDs)
+	// with a leader election, the controllers claim the objects they process with this status and their replica ID,
+	// so that the objects of a replica which terminates are taken over by the next leader
 	StatusINTRANSIT Status = "INTRANSIT"
 
 	// StatusREADY object is READY to be used
@@ -60,6 +64,9 @@ const (
 	// StatusMISSING temporary error state
 	// Used when external resources cannot be found
 	StatusMISSING Status = "MISSING"
+
+	// StatusTIMEDOUT object (e.g. function run) did not complete in time
+	StatusTIMEDOUT Status = "TIMEDOUT"
 )
 
 // Status represents the current state
@@ -273,6 +280,40 @@ type EntityStore interface {
 	// UpdateWithError is used by entity handlers to save changes and/or error status
 	// e.g. `defer func() { h.store.UpdateWithError(e, err) }()`
 	UpdateWithError(e Entity, err error)
+	// Watch streams the changes of the entities of a single data type satisfying the filter, until stopCh is closed.
+	// entityType is the pointer type of the entities, e.g. reflect.TypeOf(&BaseEntity{}). The channel is closed when
+	// the watch ends, e.g. when the connection to the backend is lost.
+	Watch(organizationID string, entityType reflect.Type, filter Filter, stopCh <-chan struct{}) (<-chan Event, error)
+}
+
+// EventType is the type of a change of an entity
+type EventType string
+
+const (
+	// EventAdded an entity was added
+	EventAdded EventType = "ADDED"
+	// EventUpdated an entity was updated
+	EventUpdated EventType = "UPDATED"
+	// EventDeleted an entity was deleted
+	EventDeleted EventType = "DELETED"
+)
+
+// Event is a change of an entity. The entity of a deletion is its last known state, which may only have its ID,
+// name and organization set.
+type Event struct {
+	Type   EventType
+	Entity Entity
+}
+
+// ErrWatchNotSupported is returned by Watch when the backend cannot stream changes, e.g. boltdb
+var ErrWatchNotSupported = errors.New("the entity store backend does not support watching changes")
+
+// newEntity returns a new entity of a pointer type, which must implement Entity
+func newEntity(entityType reflect.Type) (Entity, error) {
+	if entityType == nil || entityType.Kind() != reflect.Ptr || !entityType.Implements(reflect.TypeOf((*Entity)(nil)).Elem()) {
+		return nil, errors.Errorf("non-entity type %v: maybe use pointers", entityType)
+	}
+	return reflect.New(entityType.Elem()).Interface().(Entity), nil
 }
 
 type uniqueViolation interface {
@@ -305,11 +346,18 @@ func NewFromBackend(config BackendConfig) (EntityStore, error) {
 		}
 		return es, nil
 
-	case string(store.BOLTDB):
+	case string(store.BOLTDB), string(store.ETCD), string(store.CONSUL):
 		boltdb.Register()
+		etcd.Register()
+		consul.Register()
+		// etcd and Consul clusters may have several endpoints
+		addrs := []string{config.Address}
+		if config.Backend != string(store.BOLTDB) {
+			addrs = strings.Split(config.Address, ",")
+		}
 		kv, err := libkv.NewStore(
 			store.Backend(config.Backend),
-			[]string{config.Address},
+			addrs,
 			&store.Config{
 				Bucket:            config.Bucket,
 				ConnectionTimeout: 1 * time.Second,
@@ -53,15 +53,7 @@ func TestPostgresEntityStore(t *testing.T) {
 	es, err := NewFromBackend(postgresConfig)
 	assert.NoError(t, err, "Cannot connect to postgres DB")
 
-	testGet(t, es)
-	testAdd(t, es)
-	testPut(t, es)
-	testList(t, es)
-	testListWithFilter(t, es)
-	testListWithFilterOnTags(t, es)
-	testDelete(t, es)
-	testInvalidNames(t, es)
-	testMixedTypes(t, es)
+	testConformance(t, es)
 }
 
 func TestLibkvEntityStore(t *testing.T) {
@@ -78,19 +70,46 @@ func TestLibkvEntityStore(t *testing.T) {
 	es, err := NewFromBackend(libkvConfig)
 	assert.NoError(t, err, "Cannot create store")
 
-	testGet(t, es)
-	testAdd(t, es)
-	testPut(t, es)
-	testList(t, es)
-	testListWithFilter(t, es)
-	testListWithFilterOnTags(t, es)
-	testDelete(t, es)
-	testInvalidNames(t, es)
-	testMixedTypes(t, es)
+	testConformance(t, es)
 
 	os.Remove(file.Name())
 }
 
+func TestLibkvEntityStoreListPage(t *testing.T) {
+
+	file, err := ioutil.TempFile(os.TempDir(), "test")
+	require.NoError(t, err, "Cannot create temp file")
+	defer os.Remove(file.Name())
+
+	es, err := NewFromBackend(BackendConfig{
+		Backend: "boltdb",
+		Address: file.Name(),
+		Bucket:  "test",
+	})
+	require.NoError(t, err, "Cannot create store")
+
+	for _, name := range []string{"page-a", "page-b", "page-c", "page-d"} {
+		_, err = es.Add(&testEntity{BaseEntity: BaseEntity{OrganizationID: "testOrg", Name: name}, Value: name})
+		require.NoError(t, err)
+	}
+	// the entities before the continue token and past the limit are not decoded
+	kv := es.(*libkvEntityStore).kv
+	for _, name := range []string{"page-a", "page-d"} {
+		require.NoError(t, kv.Put(buildKey("testOrg", dataType("testEntity"), name), []byte("invalid"), nil))
+	}
+
+	// the token of a first page of one entity
+	continueToken, err := ContinueToken(Options{Limit: 1}, []*testEntity{{BaseEntity: BaseEntity{Name: "page-a"}}})
+	require.NoError(t, err)
+	opts := Options{Limit: 2, Continue: continueToken}
+
+	var entities []*testEntity
+	require.NoError(t, es.List("testOrg", opts, &entities))
+	require.Len(t, entities, 2)
+	assert.Equal(t, "page-b", entities[0].Value)
+	assert.Equal(t, "page-c", entities[1].Value)
+}
+
 func testGet(t *testing.T, es EntityStore) {
 
 	e := &testEntity{
@@ -513,3 +532,39 @@ func testDelete(t *testing.T, es EntityStore) {
 	err = es.Get("testOrg", "testEntityDelete", Options{}, &retreived)
 	assert.Error(t, err)
 }
+
+func TestMakeListQuery(t *testing.T) {
+	sortBy, err := ParseSortKeys([]string{"-createdTime", "tags.rank"})
+	require.NoError(t, err)
+	opts := Options{
+		Filter: FilterEverything().Add(
+			FilterStat{Scope: FilterScopeField, Subject: "Name", Verb: FilterVerbPrefix, Object: "a_b"},
+		).Or(
+			FilterEverything().Add(FilterStat{Scope: FilterScopeTag, Subject: "app", Verb: FilterVerbExists, Object: false}),
+			FilterEverything().Add(FilterStat{Scope: FilterScopeExtra, Subject: "Value", Verb: FilterVerbNotEqual, Object: "x"}),
+		),
+		SortBy: sortBy,
+		Limit:  10,
+	}
+	entities := []*testEntity{{BaseEntity: BaseEntity{Name: "last", CreatedTime: time.Unix(1000, 0).UTC(), Tags: Tags{"rank": "2"}}}}
+	opts.Continue, err = ContinueToken(Options{SortBy: sortBy, Limit: 1}, entities)
+	require.NoError(t, err)
+
+	sql, args, err := makeListQuery("testOrg", opts, reflect.TypeOf(testEntity{}))
+	require.NoError(t, err)
+	assert.Equal(t, "SELECT * FROM entity WHERE organization_id = ? AND type = ? AND "+
+		"(name LIKE ? AND (FALSE OR (NOT (tags->>CAST(? AS text) IS NOT NULL)) OR (value->>'value' IS DISTINCT FROM ?))) AND "+
+		"((created_time < ?) OR "+
+		"(created_time = ? AND COALESCE(tags->>CAST(? AS text), '') > ?) OR "+
+		"(created_time = ? AND COALESCE(tags->>CAST(? AS text), '') = ? AND name > ?)) "+
+		"ORDER BY created_time DESC, COALESCE(tags->>CAST(? AS text), '') ASC, name ASC LIMIT 10", sql)
+	last := time.Unix(1000, 0).UTC()
+	assert.Equal(t, []interface{}{
+		"testOrg", dataType("testEntity"),
+		`a\_b%`, "app", "x",
+		last,
+		last, "rank", "2",
+		last, "rank", "2", "last",
+		"rank",
+	}, args)
+}
@@ -26,6 +26,7 @@ type EventControllerConfig struct {
 	ResyncPeriod   time.Duration
 	OrganizationID string
 	WorkerNumber   int
+	Elector        controller.LeaderElector
 }
 
 // NewEventController creates a new controller to manage the reconciliation of event manager entities
@@ -44,6 +45,8 @@ func NewEventController(manager subscriptions.Manager, backend drivers.Backend,
 		OrganizationID: config.OrganizationID,
 		ResyncPeriod:   config.ResyncPeriod,
 		Workers:        config.WorkerNumber,
+		Store:          store,
+		Elector:        config.Elector,
 	})
 
 	c.AddEntityHandler(drivers.NewEntityHandler(store, backend))
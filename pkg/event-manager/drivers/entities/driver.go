@@ -38,6 +38,7 @@ func (d *Driver) ToModel() *models.Driver {
 		Type:         swag.String(d.Type),
 		Config:       mconfig,
 		Status:       models.Status(d.Status),
+		Reason:       d.Reason,
 		CreatedTime:  d.CreatedTime.Unix(),
 		ModifiedTime: d.ModifiedTime.Unix(),
 		Secrets:      d.Secrets,
@@ -53,17 +53,30 @@ func (h *EntityHandler) Add(obj entitystore.Entity) (err error) {
 	}
 
 	driver.Status = entitystore.StatusREADY
+	driver.Reason = nil
 
 	log.Infof("%s-driver %s has been deployed on k8s", driver.Type, driver.Name)
 
 	return nil
 }
 
-// Update updates the driver by executing EntityHandler.Add()
-func (h *EntityHandler) Update(obj entitystore.Entity) error {
-	defer trace.Trace("")()
+// Update rolls out the updated driver to the backend
+func (h *EntityHandler) Update(obj entitystore.Entity) (err error) {
+	defer trace.Tracef("name %s", obj.GetName())()
+
+	driver := obj.(*entities.Driver)
+	defer func() { h.store.UpdateWithError(driver, err) }()
+
+	if err := h.backend.Update(driver); err != nil {
+		return ewrapper.Wrap(err, "error updating driver")
+	}
+
+	driver.Status = entitystore.StatusREADY
+	driver.Reason = nil
 
-	return h.Add(obj)
+	log.Infof("%s-driver %s has been updated on k8s", driver.Type, driver.Name)
+
+	return nil
 }
 
 // Delete deletes the driver from the backend
@@ -85,11 +98,54 @@ func (h *EntityHandler) Delete(obj entitystore.Entity) error {
 	return nil
 }
 
-// Sync Executes sync loop
+// Sync Executes sync loop. Besides drivers in transition, it checks the health of deployed drivers: status changes
+// are saved in the store, and drivers whose deployment is missing are returned, so that they are deployed again.
 func (h *EntityHandler) Sync(organizationID string, resyncPeriod time.Duration) ([]entitystore.Entity, error) {
 	defer trace.Trace("")()
 
-	return controller.DefaultSync(h.store, h.Type(), organizationID, resyncPeriod, nil)
+	result, err := controller.DefaultSync(h.store, h.Type(), organizationID, resyncPeriod, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	opts := entitystore.Options{
+		Filter: entitystore.FilterEverything().Add(
+			entitystore.FilterStat{
+				Scope:   entitystore.FilterScopeField,
+				Subject: "Status",
+				Verb:    entitystore.FilterVerbIn,
+				Object:  []entitystore.Status{entitystore.StatusREADY, entitystore.StatusERROR},
+			}),
+	}
+	var drivers []*entities.Driver
+	if err := h.store.List(organizationID, opts, &drivers); err != nil {
+		return nil, ewrapper.Wrap(err, "store error when listing drivers")
+	}
+	for _, driver := range drivers {
+		if driver.Delete {
+			continue
+		}
+		status, reason, err := h.backend.Status(driver)
+		if err != nil {
+			log.Warnf("error checking status of driver %s: %+v", driver.Name, err)
+			continue
+		}
+		if status == driver.Status && reflect.DeepEqual([]string(reason), []string(driver.Reason)) {
+			continue
+		}
+		log.Infof("driver %s changed status from %s to %s: %v", driver.Name, driver.Status, status, reason)
+		driver.Status = status
+		driver.Reason = reason
+		if status == entitystore.StatusMISSING {
+			// handled by Add, which deploys the driver again
+			result = append(result, driver)
+			continue
+		}
+		if _, err := h.store.Update(driver.Revision, driver); err != nil {
+			log.Errorf("store error when updating status of driver %s: %+v", driver.Name, err)
+		}
+	}
+	return result, nil
 }
 
 // Error handles error state
@@ -59,3 +59,75 @@ func TestDriverDelete(t *testing.T) {
 	es.List("", entitystore.Options{}, drivers)
 	assert.Len(t, drivers, 0)
 }
+
+func TestDriverUpdate(t *testing.T) {
+	backend := &mocks.Backend{}
+	es := helpers.MakeEntityStore(t)
+	handler := mockDriverHandler(backend, es)
+	driver := &entities.Driver{
+		BaseEntity: entitystore.BaseEntity{
+			Name:   "driver1",
+			Status: entitystore.StatusUPDATING,
+		},
+		Type: "vcenter",
+	}
+	es.Add(driver)
+	backend.On("Update", mock.Anything).Return(nil)
+	assert.NoError(t, handler.Update(driver))
+	backend.AssertNotCalled(t, "Deploy", mock.Anything)
+
+	var stored entities.Driver
+	assert.NoError(t, es.Get("", "driver1", entitystore.Options{}, &stored))
+	assert.Equal(t, entitystore.StatusREADY, stored.Status)
+}
+
+func TestDriverSync(t *testing.T) {
+	backend := &mocks.Backend{}
+	es := helpers.MakeEntityStore(t)
+	handler := mockDriverHandler(backend, es)
+	for _, name := range []string{"healthy", "failing", "missing", "recovered"} {
+		driver := &entities.Driver{
+			BaseEntity: entitystore.BaseEntity{
+				Name:   name,
+				Status: entitystore.StatusREADY,
+			},
+			Type: "vcenter",
+		}
+		if name == "recovered" {
+			driver.Status = entitystore.StatusERROR
+			driver.Reason = entitystore.Reason{"container driver: CrashLoopBackOff"}
+		}
+		_, err := es.Add(driver)
+		assert.NoError(t, err)
+	}
+	named := func(name string) interface{} {
+		return mock.MatchedBy(func(d *entities.Driver) bool { return d.Name == name })
+	}
+	backend.On("Status", named("healthy")).Return(entitystore.StatusREADY, nil, nil)
+	backend.On("Status", named("failing")).Return(entitystore.StatusERROR, entitystore.Reason{"container driver: ErrImagePull"}, nil)
+	backend.On("Status", named("missing")).Return(entitystore.StatusMISSING, entitystore.Reason{"deployment not found"}, nil)
+	backend.On("Status", named("recovered")).Return(entitystore.StatusREADY, nil, nil)
+
+	result, err := handler.Sync("", 0)
+	assert.NoError(t, err)
+	assert.Len(t, result, 1)
+	assert.Equal(t, "missing", result[0].GetName())
+	assert.Equal(t, entitystore.StatusMISSING, result[0].GetStatus())
+
+	var stored entities.Driver
+	assert.NoError(t, es.Get("", "failing", entitystore.Options{}, &stored))
+	assert.Equal(t, entitystore.StatusERROR, stored.Status)
+	assert.Equal(t, entitystore.Reason{"container driver: ErrImagePull"}, stored.Reason)
+
+	stored = entities.Driver{}
+	assert.NoError(t, es.Get("", "recovered", entitystore.Options{}, &stored))
+	assert.Equal(t, entitystore.StatusREADY, stored.Status)
+	assert.Empty(t, stored.Reason)
+
+	backend.On("Deploy", named("missing")).Return(nil)
+	assert.NoError(t, handler.Add(result[0]))
+	stored = entities.Driver{}
+	assert.NoError(t, es.Get("", "missing", entitystore.Options{}, &stored))
+	assert.Equal(t, entitystore.StatusREADY, stored.Status)
+	assert.Empty(t, stored.Reason)
+}
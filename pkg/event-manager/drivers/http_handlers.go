@@ -47,6 +47,7 @@ type ConfigOpts struct {
 	SidecarImage    string
 	TransportType   string
 	RabbitMQURL     string
+	KafkaBrokers    []string
 	TracerURL       string
 	K8sConfig       string
 	DriverNamespace string
@@ -74,6 +75,7 @@ func (h *Handlers) ConfigureHandlers(api middleware.RoutableAPI) {
 	a.DriversAddDriverHandler = driverapi.AddDriverHandlerFunc(h.addDriver)
 	a.DriversGetDriverHandler = driverapi.GetDriverHandlerFunc(h.getDriver)
 	a.DriversGetDriversHandler = driverapi.GetDriversHandlerFunc(h.getDrivers)
+	a.DriversUpdateDriverHandler = driverapi.UpdateDriverHandlerFunc(h.updateDriver)
 	a.DriversDeleteDriverHandler = driverapi.DeleteDriverHandlerFunc(h.deleteDriver)
 	a.DriversAddDriverTypeHandler = driverapi.AddDriverTypeHandlerFunc(h.addDriverType)
 	a.DriversGetDriverTypeHandler = driverapi.GetDriverTypeHandlerFunc(h.getDriverType)
@@ -184,6 +186,10 @@ func (h *Handlers) getDrivers(params driverapi.GetDriversParams, principal inter
 	var drivers []*entities.Driver
 
 	filter, err := utils.ParseTags(entitystore.FilterEverything(), params.Tags)
+	opts := entitystore.Options{Filter: filter}
+	if err == nil {
+		err = utils.ParsePage(&opts, params.Limit, params.Continue, params.SortBy)
+	}
 	if err != nil {
 		log.Errorf(err.Error())
 		return driverapi.NewDeleteDriverBadRequest().WithPayload(
@@ -192,10 +198,9 @@ func (h *Handlers) getDrivers(params driverapi.GetDriversParams, principal inter
 				Message: swag.String(err.Error()),
 			})
 	}
-	opts := entitystore.Options{Filter: filter}
 
 	// delete filter
-	err = h.store.List(h.config.OrgID, opts, &drivers)
+	next, err := utils.ListPage(h.store, h.config.OrgID, opts, &drivers)
 	if err != nil {
 		log.Errorf("store error when listing drivers: %+v", err)
 		return driverapi.NewGetDriverDefault(http.StatusInternalServerError).WithPayload(
@@ -208,7 +213,83 @@ func (h *Handlers) getDrivers(params driverapi.GetDriversParams, principal inter
 	for _, driver := range drivers {
 		driverModels = append(driverModels, driver.ToModel())
 	}
-	return driverapi.NewGetDriversOK().WithPayload(driverModels)
+	return driverapi.NewGetDriversOK().WithXContinue(next).WithPayload(driverModels)
+}
+
+func (h *Handlers) updateDriver(params driverapi.UpdateDriverParams, principal interface{}) middleware.Responder {
+	defer trace.Tracef("name '%s'", params.DriverName)()
+
+	sp, _ := utils.AddHTTPTracing(params.HTTPRequest, "EventManager.updateDriver")
+	defer sp.Finish()
+
+	if err := params.Body.Validate(strfmt.Default); err != nil {
+		return driverapi.NewUpdateDriverBadRequest().WithPayload(&models.Error{
+			Code:    http.StatusBadRequest,
+			Message: swag.String(fmt.Sprintf("invalid event driver payload: %s", err)),
+		})
+	}
+	if *params.Body.Name != params.DriverName {
+		return driverapi.NewUpdateDriverBadRequest().WithPayload(&models.Error{
+			Code:    http.StatusBadRequest,
+			Message: swag.String(fmt.Sprintf("driver name %s does not match %s", *params.Body.Name, params.DriverName)),
+		})
+	}
+
+	filter, err := utils.ParseTags(entitystore.FilterEverything(), params.Tags)
+	if err != nil {
+		log.Error(err)
+		return driverapi.NewUpdateDriverBadRequest().WithPayload(
+			&models.Error{
+				Code:    http.StatusBadRequest,
+				Message: swag.String(err.Error()),
+			})
+	}
+	opts := entitystore.Options{Filter: filter}
+
+	d := &entities.Driver{}
+	if err = h.store.Get(h.config.OrgID, params.DriverName, opts, d); err != nil {
+		log.Debugf("store error when getting driver: %+v", err)
+		return driverapi.NewUpdateDriverNotFound().WithPayload(
+			&models.Error{
+				Code:    http.StatusNotFound,
+				Message: swag.String(fmt.Sprintf("driver %s not found", params.DriverName)),
+			})
+	}
+
+	updated := &entities.Driver{}
+	updated.FromModel(params.Body, h.config.OrgID)
+	if updated.Type != d.Type {
+		return driverapi.NewUpdateDriverBadRequest().WithPayload(&models.Error{
+			Code:    http.StatusBadRequest,
+			Message: swag.String(fmt.Sprintf("driver type cannot be changed from %s to %s", d.Type, updated.Type)),
+		})
+	}
+	d.Config = updated.Config
+	d.Secrets = updated.Secrets
+	d.Tags = updated.Tags
+
+	if err := h.validateEventDriver(d); err != nil {
+		log.Error(err)
+		return driverapi.NewUpdateDriverBadRequest().WithPayload(&models.Error{
+			Code:    http.StatusBadRequest,
+			Message: swag.String(fmt.Sprintf("invalid event driver type or configuration: %s", err)),
+		})
+	}
+
+	d.Status = entitystore.StatusUPDATING
+	if _, err = h.store.Update(d.Revision, d); err != nil {
+		log.Errorf("store error when updating the event driver %s: %+v", d.Name, err)
+		return driverapi.NewUpdateDriverInternalServerError().WithPayload(&models.Error{
+			Code:    http.StatusInternalServerError,
+			Message: swag.String("internal server error when updating an event driver"),
+		})
+	}
+	if h.watcher != nil {
+		h.watcher.OnAction(d)
+	} else {
+		log.Debugf("note: the watcher is nil")
+	}
+	return driverapi.NewUpdateDriverOK().WithPayload(d.ToModel())
 }
 
 func (h *Handlers) deleteDriver(params driverapi.DeleteDriverParams, principal interface{}) middleware.Responder {
@@ -363,6 +444,10 @@ func (h *Handlers) getDriverTypes(params driverapi.GetDriverTypesParams, princip
 	var driverTypes []*entities.DriverType
 
 	filter, err := utils.ParseTags(entitystore.FilterEverything(), params.Tags)
+	opts := entitystore.Options{Filter: filter}
+	if err == nil {
+		err = utils.ParsePage(&opts, params.Limit, params.Continue, params.SortBy)
+	}
 	if err != nil {
 		log.Errorf(err.Error())
 		return driverapi.NewGetDriverTypeBadRequest().WithPayload(
@@ -371,10 +456,9 @@ func (h *Handlers) getDriverTypes(params driverapi.GetDriverTypesParams, princip
 				Message: swag.String(err.Error()),
 			})
 	}
-	opts := entitystore.Options{Filter: filter}
 
 	// delete filter
-	err = h.store.List(h.config.OrgID, opts, &driverTypes)
+	next, err := utils.ListPage(h.store, h.config.OrgID, opts, &driverTypes)
 	if err != nil {
 		log.Errorf("store error when listing driver types: %+v", err)
 		return driverapi.NewGetDriverTypesDefault(http.StatusInternalServerError).WithPayload(
@@ -388,6 +472,10 @@ func (h *Handlers) getDriverTypes(params driverapi.GetDriverTypesParams, princip
 		driverTypeModels = append(driverTypeModels, dt.ToModel())
 	}
 	for typeName := range builtInDrivers {
+		if opts.Continue != "" {
+			// built-in driver types are on the first page
+			break
+		}
 		// Include built-in driver types.
 		// TODO: See if there is a better way to handle built-in driver types
 		d := models.DriverType{
@@ -397,7 +485,7 @@ func (h *Handlers) getDriverTypes(params driverapi.GetDriverTypesParams, princip
 		}
 		driverTypeModels = append(driverTypeModels, &d)
 	}
-	return driverapi.NewGetDriverTypesOK().WithPayload(driverTypeModels)
+	return driverapi.NewGetDriverTypesOK().WithXContinue(next).WithPayload(driverTypeModels)
 }
 
 func (h *Handlers) deleteDriverType(params driverapi.DeleteDriverTypeParams, principal interface{}) middleware.Responder {
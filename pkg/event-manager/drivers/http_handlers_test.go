@@ -111,6 +111,46 @@ func TestDriversAddDriverHandler(t *testing.T) {
 	assert.Equal(t, "vcenter", *respBody.Type)
 }
 
+func TestDriversUpdateDriverHandler(t *testing.T) {
+	api := operations.NewEventManagerAPI(nil)
+	es := helpers.MakeEntityStore(t)
+	h := testHandlers(es)
+	helpers.MakeAPI(t, h.ConfigureHandlers, api)
+
+	addDriverEntity(t, api, "drivername", "vcenter")
+
+	reqBody := &models.Driver{
+		Name: swag.String("drivername"),
+		Type: swag.String("vcenter"),
+		Config: []*models.Config{
+			&models.Config{Key: "vcenterurl", Value: "newurl"},
+			&models.Config{Key: "insecure", Value: "true"},
+		},
+	}
+	r := httptest.NewRequest("PUT", "/v1/event/drivers/drivername", nil)
+	params := drivers.UpdateDriverParams{
+		HTTPRequest: r,
+		DriverName:  "drivername",
+		Body:        reqBody,
+	}
+	var respBody models.Driver
+	helpers.HandlerRequest(t, api.DriversUpdateDriverHandler.Handle(params, "testCookie"), &respBody, 200)
+	assert.Equal(t, models.StatusUPDATING, respBody.Status)
+	assert.Len(t, respBody.Config, 2)
+
+	var errorBody models.Error
+	reqBody.Type = swag.String("custom")
+	helpers.HandlerRequest(t, api.DriversUpdateDriverHandler.Handle(params, "testCookie"), &errorBody, 400)
+
+	reqBody.Type = swag.String("vcenter")
+	reqBody.Config = nil
+	helpers.HandlerRequest(t, api.DriversUpdateDriverHandler.Handle(params, "testCookie"), &errorBody, 400)
+
+	params.DriverName = "missing"
+	reqBody.Name = swag.String("missing")
+	helpers.HandlerRequest(t, api.DriversUpdateDriverHandler.Handle(params, "testCookie"), &errorBody, 404)
+}
+
 func TestDriversGetDriverHandler(t *testing.T) {
 	api := operations.NewEventManagerAPI(nil)
 	es := helpers.MakeEntityStore(t)
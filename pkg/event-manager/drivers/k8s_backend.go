@@ -5,12 +5,11 @@
 
 package drivers
 
-// NO TEST
-
 import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"sort"
 	"strings"
 
 	apiclient "github.com/go-openapi/runtime/client"
@@ -23,10 +22,12 @@ import (
 	"k8s.io/api/extensions/v1beta1"
 	k8serrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/clientcmd"
 
+	"github.com/vmware/dispatch/pkg/entity-store"
 	"github.com/vmware/dispatch/pkg/errors"
 	"github.com/vmware/dispatch/pkg/event-manager/drivers/entities"
 	secretsclient "github.com/vmware/dispatch/pkg/secret-store/gen/client"
@@ -117,15 +118,31 @@ func (k *k8sBackend) makeDeploymentSpec(driver *entities.Driver) (*v1beta1.Deplo
 		},
 		Spec: v1beta1.DeploymentSpec{
 			Replicas: swag.Int32(1),
+			// Keep the old driver running until the new one is up, so that no events are missed during updates
+			Strategy: v1beta1.DeploymentStrategy{
+				Type: v1beta1.RollingUpdateDeploymentStrategyType,
+				RollingUpdate: &v1beta1.RollingUpdateDeployment{
+					MaxUnavailable: &maxUnavailable,
+					MaxSurge:       &maxSurge,
+				},
+			},
 			Template: corev1.PodTemplateSpec{
 				ObjectMeta: metav1.ObjectMeta{
 					Name: fullname,
 					Labels: map[string]string{
-						"app": "event-driver",
+						"app":           "event-driver",
+						driverNameLabel: fullname,
 					},
 				},
 				Spec: corev1.PodSpec{
-					Volumes:        nil,
+					Volumes: []corev1.Volume{
+						{
+							Name: "pipes",
+							VolumeSource: corev1.VolumeSource{
+								EmptyDir: &corev1.EmptyDirVolumeSource{},
+							},
+						},
+					},
 					InitContainers: nil,
 					Containers: []corev1.Container{
 						{
@@ -134,6 +151,7 @@ func (k *k8sBackend) makeDeploymentSpec(driver *entities.Driver) (*v1beta1.Deplo
 							Args:            driverArgs,
 							Env:             buildEnv(inputMap),
 							ImagePullPolicy: corev1.PullIfNotPresent,
+							VolumeMounts:    pipesVolumeMounts,
 						},
 						{
 							Name:            "driver-sidecar",
@@ -141,6 +159,7 @@ func (k *k8sBackend) makeDeploymentSpec(driver *entities.Driver) (*v1beta1.Deplo
 							Env:             k.buildSidecarEnv(driver),
 							Resources:       corev1.ResourceRequirements{},
 							ImagePullPolicy: corev1.PullIfNotPresent,
+							VolumeMounts:    pipesVolumeMounts,
 						},
 					},
 				},
@@ -150,6 +169,22 @@ func (k *k8sBackend) makeDeploymentSpec(driver *entities.Driver) (*v1beta1.Deplo
 	return deploymentSpec, nil
 }
 
+// driverNameLabel selects pods of a single driver, as all driver pods share the app label
+const driverNameLabel = "event-driver-name"
+
+var (
+	maxUnavailable = intstr.FromInt(0)
+	maxSurge       = intstr.FromInt(1)
+)
+
+// pipesVolumeMounts shares named pipes of the sidecar pipe listener with the driver
+var pipesVolumeMounts = []corev1.VolumeMount{
+	{
+		Name:      "pipes",
+		MountPath: "/pipes",
+	},
+}
+
 func (k *k8sBackend) Deploy(driver *entities.Driver) error {
 
 	deploymentSpec, err := k.makeDeploymentSpec(driver)
@@ -238,8 +273,121 @@ func (k *k8sBackend) Delete(driver *entities.Driver) error {
 }
 
 func (k *k8sBackend) Update(driver *entities.Driver) error {
-	// TODO:
-	return fmt.Errorf("driver %s: update not implemented yet", driver.Name)
+
+	fullname := getDriverFullName(driver)
+
+	deploymentSpec, err := k.makeDeploymentSpec(driver)
+	if err != nil {
+		err = &errors.DriverError{
+			Err: ewrapper.Wrapf(err, "k8s: error making a deployment"),
+		}
+		log.Errorln(err)
+		return err
+	}
+
+	deployments := k.clientset.ExtensionsV1beta1().Deployments(k.config.DriverNamespace)
+	deployment, err := deployments.Get(fullname, metav1.GetOptions{})
+	if err != nil {
+		if k8serrors.IsNotFound(err) {
+			log.Warnf("k8s: deployment=%s not found, creating it", fullname)
+			return k.Deploy(driver)
+		}
+		err = &errors.DriverError{
+			Err: ewrapper.Wrapf(err, "k8s: deployment=%s unexpected error", fullname),
+		}
+		log.Errorln(err)
+		return err
+	}
+
+	if !isEventDriver(deployment) {
+		return &errors.DriverError{
+			Err: ewrapper.Errorf("k8s: deployment=%s: updating a NON-event-driver deployment", fullname),
+		}
+	}
+
+	// The pod template changes with config and secrets, which triggers a rolling update of the deployment
+	selector := deployment.Spec.Selector
+	deployment.Spec = deploymentSpec.Spec
+	deployment.Spec.Selector = selector
+	if _, err := deployments.Update(deployment); err != nil {
+		err = &errors.DriverError{
+			Err: ewrapper.Wrapf(err, "k8s: error updating deployment=%s", fullname),
+		}
+		log.Errorln(err)
+		return err
+	}
+
+	log.Debugf("k8s: deployment=%s updated", fullname)
+	return nil
+}
+
+func (k *k8sBackend) Status(driver *entities.Driver) (entitystore.Status, entitystore.Reason, error) {
+
+	fullname := getDriverFullName(driver)
+
+	deployment, err := k.clientset.ExtensionsV1beta1().Deployments(k.config.DriverNamespace).Get(fullname, metav1.GetOptions{})
+	if err != nil {
+		if k8serrors.IsNotFound(err) {
+			return entitystore.StatusMISSING, entitystore.Reason{fmt.Sprintf("deployment %s not found", fullname)}, nil
+		}
+		return "", nil, &errors.DriverError{
+			Err: ewrapper.Wrapf(err, "k8s: deployment=%s unexpected error", fullname),
+		}
+	}
+
+	if !isEventDriver(deployment) {
+		return entitystore.StatusERROR, entitystore.Reason{fmt.Sprintf("deployment %s is not an event driver", fullname)}, nil
+	}
+
+	pods, err := k.clientset.CoreV1().Pods(k.config.DriverNamespace).List(metav1.ListOptions{
+		LabelSelector: fmt.Sprintf("%s=%s", driverNameLabel, fullname),
+	})
+	if err != nil {
+		return "", nil, &errors.DriverError{
+			Err: ewrapper.Wrapf(err, "k8s: error listing pods of deployment=%s", fullname),
+		}
+	}
+
+	if reason := podsFailures(pods.Items); len(reason) > 0 {
+		return entitystore.StatusERROR, reason, nil
+	}
+	return entitystore.StatusREADY, nil, nil
+}
+
+// failingReasons are reasons of waiting containers, from which the container will not recover without intervention
+var failingReasons = map[string]bool{
+	"CrashLoopBackOff":           true,
+	"ErrImagePull":               true,
+	"ImagePullBackOff":           true,
+	"InvalidImageName":           true,
+	"CreateContainerConfigError": true,
+	"CreateContainerError":       true,
+	"RunContainerError":          true,
+}
+
+// podsFailures returns the reasons why pods are failing, or nothing if pods are running or starting
+func podsFailures(pods []corev1.Pod) entitystore.Reason {
+	var reason entitystore.Reason
+	for _, pod := range pods {
+		if pod.DeletionTimestamp != nil {
+			continue
+		}
+		if pod.Status.Phase == corev1.PodFailed {
+			reason = append(reason, fmt.Sprintf("pod %s failed: %s", pod.Name, pod.Status.Message))
+			continue
+		}
+		for _, cond := range pod.Status.Conditions {
+			if cond.Type == corev1.PodScheduled && cond.Status == corev1.ConditionFalse && cond.Reason == corev1.PodReasonUnschedulable {
+				reason = append(reason, fmt.Sprintf("pod %s cannot be scheduled: %s", pod.Name, cond.Message))
+			}
+		}
+		for _, status := range pod.Status.ContainerStatuses {
+			if waiting := status.State.Waiting; waiting != nil && failingReasons[waiting.Reason] {
+				reason = append(reason, fmt.Sprintf("container %s of pod %s: %s: %s", status.Name, pod.Name, waiting.Reason, waiting.Message))
+			}
+		}
+	}
+	return reason
 }
 
 func (k *k8sBackend) getSecrets(secretNames []string) (map[string]string, error) {
@@ -263,10 +411,18 @@ func (k *k8sBackend) getSecrets(secretNames []string) (map[string]string, error)
 
 func (k *k8sBackend) buildSidecarEnv(d *entities.Driver) []corev1.EnvVar {
 	vars := []corev1.EnvVar{
+		{
+			Name:  "DISPATCH_TRANSPORT",
+			Value: k.config.TransportType,
+		},
 		{
 			Name:  "DISPATCH_RABBITMQ_URL",
 			Value: k.config.RabbitMQURL,
 		},
+		{
+			Name:  "DISPATCH_KAFKA_BROKER",
+			Value: strings.Join(k.config.KafkaBrokers, ","),
+		},
 		{
 			Name:  "DISPATCH_TENANT",
 			Value: k.config.OrgID,
@@ -286,12 +442,22 @@ func (k *k8sBackend) buildSidecarEnv(d *entities.Driver) []corev1.EnvVar {
 	return vars
 }
 
+// sortedKeys returns keys of the input in a stable order, so that the deployment spec changes only with the input
+func sortedKeys(input map[string]string) []string {
+	var keys []string
+	for key := range input {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
 func buildEnv(input map[string]string) []corev1.EnvVar {
 	var vars []corev1.EnvVar
-	for key, val := range input {
+	for _, key := range sortedKeys(input) {
 		envVar := corev1.EnvVar{
 			Name:  strings.Replace(strings.ToUpper(key), "-", "_", -1),
-			Value: val,
+			Value: input[key],
 		}
 		vars = append(vars, envVar)
 	}
@@ -300,8 +466,8 @@ func buildEnv(input map[string]string) []corev1.EnvVar {
 
 func buildArgs(input map[string]string) []string {
 	var args []string
-	for key, val := range input {
-		args = append(args, fmt.Sprintf("--%s=%s", key, val))
+	for _, key := range sortedKeys(input) {
+		args = append(args, fmt.Sprintf("--%s=%s", key, input[key]))
 	}
 	return args
 }
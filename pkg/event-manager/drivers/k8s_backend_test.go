@@ -0,0 +1,68 @@
+///////////////////////////////////////////////////////////////////////
+// Copyright (c) 2018 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+///////////////////////////////////////////////////////////////////////
+
+package drivers
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestPodsFailures(t *testing.T) {
+	running := corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "running"},
+		Status: corev1.PodStatus{
+			Phase: corev1.PodRunning,
+			ContainerStatuses: []corev1.ContainerStatus{
+				{Name: "driver", State: corev1.ContainerState{Running: &corev1.ContainerStateRunning{}}},
+			},
+		},
+	}
+	starting := corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "starting"},
+		Status: corev1.PodStatus{
+			Phase: corev1.PodPending,
+			ContainerStatuses: []corev1.ContainerStatus{
+				{Name: "driver", State: corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: "ContainerCreating"}}},
+			},
+		},
+	}
+	pulling := corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "pulling"},
+		Status: corev1.PodStatus{
+			Phase: corev1.PodPending,
+			ContainerStatuses: []corev1.ContainerStatus{
+				{Name: "driver", State: corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{
+					Reason:  "ImagePullBackOff",
+					Message: "Back-off pulling image",
+				}}},
+			},
+		},
+	}
+	unschedulable := corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "unschedulable"},
+		Status: corev1.PodStatus{
+			Phase: corev1.PodPending,
+			Conditions: []corev1.PodCondition{
+				{
+					Type:    corev1.PodScheduled,
+					Status:  corev1.ConditionFalse,
+					Reason:  corev1.PodReasonUnschedulable,
+					Message: "Insufficient cpu",
+				},
+			},
+		},
+	}
+
+	assert.Empty(t, podsFailures(nil))
+	assert.Empty(t, podsFailures([]corev1.Pod{running, starting}))
+	assert.Equal(t, []string{
+		"container driver of pod pulling: ImagePullBackOff: Back-off pulling image",
+		"pod unschedulable cannot be scheduled: Insufficient cpu",
+	}, []string(podsFailures([]corev1.Pod{running, pulling, unschedulable})))
+}
@@ -3,6 +3,7 @@
 package mocks
 
 import entities "github.com/vmware/dispatch/pkg/event-manager/drivers/entities"
+import entitystore "github.com/vmware/dispatch/pkg/entity-store"
 import mock "github.com/stretchr/testify/mock"
 
 // Backend is an autogenerated mock type for the Backend type
@@ -51,3 +52,33 @@ func (_m *Backend) Update(_a0 *entities.Driver) error {
 
 	return r0
 }
+
+// Status provides a mock function with given fields: _a0
+func (_m *Backend) Status(_a0 *entities.Driver) (entitystore.Status, entitystore.Reason, error) {
+	ret := _m.Called(_a0)
+
+	var r0 entitystore.Status
+	if rf, ok := ret.Get(0).(func(*entities.Driver) entitystore.Status); ok {
+		r0 = rf(_a0)
+	} else {
+		r0 = ret.Get(0).(entitystore.Status)
+	}
+
+	var r1 entitystore.Reason
+	if rf, ok := ret.Get(1).(func(*entities.Driver) entitystore.Reason); ok {
+		r1 = rf(_a0)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(entitystore.Reason)
+		}
+	}
+
+	var r2 error
+	if rf, ok := ret.Get(2).(func(*entities.Driver) error); ok {
+		r2 = rf(_a0)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
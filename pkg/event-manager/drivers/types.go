@@ -5,7 +5,10 @@
 
 package drivers
 
-import "github.com/vmware/dispatch/pkg/event-manager/drivers/entities"
+import (
+	"github.com/vmware/dispatch/pkg/entity-store"
+	"github.com/vmware/dispatch/pkg/event-manager/drivers/entities"
+)
 
 // NO TEST
 
@@ -14,4 +17,7 @@ type Backend interface {
 	Deploy(*entities.Driver) error
 	Update(*entities.Driver) error
 	Delete(*entities.Driver) error
+	// Status reports the health of a deployed driver: StatusMISSING if its deployment does not exist, StatusERROR
+	// (with the reason) if its pods are failing, StatusREADY otherwise.
+	Status(*entities.Driver) (entitystore.Status, entitystore.Reason, error)
 }
@@ -19,6 +19,8 @@ import (
 	"github.com/vmware/dispatch/pkg/event-manager/gen/restapi/operations"
 	"github.com/vmware/dispatch/pkg/event-manager/gen/restapi/operations/events"
 	"github.com/vmware/dispatch/pkg/event-manager/helpers"
+	historyentities "github.com/vmware/dispatch/pkg/event-manager/history/entities"
+	historymocks "github.com/vmware/dispatch/pkg/event-manager/history/mocks"
 	eventtypes "github.com/vmware/dispatch/pkg/events"
 	eventsmocks "github.com/vmware/dispatch/pkg/events/mocks"
 	testhelpers "github.com/vmware/dispatch/pkg/testing/api"
@@ -65,6 +67,37 @@ func TestEventsEmitEvent(t *testing.T) {
 	queue.AssertCalled(t, "Publish", mock.Anything, mock.Anything, (&testCloudEvent1).DefaultTopic(), "")
 }
 
+func TestEventsEmitEventRecorded(t *testing.T) {
+	api := operations.NewEventManagerAPI(nil)
+	es := testhelpers.MakeEntityStore(t)
+	queue := &eventsmocks.Transport{}
+	recorder := &historymocks.Recorder{}
+	h := Handlers{Store: es, EQ: queue, Recorder: recorder}
+	testhelpers.MakeAPI(t, h.ConfigureHandlers, api)
+
+	queue.On("Publish", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	recorder.On("RecordEvent", mock.Anything, mock.MatchedBy(func(r *historyentities.EventRecord) bool {
+		return r.Emitted && r.Topic == (&testCloudEvent1).DefaultTopic() && r.Event.EventID == testCloudEvent1.EventID && r.Tags["app"] == "app1"
+	})).Return(nil).Once().Run(func(mock.Arguments) {
+		// recorded before the subscriptions receive the event
+		queue.AssertNotCalled(t, "Publish", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	reqBody := &models.Emission{
+		Event: helpers.CloudEventToSwagger(&testCloudEvent1),
+		Tags:  []*models.Tag{{Key: "app", Value: "app1"}},
+	}
+	r := httptest.NewRequest("POST", "/v1/event/", nil)
+	params := events.EmitEventParams{
+		HTTPRequest: r,
+		Body:        reqBody,
+	}
+	responder := api.EventsEmitEventHandler.Handle(params, "testCookie")
+	var respBody models.Emission
+	testhelpers.HandlerRequest(t, responder, &respBody, 200)
+	recorder.AssertExpectations(t)
+}
+
 func TestEventsEmitError(t *testing.T) {
 	api := operations.NewEventManagerAPI(nil)
 	es := testhelpers.MakeEntityStore(t)
@@ -0,0 +1,68 @@
+///////////////////////////////////////////////////////////////////////
+// Copyright (c) 2018 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+///////////////////////////////////////////////////////////////////////
+
+package entities
+
+import (
+	"time"
+
+	"github.com/go-openapi/strfmt"
+
+	"github.com/vmware/dispatch/pkg/entity-store"
+	"github.com/vmware/dispatch/pkg/event-manager/gen/models"
+	"github.com/vmware/dispatch/pkg/event-manager/helpers"
+	"github.com/vmware/dispatch/pkg/events"
+)
+
+// NO TESTS
+
+// EventRecord struct represents an event emitted or received by event manager, along with the function runs it triggered
+type EventRecord struct {
+	entitystore.BaseEntity
+	EventType  string            `json:"eventType"`
+	SourceType string            `json:"sourceType"`
+	Topic      string            `json:"topic"`
+	Emitted    bool              `json:"emitted"`
+	Event      events.CloudEvent `json:"event"`
+	Runs       []Run             `json:"runs,omitempty"`
+}
+
+// Run struct represents a single attempt to run a function for a recorded event
+type Run struct {
+	Subscription string    `json:"subscription"`
+	Function     string    `json:"function"`
+	Run          string    `json:"run,omitempty"`
+	Error        string    `json:"error,omitempty"`
+	Time         time.Time `json:"time"`
+}
+
+// ToModel converts event record to swagger model
+func (r *EventRecord) ToModel() *models.EventRecord {
+	var tags []*models.Tag
+	for k, v := range r.Tags {
+		tags = append(tags, &models.Tag{Key: k, Value: v})
+	}
+	var runs []*models.EventRun
+	for _, run := range r.Runs {
+		runs = append(runs, &models.EventRun{
+			Subscription: run.Subscription,
+			Function:     run.Function,
+			Run:          run.Run,
+			Error:        run.Error,
+			Time:         run.Time.Unix(),
+		})
+	}
+	return &models.EventRecord{
+		ID:           strfmt.UUID(r.ID),
+		Name:         r.Name,
+		Topic:        r.Topic,
+		Emitted:      r.Emitted,
+		Event:        helpers.CloudEventToSwagger(&r.Event),
+		Runs:         runs,
+		CreatedTime:  r.CreatedTime.Unix(),
+		ModifiedTime: r.ModifiedTime.Unix(),
+		Tags:         tags,
+	}
+}
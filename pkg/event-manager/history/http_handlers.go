@@ -0,0 +1,202 @@
+///////////////////////////////////////////////////////////////////////
+// Copyright (c) 2018 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+///////////////////////////////////////////////////////////////////////
+
+package history
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/go-openapi/runtime/middleware"
+	"github.com/go-openapi/swag"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/vmware/dispatch/pkg/entity-store"
+	"github.com/vmware/dispatch/pkg/event-manager/gen/models"
+	"github.com/vmware/dispatch/pkg/event-manager/gen/restapi/operations"
+	eventsapi "github.com/vmware/dispatch/pkg/event-manager/gen/restapi/operations/events"
+	"github.com/vmware/dispatch/pkg/event-manager/history/entities"
+	"github.com/vmware/dispatch/pkg/events"
+	"github.com/vmware/dispatch/pkg/trace"
+	"github.com/vmware/dispatch/pkg/utils"
+)
+
+// Handlers is a base struct for event history API handlers.
+type Handlers struct {
+	orgID string
+	store entitystore.EntityStore
+	queue events.Transport
+}
+
+// NewHandlers Creates new instance of event history handlers
+func NewHandlers(store entitystore.EntityStore, queue events.Transport, orgID string) *Handlers {
+	return &Handlers{
+		store: store,
+		queue: queue,
+		orgID: orgID,
+	}
+}
+
+// ConfigureHandlers configures API handlers for event history endpoints
+func (h *Handlers) ConfigureHandlers(api middleware.RoutableAPI) {
+	defer trace.Trace("ConfigureHandlers")()
+	a, ok := api.(*operations.EventManagerAPI)
+	if !ok {
+		panic("Cannot configure api")
+	}
+
+	a.EventsGetEventsHandler = eventsapi.GetEventsHandlerFunc(h.getEvents)
+	a.EventsGetEventHandler = eventsapi.GetEventHandlerFunc(h.getEvent)
+	a.EventsReplayEventHandler = eventsapi.ReplayEventHandlerFunc(h.replayEvent)
+}
+
+// getEvents handles retrieval of recorded events
+func (h *Handlers) getEvents(params eventsapi.GetEventsParams, principal interface{}) middleware.Responder {
+	defer trace.Trace("getEvents")()
+
+	sp, _ := utils.AddHTTPTracing(params.HTTPRequest, "EventManager.getEvents")
+	defer sp.Finish()
+
+	opts := entitystore.Options{
+		Filter: entitystore.FilterEverything(),
+	}
+	if params.EventType != nil {
+		opts.Filter.Add(
+			entitystore.FilterStat{
+				Scope:   entitystore.FilterScopeExtra,
+				Subject: "EventType",
+				Verb:    entitystore.FilterVerbEqual,
+				Object:  *params.EventType,
+			})
+	}
+	if params.SourceType != nil {
+		opts.Filter.Add(
+			entitystore.FilterStat{
+				Scope:   entitystore.FilterScopeExtra,
+				Subject: "SourceType",
+				Verb:    entitystore.FilterVerbEqual,
+				Object:  *params.SourceType,
+			})
+	}
+	if params.Since != nil {
+		opts.Filter.Add(
+			entitystore.FilterStat{
+				Scope:   entitystore.FilterScopeField,
+				Subject: "CreatedTime",
+				Verb:    entitystore.FilterVerbAfter,
+				Object:  time.Unix(*params.Since, 0),
+			})
+	}
+	var err error
+	opts.Filter, err = utils.ParseTags(opts.Filter, params.Tags)
+	if err == nil {
+		err = utils.ParsePage(&opts, params.Limit, params.Continue, params.SortBy)
+	}
+	if err != nil {
+		log.Error(err)
+		return eventsapi.NewGetEventsBadRequest().WithPayload(
+			&models.Error{
+				Code:    http.StatusBadRequest,
+				Message: swag.String(err.Error()),
+			})
+	}
+
+	var records []*entities.EventRecord
+	next, err := utils.ListPage(h.store, h.orgID, opts, &records)
+	if err != nil {
+		log.Errorf("store error when listing events: %+v", err)
+		return eventsapi.NewGetEventsDefault(http.StatusInternalServerError).WithPayload(
+			&models.Error{
+				Code:    http.StatusInternalServerError,
+				Message: swag.String("internal server error when getting events"),
+			})
+	}
+	sort.Slice(records, func(i, j int) bool {
+		return records[i].CreatedTime.Before(records[j].CreatedTime)
+	})
+	var recordModels []*models.EventRecord
+	for _, r := range records {
+		// The store cannot filter a field with two conditions, so the upper bound is applied here
+		if params.Until != nil && !r.CreatedTime.Before(time.Unix(*params.Until, 0)) {
+			continue
+		}
+		recordModels = append(recordModels, r.ToModel())
+	}
+	return eventsapi.NewGetEventsOK().WithXContinue(next).WithPayload(recordModels)
+}
+
+// getEvent handles retrieval of single recorded event
+func (h *Handlers) getEvent(params eventsapi.GetEventParams, principal interface{}) middleware.Responder {
+	defer trace.Trace("getEvent")()
+
+	sp, _ := utils.AddHTTPTracing(params.HTTPRequest, "EventManager.getEvent")
+	defer sp.Finish()
+
+	opts := entitystore.Options{
+		Filter: entitystore.FilterEverything(),
+	}
+	var err error
+	opts.Filter, err = utils.ParseTags(opts.Filter, params.Tags)
+	if err != nil {
+		log.Error(err)
+		return eventsapi.NewGetEventBadRequest().WithPayload(
+			&models.Error{
+				Code:    http.StatusBadRequest,
+				Message: swag.String(err.Error()),
+			})
+	}
+	r := entities.EventRecord{}
+	if err = h.store.Get(h.orgID, params.EventName, opts, &r); err != nil {
+		log.Debugf("store error when getting event: %+v", err)
+		return eventsapi.NewGetEventNotFound().WithPayload(
+			&models.Error{
+				Code:    http.StatusNotFound,
+				Message: swag.String(fmt.Sprintf("event %s not found", params.EventName)),
+			})
+	}
+	return eventsapi.NewGetEventOK().WithPayload(r.ToModel())
+}
+
+// replayEvent handles publishing of a recorded event
+func (h *Handlers) replayEvent(params eventsapi.ReplayEventParams, principal interface{}) middleware.Responder {
+	defer trace.Trace("replayEvent")()
+
+	sp, spCtx := utils.AddHTTPTracing(params.HTTPRequest, "EventManager.replayEvent")
+	defer sp.Finish()
+
+	opts := entitystore.Options{
+		Filter: entitystore.FilterEverything(),
+	}
+	var err error
+	opts.Filter, err = utils.ParseTags(opts.Filter, params.Tags)
+	if err != nil {
+		log.Error(err)
+		return eventsapi.NewReplayEventBadRequest().WithPayload(
+			&models.Error{
+				Code:    http.StatusBadRequest,
+				Message: swag.String(err.Error()),
+			})
+	}
+	r := entities.EventRecord{}
+	if err = h.store.Get(h.orgID, params.EventName, opts, &r); err != nil {
+		log.Debugf("store error when getting event: %+v", err)
+		return eventsapi.NewReplayEventNotFound().WithPayload(
+			&models.Error{
+				Code:    http.StatusNotFound,
+				Message: swag.String(fmt.Sprintf("event %s not found", params.EventName)),
+			})
+	}
+	if err = h.queue.Publish(spCtx, &r.Event, r.Topic, h.orgID); err != nil {
+		log.Errorf("error when publishing a message to MQ: %+v", err)
+		return eventsapi.NewReplayEventInternalServerError().WithPayload(
+			&models.Error{
+				Code:    http.StatusInternalServerError,
+				Message: swag.String("internal server error when replaying an event"),
+			})
+	}
+	return eventsapi.NewReplayEventOK().WithPayload(r.ToModel())
+}
@@ -0,0 +1,115 @@
+///////////////////////////////////////////////////////////////////////
+// Copyright (c) 2018 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+///////////////////////////////////////////////////////////////////////
+
+package history
+
+import (
+	"context"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-openapi/swag"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/vmware/dispatch/pkg/entity-store"
+	"github.com/vmware/dispatch/pkg/event-manager/gen/models"
+	"github.com/vmware/dispatch/pkg/event-manager/gen/restapi/operations"
+	eventsapi "github.com/vmware/dispatch/pkg/event-manager/gen/restapi/operations/events"
+	"github.com/vmware/dispatch/pkg/event-manager/history/entities"
+	"github.com/vmware/dispatch/pkg/events"
+	eventsmocks "github.com/vmware/dispatch/pkg/events/mocks"
+	helpers "github.com/vmware/dispatch/pkg/testing/api"
+)
+
+func recordEvent(t *testing.T, es entitystore.EntityStore, eventType, sourceType string) *events.CloudEvent {
+	ev := events.NewCloudEventWithDefaults(eventType)
+	ev.SourceType = sourceType
+	record := &entities.EventRecord{Topic: ev.DefaultTopic(), Event: ev}
+	require.NoError(t, NewStoreRecorder(es, "").RecordEvent(context.Background(), record))
+	return &ev
+}
+
+func TestGetEventsHandler(t *testing.T) {
+	api := operations.NewEventManagerAPI(nil)
+	es := helpers.MakeEntityStore(t)
+	h := NewHandlers(es, nil, "")
+	helpers.MakeAPI(t, h.ConfigureHandlers, api)
+
+	first := recordEvent(t, es, "test.topic", "source1")
+	recordEvent(t, es, "test.topic", "source2")
+	recordEvent(t, es, "other.topic", "source1")
+
+	r := httptest.NewRequest("GET", "/v1/event/", nil)
+	get := eventsapi.GetEventsParams{
+		HTTPRequest: r,
+	}
+	var getBody []models.EventRecord
+	helpers.HandlerRequest(t, api.EventsGetEventsHandler.Handle(get, "testCookie"), &getBody, 200)
+	require.Len(t, getBody, 3)
+	assert.Equal(t, first.EventID, getBody[0].Name)
+
+	get.EventType = swag.String("test.topic")
+	helpers.HandlerRequest(t, api.EventsGetEventsHandler.Handle(get, "testCookie"), &getBody, 200)
+	assert.Len(t, getBody, 2)
+
+	get.SourceType = swag.String("source2")
+	helpers.HandlerRequest(t, api.EventsGetEventsHandler.Handle(get, "testCookie"), &getBody, 200)
+	require.Len(t, getBody, 1)
+	assert.Equal(t, "source2.test.topic", getBody[0].Topic)
+
+	get = eventsapi.GetEventsParams{
+		HTTPRequest: r,
+		Since:       swag.Int64(time.Now().Add(-time.Hour).Unix()),
+		Until:       swag.Int64(time.Now().Add(time.Hour).Unix()),
+	}
+	helpers.HandlerRequest(t, api.EventsGetEventsHandler.Handle(get, "testCookie"), &getBody, 200)
+	assert.Len(t, getBody, 3)
+
+	get.Until = swag.Int64(time.Now().Add(-time.Minute).Unix())
+	helpers.HandlerRequest(t, api.EventsGetEventsHandler.Handle(get, "testCookie"), &getBody, 200)
+	assert.Len(t, getBody, 0)
+
+	r = httptest.NewRequest("GET", "/v1/event/history/"+first.EventID, nil)
+	getOne := eventsapi.GetEventParams{
+		HTTPRequest: r,
+		EventName:   first.EventID,
+	}
+	var getOneBody models.EventRecord
+	helpers.HandlerRequest(t, api.EventsGetEventHandler.Handle(getOne, "testCookie"), &getOneBody, 200)
+	assert.Equal(t, "source1", *getOneBody.Event.SourceType)
+
+	getOne.EventName = "missing"
+	var errorBody models.Error
+	helpers.HandlerRequest(t, api.EventsGetEventHandler.Handle(getOne, "testCookie"), &errorBody, 404)
+}
+
+func TestReplayEventHandler(t *testing.T) {
+	api := operations.NewEventManagerAPI(nil)
+	es := helpers.MakeEntityStore(t)
+	queue := &eventsmocks.Transport{}
+	h := NewHandlers(es, queue, "")
+	helpers.MakeAPI(t, h.ConfigureHandlers, api)
+
+	ev := recordEvent(t, es, "test.topic", "source1")
+
+	queue.On("Publish", mock.Anything, mock.Anything, "source1.test.topic", "").Return(nil).Once()
+	r := httptest.NewRequest("POST", "/v1/event/history/"+ev.EventID+"/replay", nil)
+	replay := eventsapi.ReplayEventParams{
+		HTTPRequest: r,
+		EventName:   ev.EventID,
+	}
+	var replayBody models.EventRecord
+	helpers.HandlerRequest(t, api.EventsReplayEventHandler.Handle(replay, "testCookie"), &replayBody, 200)
+	assert.Equal(t, ev.EventID, replayBody.Name)
+	queue.AssertExpectations(t)
+	assert.Equal(t, ev.EventID, queue.Calls[0].Arguments.Get(1).(*events.CloudEvent).EventID)
+
+	replay.EventName = "missing"
+	var errorBody models.Error
+	helpers.HandlerRequest(t, api.EventsReplayEventHandler.Handle(replay, "testCookie"), &errorBody, 404)
+}
@@ -0,0 +1,41 @@
+// Code generated by mockery v1.0.0
+
+package mocks
+
+import context "context"
+import entities "github.com/vmware/dispatch/pkg/event-manager/history/entities"
+import events "github.com/vmware/dispatch/pkg/events"
+import mock "github.com/stretchr/testify/mock"
+
+// Recorder is an autogenerated mock type for the Recorder type
+type Recorder struct {
+	mock.Mock
+}
+
+// RecordEvent provides a mock function with given fields: ctx, record
+func (_m *Recorder) RecordEvent(ctx context.Context, record *entities.EventRecord) error {
+	ret := _m.Called(ctx, record)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *entities.EventRecord) error); ok {
+		r0 = rf(ctx, record)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// RecordRun provides a mock function with given fields: ctx, event, run
+func (_m *Recorder) RecordRun(ctx context.Context, event *events.CloudEvent, run *entities.Run) error {
+	ret := _m.Called(ctx, event, run)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *events.CloudEvent, *entities.Run) error); ok {
+		r0 = rf(ctx, event, run)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
@@ -0,0 +1,115 @@
+///////////////////////////////////////////////////////////////////////
+// Copyright (c) 2018 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+///////////////////////////////////////////////////////////////////////
+
+package history
+
+import (
+	"context"
+	"crypto/sha1"
+	"fmt"
+	"regexp"
+	"sync"
+
+	"github.com/pkg/errors"
+
+	"github.com/vmware/dispatch/pkg/entity-store"
+	"github.com/vmware/dispatch/pkg/event-manager/history/entities"
+	"github.com/vmware/dispatch/pkg/events"
+	"github.com/vmware/dispatch/pkg/trace"
+)
+
+// Recorder records events and the function runs they triggered
+type Recorder interface {
+	// RecordEvent records an event. Topic, Emitted, Event and Tags of the record must be set, other fields are
+	// filled in by the recorder. Recording an event which is already recorded is not an error, the emission details
+	// of the record are added to the existing one.
+	RecordEvent(ctx context.Context, record *entities.EventRecord) error
+	// RecordRun adds a function run to the record of an event
+	RecordRun(ctx context.Context, event *events.CloudEvent, run *entities.Run) error
+}
+
+var validName = regexp.MustCompile(`^[\w\d\-]+$`)
+
+// RecordName returns the name of the record of an event. Event IDs which are not valid entity names are hashed.
+func RecordName(eventID string) string {
+	if validName.MatchString(eventID) {
+		return eventID
+	}
+	return fmt.Sprintf("%x", sha1.Sum([]byte(eventID)))
+}
+
+type storeRecorder struct {
+	store entitystore.EntityStore
+	orgID string
+
+	// serializes updates of records, as an event is usually received by multiple subscriptions at once
+	sync.Mutex
+}
+
+// NewStoreRecorder creates a recorder, which keeps event records in the entity store
+func NewStoreRecorder(store entitystore.EntityStore, orgID string) Recorder {
+	return &storeRecorder{
+		store: store,
+		orgID: orgID,
+	}
+}
+
+func (r *storeRecorder) RecordEvent(ctx context.Context, record *entities.EventRecord) error {
+	defer trace.Tracef("event %s", record.Event.EventID)()
+	r.Lock()
+	defer r.Unlock()
+
+	record.OrganizationID = r.orgID
+	record.Name = RecordName(record.Event.EventID)
+	record.Status = entitystore.StatusREADY
+	record.EventType = record.Event.EventType
+	record.SourceType = record.Event.SourceType
+	if _, err := r.store.Add(record); err != nil {
+		if entitystore.IsUniqueViolation(err) {
+			return r.recordEmission(record)
+		}
+		return errors.Wrapf(err, "error storing record of event %s", record.Event.EventID)
+	}
+	return nil
+}
+
+// recordEmission adds the emission details of record to the existing record of the event, which a subscription
+// receiving the event may have stored first
+func (r *storeRecorder) recordEmission(record *entities.EventRecord) error {
+	if !record.Emitted {
+		return nil
+	}
+	existing := &entities.EventRecord{}
+	if err := r.store.Get(r.orgID, record.Name, entitystore.Options{}, existing); err != nil {
+		return errors.Wrapf(err, "error getting record of event %s", record.Event.EventID)
+	}
+	existing.Emitted = true
+	if existing.Tags == nil {
+		existing.Tags = make(entitystore.Tags)
+	}
+	for k, v := range record.Tags {
+		existing.Tags[k] = v
+	}
+	if _, err := r.store.Update(existing.Revision, existing); err != nil {
+		return errors.Wrapf(err, "error updating record of event %s", record.Event.EventID)
+	}
+	return nil
+}
+
+func (r *storeRecorder) RecordRun(ctx context.Context, event *events.CloudEvent, run *entities.Run) error {
+	defer trace.Tracef("event %s, function %s", event.EventID, run.Function)()
+	r.Lock()
+	defer r.Unlock()
+
+	record := &entities.EventRecord{}
+	if err := r.store.Get(r.orgID, RecordName(event.EventID), entitystore.Options{}, record); err != nil {
+		return errors.Wrapf(err, "error getting record of event %s", event.EventID)
+	}
+	record.Runs = append(record.Runs, *run)
+	if _, err := r.store.Update(record.Revision, record); err != nil {
+		return errors.Wrapf(err, "error updating record of event %s", event.EventID)
+	}
+	return nil
+}
@@ -0,0 +1,68 @@
+///////////////////////////////////////////////////////////////////////
+// Copyright (c) 2018 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+///////////////////////////////////////////////////////////////////////
+
+package history
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/vmware/dispatch/pkg/entity-store"
+	"github.com/vmware/dispatch/pkg/event-manager/history/entities"
+	"github.com/vmware/dispatch/pkg/events"
+	helpers "github.com/vmware/dispatch/pkg/testing/api"
+)
+
+func TestRecordName(t *testing.T) {
+	assert.Equal(t, "c2a1fd4a-01a8-4dd8-ae6e-bf1bc8e0e5d9", RecordName("c2a1fd4a-01a8-4dd8-ae6e-bf1bc8e0e5d9"))
+	name := RecordName("vcenter:1234/5")
+	assert.Len(t, name, 40)
+	assert.Equal(t, name, RecordName("vcenter:1234/5"))
+}
+
+func TestStoreRecorder(t *testing.T) {
+	es := helpers.MakeEntityStore(t)
+	recorder := NewStoreRecorder(es, "testorg")
+	ev := events.NewCloudEventWithDefaults("test.topic")
+	ev.SourceType = "test"
+
+	record := &entities.EventRecord{Topic: "test.test.topic", Event: ev}
+	require.NoError(t, recorder.RecordEvent(context.Background(), record))
+	// recording the same event again, e.g. for another subscription, keeps the first record
+	require.NoError(t, recorder.RecordEvent(context.Background(), &entities.EventRecord{Topic: "other", Event: ev}))
+
+	require.NoError(t, recorder.RecordRun(context.Background(), &ev, &entities.Run{Subscription: "sub1", Function: "fn1", Run: "run1", Time: time.Now()}))
+	require.NoError(t, recorder.RecordRun(context.Background(), &ev, &entities.Run{Subscription: "sub2", Function: "fn2", Error: "testerror", Time: time.Now()}))
+
+	stored := &entities.EventRecord{}
+	require.NoError(t, es.Get("testorg", ev.EventID, entitystore.Options{}, stored))
+	assert.Equal(t, "test.test.topic", stored.Topic)
+	assert.Equal(t, "test.topic", stored.EventType)
+	assert.Equal(t, "test", stored.SourceType)
+	require.Len(t, stored.Runs, 2)
+	assert.Equal(t, "run1", stored.Runs[0].Run)
+	assert.Equal(t, "testerror", stored.Runs[1].Error)
+
+	other := events.NewCloudEventWithDefaults("test.topic")
+	assert.Error(t, recorder.RecordRun(context.Background(), &other, &entities.Run{Subscription: "sub1"}))
+
+	// the emission details are added to a record stored first by a subscription
+	emitted := &entities.EventRecord{
+		BaseEntity: entitystore.BaseEntity{Tags: entitystore.Tags{"app": "app1"}},
+		Topic:      "test.test.topic",
+		Emitted:    true,
+		Event:      ev,
+	}
+	require.NoError(t, recorder.RecordEvent(context.Background(), emitted))
+	stored = &entities.EventRecord{}
+	require.NoError(t, es.Get("testorg", ev.EventID, entitystore.Options{}, stored))
+	assert.True(t, stored.Emitted)
+	assert.Equal(t, "app1", stored.Tags["app"])
+	assert.Len(t, stored.Runs, 2)
+}
@@ -0,0 +1,49 @@
+///////////////////////////////////////////////////////////////////////
+// Copyright (c) 2018 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+///////////////////////////////////////////////////////////////////////
+
+package entities
+
+import (
+	"github.com/go-openapi/strfmt"
+
+	"github.com/vmware/dispatch/pkg/entity-store"
+	"github.com/vmware/dispatch/pkg/event-manager/gen/models"
+	"github.com/vmware/dispatch/pkg/event-manager/helpers"
+	"github.com/vmware/dispatch/pkg/events"
+)
+
+// NO TESTS
+
+// DeadLetter struct represents an event which could not be delivered to the function of a subscription
+type DeadLetter struct {
+	entitystore.BaseEntity
+	Subscription string            `json:"subscription"`
+	Function     string            `json:"function"`
+	Event        events.CloudEvent `json:"event"`
+	Attempts     int               `json:"attempts"`
+}
+
+// ToModel converts dead letter to swagger model
+func (d *DeadLetter) ToModel() *models.DeadLetter {
+	var tags []*models.Tag
+	for k, v := range d.Tags {
+		tags = append(tags, &models.Tag{Key: k, Value: v})
+	}
+	var reason string
+	if len(d.Reason) > 0 {
+		reason = d.Reason[0]
+	}
+	return &models.DeadLetter{
+		ID:           strfmt.UUID(d.ID),
+		Name:         d.Name,
+		Subscription: d.Subscription,
+		Function:     d.Function,
+		Event:        helpers.CloudEventToSwagger(&d.Event),
+		Attempts:     int64(d.Attempts),
+		Reason:       reason,
+		CreatedTime:  d.CreatedTime.Unix(),
+		Tags:         tags,
+	}
+}
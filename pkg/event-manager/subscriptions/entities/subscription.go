@@ -6,6 +6,8 @@
 package entities
 
 import (
+	"time"
+
 	"github.com/go-openapi/swag"
 	"github.com/vmware/dispatch/pkg/entity-store"
 	"github.com/vmware/dispatch/pkg/event-manager/gen/models"
@@ -20,6 +22,38 @@ type Subscription struct {
 	SourceType string   `json:"sourceType"`
 	Function   string   `json:"function"`
 	Secrets    []string `json:"secrets,omitempty"`
+	Filter     string   `json:"filter,omitempty"`
+
+	DeliveryPolicy DeliveryPolicy `json:"deliveryPolicy"`
+}
+
+// Defaults used for fields of DeliveryPolicy which are not set
+const (
+	DefaultMaxAttempts     = 3
+	DefaultRetryTimeout    = 30 * time.Second
+	DefaultDeadLetterTopic = "dispatch.deadletter"
+)
+
+// DeliveryPolicy defines how function runs triggered by a subscription are retried,
+// and where events are sent when all attempts fail.
+type DeliveryPolicy struct {
+	MaxAttempts     int           `json:"maxAttempts,omitempty"`
+	RetryTimeout    time.Duration `json:"retryTimeout,omitempty"`
+	DeadLetterTopic string        `json:"deadLetterTopic,omitempty"`
+}
+
+// WithDefaults returns a copy of the policy, with unset fields replaced by defaults
+func (p DeliveryPolicy) WithDefaults() DeliveryPolicy {
+	if p.MaxAttempts <= 0 {
+		p.MaxAttempts = DefaultMaxAttempts
+	}
+	if p.RetryTimeout <= 0 {
+		p.RetryTimeout = DefaultRetryTimeout
+	}
+	if p.DeadLetterTopic == "" {
+		p.DeadLetterTopic = DefaultDeadLetterTopic
+	}
+	return p
 }
 
 // ToModel converts subscription to swagger model
@@ -28,6 +62,7 @@ func (s *Subscription) ToModel() *models.Subscription {
 	for k, v := range s.Tags {
 		tags = append(tags, &models.Tag{Key: k, Value: v})
 	}
+	policy := s.DeliveryPolicy.WithDefaults()
 	m := models.Subscription{
 		Name:         swag.String(s.Name),
 		EventType:    swag.String(s.EventType),
@@ -35,9 +70,15 @@ func (s *Subscription) ToModel() *models.Subscription {
 		Function:     &s.Function,
 		Status:       models.Status(s.Status),
 		Secrets:      s.Secrets,
+		Filter:       s.Filter,
 		CreatedTime:  s.CreatedTime.Unix(),
 		ModifiedTime: s.ModifiedTime.Unix(),
 		Tags:         tags,
+		DeliveryPolicy: &models.DeliveryPolicy{
+			MaxAttempts:     int64(policy.MaxAttempts),
+			RetryTimeout:    int64(policy.RetryTimeout / time.Second),
+			DeadLetterTopic: policy.DeadLetterTopic,
+		},
 	}
 	return &m
 }
@@ -58,4 +99,12 @@ func (s *Subscription) FromModel(m *models.Subscription, orgID string) {
 	s.SourceType = *m.SourceType
 	s.Function = *m.Function
 	s.Secrets = m.Secrets
+	s.Filter = m.Filter
+	if m.DeliveryPolicy != nil {
+		s.DeliveryPolicy = DeliveryPolicy{
+			MaxAttempts:     int(m.DeliveryPolicy.MaxAttempts),
+			RetryTimeout:    time.Duration(m.DeliveryPolicy.RetryTimeout) * time.Second,
+			DeadLetterTopic: m.DeliveryPolicy.DeadLetterTopic,
+		}
+	}
 }
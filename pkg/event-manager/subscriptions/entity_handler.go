@@ -91,6 +91,35 @@ func (h *EntityHandler) Sync(organizationID string, resyncPeriod time.Duration)
 	return controller.DefaultSync(h.store, h.Type(), organizationID, resyncPeriod, nil)
 }
 
+// LeadershipChanged activates the ready subscriptions when this replica starts leading, and deactivates them when it
+// stops, so that the events are only delivered by the leader
+func (h *EntityHandler) LeadershipChanged(organizationID string, leading bool) error {
+	defer trace.Tracef("leading %t", leading)()
+
+	opts := entitystore.Options{
+		Filter: entitystore.FilterEverything().Add(
+			entitystore.FilterStat{
+				Scope:   entitystore.FilterScopeField,
+				Subject: "Status",
+				Verb:    entitystore.FilterVerbIn,
+				Object:  []entitystore.Status{entitystore.StatusREADY},
+			}),
+	}
+	var subs []*entities.Subscription
+	if err := h.store.List(organizationID, opts, &subs); err != nil {
+		return ewrapper.Wrap(err, "store error when listing subscriptions")
+	}
+	if leading {
+		return h.manager.Run(subs)
+	}
+	for _, sub := range subs {
+		if err := h.manager.Delete(context.Background(), sub); err != nil {
+			return ewrapper.Wrapf(err, "error deactivating subscription %s", sub.Name)
+		}
+	}
+	return nil
+}
+
 // Error handles error state
 func (h *EntityHandler) Error(obj entitystore.Entity) error {
 	defer trace.Tracef("")()
@@ -61,3 +61,31 @@ func TestSubscriptionDelete(t *testing.T) {
 	es.List("", entitystore.Options{}, subs)
 	assert.Len(t, subs, 0)
 }
+
+func TestSubscriptionLeadershipChanged(t *testing.T) {
+	manager := &mocks2.Manager{}
+	es := helpers.MakeEntityStore(t)
+	handler := mockSubscriptionHandler(manager, es)
+	for name, status := range map[string]entitystore.Status{"ready": entitystore.StatusREADY, "creating": entitystore.StatusCREATING} {
+		_, err := es.Add(&entities.Subscription{
+			BaseEntity: entitystore.BaseEntity{
+				Name:   name,
+				Status: status,
+			},
+			EventType: "test.topic",
+			Function:  "test.function",
+		})
+		assert.NoError(t, err)
+	}
+	isReady := mock.MatchedBy(func(subs []*entities.Subscription) bool {
+		return len(subs) == 1 && subs[0].Name == "ready"
+	})
+	manager.On("Run", isReady).Return(nil)
+	assert.NoError(t, handler.LeadershipChanged("", true))
+
+	manager.On("Delete", mock.Anything, mock.MatchedBy(func(sub *entities.Subscription) bool {
+		return sub.Name == "ready"
+	})).Return(nil)
+	assert.NoError(t, handler.LeadershipChanged("", false))
+	manager.AssertExpectations(t)
+}
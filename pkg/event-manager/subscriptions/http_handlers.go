@@ -18,8 +18,10 @@ import (
 	"github.com/vmware/dispatch/pkg/entity-store"
 	"github.com/vmware/dispatch/pkg/event-manager/gen/models"
 	"github.com/vmware/dispatch/pkg/event-manager/gen/restapi/operations"
+	deadlettersapi "github.com/vmware/dispatch/pkg/event-manager/gen/restapi/operations/deadletters"
 	subscriptionsapi "github.com/vmware/dispatch/pkg/event-manager/gen/restapi/operations/subscriptions"
 	"github.com/vmware/dispatch/pkg/event-manager/subscriptions/entities"
+	"github.com/vmware/dispatch/pkg/events/filter"
 	"github.com/vmware/dispatch/pkg/trace"
 	"github.com/vmware/dispatch/pkg/utils"
 )
@@ -29,13 +31,15 @@ type Handlers struct {
 	orgID   string
 	store   entitystore.EntityStore
 	watcher controller.Watcher
+	manager Manager
 }
 
 // NewHandlers Creates new instance of subscription handlers
-func NewHandlers(store entitystore.EntityStore, watcher controller.Watcher, orgID string) *Handlers {
+func NewHandlers(store entitystore.EntityStore, watcher controller.Watcher, manager Manager, orgID string) *Handlers {
 	return &Handlers{
 		watcher: watcher,
 		store:   store,
+		manager: manager,
 		orgID:   orgID,
 	}
 }
@@ -52,6 +56,11 @@ func (h *Handlers) ConfigureHandlers(api middleware.RoutableAPI) {
 	a.SubscriptionsGetSubscriptionHandler = subscriptionsapi.GetSubscriptionHandlerFunc(h.getSubscription)
 	a.SubscriptionsGetSubscriptionsHandler = subscriptionsapi.GetSubscriptionsHandlerFunc(h.getSubscriptions)
 	a.SubscriptionsDeleteSubscriptionHandler = subscriptionsapi.DeleteSubscriptionHandlerFunc(h.deleteSubscription)
+
+	a.DeadlettersGetDeadLetterHandler = deadlettersapi.GetDeadLetterHandlerFunc(h.getDeadLetter)
+	a.DeadlettersGetDeadLettersHandler = deadlettersapi.GetDeadLettersHandlerFunc(h.getDeadLetters)
+	a.DeadlettersDeleteDeadLetterHandler = deadlettersapi.DeleteDeadLetterHandlerFunc(h.deleteDeadLetter)
+	a.DeadlettersReplayDeadLetterHandler = deadlettersapi.ReplayDeadLetterHandlerFunc(h.replayDeadLetter)
 }
 
 // addSubscription handles creation of new Event Subscriptions
@@ -68,6 +77,15 @@ func (h *Handlers) addSubscription(params subscriptionsapi.AddSubscriptionParams
 		})
 	}
 
+	if params.Body.Filter != "" {
+		if _, err := filter.Parse(params.Body.Filter); err != nil {
+			return subscriptionsapi.NewAddSubscriptionBadRequest().WithPayload(&models.Error{
+				Code:    http.StatusBadRequest,
+				Message: swag.String(fmt.Sprintf("error parsing the filter: %s", err)),
+			})
+		}
+	}
+
 	s := &entities.Subscription{}
 	s.FromModel(params.Body, h.orgID)
 	s.Status = entitystore.StatusCREATING
@@ -138,6 +156,9 @@ func (h *Handlers) getSubscriptions(params subscriptionsapi.GetSubscriptionsPara
 		Filter: entitystore.FilterEverything(),
 	}
 	opts.Filter, err = utils.ParseTags(opts.Filter, params.Tags)
+	if err == nil {
+		err = utils.ParsePage(&opts, params.Limit, params.Continue, params.SortBy)
+	}
 	if err != nil {
 		log.Errorf(err.Error())
 		return subscriptionsapi.NewGetSubscriptionsBadRequest().WithPayload(
@@ -147,7 +168,7 @@ func (h *Handlers) getSubscriptions(params subscriptionsapi.GetSubscriptionsPara
 			})
 	}
 
-	err = h.store.List(h.orgID, opts, &subscriptions)
+	next, err := utils.ListPage(h.store, h.orgID, opts, &subscriptions)
 	if err != nil {
 		log.Errorf("store error when listing subscriptions: %+v", err)
 		return subscriptionsapi.NewGetSubscriptionsDefault(http.StatusInternalServerError).WithPayload(
@@ -160,7 +181,7 @@ func (h *Handlers) getSubscriptions(params subscriptionsapi.GetSubscriptionsPara
 	for _, sub := range subscriptions {
 		subscriptionModels = append(subscriptionModels, sub.ToModel())
 	}
-	return subscriptionsapi.NewGetSubscriptionsOK().WithPayload(subscriptionModels)
+	return subscriptionsapi.NewGetSubscriptionsOK().WithXContinue(next).WithPayload(subscriptionModels)
 }
 
 // deleteSubscription handles deletion of a Subscription
@@ -214,3 +235,187 @@ func (h *Handlers) deleteSubscription(params subscriptionsapi.DeleteSubscription
 	h.watcher.OnAction(s)
 	return subscriptionsapi.NewDeleteSubscriptionOK().WithPayload(s.ToModel())
 }
+
+// getDeadLetter handles retrieval of single dead letter
+func (h *Handlers) getDeadLetter(params deadlettersapi.GetDeadLetterParams, principal interface{}) middleware.Responder {
+	defer trace.Trace("getDeadLetter")()
+
+	sp, _ := utils.AddHTTPTracing(params.HTTPRequest, "EventManager.getDeadLetter")
+	defer sp.Finish()
+
+	opts := entitystore.Options{
+		Filter: entitystore.FilterEverything(),
+	}
+	var err error
+	opts.Filter, err = utils.ParseTags(opts.Filter, params.Tags)
+	if err != nil {
+		log.Error(err)
+		return deadlettersapi.NewGetDeadLetterBadRequest().WithPayload(
+			&models.Error{
+				Code:    http.StatusBadRequest,
+				Message: swag.String(err.Error()),
+			})
+	}
+	dl := entities.DeadLetter{}
+	if err = h.store.Get(h.orgID, params.DeadLetterName, opts, &dl); err != nil {
+		log.Debugf("store error when getting dead letter: %+v", err)
+		return deadlettersapi.NewGetDeadLetterNotFound().WithPayload(
+			&models.Error{
+				Code:    http.StatusNotFound,
+				Message: swag.String(fmt.Sprintf("dead letter %s not found", params.DeadLetterName)),
+			})
+	}
+	return deadlettersapi.NewGetDeadLetterOK().WithPayload(dl.ToModel())
+}
+
+// getDeadLetters handles retrieval of dead letter list
+func (h *Handlers) getDeadLetters(params deadlettersapi.GetDeadLettersParams, principal interface{}) middleware.Responder {
+	defer trace.Trace("getDeadLetters")()
+
+	sp, _ := utils.AddHTTPTracing(params.HTTPRequest, "EventManager.getDeadLetters")
+	defer sp.Finish()
+
+	opts := entitystore.Options{
+		Filter: entitystore.FilterEverything(),
+	}
+	if params.Subscription != nil {
+		opts.Filter.Add(
+			entitystore.FilterStat{
+				Scope:   entitystore.FilterScopeExtra,
+				Subject: "Subscription",
+				Verb:    entitystore.FilterVerbEqual,
+				Object:  *params.Subscription,
+			})
+	}
+	var err error
+	opts.Filter, err = utils.ParseTags(opts.Filter, params.Tags)
+	if err == nil {
+		err = utils.ParsePage(&opts, params.Limit, params.Continue, params.SortBy)
+	}
+	if err != nil {
+		log.Error(err)
+		return deadlettersapi.NewGetDeadLettersBadRequest().WithPayload(
+			&models.Error{
+				Code:    http.StatusBadRequest,
+				Message: swag.String(err.Error()),
+			})
+	}
+
+	var deadLetters []*entities.DeadLetter
+	next, err := utils.ListPage(h.store, h.orgID, opts, &deadLetters)
+	if err != nil {
+		log.Errorf("store error when listing dead letters: %+v", err)
+		return deadlettersapi.NewGetDeadLettersDefault(http.StatusInternalServerError).WithPayload(
+			&models.Error{
+				Code:    http.StatusInternalServerError,
+				Message: swag.String("internal server error when getting dead letters"),
+			})
+	}
+	var deadLetterModels []*models.DeadLetter
+	for _, dl := range deadLetters {
+		deadLetterModels = append(deadLetterModels, dl.ToModel())
+	}
+	return deadlettersapi.NewGetDeadLettersOK().WithXContinue(next).WithPayload(deadLetterModels)
+}
+
+// deleteDeadLetter handles deletion of a dead letter
+func (h *Handlers) deleteDeadLetter(params deadlettersapi.DeleteDeadLetterParams, principal interface{}) middleware.Responder {
+	defer trace.Trace("deleteDeadLetter")()
+
+	sp, _ := utils.AddHTTPTracing(params.HTTPRequest, "EventManager.deleteDeadLetter")
+	defer sp.Finish()
+
+	opts := entitystore.Options{
+		Filter: entitystore.FilterEverything(),
+	}
+	var err error
+	opts.Filter, err = utils.ParseTags(opts.Filter, params.Tags)
+	if err != nil {
+		log.Error(err)
+		return deadlettersapi.NewDeleteDeadLetterBadRequest().WithPayload(
+			&models.Error{
+				Code:    http.StatusBadRequest,
+				Message: swag.String(err.Error()),
+			})
+	}
+	dl := entities.DeadLetter{}
+	if err = h.store.Get(h.orgID, params.DeadLetterName, opts, &dl); err != nil {
+		log.Debugf("store error when getting dead letter: %+v", err)
+		return deadlettersapi.NewDeleteDeadLetterNotFound().WithPayload(
+			&models.Error{
+				Code:    http.StatusNotFound,
+				Message: swag.String(fmt.Sprintf("dead letter %s not found", params.DeadLetterName)),
+			})
+	}
+	if err = h.store.Delete(h.orgID, dl.Name, &dl); err != nil {
+		log.Errorf("store error when deleting dead letter %s: %+v", dl.Name, err)
+		return deadlettersapi.NewDeleteDeadLetterInternalServerError().WithPayload(
+			&models.Error{
+				Code:    http.StatusInternalServerError,
+				Message: swag.String("internal server error when deleting a dead letter"),
+			})
+	}
+	return deadlettersapi.NewDeleteDeadLetterOK().WithPayload(dl.ToModel())
+}
+
+// replayDeadLetter handles replaying of a dead letter. The dead letter is deleted if the function run succeeds.
+func (h *Handlers) replayDeadLetter(params deadlettersapi.ReplayDeadLetterParams, principal interface{}) middleware.Responder {
+	defer trace.Trace("replayDeadLetter")()
+
+	sp, spCtx := utils.AddHTTPTracing(params.HTTPRequest, "EventManager.replayDeadLetter")
+	defer sp.Finish()
+
+	opts := entitystore.Options{
+		Filter: entitystore.FilterEverything(),
+	}
+	var err error
+	opts.Filter, err = utils.ParseTags(opts.Filter, params.Tags)
+	if err != nil {
+		log.Error(err)
+		return deadlettersapi.NewReplayDeadLetterBadRequest().WithPayload(
+			&models.Error{
+				Code:    http.StatusBadRequest,
+				Message: swag.String(err.Error()),
+			})
+	}
+	dl := entities.DeadLetter{}
+	if err = h.store.Get(h.orgID, params.DeadLetterName, opts, &dl); err != nil {
+		log.Debugf("store error when getting dead letter: %+v", err)
+		return deadlettersapi.NewReplayDeadLetterNotFound().WithPayload(
+			&models.Error{
+				Code:    http.StatusNotFound,
+				Message: swag.String(fmt.Sprintf("dead letter %s not found", params.DeadLetterName)),
+			})
+	}
+	sub := entities.Subscription{}
+	if err = h.store.Get(h.orgID, dl.Subscription, entitystore.Options{Filter: entitystore.FilterEverything()}, &sub); err != nil {
+		log.Debugf("store error when getting subscription: %+v", err)
+		return deadlettersapi.NewReplayDeadLetterNotFound().WithPayload(
+			&models.Error{
+				Code:    http.StatusNotFound,
+				Message: swag.String(fmt.Sprintf("subscription %s of dead letter %s not found", dl.Subscription, dl.Name)),
+			})
+	}
+
+	if err = h.manager.Replay(spCtx, &sub, &dl.Event); err != nil {
+		dl.Attempts++
+		dl.Reason = entitystore.Reason{err.Error()}
+		if _, err := h.store.Update(dl.Revision, &dl); err != nil {
+			log.Errorf("store error when updating dead letter %s: %+v", dl.Name, err)
+		}
+		return deadlettersapi.NewReplayDeadLetterBadGateway().WithPayload(
+			&models.Error{
+				Code:    http.StatusBadGateway,
+				Message: swag.String(fmt.Sprintf("error replaying dead letter %s: %s", dl.Name, err)),
+			})
+	}
+	if err = h.store.Delete(h.orgID, dl.Name, &dl); err != nil {
+		log.Errorf("store error when deleting dead letter %s: %+v", dl.Name, err)
+		return deadlettersapi.NewReplayDeadLetterInternalServerError().WithPayload(
+			&models.Error{
+				Code:    http.StatusInternalServerError,
+				Message: swag.String("internal server error when deleting a replayed dead letter"),
+			})
+	}
+	return deadlettersapi.NewReplayDeadLetterOK().WithPayload(dl.ToModel())
+}
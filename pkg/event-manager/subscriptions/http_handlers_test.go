@@ -6,16 +6,24 @@
 package subscriptions
 
 import (
+	"errors"
 	"net/http"
 	"net/http/httptest"
 	"testing"
 
 	"github.com/go-openapi/swag"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 
+	"github.com/vmware/dispatch/pkg/entity-store"
 	"github.com/vmware/dispatch/pkg/event-manager/gen/models"
 	"github.com/vmware/dispatch/pkg/event-manager/gen/restapi/operations"
+	"github.com/vmware/dispatch/pkg/event-manager/gen/restapi/operations/deadletters"
 	"github.com/vmware/dispatch/pkg/event-manager/gen/restapi/operations/subscriptions"
+	"github.com/vmware/dispatch/pkg/event-manager/subscriptions/entities"
+	"github.com/vmware/dispatch/pkg/event-manager/subscriptions/mocks"
+	"github.com/vmware/dispatch/pkg/events"
 	helpers "github.com/vmware/dispatch/pkg/testing/api"
 )
 
@@ -56,7 +64,7 @@ func addSubscriptionEntityWithError(t *testing.T, api *operations.EventManagerAP
 func TestSubscriptionsAddSubscriptionHandlerError(t *testing.T) {
 	api := operations.NewEventManagerAPI(nil)
 	es := helpers.MakeEntityStore(t)
-	h := Handlers{"", es, nil}
+	h := Handlers{"", es, nil, nil}
 	helpers.MakeAPI(t, h.ConfigureHandlers, api)
 
 	respBody := addSubscriptionEntityWithError(t, api, "test.topic", "testfunction")
@@ -64,10 +72,34 @@ func TestSubscriptionsAddSubscriptionHandlerError(t *testing.T) {
 	assert.Equal(t, int64(http.StatusBadRequest), respBody.Code)
 }
 
+func TestSubscriptionsAddSubscriptionHandlerInvalidFilter(t *testing.T) {
+	api := operations.NewEventManagerAPI(nil)
+	es := helpers.MakeEntityStore(t)
+	h := Handlers{"", es, nil, nil}
+	helpers.MakeAPI(t, h.ConfigureHandlers, api)
+
+	reqBody := &models.Subscription{
+		Name:       swag.String("mysubscription"),
+		EventType:  swag.String("test.topic"),
+		Function:   swag.String("testfunction"),
+		SourceType: swag.String("*"),
+		Filter:     `data.vm.name startsWith`,
+	}
+	r := httptest.NewRequest("POST", "/v1/event/subscriptions", nil)
+	params := subscriptions.AddSubscriptionParams{
+		HTTPRequest: r,
+		Body:        reqBody,
+	}
+	responder := api.SubscriptionsAddSubscriptionHandler.Handle(params, "testCookie")
+	var respBody models.Error
+	helpers.HandlerRequest(t, responder, &respBody, 400)
+	assert.Contains(t, *respBody.Message, "error parsing the filter")
+}
+
 func TestSubscriptionsAddSubscriptionHandler(t *testing.T) {
 	api := operations.NewEventManagerAPI(nil)
 	es := helpers.MakeEntityStore(t)
-	h := Handlers{"", es, nil}
+	h := Handlers{"", es, nil, nil}
 	helpers.MakeAPI(t, h.ConfigureHandlers, api)
 
 	respBody := addSubscriptionEntity(t, api, "mysubscription", "test.topic", "testfunction")
@@ -78,7 +110,7 @@ func TestSubscriptionsAddSubscriptionHandler(t *testing.T) {
 func TestSubscriptionsGetSubscriptionHandler(t *testing.T) {
 	api := operations.NewEventManagerAPI(nil)
 	es := helpers.MakeEntityStore(t)
-	h := Handlers{"", es, nil}
+	h := Handlers{"", es, nil, nil}
 	helpers.MakeAPI(t, h.ConfigureHandlers, api)
 
 	addBody := addSubscriptionEntity(t, api, "mysubscription", "test.topic", "testfunction")
@@ -114,7 +146,7 @@ func TestSubscriptionsGetSubscriptionHandler(t *testing.T) {
 func TestSubscriptionsDeleteSubscriptionHandler(t *testing.T) {
 	api := operations.NewEventManagerAPI(nil)
 	es := helpers.MakeEntityStore(t)
-	h := Handlers{"", es, nil}
+	h := Handlers{"", es, nil, nil}
 	helpers.MakeAPI(t, h.ConfigureHandlers, api)
 
 	addBody := addSubscriptionEntity(t, api, "mysubscription", "test.topic", "testfunction")
@@ -143,3 +175,107 @@ func TestSubscriptionsDeleteSubscriptionHandler(t *testing.T) {
 	getResponder = api.SubscriptionsGetSubscriptionsHandler.Handle(get, "testCookie")
 	helpers.HandlerRequest(t, getResponder, &getBody, 200)
 }
+
+func addDeadLetterEntity(t *testing.T, es entitystore.EntityStore, name, subscription string) {
+	_, err := es.Add(&entities.DeadLetter{
+		BaseEntity: entitystore.BaseEntity{
+			Name:   name,
+			Reason: entitystore.Reason{"testerror"},
+		},
+		Subscription: subscription,
+		Function:     "testfunction",
+		Event:        events.NewCloudEventWithDefaults("test.topic"),
+		Attempts:     3,
+	})
+	require.NoError(t, err)
+}
+
+func TestDeadLettersGetDeadLettersHandler(t *testing.T) {
+	api := operations.NewEventManagerAPI(nil)
+	es := helpers.MakeEntityStore(t)
+	h := Handlers{"", es, nil, nil}
+	helpers.MakeAPI(t, h.ConfigureHandlers, api)
+
+	addDeadLetterEntity(t, es, "dl1", "sub1")
+	addDeadLetterEntity(t, es, "dl2", "sub2")
+
+	r := httptest.NewRequest("GET", "/v1/event/deadletters", nil)
+	get := deadletters.GetDeadLettersParams{
+		HTTPRequest: r,
+	}
+	var getBody []models.DeadLetter
+	helpers.HandlerRequest(t, api.DeadlettersGetDeadLettersHandler.Handle(get, "testCookie"), &getBody, 200)
+	assert.Len(t, getBody, 2)
+
+	get.Subscription = swag.String("sub2")
+	helpers.HandlerRequest(t, api.DeadlettersGetDeadLettersHandler.Handle(get, "testCookie"), &getBody, 200)
+	require.Len(t, getBody, 1)
+	assert.Equal(t, "dl2", getBody[0].Name)
+	assert.Equal(t, "testerror", getBody[0].Reason)
+	assert.Equal(t, int64(3), getBody[0].Attempts)
+	assert.Equal(t, "test.topic", *getBody[0].Event.EventType)
+
+	r = httptest.NewRequest("GET", "/v1/event/deadletters/dl1", nil)
+	getOne := deadletters.GetDeadLetterParams{
+		HTTPRequest:    r,
+		DeadLetterName: "dl1",
+	}
+	var getOneBody models.DeadLetter
+	helpers.HandlerRequest(t, api.DeadlettersGetDeadLetterHandler.Handle(getOne, "testCookie"), &getOneBody, 200)
+	assert.Equal(t, "sub1", getOneBody.Subscription)
+}
+
+func TestDeadLettersReplayDeadLetterHandler(t *testing.T) {
+	api := operations.NewEventManagerAPI(nil)
+	es := helpers.MakeEntityStore(t)
+	manager := &mocks.Manager{}
+	h := Handlers{"", es, nil, manager}
+	helpers.MakeAPI(t, h.ConfigureHandlers, api)
+
+	addSubscriptionEntity(t, api, "sub1", "test.topic", "testfunction")
+	addDeadLetterEntity(t, es, "dl1", "sub1")
+
+	r := httptest.NewRequest("POST", "/v1/event/deadletters/dl1/replay", nil)
+	replay := deadletters.ReplayDeadLetterParams{
+		HTTPRequest:    r,
+		DeadLetterName: "dl1",
+	}
+
+	manager.On("Replay", mock.Anything, mock.Anything, mock.Anything).Return(errors.New("testerror")).Once()
+	var errorBody models.Error
+	helpers.HandlerRequest(t, api.DeadlettersReplayDeadLetterHandler.Handle(replay, "testCookie"), &errorBody, 502)
+	dl := entities.DeadLetter{}
+	require.NoError(t, es.Get("", "dl1", entitystore.Options{}, &dl))
+	assert.Equal(t, 4, dl.Attempts)
+
+	manager.On("Replay", mock.Anything, mock.Anything, mock.Anything).Return(nil).Once()
+	var replayBody models.DeadLetter
+	helpers.HandlerRequest(t, api.DeadlettersReplayDeadLetterHandler.Handle(replay, "testCookie"), &replayBody, 200)
+	assert.Equal(t, "dl1", replayBody.Name)
+	sub := manager.Calls[1].Arguments.Get(1).(*entities.Subscription)
+	assert.Equal(t, "testfunction", sub.Function)
+	assert.Error(t, es.Get("", "dl1", entitystore.Options{}, &dl))
+
+	helpers.HandlerRequest(t, api.DeadlettersReplayDeadLetterHandler.Handle(replay, "testCookie"), &errorBody, 404)
+}
+
+func TestDeadLettersDeleteDeadLetterHandler(t *testing.T) {
+	api := operations.NewEventManagerAPI(nil)
+	es := helpers.MakeEntityStore(t)
+	h := Handlers{"", es, nil, nil}
+	helpers.MakeAPI(t, h.ConfigureHandlers, api)
+
+	addDeadLetterEntity(t, es, "dl1", "sub1")
+
+	r := httptest.NewRequest("DELETE", "/v1/event/deadletters/dl1", nil)
+	del := deadletters.DeleteDeadLetterParams{
+		HTTPRequest:    r,
+		DeadLetterName: "dl1",
+	}
+	var delBody models.DeadLetter
+	helpers.HandlerRequest(t, api.DeadlettersDeleteDeadLetterHandler.Handle(del, "testCookie"), &delBody, 200)
+	assert.Equal(t, "dl1", delBody.Name)
+
+	var errorBody models.Error
+	helpers.HandlerRequest(t, api.DeadlettersDeleteDeadLetterHandler.Handle(del, "testCookie"), &errorBody, 404)
+}
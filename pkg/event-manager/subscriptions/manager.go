@@ -9,41 +9,64 @@ import (
 	"context"
 	"fmt"
 	"sync"
+	"time"
 
 	"github.com/opentracing/opentracing-go"
 	"github.com/pkg/errors"
+	"github.com/satori/go.uuid"
 	log "github.com/sirupsen/logrus"
 
 	"github.com/vmware/dispatch/pkg/client"
+	"github.com/vmware/dispatch/pkg/entity-store"
 	"github.com/vmware/dispatch/pkg/event-manager/helpers"
+	"github.com/vmware/dispatch/pkg/event-manager/history"
+	historyentities "github.com/vmware/dispatch/pkg/event-manager/history/entities"
 	"github.com/vmware/dispatch/pkg/event-manager/subscriptions/entities"
 	"github.com/vmware/dispatch/pkg/events"
+	"github.com/vmware/dispatch/pkg/events/filter"
 	"github.com/vmware/dispatch/pkg/function-manager/gen/models"
 	"github.com/vmware/dispatch/pkg/trace"
+	"github.com/vmware/dispatch/pkg/utils"
 )
 
+// DeadLetterExtension is the CloudEvent extension holding details of a dead-lettered event
+const DeadLetterExtension = "dispatchdeadletter"
+
 // Manager defines the subscription manager interface
 type Manager interface {
 	Run([]*entities.Subscription) error
 	Create(context.Context, *entities.Subscription) error
 	Delete(context.Context, *entities.Subscription) error
+	Replay(context.Context, *entities.Subscription, *events.CloudEvent) error
+	Shutdown()
 }
 
 type defaultManager struct {
 	queue    events.Transport
 	fnClient client.FunctionsClient
+	store    entitystore.EntityStore
+	recorder history.Recorder
 
 	sync.RWMutex
 	activeSubs map[string]events.Subscription
+	// cancelRetries end the retries of the deliveries of each active subscription
+	cancelRetries map[string]context.CancelFunc
+
+	// retries tracks the deliveries retried in the background
+	retries sync.WaitGroup
 }
 
 // NewManager creates a new subscription manager
-func NewManager(mq events.Transport, fnClient client.FunctionsClient) (Manager, error) {
+func NewManager(mq events.Transport, fnClient client.FunctionsClient, store entitystore.EntityStore, recorder history.Recorder) (Manager, error) {
 	defer trace.Trace("")()
 	ec := defaultManager{
 		queue:      mq,
 		fnClient:   fnClient,
+		store:      store,
+		recorder:   recorder,
 		activeSubs: make(map[string]events.Subscription),
+
+		cancelRetries: make(map[string]context.CancelFunc),
 	}
 
 	return &ec, nil
@@ -71,14 +94,27 @@ func (m *defaultManager) Create(ctx context.Context, sub *entities.Subscription)
 		eventSub.Unsubscribe()
 		delete(m.activeSubs, sub.ID)
 	}
+	m.stopRetries(sub)
+	var expr *filter.Expression
+	if sub.Filter != "" {
+		var err error
+		if expr, err = filter.Parse(sub.Filter); err != nil {
+			err = errors.Wrapf(err, "invalid filter of subscription %s", sub.Name)
+			log.Error(err)
+			return err
+		}
+	}
 	topic := fmt.Sprintf("%s.%s", sub.SourceType, sub.EventType)
-	eventSub, err := m.queue.Subscribe(ctx, topic, m.handler(sub))
+	retryCtx, cancel := context.WithCancel(context.Background())
+	eventSub, err := m.queue.Subscribe(ctx, topic, m.handler(retryCtx, sub, expr))
 	if err != nil {
+		cancel()
 		err = errors.Wrapf(err, "unable to create an EventQueue subscription for event %s and function %s", sub.EventType, sub.Function)
 		log.Error(err)
 		return err
 	}
 	m.activeSubs[sub.ID] = eventSub
+	m.cancelRetries[sub.ID] = cancel
 	return nil
 }
 
@@ -92,28 +128,59 @@ func (m *defaultManager) Delete(ctx context.Context, sub *entities.Subscription)
 		eventSub.Unsubscribe()
 		delete(m.activeSubs, sub.ID)
 	}
+	m.stopRetries(sub)
 	log.Debugf("Deleting subscription topic=%s id=%s revision=%d", sub.EventType, sub.Name, sub.Revision)
 	return nil
 }
 
-// Shutdown ends event controller loop
+// stopRetries ends the retries of the deliveries of a subscription being deleted or replaced, the caller holds the lock
+func (m *defaultManager) stopRetries(sub *entities.Subscription) {
+	if cancel, ok := m.cancelRetries[sub.ID]; ok {
+		cancel()
+		delete(m.cancelRetries, sub.ID)
+	}
+}
+
+// Replay runs the function of a subscription once for a previously dead-lettered event.
+func (m *defaultManager) Replay(ctx context.Context, sub *entities.Subscription, event *events.CloudEvent) error {
+	defer trace.Tracef("subscription %s, event %s", sub.Name, event.EventID)()
+
+	_, err := m.run(ctx, sub, event)
+	return err
+}
+
+// Shutdown ends event controller loop, once the deliveries being retried are done
 func (m *defaultManager) Shutdown() {
 	defer trace.Trace("")()
 	log.Infof("Event controller shutdown")
 	m.Lock()
-	defer m.Unlock()
 	for _, sub := range m.activeSubs {
 		sub.Unsubscribe()
 	}
+	m.Unlock()
+	m.retries.Wait()
 }
 
 // handler creates a function to handle the incoming event. it takes name of the function to be invoked as an argument.
-func (m *defaultManager) handler(sub *entities.Subscription) func(context.Context, *events.CloudEvent) {
+// Events not matching the filter expression (if not nil) are skipped. The deliveries are retried until retryCtx ends.
+func (m *defaultManager) handler(retryCtx context.Context, sub *entities.Subscription, expr *filter.Expression) func(context.Context, *events.CloudEvent) {
 	defer trace.Tracef("function name:%s", sub.Function)()
 
 	return func(ctx context.Context, event *events.CloudEvent) {
 		trace.Tracef("HandlerClosure(). function name:%s, event:%s", sub.Name, event.EventID)()
-		sp, _ := opentracing.StartSpanFromContext(
+		// the subscription topic may be a pattern, the record holds the topic the event is replayed to
+		record := &historyentities.EventRecord{
+			Topic: event.DefaultTopic(),
+			Event: *event,
+		}
+		if err := m.recorder.RecordEvent(ctx, record); err != nil {
+			log.Warnf("Unable to record event %s: %+v", event.EventID, err)
+		}
+		if expr != nil && !expr.Match(event) {
+			log.Debugf("Event %s does not match filter of subscription %s, skipping", event.EventID, sub.Name)
+			return
+		}
+		sp, spCtx := opentracing.StartSpanFromContext(
 			ctx,
 			"EventManager.EventHandler",
 			opentracing.Tag{Key: "subscriptionName", Value: sub.Name},
@@ -122,12 +189,130 @@ func (m *defaultManager) handler(sub *entities.Subscription) func(context.Contex
 		defer sp.Finish()
 
 		// TODO: Pass tracing context once Function Manager is tracing-aware
-		m.runFunction(sub.Function, event, sub.Secrets)
+		m.deliver(spCtx, retryCtx, sub, event)
 	}
 }
 
+// deliver runs the function of a subscription, retrying according to the subscription delivery policy.
+// Events which could not be delivered are dead-lettered. The retries run in the background, so that the transport
+// acknowledges the event and passes the next ones to the handler meanwhile. The retries stop when retryCtx ends, i.e.
+// when the subscription is deleted or replaced.
+func (m *defaultManager) deliver(ctx, retryCtx context.Context, sub *entities.Subscription, event *events.CloudEvent) {
+	defer trace.Tracef("subscription %s, event %s", sub.Name, event.EventID)()
+
+	policy := sub.DeliveryPolicy.WithDefaults()
+	_, err := m.run(ctx, sub, event)
+	if err == nil {
+		return
+	}
+	if policy.MaxAttempts <= 1 {
+		m.deadLetter(ctx, sub, &policy, event, 1, err)
+		return
+	}
+	m.retries.Add(1)
+	go func() {
+		defer m.retries.Done()
+		// the context of the handler ends with it
+		m.retry(retryCtx, sub, &policy, event, err)
+	}()
+}
+
+// retry runs the function of a subscription again, after a first attempt which failed with err. The delivery is
+// abandoned, without dead-lettering the event, once ctx ends.
+func (m *defaultManager) retry(ctx context.Context, sub *entities.Subscription, policy *entities.DeliveryPolicy, event *events.CloudEvent, err error) {
+	defer trace.Tracef("subscription %s, event %s", sub.Name, event.EventID)()
+
+	attempts := 1
+	first := true
+	abandoned := false
+	// Backoff retries until its timeout, so the number of attempts is enforced by reporting success after the last one
+	utils.Backoff(policy.RetryTimeout, func() error {
+		if first {
+			// the first attempt ran already, Backoff waits before the next one
+			first = false
+			return err
+		}
+		if ctx.Err() != nil {
+			abandoned = true
+			return nil
+		}
+		attempts++
+		_, err = m.run(ctx, sub, event)
+		if err == nil || attempts >= policy.MaxAttempts {
+			return nil
+		}
+		return err
+	})
+	if abandoned {
+		log.Infof("Subscription %s deleted, abandoning the delivery of event %s after %d attempts", sub.Name, event.EventID, attempts)
+		return
+	}
+	if err != nil {
+		m.deadLetter(ctx, sub, policy, event, attempts, err)
+	}
+}
+
+// deadLetter stores an event which could not be delivered, and publishes it to the dead-letter topic
+func (m *defaultManager) deadLetter(ctx context.Context, sub *entities.Subscription, policy *entities.DeliveryPolicy, event *events.CloudEvent, attempts int, cause error) {
+	defer trace.Tracef("subscription %s, event %s", sub.Name, event.EventID)()
+
+	log.Warnf("Giving up running function %s for event %s after %d attempts, dead-lettering: %s", sub.Function, event.EventID, attempts, cause)
+
+	dl := &entities.DeadLetter{
+		BaseEntity: entitystore.BaseEntity{
+			OrganizationID: sub.OrganizationID,
+			Name:           uuid.NewV4().String(),
+			Status:         entitystore.StatusREADY,
+			Reason:         entitystore.Reason{cause.Error()},
+			Tags:           sub.Tags,
+		},
+		Subscription: sub.Name,
+		Function:     sub.Function,
+		Event:        *event,
+		Attempts:     attempts,
+	}
+	if _, err := m.store.Add(dl); err != nil {
+		log.Errorf("Unable to store dead letter for event %s: %+v", event.EventID, err)
+	}
+
+	dlEvent := *event
+	dlEvent.Extensions = make(events.CloudEventExtensions, len(event.Extensions)+1)
+	for k, v := range event.Extensions {
+		dlEvent.Extensions[k] = v
+	}
+	dlEvent.Extensions[DeadLetterExtension] = map[string]interface{}{
+		"name":         dl.Name,
+		"subscription": sub.Name,
+		"function":     sub.Function,
+		"attempts":     attempts,
+		"reason":       cause.Error(),
+	}
+	if err := m.queue.Publish(ctx, &dlEvent, policy.DeadLetterTopic, sub.OrganizationID); err != nil {
+		log.Errorf("Unable to publish event %s to dead-letter topic %s: %+v", event.EventID, policy.DeadLetterTopic, err)
+	}
+}
+
+// run runs the function of a subscription, and records the run in event history
+func (m *defaultManager) run(ctx context.Context, sub *entities.Subscription, event *events.CloudEvent) (*client.FunctionRun, error) {
+	result, err := m.runFunction(sub.Function, event, sub.Secrets)
+	run := &historyentities.Run{
+		Subscription: sub.Name,
+		Function:     sub.Function,
+		Time:         time.Now(),
+	}
+	if err != nil {
+		run.Error = err.Error()
+	} else {
+		run.Run = string(result.Name)
+	}
+	if err := m.recorder.RecordRun(ctx, event, run); err != nil {
+		log.Warnf("Unable to record run of function %s for event %s: %+v", sub.Function, event.EventID, err)
+	}
+	return result, err
+}
+
 // executes a function by connecting to function manager
-func (m *defaultManager) runFunction(fnName string, event *events.CloudEvent, secrets []string) {
+func (m *defaultManager) runFunction(fnName string, event *events.CloudEvent, secrets []string) (*client.FunctionRun, error) {
 	defer trace.Tracef("function:%s", fnName)()
 
 	run := client.FunctionRun{}
@@ -141,8 +326,8 @@ func (m *defaultManager) runFunction(fnName string, event *events.CloudEvent, se
 	result, err := m.fnClient.RunFunction(context.Background(), &run)
 	if err != nil {
 		log.Warnf("Unable to run function %s, error from function manager: %+v", fnName, err)
-		return
+		return nil, err
 	}
 	log.Debugf("Function %s returned %+v", result.FunctionName, result.Output)
-	return
+	return result, nil
 }
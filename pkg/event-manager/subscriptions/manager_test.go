@@ -6,34 +6,194 @@
 package subscriptions
 
 import (
+	"context"
 	"errors"
 	"testing"
+	"time"
 
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 
 	"github.com/vmware/dispatch/pkg/client"
 	clientmocks "github.com/vmware/dispatch/pkg/client/mocks"
+	"github.com/vmware/dispatch/pkg/entity-store"
+	historyentities "github.com/vmware/dispatch/pkg/event-manager/history/entities"
+	historymocks "github.com/vmware/dispatch/pkg/event-manager/history/mocks"
+	"github.com/vmware/dispatch/pkg/event-manager/subscriptions/entities"
 	"github.com/vmware/dispatch/pkg/events"
 	eventsmocks "github.com/vmware/dispatch/pkg/events/mocks"
+	helpers "github.com/vmware/dispatch/pkg/testing/api"
 )
 
-func mockSubscriptionManager(queue events.Transport, fnClient client.FunctionsClient) *defaultManager {
+func mockSubscriptionManager(queue events.Transport, fnClient client.FunctionsClient, store entitystore.EntityStore) *defaultManager {
+	recorder := &historymocks.Recorder{}
+	recorder.On("RecordEvent", mock.Anything, mock.Anything).Return(nil)
+	recorder.On("RecordRun", mock.Anything, mock.Anything, mock.Anything).Return(nil)
 	return &defaultManager{
 		queue:      queue,
 		fnClient:   fnClient,
+		store:      store,
+		recorder:   recorder,
 		activeSubs: make(map[string]events.Subscription),
+
+		cancelRetries: make(map[string]context.CancelFunc),
 	}
 }
 
 func TestRunFunction(t *testing.T) {
 	fnClient := &clientmocks.FunctionsClient{}
 	queue := &eventsmocks.Transport{}
-	manager := mockSubscriptionManager(queue, fnClient)
+	manager := mockSubscriptionManager(queue, fnClient, nil)
 	ev := &events.CloudEvent{}
 	fnClient.On("RunFunction", mock.Anything, mock.AnythingOfType("*client.FunctionRun")).Return(&client.FunctionRun{}, nil).Once()
-	manager.runFunction("testFunction", ev, []string{"secret1", "secret2"})
+	_, err := manager.runFunction("testFunction", ev, []string{"secret1", "secret2"})
+	assert.NoError(t, err)
 
 	fnClient.On("RunFunction", mock.Anything, mock.AnythingOfType("*client.FunctionRun")).Return(&client.FunctionRun{}, errors.New("testerror")).Once()
-	manager.runFunction("testFunction", ev, nil)
+	_, err = manager.runFunction("testFunction", ev, nil)
+	assert.Error(t, err)
 	fnClient.AssertNumberOfCalls(t, "RunFunction", 2)
 }
+
+func testSubscription(policy entities.DeliveryPolicy) *entities.Subscription {
+	return &entities.Subscription{
+		BaseEntity: entitystore.BaseEntity{
+			Name: "sub1",
+			Tags: entitystore.Tags{"Application": "app1"},
+		},
+		EventType:      "test.topic",
+		Function:       "testFunction",
+		DeliveryPolicy: policy,
+	}
+}
+
+func TestDeliverRetries(t *testing.T) {
+	fnClient := &clientmocks.FunctionsClient{}
+	queue := &eventsmocks.Transport{}
+	es := helpers.MakeEntityStore(t)
+	manager := mockSubscriptionManager(queue, fnClient, es)
+	ev := events.NewCloudEventWithDefaults("test.topic")
+
+	fnClient.On("RunFunction", mock.Anything, mock.Anything).Return(nil, errors.New("testerror")).Once()
+	fnClient.On("RunFunction", mock.Anything, mock.Anything).Return(&client.FunctionRun{}, nil).Once()
+	manager.deliver(context.Background(), context.Background(), testSubscription(entities.DeliveryPolicy{MaxAttempts: 3, RetryTimeout: 10 * time.Second}), &ev)
+	// the handler does not wait for the retries
+	fnClient.AssertNumberOfCalls(t, "RunFunction", 1)
+	manager.Shutdown()
+
+	fnClient.AssertNumberOfCalls(t, "RunFunction", 2)
+	queue.AssertNotCalled(t, "Publish", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	var deadLetters []*entities.DeadLetter
+	require.NoError(t, es.List("", entitystore.Options{}, &deadLetters))
+	assert.Len(t, deadLetters, 0)
+}
+
+func TestDeliverDeadLetter(t *testing.T) {
+	fnClient := &clientmocks.FunctionsClient{}
+	queue := &eventsmocks.Transport{}
+	es := helpers.MakeEntityStore(t)
+	manager := mockSubscriptionManager(queue, fnClient, es)
+	ev := events.NewCloudEventWithDefaults("test.topic")
+
+	fnClient.On("RunFunction", mock.Anything, mock.Anything).Return(nil, errors.New("testerror"))
+	queue.On("Publish", mock.Anything, mock.Anything, "custom.deadletter", mock.Anything).Return(nil).Once()
+	manager.deliver(context.Background(), context.Background(), testSubscription(entities.DeliveryPolicy{MaxAttempts: 2, RetryTimeout: 10 * time.Second, DeadLetterTopic: "custom.deadletter"}), &ev)
+	manager.Shutdown()
+
+	fnClient.AssertNumberOfCalls(t, "RunFunction", 2)
+	queue.AssertExpectations(t)
+	published := queue.Calls[0].Arguments.Get(1).(*events.CloudEvent)
+	assert.Equal(t, ev.EventID, published.EventID)
+	assert.Contains(t, published.Extensions, DeadLetterExtension)
+	assert.Nil(t, ev.Extensions)
+
+	var deadLetters []*entities.DeadLetter
+	require.NoError(t, es.List("", entitystore.Options{}, &deadLetters))
+	require.Len(t, deadLetters, 1)
+	assert.Equal(t, "sub1", deadLetters[0].Subscription)
+	assert.Equal(t, "testFunction", deadLetters[0].Function)
+	assert.Equal(t, 2, deadLetters[0].Attempts)
+	assert.Equal(t, ev.EventID, deadLetters[0].Event.EventID)
+	assert.Equal(t, entitystore.Reason{"testerror"}, deadLetters[0].Reason)
+	assert.Equal(t, "app1", deadLetters[0].Tags["Application"])
+}
+
+func TestDeleteStopsRetries(t *testing.T) {
+	fnClient := &clientmocks.FunctionsClient{}
+	queue := &eventsmocks.Transport{}
+	es := helpers.MakeEntityStore(t)
+	manager := mockSubscriptionManager(queue, fnClient, es)
+	sub := testSubscription(entities.DeliveryPolicy{MaxAttempts: 10, RetryTimeout: 10 * time.Second})
+	eventSub := &eventsmocks.Subscription{}
+	eventSub.On("Unsubscribe").Return(nil)
+	queue.On("Subscribe", mock.Anything, ".test.topic", mock.Anything).Return(eventSub, nil).Once()
+	require.NoError(t, manager.Create(context.Background(), sub))
+	handler := queue.Calls[0].Arguments.Get(2).(events.Handler)
+
+	fnClient.On("RunFunction", mock.Anything, mock.Anything).Return(nil, errors.New("testerror"))
+	ev := events.NewCloudEventWithDefaults("test.topic")
+	handler(context.Background(), &ev)
+	require.NoError(t, manager.Delete(context.Background(), sub))
+	manager.Shutdown()
+
+	// the retry is abandoned, the event is not dead-lettered
+	fnClient.AssertNumberOfCalls(t, "RunFunction", 1)
+	queue.AssertNotCalled(t, "Publish", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	var deadLetters []*entities.DeadLetter
+	require.NoError(t, es.List("", entitystore.Options{}, &deadLetters))
+	assert.Len(t, deadLetters, 0)
+}
+
+func TestHandlerFilter(t *testing.T) {
+	fnClient := &clientmocks.FunctionsClient{}
+	queue := &eventsmocks.Transport{}
+	manager := mockSubscriptionManager(queue, fnClient, nil)
+	sub := testSubscription(entities.DeliveryPolicy{})
+	sub.Filter = `extensions.datacenter == "dc1"`
+	eventSub := &eventsmocks.Subscription{}
+	queue.On("Subscribe", mock.Anything, ".test.topic", mock.Anything).Return(eventSub, nil).Once()
+	require.NoError(t, manager.Create(context.Background(), sub))
+	handler := queue.Calls[0].Arguments.Get(2).(events.Handler)
+
+	fnClient.On("RunFunction", mock.Anything, mock.Anything).Return(&client.FunctionRun{}, nil).Once()
+	ev := events.NewCloudEventWithDefaults("test.topic")
+	handler(context.Background(), &ev)
+	ev.Extensions = events.CloudEventExtensions{"datacenter": "dc1"}
+	handler(context.Background(), &ev)
+	fnClient.AssertNumberOfCalls(t, "RunFunction", 1)
+
+	eventSub.On("Unsubscribe").Return(nil).Once()
+	sub.Filter = `extensions.datacenter ==`
+	assert.Error(t, manager.Create(context.Background(), sub))
+}
+
+func TestHandlerRecordsHistory(t *testing.T) {
+	fnClient := &clientmocks.FunctionsClient{}
+	queue := &eventsmocks.Transport{}
+	recorder := &historymocks.Recorder{}
+	manager := mockSubscriptionManager(queue, fnClient, nil)
+	manager.recorder = recorder
+	sub := testSubscription(entities.DeliveryPolicy{MaxAttempts: 2, RetryTimeout: 10 * time.Second})
+	sub.SourceType = "*"
+	ev := events.NewCloudEventWithDefaults("test.topic")
+	ev.SourceType = "test"
+
+	recorder.On("RecordEvent", mock.Anything, mock.MatchedBy(func(r *historyentities.EventRecord) bool {
+		return r.Topic == "test.test.topic" && r.Event.EventID == ev.EventID && !r.Emitted
+	})).Return(nil).Once()
+	recorder.On("RecordRun", mock.Anything, mock.Anything, mock.MatchedBy(func(r *historyentities.Run) bool {
+		return r.Subscription == "sub1" && r.Function == "testFunction" && r.Error == "testerror"
+	})).Return(nil).Once()
+	recorder.On("RecordRun", mock.Anything, mock.Anything, mock.MatchedBy(func(r *historyentities.Run) bool {
+		return r.Subscription == "sub1" && r.Run == "run1" && r.Error == ""
+	})).Return(nil).Once()
+	fnClient.On("RunFunction", mock.Anything, mock.Anything).Return(nil, errors.New("testerror")).Once()
+	run := &client.FunctionRun{}
+	run.Name = "run1"
+	fnClient.On("RunFunction", mock.Anything, mock.Anything).Return(run, nil).Once()
+
+	manager.handler(context.Background(), sub, nil)(context.Background(), &ev)
+	manager.Shutdown()
+	recorder.AssertExpectations(t)
+}
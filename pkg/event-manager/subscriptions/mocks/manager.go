@@ -3,6 +3,7 @@
 package mocks
 
 import context "context"
+import events "github.com/vmware/dispatch/pkg/events"
 import mock "github.com/stretchr/testify/mock"
 
 import types "github.com/vmware/dispatch/pkg/event-manager/subscriptions/entities"
@@ -40,6 +41,25 @@ func (_m *Manager) Delete(_a0 context.Context, _a1 *types.Subscription) error {
 	return r0
 }
 
+// Replay provides a mock function with given fields: _a0, _a1, _a2
+func (_m *Manager) Replay(_a0 context.Context, _a1 *types.Subscription, _a2 *events.CloudEvent) error {
+	ret := _m.Called(_a0, _a1, _a2)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *types.Subscription, *events.CloudEvent) error); ok {
+		r0 = rf(_a0, _a1, _a2)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Shutdown provides a mock function with given fields:
+func (_m *Manager) Shutdown() {
+	_m.Called()
+}
+
 // Run provides a mock function with given fields: _a0
 func (_m *Manager) Run(_a0 []*types.Subscription) error {
 	ret := _m.Called(_a0)
@@ -4,3 +4,144 @@
 ///////////////////////////////////////////////////////////////////////
 
 package listener
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+
+	"github.com/opentracing/opentracing-go"
+	log "github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"github.com/vmware/dispatch/pkg/events/rpc"
+)
+
+// GRPCListener implements EventListener using gRPC server
+type GRPCListener struct {
+	SharedListener
+	server *grpc.Server
+	addr   string
+}
+
+// NewGRPC creates new gRPC Listener
+func NewGRPC(shared SharedListener, port int) (*GRPCListener, error) {
+	l := &GRPCListener{
+		SharedListener: shared,
+		server:         grpc.NewServer(),
+		addr:           fmt.Sprintf("127.0.0.1:%d", port),
+	}
+	rpc.RegisterEventSidecarServer(l.server, l)
+	return l, nil
+}
+
+// Serve starts serving loop. Blocks until Shutdown() is called.
+func (l *GRPCListener) Serve() error {
+	lis, err := net.Listen("tcp", l.addr)
+	if err != nil {
+		return err
+	}
+	log.Printf("Listening on grpc://%s\n", l.addr)
+	err = l.server.Serve(lis)
+	if err == grpc.ErrServerStopped {
+		return nil
+	}
+	return err
+}
+
+// Shutdown gracefully shuts down the server.
+func (l *GRPCListener) Shutdown() error {
+	log.Printf("Shutting down...")
+	l.server.GracefulStop()
+	return nil
+}
+
+// Send validates and publishes a batch of events. Processing stops at first invalid event.
+func (l *GRPCListener) Send(ctx context.Context, req *rpc.Events) (*rpc.Response, error) {
+	span, spCtx := l.startSpan(ctx, "EventSidecar.Send")
+	defer span.Finish()
+
+	for i, e := range req.GetEvents() {
+		if err := l.handle(spCtx, e, true); err != nil {
+			return &rpc.Response{Count: int32(i)}, err
+		}
+	}
+	return &rpc.Response{Count: int32(len(req.GetEvents()))}, nil
+}
+
+// SendStream validates and publishes events received on the stream. Processing stops at first invalid event.
+func (l *GRPCListener) SendStream(stream rpc.EventSidecar_SendStreamServer) error {
+	span, spCtx := l.startSpan(stream.Context(), "EventSidecar.SendStream")
+	defer span.Finish()
+
+	return l.handleStream(spCtx, stream, true)
+}
+
+// Validate validates a batch of events without publishing them.
+func (l *GRPCListener) Validate(ctx context.Context, req *rpc.Events) (*rpc.Response, error) {
+	for i, e := range req.GetEvents() {
+		if err := l.handle(ctx, e, false); err != nil {
+			return &rpc.Response{Count: int32(i)}, err
+		}
+	}
+	return &rpc.Response{Count: int32(len(req.GetEvents()))}, nil
+}
+
+// ValidateStream validates events received on the stream without publishing them.
+func (l *GRPCListener) ValidateStream(stream rpc.EventSidecar_ValidateStreamServer) error {
+	return l.handleStream(stream.Context(), stream, false)
+}
+
+// eventStream is implemented by both client-streaming servers
+type eventStream interface {
+	Recv() (*rpc.CloudEvent, error)
+	SendAndClose(*rpc.Response) error
+}
+
+func (l *GRPCListener) handleStream(ctx context.Context, stream eventStream, publish bool) error {
+	var count int32
+	for {
+		e, err := stream.Recv()
+		if err == io.EOF {
+			return stream.SendAndClose(&rpc.Response{Count: count})
+		}
+		if err != nil {
+			return err
+		}
+		if err := l.handle(ctx, e, publish); err != nil {
+			return err
+		}
+		count++
+	}
+}
+
+func (l *GRPCListener) handle(ctx context.Context, e *rpc.CloudEvent, publish bool) error {
+	ev, err := e.ToCloudEvent()
+	if err != nil {
+		return status.Errorf(codes.InvalidArgument, "Error parsing event with ID %s: %s", e.GetEventId(), err)
+	}
+	if err := l.validate(ev); err != nil {
+		return status.Errorf(codes.InvalidArgument, "Error validating event with ID %s: %s", ev.EventID, err)
+	}
+	if !publish {
+		return nil
+	}
+	if err := l.publish(ctx, ev); err != nil {
+		return status.Errorf(codes.Unavailable, "Error publishing event with ID %s: %s", ev.EventID, err)
+	}
+	return nil
+}
+
+func (l *GRPCListener) startSpan(ctx context.Context, operation string) (opentracing.Span, context.Context) {
+	var wireContext opentracing.SpanContext
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		// This will commonly fail as most drivers won't provide tracing metadata.
+		wireContext, _ = opentracing.GlobalTracer().Extract(opentracing.TextMap, rpc.MetadataCarrier(md))
+	}
+	span := opentracing.StartSpan(operation, opentracing.ChildOf(wireContext))
+	return span, opentracing.ContextWithSpan(ctx, span)
+}
@@ -0,0 +1,77 @@
+///////////////////////////////////////////////////////////////////////
+// Copyright (c) 2017 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+///////////////////////////////////////////////////////////////////////
+
+package listener
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/vmware/dispatch/pkg/events/mocks"
+	"github.com/vmware/dispatch/pkg/events/rpc"
+	"github.com/vmware/dispatch/pkg/events/validator"
+)
+
+func testRPCEvent(t *testing.T) *rpc.CloudEvent {
+	e, err := rpc.FromCloudEvent(&testEvent1)
+	assert.NoError(t, err)
+	return e
+}
+
+func TestGRPCSendSuccess(t *testing.T) {
+	m := mockSharedListener()
+	m.validator = validator.NewDefaultValidator()
+	m.transport.(*mocks.Transport).On("Publish", mock.Anything, mock.Anything, "test.source.test.event", mock.Anything).Return(nil)
+	listener, err := NewGRPC(m, 8081)
+	assert.NoError(t, err)
+
+	resp, err := listener.Send(context.Background(), &rpc.Events{Events: []*rpc.CloudEvent{testRPCEvent(t), testRPCEvent(t)}})
+	assert.NoError(t, err)
+	assert.Equal(t, int32(2), resp.Count)
+	m.transport.(*mocks.Transport).AssertNumberOfCalls(t, "Publish", 2)
+}
+
+func TestGRPCSendInvalidEvent(t *testing.T) {
+	m := mockSharedListener()
+	m.validator = validator.NewDefaultValidator()
+	listener, err := NewGRPC(m, 8081)
+	assert.NoError(t, err)
+
+	invalid := testRPCEvent(t)
+	invalid.EventType = ""
+	resp, err := listener.Send(context.Background(), &rpc.Events{Events: []*rpc.CloudEvent{invalid}})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	assert.Equal(t, int32(0), resp.Count)
+	m.transport.(*mocks.Transport).AssertNotCalled(t, "Publish", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestGRPCSendTransportError(t *testing.T) {
+	m := mockSharedListener()
+	m.validator = validator.NewDefaultValidator()
+	m.transport.(*mocks.Transport).On("Publish", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(errors.New("transport down"))
+	listener, err := NewGRPC(m, 8081)
+	assert.NoError(t, err)
+
+	_, err = listener.Send(context.Background(), &rpc.Events{Events: []*rpc.CloudEvent{testRPCEvent(t)}})
+	assert.Equal(t, codes.Unavailable, status.Code(err))
+}
+
+func TestGRPCValidate(t *testing.T) {
+	m := mockSharedListener()
+	m.validator = validator.NewDefaultValidator()
+	listener, err := NewGRPC(m, 8081)
+	assert.NoError(t, err)
+
+	resp, err := listener.Validate(context.Background(), &rpc.Events{Events: []*rpc.CloudEvent{testRPCEvent(t)}})
+	assert.NoError(t, err)
+	assert.Equal(t, int32(1), resp.Count)
+	m.transport.(*mocks.Transport).AssertNotCalled(t, "Publish", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
@@ -35,7 +35,8 @@ func NewHTTP(shared SharedListener, port int) (*HTTPListener, error) {
 		MaxHeaderBytes:    0,
 	}
 	l.SharedListener = shared
-	l.done = make(chan struct{})
+	// buffered, so that Shutdown does not block if Serve has already returned with an error
+	l.done = make(chan struct{}, 1)
 
 	return l, nil
 }
@@ -95,14 +96,12 @@ func (l *HTTPListener) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	}
 
 	for _, ev := range evs {
-		log.Debugf("Validating event %+v", ev)
-		err = l.validator.Validate(&ev)
+		err = l.validate(&ev)
 		if err != nil {
 			http.Error(w, fmt.Sprintf("Error validating event with ID %s: %s", ev.EventID, err), http.StatusBadRequest)
 			return
 		}
-		log.Debugf("Pushing event %+v using topic %s and tenant %s", ev, ev.DefaultTopic(), l.tenant)
-		err = l.transport.Publish(spCtx, &ev, ev.DefaultTopic(), l.tenant)
+		err = l.publish(spCtx, &ev)
 		if err != nil {
 			http.Error(w, fmt.Sprintf("Error publishing event with ID %s: %s", ev.EventID, err), http.StatusInternalServerError)
 			return
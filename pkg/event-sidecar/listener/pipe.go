@@ -7,62 +7,204 @@ package listener
 
 import (
 	"bufio"
-	"errors"
-	"log"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
 	"os"
+	"path/filepath"
 	"syscall"
 
-	"github.com/vmware/dispatch/pkg/events"
+	"github.com/opentracing/opentracing-go"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
 )
 
 // Event sidecar pipe files
 const (
-	EventPipe    = "/pipes/events"
-	ResponsePipe = "/pipes/response"
+	DefaultPipeDir   = "/pipes"
+	EventPipeName    = "events"
+	ResponsePipeName = "response"
 )
 
-// PipeListener type for event pipe channels
+// pipeBuf is the size up to which writes to a pipe are atomic (PIPE_BUF on Linux)
+const pipeBuf = 4096
+
+// PipeResponse is written to the response pipe, as a single line of JSON, for every line read from the event pipe.
+type PipeResponse struct {
+	// EventID is the ID of the first event of the line, which identifies the request. It is empty if the line could
+	// not be parsed.
+	EventID string `json:"eventId,omitempty"`
+	// Count is the number of events published
+	Count int `json:"count"`
+	// Error is set if any of the events could not be parsed, validated or published
+	Error string `json:"error,omitempty"`
+}
+
+// PipeListener implements EventListener using named pipes. Every line written to the event pipe must hold
+// a single CloudEvent or a list of CloudEvents in JSON format. For every line, a PipeResponse is written
+// to the response pipe. Responses are dropped if nobody has the response pipe open for reading, so readers
+// should open it before writing events, read-write for their reads to wait for responses instead of ending.
+// Writers sharing the pipes must serialize their requests, e.g. with an exclusive flock on the event pipe,
+// for lines over PIPE_BUF not to interleave and for each writer to read its own response.
 type PipeListener struct {
+	SharedListener
+	eventPipe    string
+	responsePipe string
+	events       *os.File
+	// responses is the write end of the response pipe, -1 if not open
+	responses int
+	done      chan struct{}
 }
 
-// NewPipe creates a new PipeListener - TODO: finish me
-func NewPipe() (*PipeListener, error) {
-	log.Print("Creating new pipe listener")
-	err := syscall.Mkfifo(EventPipe, 0666)
+// NewPipe creates a new PipeListener, creating named pipes in dir.
+func NewPipe(shared SharedListener, dir string) (*PipeListener, error) {
+	if dir == "" {
+		return nil, errors.New("pipe directory not set")
+	}
+	l := &PipeListener{
+		SharedListener: shared,
+		eventPipe:      filepath.Join(dir, EventPipeName),
+		responsePipe:   filepath.Join(dir, ResponsePipeName),
+		responses:      -1,
+		done:           make(chan struct{}),
+	}
+
+	for _, pipe := range []string{l.eventPipe, l.responsePipe} {
+		if err := mkfifo(pipe); err != nil {
+			return nil, err
+		}
+	}
+
+	// Opening with O_RDWR prevents blocking until a driver opens the other end,
+	// and keeps the pipe open when writers come and go.
+	var err error
+	l.events, err = os.OpenFile(l.eventPipe, os.O_RDWR, os.ModeNamedPipe)
 	if err != nil {
-		log.Printf("error creating input pipe: %v", err)
-		err = os.Remove(EventPipe)
-		if err != nil {
-			log.Printf("error removing input pipe: %v", err)
-			return nil, nil
+		return nil, errors.Wrapf(err, "error opening event pipe %s", l.eventPipe)
+	}
+	return l, nil
+}
+
+// Serve starts reading loop. Blocks until Shutdown() is called.
+func (l *PipeListener) Serve() error {
+	defer l.closeResponses()
+
+	log.Printf("Listening on pipe://%s\n", l.eventPipe)
+	reader := bufio.NewReader(l.events)
+	for {
+		line, err := readMessage(reader)
+		select {
+		case <-l.done:
+			return nil
+		default:
 		}
-		err = syscall.Mkfifo(EventPipe, 0666)
 		if err != nil {
-			return nil, nil
+			return errors.Wrapf(err, "error reading from event pipe %s", l.eventPipe)
+		}
+		if len(bytes.TrimSpace(line)) == 0 {
+			continue
+		}
+		l.respond(l.handle(line))
+	}
+}
+
+// Shutdown stops the reading loop.
+func (l *PipeListener) Shutdown() error {
+	log.Printf("Shutting down...")
+	close(l.done)
+	return l.events.Close()
+}
+
+func (l *PipeListener) handle(message []byte) *PipeResponse {
+	span := opentracing.StartSpan("EventSidecar.Pipe")
+	defer span.Finish()
+	spCtx := opentracing.ContextWithSpan(context.Background(), span)
+
+	evs, err := l.parser.Parse(bytes.NewReader(message))
+	if err != nil {
+		return &PipeResponse{Error: fmt.Sprintf("Error parsing input: %s", err)}
+	}
+	if len(evs) == 0 {
+		return &PipeResponse{Error: "No events parsed"}
+	}
+
+	id := evs[0].EventID
+	for i, ev := range evs {
+		if err = l.validate(&ev); err != nil {
+			return &PipeResponse{EventID: id, Count: i, Error: fmt.Sprintf("Error validating event with ID %s: %s", ev.EventID, err)}
+		}
+		if err = l.publish(spCtx, &ev); err != nil {
+			return &PipeResponse{EventID: id, Count: i, Error: fmt.Sprintf("Error publishing event with ID %s: %s", ev.EventID, err)}
 		}
-	} else {
-		log.Printf("Created %v\n", EventPipe)
 	}
+	return &PipeResponse{EventID: id, Count: len(evs)}
+}
 
-	infile, err := os.OpenFile(EventPipe, os.O_RDWR, os.ModeNamedPipe)
+func (l *PipeListener) respond(resp *PipeResponse) {
+	if resp.Error != "" {
+		log.Debugf("pipe: %s", resp.Error)
+	}
+	payload, err := json.Marshal(resp)
 	if err != nil {
-		panic(err)
+		log.Errorf("error encoding pipe response: %+v", err)
+		return
 	}
-	reader := bufio.NewReader(infile)
+	if over := len(payload) + 1 - pipeBuf; over > 0 {
+		// responses are kept atomic, by truncating the error
+		cut := len(resp.Error) - over - len("...")
+		if cut < 0 {
+			cut = 0
+		}
+		resp.Error = resp.Error[:cut] + "..."
+		payload, _ = json.Marshal(resp)
+	}
+	payload = append(payload, '\n')
 
-	go func() {
-		for {
-			_, err := readMessage(reader)
-			if err != nil {
-				log.Printf("error reading message: %v", err)
-				continue
-			}
+	if l.responses < 0 {
+		// Non-blocking open fails if nobody reads the pipe, responses are then dropped instead of being buffered
+		// for the next reader. Non-blocking writes, so that readers not reading responses cannot stall the listener.
+		l.responses, err = syscall.Open(l.responsePipe, syscall.O_WRONLY|syscall.O_NONBLOCK, 0)
+		if err != nil {
+			l.responses = -1
+			log.Debugf("dropping pipe response %s: %s", payload, err)
+			return
 		}
-	}()
+	}
+	if _, err := syscall.Write(l.responses, payload); err != nil {
+		log.Debugf("dropping pipe response %s: %s", payload, err)
+		if err == syscall.EPIPE {
+			// the readers are gone, the pipe is opened again for the next ones
+			l.closeResponses()
+		}
+	}
+}
+
+func (l *PipeListener) closeResponses() {
+	if l.responses >= 0 {
+		syscall.Close(l.responses)
+		l.responses = -1
+	}
+}
 
-	return nil, nil
+// readMessage reads a single newline-delimited message
+func readMessage(reader *bufio.Reader) ([]byte, error) {
+	line, err := reader.ReadBytes('\n')
+	if err == io.EOF && len(line) > 0 {
+		return line, nil
+	}
+	return line, err
 }
 
-func readMessage(reader *bufio.Reader) (*events.CloudEvent, error) {
-	return nil, errors.New("not implemented")
+// mkfifo creates a named pipe, replacing any file left at path
+func mkfifo(path string) error {
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return errors.Wrapf(err, "error removing pipe %s", path)
+	}
+	if err := syscall.Mkfifo(path, 0666); err != nil {
+		return errors.Wrapf(err, "error creating pipe %s", path)
+	}
+	log.Printf("Created %v\n", path)
+	return nil
 }
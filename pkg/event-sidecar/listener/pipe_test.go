@@ -0,0 +1,97 @@
+///////////////////////////////////////////////////////////////////////
+// Copyright (c) 2017 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+///////////////////////////////////////////////////////////////////////
+
+package listener
+
+import (
+	"bufio"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/vmware/dispatch/pkg/events"
+	"github.com/vmware/dispatch/pkg/events/mocks"
+	"github.com/vmware/dispatch/pkg/events/parser"
+	"github.com/vmware/dispatch/pkg/events/validator"
+)
+
+func TestPipeListener(t *testing.T) {
+	dir, err := ioutil.TempDir("", "pipes")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	m := mockSharedListener()
+	m.parser = &parser.JSONEventParser{}
+	m.validator = validator.NewDefaultValidator()
+	published := make(chan struct{}, 10)
+	m.transport.(*mocks.Transport).On("Publish", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil).
+		Run(func(mock.Arguments) { published <- struct{}{} })
+
+	listener, err := NewPipe(m, dir)
+	require.NoError(t, err)
+	served := make(chan error)
+	go func() { served <- listener.Serve() }()
+
+	in, err := os.OpenFile(filepath.Join(dir, EventPipeName), os.O_WRONLY, os.ModeNamedPipe)
+	require.NoError(t, err)
+	defer in.Close()
+
+	// the response is dropped, as nobody reads the response pipe
+	unread := testEvent1
+	unread.EventID = "unread"
+	_, err = in.Write(append(eventJSON(&unread), '\n'))
+	require.NoError(t, err)
+	<-published
+
+	out, err := os.OpenFile(filepath.Join(dir, ResponsePipeName), os.O_RDWR, os.ModeNamedPipe)
+	require.NoError(t, err)
+	defer out.Close()
+	responses := bufio.NewReader(out)
+
+	readResponse := func() PipeResponse {
+		line, err := responses.ReadBytes('\n')
+		require.NoError(t, err)
+		var resp PipeResponse
+		require.NoError(t, json.Unmarshal(line, &resp))
+		return resp
+	}
+
+	// single event
+	_, err = in.Write(append(eventJSON(&testEvent1), '\n'))
+	require.NoError(t, err)
+	assert.Equal(t, PipeResponse{EventID: testEvent1.EventID, Count: 1}, readResponse())
+
+	// list of events
+	list, _ := json.Marshal([]events.CloudEvent{testEvent1, testEvent1})
+	_, err = in.Write(append(list, '\n'))
+	require.NoError(t, err)
+	assert.Equal(t, PipeResponse{EventID: testEvent1.EventID, Count: 2}, readResponse())
+
+	// invalid event
+	invalid := testEvent1
+	invalid.EventType = ""
+	_, err = in.Write(append(eventJSON(&invalid), '\n'))
+	require.NoError(t, err)
+	resp := readResponse()
+	assert.Equal(t, testEvent1.EventID, resp.EventID)
+	assert.Equal(t, 0, resp.Count)
+	assert.Contains(t, resp.Error, "Error validating event with ID")
+
+	// garbage
+	_, err = in.Write([]byte("{not json\n"))
+	require.NoError(t, err)
+	assert.Contains(t, readResponse().Error, "Error parsing input")
+
+	m.transport.(*mocks.Transport).AssertNumberOfCalls(t, "Publish", 4)
+
+	assert.NoError(t, listener.Shutdown())
+	assert.NoError(t, <-served)
+}
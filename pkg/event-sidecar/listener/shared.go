@@ -5,7 +5,13 @@
 
 package listener
 
-import "github.com/vmware/dispatch/pkg/events"
+import (
+	"context"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/vmware/dispatch/pkg/events"
+)
 
 // SharedListener serves as a simple DI container for Listener.
 type SharedListener struct {
@@ -35,3 +41,15 @@ func NewSharedListener(transport events.Transport, parser events.StreamParser, v
 		driverType: driverType,
 	}
 }
+
+// validate validates a single event.
+func (l *SharedListener) validate(ev *events.CloudEvent) error {
+	log.Debugf("Validating event %+v", ev)
+	return l.validator.Validate(ev)
+}
+
+// publish pushes a single event to the transport, using default topic of the event and tenant of the listener.
+func (l *SharedListener) publish(ctx context.Context, ev *events.CloudEvent) error {
+	log.Debugf("Pushing event %+v using topic %s and tenant %s", ev, ev.DefaultTopic(), l.tenant)
+	return l.transport.Publish(ctx, ev, ev.DefaultTopic(), l.tenant)
+}
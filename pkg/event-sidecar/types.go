@@ -5,6 +5,12 @@
 
 package eventsidecar
 
+import (
+	"sync"
+
+	log "github.com/sirupsen/logrus"
+)
+
 // NO TESTS
 
 // EventListener listens for new events, parses & validates them,
@@ -13,3 +19,42 @@ type EventListener interface {
 	Serve() error
 	Shutdown() error
 }
+
+// multiListener serves multiple listeners at once
+type multiListener []EventListener
+
+// Serve starts all listeners. Blocks until all of them return, and returns the first error encountered.
+// If one of the listeners fails, others are shut down.
+func (m multiListener) Serve() error {
+	var wg sync.WaitGroup
+	var once sync.Once
+	var firstErr error
+	for _, l := range m {
+		wg.Add(1)
+		go func(l EventListener) {
+			defer wg.Done()
+			if err := l.Serve(); err != nil {
+				once.Do(func() {
+					firstErr = err
+					go m.Shutdown()
+				})
+			}
+		}(l)
+	}
+	wg.Wait()
+	return firstErr
+}
+
+// Shutdown shuts down all listeners
+func (m multiListener) Shutdown() error {
+	var firstErr error
+	for _, l := range m {
+		if err := l.Shutdown(); err != nil {
+			log.Errorf("error shutting down listener: %+v", err)
+			if firstErr == nil {
+				firstErr = err
+			}
+		}
+	}
+	return firstErr
+}
@@ -0,0 +1,167 @@
+///////////////////////////////////////////////////////////////////////
+// Copyright (c) 2017 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+///////////////////////////////////////////////////////////////////////
+
+package driverclient
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/opentracing/opentracing-go"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+
+	"github.com/vmware/dispatch/pkg/events"
+	"github.com/vmware/dispatch/pkg/events/rpc"
+	"github.com/vmware/dispatch/pkg/utils"
+)
+
+// GRPCClientOpt allows customization of GRPCClient
+type GRPCClientOpt func(client *GRPCClient) error
+
+// WithGRPCPort allows to customize the port of sidecar gRPC listener
+func WithGRPCPort(port int) GRPCClientOpt {
+	return func(client *GRPCClient) error {
+		client.port = port
+		return nil
+	}
+}
+
+// WithGRPCTracer allows setting custom tracer
+func WithGRPCTracer(t opentracing.Tracer) GRPCClientOpt {
+	return func(client *GRPCClient) error {
+		client.tracer = t
+		return nil
+	}
+}
+
+// GRPCClient implements event driver client using gRPC protocol. Batches are sent using
+// client-side streaming, avoiding per-event request overhead.
+type GRPCClient struct {
+	conn    *grpc.ClientConn
+	client  rpc.EventSidecarClient
+	host    string
+	port    int
+	timeout time.Duration
+
+	tracer opentracing.Tracer
+}
+
+// NewGRPCClient returns new instance of driverclient.Client using GRPCClient implementation
+func NewGRPCClient(opts ...GRPCClientOpt) (Client, error) {
+	c := &GRPCClient{
+		host:    "localhost",
+		port:    8081,
+		timeout: 5 * time.Second,
+		tracer:  opentracing.NoopTracer{},
+	}
+
+	for _, opt := range opts {
+		err := opt(c)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return c, c.connect()
+}
+
+// Send sends slice of events to Dispatch system. Events are validated by the sidecar.
+func (c *GRPCClient) Send(evs []events.CloudEvent) error {
+	ctx, cancel, span := c.startSpan("GRPCClient.Send")
+	defer cancel()
+	defer span.Finish()
+
+	stream, err := c.client.SendStream(ctx)
+	if err != nil {
+		return err
+	}
+	for i := range evs {
+		e, err := rpc.FromCloudEvent(&evs[i])
+		if err != nil {
+			return err
+		}
+		if err := stream.Send(e); err != nil {
+			// The actual error is returned by CloseAndRecv()
+			break
+		}
+	}
+	_, err = stream.CloseAndRecv()
+	return err
+}
+
+// SendOne sends single event to Dispatch system. Event is validated by the sidecar.
+func (c *GRPCClient) SendOne(event *events.CloudEvent) error {
+	ctx, cancel, span := c.startSpan("GRPCClient.SendOne")
+	defer cancel()
+	defer span.Finish()
+
+	e, err := rpc.FromCloudEvent(event)
+	if err != nil {
+		return err
+	}
+	_, err = c.client.Send(ctx, &rpc.Events{Events: []*rpc.CloudEvent{e}})
+	return err
+}
+
+// Validate validates slice of events without sending it
+func (c *GRPCClient) Validate(evs []events.CloudEvent) error {
+	ctx, cancel, span := c.startSpan("GRPCClient.Validate")
+	defer cancel()
+	defer span.Finish()
+
+	req := &rpc.Events{}
+	for i := range evs {
+		e, err := rpc.FromCloudEvent(&evs[i])
+		if err != nil {
+			return err
+		}
+		req.Events = append(req.Events, e)
+	}
+	_, err := c.client.Validate(ctx, req)
+	return err
+}
+
+// ValidateOne validates single event without sending it
+func (c *GRPCClient) ValidateOne(event *events.CloudEvent) error {
+	return c.Validate([]events.CloudEvent{*event})
+}
+
+// Close closes the connection to the sidecar
+func (c *GRPCClient) Close() error {
+	return c.conn.Close()
+}
+
+func (c *GRPCClient) getAddr() string {
+	return fmt.Sprintf("%s:%d", c.host, c.port)
+}
+
+func (c *GRPCClient) startSpan(operation string) (context.Context, context.CancelFunc, opentracing.Span) {
+	span := c.tracer.StartSpan(operation)
+	md := metadata.MD{}
+	if err := c.tracer.Inject(span.Context(), opentracing.TextMap, rpc.MetadataCarrier(md)); err != nil {
+		log.Printf("error injecting tracing metadata: %s", err)
+	}
+	ctx, cancel := context.WithTimeout(metadata.NewOutgoingContext(context.Background(), md), c.timeout)
+	return ctx, cancel, span
+}
+
+func (c *GRPCClient) connect() error {
+	return utils.Backoff(30*time.Second, func() error {
+		log.Printf("checking connection to %s", c.getAddr())
+		ctx, cancel := context.WithTimeout(context.Background(), c.timeout)
+		defer cancel()
+		conn, err := grpc.DialContext(ctx, c.getAddr(), grpc.WithInsecure(), grpc.WithBlock())
+		if err != nil {
+			log.Printf("connection failed: %s", err)
+			return err
+		}
+		c.conn = conn
+		c.client = rpc.NewEventSidecarClient(conn)
+		return nil
+	})
+}
@@ -0,0 +1,120 @@
+///////////////////////////////////////////////////////////////////////
+// Copyright (c) 2017 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+///////////////////////////////////////////////////////////////////////
+
+package driverclient
+
+import (
+	"context"
+	"io"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/vmware/dispatch/pkg/events"
+	"github.com/vmware/dispatch/pkg/events/rpc"
+)
+
+type fakeSidecar struct {
+	received []*rpc.CloudEvent
+}
+
+func (s *fakeSidecar) check(e *rpc.CloudEvent) error {
+	if e.EventType == "" {
+		return status.Error(codes.InvalidArgument, "missing event type")
+	}
+	return nil
+}
+
+func (s *fakeSidecar) Send(ctx context.Context, req *rpc.Events) (*rpc.Response, error) {
+	for _, e := range req.Events {
+		if err := s.check(e); err != nil {
+			return nil, err
+		}
+		s.received = append(s.received, e)
+	}
+	return &rpc.Response{Count: int32(len(req.Events))}, nil
+}
+
+func (s *fakeSidecar) SendStream(stream rpc.EventSidecar_SendStreamServer) error {
+	var count int32
+	for {
+		e, err := stream.Recv()
+		if err == io.EOF {
+			return stream.SendAndClose(&rpc.Response{Count: count})
+		}
+		if err != nil {
+			return err
+		}
+		if err := s.check(e); err != nil {
+			return err
+		}
+		s.received = append(s.received, e)
+		count++
+	}
+}
+
+func (s *fakeSidecar) Validate(ctx context.Context, req *rpc.Events) (*rpc.Response, error) {
+	for _, e := range req.Events {
+		if err := s.check(e); err != nil {
+			return nil, err
+		}
+	}
+	return &rpc.Response{Count: int32(len(req.Events))}, nil
+}
+
+func (s *fakeSidecar) ValidateStream(stream rpc.EventSidecar_ValidateStreamServer) error {
+	return status.Error(codes.Unimplemented, "not used by client")
+}
+
+func startFakeSidecar(t *testing.T) (*fakeSidecar, int, func()) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	sidecar := &fakeSidecar{}
+	server := grpc.NewServer()
+	rpc.RegisterEventSidecarServer(server, sidecar)
+	go server.Serve(lis)
+	return sidecar, lis.Addr().(*net.TCPAddr).Port, server.Stop
+}
+
+func TestGRPCClientSend(t *testing.T) {
+	sidecar, port, stop := startFakeSidecar(t)
+	defer stop()
+
+	client, err := NewGRPCClient(WithGRPCPort(port))
+	require.NoError(t, err)
+
+	ev1 := events.NewCloudEventWithDefaults("test.event")
+	ev2 := events.NewCloudEventWithDefaults("test.event")
+	ev2.Extensions = events.CloudEventExtensions{"key": "value"}
+	assert.NoError(t, client.Send([]events.CloudEvent{ev1, ev2}))
+	assert.NoError(t, client.SendOne(&ev1))
+
+	require.Len(t, sidecar.received, 3)
+	assert.Equal(t, ev1.EventID, sidecar.received[0].EventId)
+	assert.Equal(t, `"value"`, sidecar.received[1].Extensions["key"])
+	assert.Equal(t, ev1.EventID, sidecar.received[2].EventId)
+}
+
+func TestGRPCClientValidate(t *testing.T) {
+	_, port, stop := startFakeSidecar(t)
+	defer stop()
+
+	client, err := NewGRPCClient(WithGRPCPort(port))
+	require.NoError(t, err)
+
+	ev := events.NewCloudEventWithDefaults("test.event")
+	assert.NoError(t, client.ValidateOne(&ev))
+
+	ev.EventType = ""
+	err = client.ValidateOne(&ev)
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	err = client.Send([]events.CloudEvent{ev})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
@@ -5,28 +5,192 @@
 
 package driverclient
 
-import "github.com/vmware/dispatch/pkg/events"
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sync"
+	"syscall"
+	"time"
 
-// PipeClient implements Event Driver client using named pipes.
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/vmware/dispatch/pkg/events"
+	"github.com/vmware/dispatch/pkg/events/validator"
+	"github.com/vmware/dispatch/pkg/utils"
+)
+
+// PipeClientOpt allows customization of PipeClient
+type PipeClientOpt func(client *PipeClient) error
+
+// WithPipeDir allows to customize the directory holding sidecar pipes
+func WithPipeDir(dir string) PipeClientOpt {
+	return func(client *PipeClient) error {
+		client.dir = dir
+		return nil
+	}
+}
+
+// WithPipeTimeout allows to customize the time to wait for the response of the sidecar
+func WithPipeTimeout(timeout time.Duration) PipeClientOpt {
+	return func(client *PipeClient) error {
+		client.timeout = timeout
+		return nil
+	}
+}
+
+// PipeClient implements Event Driver client using named pipes. Events are written to the events pipe
+// as a single line of JSON, and the response of the sidecar is read from the response pipe. Requests are
+// serialized with an exclusive flock on the events pipe, as other processes may share the pipes.
 type PipeClient struct {
+	dir       string
+	timeout   time.Duration
+	validator events.Validator
+
+	// mu serializes the requests of this client, the flock those of other clients
+	mu            sync.Mutex
+	events        *os.File
+	responsesFile *os.File
+	responses     *bufio.Reader
+}
+
+// pipeResponse mirrors a line written by the sidecar to the response pipe
+type pipeResponse struct {
+	EventID string `json:"eventId,omitempty"`
+	Count   int    `json:"count"`
+	Error   string `json:"error,omitempty"`
+}
+
+// NewPipeClient returns new instance of driverclient.Client using PipeClient implementation
+func NewPipeClient(opts ...PipeClientOpt) (Client, error) {
+	c := &PipeClient{
+		dir:       "/pipes",
+		timeout:   5 * time.Second,
+		validator: validator.NewDefaultValidator(),
+	}
+
+	for _, opt := range opts {
+		err := opt(c)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return c, c.open()
 }
 
-// Send sends slice of events to event driver sidecar
-func (PipeClient) Send(event []events.CloudEvent) error {
-	panic("implement me")
+// Send sends slice of events to event driver sidecar. It runs Validate() first.
+func (c *PipeClient) Send(evs []events.CloudEvent) error {
+	if err := c.Validate(evs); err != nil {
+		return err
+	}
+	if len(evs) == 0 {
+		return nil
+	}
+	return c.send(evs[0].EventID, evs)
 }
 
-// SendOne sends single event to event driver sidecar
-func (PipeClient) SendOne(event *events.CloudEvent) error {
-	panic("implement me")
+// SendOne sends single event to event driver sidecar. It runs ValidateOne() first.
+func (c *PipeClient) SendOne(event *events.CloudEvent) error {
+	if err := c.ValidateOne(event); err != nil {
+		return err
+	}
+	return c.send(event.EventID, event)
 }
 
 // Validate validates list of events without sending them.
-func (PipeClient) Validate(event []events.CloudEvent) error {
-	panic("implement me")
+func (c *PipeClient) Validate(evs []events.CloudEvent) error {
+	for _, e := range evs {
+		if err := c.validator.Validate(&e); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
 // ValidateOne validates single event without sending it.
-func (PipeClient) ValidateOne(event *events.CloudEvent) error {
-	panic("implement me")
+func (c *PipeClient) ValidateOne(event *events.CloudEvent) error {
+	return c.validator.Validate(event)
+}
+
+// Close closes the pipes.
+func (c *PipeClient) Close() error {
+	c.responsesFile.Close()
+	return c.events.Close()
+}
+
+// send writes payload, whose first event has ID id, and waits for its response. The sidecar drops the response if it
+// cannot write it, so the wait is bounded by the timeout.
+func (c *PipeClient) send(id string, payload interface{}) error {
+	// encoding/json never emits new line characters in compact mode
+	message, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	message = append(message, '\n')
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	fd := int(c.events.Fd())
+	if err := syscall.Flock(fd, syscall.LOCK_EX); err != nil {
+		return err
+	}
+	defer syscall.Flock(fd, syscall.LOCK_UN)
+
+	if _, err := c.events.Write(message); err != nil {
+		return err
+	}
+	if err := c.responsesFile.SetReadDeadline(time.Now().Add(c.timeout)); err != nil {
+		return errors.Wrap(err, "error setting the response pipe deadline")
+	}
+	for {
+		line, err := c.responses.ReadBytes('\n')
+		if os.IsTimeout(err) {
+			return errors.Errorf("no response to event %s from the sidecar within %s", id, c.timeout)
+		}
+		if err != nil {
+			return err
+		}
+		var resp pipeResponse
+		if err := json.Unmarshal(line, &resp); err != nil {
+			return err
+		}
+		if resp.EventID != id {
+			// the response to a request whose client stopped waiting for it
+			log.Debugf("skipping response to event %s", resp.EventID)
+			continue
+		}
+		if resp.Error != "" {
+			return errors.New(resp.Error)
+		}
+		return nil
+	}
+}
+
+func (c *PipeClient) open() error {
+	eventPipe := filepath.Join(c.dir, "events")
+	responsePipe := filepath.Join(c.dir, "response")
+	// Pipes are created by the sidecar, which may not be running yet
+	return utils.Backoff(30*time.Second, func() error {
+		log.Debugf("checking pipes in %s", c.dir)
+		// The response pipe is opened first, as the sidecar drops responses if nobody reads them, and read-write
+		// so that reads wait for the sidecar to write instead of ending when it closes the pipe.
+		responses, err := os.OpenFile(responsePipe, os.O_RDWR, os.ModeNamedPipe)
+		if err != nil {
+			log.Warnf("opening pipe failed: %s", err)
+			return err
+		}
+		events, err := os.OpenFile(eventPipe, os.O_WRONLY, os.ModeNamedPipe)
+		if err != nil {
+			responses.Close()
+			log.Warnf("opening pipe failed: %s", err)
+			return err
+		}
+		c.events = events
+		c.responsesFile = responses
+		c.responses = bufio.NewReader(responses)
+		return nil
+	})
 }
@@ -0,0 +1,117 @@
+///////////////////////////////////////////////////////////////////////
+// Copyright (c) 2017 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+///////////////////////////////////////////////////////////////////////
+
+package driverclient
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/vmware/dispatch/pkg/events"
+)
+
+// fakePipeSidecar accepts every message with an event of type "test.event", and rejects others. A stale response,
+// to a request of a previous client, is buffered in the response pipe first.
+func fakePipeSidecar(t *testing.T, dir string) (received chan string) {
+	for _, name := range []string{"events", "response"} {
+		require.NoError(t, syscall.Mkfifo(filepath.Join(dir, name), 0666))
+	}
+	received = make(chan string, 10)
+	go func() {
+		in, _ := os.OpenFile(filepath.Join(dir, "events"), os.O_RDWR, os.ModeNamedPipe)
+		out, _ := os.OpenFile(filepath.Join(dir, "response"), os.O_RDWR, os.ModeNamedPipe)
+		out.Write([]byte(`{"eventId":"stale","count":0,"error":"stale"}` + "\n"))
+		reader := bufio.NewReader(in)
+		for {
+			line, err := reader.ReadString('\n')
+			if err != nil {
+				return
+			}
+			received <- line
+			var evs []events.CloudEvent
+			if strings.HasPrefix(line, "{") {
+				evs = make([]events.CloudEvent, 1)
+				json.Unmarshal([]byte(line), &evs[0])
+			} else {
+				json.Unmarshal([]byte(line), &evs)
+			}
+			if strings.Contains(line, `"event-type":"test.event"`) {
+				fmt.Fprintf(out, "{\"eventId\":%q,\"count\":1}\n", evs[0].EventID)
+			} else {
+				fmt.Fprintf(out, "{\"eventId\":%q,\"count\":0,\"error\":\"rejected\"}\n", evs[0].EventID)
+			}
+		}
+	}()
+	return received
+}
+
+func TestPipeClient(t *testing.T) {
+	dir, err := ioutil.TempDir("", "pipes")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	received := fakePipeSidecar(t, dir)
+
+	client, err := NewPipeClient(WithPipeDir(dir))
+	require.NoError(t, err)
+	defer client.(*PipeClient).Close()
+
+	ev := events.NewCloudEventWithDefaults("test.event")
+	assert.NoError(t, client.SendOne(&ev))
+	line := <-received
+	assert.True(t, strings.HasPrefix(line, "{"))
+	assert.True(t, strings.HasSuffix(line, "}\n"))
+	assert.Contains(t, line, ev.EventID)
+
+	assert.NoError(t, client.Send([]events.CloudEvent{ev}))
+	line = <-received
+	assert.True(t, strings.HasPrefix(line, "["))
+
+	other := events.NewCloudEventWithDefaults("other.event")
+	assert.EqualError(t, client.SendOne(&other), "rejected")
+
+	invalid := events.NewCloudEventWithDefaults("test.event")
+	invalid.EventID = ""
+	assert.Error(t, client.SendOne(&invalid))
+	assert.Len(t, received, 1)
+
+	// nothing to send
+	assert.NoError(t, client.Send(nil))
+	assert.Len(t, received, 1)
+}
+
+func TestPipeClientTimeout(t *testing.T) {
+	dir, err := ioutil.TempDir("", "pipes")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	// the sidecar drops the responses
+	for _, name := range []string{"events", "response"} {
+		require.NoError(t, syscall.Mkfifo(filepath.Join(dir, name), 0666))
+	}
+	in, err := os.OpenFile(filepath.Join(dir, "events"), os.O_RDWR, os.ModeNamedPipe)
+	require.NoError(t, err)
+	defer in.Close()
+
+	client, err := NewPipeClient(WithPipeDir(dir), WithPipeTimeout(100*time.Millisecond))
+	require.NoError(t, err)
+	defer client.(*PipeClient).Close()
+
+	ev := events.NewCloudEventWithDefaults("test.event")
+	err = client.SendOne(&ev)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "no response")
+}
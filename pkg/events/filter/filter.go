@@ -0,0 +1,562 @@
+///////////////////////////////////////////////////////////////////////
+// Copyright (c) 2018 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+///////////////////////////////////////////////////////////////////////
+
+// Package filter implements filter expressions, which match CloudEvents based on their content.
+//
+// An expression compares event attributes, extensions and data with literals, e.g.:
+//
+//	data.vm.name startsWith "prod-" && extensions.datacenter == "dc1"
+//
+// Operands are either literals (strings in single or double quotes, numbers, true, false and null) or paths.
+// The first segment of a path is "data", "extensions" or the name of a CloudEvent attribute (e.g. eventType or
+// event-type). Segments following "data" and "extensions" select object fields or array elements (by index).
+// If event data is not a JSON document, "data" holds the raw string.
+//
+// Supported operators are ==, !=, <, <=, >, >=, startsWith, endsWith, contains, matches (regular expression),
+// and logical &&, ||, ! (or and, or, not). An operand used without operator is true when present and not
+// false, zero or empty.
+package filter
+
+import (
+	"encoding/json"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/vmware/dispatch/pkg/events"
+)
+
+// Expression is a parsed filter expression
+type Expression struct {
+	source string
+	root   node
+}
+
+// Parse parses the filter expression
+func Parse(expression string) (*Expression, error) {
+	p := &parser{lexer: lexer{input: expression}}
+	if err := p.next(); err != nil {
+		return nil, err
+	}
+	root, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if p.tok.kind != tokEOF {
+		return nil, p.unexpected()
+	}
+	return &Expression{source: expression, root: root}, nil
+}
+
+// String returns the source of the expression
+func (e *Expression) String() string {
+	return e.source
+}
+
+// Match evaluates the expression for the event
+func (e *Expression) Match(event *events.CloudEvent) bool {
+	return truthy(e.root.eval(&env{event: event}))
+}
+
+// env holds the event an expression is evaluated for
+type env struct {
+	event *events.CloudEvent
+
+	dataParsed bool
+	data       interface{}
+}
+
+func (e *env) getData() interface{} {
+	if !e.dataParsed {
+		e.dataParsed = true
+		if err := json.Unmarshal([]byte(e.event.Data), &e.data); err != nil {
+			e.data = e.event.Data
+		}
+	}
+	return e.data
+}
+
+// attributes maps names of CloudEvent attributes to their getters, both camel-case and JSON names are accepted
+var attributes = map[string]func(*events.CloudEvent) interface{}{
+	"namespace":          func(e *events.CloudEvent) interface{} { return e.Namespace },
+	"eventType":          func(e *events.CloudEvent) interface{} { return e.EventType },
+	"eventTypeVersion":   func(e *events.CloudEvent) interface{} { return e.EventTypeVersion },
+	"cloudEventsVersion": func(e *events.CloudEvent) interface{} { return e.CloudEventsVersion },
+	"sourceType":         func(e *events.CloudEvent) interface{} { return e.SourceType },
+	"sourceID":           func(e *events.CloudEvent) interface{} { return e.SourceID },
+	"eventID":            func(e *events.CloudEvent) interface{} { return e.EventID },
+	"eventTime":          func(e *events.CloudEvent) interface{} { return e.EventTime.Format(time.RFC3339Nano) },
+	"schemaURL":          func(e *events.CloudEvent) interface{} { return e.SchemaURL },
+	"contentType":        func(e *events.CloudEvent) interface{} { return e.ContentType },
+}
+
+func init() {
+	for name, getter := range map[string]string{
+		"event-type":           "eventType",
+		"event-type-version":   "eventTypeVersion",
+		"cloud-events-version": "cloudEventsVersion",
+		"source-type":          "sourceType",
+		"source-id":            "sourceID",
+		"event-id":             "eventID",
+		"event-time":           "eventTime",
+		"schema-url":           "schemaURL",
+		"content-type":         "contentType",
+	} {
+		attributes[name] = attributes[getter]
+	}
+}
+
+type node interface {
+	eval(*env) interface{}
+}
+
+type literal struct {
+	value interface{}
+}
+
+func (n *literal) eval(*env) interface{} {
+	return n.value
+}
+
+type path struct {
+	root     string
+	segments []string
+}
+
+func (n *path) eval(e *env) interface{} {
+	var v interface{}
+	switch n.root {
+	case "data":
+		v = e.getData()
+	case "extensions":
+		v = normalize(e.event.Extensions)
+	default:
+		return attributes[n.root](e.event)
+	}
+	for _, segment := range n.segments {
+		switch value := v.(type) {
+		case map[string]interface{}:
+			v = value[segment]
+		case []interface{}:
+			i, err := strconv.Atoi(segment)
+			if err != nil || i < 0 || i >= len(value) {
+				return nil
+			}
+			v = value[i]
+		default:
+			return nil
+		}
+	}
+	return v
+}
+
+// normalize converts extensions set by drivers to the types produced by encoding/json
+func normalize(extensions events.CloudEventExtensions) interface{} {
+	if extensions == nil {
+		return nil
+	}
+	b, err := json.Marshal(extensions)
+	if err != nil {
+		return nil
+	}
+	var v interface{}
+	json.Unmarshal(b, &v)
+	return v
+}
+
+type not struct {
+	operand node
+}
+
+func (n *not) eval(e *env) interface{} {
+	return !truthy(n.operand.eval(e))
+}
+
+type logical struct {
+	and         bool
+	left, right node
+}
+
+func (n *logical) eval(e *env) interface{} {
+	left := truthy(n.left.eval(e))
+	if n.and != left {
+		// short circuit: false && ..., true || ...
+		return left
+	}
+	return truthy(n.right.eval(e))
+}
+
+type comparison struct {
+	op          string
+	left, right node
+	re          *regexp.Regexp
+}
+
+func (n *comparison) eval(e *env) interface{} {
+	left, right := n.left.eval(e), n.right.eval(e)
+	switch n.op {
+	case "==":
+		return equal(left, right)
+	case "!=":
+		return !equal(left, right)
+	case "<", "<=", ">", ">=":
+		c, ok := compare(left, right)
+		if !ok {
+			return false
+		}
+		switch n.op {
+		case "<":
+			return c < 0
+		case "<=":
+			return c <= 0
+		case ">":
+			return c > 0
+		default:
+			return c >= 0
+		}
+	case "contains":
+		if list, ok := left.([]interface{}); ok {
+			for _, item := range list {
+				if equal(item, right) {
+					return true
+				}
+			}
+			return false
+		}
+	}
+
+	l, lok := left.(string)
+	r, rok := right.(string)
+	if !lok || !rok {
+		return false
+	}
+	switch n.op {
+	case "startsWith":
+		return strings.HasPrefix(l, r)
+	case "endsWith":
+		return strings.HasSuffix(l, r)
+	case "contains":
+		return strings.Contains(l, r)
+	case "matches":
+		return n.re.MatchString(l)
+	}
+	return false
+}
+
+func equal(left, right interface{}) bool {
+	switch left.(type) {
+	case nil:
+		return right == nil
+	case string, float64, bool:
+		return left == right
+	}
+	return false
+}
+
+func compare(left, right interface{}) (int, bool) {
+	switch l := left.(type) {
+	case float64:
+		if r, ok := right.(float64); ok {
+			switch {
+			case l < r:
+				return -1, true
+			case l > r:
+				return 1, true
+			}
+			return 0, true
+		}
+	case string:
+		if r, ok := right.(string); ok {
+			return strings.Compare(l, r), true
+		}
+	}
+	return 0, false
+}
+
+func truthy(v interface{}) bool {
+	switch value := v.(type) {
+	case nil:
+		return false
+	case bool:
+		return value
+	case string:
+		return value != ""
+	case float64:
+		return value != 0
+	}
+	return true
+}
+
+const (
+	tokEOF = iota
+	tokIdent
+	tokString
+	tokNumber
+	tokLiteral
+	tokOp
+	tokLParen
+	tokRParen
+)
+
+type token struct {
+	kind  int
+	text  string
+	value interface{}
+	pos   int
+}
+
+// words which are operators or literals rather than paths
+var (
+	wordOps = map[string]string{
+		"and":        "&&",
+		"or":         "||",
+		"not":        "!",
+		"startsWith": "startsWith",
+		"endsWith":   "endsWith",
+		"contains":   "contains",
+		"matches":    "matches",
+	}
+	wordLiterals = map[string]interface{}{
+		"true":  true,
+		"false": false,
+		"null":  nil,
+	}
+)
+
+type lexer struct {
+	input string
+	pos   int
+}
+
+func isIdentStart(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func isIdentPart(c byte) bool {
+	return isIdentStart(c) || isDigit(c) || c == '-' || c == '.'
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func (l *lexer) next() (token, error) {
+	for l.pos < len(l.input) && strings.IndexByte(" \t\r\n", l.input[l.pos]) >= 0 {
+		l.pos++
+	}
+	start := l.pos
+	if l.pos >= len(l.input) {
+		return token{kind: tokEOF, pos: start}, nil
+	}
+	c := l.input[l.pos]
+	switch {
+	case c == '(':
+		l.pos++
+		return token{kind: tokLParen, text: "(", pos: start}, nil
+	case c == ')':
+		l.pos++
+		return token{kind: tokRParen, text: ")", pos: start}, nil
+	case c == '"' || c == '\'':
+		return l.string(c)
+	case isDigit(c) || (c == '-' && l.pos+1 < len(l.input) && isDigit(l.input[l.pos+1])):
+		l.pos++
+		for l.pos < len(l.input) && (isDigit(l.input[l.pos]) || strings.IndexByte(".eE+-", l.input[l.pos]) >= 0) {
+			l.pos++
+		}
+		text := l.input[start:l.pos]
+		f, err := strconv.ParseFloat(text, 64)
+		if err != nil {
+			return token{}, errors.Errorf("invalid number %s at position %d", text, start)
+		}
+		return token{kind: tokNumber, text: text, value: f, pos: start}, nil
+	case isIdentStart(c):
+		for l.pos < len(l.input) && isIdentPart(l.input[l.pos]) {
+			l.pos++
+		}
+		text := l.input[start:l.pos]
+		if op, ok := wordOps[text]; ok {
+			return token{kind: tokOp, text: op, pos: start}, nil
+		}
+		if value, ok := wordLiterals[text]; ok {
+			return token{kind: tokLiteral, text: text, value: value, pos: start}, nil
+		}
+		return token{kind: tokIdent, text: text, pos: start}, nil
+	}
+	for _, op := range []string{"==", "!=", "<=", ">=", "&&", "||", "<", ">", "!"} {
+		if strings.HasPrefix(l.input[l.pos:], op) {
+			l.pos += len(op)
+			return token{kind: tokOp, text: op, pos: start}, nil
+		}
+	}
+	return token{}, errors.Errorf("unexpected character %q at position %d", c, start)
+}
+
+func (l *lexer) string(quote byte) (token, error) {
+	start := l.pos
+	var b strings.Builder
+	for l.pos++; l.pos < len(l.input); l.pos++ {
+		c := l.input[l.pos]
+		switch {
+		case c == quote:
+			l.pos++
+			return token{kind: tokString, text: l.input[start:l.pos], value: b.String(), pos: start}, nil
+		case c == '\\' && l.pos+1 < len(l.input):
+			l.pos++
+			switch e := l.input[l.pos]; e {
+			case 'n':
+				b.WriteByte('\n')
+			case 't':
+				b.WriteByte('\t')
+			default:
+				b.WriteByte(e)
+			}
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return token{}, errors.Errorf("unterminated string at position %d", start)
+}
+
+type parser struct {
+	lexer lexer
+	tok   token
+}
+
+func (p *parser) next() error {
+	tok, err := p.lexer.next()
+	if err != nil {
+		return err
+	}
+	p.tok = tok
+	return nil
+}
+
+func (p *parser) unexpected() error {
+	if p.tok.kind == tokEOF {
+		return errors.New("unexpected end of expression")
+	}
+	return errors.Errorf("unexpected %s at position %d", p.tok.text, p.tok.pos)
+}
+
+func (p *parser) parseOr() (node, error) {
+	return p.parseLogical("||", p.parseAnd)
+}
+
+func (p *parser) parseAnd() (node, error) {
+	return p.parseLogical("&&", p.parseUnary)
+}
+
+func (p *parser) parseLogical(op string, operand func() (node, error)) (node, error) {
+	left, err := operand()
+	if err != nil {
+		return nil, err
+	}
+	for p.tok.kind == tokOp && p.tok.text == op {
+		if err := p.next(); err != nil {
+			return nil, err
+		}
+		right, err := operand()
+		if err != nil {
+			return nil, err
+		}
+		left = &logical{and: op == "&&", left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseUnary() (node, error) {
+	switch {
+	case p.tok.kind == tokOp && p.tok.text == "!":
+		if err := p.next(); err != nil {
+			return nil, err
+		}
+		operand, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return &not{operand: operand}, nil
+	case p.tok.kind == tokLParen:
+		if err := p.next(); err != nil {
+			return nil, err
+		}
+		n, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if p.tok.kind != tokRParen {
+			return nil, p.unexpected()
+		}
+		return n, p.next()
+	}
+	return p.parseComparison()
+}
+
+func (p *parser) parseComparison() (node, error) {
+	left, err := p.parseOperand()
+	if err != nil {
+		return nil, err
+	}
+	if p.tok.kind != tokOp {
+		return left, nil
+	}
+	op := p.tok.text
+	switch op {
+	case "&&", "||", "!":
+		return left, nil
+	}
+	if err := p.next(); err != nil {
+		return nil, err
+	}
+	right, err := p.parseOperand()
+	if err != nil {
+		return nil, err
+	}
+	n := &comparison{op: op, left: left, right: right}
+	if op == "matches" {
+		lit, ok := right.(*literal)
+		if !ok {
+			return nil, errors.New("matches requires a string literal pattern")
+		}
+		pattern, ok := lit.value.(string)
+		if !ok {
+			return nil, errors.New("matches requires a string literal pattern")
+		}
+		if n.re, err = regexp.Compile(pattern); err != nil {
+			return nil, errors.Wrapf(err, "invalid pattern %s", pattern)
+		}
+	}
+	return n, nil
+}
+
+func (p *parser) parseOperand() (node, error) {
+	tok := p.tok
+	switch tok.kind {
+	case tokString, tokNumber, tokLiteral:
+		return &literal{value: tok.value}, p.next()
+	case tokIdent:
+		segments := strings.Split(tok.text, ".")
+		for _, s := range segments {
+			if s == "" {
+				return nil, errors.Errorf("invalid path %s at position %d", tok.text, tok.pos)
+			}
+		}
+		n := &path{root: segments[0], segments: segments[1:]}
+		switch n.root {
+		case "data", "extensions":
+		default:
+			if _, ok := attributes[n.root]; !ok {
+				return nil, errors.Errorf("unknown attribute %s at position %d", n.root, tok.pos)
+			}
+			if len(n.segments) > 0 {
+				return nil, errors.Errorf("attribute %s has no fields, at position %d", n.root, tok.pos)
+			}
+		}
+		return n, p.next()
+	}
+	return nil, p.unexpected()
+}
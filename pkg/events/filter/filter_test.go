@@ -0,0 +1,105 @@
+///////////////////////////////////////////////////////////////////////
+// Copyright (c) 2018 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+///////////////////////////////////////////////////////////////////////
+
+package filter
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/vmware/dispatch/pkg/events"
+)
+
+var testEvent = events.CloudEvent{
+	Namespace:          "dispatchframework.io",
+	EventType:          "vm.being.created",
+	EventTypeVersion:   "0.1",
+	CloudEventsVersion: events.CloudEventsVersion,
+	SourceType:         "vcenter",
+	SourceID:           "vcenter1",
+	EventID:            "c2a1fd4a-01a8-4dd8-ae6e-bf1bc8e0e5d9",
+	EventTime:          time.Date(2018, 6, 1, 12, 0, 0, 0, time.UTC),
+	ContentType:        "application/json",
+	Extensions: events.CloudEventExtensions{
+		"datacenter": "dc1",
+		"priority":   3,
+	},
+	Data: `{"vm":{"name":"prod-web-1","cpus":4,"tags":["web","linux"]},"disks":[{"size":20},{"size":100}]}`,
+}
+
+func TestMatch(t *testing.T) {
+	tests := []struct {
+		expression string
+		match      bool
+	}{
+		{`data.vm.name startsWith "prod-"`, true},
+		{`data.vm.name startsWith 'test-'`, false},
+		{`data.vm.name endsWith "-1"`, true},
+		{`data.vm.name contains "web"`, true},
+		{`data.vm.name matches "^prod-[a-z]+-[0-9]+$"`, true},
+		{`data.vm.tags contains "linux"`, true},
+		{`data.vm.tags contains "windows"`, false},
+		{`data.vm.cpus >= 4`, true},
+		{`data.vm.cpus > 4`, false},
+		{`data.disks.1.size == 100`, true},
+		{`data.disks.5.size == 100`, false},
+		{`data.vm.missing == null`, true},
+		{`data.vm.missing`, false},
+		{`data.vm.name`, true},
+		{`extensions.datacenter == "dc1"`, true},
+		{`extensions.datacenter != "dc1"`, false},
+		{`extensions.priority < 5`, true},
+		{`eventType == "vm.being.created"`, true},
+		{`source-type == "vcenter" && sourceID == "vcenter1"`, true},
+		{`eventTime > "2018-01-01"`, true},
+		{`data.vm.cpus == "4"`, false},
+		{`data.vm.name < 4`, false},
+		{`extensions.datacenter == "dc2" || data.vm.cpus == 4`, true},
+		{`extensions.datacenter == "dc2" or data.vm.cpus == 4 and false`, false},
+		{`!(extensions.datacenter == "dc2")`, true},
+		{`not extensions.datacenter == "dc1"`, false},
+		{`(true || false) && !false`, true},
+	}
+	for _, test := range tests {
+		e, err := Parse(test.expression)
+		require.NoError(t, err, test.expression)
+		assert.Equal(t, test.match, e.Match(&testEvent), test.expression)
+	}
+}
+
+func TestMatchRawData(t *testing.T) {
+	e, err := Parse(`data startsWith "hello"`)
+	require.NoError(t, err)
+	assert.True(t, e.Match(&events.CloudEvent{Data: "hello world"}))
+	assert.False(t, e.Match(&events.CloudEvent{Data: "goodbye"}))
+
+	e, err = Parse(`extensions.datacenter == "dc1"`)
+	require.NoError(t, err)
+	assert.False(t, e.Match(&events.CloudEvent{}))
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []string{
+		``,
+		`data.vm.name startsWith`,
+		`data.vm.name == "prod`,
+		`data..name == 1`,
+		`vm.name == "prod"`,
+		`eventType.name == "x"`,
+		`(data.vm.cpus == 4`,
+		`data.vm.cpus == 4)`,
+		`data.vm.name matches "["`,
+		`data.vm.name matches data.pattern`,
+		`data.vm.name = "x"`,
+		`data.vm.cpus == 4 4`,
+	}
+	for _, test := range tests {
+		_, err := Parse(test)
+		assert.Error(t, err, test)
+	}
+}
@@ -0,0 +1,81 @@
+///////////////////////////////////////////////////////////////////////
+// Copyright (c) 2017 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+///////////////////////////////////////////////////////////////////////
+
+package rpc
+
+//go:generate protoc --go_out=plugins=grpc:. events.proto
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/vmware/dispatch/pkg/events"
+)
+
+// FromCloudEvent converts events.CloudEvent into its wire representation.
+func FromCloudEvent(e *events.CloudEvent) (*CloudEvent, error) {
+	ce := &CloudEvent{
+		Namespace:          e.Namespace,
+		EventType:          e.EventType,
+		EventTypeVersion:   e.EventTypeVersion,
+		CloudEventsVersion: e.CloudEventsVersion,
+		SourceType:         e.SourceType,
+		SourceId:           e.SourceID,
+		EventId:            e.EventID,
+		SchemaUrl:          e.SchemaURL,
+		ContentType:        e.ContentType,
+		Data:               e.Data,
+	}
+	if !e.EventTime.IsZero() {
+		ce.EventTime = e.EventTime.Format(time.RFC3339Nano)
+	}
+	if len(e.Extensions) > 0 {
+		ce.Extensions = make(map[string]string, len(e.Extensions))
+		for key, val := range e.Extensions {
+			encoded, err := json.Marshal(val)
+			if err != nil {
+				return nil, errors.Wrapf(err, "error encoding extension %s", key)
+			}
+			ce.Extensions[key] = string(encoded)
+		}
+	}
+	return ce, nil
+}
+
+// ToCloudEvent converts wire representation of an event into events.CloudEvent.
+func (m *CloudEvent) ToCloudEvent() (*events.CloudEvent, error) {
+	e := &events.CloudEvent{
+		Namespace:          m.GetNamespace(),
+		EventType:          m.GetEventType(),
+		EventTypeVersion:   m.GetEventTypeVersion(),
+		CloudEventsVersion: m.GetCloudEventsVersion(),
+		SourceType:         m.GetSourceType(),
+		SourceID:           m.GetSourceId(),
+		EventID:            m.GetEventId(),
+		SchemaURL:          m.GetSchemaUrl(),
+		ContentType:        m.GetContentType(),
+		Data:               m.GetData(),
+	}
+	if m.GetEventTime() != "" {
+		t, err := time.Parse(time.RFC3339Nano, m.GetEventTime())
+		if err != nil {
+			return nil, errors.Wrapf(err, "error parsing event-time %s", m.GetEventTime())
+		}
+		e.EventTime = t
+	}
+	if len(m.GetExtensions()) > 0 {
+		e.Extensions = make(events.CloudEventExtensions, len(m.GetExtensions()))
+		for key, val := range m.GetExtensions() {
+			var decoded interface{}
+			if err := json.Unmarshal([]byte(val), &decoded); err != nil {
+				return nil, errors.Wrapf(err, "error decoding extension %s", key)
+			}
+			e.Extensions[key] = decoded
+		}
+	}
+	return e, nil
+}
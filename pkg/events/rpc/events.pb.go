@@ -0,0 +1,472 @@
+///////////////////////////////////////////////////////////////////////
+// Copyright (c) 2017 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+///////////////////////////////////////////////////////////////////////
+
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// source: events.proto
+
+/*
+Package rpc is a generated protocol buffer package.
+
+It is generated from these files:
+
+	events.proto
+
+It has these top-level messages:
+
+	CloudEvent
+	Events
+	Response
+*/
+package rpc
+
+import proto "github.com/golang/protobuf/proto"
+import fmt "fmt"
+import math "math"
+
+import (
+	context "golang.org/x/net/context"
+	grpc "google.golang.org/grpc"
+)
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ = proto.Marshal
+var _ = fmt.Errorf
+var _ = math.Inf
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the proto package it is being compiled against.
+// A compilation error at this line likely means your copy of the
+// proto package needs to be updated.
+const _ = proto.ProtoPackageIsVersion2 // please upgrade the proto package
+
+// CloudEvent is the wire representation of events.CloudEvent.
+type CloudEvent struct {
+	Namespace          string `protobuf:"bytes,1,opt,name=namespace" json:"namespace,omitempty"`
+	EventType          string `protobuf:"bytes,2,opt,name=event_type,json=eventType" json:"event_type,omitempty"`
+	EventTypeVersion   string `protobuf:"bytes,3,opt,name=event_type_version,json=eventTypeVersion" json:"event_type_version,omitempty"`
+	CloudEventsVersion string `protobuf:"bytes,4,opt,name=cloud_events_version,json=cloudEventsVersion" json:"cloud_events_version,omitempty"`
+	SourceType         string `protobuf:"bytes,5,opt,name=source_type,json=sourceType" json:"source_type,omitempty"`
+	SourceId           string `protobuf:"bytes,6,opt,name=source_id,json=sourceId" json:"source_id,omitempty"`
+	EventId            string `protobuf:"bytes,7,opt,name=event_id,json=eventId" json:"event_id,omitempty"`
+	// Timestamp in RFC 3339 format, e.g. "1985-04-12T23:20:50.52Z"
+	EventTime   string `protobuf:"bytes,8,opt,name=event_time,json=eventTime" json:"event_time,omitempty"`
+	SchemaUrl   string `protobuf:"bytes,9,opt,name=schema_url,json=schemaUrl" json:"schema_url,omitempty"`
+	ContentType string `protobuf:"bytes,10,opt,name=content_type,json=contentType" json:"content_type,omitempty"`
+	// Values are JSON-encoded
+	Extensions map[string]string `protobuf:"bytes,11,rep,name=extensions" json:"extensions,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	Data       string            `protobuf:"bytes,12,opt,name=data" json:"data,omitempty"`
+}
+
+func (m *CloudEvent) Reset()                    { *m = CloudEvent{} }
+func (m *CloudEvent) String() string            { return proto.CompactTextString(m) }
+func (*CloudEvent) ProtoMessage()               {}
+func (*CloudEvent) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{0} }
+
+func (m *CloudEvent) GetNamespace() string {
+	if m != nil {
+		return m.Namespace
+	}
+	return ""
+}
+
+func (m *CloudEvent) GetEventType() string {
+	if m != nil {
+		return m.EventType
+	}
+	return ""
+}
+
+func (m *CloudEvent) GetEventTypeVersion() string {
+	if m != nil {
+		return m.EventTypeVersion
+	}
+	return ""
+}
+
+func (m *CloudEvent) GetCloudEventsVersion() string {
+	if m != nil {
+		return m.CloudEventsVersion
+	}
+	return ""
+}
+
+func (m *CloudEvent) GetSourceType() string {
+	if m != nil {
+		return m.SourceType
+	}
+	return ""
+}
+
+func (m *CloudEvent) GetSourceId() string {
+	if m != nil {
+		return m.SourceId
+	}
+	return ""
+}
+
+func (m *CloudEvent) GetEventId() string {
+	if m != nil {
+		return m.EventId
+	}
+	return ""
+}
+
+func (m *CloudEvent) GetEventTime() string {
+	if m != nil {
+		return m.EventTime
+	}
+	return ""
+}
+
+func (m *CloudEvent) GetSchemaUrl() string {
+	if m != nil {
+		return m.SchemaUrl
+	}
+	return ""
+}
+
+func (m *CloudEvent) GetContentType() string {
+	if m != nil {
+		return m.ContentType
+	}
+	return ""
+}
+
+func (m *CloudEvent) GetExtensions() map[string]string {
+	if m != nil {
+		return m.Extensions
+	}
+	return nil
+}
+
+func (m *CloudEvent) GetData() string {
+	if m != nil {
+		return m.Data
+	}
+	return ""
+}
+
+// Events is a batch of events.
+type Events struct {
+	Events []*CloudEvent `protobuf:"bytes,1,rep,name=events" json:"events,omitempty"`
+}
+
+func (m *Events) Reset()                    { *m = Events{} }
+func (m *Events) String() string            { return proto.CompactTextString(m) }
+func (*Events) ProtoMessage()               {}
+func (*Events) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{1} }
+
+func (m *Events) GetEvents() []*CloudEvent {
+	if m != nil {
+		return m.Events
+	}
+	return nil
+}
+
+// Response is returned once all events of the request were processed.
+type Response struct {
+	// Number of events accepted
+	Count int32 `protobuf:"varint,1,opt,name=count" json:"count,omitempty"`
+}
+
+func (m *Response) Reset()                    { *m = Response{} }
+func (m *Response) String() string            { return proto.CompactTextString(m) }
+func (*Response) ProtoMessage()               {}
+func (*Response) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{2} }
+
+func (m *Response) GetCount() int32 {
+	if m != nil {
+		return m.Count
+	}
+	return 0
+}
+
+func init() {
+	proto.RegisterType((*CloudEvent)(nil), "dispatch.events.CloudEvent")
+	proto.RegisterType((*Events)(nil), "dispatch.events.Events")
+	proto.RegisterType((*Response)(nil), "dispatch.events.Response")
+}
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ context.Context
+var _ grpc.ClientConn
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+const _ = grpc.SupportPackageIsVersion4
+
+// Client API for EventSidecar service
+
+type EventSidecarClient interface {
+	// Send validates and publishes a batch of events.
+	Send(ctx context.Context, in *Events, opts ...grpc.CallOption) (*Response, error)
+	// SendStream validates and publishes events as they arrive on the stream.
+	SendStream(ctx context.Context, opts ...grpc.CallOption) (EventSidecar_SendStreamClient, error)
+	// Validate validates a batch of events without publishing them.
+	Validate(ctx context.Context, in *Events, opts ...grpc.CallOption) (*Response, error)
+	// ValidateStream validates events as they arrive on the stream without publishing them.
+	ValidateStream(ctx context.Context, opts ...grpc.CallOption) (EventSidecar_ValidateStreamClient, error)
+}
+
+type eventSidecarClient struct {
+	cc *grpc.ClientConn
+}
+
+func NewEventSidecarClient(cc *grpc.ClientConn) EventSidecarClient {
+	return &eventSidecarClient{cc}
+}
+
+func (c *eventSidecarClient) Send(ctx context.Context, in *Events, opts ...grpc.CallOption) (*Response, error) {
+	out := new(Response)
+	err := grpc.Invoke(ctx, "/dispatch.events.EventSidecar/Send", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *eventSidecarClient) SendStream(ctx context.Context, opts ...grpc.CallOption) (EventSidecar_SendStreamClient, error) {
+	stream, err := grpc.NewClientStream(ctx, &_EventSidecar_serviceDesc.Streams[0], c.cc, "/dispatch.events.EventSidecar/SendStream", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &eventSidecarSendStreamClient{stream}
+	return x, nil
+}
+
+type EventSidecar_SendStreamClient interface {
+	Send(*CloudEvent) error
+	CloseAndRecv() (*Response, error)
+	grpc.ClientStream
+}
+
+type eventSidecarSendStreamClient struct {
+	grpc.ClientStream
+}
+
+func (x *eventSidecarSendStreamClient) Send(m *CloudEvent) error {
+	return x.ClientStream.SendMsg(m)
+}
+
+func (x *eventSidecarSendStreamClient) CloseAndRecv() (*Response, error) {
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	m := new(Response)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *eventSidecarClient) Validate(ctx context.Context, in *Events, opts ...grpc.CallOption) (*Response, error) {
+	out := new(Response)
+	err := grpc.Invoke(ctx, "/dispatch.events.EventSidecar/Validate", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *eventSidecarClient) ValidateStream(ctx context.Context, opts ...grpc.CallOption) (EventSidecar_ValidateStreamClient, error) {
+	stream, err := grpc.NewClientStream(ctx, &_EventSidecar_serviceDesc.Streams[1], c.cc, "/dispatch.events.EventSidecar/ValidateStream", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &eventSidecarValidateStreamClient{stream}
+	return x, nil
+}
+
+type EventSidecar_ValidateStreamClient interface {
+	Send(*CloudEvent) error
+	CloseAndRecv() (*Response, error)
+	grpc.ClientStream
+}
+
+type eventSidecarValidateStreamClient struct {
+	grpc.ClientStream
+}
+
+func (x *eventSidecarValidateStreamClient) Send(m *CloudEvent) error {
+	return x.ClientStream.SendMsg(m)
+}
+
+func (x *eventSidecarValidateStreamClient) CloseAndRecv() (*Response, error) {
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	m := new(Response)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// Server API for EventSidecar service
+
+type EventSidecarServer interface {
+	// Send validates and publishes a batch of events.
+	Send(context.Context, *Events) (*Response, error)
+	// SendStream validates and publishes events as they arrive on the stream.
+	SendStream(EventSidecar_SendStreamServer) error
+	// Validate validates a batch of events without publishing them.
+	Validate(context.Context, *Events) (*Response, error)
+	// ValidateStream validates events as they arrive on the stream without publishing them.
+	ValidateStream(EventSidecar_ValidateStreamServer) error
+}
+
+func RegisterEventSidecarServer(s *grpc.Server, srv EventSidecarServer) {
+	s.RegisterService(&_EventSidecar_serviceDesc, srv)
+}
+
+func _EventSidecar_Send_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Events)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(EventSidecarServer).Send(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/dispatch.events.EventSidecar/Send",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(EventSidecarServer).Send(ctx, req.(*Events))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _EventSidecar_SendStream_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(EventSidecarServer).SendStream(&eventSidecarSendStreamServer{stream})
+}
+
+type EventSidecar_SendStreamServer interface {
+	SendAndClose(*Response) error
+	Recv() (*CloudEvent, error)
+	grpc.ServerStream
+}
+
+type eventSidecarSendStreamServer struct {
+	grpc.ServerStream
+}
+
+func (x *eventSidecarSendStreamServer) SendAndClose(m *Response) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func (x *eventSidecarSendStreamServer) Recv() (*CloudEvent, error) {
+	m := new(CloudEvent)
+	if err := x.ServerStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func _EventSidecar_Validate_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Events)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(EventSidecarServer).Validate(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/dispatch.events.EventSidecar/Validate",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(EventSidecarServer).Validate(ctx, req.(*Events))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _EventSidecar_ValidateStream_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(EventSidecarServer).ValidateStream(&eventSidecarValidateStreamServer{stream})
+}
+
+type EventSidecar_ValidateStreamServer interface {
+	SendAndClose(*Response) error
+	Recv() (*CloudEvent, error)
+	grpc.ServerStream
+}
+
+type eventSidecarValidateStreamServer struct {
+	grpc.ServerStream
+}
+
+func (x *eventSidecarValidateStreamServer) SendAndClose(m *Response) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func (x *eventSidecarValidateStreamServer) Recv() (*CloudEvent, error) {
+	m := new(CloudEvent)
+	if err := x.ServerStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+var _EventSidecar_serviceDesc = grpc.ServiceDesc{
+	ServiceName: "dispatch.events.EventSidecar",
+	HandlerType: (*EventSidecarServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Send",
+			Handler:    _EventSidecar_Send_Handler,
+		},
+		{
+			MethodName: "Validate",
+			Handler:    _EventSidecar_Validate_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "SendStream",
+			Handler:       _EventSidecar_SendStream_Handler,
+			ClientStreams: true,
+		},
+		{
+			StreamName:    "ValidateStream",
+			Handler:       _EventSidecar_ValidateStream_Handler,
+			ClientStreams: true,
+		},
+	},
+	Metadata: "events.proto",
+}
+
+func init() { proto.RegisterFile("events.proto", fileDescriptor0) }
+
+var fileDescriptor0 = []byte{
+	// 436 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xa4, 0x53, 0x4d, 0x6b, 0x1b, 0x31,
+	0x10, 0x65, 0xfd, 0x95, 0xf5, 0xd8, 0x34, 0x61, 0x08, 0x54, 0x49, 0x5a, 0xea, 0xfa, 0x64, 0x68,
+	0x59, 0x4a, 0x72, 0x29, 0x81, 0xf4, 0xd0, 0xe2, 0x42, 0xda, 0xdb, 0xba, 0xcd, 0xa1, 0x17, 0xa3,
+	0x4a, 0x03, 0x59, 0xba, 0xab, 0x5d, 0x24, 0xad, 0xa9, 0xcf, 0xfd, 0x1f, 0xfd, 0xad, 0x65, 0x47,
+	0xf6, 0xda, 0x24, 0xe0, 0x43, 0x73, 0xd3, 0xbc, 0x79, 0x4f, 0xef, 0x8d, 0x06, 0xc1, 0x98, 0x56,
+	0x64, 0xbc, 0x4b, 0x2a, 0x5b, 0xfa, 0x12, 0x8f, 0x75, 0xe6, 0x2a, 0xe9, 0xd5, 0x7d, 0x12, 0xe0,
+	0xe9, 0x9f, 0x1e, 0xc0, 0xa7, 0xbc, 0xac, 0xf5, 0xbc, 0xa9, 0xf1, 0x05, 0x0c, 0x8d, 0x2c, 0xc8,
+	0x55, 0x52, 0x91, 0x88, 0x26, 0xd1, 0x6c, 0x98, 0xee, 0x00, 0x7c, 0x09, 0xc0, 0xb2, 0xa5, 0x5f,
+	0x57, 0x24, 0x3a, 0xa1, 0xcd, 0xc8, 0xb7, 0x75, 0x45, 0xf8, 0x16, 0x70, 0xd7, 0x5e, 0xae, 0xc8,
+	0xba, 0xac, 0x34, 0xa2, 0xcb, 0xb4, 0x93, 0x96, 0x76, 0x17, 0x70, 0x7c, 0x07, 0xa7, 0xaa, 0x31,
+	0x5e, 0x86, 0x24, 0x2d, 0xbf, 0xc7, 0x7c, 0x54, 0x6d, 0x28, 0xb7, 0x55, 0xbc, 0x82, 0x91, 0x2b,
+	0x6b, 0xab, 0x28, 0xf8, 0xf7, 0x99, 0x08, 0x01, 0xe2, 0x00, 0x17, 0x30, 0xdc, 0x10, 0x32, 0x2d,
+	0x06, 0xdc, 0x8e, 0x03, 0x70, 0xab, 0xf1, 0x0c, 0xe2, 0x90, 0x2e, 0xd3, 0xe2, 0x88, 0x7b, 0x47,
+	0x5c, 0xdf, 0xea, 0xbd, 0xb9, 0xb2, 0x82, 0x44, 0xbc, 0x3f, 0x57, 0x56, 0xf0, 0xd8, 0x4e, 0xdd,
+	0x53, 0x21, 0x97, 0xb5, 0xcd, 0xc5, 0x30, 0xb4, 0x03, 0xf2, 0xdd, 0xe6, 0xf8, 0x1a, 0xc6, 0xaa,
+	0x34, 0xbe, 0x7d, 0x17, 0x60, 0xc2, 0x68, 0x83, 0x71, 0xb0, 0xaf, 0x00, 0xf4, 0xdb, 0x93, 0x69,
+	0xc6, 0x70, 0x62, 0x34, 0xe9, 0xce, 0x46, 0x97, 0x6f, 0x92, 0x07, 0xbb, 0x48, 0x76, 0x7b, 0x48,
+	0xe6, 0x2d, 0x7b, 0x6e, 0xbc, 0x5d, 0xa7, 0x7b, 0x72, 0x44, 0xe8, 0x69, 0xe9, 0xa5, 0x18, 0xb3,
+	0x0f, 0x9f, 0xcf, 0x6f, 0xe0, 0xf8, 0x81, 0x04, 0x4f, 0xa0, 0xfb, 0x8b, 0xd6, 0x9b, 0x25, 0x36,
+	0x47, 0x3c, 0x85, 0xfe, 0x4a, 0xe6, 0xf5, 0x76, 0x73, 0xa1, 0xb8, 0xee, 0xbc, 0x8f, 0xa6, 0x37,
+	0x30, 0x08, 0x4f, 0x8d, 0x57, 0x30, 0x08, 0x69, 0x44, 0xc4, 0x29, 0x2f, 0x0e, 0xa4, 0x4c, 0x37,
+	0xd4, 0xe9, 0x04, 0xe2, 0x94, 0x5c, 0x55, 0x1a, 0x47, 0x8d, 0x89, 0x2a, 0x6b, 0xe3, 0xd9, 0xb8,
+	0x9f, 0x86, 0xe2, 0xf2, 0x6f, 0x07, 0xc6, 0xac, 0x59, 0x64, 0x9a, 0x94, 0xb4, 0x78, 0x0d, 0xbd,
+	0x05, 0x19, 0x8d, 0xcf, 0x1f, 0xdd, 0x1f, 0x82, 0x9c, 0x9f, 0x3d, 0x6a, 0xb4, 0x16, 0x9f, 0x01,
+	0x1a, 0xed, 0xc2, 0x5b, 0x92, 0x05, 0x1e, 0x4a, 0x78, 0xe0, 0x96, 0x59, 0x84, 0x1f, 0x20, 0xbe,
+	0x93, 0x79, 0xa6, 0xa5, 0xa7, 0xff, 0xca, 0xf1, 0x05, 0x9e, 0x6d, 0xf5, 0x4f, 0xcd, 0xf2, 0xb1,
+	0xff, 0xa3, 0x6b, 0x2b, 0xf5, 0x73, 0xc0, 0xdf, 0xf4, 0xea, 0xdf, 0x00, 0x13, 0x73, 0x2d, 0xc1,
+	0xb6, 0x03, 0x00, 0x00,
+}
@@ -0,0 +1,35 @@
+///////////////////////////////////////////////////////////////////////
+// Copyright (c) 2017 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+///////////////////////////////////////////////////////////////////////
+
+package rpc
+
+import (
+	"strings"
+
+	"google.golang.org/grpc/metadata"
+)
+
+// NO TESTS
+
+// MetadataCarrier implements opentracing TextMapReader and TextMapWriter on top of gRPC metadata.
+type MetadataCarrier metadata.MD
+
+// ForeachKey conforms to the opentracing TextMapReader interface.
+func (c MetadataCarrier) ForeachKey(handler func(key, val string) error) error {
+	for key, vals := range c {
+		for _, val := range vals {
+			if err := handler(key, val); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Set conforms to the opentracing TextMapWriter interface. gRPC metadata keys are always lowercase.
+func (c MetadataCarrier) Set(key, val string) {
+	key = strings.ToLower(key)
+	c[key] = append(c[key], val)
+}
@@ -5,4 +5,400 @@
 
 package transport
 
-// NO TESTS
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Shopify/sarama"
+	"github.com/opentracing/opentracing-go"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/vmware/dispatch/pkg/events"
+	"github.com/vmware/dispatch/pkg/trace"
+)
+
+const (
+	kafkaHeaderPrefix    = "dispatch-"
+	kafkaExtensionPrefix = "dispatch-ext-"
+)
+
+// kafkaPartitionsPollPeriod is the period at which subscriptions look for new partitions of their topic, including
+// the topic being created
+var kafkaPartitionsPollPeriod = 10 * time.Second
+
+// Kafka implements transport over Kafka messaging service
+type Kafka struct {
+	brokers  []string
+	tenant   string
+	sendOnly bool
+	config   *sarama.Config
+	producer sarama.SyncProducer
+	consumer sarama.Consumer
+
+	done chan struct{}
+	wg   sync.WaitGroup
+}
+
+// OptKafkaSendOnly creates only the producer. Subscribe operation will return an error.
+func OptKafkaSendOnly() func(k *Kafka) error {
+	return func(k *Kafka) error {
+		k.sendOnly = true
+		return nil
+	}
+}
+
+// OptKafkaClients makes Kafka use the provided producer and consumer instead of connecting
+// to brokers. Useful when testing against an in-process broker.
+func OptKafkaClients(producer sarama.SyncProducer, consumer sarama.Consumer) func(k *Kafka) error {
+	return func(k *Kafka) error {
+		k.producer = producer
+		k.consumer = consumer
+		return nil
+	}
+}
+
+// NewKafka creates new instance of Kafka transport. brokers is a list of Kafka brokers addresses,
+// defaultTenant is used when publishing without tenant and when subscribing.
+// Accepts variadic list of function options.
+func NewKafka(brokers []string, defaultTenant string, options ...func(k *Kafka) error) (*Kafka, error) {
+	defer trace.Tracef("brokers: %+v, defaultTenant: %+v", brokers, defaultTenant)()
+
+	config := sarama.NewConfig()
+	// Record headers were introduced in Kafka 0.11
+	config.Version = sarama.V0_11_0_0
+	config.Producer.RequiredAcks = sarama.WaitForAll
+	config.Producer.Return.Successes = true
+	// the partitions added to a topic are only known once the metadata is refreshed
+	config.Metadata.RefreshFrequency = kafkaPartitionsPollPeriod
+
+	k := &Kafka{
+		brokers: brokers,
+		tenant:  defaultTenant,
+		config:  config,
+		done:    make(chan struct{}),
+	}
+
+	for _, option := range options {
+		if err := option(k); err != nil {
+			return nil, errors.Wrap(err, "error when applying Kafka option")
+		}
+	}
+
+	var err error
+	if k.producer == nil {
+		k.producer, err = sarama.NewSyncProducer(brokers, config)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to create Kafka producer")
+		}
+	}
+	if k.consumer == nil && !k.sendOnly {
+		k.consumer, err = sarama.NewConsumer(brokers, config)
+		if err != nil {
+			k.producer.Close()
+			return nil, errors.Wrap(err, "failed to create Kafka consumer")
+		}
+	}
+	return k, nil
+}
+
+// Publish sends an event to Kafka. tenant specifies the tenant
+func (k *Kafka) Publish(ctx context.Context, event *events.CloudEvent, topic string, tenant string) error {
+	defer trace.Tracef("topic: %s", event.EventType)()
+
+	sp, _ := opentracing.StartSpanFromContext(
+		ctx,
+		"Kafka.Publish",
+	)
+	defer sp.Finish()
+
+	if tenant == "" {
+		tenant = k.tenant
+	}
+
+	msg, err := k.eventToMsg(event)
+	if err != nil {
+		return errors.Wrapf(err, "error when encoding an event, topic: %s, tenant: %s", topic, tenant)
+	}
+	msg.Topic = kafkaTopic(tenant, topic)
+
+	// Inject the span context into the Kafka headers.
+	carrier := kafkaHeaders(msg.Headers)
+	if err := sp.Tracer().Inject(sp.Context(), opentracing.TextMap, &carrier); err != nil {
+		return err
+	}
+	msg.Headers = carrier
+
+	if _, _, err := k.producer.SendMessage(msg); err != nil {
+		return errors.Wrapf(err, "error when publishing a message, topic: %s, tenant: %s", topic, tenant)
+	}
+	return nil
+}
+
+// Subscribe creates an active subscription on specified topic, and invokes handler function
+// for every event received on given topic.
+func (k *Kafka) Subscribe(ctx context.Context, topic string, handler events.Handler) (events.Subscription, error) {
+	defer trace.Tracef("topic: %s", topic)()
+	sp, _ := opentracing.StartSpanFromContext(
+		ctx,
+		"Kafka.Subscribe",
+	)
+	defer sp.Finish()
+
+	if k.consumer == nil {
+		return nil, errors.New("Kafka transport created in send-only mode")
+	}
+
+	name := kafkaTopic(k.tenant, topic)
+	partitions, err := k.partitions(name)
+	if err != nil {
+		return nil, err
+	}
+
+	doneChan := make(chan struct{})
+	closed := k.done
+	consume := func(partition int32, offset int64) error {
+		pc, err := k.consumer.ConsumePartition(name, partition, offset)
+		if err != nil {
+			return errors.Wrapf(err, "error when consuming partition %d of topic %s", partition, name)
+		}
+		k.wg.Add(1)
+		go func() {
+			defer k.wg.Done()
+			defer pc.Close()
+			defer trace.Tracef("listening for messages on topic: %s, partition: %d", name, partition)()
+			for {
+				select {
+				case msg, open := <-pc.Messages():
+					if !open {
+						return
+					}
+					k.handleMessage(msg, handler)
+				case <-doneChan:
+					return
+				case <-closed:
+					return
+				}
+			}
+		}()
+		return nil
+	}
+
+	// Like the exclusive queues used by RabbitMQ, every subscription receives all events
+	// published after it was created.
+	consumed := make(map[int32]bool)
+	for _, partition := range partitions {
+		if err := consume(partition, sarama.OffsetNewest); err != nil {
+			close(doneChan)
+			return nil, err
+		}
+		consumed[partition] = true
+	}
+
+	// The topic may not exist yet, and partitions may be added later. They are created after the subscription, so
+	// they are consumed from the oldest offset.
+	k.wg.Add(1)
+	go func() {
+		defer k.wg.Done()
+		ticker := time.NewTicker(kafkaPartitionsPollPeriod)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+			case <-doneChan:
+				return
+			case <-closed:
+				return
+			}
+			partitions, err := k.partitions(name)
+			if err != nil {
+				log.Warnf("Error when polling partitions of topic %s: %+v", name, err)
+				continue
+			}
+			for _, partition := range partitions {
+				if consumed[partition] {
+					continue
+				}
+				if err := consume(partition, sarama.OffsetOldest); err != nil {
+					log.Errorf("%+v", err)
+					continue
+				}
+				consumed[partition] = true
+			}
+		}
+	}()
+	return &subscription{done: doneChan}, nil
+}
+
+// partitions returns the partitions of a topic, none if the topic does not exist yet
+func (k *Kafka) partitions(name string) ([]int32, error) {
+	partitions, err := k.consumer.Partitions(name)
+	if err == sarama.ErrUnknownTopicOrPartition {
+		return nil, nil
+	}
+	return partitions, errors.Wrapf(err, "error when getting partitions of topic %s", name)
+}
+
+func (k *Kafka) handleMessage(msg *sarama.ConsumerMessage, handler events.Handler) {
+	headers := make(kafkaHeaders, 0, len(msg.Headers))
+	for _, h := range msg.Headers {
+		headers = append(headers, *h)
+	}
+
+	spCtx, _ := opentracing.GlobalTracer().Extract(opentracing.TextMap, headers)
+	spSub := opentracing.StartSpan(
+		"Kafka.SubscriptionHandler",
+		opentracing.FollowsFrom(spCtx),
+	)
+	defer spSub.Finish()
+
+	// Update the context with the span for the subsequent reference.
+	ctx := opentracing.ContextWithSpan(context.Background(), spSub)
+	log.Debugf("Got an event: %s, partition %d, offset %d", msg.Topic, msg.Partition, msg.Offset)
+	event, err := k.msgToEvent(msg.Value, headers)
+	if err != nil {
+		log.Errorf("Error when decoding message from topic %s: %+v", msg.Topic, err)
+		return
+	}
+	handler(ctx, event)
+}
+
+func (k *Kafka) eventToMsg(event *events.CloudEvent) (*sarama.ProducerMessage, error) {
+	headers := kafkaHeaders{}
+	headers.Set(kafkaHeaderPrefix+"namespace", event.Namespace)
+	headers.Set(kafkaHeaderPrefix+"event-type", event.EventType)
+	headers.Set(kafkaHeaderPrefix+"event-type-version", event.EventTypeVersion)
+	headers.Set(kafkaHeaderPrefix+"cloud-events-version", event.CloudEventsVersion)
+	headers.Set(kafkaHeaderPrefix+"source-type", event.SourceType)
+	headers.Set(kafkaHeaderPrefix+"source-id", event.SourceID)
+	headers.Set(kafkaHeaderPrefix+"event-id", event.EventID)
+	headers.Set(kafkaHeaderPrefix+"event-time", event.EventTime.Format(time.RFC3339Nano))
+	headers.Set(kafkaHeaderPrefix+"schema-url", event.SchemaURL)
+	headers.Set(kafkaHeaderPrefix+"content-type", event.ContentType)
+	for key, val := range event.Extensions {
+		encoded, err := json.Marshal(val)
+		if err != nil {
+			return nil, errors.Wrapf(err, "error when encoding extension %s", key)
+		}
+		headers = append(headers, sarama.RecordHeader{Key: []byte(kafkaExtensionPrefix + key), Value: encoded})
+	}
+
+	return &sarama.ProducerMessage{
+		Key:       sarama.StringEncoder(event.EventID),
+		Value:     sarama.StringEncoder(event.Data),
+		Headers:   headers,
+		Timestamp: event.EventTime,
+	}, nil
+}
+
+func (k *Kafka) msgToEvent(value []byte, headers kafkaHeaders) (*events.CloudEvent, error) {
+	event := &events.CloudEvent{
+		Namespace:          headers.Get(kafkaHeaderPrefix + "namespace"),
+		EventType:          headers.Get(kafkaHeaderPrefix + "event-type"),
+		EventTypeVersion:   headers.Get(kafkaHeaderPrefix + "event-type-version"),
+		CloudEventsVersion: headers.Get(kafkaHeaderPrefix + "cloud-events-version"),
+		SourceType:         headers.Get(kafkaHeaderPrefix + "source-type"),
+		SourceID:           headers.Get(kafkaHeaderPrefix + "source-id"),
+		EventID:            headers.Get(kafkaHeaderPrefix + "event-id"),
+		SchemaURL:          headers.Get(kafkaHeaderPrefix + "schema-url"),
+		ContentType:        headers.Get(kafkaHeaderPrefix + "content-type"),
+		Data:               string(value),
+	}
+	if event.CloudEventsVersion == "" {
+		event.CloudEventsVersion = events.CloudEventsVersion
+	}
+	if t := headers.Get(kafkaHeaderPrefix + "event-time"); t != "" {
+		eventTime, err := time.Parse(time.RFC3339Nano, t)
+		if err != nil {
+			return nil, errors.Wrapf(err, "error when parsing event time %s", t)
+		}
+		event.EventTime = eventTime
+	}
+	for _, h := range headers {
+		key := string(h.Key)
+		if !strings.HasPrefix(key, kafkaExtensionPrefix) {
+			continue
+		}
+		var val interface{}
+		if err := json.Unmarshal(h.Value, &val); err != nil {
+			return nil, errors.Wrapf(err, "error when decoding extension %s", key)
+		}
+		if event.Extensions == nil {
+			event.Extensions = events.CloudEventExtensions{}
+		}
+		event.Extensions[strings.TrimPrefix(key, kafkaExtensionPrefix)] = val
+	}
+	return event, nil
+}
+
+// Close closes Kafka producer and consumer and stops all subscriptions
+func (k *Kafka) Close() {
+	defer trace.Trace("")()
+	if k.done == nil {
+		return
+	}
+	close(k.done)
+	k.done = nil
+	// Partition consumers must be closed before the consumer
+	k.wg.Wait()
+	if k.producer != nil {
+		if err := k.producer.Close(); err != nil {
+			log.Errorf("Error when closing Kafka producer: %+v", err)
+		}
+	}
+	if k.consumer != nil {
+		if err := k.consumer.Close(); err != nil {
+			log.Errorf("Error when closing Kafka consumer: %+v", err)
+		}
+	}
+}
+
+// kafkaTopic maps tenant and topic onto a Kafka topic name. Kafka has no equivalent of RabbitMQ
+// exchanges, so tenant becomes part of the topic name.
+func kafkaTopic(tenant, topic string) string {
+	name := fmt.Sprintf("%s.%s", tenant, topic)
+	// Kafka topic names are limited to [a-zA-Z0-9._-]
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '.', r == '_', r == '-':
+			return r
+		default:
+			return '_'
+		}
+	}, name)
+}
+
+// kafkaHeaders implements opentracing TextMapReader and TextMapWriter on top of Kafka record headers.
+type kafkaHeaders []sarama.RecordHeader
+
+// Get returns value of first header matching the key.
+func (h kafkaHeaders) Get(key string) string {
+	for _, header := range h {
+		if string(header.Key) == key {
+			return string(header.Value)
+		}
+	}
+	return ""
+}
+
+// Set conforms to the opentracing TextMapWriter interface.
+func (h *kafkaHeaders) Set(key, val string) {
+	if val == "" {
+		return
+	}
+	*h = append(*h, sarama.RecordHeader{Key: []byte(key), Value: []byte(val)})
+}
+
+// ForeachKey conforms to the opentracing TextMapReader interface.
+func (h kafkaHeaders) ForeachKey(handler func(key, val string) error) error {
+	for _, header := range h {
+		if err := handler(string(header.Key), string(header.Value)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,142 @@
+///////////////////////////////////////////////////////////////////////
+// Copyright (c) 2017 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+///////////////////////////////////////////////////////////////////////
+
+package transport
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/vmware/dispatch/pkg/events"
+	"github.com/vmware/dispatch/pkg/events/transport/kafkatest"
+)
+
+func testKafka(t *testing.T, broker *kafkatest.Broker) *Kafka {
+	k, err := NewKafka(nil, "dispatch", OptKafkaClients(broker, broker))
+	require.NoError(t, err)
+	return k
+}
+
+func testCloudEvent() *events.CloudEvent {
+	event := events.NewCloudEventWithDefaults("test.event")
+	event.EventTime = time.Date(2018, 3, 14, 12, 0, 0, 0, time.UTC)
+	event.ContentType = "application/json"
+	event.SchemaURL = "http://some.url.com/file"
+	event.Extensions = events.CloudEventExtensions{
+		"datacenter": "dc1",
+		"count":      float64(3),
+	}
+	event.Data = `{"example":"value"}`
+	return &event
+}
+
+func TestKafkaPublishSubscribe(t *testing.T) {
+	broker := kafkatest.NewBroker()
+	broker.CreateTopic("dispatch.dispatch.test.event")
+	k := testKafka(t, broker)
+	defer k.Close()
+
+	received := make(chan *events.CloudEvent, 1)
+	sub, err := k.Subscribe(context.Background(), "dispatch.test.event", func(ctx context.Context, e *events.CloudEvent) {
+		received <- e
+	})
+	require.NoError(t, err)
+	defer sub.Unsubscribe()
+
+	sent := testCloudEvent()
+	require.NoError(t, k.Publish(context.Background(), sent, "dispatch.test.event", ""))
+
+	select {
+	case got := <-received:
+		assert.Equal(t, sent.EventID, got.EventID)
+		assert.Equal(t, sent.Namespace, got.Namespace)
+		assert.Equal(t, sent.EventType, got.EventType)
+		assert.Equal(t, sent.CloudEventsVersion, got.CloudEventsVersion)
+		assert.Equal(t, sent.SourceType, got.SourceType)
+		assert.Equal(t, sent.SourceID, got.SourceID)
+		assert.Equal(t, sent.SchemaURL, got.SchemaURL)
+		assert.Equal(t, sent.ContentType, got.ContentType)
+		assert.True(t, sent.EventTime.Equal(got.EventTime))
+		assert.Equal(t, sent.Extensions, got.Extensions)
+		assert.Equal(t, sent.Data, got.Data)
+	case <-time.After(5 * time.Second):
+		t.Fatal("event not received")
+	}
+}
+
+func TestKafkaSubscribeBeforeTopic(t *testing.T) {
+	defer func(period time.Duration) { kafkaPartitionsPollPeriod = period }(kafkaPartitionsPollPeriod)
+	kafkaPartitionsPollPeriod = 10 * time.Millisecond
+
+	broker := kafkatest.NewBroker()
+	k := testKafka(t, broker)
+	defer k.Close()
+
+	received := make(chan *events.CloudEvent, 1)
+	sub, err := k.Subscribe(context.Background(), "dispatch.test.event", func(ctx context.Context, e *events.CloudEvent) {
+		received <- e
+	})
+	require.NoError(t, err)
+	defer sub.Unsubscribe()
+
+	// the topic is created by the first event, which is received once the subscription finds the topic
+	sent := testCloudEvent()
+	require.NoError(t, k.Publish(context.Background(), sent, "dispatch.test.event", ""))
+
+	select {
+	case got := <-received:
+		assert.Equal(t, sent.EventID, got.EventID)
+	case <-time.After(5 * time.Second):
+		t.Fatal("event not received")
+	}
+}
+
+func TestKafkaTenant(t *testing.T) {
+	broker := kafkatest.NewBroker()
+	k := testKafka(t, broker)
+	defer k.Close()
+
+	require.NoError(t, k.Publish(context.Background(), testCloudEvent(), "dispatch.test.event", "tenant1"))
+	require.NoError(t, k.Publish(context.Background(), testCloudEvent(), "dispatch.test.event", ""))
+
+	assert.Len(t, broker.Messages("tenant1.dispatch.test.event"), 1)
+	assert.Len(t, broker.Messages("dispatch.dispatch.test.event"), 1)
+	assert.Equal(t, "tenant_1.topic_x", kafkaTopic("tenant/1", "topic*x"))
+}
+
+func TestKafkaUnsubscribe(t *testing.T) {
+	broker := kafkatest.NewBroker()
+	k := testKafka(t, broker)
+	defer k.Close()
+
+	received := make(chan *events.CloudEvent, 2)
+	sub, err := k.Subscribe(context.Background(), "topic", func(ctx context.Context, e *events.CloudEvent) {
+		received <- e
+	})
+	require.NoError(t, err)
+	require.NoError(t, sub.Unsubscribe())
+
+	require.NoError(t, k.Publish(context.Background(), testCloudEvent(), "topic", ""))
+	select {
+	case <-received:
+		t.Fatal("event received after unsubscribe")
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func TestKafkaSendOnly(t *testing.T) {
+	broker := kafkatest.NewBroker()
+	k, err := NewKafka(nil, "dispatch", OptKafkaSendOnly(), OptKafkaClients(broker, nil))
+	require.NoError(t, err)
+	defer k.Close()
+
+	_, err = k.Subscribe(context.Background(), "topic", func(context.Context, *events.CloudEvent) {})
+	assert.Error(t, err)
+	assert.NoError(t, k.Publish(context.Background(), testCloudEvent(), "topic", ""))
+}
@@ -0,0 +1,277 @@
+///////////////////////////////////////////////////////////////////////
+// Copyright (c) 2017 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+///////////////////////////////////////////////////////////////////////
+
+// Package kafkatest provides an in-process stand-in for a Kafka broker. It implements both
+// sarama.SyncProducer and sarama.Consumer, so it can be passed to transport.OptKafkaClients
+// to exercise the Kafka transport without a cluster.
+package kafkatest
+
+// NO TESTS
+
+import (
+	"sync"
+	"time"
+
+	"github.com/Shopify/sarama"
+	"github.com/pkg/errors"
+)
+
+// Broker keeps every topic as a single, in-memory partition.
+type Broker struct {
+	mu        sync.Mutex
+	topics    map[string][]*sarama.ConsumerMessage
+	consumers map[string][]*partitionConsumer
+	closed    bool
+}
+
+// NewBroker creates an empty in-process broker
+func NewBroker() *Broker {
+	return &Broker{
+		topics:    make(map[string][]*sarama.ConsumerMessage),
+		consumers: make(map[string][]*partitionConsumer),
+	}
+}
+
+// SendMessage appends the message to the topic log and delivers it to all partition consumers.
+func (b *Broker) SendMessage(msg *sarama.ProducerMessage) (partition int32, offset int64, err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.closed {
+		return 0, 0, sarama.ErrClosedClient
+	}
+
+	cm := &sarama.ConsumerMessage{
+		Topic:     msg.Topic,
+		Timestamp: msg.Timestamp,
+		Offset:    int64(len(b.topics[msg.Topic])),
+	}
+	if cm.Timestamp.IsZero() {
+		cm.Timestamp = time.Now()
+	}
+	if msg.Key != nil {
+		if cm.Key, err = msg.Key.Encode(); err != nil {
+			return 0, 0, errors.Wrap(err, "error encoding message key")
+		}
+	}
+	if msg.Value != nil {
+		if cm.Value, err = msg.Value.Encode(); err != nil {
+			return 0, 0, errors.Wrap(err, "error encoding message value")
+		}
+	}
+	for i := range msg.Headers {
+		header := msg.Headers[i]
+		cm.Headers = append(cm.Headers, &header)
+	}
+
+	b.topics[msg.Topic] = append(b.topics[msg.Topic], cm)
+	for _, pc := range b.consumers[msg.Topic] {
+		pc.deliver(cm)
+	}
+	return 0, cm.Offset, nil
+}
+
+// SendMessages sends every message in order, stopping at first error.
+func (b *Broker) SendMessages(msgs []*sarama.ProducerMessage) error {
+	for _, msg := range msgs {
+		if _, _, err := b.SendMessage(msg); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Topics returns the list of topics, created or which received at least one message.
+func (b *Broker) Topics() ([]string, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	var topics []string
+	for topic := range b.topics {
+		topics = append(topics, topic)
+	}
+	return topics, nil
+}
+
+// CreateTopic creates an empty topic. Topics are also created when a message is sent to them.
+func (b *Broker) CreateTopic(topic string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if _, ok := b.topics[topic]; !ok {
+		b.topics[topic] = nil
+	}
+}
+
+// Partitions returns the single partition of a topic, it fails if the topic does not exist.
+func (b *Broker) Partitions(topic string) ([]int32, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if _, ok := b.topics[topic]; !ok {
+		return nil, sarama.ErrUnknownTopicOrPartition
+	}
+	return []int32{0}, nil
+}
+
+// ConsumePartition creates a partition consumer starting at given offset.
+func (b *Broker) ConsumePartition(topic string, partition int32, offset int64) (sarama.PartitionConsumer, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.closed {
+		return nil, sarama.ErrClosedClient
+	}
+	log, ok := b.topics[topic]
+	if !ok || partition != 0 {
+		return nil, sarama.ErrUnknownTopicOrPartition
+	}
+
+	switch offset {
+	case sarama.OffsetNewest:
+		offset = int64(len(log))
+	case sarama.OffsetOldest:
+		offset = 0
+	}
+	if offset < 0 || offset > int64(len(log)) {
+		return nil, sarama.ErrOffsetOutOfRange
+	}
+
+	pc := newPartitionConsumer(b, topic)
+	for _, msg := range log[offset:] {
+		pc.deliver(msg)
+	}
+	b.consumers[topic] = append(b.consumers[topic], pc)
+	return pc, nil
+}
+
+// HighWaterMarks returns the next offset of every topic.
+func (b *Broker) HighWaterMarks() map[string]map[int32]int64 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	marks := make(map[string]map[int32]int64)
+	for topic, log := range b.topics {
+		marks[topic] = map[int32]int64{0: int64(len(log))}
+	}
+	return marks
+}
+
+// Close closes the broker. Subsequent sends fail.
+func (b *Broker) Close() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.closed = true
+	return nil
+}
+
+// Messages returns all messages stored in the topic.
+func (b *Broker) Messages(topic string) []*sarama.ConsumerMessage {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return append([]*sarama.ConsumerMessage(nil), b.topics[topic]...)
+}
+
+func (b *Broker) removeConsumer(pc *partitionConsumer) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	consumers := b.consumers[pc.topic]
+	for i, c := range consumers {
+		if c == pc {
+			b.consumers[pc.topic] = append(consumers[:i], consumers[i+1:]...)
+			break
+		}
+	}
+}
+
+type partitionConsumer struct {
+	broker   *Broker
+	topic    string
+	messages chan *sarama.ConsumerMessage
+	errors   chan *sarama.ConsumerError
+	dying    chan struct{}
+	once     sync.Once
+
+	mu      sync.Mutex
+	cond    *sync.Cond
+	pending []*sarama.ConsumerMessage
+	closed  bool
+}
+
+func newPartitionConsumer(b *Broker, topic string) *partitionConsumer {
+	pc := &partitionConsumer{
+		broker:   b,
+		topic:    topic,
+		messages: make(chan *sarama.ConsumerMessage),
+		errors:   make(chan *sarama.ConsumerError),
+		dying:    make(chan struct{}),
+	}
+	pc.cond = sync.NewCond(&pc.mu)
+	go pc.pump()
+	return pc
+}
+
+// deliver queues the message without blocking, so that handlers may publish from within a subscription.
+func (pc *partitionConsumer) deliver(msg *sarama.ConsumerMessage) {
+	pc.mu.Lock()
+	pc.pending = append(pc.pending, msg)
+	pc.mu.Unlock()
+	pc.cond.Signal()
+}
+
+func (pc *partitionConsumer) pump() {
+	defer close(pc.messages)
+	for {
+		pc.mu.Lock()
+		for len(pc.pending) == 0 && !pc.closed {
+			pc.cond.Wait()
+		}
+		if pc.closed {
+			pc.mu.Unlock()
+			return
+		}
+		msg := pc.pending[0]
+		pc.pending = pc.pending[1:]
+		pc.mu.Unlock()
+
+		select {
+		case pc.messages <- msg:
+		case <-pc.dying:
+			return
+		}
+	}
+}
+
+func (pc *partitionConsumer) AsyncClose() {
+	pc.Close()
+}
+
+func (pc *partitionConsumer) Close() error {
+	pc.once.Do(func() {
+		pc.broker.removeConsumer(pc)
+		pc.mu.Lock()
+		pc.closed = true
+		pc.mu.Unlock()
+		pc.cond.Broadcast()
+		close(pc.dying)
+		close(pc.errors)
+	})
+	return nil
+}
+
+func (pc *partitionConsumer) Messages() <-chan *sarama.ConsumerMessage {
+	return pc.messages
+}
+
+func (pc *partitionConsumer) Errors() <-chan *sarama.ConsumerError {
+	return pc.errors
+}
+
+func (pc *partitionConsumer) HighWaterMarkOffset() int64 {
+	return pc.broker.HighWaterMarks()[pc.topic][0]
+}
@@ -7,6 +7,7 @@ package functionmanager
 
 import (
 	"context"
+	"fmt"
 	"reflect"
 	"time"
 
@@ -26,12 +27,14 @@ import (
 type ControllerConfig struct {
 	ResyncPeriod   time.Duration
 	OrganizationID string
+	Elector        controller.LeaderElector
 }
 
 type funcEntityHandler struct {
 	FaaS      functions.FaaSDriver
 	Store     entitystore.EntityStore
 	ImgClient ImageManager
+	ImageGC   *ImageGC
 }
 
 // Type returns the reflect.Type of a functions.Function
@@ -46,10 +49,17 @@ func (h *funcEntityHandler) Add(obj entitystore.Entity) (err error) {
 	defer trace.Trace("")()
 
 	e := obj.(*functions.Function)
+	return h.deploy(e, e)
+}
+
+// deploy creates the FaaS function of a function entity (or of a function version entity, obj), the status of which
+// is updated
+func (h *funcEntityHandler) deploy(obj entitystore.Entity, e *functions.Function) (err error) {
+	defer trace.Trace("")()
 
 	defer func() {
-		log.Debugf("function org=%s, name=%s, id=%s, status=%s", e.OrganizationID, e.Name, e.ID, e.Status)
-		h.Store.UpdateWithError(e, err)
+		log.Debugf("function org=%s, name=%s, id=%s, status=%s", e.OrganizationID, e.Name, e.ID, obj.GetStatus())
+		h.Store.UpdateWithError(obj, err)
 	}()
 
 	img, err := h.getImage(e.ImageName)
@@ -67,26 +77,43 @@ func (h *funcEntityHandler) Add(obj entitystore.Entity) (err error) {
 		return
 	}
 
-	e.Status = entitystore.StatusCREATING
-	h.Store.UpdateWithError(e, nil)
+	obj.SetStatus(entitystore.StatusCREATING)
+	h.Store.UpdateWithError(obj, nil)
 
 	if err := h.FaaS.Create(e, &functions.Exec{
 		Code:     e.Code,
 		Main:     e.Main,
 		Image:    img.DockerURL,
 		Language: string(img.Language),
+
+		Environment: e.Environment,
+		Limits:      e.Limits,
+		Scaling:     e.Scaling,
 	}); err != nil {
 		return errors.Wrapf(err, "Driver error when creating a FaaS function")
 	}
 
-	e.Status = entitystore.StatusREADY
+	// the image just built is the one deployed, it is kept by the image garbage collection
+	imageID, errImage := h.ImageGC.DeployedImage(e.ID)
+	if errImage != nil {
+		log.Warnf("Error recording the image of function %s: %+v", e.ID, errImage)
+	}
+	switch o := obj.(type) {
+	case *functions.Function:
+		o.FunctionImageID = imageID
+	case *functions.FunctionVersion:
+		o.FunctionImageID = imageID
+	}
+
+	obj.SetStatus(entitystore.StatusREADY)
+	// the previous image of the function is superseded
+	h.ImageGC.Trigger()
 
 	return
 }
 
 // Update updates functions (and function images) for the configured FaaS
-// TODO: we are leaking images... the images should be deleted from the image
-// repository
+// The superseded images are removed by the image garbage collection
 func (h *funcEntityHandler) Update(obj entitystore.Entity) error {
 	defer trace.Trace("")()
 
@@ -94,8 +121,7 @@ func (h *funcEntityHandler) Update(obj entitystore.Entity) error {
 }
 
 // Delete deletes functions (and function images) for the configured FaaS
-// TODO: we are leaking images... the images should be deleted from the image
-// repository
+// The superseded images are removed by the image garbage collection
 func (h *funcEntityHandler) Delete(obj entitystore.Entity) error {
 	defer trace.Trace("")()
 
@@ -112,6 +138,7 @@ func (h *funcEntityHandler) Delete(obj entitystore.Entity) error {
 		return errors.Wrap(err, "store error when updating function")
 	}
 	log.Debugf("delete the entity successfully")
+	h.ImageGC.Trigger()
 
 	return nil
 }
@@ -154,6 +181,19 @@ func (h *funcEntityHandler) Sync(organizationID string, resyncPeriod time.Durati
 	return controller.DefaultSync(h.Store, h.Type(), organizationID, resyncPeriod, syncFilter(resyncPeriod))
 }
 
+// LeadershipChanged starts the periodic image garbage collection when this replica starts leading, and stops it when
+// it stops
+func (h *funcEntityHandler) LeadershipChanged(organizationID string, leading bool) error {
+	defer trace.Tracef("leading %t", leading)()
+
+	if leading {
+		h.ImageGC.Start()
+	} else {
+		h.ImageGC.Shutdown()
+	}
+	return nil
+}
+
 func (h *funcEntityHandler) getImage(imageName string) (*imagemodels.Image, error) {
 	defer trace.Trace("")()
 
@@ -169,10 +209,66 @@ func (h *funcEntityHandler) getImage(imageName string) (*imagemodels.Image, erro
 	return nil, errors.Wrapf(err, "failed to get image: '%s'", imageName)
 }
 
+type versionEntityHandler struct {
+	*funcEntityHandler
+}
+
+// Type returns the reflect.Type of a functions.FunctionVersion
+func (h *versionEntityHandler) Type() reflect.Type {
+	defer trace.Trace("")()
+
+	return reflect.TypeOf(&functions.FunctionVersion{})
+}
+
+// Add creates the FaaS function of a function version
+func (h *versionEntityHandler) Add(obj entitystore.Entity) error {
+	defer trace.Trace("")()
+
+	v := obj.(*functions.FunctionVersion)
+	return h.deploy(v, v.Function())
+}
+
+// Update creates the FaaS function of a function version, versions are immutable
+func (h *versionEntityHandler) Update(obj entitystore.Entity) error {
+	defer trace.Trace("")()
+
+	return h.Add(obj)
+}
+
+// Delete deletes the FaaS function of a function version
+func (h *versionEntityHandler) Delete(obj entitystore.Entity) error {
+	defer trace.Trace("")()
+
+	v := obj.(*functions.FunctionVersion)
+
+	if err := h.FaaS.Delete(v.Function()); err != nil {
+		return errors.Wrapf(err, "Driver error when deleting a FaaS function")
+	}
+	if err := h.Store.Delete(v.OrganizationID, v.Name, v); err != nil {
+		return errors.Wrap(err, "store error when deleting function version")
+	}
+	h.ImageGC.Trigger()
+	return nil
+}
+
+// Sync compares actual and desired state to return a list of function version entities which must be resolved
+func (h *versionEntityHandler) Sync(organizationID string, resyncPeriod time.Duration) ([]entitystore.Entity, error) {
+	defer trace.Trace("")()
+
+	return controller.DefaultSync(h.Store, h.Type(), organizationID, resyncPeriod, syncFilter(resyncPeriod))
+}
+
+// LeadershipChanged does nothing, the image garbage collection is handled by the function entity handler
+func (h *versionEntityHandler) LeadershipChanged(organizationID string, leading bool) error {
+	return nil
+}
+
 type runEntityHandler struct {
 	FaaS   functions.FaaSDriver
 	Runner functions.Runner
 	Store  entitystore.EntityStore
+	Queue  *RunQueue
+	Pruner *RunPruner
 }
 
 // Type returns the reflect.Type of a functions.FnRun
@@ -188,14 +284,34 @@ func (h *runEntityHandler) Add(obj entitystore.Entity) (err error) {
 
 	run := obj.(*functions.FnRun)
 	defer run.Done()
+	defer h.Queue.Done(run)
 
-	defer func() { h.Store.UpdateWithError(run, err) }()
+	runCtx, cancel := h.Queue.Context(run)
+	defer cancel()
+	if run.Timeout > 0 {
+		runCtx, cancel = context.WithTimeout(runCtx, time.Duration(run.Timeout)*time.Second)
+		defer cancel()
+	}
 
+	// Starting the run fails if the run was changed since it was queued, e.g. started by the leader resuming it. A
+	// blocking caller then waits for the run to finish in the store.
 	run.Status = entitystore.StatusCREATING
-	h.Store.UpdateWithError(run, nil)
+	if _, err := h.Store.Update(run.GetRevision(), run); err != nil {
+		log.Infof("skipping function run %s, started already: %v", run.Name, err)
+		return nil
+	}
+
+	defer func() { h.Store.UpdateWithError(run, err) }()
 
 	f := new(functions.Function)
-	if err = h.Store.Get(FunctionManagerFlags.OrgID, run.FunctionName, entitystore.Options{}, f); err != nil {
+	if run.FunctionVersion > 0 {
+		v := new(functions.FunctionVersion)
+		name := functions.VersionName(run.FunctionName, run.FunctionVersion)
+		if err = h.Store.Get(FunctionManagerFlags.OrgID, name, entitystore.Options{}, v); err != nil {
+			return errors.Wrapf(err, "Error getting function version from store: '%s'", name)
+		}
+		f = v.Function()
+	} else if err = h.Store.Get(FunctionManagerFlags.OrgID, run.FunctionName, entitystore.Options{}, f); err != nil {
 		return errors.Wrapf(err, "Error getting function from store: '%s'", run.FunctionName)
 	}
 
@@ -204,8 +320,14 @@ func (h *runEntityHandler) Add(obj entitystore.Entity) (err error) {
 	if run.Event != nil {
 		ctx[functions.EventKey] = run.Event
 	}
+	if run.HTTPRequest != nil {
+		ctx[functions.HTTPRequestKey] = run.HTTPRequest
+	}
 
-	output, err := h.Runner.Run(&functions.FunctionExecution{
+	// the run may be cancelled by another replica, through the store
+	stop := make(chan struct{})
+	cancelled := h.pollCancel(run.Name, stop)
+	output, err := h.Runner.Run(runCtx, &functions.FunctionExecution{
 		Context:    ctx,
 		RunID:      run.ID,
 		FunctionID: run.FunctionID,
@@ -216,9 +338,25 @@ func (h *runEntityHandler) Add(obj entitystore.Entity) (err error) {
 		Cookie:  "cookie",
 		Secrets: run.Secrets,
 	}, run.Input)
+	close(stop)
+	if revision := <-cancelled; revision != 0 {
+		// the run is updated over the recorded cancellation
+		run.Revision = revision
+		run.Cancelled = true
+	}
 	run.Logs = ctx.Logs()
 	run.Output = output
 	if err != nil {
+		switch runCtx.Err() {
+		case context.DeadlineExceeded:
+			run.Status = entitystore.StatusTIMEDOUT
+			run.Reason = []string{fmt.Sprintf("function run timed out after %d seconds", run.Timeout)}
+			run.FinishedTime = time.Now()
+			return nil
+		case context.Canceled:
+			run.FinishedTime = time.Now()
+			return errRunCancelled
+		}
 		return errors.Wrapf(err, "error running function: %s", run.FunctionName)
 	}
 
@@ -228,6 +366,38 @@ func (h *runEntityHandler) Add(obj entitystore.Entity) (err error) {
 	return
 }
 
+// runCancelPollPeriod is the period at which the running runs are checked for a cancellation by another replica
+var runCancelPollPeriod = time.Second
+
+// pollCancel cancels a running run once its cancellation is recorded in the store, until stop is closed. The returned
+// channel receives the revision of the cancelled run, or 0.
+func (h *runEntityHandler) pollCancel(name string, stop <-chan struct{}) <-chan uint64 {
+	cancelled := make(chan uint64, 1)
+	go func() {
+		ticker := time.NewTicker(runCancelPollPeriod)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stop:
+				cancelled <- 0
+				return
+			case <-ticker.C:
+			}
+			stored := new(functions.FnRun)
+			if err := h.Store.Get(FunctionManagerFlags.OrgID, name, entitystore.Options{}, stored); err != nil {
+				log.Debugf("Error checking function run %s for cancellation: %v", name, err)
+				continue
+			}
+			if stored.Cancelled {
+				h.Queue.Cancel(name)
+				cancelled <- stored.Revision
+				return
+			}
+		}
+	}()
+	return cancelled
+}
+
 // Update updates a function execution (run)
 func (h *runEntityHandler) Update(obj entitystore.Entity) (err error) {
 	defer trace.Trace("")()
@@ -237,20 +407,46 @@ func (h *runEntityHandler) Update(obj entitystore.Entity) (err error) {
 	return errors.Errorf("updating runs not supported, fn: '%s'", run.FunctionName)
 }
 
-// Delete deletes a function execution (run)
+// Delete deletes a function execution (run) record, runs in progress are cancelled instead
 func (h *runEntityHandler) Delete(obj entitystore.Entity) (err error) {
 	defer trace.Trace("")()
 
 	run := obj.(*functions.FnRun)
-	defer func() { h.Store.UpdateWithError(run, err) }()
-	return errors.Errorf("deleting runs not supported, fn: '%s'", run.FunctionName)
+	if run.Status == entitystore.StatusINITIALIZED || run.Status == entitystore.StatusCREATING {
+		defer func() { h.Store.UpdateWithError(run, err) }()
+		return errors.Errorf("deleting runs in progress not supported, fn: '%s'", run.FunctionName)
+	}
+	if err := h.Store.Delete(run.OrganizationID, run.Name, run); err != nil {
+		return errors.Wrap(err, "store error when deleting function run")
+	}
+	return nil
 }
 
-// Sync compares actual and desired state to return a list of function execution (run) entities which must be resolved
+// Sync compares actual and desired state to return a list of function execution (run) entities which must be resolved.
+// Runs waiting for execution are handed over by the run queue, so there is nothing to resolve.
 func (h *runEntityHandler) Sync(organizationID string, resyncPeriod time.Duration) ([]entitystore.Entity, error) {
 	defer trace.Trace("")()
 
-	return controller.DefaultSync(h.Store, h.Type(), organizationID, resyncPeriod, syncFilter(resyncPeriod))
+	return nil, nil
+}
+
+// WatchFilter disables the store watch for runs, which are handed over by the run queue to limit their concurrency
+func (h *runEntityHandler) WatchFilter() entitystore.Filter {
+	return nil
+}
+
+// LeadershipChanged resumes the queued runs and starts the periodic run pruning when this replica starts leading, and
+// suspends the queued runs and stops the pruning when it stops. Runs pushed on any replica are executed by it.
+func (h *runEntityHandler) LeadershipChanged(organizationID string, leading bool) error {
+	defer trace.Tracef("leading %t", leading)()
+
+	if !leading {
+		h.Queue.Suspend()
+		h.Pruner.Shutdown()
+		return nil
+	}
+	h.Pruner.Start()
+	return h.Queue.Resume(organizationID)
 }
 
 // Error handles errors with regards to function execution entities (currently a no-op)
@@ -261,18 +457,35 @@ func (h *runEntityHandler) Error(obj entitystore.Entity) error {
 	return nil
 }
 
-// NewController is the contstructor for the function manager controller
-func NewController(config *ControllerConfig, store entitystore.EntityStore, faas functions.FaaSDriver, runner functions.Runner, imgClient ImageManager) controller.Controller {
+// defaultRunWorkers is the number of entities processed at the same time, if the number of runs is not limited
+const defaultRunWorkers = 1000
+
+// functionWorkers is the number of function entities processed at the same time, in addition to runs
+const functionWorkers = 10
+
+// NewController is the contstructor for the function manager controller. Runs are handed over to the controller by
+// the run queue, which limits their concurrency. With a leader election, the queued runs are resumed, and the images
+// and runs are collected, by the leader only.
+func NewController(config *ControllerConfig, store entitystore.EntityStore, faas functions.FaaSDriver, runner functions.Runner, imgClient ImageManager, queue *RunQueue, imageGC *ImageGC, runPruner *RunPruner) controller.Controller {
 
 	defer trace.Trace("")()
 
+	workers := functionWorkers + queue.config.MaxRuns
+	if queue.config.MaxRuns <= 0 {
+		workers = defaultRunWorkers
+	}
 	c := controller.NewController(controller.Options{
 		OrganizationID: FunctionManagerFlags.OrgID,
 		ResyncPeriod:   config.ResyncPeriod,
-		Workers:        1000, // want more functions concurrently? add more workers // TODO configure workers
+		Workers:        workers,
+		Store:          store,
+		Elector:        config.Elector,
 	})
-	c.AddEntityHandler(&funcEntityHandler{Store: store, FaaS: faas, ImgClient: imgClient})
-	c.AddEntityHandler(&runEntityHandler{Store: store, FaaS: faas, Runner: runner})
+	queue.watcher = c.Watcher()
+	funcHandler := &funcEntityHandler{Store: store, FaaS: faas, ImgClient: imgClient, ImageGC: imageGC}
+	c.AddEntityHandler(funcHandler)
+	c.AddEntityHandler(&versionEntityHandler{funcHandler})
+	c.AddEntityHandler(&runEntityHandler{Store: store, FaaS: faas, Runner: runner, Queue: queue, Pruner: runPruner})
 
 	return c
 }
@@ -6,7 +6,9 @@
 package functionmanager
 
 import (
+	"context"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
@@ -86,8 +88,9 @@ func TestFuncEntityHandler_Add_ImageReady(t *testing.T) {
 	}
 	faas.On("Create", function, exec).Return(nil)
 
+	store := helpers.MakeEntityStore(t)
 	h := &funcEntityHandler{
-		Store:     helpers.MakeEntityStore(t),
+		Store:     store,
 		FaaS:      faas,
 		ImgClient: imgMgr,
 	}
@@ -95,8 +98,22 @@ func TestFuncEntityHandler_Add_ImageReady(t *testing.T) {
 	_, err := h.Store.Add(function)
 	require.NoError(t, err)
 
+	collector := &fnmocks.ImageCollector{}
+	now := time.Now()
+	collector.On("ListImages").Return([]*functions.FunctionImage{
+		{ID: "previous", FunctionID: function.ID, Created: now.Add(-time.Hour)},
+		{ID: "built", FunctionID: function.ID, Created: now},
+		{ID: "other", FunctionID: "otherFunction", Created: now.Add(time.Hour)},
+	}, nil)
+	h.ImageGC = NewImageGC(&ImageGCConfig{}, store, collector)
+
 	require.NoError(t, h.Add(function))
 
+	stored := new(functions.Function)
+	require.NoError(t, store.Get(function.OrganizationID, function.Name, entitystore.Options{}, stored))
+	assert.Equal(t, entitystore.StatusREADY, stored.Status)
+	assert.Equal(t, "built", stored.FunctionImageID)
+
 	faas.AssertExpectations(t)
 	imgMgr.AssertExpectations(t)
 }
@@ -127,6 +144,59 @@ func TestFuncEntityHandler_Delete(t *testing.T) {
 	faas.AssertExpectations(t)
 }
 
+func TestVersionEntityHandler_AddDelete(t *testing.T) {
+	imgMgr := &mocks.ImageManager{}
+	imgMgr.On("GetImageByName", mock.Anything, mock.Anything).Return(
+		&image.GetImageByNameOK{
+			Payload: &imagemodels.Image{
+				DockerURL: "test/image:latest",
+				Language:  imagemodels.LanguagePython3,
+				Status:    imagemodels.StatusREADY,
+			},
+		}, nil)
+	faas := &fnmocks.FaaSDriver{}
+	version := &functions.FunctionVersion{
+		BaseEntity: entitystore.BaseEntity{
+			Name:   functions.VersionName("testFunction", 1),
+			Status: entitystore.StatusINITIALIZED,
+		},
+		FunctionName: "testFunction",
+		Number:       1,
+		ImageName:    "testImage",
+		Code:         "some code",
+		Main:         "main",
+	}
+	exec := &functions.Exec{
+		Code: "some code", Main: "main", Image: "test/image:latest", Language: "python3",
+	}
+	faas.On("Create", mock.AnythingOfType("*functions.Function"), exec).Return(nil)
+	faas.On("Delete", mock.AnythingOfType("*functions.Function")).Return(nil)
+
+	h := &versionEntityHandler{&funcEntityHandler{
+		Store:     helpers.MakeEntityStore(t),
+		FaaS:      faas,
+		ImgClient: imgMgr,
+	}}
+
+	_, err := h.Store.Add(version)
+	require.NoError(t, err)
+
+	require.NoError(t, h.Add(version))
+	created := faas.Calls[0].Arguments.Get(0).(*functions.Function)
+	assert.Equal(t, "testFunction", created.Name)
+	assert.Equal(t, version.ID, created.ID)
+
+	stored := new(functions.FunctionVersion)
+	require.NoError(t, h.Store.Get(version.OrganizationID, version.Name, entitystore.Options{}, stored))
+	assert.Equal(t, entitystore.StatusREADY, stored.Status)
+
+	require.NoError(t, h.Delete(stored))
+	assert.Error(t, h.Store.Get(version.OrganizationID, version.Name, entitystore.Options{}, stored))
+
+	faas.AssertExpectations(t)
+	imgMgr.AssertExpectations(t)
+}
+
 func TestRunEntityHandler_Add(t *testing.T) {
 	faas := &fnmocks.FaaSDriver{}
 	function := &functions.Function{
@@ -147,7 +217,7 @@ func TestRunEntityHandler_Add(t *testing.T) {
 	}
 
 	functionCalled := false
-	var runnable functions.Runnable = func(ctx functions.Context, in interface{}) (interface{}, error) {
+	var runnable functions.Runnable = func(ctx context.Context, fctx functions.Context, in interface{}) (interface{}, error) {
 		functionCalled = true
 		return nil, nil
 	}
@@ -158,9 +228,11 @@ func TestRunEntityHandler_Add(t *testing.T) {
 		return f
 	}
 	secretInjector.On("GetMiddleware", mock.Anything, "cookie").Return(simw)
+	store := helpers.MakeEntityStore(t)
 	h := &runEntityHandler{
-		Store: helpers.MakeEntityStore(t),
+		Store: store,
 		FaaS:  faas,
+		Queue: NewRunQueue(&RunQueueConfig{}, store),
 		Runner: runner.New(&runner.Config{
 			Faas:           faas,
 			Validator:      validator.NoOp(),
@@ -179,3 +251,154 @@ func TestRunEntityHandler_Add(t *testing.T) {
 	secretInjector.AssertExpectations(t)
 	assert.True(t, functionCalled)
 }
+
+func TestRunEntityHandler_AddCancelledInStore(t *testing.T) {
+	defer func(period time.Duration) { runCancelPollPeriod = period }(runCancelPollPeriod)
+	runCancelPollPeriod = 10 * time.Millisecond
+
+	h := makeBlockingRunHandler(t)
+	watcher := make(chan entitystore.Entity, 1)
+	h.Queue.watcher = watcher
+	fnRun := &functions.FnRun{
+		BaseEntity: entitystore.BaseEntity{
+			Name:   "testRun",
+			Status: entitystore.StatusINITIALIZED,
+		},
+		FunctionName: "testFunction",
+	}
+	_, err := h.Store.Add(fnRun)
+	require.NoError(t, err)
+	require.NoError(t, h.Queue.Push(fnRun))
+	<-watcher
+
+	// the run is cancelled by another replica once running
+	go func() {
+		time.Sleep(100 * time.Millisecond)
+		var stored functions.FnRun
+		require.NoError(t, h.Store.Get("", "testRun", entitystore.Options{}, &stored))
+		stored.Cancelled = true
+		_, err := h.Store.Update(stored.Revision, &stored)
+		assert.NoError(t, err)
+	}()
+	assert.Error(t, h.Add(fnRun))
+
+	var stored functions.FnRun
+	require.NoError(t, h.Store.Get("", "testRun", entitystore.Options{}, &stored))
+	assert.Equal(t, entitystore.StatusERROR, stored.Status)
+	assert.Equal(t, entitystore.Reason{errRunCancelled.Error()}, stored.Reason)
+	assert.True(t, stored.Cancelled)
+}
+
+func TestRunEntityHandler_AddStartedAlready(t *testing.T) {
+	h := makeBlockingRunHandler(t)
+	fnRun := &functions.FnRun{
+		BaseEntity: entitystore.BaseEntity{
+			Name:   "testRun",
+			Status: entitystore.StatusINITIALIZED,
+		},
+		FunctionName: "testFunction",
+	}
+	_, err := h.Store.Add(fnRun)
+	require.NoError(t, err)
+
+	// the run was started by another replica meanwhile
+	started := *fnRun
+	started.Status = entitystore.StatusCREATING
+	_, err = h.Store.Update(started.Revision, &started)
+	require.NoError(t, err)
+
+	assert.NoError(t, h.Add(fnRun))
+
+	var stored functions.FnRun
+	require.NoError(t, h.Store.Get("", "testRun", entitystore.Options{}, &stored))
+	assert.Equal(t, entitystore.StatusCREATING, stored.Status)
+	assert.Empty(t, stored.Reason)
+}
+
+func makeBlockingRunHandler(t *testing.T) *runEntityHandler {
+	faas := &fnmocks.FaaSDriver{}
+	var runnable functions.Runnable = func(ctx context.Context, fctx functions.Context, in interface{}) (interface{}, error) {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}
+	faas.On("GetRunnable", mock.Anything).Return(runnable)
+
+	secretInjector := &fnmocks.SecretInjector{}
+	var simw functions.Middleware = func(f functions.Runnable) functions.Runnable {
+		return f
+	}
+	secretInjector.On("GetMiddleware", mock.Anything, "cookie").Return(simw)
+
+	store := helpers.MakeEntityStore(t)
+	_, err := store.Add(&functions.Function{
+		BaseEntity: entitystore.BaseEntity{
+			Name:   "testFunction",
+			Status: entitystore.StatusREADY,
+		},
+		Schema: &functions.Schema{},
+	})
+	require.NoError(t, err)
+	return &runEntityHandler{
+		Store: store,
+		FaaS:  faas,
+		Queue: NewRunQueue(&RunQueueConfig{}, store),
+		Runner: runner.New(&runner.Config{
+			Faas:           faas,
+			Validator:      validator.NoOp(),
+			SecretInjector: secretInjector,
+		}),
+	}
+}
+
+func TestRunEntityHandler_AddTimeout(t *testing.T) {
+	h := makeBlockingRunHandler(t)
+	fnRun := &functions.FnRun{
+		BaseEntity: entitystore.BaseEntity{
+			Name: "testRun",
+		},
+		FunctionName: "testFunction",
+		Timeout:      1,
+	}
+	_, err := h.Store.Add(fnRun)
+	require.NoError(t, err)
+
+	assert.NoError(t, h.Add(fnRun))
+
+	var stored functions.FnRun
+	require.NoError(t, h.Store.Get("", "testRun", entitystore.Options{}, &stored))
+	assert.Equal(t, entitystore.StatusTIMEDOUT, stored.Status)
+	assert.Equal(t, entitystore.Reason{"function run timed out after 1 seconds"}, stored.Reason)
+}
+
+func TestRunEntityHandler_AddCancel(t *testing.T) {
+	h := makeBlockingRunHandler(t)
+	watcher := make(chan entitystore.Entity, 1)
+	h.Queue.watcher = watcher
+	fnRun := &functions.FnRun{
+		BaseEntity: entitystore.BaseEntity{
+			Name: "testRun",
+		},
+		FunctionName: "testFunction",
+	}
+	_, err := h.Store.Add(fnRun)
+	require.NoError(t, err)
+	require.NoError(t, h.Queue.Push(fnRun))
+	<-watcher
+
+	go func() {
+		time.Sleep(100 * time.Millisecond)
+		queued, found := h.Queue.Cancel("testRun")
+		assert.Nil(t, queued)
+		assert.True(t, found)
+	}()
+	assert.Error(t, h.Add(fnRun))
+
+	var stored functions.FnRun
+	require.NoError(t, h.Store.Get("", "testRun", entitystore.Options{}, &stored))
+	assert.Equal(t, entitystore.StatusERROR, stored.Status)
+	assert.Equal(t, entitystore.Reason{errRunCancelled.Error()}, stored.Reason)
+
+	// the run is not in progress anymore
+	_, found := h.Queue.Cancel("testRun")
+	assert.False(t, found)
+}
@@ -6,10 +6,15 @@
 package functionmanager
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
+	"math/rand"
 	"net/http"
+	"sort"
+	"strings"
+	"time"
 
 	"github.com/go-openapi/runtime"
 	httptransport "github.com/go-openapi/runtime/client"
@@ -20,6 +25,7 @@ import (
 	"github.com/pkg/errors"
 	"github.com/satori/go.uuid"
 	log "github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/api/resource"
 
 	"github.com/vmware/dispatch/pkg/controller"
 	"github.com/vmware/dispatch/pkg/entity-store"
@@ -40,17 +46,28 @@ import (
 
 // FunctionManagerFlags are configuration flags for the function manager
 var FunctionManagerFlags = struct {
-	Config           string `long:"config" description:"Path to Config file" default:"./config.dev.json"`
-	DbFile           string `long:"db-file" description:"Backend DB URL/Path" default:"./db.bolt"`
-	DbBackend        string `long:"db-backend" description:"Backend DB Name" default:"boltdb"`
-	DbUser           string `long:"db-username" description:"Backend DB Username" default:"dispatch"`
-	DbPassword       string `long:"db-password" description:"Backend DB Password" default:"dispatch"`
-	DbDatabase       string `long:"db-database" description:"Backend DB Name" default:"dispatch"`
-	OrgID            string `long:"organization" description:"(temporary) Static organization id" default:"dispatch"`
-	ImageManager     string `long:"image-manager" description:"Image manager endpoint" default:"localhost:8002"`
-	SecretStore      string `long:"secret-store" description:"Secret store endpoint" default:"localhost:8003"`
-	K8sConfig        string `long:"kubeconfig" description:"Path to kubernetes config file" default:""`
-	FileImageManager string `long:"file-image-manager" description:"Path to file containing images (useful for testing)"`
+	Config             string   `long:"config" description:"Path to Config file" default:"./config.dev.json"`
+	DbFile             string   `long:"db-file" description:"Backend DB URL/Path (comma separated endpoints for etcd and consul)" default:"./db.bolt"`
+	DbBackend          string   `long:"db-backend" description:"Backend DB Name (boltdb, postgres, etcd or consul)" default:"boltdb"`
+	DbUser             string   `long:"db-username" description:"Backend DB Username" default:"dispatch"`
+	DbPassword         string   `long:"db-password" description:"Backend DB Password" default:"dispatch"`
+	DbDatabase         string   `long:"db-database" description:"Backend DB Name" default:"dispatch"`
+	OrgID              string   `long:"organization" description:"(temporary) Static organization id" default:"dispatch"`
+	ImageManager       string   `long:"image-manager" description:"Image manager endpoint" default:"localhost:8002"`
+	SecretStore        string   `long:"secret-store" description:"Secret store endpoint" default:"localhost:8003"`
+	K8sConfig          string   `long:"kubeconfig" description:"Path to kubernetes config file" default:""`
+	FileImageManager   string   `long:"file-image-manager" description:"Path to file containing images (useful for testing)"`
+	MaxRuns            int      `long:"max-runs" description:"Maximum number of function runs executed at the same time (0 for no limit)" default:"100"`
+	MaxRunsPerFunction int      `long:"max-runs-per-function" description:"Maximum number of runs of a single function executed at the same time (0 for no limit)" default:"0"`
+	RunQueueSize       int      `long:"run-queue-size" description:"Maximum number of function runs waiting for execution (0 for no limit)" default:"1000"`
+	ImageGCKeep        int      `long:"image-gc-keep" description:"Number of superseded images kept per function" default:"2"`
+	ImageGCMaxAge      int      `long:"image-gc-max-age" description:"Hours after which superseded images are collected regardless of image-gc-keep (0 for no limit)" default:"0"`
+	ImageGCPeriod      int      `long:"image-gc-period" description:"Minutes between periodic image garbage collections (0 to only collect on function changes)" default:"60"`
+	RunRetentionMaxAge int      `long:"run-retention-max-age" description:"Hours after which finished function runs are pruned (0 for no limit)" default:"0"`
+	RunRetentionKeep   int      `long:"run-retention-keep" description:"Number of most recent finished runs kept per function (0 for no limit)" default:"0"`
+	RunRetentionStatus []string `long:"run-retention-status" description:"Status of the finished runs to prune, can be repeated (all finished runs by default)"`
+	RunRetentionPeriod int      `long:"run-retention-period" description:"Minutes between periodic run prunings (0 to disable)" default:"60"`
+	RunArchiveFile     string   `long:"run-archive-file" description:"Path to a file the pruned runs are appended to, as JSON lines"`
 }{}
 
 func functionEntityToModel(f *functions.Function) *models.Function {
@@ -66,16 +83,97 @@ func functionEntityToModel(f *functions.Function) *models.Function {
 		ID:          strfmt.UUID(f.ID),
 		Image:       swag.String(f.ImageName),
 		Code:        swag.String(f.Code),
+		Main:        swag.String(f.Main),
 		Schema: &models.Schema{
 			In:  f.Schema.In,
 			Out: f.Schema.Out,
 		},
-		Secrets: f.Secrets,
-		Tags:    tags,
-		Status:  models.Status(f.Status),
+		Secrets:       f.Secrets,
+		Timeout:       swag.Int64(f.Timeout),
+		Environment:   f.Environment,
+		Limits:        limitsEntityToModel(f.Limits),
+		Scaling:       scalingEntityToModel(f.Scaling),
+		LatestVersion: f.LatestVersion,
+		Aliases:       aliasesEntityToModel(f.Aliases),
+		Tags:          tags,
+		Status:        models.Status(f.Status),
 	}
 }
 
+func routesEntityToModel(routes []functions.Route) []*models.Route {
+	var result []*models.Route
+	for _, r := range routes {
+		result = append(result, &models.Route{Version: swag.Int64(r.Version), Weight: swag.Int64(r.Weight)})
+	}
+	return result
+}
+
+func aliasesEntityToModel(aliases map[string]*functions.Alias) []*models.Alias {
+	var names []string
+	for name := range aliases {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var result []*models.Alias
+	for _, name := range names {
+		result = append(result, &models.Alias{
+			Name:           name,
+			Routes:         routesEntityToModel(aliases[name].Routes),
+			PreviousRoutes: routesEntityToModel(aliases[name].PreviousRoutes),
+		})
+	}
+	return result
+}
+
+// aliasModelToEntity validates the routes of an alias, the versions must be published and the total weight positive
+func aliasModelToEntity(m *models.Alias, f *functions.Function) (*functions.Alias, error) {
+	a := &functions.Alias{}
+	seen := make(map[int64]bool)
+	for _, r := range m.Routes {
+		if r == nil || r.Version == nil || r.Weight == nil {
+			return nil, errors.New("routes must have a version and a weight")
+		}
+		if *r.Version < 1 || *r.Version > f.LatestVersion {
+			return nil, errors.Errorf("version %d of function %s is not published", *r.Version, f.Name)
+		}
+		if *r.Weight < 0 {
+			return nil, errors.Errorf("invalid weight %d of version %d", *r.Weight, *r.Version)
+		}
+		if seen[*r.Version] {
+			return nil, errors.Errorf("duplicate route to version %d", *r.Version)
+		}
+		seen[*r.Version] = true
+		a.Routes = append(a.Routes, functions.Route{Version: *r.Version, Weight: *r.Weight})
+	}
+	if a.TotalWeight() <= 0 {
+		return nil, errors.New("the total weight of the routes must be positive")
+	}
+	return a, nil
+}
+
+func versionEntityToModel(v *functions.FunctionVersion) *models.FunctionVersion {
+	defer trace.Trace("versionEntityToModel")()
+	m := &models.FunctionVersion{
+		ID:           strfmt.UUID(v.ID),
+		FunctionName: v.FunctionName,
+		Version:      v.Number,
+		Image:        v.ImageName,
+		Main:         v.Main,
+		Secrets:      v.Secrets,
+		Timeout:      v.Timeout,
+		CreatedTime:  v.CreatedTime.Unix(),
+		Status:       models.Status(v.Status),
+		Reason:       v.Reason,
+	}
+	if v.Schema != nil {
+		m.Schema = &models.Schema{
+			In:  v.Schema.In,
+			Out: v.Schema.Out,
+		}
+	}
+	return m
+}
+
 func functionListToModel(funcs []*functions.Function) []*models.Function {
 	defer trace.Trace("functionListToModel")()
 	body := make([]*models.Function, 0, len(funcs))
@@ -128,9 +226,64 @@ func functionModelOntoEntity(m *models.Function, e *functions.Function) error {
 	}
 	e.Schema = schema
 	e.Secrets = m.Secrets
+	if m.Timeout != nil {
+		e.Timeout = *m.Timeout
+	}
+	e.Environment = m.Environment
+	limits, err := limitsModelToEntity(m.Limits)
+	if err != nil {
+		return err
+	}
+	e.Limits = limits
+	e.Scaling = scalingModelToEntity(m.Scaling)
 	return nil
 }
 
+// limitsModelToEntity validates the resource limits, which are Kubernetes quantities
+func limitsModelToEntity(m *models.FunctionLimits) (*functions.Limits, error) {
+	if m == nil || (m.Memory == "" && m.CPU == "") {
+		return nil, nil
+	}
+	for name, value := range map[string]string{"memory": m.Memory, "cpu": m.CPU} {
+		if value == "" {
+			continue
+		}
+		if _, err := resource.ParseQuantity(value); err != nil {
+			return nil, errors.Errorf("invalid %s limit %s", name, value)
+		}
+	}
+	return &functions.Limits{Memory: m.Memory, CPU: m.CPU}, nil
+}
+
+func scalingModelToEntity(m *models.FunctionScaling) *functions.Scaling {
+	if m == nil {
+		return nil
+	}
+	return &functions.Scaling{
+		MinReplicas: swag.Int64Value(m.MinReplicas),
+		MaxReplicas: swag.Int64Value(m.MaxReplicas),
+		Concurrency: swag.Int64Value(m.Concurrency),
+	}
+}
+
+func limitsEntityToModel(l *functions.Limits) *models.FunctionLimits {
+	if l == nil {
+		return nil
+	}
+	return &models.FunctionLimits{Memory: l.Memory, CPU: l.CPU}
+}
+
+func scalingEntityToModel(s *functions.Scaling) *models.FunctionScaling {
+	if s == nil {
+		return nil
+	}
+	return &models.FunctionScaling{
+		MinReplicas: swag.Int64(s.MinReplicas),
+		MaxReplicas: swag.Int64(s.MaxReplicas),
+		Concurrency: swag.Int64(s.Concurrency),
+	}
+}
+
 func runModelToEntity(m *models.Run, f *functions.Function) *functions.FnRun {
 	defer trace.Trace("runModelToEntity")()
 	secrets := f.Secrets
@@ -147,6 +300,10 @@ func runModelToEntity(m *models.Run, f *functions.Function) *functions.FnRun {
 	if m.Blocking {
 		waitChan = make(chan struct{})
 	}
+	timeout := f.Timeout
+	if m.Timeout != nil {
+		timeout = *m.Timeout
+	}
 	return &functions.FnRun{
 		BaseEntity: entitystore.BaseEntity{
 			OrganizationID: FunctionManagerFlags.OrgID,
@@ -156,11 +313,13 @@ func runModelToEntity(m *models.Run, f *functions.Function) *functions.FnRun {
 			Tags:           tags,
 		},
 		Blocking:     m.Blocking,
+		Timeout:      timeout,
 		Input:        m.Input,
 		Secrets:      secrets,
 		FunctionName: f.Name,
 		FunctionID:   f.ID,
 		Event:        helpers.CloudEventFromSwagger((*eventmodels.CloudEvent)(m.Event)),
+		HTTPRequest:  (*functions.HTTPRequest)(m.HTTPRequest),
 		WaitChan:     waitChan,
 	}
 }
@@ -172,20 +331,23 @@ func runEntityToModel(f *functions.FnRun) *models.Run {
 		tags = append(tags, &models.Tag{Key: k, Value: v})
 	}
 	return &models.Run{
-		ExecutedTime: f.CreatedTime.Unix(),
-		FinishedTime: f.FinishedTime.Unix(),
-		Name:         strfmt.UUID(f.Name),
-		Blocking:     f.Blocking,
-		Input:        f.Input,
-		Output:       f.Output,
-		Logs:         f.Logs,
-		Secrets:      f.Secrets,
-		FunctionName: f.FunctionName,
-		FunctionID:   f.FunctionID,
-		Status:       models.Status(f.Status),
-		Event:        (*models.CloudEvent)(helpers.CloudEventToSwagger(f.Event)),
-		Reason:       f.Reason,
-		Tags:         tags,
+		ExecutedTime:    f.CreatedTime.Unix(),
+		FinishedTime:    f.FinishedTime.Unix(),
+		Name:            strfmt.UUID(f.Name),
+		Blocking:        f.Blocking,
+		Timeout:         swag.Int64(f.Timeout),
+		Input:           f.Input,
+		Output:          f.Output,
+		Logs:            f.Logs,
+		Secrets:         f.Secrets,
+		FunctionName:    f.FunctionName,
+		FunctionID:      f.FunctionID,
+		FunctionVersion: f.FunctionVersion,
+		Status:          models.Status(f.Status),
+		Event:           (*models.CloudEvent)(helpers.CloudEventToSwagger(f.Event)),
+		HTTPRequest:     (*models.HTTPRequest)(f.HTTPRequest),
+		Reason:          f.Reason,
+		Tags:            tags,
 	}
 }
 
@@ -200,16 +362,22 @@ func runListToModel(runs []*functions.FnRun) []*models.Run {
 
 // Handlers is the API handler for function manager
 type Handlers struct {
-	Watcher controller.Watcher
+	Watcher   controller.Watcher
+	Queue     *RunQueue
+	ImageGC   *ImageGC
+	RunPruner *RunPruner
 
 	Store entitystore.EntityStore
 }
 
 // NewHandlers is the contstructor for the function manager API handlers
-func NewHandlers(watcher controller.Watcher, store entitystore.EntityStore) *Handlers {
+func NewHandlers(watcher controller.Watcher, store entitystore.EntityStore, queue *RunQueue, imageGC *ImageGC, runPruner *RunPruner) *Handlers {
 	return &Handlers{
-		Watcher: watcher,
-		Store:   store,
+		Watcher:   watcher,
+		Queue:     queue,
+		ImageGC:   imageGC,
+		RunPruner: runPruner,
+		Store:     store,
 	}
 }
 
@@ -283,9 +451,16 @@ func (h *Handlers) ConfigureHandlers(api middleware.RoutableAPI) {
 	a.StoreDeleteFunctionHandler = fnstore.DeleteFunctionHandlerFunc(h.deleteFunction)
 	a.StoreGetFunctionsHandler = fnstore.GetFunctionsHandlerFunc(h.getFunctions)
 	a.StoreUpdateFunctionHandler = fnstore.UpdateFunctionHandlerFunc(h.updateFunction)
+	a.StorePublishVersionHandler = fnstore.PublishVersionHandlerFunc(h.publishVersion)
+	a.StoreGetVersionsHandler = fnstore.GetVersionsHandlerFunc(h.getVersions)
+	a.StoreSetAliasHandler = fnstore.SetAliasHandlerFunc(h.setAlias)
+	a.StoreDeleteAliasHandler = fnstore.DeleteAliasHandlerFunc(h.deleteAlias)
+	a.StoreCollectImagesHandler = fnstore.CollectImagesHandlerFunc(h.collectImages)
 	a.RunnerRunFunctionHandler = fnrunner.RunFunctionHandlerFunc(h.runFunction)
 	a.RunnerGetRunHandler = fnrunner.GetRunHandlerFunc(h.getRun)
 	a.RunnerGetRunsHandler = fnrunner.GetRunsHandlerFunc(h.getRuns)
+	a.RunnerCancelRunHandler = fnrunner.CancelRunHandlerFunc(h.cancelRun)
+	a.RunnerDeleteRunsHandler = fnrunner.DeleteRunsHandlerFunc(h.deleteRuns)
 }
 
 func (h *Handlers) addFunction(params fnstore.AddFunctionParams, principal interface{}) middleware.Responder {
@@ -388,6 +563,20 @@ func (h *Handlers) deleteFunction(params fnstore.DeleteFunctionParams, principal
 		})
 	}
 	h.Watcher.OnAction(e)
+
+	versions, _, err := h.listVersions(e.Name, entitystore.Options{})
+	if err != nil {
+		log.Errorf("Store error when listing versions of function %s: %+v", e.Name, err)
+	}
+	for _, v := range versions {
+		v.Status = entitystore.StatusDELETING
+		if _, err := h.Store.Update(v.Revision, v); err != nil {
+			log.Errorf("Store error when deleting function version %s: %+v", v.Name, err)
+			continue
+		}
+		h.Watcher.OnAction(v)
+	}
+
 	m := functionEntityToModel(e)
 	return fnstore.NewDeleteFunctionOK().WithPayload(m)
 }
@@ -400,6 +589,9 @@ func (h *Handlers) getFunctions(params fnstore.GetFunctionsParams, principal int
 		Filter: entitystore.FilterEverything(),
 	}
 	opts.Filter, err = utils.ParseTags(opts.Filter, params.Tags)
+	if err == nil {
+		err = utils.ParsePage(&opts, params.Limit, params.Continue, params.SortBy)
+	}
 	if err != nil {
 		log.Errorf(err.Error())
 		return fnstore.NewGetFunctionsBadRequest().WithPayload(
@@ -410,7 +602,7 @@ func (h *Handlers) getFunctions(params fnstore.GetFunctionsParams, principal int
 	}
 
 	var funcs []*functions.Function
-	err = h.Store.List(FunctionManagerFlags.OrgID, opts, &funcs)
+	next, err := utils.ListPage(h.Store, FunctionManagerFlags.OrgID, opts, &funcs)
 	if err != nil {
 		log.Errorf("Store error when listing functions: %+v\n", err)
 		return fnstore.NewGetFunctionsDefault(http.StatusInternalServerError).WithPayload(&models.Error{
@@ -418,7 +610,7 @@ func (h *Handlers) getFunctions(params fnstore.GetFunctionsParams, principal int
 			Message: swag.String("error when listing functions"),
 		})
 	}
-	return fnstore.NewGetFunctionsOK().WithPayload(functionListToModel(funcs))
+	return fnstore.NewGetFunctionsOK().WithXContinue(next).WithPayload(functionListToModel(funcs))
 }
 
 func (h *Handlers) updateFunction(params fnstore.UpdateFunctionParams, principal interface{}) middleware.Responder {
@@ -503,8 +695,9 @@ func (h *Handlers) runFunction(params fnrunner.RunFunctionParams, principal inte
 				Message: swag.String(err.Error()),
 			})
 	}
+	name, version, alias := functions.ParseFunctionName(*params.FunctionName)
 	f := new(functions.Function)
-	if err := h.Store.Get(FunctionManagerFlags.OrgID, *params.FunctionName, opts, f); err != nil {
+	if err := h.Store.Get(FunctionManagerFlags.OrgID, name, opts, f); err != nil {
 		log.Debugf("Error returned by h.Store.Get: %+v", err)
 		log.Infof("Trying to create run for non-existent function %s", *params.FunctionName)
 		return fnrunner.NewRunFunctionNotFound().WithPayload(&models.Error{
@@ -513,6 +706,28 @@ func (h *Handlers) runFunction(params fnrunner.RunFunctionParams, principal inte
 		})
 	}
 
+	if alias != "" {
+		a, ok := f.Aliases[alias]
+		if !ok {
+			return fnrunner.NewRunFunctionNotFound().WithPayload(&models.Error{
+				Code:    http.StatusNotFound,
+				Message: swag.String(fmt.Sprintf("alias %s of function %s not found", alias, name)),
+			})
+		}
+		version = a.PickVersion(rand.Int63n(a.TotalWeight()))
+	}
+	if version > 0 {
+		v := new(functions.FunctionVersion)
+		if err := h.Store.Get(FunctionManagerFlags.OrgID, functions.VersionName(name, version), entitystore.Options{}, v); err != nil {
+			log.Debugf("Error returned by h.Store.Get: %+v", err)
+			return fnrunner.NewRunFunctionNotFound().WithPayload(&models.Error{
+				Code:    http.StatusNotFound,
+				Message: swag.String(fmt.Sprintf("version %d of function %s not found", version, name)),
+			})
+		}
+		f = v.Function()
+	}
+
 	if f.Status != entitystore.StatusREADY {
 		return fnrunner.NewRunFunctionNotFound().WithPayload(&models.Error{
 			Code:    http.StatusNotFound,
@@ -521,6 +736,7 @@ func (h *Handlers) runFunction(params fnrunner.RunFunctionParams, principal inte
 	}
 
 	run := runModelToEntity(params.Body, f)
+	run.FunctionVersion = version
 
 	run.Status = entitystore.StatusINITIALIZED
 
@@ -532,16 +748,72 @@ func (h *Handlers) runFunction(params fnrunner.RunFunctionParams, principal inte
 		})
 	}
 
-	h.Watcher.OnAction(run)
+	if err := h.Queue.Push(run); err != nil {
+		log.Warnf("Function run %s rejected: %s", run.Name, err)
+		if err := h.Store.Delete(run.OrganizationID, run.Name, run); err != nil {
+			log.Errorf("Store error when deleting rejected function run %s: %+v", run.Name, err)
+		}
+		return fnrunner.NewRunFunctionTooManyRequests().WithPayload(&models.Error{
+			Code:    http.StatusTooManyRequests,
+			Message: swag.String(err.Error()),
+		})
+	}
 
 	if run.Blocking {
 		run.Wait()
+		ctx := context.Background()
+		if params.HTTPRequest != nil {
+			ctx = params.HTTPRequest.Context()
+		}
+		if err := h.waitFinished(ctx, run); err != nil {
+			log.Errorf("Error waiting for function run %s: %+v", run.Name, err)
+			return fnrunner.NewRunFunctionInternalServerError().WithPayload(&models.Error{
+				Code:    http.StatusInternalServerError,
+				Message: swag.String("internal server error when waiting for the function run"),
+			})
+		}
+		if r := runHTTPResponse(run); r != nil {
+			return r
+		}
 		return fnrunner.NewRunFunctionOK().WithPayload(runEntityToModel(run))
 	}
 
 	return fnrunner.NewRunFunctionAccepted().WithPayload(runEntityToModel(run))
 }
 
+// runPollPeriod is the period at which a blocking request polls a run executed by another replica
+var runPollPeriod = time.Second
+
+// waitFinished waits for a run, which was started by another replica meanwhile (e.g. by the leader resuming it), to
+// finish in the store. The run is updated with the stored one.
+func (h *Handlers) waitFinished(ctx context.Context, run *functions.FnRun) error {
+	defer trace.Tracef("run %s", run.Name)()
+
+	for !runFinished(run) {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(runPollPeriod):
+		}
+		stored := new(functions.FnRun)
+		if err := h.Store.Get(FunctionManagerFlags.OrgID, run.Name, entitystore.Options{}, stored); err != nil {
+			return errors.Wrapf(err, "store error when getting function run %s", run.Name)
+		}
+		*run = *stored
+	}
+	return nil
+}
+
+// runFinished reports whether a run is not in progress anymore
+func runFinished(run *functions.FnRun) bool {
+	for _, status := range FinishedRunStatuses {
+		if run.Status == status {
+			return true
+		}
+	}
+	return false
+}
+
 func (h *Handlers) getRun(params fnrunner.GetRunParams, principal interface{}) middleware.Responder {
 	defer trace.Trace("RunnerGetRunHandler")()
 	run := functions.FnRun{}
@@ -576,6 +848,110 @@ func (h *Handlers) getRun(params fnrunner.GetRunParams, principal interface{}) m
 	return fnrunner.NewGetRunOK().WithPayload(runEntityToModel(&run))
 }
 
+func (h *Handlers) cancelRun(params fnrunner.CancelRunParams, principal interface{}) middleware.Responder {
+	defer trace.Trace("RunnerCancelRunHandler")()
+	run := functions.FnRun{}
+
+	var err error
+	opts := entitystore.Options{
+		Filter: entitystore.FilterEverything(),
+	}
+	opts.Filter, err = utils.ParseTags(opts.Filter, params.Tags)
+	if err != nil {
+		log.Error(err)
+		return fnrunner.NewCancelRunBadRequest().WithPayload(
+			&models.Error{
+				Code:    http.StatusBadRequest,
+				Message: swag.String(err.Error()),
+			})
+	}
+
+	err = h.Store.Get(FunctionManagerFlags.OrgID, params.RunName.String(), opts, &run)
+	if err != nil || (params.FunctionName != nil && run.FunctionName != *params.FunctionName) {
+		log.Debugf("Error returned by h.Store.Get: %+v", err)
+		return fnrunner.NewCancelRunNotFound().WithPayload(&models.Error{
+			Code:    http.StatusNotFound,
+			Message: swag.String(fmt.Sprintf("function run %s not found", params.RunName)),
+		})
+	}
+
+	if !runInProgress(&run) {
+		return cancelRunConflict(params)
+	}
+
+	// A run queued or running on this replica is cancelled right away, otherwise the cancellation is recorded in the
+	// store for the replica executing it
+	queued, found := h.Queue.Cancel(run.Name)
+	if !found {
+		if err := h.cancelInStore(&run); err == errRunNotInProgress {
+			return cancelRunConflict(params)
+		} else if err != nil {
+			log.Errorf("Store error when cancelling function run %s: %+v", run.Name, err)
+			return fnrunner.NewCancelRunInternalServerError().WithPayload(&models.Error{
+				Code:    http.StatusInternalServerError,
+				Message: swag.String("internal server error when cancelling the function run"),
+			})
+		}
+	}
+
+	// A running run is finished by the controller, a queued one is finished here
+	if queued != nil {
+		queued.FinishedTime = time.Now()
+		queued.Cancelled = true
+		h.Store.UpdateWithError(queued, errRunCancelled)
+		queued.Done()
+		run = *queued
+	}
+	log.Infof("Function run %s cancelled", run.Name)
+	return fnrunner.NewCancelRunOK().WithPayload(runEntityToModel(&run))
+}
+
+var errRunNotInProgress = errors.New("function run not in progress")
+
+// cancelRunAttempts is the number of attempts to record a cancellation, while the run is changed concurrently
+const cancelRunAttempts = 5
+
+// cancelInStore records the cancellation of a run queued or running on another replica. A queued run is finished
+// here, a running one is cancelled by the replica running it. It returns errRunNotInProgress if the run finished
+// meanwhile.
+func (h *Handlers) cancelInStore(run *functions.FnRun) error {
+	defer trace.Tracef("run %s", run.Name)()
+
+	var err error
+	for i := 0; i < cancelRunAttempts; i++ {
+		if i > 0 {
+			if err := h.Store.Get(FunctionManagerFlags.OrgID, run.Name, entitystore.Options{}, run); err != nil {
+				return errors.Wrapf(err, "store error when getting function run %s", run.Name)
+			}
+			if !runInProgress(run) {
+				return errRunNotInProgress
+			}
+		}
+		run.Cancelled = true
+		if run.Status == entitystore.StatusINITIALIZED {
+			run.Status = entitystore.StatusERROR
+			run.Reason = []string{errRunCancelled.Error()}
+			run.FinishedTime = time.Now()
+		}
+		if _, err = h.Store.Update(run.GetRevision(), run); err == nil {
+			return nil
+		}
+	}
+	return errors.Wrapf(err, "store error when cancelling function run %s", run.Name)
+}
+
+// runInProgress reports whether a run is queued or running
+func runInProgress(run *functions.FnRun) bool {
+	return run.Status == entitystore.StatusINITIALIZED || run.Status == entitystore.StatusCREATING
+}
+
+func cancelRunConflict(params fnrunner.CancelRunParams) middleware.Responder {
+	return fnrunner.NewCancelRunConflict().WithPayload(&models.Error{
+		Code:    http.StatusConflict,
+		Message: swag.String(fmt.Sprintf("function run %s is not in progress", params.RunName)),
+	})
+}
+
 func (h *Handlers) getRuns(params fnrunner.GetRunsParams, principal interface{}) middleware.Responder {
 	defer trace.Trace("RunnerGetRunsHandler")()
 	var runs []*functions.FnRun
@@ -586,16 +962,29 @@ func (h *Handlers) getRuns(params fnrunner.GetRunsParams, principal interface{})
 	}
 
 	if params.FunctionName != nil {
+		name, version, _ := functions.ParseFunctionName(*params.FunctionName)
 		opts.Filter.Add(
 			entitystore.FilterStat{
 				Scope:   entitystore.FilterScopeExtra,
 				Subject: "FunctionName",
 				Verb:    entitystore.FilterVerbEqual,
-				Object:  *params.FunctionName,
+				Object:  name,
 			})
+		if version > 0 {
+			opts.Filter.Add(
+				entitystore.FilterStat{
+					Scope:   entitystore.FilterScopeExtra,
+					Subject: "FunctionVersion",
+					Verb:    entitystore.FilterVerbEqual,
+					Object:  version,
+				})
+		}
 	}
 
 	opts.Filter, err = utils.ParseTags(opts.Filter, params.Tags)
+	if err == nil {
+		err = utils.ParsePage(&opts, params.Limit, params.Continue, params.SortBy)
+	}
 	if err != nil {
 		log.Errorf(err.Error())
 		return fnrunner.NewGetRunsBadRequest().WithPayload(
@@ -605,7 +994,8 @@ func (h *Handlers) getRuns(params fnrunner.GetRunsParams, principal interface{})
 			})
 	}
 
-	if err = h.Store.List(FunctionManagerFlags.OrgID, opts, &runs); err != nil {
+	next, err := utils.ListPage(h.Store, FunctionManagerFlags.OrgID, opts, &runs)
+	if err != nil {
 		if params.FunctionName != nil {
 			log.Errorf("Store error when listing runs for function %s: %+v", *params.FunctionName, err)
 		} else {
@@ -617,5 +1007,289 @@ func (h *Handlers) getRuns(params fnrunner.GetRunsParams, principal interface{})
 			Message: swag.String("error when listing function runs"),
 		})
 	}
-	return fnrunner.NewGetRunsOK().WithPayload(runListToModel(runs))
+	return fnrunner.NewGetRunsOK().WithXContinue(next).WithPayload(runListToModel(runs))
+}
+
+func (h *Handlers) deleteRuns(params fnrunner.DeleteRunsParams, principal interface{}) middleware.Responder {
+	defer trace.Trace("RunnerDeleteRunsHandler")()
+
+	if h.RunPruner == nil {
+		return fnrunner.NewDeleteRunsInternalServerError().WithPayload(&models.Error{
+			Code:    http.StatusInternalServerError,
+			Message: swag.String("run pruning is not enabled"),
+		})
+	}
+
+	var err error
+	filter := entitystore.FilterEverything()
+	if params.FunctionName != nil {
+		name, version, _ := functions.ParseFunctionName(*params.FunctionName)
+		filter.Add(
+			entitystore.FilterStat{
+				Scope:   entitystore.FilterScopeExtra,
+				Subject: "FunctionName",
+				Verb:    entitystore.FilterVerbEqual,
+				Object:  name,
+			})
+		if version > 0 {
+			filter.Add(
+				entitystore.FilterStat{
+					Scope:   entitystore.FilterScopeExtra,
+					Subject: "FunctionVersion",
+					Verb:    entitystore.FilterVerbEqual,
+					Object:  version,
+				})
+		}
+	}
+	if len(params.Name) > 0 {
+		filter.Add(
+			entitystore.FilterStat{
+				Scope:   entitystore.FilterScopeField,
+				Subject: "Name",
+				Verb:    entitystore.FilterVerbIn,
+				Object:  params.Name,
+			})
+	}
+
+	retention := RunRetention{Keep: int(swag.Int64Value(params.Keep))}
+	for _, status := range params.Status {
+		retention.Statuses = append(retention.Statuses, entitystore.Status(strings.ToUpper(status)))
+	}
+	filter, err = utils.ParseTags(filter, params.Tags)
+	if err == nil && params.OlderThan != nil {
+		retention.MaxAge, err = time.ParseDuration(*params.OlderThan)
+	}
+	if err == nil {
+		err = retention.Validate()
+	}
+	if err != nil {
+		log.Error(err)
+		return fnrunner.NewDeleteRunsBadRequest().WithPayload(
+			&models.Error{
+				Code:    http.StatusBadRequest,
+				Message: swag.String(err.Error()),
+			})
+	}
+
+	runs, err := h.RunPruner.Prune(&retention, filter, swag.BoolValue(params.DryRun))
+	if err != nil {
+		log.Errorf("Error deleting function runs: %+v", err)
+		return fnrunner.NewDeleteRunsInternalServerError().WithPayload(&models.Error{
+			Code:    http.StatusInternalServerError,
+			Message: swag.String(err.Error()),
+		})
+	}
+	body := make([]*models.Run, 0, len(runs))
+	for _, run := range runs {
+		body = append(body, runEntityToModel(run.FnRun))
+	}
+	return fnrunner.NewDeleteRunsOK().WithPayload(body)
+}
+
+// listVersions lists a page of the versions of a function, by number unless sorted otherwise
+func (h *Handlers) listVersions(functionName string, opts entitystore.Options) ([]*functions.FunctionVersion, string, error) {
+	opts.Filter = entitystore.FilterEverything().Add(
+		entitystore.FilterStat{
+			Scope:   entitystore.FilterScopeExtra,
+			Subject: "FunctionName",
+			Verb:    entitystore.FilterVerbEqual,
+			Object:  functionName,
+		})
+	if len(opts.SortBy) == 0 {
+		opts.SortBy = []entitystore.SortKey{{Scope: entitystore.FilterScopeExtra, Subject: "Number"}}
+	}
+	var versions []*functions.FunctionVersion
+	next, err := utils.ListPage(h.Store, FunctionManagerFlags.OrgID, opts, &versions)
+	if err != nil {
+		return nil, "", err
+	}
+	return versions, next, nil
+}
+
+func (h *Handlers) publishVersion(params fnstore.PublishVersionParams, principal interface{}) middleware.Responder {
+	defer trace.Trace("StorePublishVersionHandler")()
+
+	f := new(functions.Function)
+	if err := h.Store.Get(FunctionManagerFlags.OrgID, params.FunctionName, entitystore.Options{}, f); err != nil {
+		log.Debugf("Error returned by h.Store.Get: %+v", err)
+		return fnstore.NewPublishVersionNotFound().WithPayload(&models.Error{
+			Code:    http.StatusNotFound,
+			Message: swag.String("function not found"),
+		})
+	}
+
+	// The version number is reserved first, concurrent publications fail on the revision of the function
+	f.LatestVersion++
+	if _, err := h.Store.Update(f.Revision, f); err != nil {
+		log.Errorf("Store error when publishing a version of function %s: %+v", f.Name, err)
+		return fnstore.NewPublishVersionConflict().WithPayload(&models.Error{
+			Code:    http.StatusConflict,
+			Message: swag.String("function is being updated, try again"),
+		})
+	}
+
+	v := &functions.FunctionVersion{
+		BaseEntity: entitystore.BaseEntity{
+			OrganizationID: FunctionManagerFlags.OrgID,
+			Name:           functions.VersionName(f.Name, f.LatestVersion),
+			Status:         entitystore.StatusINITIALIZED,
+			Tags:           f.Tags,
+		},
+		FunctionName: f.Name,
+		Number:       f.LatestVersion,
+		Code:         f.Code,
+		Main:         f.Main,
+		ImageName:    f.ImageName,
+		Schema:       f.Schema,
+		Secrets:      f.Secrets,
+		Timeout:      f.Timeout,
+		Environment:  f.Environment,
+		Limits:       f.Limits,
+		Scaling:      f.Scaling,
+	}
+	if _, err := h.Store.Add(v); err != nil {
+		log.Errorf("Store error when adding function version %s: %+v", v.Name, err)
+		return fnstore.NewPublishVersionInternalServerError().WithPayload(&models.Error{
+			Code:    http.StatusInternalServerError,
+			Message: swag.String("internal server error when storing a new function version"),
+		})
+	}
+
+	h.Watcher.OnAction(v)
+
+	return fnstore.NewPublishVersionCreated().WithPayload(versionEntityToModel(v))
+}
+
+func (h *Handlers) getVersions(params fnstore.GetVersionsParams, principal interface{}) middleware.Responder {
+	defer trace.Trace("StoreGetVersionsHandler")()
+
+	f := new(functions.Function)
+	if err := h.Store.Get(FunctionManagerFlags.OrgID, params.FunctionName, entitystore.Options{}, f); err != nil {
+		log.Debugf("Error returned by h.Store.Get: %+v", err)
+		return fnstore.NewGetVersionsNotFound().WithPayload(&models.Error{
+			Code:    http.StatusNotFound,
+			Message: swag.String("function not found"),
+		})
+	}
+
+	var opts entitystore.Options
+	if err := utils.ParsePage(&opts, params.Limit, params.Continue, params.SortBy); err != nil {
+		log.Error(err)
+		return fnstore.NewGetVersionsBadRequest().WithPayload(&models.Error{
+			Code:    http.StatusBadRequest,
+			Message: swag.String(err.Error()),
+		})
+	}
+	versions, next, err := h.listVersions(f.Name, opts)
+	if err != nil {
+		log.Errorf("Store error when listing versions of function %s: %+v", f.Name, err)
+		return fnstore.NewGetVersionsInternalServerError().WithPayload(&models.Error{
+			Code:    http.StatusInternalServerError,
+			Message: swag.String("error when listing function versions"),
+		})
+	}
+	body := make([]*models.FunctionVersion, 0, len(versions))
+	for _, v := range versions {
+		body = append(body, versionEntityToModel(v))
+	}
+	return fnstore.NewGetVersionsOK().WithXContinue(next).WithPayload(body)
+}
+
+func (h *Handlers) setAlias(params fnstore.SetAliasParams, principal interface{}) middleware.Responder {
+	defer trace.Trace("StoreSetAliasHandler")()
+
+	f := new(functions.Function)
+	if err := h.Store.Get(FunctionManagerFlags.OrgID, params.FunctionName, entitystore.Options{}, f); err != nil {
+		log.Debugf("Error returned by h.Store.Get: %+v", err)
+		return fnstore.NewSetAliasNotFound().WithPayload(&models.Error{
+			Code:    http.StatusNotFound,
+			Message: swag.String("function not found"),
+		})
+	}
+
+	a, err := aliasModelToEntity(params.Body, f)
+	if err != nil {
+		return fnstore.NewSetAliasBadRequest().WithPayload(&models.Error{
+			Code:    http.StatusBadRequest,
+			Message: swag.String(err.Error()),
+		})
+	}
+	if f.Aliases == nil {
+		f.Aliases = make(map[string]*functions.Alias)
+	}
+	if previous, ok := f.Aliases[params.AliasName]; ok {
+		a.PreviousRoutes = previous.Routes
+	}
+	f.Aliases[params.AliasName] = a
+
+	if _, err := h.Store.Update(f.Revision, f); err != nil {
+		log.Errorf("Store error when setting alias %s of function %s: %+v", params.AliasName, f.Name, err)
+		return fnstore.NewSetAliasConflict().WithPayload(&models.Error{
+			Code:    http.StatusConflict,
+			Message: swag.String("function is being updated, try again"),
+		})
+	}
+	log.Infof("Alias %s of function %s moved to %+v", params.AliasName, f.Name, a.Routes)
+	return fnstore.NewSetAliasOK().WithPayload(functionEntityToModel(f))
+}
+
+func (h *Handlers) deleteAlias(params fnstore.DeleteAliasParams, principal interface{}) middleware.Responder {
+	defer trace.Trace("StoreDeleteAliasHandler")()
+
+	f := new(functions.Function)
+	if err := h.Store.Get(FunctionManagerFlags.OrgID, params.FunctionName, entitystore.Options{}, f); err != nil {
+		log.Debugf("Error returned by h.Store.Get: %+v", err)
+		return fnstore.NewDeleteAliasNotFound().WithPayload(&models.Error{
+			Code:    http.StatusNotFound,
+			Message: swag.String("function not found"),
+		})
+	}
+	if _, ok := f.Aliases[params.AliasName]; !ok {
+		return fnstore.NewDeleteAliasNotFound().WithPayload(&models.Error{
+			Code:    http.StatusNotFound,
+			Message: swag.String(fmt.Sprintf("alias %s of function %s not found", params.AliasName, f.Name)),
+		})
+	}
+	delete(f.Aliases, params.AliasName)
+
+	if _, err := h.Store.Update(f.Revision, f); err != nil {
+		log.Errorf("Store error when deleting alias %s of function %s: %+v", params.AliasName, f.Name, err)
+		return fnstore.NewDeleteAliasConflict().WithPayload(&models.Error{
+			Code:    http.StatusConflict,
+			Message: swag.String("function is being updated, try again"),
+		})
+	}
+	return fnstore.NewDeleteAliasOK().WithPayload(functionEntityToModel(f))
+}
+
+func (h *Handlers) collectImages(params fnstore.CollectImagesParams, principal interface{}) middleware.Responder {
+	defer trace.Trace("StoreCollectImagesHandler")()
+
+	if h.ImageGC == nil {
+		return fnstore.NewCollectImagesInternalServerError().WithPayload(&models.Error{
+			Code:    http.StatusInternalServerError,
+			Message: swag.String("image garbage collection is not enabled"),
+		})
+	}
+	images, err := h.ImageGC.Collect(swag.BoolValue(params.DryRun))
+	if err != nil {
+		log.Errorf("Error collecting function images: %+v", err)
+		return fnstore.NewCollectImagesInternalServerError().WithPayload(&models.Error{
+			Code:    http.StatusInternalServerError,
+			Message: swag.String(err.Error()),
+		})
+	}
+	body := make([]*models.FunctionImage, 0, len(images))
+	for _, image := range images {
+		body = append(body, &models.FunctionImage{
+			ID:          image.ID,
+			FunctionID:  image.FunctionID,
+			Faas:        image.FaaS,
+			Names:       image.Names,
+			CreatedTime: image.Created.Unix(),
+			Size:        image.Size,
+			Reason:      image.Reason,
+		})
+	}
+	return fnstore.NewCollectImagesOK().WithPayload(body)
 }
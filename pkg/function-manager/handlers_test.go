@@ -6,12 +6,16 @@
 package functionmanager
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
+	"github.com/go-openapi/runtime/middleware"
+	"github.com/go-openapi/strfmt"
 	"github.com/go-openapi/swag"
 	"github.com/stretchr/testify/assert"
 
@@ -73,11 +77,48 @@ func TestStoreAddFunctionHandler(t *testing.T) {
 	assert.Equal(t, "test", respBody.Tags[0].Value)
 }
 
+func TestStoreAddFunctionHandler_settings(t *testing.T) {
+	handlers := &Handlers{
+		Store: helpers.MakeEntityStore(t),
+	}
+
+	api := operations.NewFunctionManagerAPI(nil)
+	handlers.ConfigureHandlers(api)
+
+	add := func(name string, limits *models.FunctionLimits) middleware.Responder {
+		r := httptest.NewRequest("POST", "/v1/function", nil)
+		return api.StoreAddFunctionHandler.Handle(fnstore.AddFunctionParams{
+			HTTPRequest: r,
+			Body: &models.Function{
+				Name:        swag.String(name),
+				Schema:      &models.Schema{},
+				Code:        swag.String("some code"),
+				Image:       swag.String("imageID"),
+				Environment: map[string]string{"DEBUG": "true"},
+				Limits:      limits,
+				Scaling:     &models.FunctionScaling{MaxReplicas: swag.Int64(5)},
+			},
+		}, "testCookie")
+	}
+
+	helpers.HandlerRequest(t, add("invalid", &models.FunctionLimits{Memory: "lots"}), &models.Error{}, 400)
+
+	var respBody models.Function
+	helpers.HandlerRequest(t, add("testEntity", &models.FunctionLimits{Memory: "128Mi", CPU: "500m"}), &respBody, 200)
+	assert.Equal(t, map[string]string{"DEBUG": "true"}, respBody.Environment)
+	assert.Equal(t, &models.FunctionLimits{Memory: "128Mi", CPU: "500m"}, respBody.Limits)
+	assert.EqualValues(t, 5, *respBody.Scaling.MaxReplicas)
+	assert.EqualValues(t, 0, *respBody.Scaling.MinReplicas)
+}
+
 func TestHandlers_runFunction_notREADY(t *testing.T) {
 	store := helpers.MakeEntityStore(t)
 	watcher := make(chan entitystore.Entity, 1)
+	queue := NewRunQueue(&RunQueueConfig{}, store)
+	queue.watcher = watcher
 	handlers := &Handlers{
 		Watcher: watcher,
+		Queue:   queue,
 		Store:   store,
 	}
 
@@ -113,8 +154,11 @@ func TestHandlers_runFunction_notREADY(t *testing.T) {
 func TestHandlers_runFunction_READY(t *testing.T) {
 	store := helpers.MakeEntityStore(t)
 	watcher := make(chan entitystore.Entity, 1)
+	queue := NewRunQueue(&RunQueueConfig{}, store)
+	queue.watcher = watcher
 	handlers := &Handlers{
 		Watcher: watcher,
+		Queue:   queue,
 		Store:   store,
 	}
 
@@ -148,6 +192,302 @@ func TestHandlers_runFunction_READY(t *testing.T) {
 	assert.Equal(t, runEntityToModel((<-watcher).(*functions.FnRun)), &respBody)
 }
 
+func TestHandlers_runFunction_queueFull(t *testing.T) {
+	store := helpers.MakeEntityStore(t)
+	watcher := make(chan entitystore.Entity, 2)
+	queue := NewRunQueue(&RunQueueConfig{MaxRuns: 1, QueueSize: 1}, store)
+	queue.watcher = watcher
+	handlers := &Handlers{
+		Watcher: watcher,
+		Queue:   queue,
+		Store:   store,
+	}
+
+	testFuncName := "testFunction"
+
+	store.Add(&functions.Function{
+		BaseEntity: entitystore.BaseEntity{
+			Name:   testFuncName,
+			Status: entitystore.StatusREADY,
+		},
+	})
+
+	api := operations.NewFunctionManagerAPI(nil)
+	handlers.ConfigureHandlers(api)
+
+	run := func() middleware.Responder {
+		r := httptest.NewRequest("POST", fmt.Sprintf("/v1/runs?functionName=%s", testFuncName), nil)
+		params := fnrunner.RunFunctionParams{
+			HTTPRequest:  r,
+			Body:         &models.Run{},
+			FunctionName: &testFuncName,
+		}
+		return api.RunnerRunFunctionHandler.Handle(params, "testCookie")
+	}
+	// the first run is executed, the second one waits in the queue
+	helpers.HandlerRequest(t, run(), &models.Run{}, 202)
+	helpers.HandlerRequest(t, run(), &models.Run{}, 202)
+	assert.Len(t, watcher, 1)
+
+	var respBody models.Error
+	helpers.HandlerRequest(t, run(), &respBody, 429)
+	assert.EqualValues(t, http.StatusTooManyRequests, respBody.Code)
+
+	var runs []*functions.FnRun
+	assert.NoError(t, store.List(FunctionManagerFlags.OrgID, entitystore.Options{}, &runs))
+	assert.Len(t, runs, 2)
+}
+
+func TestHandlers_cancelRun(t *testing.T) {
+	store := helpers.MakeEntityStore(t)
+	watcher := make(chan entitystore.Entity, 1)
+	queue := NewRunQueue(&RunQueueConfig{MaxRuns: 1}, store)
+	queue.watcher = watcher
+	handlers := &Handlers{
+		Watcher: watcher,
+		Queue:   queue,
+		Store:   store,
+	}
+
+	api := operations.NewFunctionManagerAPI(nil)
+	handlers.ConfigureHandlers(api)
+
+	var runs []*functions.FnRun
+	for _, name := range []string{"d7a50dbc-5a7e-4a0b-8b0e-1f3a6e0e0a01", "d7a50dbc-5a7e-4a0b-8b0e-1f3a6e0e0a02"} {
+		run := &functions.FnRun{
+			BaseEntity: entitystore.BaseEntity{
+				Name:   name,
+				Status: entitystore.StatusINITIALIZED,
+			},
+			FunctionName: "testFunction",
+		}
+		_, err := store.Add(run)
+		assert.NoError(t, err)
+		assert.NoError(t, queue.Push(run))
+		runs = append(runs, run)
+	}
+	// the first run is running, the second one is queued
+	assert.Len(t, watcher, 1)
+
+	cancel := func(name string) middleware.Responder {
+		r := httptest.NewRequest("DELETE", "/v1/runs/"+name, nil)
+		params := fnrunner.CancelRunParams{
+			HTTPRequest: r,
+			RunName:     strfmt.UUID(name),
+		}
+		return api.RunnerCancelRunHandler.Handle(params, "testCookie")
+	}
+
+	var respBody models.Run
+	helpers.HandlerRequest(t, cancel(runs[1].Name), &respBody, 200)
+	assert.Equal(t, models.StatusERROR, respBody.Status)
+	assert.Equal(t, []string{errRunCancelled.Error()}, respBody.Reason)
+
+	var stored functions.FnRun
+	assert.NoError(t, store.Get("", runs[1].Name, entitystore.Options{}, &stored))
+	assert.Equal(t, entitystore.StatusERROR, stored.Status)
+
+	// a cancelled run is not in progress anymore
+	helpers.HandlerRequest(t, cancel(runs[1].Name), &models.Error{}, 409)
+	helpers.HandlerRequest(t, cancel("d7a50dbc-5a7e-4a0b-8b0e-1f3a6e0e0a03"), &models.Error{}, 404)
+
+	// the cancellation of runs queued or running on another replica is recorded in the store
+	remote := map[string]entitystore.Status{
+		"d7a50dbc-5a7e-4a0b-8b0e-1f3a6e0e0a04": entitystore.StatusINITIALIZED,
+		"d7a50dbc-5a7e-4a0b-8b0e-1f3a6e0e0a05": entitystore.StatusCREATING,
+	}
+	for name, status := range remote {
+		_, err := store.Add(&functions.FnRun{
+			BaseEntity: entitystore.BaseEntity{
+				Name:   name,
+				Status: status,
+			},
+			FunctionName: "testFunction",
+		})
+		assert.NoError(t, err)
+		helpers.HandlerRequest(t, cancel(name), &models.Run{}, 200)
+	}
+	var queued, running functions.FnRun
+	assert.NoError(t, store.Get("", "d7a50dbc-5a7e-4a0b-8b0e-1f3a6e0e0a04", entitystore.Options{}, &queued))
+	assert.True(t, queued.Cancelled)
+	assert.Equal(t, entitystore.StatusERROR, queued.Status)
+	assert.Equal(t, entitystore.Reason{errRunCancelled.Error()}, queued.Reason)
+	assert.NoError(t, store.Get("", "d7a50dbc-5a7e-4a0b-8b0e-1f3a6e0e0a05", entitystore.Options{}, &running))
+	assert.True(t, running.Cancelled)
+	assert.Equal(t, entitystore.StatusCREATING, running.Status)
+}
+
+func TestHandlers_waitFinished(t *testing.T) {
+	defer func(period time.Duration) { runPollPeriod = period }(runPollPeriod)
+	runPollPeriod = 10 * time.Millisecond
+
+	store := helpers.MakeEntityStore(t)
+	handlers := &Handlers{Store: store}
+	run := &functions.FnRun{
+		BaseEntity: entitystore.BaseEntity{
+			Name:   "testRun",
+			Status: entitystore.StatusCREATING,
+		},
+		FunctionName: "testFunction",
+		Blocking:     true,
+	}
+	_, err := store.Add(run)
+	assert.NoError(t, err)
+
+	// the run was started by another replica, which finishes it later
+	finished := *run
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		finished.Status = entitystore.StatusREADY
+		finished.Output = "output"
+		_, err := store.Update(finished.Revision, &finished)
+		assert.NoError(t, err)
+	}()
+	assert.NoError(t, handlers.waitFinished(context.Background(), run))
+	assert.Equal(t, entitystore.StatusREADY, run.Status)
+	assert.Equal(t, "output", run.Output)
+
+	// the request is cancelled while the run is in progress
+	run.Status = entitystore.StatusCREATING
+	_, err = store.Update(run.Revision, run)
+	assert.NoError(t, err)
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	assert.Equal(t, context.DeadlineExceeded, handlers.waitFinished(ctx, run))
+}
+
+func TestHandlers_deleteRuns(t *testing.T) {
+	store := helpers.MakeEntityStore(t)
+	handlers := &Handlers{
+		RunPruner: NewRunPruner(&RunPrunerConfig{}, store, nil),
+		Store:     store,
+	}
+
+	api := operations.NewFunctionManagerAPI(nil)
+	handlers.ConfigureHandlers(api)
+
+	for _, run := range []*functions.FnRun{
+		makeFinishedRun("run1", "fn", entitystore.StatusREADY, time.Now()),
+		makeFinishedRun("run2", "fn", entitystore.StatusERROR, time.Now()),
+		makeFinishedRun("run3", "fn", entitystore.StatusINITIALIZED, time.Now()),
+	} {
+		_, err := store.Add(run)
+		assert.NoError(t, err)
+	}
+
+	deleteRuns := func(params fnrunner.DeleteRunsParams) middleware.Responder {
+		params.HTTPRequest = httptest.NewRequest("DELETE", "/v1/runs", nil)
+		return api.RunnerDeleteRunsHandler.Handle(params, "testCookie")
+	}
+
+	helpers.HandlerRequest(t, deleteRuns(fnrunner.DeleteRunsParams{OlderThan: swag.String("1 day")}), &models.Error{}, 400)
+	helpers.HandlerRequest(t, deleteRuns(fnrunner.DeleteRunsParams{Status: []string{"INITIALIZED"}}), &models.Error{}, 400)
+
+	var respBody []*models.Run
+	helpers.HandlerRequest(t, deleteRuns(fnrunner.DeleteRunsParams{Name: []string{"run1"}}), &respBody, 200)
+	assert.Len(t, respBody, 1)
+	assert.Equal(t, strfmt.UUID("run1"), respBody[0].Name)
+
+	// the run in progress is not deleted
+	respBody = nil
+	helpers.HandlerRequest(t, deleteRuns(fnrunner.DeleteRunsParams{FunctionName: swag.String("fn")}), &respBody, 200)
+	assert.Len(t, respBody, 1)
+	assert.Equal(t, strfmt.UUID("run2"), respBody[0].Name)
+
+	var left []*functions.FnRun
+	assert.NoError(t, store.List(FunctionManagerFlags.OrgID, entitystore.Options{}, &left))
+	assert.Len(t, left, 1)
+	assert.Equal(t, "run3", left[0].Name)
+}
+
+func TestHandlers_versionsAndAliases(t *testing.T) {
+	store := helpers.MakeEntityStore(t)
+	watcher := make(chan entitystore.Entity, 1)
+	queue := NewRunQueue(&RunQueueConfig{}, store)
+	queue.watcher = watcher
+	handlers := &Handlers{
+		Watcher: watcher,
+		Queue:   queue,
+		Store:   store,
+	}
+
+	testFuncName := "testFunction"
+	store.Add(&functions.Function{
+		BaseEntity: entitystore.BaseEntity{
+			Name:   testFuncName,
+			Status: entitystore.StatusREADY,
+		},
+		Code:      "some code",
+		ImageName: "imageID",
+		Schema:    &functions.Schema{},
+	})
+
+	api := operations.NewFunctionManagerAPI(nil)
+	handlers.ConfigureHandlers(api)
+
+	r := httptest.NewRequest("POST", fmt.Sprintf("/v1/function/%s/versions", testFuncName), nil)
+	responder := api.StorePublishVersionHandler.Handle(fnstore.PublishVersionParams{HTTPRequest: r, FunctionName: testFuncName}, "testCookie")
+	var version models.FunctionVersion
+	helpers.HandlerRequest(t, responder, &version, 201)
+	assert.EqualValues(t, 1, version.Version)
+	assert.Equal(t, "imageID", version.Image)
+
+	// the version is deployed by the controller
+	v := (<-watcher).(*functions.FunctionVersion)
+	assert.Equal(t, "testFunction-v1", v.Name)
+	assert.Equal(t, "some code", v.Code)
+	v.Status = entitystore.StatusREADY
+	_, err := store.Update(v.Revision, v)
+	assert.Nil(t, err)
+
+	r = httptest.NewRequest("GET", fmt.Sprintf("/v1/function/%s/versions", testFuncName), nil)
+	responder = api.StoreGetVersionsHandler.Handle(fnstore.GetVersionsParams{HTTPRequest: r, FunctionName: testFuncName}, "testCookie")
+	var versions []*models.FunctionVersion
+	helpers.HandlerRequest(t, responder, &versions, 200)
+	assert.Len(t, versions, 1)
+
+	setAlias := func(alias *models.Alias) middleware.Responder {
+		r := httptest.NewRequest("PUT", fmt.Sprintf("/v1/function/%s/aliases/prod", testFuncName), nil)
+		return api.StoreSetAliasHandler.Handle(fnstore.SetAliasParams{
+			HTTPRequest:  r,
+			FunctionName: testFuncName,
+			AliasName:    "prod",
+			Body:         alias,
+		}, "testCookie")
+	}
+	route := func(version, weight int64) *models.Route {
+		return &models.Route{Version: swag.Int64(version), Weight: swag.Int64(weight)}
+	}
+	helpers.HandlerRequest(t, setAlias(&models.Alias{Routes: []*models.Route{route(2, 100)}}), &models.Error{}, 400)
+	helpers.HandlerRequest(t, setAlias(&models.Alias{Routes: []*models.Route{route(1, 0)}}), &models.Error{}, 400)
+	var function models.Function
+	helpers.HandlerRequest(t, setAlias(&models.Alias{Routes: []*models.Route{route(1, 100)}}), &function, 200)
+	assert.EqualValues(t, 1, function.LatestVersion)
+	assert.Len(t, function.Aliases, 1)
+	assert.Equal(t, "prod", function.Aliases[0].Name)
+
+	run := func(name string) middleware.Responder {
+		r := httptest.NewRequest("POST", fmt.Sprintf("/v1/runs?functionName=%s", name), nil)
+		return api.RunnerRunFunctionHandler.Handle(fnrunner.RunFunctionParams{
+			HTTPRequest:  r,
+			Body:         &models.Run{},
+			FunctionName: &name,
+		}, "testCookie")
+	}
+	var respBody models.Run
+	helpers.HandlerRequest(t, run("testFunction:prod"), &respBody, 202)
+	assert.Equal(t, testFuncName, respBody.FunctionName)
+	assert.EqualValues(t, 1, respBody.FunctionVersion)
+	<-watcher
+	helpers.HandlerRequest(t, run("testFunction:2"), &models.Error{}, 404)
+	helpers.HandlerRequest(t, run("testFunction:staging"), &models.Error{}, 404)
+
+	r = httptest.NewRequest("DELETE", fmt.Sprintf("/v1/function/%s/aliases/prod", testFuncName), nil)
+	deleteParams := fnstore.DeleteAliasParams{HTTPRequest: r, FunctionName: testFuncName, AliasName: "prod"}
+	helpers.HandlerRequest(t, api.StoreDeleteAliasHandler.Handle(deleteParams, "testCookie"), &models.Function{}, 200)
+	helpers.HandlerRequest(t, api.StoreDeleteAliasHandler.Handle(deleteParams, "testCookie"), &models.Error{}, 404)
+}
+
 func TestStoreGetFunctionHandler(t *testing.T) {
 	handlers := &Handlers{
 		Store: helpers.MakeEntityStore(t),
@@ -219,3 +559,52 @@ func Test_runModelToEntitySecret(t *testing.T) {
 	fnRun := runModelToEntity(&runModel, &f)
 	assert.Equal(t, secrets, fnRun.Secrets)
 }
+
+func TestStoreGetFunctionsHandler_paged(t *testing.T) {
+	handlers := &Handlers{
+		Store: helpers.MakeEntityStore(t),
+	}
+
+	api := operations.NewFunctionManagerAPI(nil)
+	helpers.MakeAPI(t, handlers.ConfigureHandlers, api)
+
+	for _, name := range []string{"a", "b", "c"} {
+		add := fnstore.AddFunctionParams{
+			HTTPRequest: httptest.NewRequest("POST", "/v1/function", nil),
+			Body: &models.Function{
+				Name:   swag.String(name),
+				Schema: &models.Schema{},
+				Code:   swag.String("some code"),
+				Image:  swag.String("imageID"),
+			},
+		}
+		helpers.HandlerRequest(t, api.StoreAddFunctionHandler.Handle(add, "testCookie"), &models.Function{}, 200)
+	}
+
+	get := fnstore.GetFunctionsParams{
+		HTTPRequest: httptest.NewRequest("GET", "/v1/function", nil),
+		Limit:       swag.Int64(2),
+		SortBy:      []string{"-name"},
+	}
+	responder := api.StoreGetFunctionsHandler.Handle(get, "testCookie")
+	ok, isOK := responder.(*fnstore.GetFunctionsOK)
+	assert.True(t, isOK)
+	if assert.Len(t, ok.Payload, 2) {
+		assert.Equal(t, "c", *ok.Payload[0].Name)
+		assert.Equal(t, "b", *ok.Payload[1].Name)
+	}
+	assert.NotEmpty(t, ok.XContinue)
+
+	get.Continue = swag.String(ok.XContinue)
+	responder = api.StoreGetFunctionsHandler.Handle(get, "testCookie")
+	ok, isOK = responder.(*fnstore.GetFunctionsOK)
+	assert.True(t, isOK)
+	if assert.Len(t, ok.Payload, 1) {
+		assert.Equal(t, "a", *ok.Payload[0].Name)
+	}
+	assert.Empty(t, ok.XContinue)
+
+	get.SortBy = []string{"-"}
+	responder = api.StoreGetFunctionsHandler.Handle(get, "testCookie")
+	helpers.HandlerRequest(t, responder, &models.Error{}, 400)
+}
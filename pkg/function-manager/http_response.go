@@ -0,0 +1,118 @@
+///////////////////////////////////////////////////////////////////////
+// Copyright (c) 2018 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+///////////////////////////////////////////////////////////////////////
+
+package functionmanager
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/go-openapi/runtime"
+	"github.com/go-openapi/runtime/middleware"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/vmware/dispatch/pkg/entity-store"
+	"github.com/vmware/dispatch/pkg/functions"
+)
+
+// HTTPResponseHeader marks the responses, which are HTTP responses returned by the functions, rather than runs. API
+// gateways pass these responses as is to the clients.
+const HTTPResponseHeader = "X-Dispatch-Http-Response"
+
+// httpResponse is the response envelope a function triggered by an API may return as output
+type httpResponse struct {
+	statusCode      int
+	headers         http.Header
+	body            interface{}
+	isBase64Encoded bool
+}
+
+// outputHTTPResponse returns the response envelope of a run output, an object with a numeric statusCode, or nil
+func outputHTTPResponse(output interface{}) *httpResponse {
+	m, ok := output.(map[string]interface{})
+	if !ok {
+		return nil
+	}
+	r := &httpResponse{headers: http.Header{}, body: m["body"]}
+	switch code := m["statusCode"].(type) {
+	case float64:
+		r.statusCode = int(code)
+	case int:
+		r.statusCode = code
+	case int64:
+		r.statusCode = int(code)
+	default:
+		return nil
+	}
+	if r.statusCode < 100 || r.statusCode > 999 {
+		return nil
+	}
+	if headers, ok := m["headers"].(map[string]interface{}); ok {
+		for k, v := range headers {
+			switch v := v.(type) {
+			case []interface{}:
+				for _, value := range v {
+					r.headers.Add(k, fmt.Sprint(value))
+				}
+			default:
+				r.headers.Set(k, fmt.Sprint(v))
+			}
+		}
+	}
+	r.isBase64Encoded, _ = m["isBase64Encoded"].(bool)
+	return r
+}
+
+// WriteResponse writes the status, headers and body of the response envelope
+func (r *httpResponse) WriteResponse(rw http.ResponseWriter, producer runtime.Producer) {
+	var body []byte
+	switch b := r.body.(type) {
+	case nil:
+	case string:
+		body = []byte(b)
+		if r.isBase64Encoded {
+			decoded, err := base64.StdEncoding.DecodeString(b)
+			if err != nil {
+				log.Errorf("error decoding the body of the function response: %v", err)
+				rw.WriteHeader(http.StatusBadGateway)
+				return
+			}
+			body = decoded
+		} else if r.headers.Get("Content-Type") == "" {
+			r.headers.Set("Content-Type", "text/plain; charset=utf-8")
+		}
+	default:
+		var err error
+		body, err = json.Marshal(b)
+		if err != nil {
+			log.Errorf("error encoding the body of the function response: %v", err)
+			rw.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		if r.headers.Get("Content-Type") == "" {
+			r.headers.Set("Content-Type", runtime.JSONMime)
+		}
+	}
+
+	for k, v := range r.headers {
+		rw.Header()[k] = v
+	}
+	rw.Header().Set(HTTPResponseHeader, "true")
+	rw.WriteHeader(r.statusCode)
+	rw.Write(body)
+}
+
+// runHTTPResponse returns the response envelope of a blocking run triggered by an API, if the function returned one
+func runHTTPResponse(run *functions.FnRun) middleware.Responder {
+	if run.HTTPRequest == nil || run.Status != entitystore.StatusREADY {
+		return nil
+	}
+	if r := outputHTTPResponse(run.Output); r != nil {
+		return r
+	}
+	return nil
+}
@@ -0,0 +1,94 @@
+///////////////////////////////////////////////////////////////////////
+// Copyright (c) 2018 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+///////////////////////////////////////////////////////////////////////
+
+package functionmanager
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/vmware/dispatch/pkg/entity-store"
+	"github.com/vmware/dispatch/pkg/functions"
+)
+
+func TestOutputHTTPResponse(t *testing.T) {
+	assert.Nil(t, outputHTTPResponse("hello"))
+	assert.Nil(t, outputHTTPResponse(map[string]interface{}{"myField": "hello"}))
+	assert.Nil(t, outputHTTPResponse(map[string]interface{}{"statusCode": "200"}))
+	assert.Nil(t, outputHTTPResponse(map[string]interface{}{"statusCode": float64(42)}))
+
+	r := outputHTTPResponse(map[string]interface{}{
+		"statusCode": float64(201),
+		"headers": map[string]interface{}{
+			"Location":   "/users/1",
+			"Set-Cookie": []interface{}{"a=1", "b=2"},
+		},
+		"body": map[string]interface{}{"id": "1"},
+	})
+	assert.NotNil(t, r)
+
+	w := httptest.NewRecorder()
+	r.WriteResponse(w, nil)
+	assert.Equal(t, 201, w.Code)
+	assert.Equal(t, "/users/1", w.Header().Get("Location"))
+	assert.Equal(t, []string{"a=1", "b=2"}, w.Header()["Set-Cookie"])
+	assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+	assert.Equal(t, "true", w.Header().Get(HTTPResponseHeader))
+	assert.Equal(t, `{"id":"1"}`, w.Body.String())
+}
+
+func TestOutputHTTPResponseBody(t *testing.T) {
+	w := httptest.NewRecorder()
+	outputHTTPResponse(map[string]interface{}{
+		"statusCode": float64(200),
+		"body":       "<p>hello</p>",
+		"headers":    map[string]interface{}{"Content-Type": "text/html"},
+	}).WriteResponse(w, nil)
+	assert.Equal(t, 200, w.Code)
+	assert.Equal(t, "text/html", w.Header().Get("Content-Type"))
+	assert.Equal(t, "<p>hello</p>", w.Body.String())
+
+	w = httptest.NewRecorder()
+	outputHTTPResponse(map[string]interface{}{
+		"statusCode": float64(404),
+		"body":       "not found",
+	}).WriteResponse(w, nil)
+	assert.Equal(t, 404, w.Code)
+	assert.Equal(t, "text/plain; charset=utf-8", w.Header().Get("Content-Type"))
+	assert.Equal(t, "not found", w.Body.String())
+
+	w = httptest.NewRecorder()
+	outputHTTPResponse(map[string]interface{}{
+		"statusCode":      float64(200),
+		"body":            "AAEC",
+		"isBase64Encoded": true,
+	}).WriteResponse(w, nil)
+	assert.Equal(t, 200, w.Code)
+	assert.Equal(t, []byte{0, 1, 2}, w.Body.Bytes())
+
+	w = httptest.NewRecorder()
+	outputHTTPResponse(map[string]interface{}{
+		"statusCode": float64(204),
+	}).WriteResponse(w, nil)
+	assert.Equal(t, 204, w.Code)
+	assert.Empty(t, w.Body.String())
+}
+
+func TestRunHTTPResponse(t *testing.T) {
+	output := map[string]interface{}{"statusCode": float64(200), "body": "hello"}
+	run := &functions.FnRun{
+		BaseEntity: entitystore.BaseEntity{Status: entitystore.StatusREADY},
+		Output:     output,
+	}
+	assert.Nil(t, runHTTPResponse(run))
+
+	run.HTTPRequest = &functions.HTTPRequest{Method: "GET", Path: "/hello"}
+	assert.NotNil(t, runHTTPResponse(run))
+
+	run.Status = entitystore.StatusERROR
+	assert.Nil(t, runHTTPResponse(run))
+}
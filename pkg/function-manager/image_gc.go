@@ -0,0 +1,232 @@
+///////////////////////////////////////////////////////////////////////
+// Copyright (c) 2018 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+///////////////////////////////////////////////////////////////////////
+
+package functionmanager
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/vmware/dispatch/pkg/entity-store"
+	"github.com/vmware/dispatch/pkg/functions"
+	"github.com/vmware/dispatch/pkg/trace"
+)
+
+// ImageGCConfig is the image garbage collection configuration
+type ImageGCConfig struct {
+	// Keep is the number of superseded images kept per function
+	Keep int
+	// MaxAge is the time after which superseded images are removed, even within Keep. 0 means no limit.
+	MaxAge time.Duration
+	// Period is the period of the collection. Besides, the images are collected when functions are updated or deleted.
+	Period time.Duration
+}
+
+// CollectedImage is an image removed by the garbage collection, and the reason why
+type CollectedImage struct {
+	*functions.FunctionImage
+	Reason string
+}
+
+// ImageGC removes the images of deleted functions, and the superseded images of functions. Each update of a function
+// builds a new image, the image in use is the one recorded by the function entity once deployed. The most recent image
+// of a function is kept as well, it may be deploying.
+type ImageGC struct {
+	config ImageGCConfig
+	store  entitystore.EntityStore
+	images functions.ImageCollector
+
+	// one collection at a time
+	sync.Mutex
+	trigger chan struct{}
+
+	// the periodic collection runs on the leader only, it is stopped and started again as the leadership changes
+	lifecycle sync.Mutex
+	done      chan struct{}
+}
+
+// NewImageGC creates a new image garbage collector
+func NewImageGC(config *ImageGCConfig, store entitystore.EntityStore, images functions.ImageCollector) *ImageGC {
+	return &ImageGC{
+		config:  *config,
+		store:   store,
+		images:  images,
+		trigger: make(chan struct{}, 1),
+	}
+}
+
+// Start collects the images periodically and on trigger, until Shutdown. It does nothing if started already.
+func (gc *ImageGC) Start() {
+	gc.lifecycle.Lock()
+	defer gc.lifecycle.Unlock()
+	if gc.done != nil {
+		return
+	}
+	done := make(chan struct{})
+	gc.done = done
+	go func() {
+		var tick <-chan time.Time
+		if gc.config.Period > 0 {
+			ticker := time.NewTicker(gc.config.Period)
+			defer ticker.Stop()
+			tick = ticker.C
+		}
+		for {
+			select {
+			case <-done:
+				return
+			case <-tick:
+			case <-gc.trigger:
+			}
+			if _, err := gc.Collect(false); err != nil {
+				log.Errorf("Error collecting function images: %+v", err)
+			}
+		}
+	}()
+}
+
+// Shutdown stops the periodic collection. It does nothing if not started.
+func (gc *ImageGC) Shutdown() {
+	gc.lifecycle.Lock()
+	defer gc.lifecycle.Unlock()
+	if gc.done != nil {
+		close(gc.done)
+		gc.done = nil
+	}
+}
+
+// Trigger requests a collection, e.g. after a function was updated. It does not wait for the collection.
+func (gc *ImageGC) Trigger() {
+	if gc == nil {
+		return
+	}
+	select {
+	case gc.trigger <- struct{}{}:
+	default:
+		// a collection is pending already
+	}
+}
+
+// DeployedImage returns the ID of the most recent image of a function, i.e. the image just built and deployed for it.
+// The ID is empty without garbage collection.
+func (gc *ImageGC) DeployedImage(functionID string) (string, error) {
+	if gc == nil {
+		return "", nil
+	}
+	images, err := gc.images.ListImages()
+	if err != nil {
+		return "", err
+	}
+	var deployed *functions.FunctionImage
+	for _, image := range images {
+		if image.FunctionID == functionID && (deployed == nil || image.Created.After(deployed.Created)) {
+			deployed = image
+		}
+	}
+	if deployed == nil {
+		return "", nil
+	}
+	return deployed.ID, nil
+}
+
+// liveFunctions returns the IDs of the functions and function versions, which have images, and the IDs of the images
+// they use
+func (gc *ImageGC) liveFunctions() (map[string]bool, map[string]bool, error) {
+	live := make(map[string]bool)
+	inUse := make(map[string]bool)
+	var fns []*functions.Function
+	if err := gc.store.List(FunctionManagerFlags.OrgID, entitystore.Options{}, &fns); err != nil {
+		return nil, nil, errors.Wrap(err, "store error when listing functions")
+	}
+	for _, f := range fns {
+		live[f.ID] = true
+		if f.FunctionImageID != "" {
+			inUse[f.FunctionImageID] = true
+		}
+	}
+	var versions []*functions.FunctionVersion
+	if err := gc.store.List(FunctionManagerFlags.OrgID, entitystore.Options{}, &versions); err != nil {
+		return nil, nil, errors.Wrap(err, "store error when listing function versions")
+	}
+	for _, v := range versions {
+		live[v.ID] = true
+		if v.FunctionImageID != "" {
+			inUse[v.FunctionImageID] = true
+		}
+	}
+	return live, inUse, nil
+}
+
+// garbage returns the images to remove, the images in use are always kept
+func (gc *ImageGC) garbage(images []*functions.FunctionImage, live, inUse map[string]bool, now time.Time) []*CollectedImage {
+	byFunction := make(map[string][]*functions.FunctionImage)
+	for _, image := range images {
+		byFunction[image.FunctionID] = append(byFunction[image.FunctionID], image)
+	}
+	var result []*CollectedImage
+	for functionID, images := range byFunction {
+		if !live[functionID] {
+			for _, image := range images {
+				result = append(result, &CollectedImage{image, fmt.Sprintf("function %s deleted", functionID)})
+			}
+			continue
+		}
+		// the most recent image is kept, each image is superseded by the next one
+		sort.Slice(images, func(i, j int) bool { return images[i].Created.After(images[j].Created) })
+		for i := 1; i < len(images); i++ {
+			if inUse[images[i].ID] {
+				continue
+			}
+			superseded := images[i-1].Created
+			switch {
+			case i > gc.config.Keep:
+				result = append(result, &CollectedImage{images[i], fmt.Sprintf("superseded, over the %d kept images", gc.config.Keep)})
+			case gc.config.MaxAge > 0 && now.Sub(superseded) > gc.config.MaxAge:
+				result = append(result, &CollectedImage{images[i], fmt.Sprintf("superseded for more than %s", gc.config.MaxAge)})
+			}
+		}
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Created.Before(result[j].Created) })
+	return result
+}
+
+// Collect removes the images of deleted functions, and the superseded images over the retention. It returns the
+// removed images, or only reports them with dryRun. Images which cannot be removed are logged, and tried again on the
+// next collection.
+func (gc *ImageGC) Collect(dryRun bool) ([]*CollectedImage, error) {
+	defer trace.Tracef("dry run: %v", dryRun)()
+
+	gc.Lock()
+	defer gc.Unlock()
+
+	// images are listed first, so that images of functions created meanwhile are not taken for garbage
+	images, err := gc.images.ListImages()
+	if err != nil {
+		return nil, err
+	}
+	live, inUse, err := gc.liveFunctions()
+	if err != nil {
+		return nil, err
+	}
+	garbage := gc.garbage(images, live, inUse, time.Now())
+	if dryRun {
+		return garbage, nil
+	}
+	var removed []*CollectedImage
+	for _, image := range garbage {
+		if err := gc.images.RemoveImage(image.FunctionImage); err != nil {
+			log.Errorf("Error removing image %s of function %s: %+v", image.ID, image.FunctionID, err)
+			continue
+		}
+		log.Infof("Removed image %s of function %s: %s", image.ID, image.FunctionID, image.Reason)
+		removed = append(removed, image)
+	}
+	return removed, nil
+}
@@ -0,0 +1,123 @@
+///////////////////////////////////////////////////////////////////////
+// Copyright (c) 2018 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+///////////////////////////////////////////////////////////////////////
+
+package functionmanager
+
+import (
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/vmware/dispatch/pkg/entity-store"
+	"github.com/vmware/dispatch/pkg/functions"
+	fnmocks "github.com/vmware/dispatch/pkg/functions/mocks"
+	helpers "github.com/vmware/dispatch/pkg/testing/api"
+)
+
+func collectedIDs(images []*CollectedImage) []string {
+	var ids []string
+	for _, image := range images {
+		ids = append(ids, image.ID)
+	}
+	return ids
+}
+
+func TestImageGC_garbage(t *testing.T) {
+	now := time.Now()
+	images := []*functions.FunctionImage{
+		{ID: "img2", FunctionID: "fn", Created: now.Add(-2 * time.Hour)},
+		{ID: "img4", FunctionID: "fn", Created: now},
+		{ID: "img1", FunctionID: "fn", Created: now.Add(-3 * time.Hour)},
+		{ID: "img3", FunctionID: "fn", Created: now.Add(-1 * time.Hour)},
+		{ID: "old", FunctionID: "deleted", Created: now.Add(-4 * time.Hour)},
+		{ID: "only", FunctionID: "other", Created: now.Add(-4 * time.Hour)},
+	}
+	live := map[string]bool{"fn": true, "other": true}
+
+	gc := NewImageGC(&ImageGCConfig{Keep: 1}, nil, nil)
+	garbage := gc.garbage(images, live, nil, now)
+	assert.Equal(t, []string{"old", "img1", "img2"}, collectedIDs(garbage))
+	assert.Equal(t, "function deleted deleted", garbage[0].Reason)
+
+	gc = NewImageGC(&ImageGCConfig{Keep: 5, MaxAge: 90 * time.Minute}, nil, nil)
+	assert.Equal(t, []string{"old", "img1"}, collectedIDs(gc.garbage(images, live, nil, now)))
+
+	gc = NewImageGC(&ImageGCConfig{Keep: 0}, nil, nil)
+	assert.Equal(t, []string{"old", "img1", "img2", "img3"}, collectedIDs(gc.garbage(images, live, nil, now)))
+
+	// the image deployed is kept, even if a more recent image was built since, e.g. by an update which failed
+	inUse := map[string]bool{"img2": true}
+	assert.Equal(t, []string{"old", "img1", "img3"}, collectedIDs(gc.garbage(images, live, inUse, now)))
+}
+
+func TestImageGC_Collect(t *testing.T) {
+	store := helpers.MakeEntityStore(t)
+	f := &functions.Function{
+		BaseEntity: entitystore.BaseEntity{
+			OrganizationID: FunctionManagerFlags.OrgID,
+			Name:           "testFunction",
+		},
+	}
+	_, err := store.Add(f)
+	require.NoError(t, err)
+
+	now := time.Now()
+	images := []*functions.FunctionImage{
+		{ID: "current", FunctionID: f.ID, Created: now},
+		{ID: "previous", FunctionID: f.ID, Created: now.Add(-time.Hour)},
+		{ID: "deleted1", FunctionID: "deletedFunction", Created: now.Add(-2 * time.Hour)},
+		{ID: "deleted2", FunctionID: "deletedFunction", Created: now.Add(-3 * time.Hour)},
+	}
+	collector := &fnmocks.ImageCollector{}
+	collector.On("ListImages").Return(images, nil)
+	gc := NewImageGC(&ImageGCConfig{Keep: 1}, store, collector)
+
+	collected, err := gc.Collect(true)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"deleted2", "deleted1"}, collectedIDs(collected))
+	collector.AssertNotCalled(t, "RemoveImage", mock.Anything)
+
+	// the image deployed before a failed update is in use
+	f.FunctionImageID = "previous"
+	_, err = store.Update(f.Revision, f)
+	require.NoError(t, err)
+	gc = NewImageGC(&ImageGCConfig{Keep: 0}, store, collector)
+	collected, err = gc.Collect(true)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"deleted2", "deleted1"}, collectedIDs(collected))
+
+	collector.On("RemoveImage", images[2]).Return(nil)
+	collector.On("RemoveImage", images[3]).Return(errors.New("image in use"))
+	collected, err = gc.Collect(false)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"deleted1"}, collectedIDs(collected))
+	collector.AssertExpectations(t)
+}
+
+func TestImageGC_Trigger(t *testing.T) {
+	var gc *ImageGC
+	// the controller may run without garbage collection
+	gc.Trigger()
+
+	collector := &fnmocks.ImageCollector{}
+	done := make(chan struct{})
+	collector.On("ListImages").Return(nil, errors.New("docker unavailable")).Run(func(mock.Arguments) {
+		close(done)
+	}).Once()
+	gc = NewImageGC(&ImageGCConfig{}, helpers.MakeEntityStore(t), collector)
+	gc.Start()
+	defer gc.Shutdown()
+
+	gc.Trigger()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("image collection not triggered")
+	}
+}
@@ -0,0 +1,298 @@
+///////////////////////////////////////////////////////////////////////
+// Copyright (c) 2018 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+///////////////////////////////////////////////////////////////////////
+
+package functionmanager
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/vmware/dispatch/pkg/entity-store"
+	"github.com/vmware/dispatch/pkg/functions"
+	"github.com/vmware/dispatch/pkg/trace"
+	"github.com/vmware/dispatch/pkg/utils"
+)
+
+// runPrunerPageSize is the number of runs listed at once when pruning
+var runPrunerPageSize = 500
+
+// FinishedRunStatuses are the statuses of the runs which are not in progress anymore, only those runs are pruned
+var FinishedRunStatuses = []entitystore.Status{
+	entitystore.StatusREADY, entitystore.StatusERROR, entitystore.StatusTIMEDOUT,
+}
+
+// RunRetention selects the finished runs to prune
+type RunRetention struct {
+	// MaxAge is the time after which finished runs are pruned. 0 means no limit.
+	MaxAge time.Duration
+	// Keep is the number of most recent runs kept per function, even within MaxAge. 0 means no limit.
+	Keep int
+	// Statuses are the statuses of the runs to prune, all the finished ones if empty
+	Statuses []entitystore.Status
+}
+
+// Validate checks that the retention only selects finished runs
+func (r *RunRetention) Validate() error {
+	for _, status := range r.Statuses {
+		finished := false
+		for _, s := range FinishedRunStatuses {
+			finished = finished || status == s
+		}
+		if !finished {
+			return errors.Errorf("invalid run status %s, runs in progress cannot be deleted", status)
+		}
+	}
+	if r.Keep < 0 {
+		return errors.Errorf("invalid number of runs kept %d", r.Keep)
+	}
+	if r.MaxAge < 0 {
+		return errors.Errorf("invalid run max age %s", r.MaxAge)
+	}
+	return nil
+}
+
+// RunPrunerConfig is the configuration of the periodic run pruning
+type RunPrunerConfig struct {
+	// Retention selects the runs pruned periodically. Nothing is pruned without MaxAge or Keep.
+	Retention RunRetention
+	// Period is the period of the pruning
+	Period time.Duration
+}
+
+// PrunedRun is a run deleted by the pruning, and the reason why
+type PrunedRun struct {
+	*functions.FnRun
+	Reason string
+}
+
+// RunArchive saves the runs before they are pruned
+type RunArchive interface {
+	Archive(runs []*functions.FnRun) error
+}
+
+// FileRunArchive appends the runs to a file, one JSON document per line
+type FileRunArchive struct {
+	path string
+
+	sync.Mutex
+}
+
+// NewFileRunArchive creates a run archive appending to the file at path
+func NewFileRunArchive(path string) *FileRunArchive {
+	return &FileRunArchive{path: path}
+}
+
+// Archive appends the runs to the archive file
+func (a *FileRunArchive) Archive(runs []*functions.FnRun) error {
+	a.Lock()
+	defer a.Unlock()
+
+	f, err := os.OpenFile(a.path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600)
+	if err != nil {
+		return errors.Wrapf(err, "error opening run archive %s", a.path)
+	}
+	encoder := json.NewEncoder(f)
+	for _, run := range runs {
+		if err := encoder.Encode(run); err != nil {
+			f.Close()
+			return errors.Wrapf(err, "error archiving run %s to %s", run.Name, a.path)
+		}
+	}
+	return errors.Wrapf(f.Close(), "error closing run archive %s", a.path)
+}
+
+// RunPruner deletes the finished function runs over the retention, and archives them first if configured to
+type RunPruner struct {
+	config  RunPrunerConfig
+	store   entitystore.EntityStore
+	archive RunArchive
+
+	// one pruning at a time
+	sync.Mutex
+
+	// the periodic pruning runs on the leader only, it is stopped and started again as the leadership changes
+	lifecycle sync.Mutex
+	done      chan struct{}
+}
+
+// NewRunPruner creates a new run pruner, archive is optional
+func NewRunPruner(config *RunPrunerConfig, store entitystore.EntityStore, archive RunArchive) *RunPruner {
+	return &RunPruner{
+		config:  *config,
+		store:   store,
+		archive: archive,
+	}
+}
+
+// Start prunes the runs periodically with the configured retention, until Shutdown. It does nothing if started
+// already.
+func (p *RunPruner) Start() {
+	retention := p.config.Retention
+	if p.config.Period <= 0 || (retention.MaxAge == 0 && retention.Keep == 0) {
+		log.Infof("Periodic function run pruning disabled")
+		return
+	}
+	p.lifecycle.Lock()
+	defer p.lifecycle.Unlock()
+	if p.done != nil {
+		return
+	}
+	done := make(chan struct{})
+	p.done = done
+	go func() {
+		ticker := time.NewTicker(p.config.Period)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+			}
+			if _, err := p.Prune(&retention, nil, false); err != nil {
+				log.Errorf("Error pruning function runs: %+v", err)
+			}
+		}
+	}()
+}
+
+// Shutdown stops the periodic pruning. It does nothing if not started.
+func (p *RunPruner) Shutdown() {
+	p.lifecycle.Lock()
+	defer p.lifecycle.Unlock()
+	if p.done != nil {
+		close(p.done)
+		p.done = nil
+	}
+}
+
+// garbage returns the runs to prune. listed is the number of more recent runs of each function, listed before runs,
+// it is updated with runs.
+func (p *RunPruner) garbage(runs []*functions.FnRun, listed map[string]int, retention *RunRetention, now time.Time) []*PrunedRun {
+	byFunction := make(map[string][]*functions.FnRun)
+	for _, run := range runs {
+		byFunction[run.FunctionName] = append(byFunction[run.FunctionName], run)
+	}
+	var result []*PrunedRun
+	for function, runs := range byFunction {
+		sort.Slice(runs, func(i, j int) bool { return runs[i].CreatedTime.After(runs[j].CreatedTime) })
+		offset := listed[function]
+		listed[function] += len(runs)
+		for i, run := range runs {
+			i += offset
+			finished := run.FinishedTime
+			if finished.IsZero() {
+				finished = run.CreatedTime
+			}
+			switch {
+			case retention.Keep == 0 && retention.MaxAge == 0:
+				result = append(result, &PrunedRun{run, "deleted"})
+			case retention.Keep > 0 && i >= retention.Keep:
+				result = append(result, &PrunedRun{run, fmt.Sprintf("over the %d kept runs", retention.Keep)})
+			case retention.MaxAge > 0 && now.Sub(finished) > retention.MaxAge:
+				result = append(result, &PrunedRun{run, fmt.Sprintf("finished for more than %s", retention.MaxAge)})
+			}
+		}
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].CreatedTime.Before(result[j].CreatedTime) })
+	return result
+}
+
+// Prune deletes the finished runs selected by filter over the retention, or all of them if the retention has neither
+// MaxAge nor Keep. It returns the deleted runs, or only reports them with dryRun. The runs are archived before they
+// are deleted, runs which cannot be archived are not deleted. Runs are listed and pruned page by page.
+func (p *RunPruner) Prune(retention *RunRetention, filter entitystore.Filter, dryRun bool) ([]*PrunedRun, error) {
+	defer trace.Tracef("dry run: %v", dryRun)()
+
+	if err := retention.Validate(); err != nil {
+		return nil, err
+	}
+	statuses := retention.Statuses
+	if len(statuses) == 0 {
+		statuses = FinishedRunStatuses
+	}
+	if filter == nil {
+		filter = entitystore.FilterEverything()
+	}
+	filter.Add(entitystore.FilterStat{
+		Scope:   entitystore.FilterScopeField,
+		Subject: "Status",
+		Verb:    entitystore.FilterVerbIn,
+		Object:  statuses,
+	})
+
+	now := time.Now()
+	opts := entitystore.Options{
+		Filter: filter,
+		Limit:  runPrunerPageSize,
+		// the runs of a function are listed together, the most recent first, so that the kept ones span pages
+		SortBy: []entitystore.SortKey{
+			{Scope: entitystore.FilterScopeExtra, Subject: "FunctionName"},
+			{Scope: entitystore.FilterScopeField, Subject: "CreatedTime", Descending: true},
+		},
+	}
+
+	p.Lock()
+	defer p.Unlock()
+
+	var pruned []*PrunedRun
+	listed := make(map[string]int)
+	for {
+		var runs []*functions.FnRun
+		next, err := utils.ListPage(p.store, FunctionManagerFlags.OrgID, opts, &runs)
+		if err != nil {
+			return nil, errors.Wrap(err, "store error when listing function runs")
+		}
+		garbage := p.garbage(runs, listed, retention, now)
+		if !dryRun {
+			if garbage, err = p.delete(garbage); err != nil {
+				return nil, err
+			}
+		}
+		pruned = append(pruned, garbage...)
+		if next == "" {
+			break
+		}
+		// the following pages are listed after the last run of this one, the deleted runs do not shift them
+		opts.Continue = next
+	}
+	sort.Slice(pruned, func(i, j int) bool { return pruned[i].CreatedTime.Before(pruned[j].CreatedTime) })
+	if !dryRun && len(pruned) > 0 {
+		log.Infof("Pruned %d function runs", len(pruned))
+	}
+	return pruned, nil
+}
+
+// delete archives and deletes runs, it returns the deleted ones
+func (p *RunPruner) delete(garbage []*PrunedRun) ([]*PrunedRun, error) {
+	if len(garbage) == 0 {
+		return nil, nil
+	}
+	if p.archive != nil {
+		archived := make([]*functions.FnRun, 0, len(garbage))
+		for _, run := range garbage {
+			archived = append(archived, run.FnRun)
+		}
+		if err := p.archive.Archive(archived); err != nil {
+			return nil, err
+		}
+	}
+	var deleted []*PrunedRun
+	for _, run := range garbage {
+		if err := p.store.Delete(run.OrganizationID, run.Name, run.FnRun); err != nil {
+			log.Errorf("Error deleting run %s of function %s: %+v", run.Name, run.FunctionName, err)
+			continue
+		}
+		log.Debugf("Deleted run %s of function %s: %s", run.Name, run.FunctionName, run.Reason)
+		deleted = append(deleted, run)
+	}
+	return deleted, nil
+}
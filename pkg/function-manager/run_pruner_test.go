@@ -0,0 +1,177 @@
+///////////////////////////////////////////////////////////////////////
+// Copyright (c) 2018 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+///////////////////////////////////////////////////////////////////////
+
+package functionmanager
+
+import (
+	"bufio"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/vmware/dispatch/pkg/entity-store"
+	"github.com/vmware/dispatch/pkg/functions"
+	helpers "github.com/vmware/dispatch/pkg/testing/api"
+)
+
+type failingRunArchive struct{}
+
+func (failingRunArchive) Archive(runs []*functions.FnRun) error {
+	return errors.New("disk full")
+}
+
+func prunedNames(runs []*PrunedRun) []string {
+	var names []string
+	for _, run := range runs {
+		names = append(names, run.Name)
+	}
+	return names
+}
+
+func makeFinishedRun(name, function string, status entitystore.Status, created time.Time) *functions.FnRun {
+	return &functions.FnRun{
+		BaseEntity: entitystore.BaseEntity{
+			OrganizationID: FunctionManagerFlags.OrgID,
+			Name:           name,
+			Status:         status,
+			CreatedTime:    created,
+		},
+		FunctionName: function,
+		FinishedTime: created.Add(time.Minute),
+	}
+}
+
+func TestRunRetention_Validate(t *testing.T) {
+	assert.NoError(t, (&RunRetention{}).Validate())
+	assert.NoError(t, (&RunRetention{Keep: 2, Statuses: []entitystore.Status{entitystore.StatusERROR}}).Validate())
+	assert.Error(t, (&RunRetention{Statuses: []entitystore.Status{entitystore.StatusINITIALIZED}}).Validate())
+	assert.Error(t, (&RunRetention{Keep: -1}).Validate())
+	assert.Error(t, (&RunRetention{MaxAge: -time.Hour}).Validate())
+}
+
+func TestRunPruner_garbage(t *testing.T) {
+	now := time.Now()
+	runs := []*functions.FnRun{
+		makeFinishedRun("run2", "fn", entitystore.StatusREADY, now.Add(-2*time.Hour)),
+		makeFinishedRun("run4", "fn", entitystore.StatusREADY, now),
+		makeFinishedRun("run1", "fn", entitystore.StatusERROR, now.Add(-3*time.Hour)),
+		makeFinishedRun("run3", "fn", entitystore.StatusREADY, now.Add(-1*time.Hour)),
+		makeFinishedRun("other", "other", entitystore.StatusREADY, now.Add(-4*time.Hour)),
+	}
+	p := NewRunPruner(&RunPrunerConfig{}, nil, nil)
+
+	garbage := p.garbage(runs, map[string]int{}, &RunRetention{Keep: 2}, now)
+	assert.Equal(t, []string{"run1", "run2"}, prunedNames(garbage))
+	assert.Equal(t, "over the 2 kept runs", garbage[0].Reason)
+
+	garbage = p.garbage(runs, map[string]int{}, &RunRetention{MaxAge: 90 * time.Minute}, now)
+	assert.Equal(t, []string{"other", "run1", "run2"}, prunedNames(garbage))
+
+	garbage = p.garbage(runs, map[string]int{}, &RunRetention{Keep: 3, MaxAge: 150 * time.Minute}, now)
+	assert.Equal(t, []string{"other", "run1"}, prunedNames(garbage))
+
+	// without limits, all the runs are pruned
+	assert.Len(t, p.garbage(runs, map[string]int{}, &RunRetention{}, now), 5)
+
+	// runs of fn were listed in a previous page
+	garbage = p.garbage(runs, map[string]int{"fn": 1}, &RunRetention{Keep: 2}, now)
+	assert.Equal(t, []string{"run1", "run2", "run3"}, prunedNames(garbage))
+}
+
+func TestRunPruner_PrunePages(t *testing.T) {
+	defer func(size int) { runPrunerPageSize = size }(runPrunerPageSize)
+	runPrunerPageSize = 2
+
+	store := helpers.MakeEntityStore(t)
+	now := time.Now()
+	for i, name := range []string{"run1", "run2", "run3", "run4", "run5"} {
+		_, err := store.Add(makeFinishedRun(name, "fn", entitystore.StatusREADY, now.Add(time.Duration(i-5)*time.Hour)))
+		require.NoError(t, err)
+	}
+	_, err := store.Add(makeFinishedRun("other", "other", entitystore.StatusREADY, now.Add(-time.Hour)))
+	require.NoError(t, err)
+	p := NewRunPruner(&RunPrunerConfig{}, store, nil)
+
+	pruned, err := p.Prune(&RunRetention{Keep: 3}, nil, false)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"run1", "run2"}, prunedNames(pruned))
+
+	pruned, err = p.Prune(&RunRetention{MaxAge: 150 * time.Minute}, nil, false)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"run3"}, prunedNames(pruned))
+
+	var left []*functions.FnRun
+	require.NoError(t, store.List(FunctionManagerFlags.OrgID, entitystore.Options{}, &left))
+	assert.Len(t, left, 3)
+}
+
+func TestRunPruner_Prune(t *testing.T) {
+	store := helpers.MakeEntityStore(t)
+	now := time.Now()
+	runs := []*functions.FnRun{
+		makeFinishedRun("old", "fn", entitystore.StatusREADY, now.Add(-3*time.Hour)),
+		makeFinishedRun("failed", "fn", entitystore.StatusERROR, now.Add(-2*time.Hour)),
+		makeFinishedRun("running", "fn", entitystore.StatusCREATING, now.Add(-2*time.Hour)),
+		makeFinishedRun("recent", "fn", entitystore.StatusREADY, now),
+	}
+	for _, run := range runs {
+		_, err := store.Add(run)
+		require.NoError(t, err)
+	}
+
+	dir, err := ioutil.TempDir("", "run-archive")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	archivePath := filepath.Join(dir, "runs.json")
+
+	p := NewRunPruner(&RunPrunerConfig{}, store, NewFileRunArchive(archivePath))
+	retention := &RunRetention{MaxAge: time.Hour}
+
+	pruned, err := p.Prune(retention, nil, true)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"old", "failed"}, prunedNames(pruned))
+	_, err = os.Stat(archivePath)
+	assert.True(t, os.IsNotExist(err))
+
+	// archiving fails, nothing is deleted
+	failing := NewRunPruner(&RunPrunerConfig{}, store, failingRunArchive{})
+	_, err = failing.Prune(retention, nil, false)
+	assert.Error(t, err)
+
+	pruned, err = p.Prune(&RunRetention{MaxAge: time.Hour, Statuses: []entitystore.Status{entitystore.StatusERROR}}, nil, false)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"failed"}, prunedNames(pruned))
+
+	pruned, err = p.Prune(retention, nil, false)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"old"}, prunedNames(pruned))
+
+	var left []*functions.FnRun
+	require.NoError(t, store.List(FunctionManagerFlags.OrgID, entitystore.Options{}, &left))
+	names := make(map[string]bool)
+	for _, run := range left {
+		names[run.Name] = true
+	}
+	assert.Equal(t, map[string]bool{"running": true, "recent": true}, names)
+
+	f, err := os.Open(archivePath)
+	require.NoError(t, err)
+	defer f.Close()
+	var archived []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var run functions.FnRun
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &run))
+		archived = append(archived, run.Name)
+	}
+	assert.Equal(t, []string{"failed", "old"}, archived)
+}
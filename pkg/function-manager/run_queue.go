@@ -0,0 +1,256 @@
+///////////////////////////////////////////////////////////////////////
+// Copyright (c) 2018 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+///////////////////////////////////////////////////////////////////////
+
+package functionmanager
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/vmware/dispatch/pkg/controller"
+	"github.com/vmware/dispatch/pkg/entity-store"
+	"github.com/vmware/dispatch/pkg/functions"
+	"github.com/vmware/dispatch/pkg/trace"
+)
+
+// ErrRunQueueFull is returned when a run cannot be queued, as too many runs are waiting already
+var ErrRunQueueFull = errors.New("too many function runs queued")
+
+var errRunCancelled = errors.New("function run cancelled")
+
+// RunQueueConfig is the run queue configuration. Limits lower than 1 mean no limit.
+type RunQueueConfig struct {
+	// MaxRuns is the maximum number of runs executed at the same time
+	MaxRuns int
+	// MaxRunsPerFunction is the maximum number of runs of a single function executed at the same time
+	MaxRunsPerFunction int
+	// QueueSize is the maximum number of runs waiting for execution
+	QueueSize int
+}
+
+// RunQueue limits the number of function runs executed at the same time. Runs over the limits wait in the queue.
+// The queue itself is kept in the entity store: runs waiting for execution are in INITIALIZED status, so that they
+// are resumed after a restart.
+type RunQueue struct {
+	config  RunQueueConfig
+	store   entitystore.EntityStore
+	watcher controller.Watcher
+
+	sync.Mutex
+	pending     []*functions.FnRun
+	running     map[string]*runningRun
+	perFunction map[string]int
+	// resumed are the pending runs queued by Resume, rather than pushed by the requests to this replica
+	resumed map[string]bool
+}
+
+// runningRun tracks a run handed over to the controller
+type runningRun struct {
+	function  string
+	cancel    context.CancelFunc
+	cancelled bool
+}
+
+// NewRunQueue creates a new run queue
+func NewRunQueue(config *RunQueueConfig, store entitystore.EntityStore) *RunQueue {
+	return &RunQueue{
+		config:      *config,
+		store:       store,
+		running:     map[string]*runningRun{},
+		perFunction: map[string]int{},
+		resumed:     map[string]bool{},
+	}
+}
+
+// Push queues a run, which must be stored already. It returns ErrRunQueueFull if the run can neither be executed nor
+// wait for execution.
+func (q *RunQueue) Push(run *functions.FnRun) error {
+	defer trace.Tracef("run %s", run.Name)()
+
+	q.Lock()
+	if q.config.QueueSize > 0 && len(q.pending) >= q.config.QueueSize && !q.canRun(run) {
+		q.Unlock()
+		return ErrRunQueueFull
+	}
+	q.pending = append(q.pending, run)
+	q.Unlock()
+
+	q.dispatch()
+	return nil
+}
+
+// Done releases the execution slot of a run
+func (q *RunQueue) Done(run *functions.FnRun) {
+	defer trace.Tracef("run %s", run.Name)()
+
+	q.Lock()
+	r, ok := q.running[run.Name]
+	if ok {
+		delete(q.running, run.Name)
+		q.perFunction[r.function]--
+		if q.perFunction[r.function] <= 0 {
+			delete(q.perFunction, r.function)
+		}
+	}
+	q.Unlock()
+
+	if ok {
+		q.dispatch()
+	}
+}
+
+// Context returns the context of a run handed over to the controller, which is cancelled by Cancel
+func (q *RunQueue) Context(run *functions.FnRun) (context.Context, context.CancelFunc) {
+	defer trace.Tracef("run %s", run.Name)()
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	q.Lock()
+	defer q.Unlock()
+	if r, ok := q.running[run.Name]; ok {
+		r.cancel = cancel
+		if r.cancelled {
+			cancel()
+		}
+	}
+	return ctx, cancel
+}
+
+// Cancel cancels a run. A run waiting in the queue is removed from it and returned, so that the caller can record the
+// cancellation. A running run is cancelled through its context. It returns false if the run is not in the queue.
+func (q *RunQueue) Cancel(name string) (*functions.FnRun, bool) {
+	defer trace.Tracef("run %s", name)()
+
+	q.Lock()
+	defer q.Unlock()
+	for i, run := range q.pending {
+		if run.Name == name {
+			q.pending = append(q.pending[:i], q.pending[i+1:]...)
+			delete(q.resumed, name)
+			return run, true
+		}
+	}
+	r, ok := q.running[name]
+	if !ok {
+		return nil, false
+	}
+	// the run may not have asked for its context yet
+	r.cancelled = true
+	if r.cancel != nil {
+		r.cancel()
+	}
+	return nil, true
+}
+
+// Resume queues the runs waiting for execution, e.g. when function manager stopped or when the previous leader
+// stepped down. Runs queued already are skipped.
+func (q *RunQueue) Resume(organizationID string) error {
+	defer trace.Trace("")()
+
+	opts := entitystore.Options{
+		Filter: entitystore.FilterEverything().Add(
+			entitystore.FilterStat{
+				Scope:   entitystore.FilterScopeField,
+				Subject: "Status",
+				Verb:    entitystore.FilterVerbEqual,
+				Object:  entitystore.StatusINITIALIZED,
+			}),
+	}
+	var runs []*functions.FnRun
+	if err := q.store.List(organizationID, opts, &runs); err != nil {
+		return errors.Wrap(err, "store error when listing queued runs")
+	}
+	sort.Slice(runs, func(i, j int) bool {
+		return runs[i].CreatedTime.Before(runs[j].CreatedTime)
+	})
+
+	// The runs were accepted already, so they are queued regardless of the queue size
+	q.Lock()
+	queued := make(map[string]bool, len(q.pending))
+	for _, run := range q.pending {
+		queued[run.Name] = true
+	}
+	resumed := 0
+	for _, run := range runs {
+		if _, running := q.running[run.Name]; running || queued[run.Name] {
+			continue
+		}
+		q.pending = append(q.pending, run)
+		q.resumed[run.Name] = true
+		resumed++
+	}
+	q.Unlock()
+	if resumed == 0 {
+		return nil
+	}
+	log.Infof("resuming %d queued function runs", resumed)
+
+	q.dispatch()
+	return nil
+}
+
+// Suspend drops the resumed runs waiting for execution, when this replica stops leading. They stay INITIALIZED in the
+// store, so that the next leader resumes them. The runs pushed by the requests to this replica stay queued, their
+// callers may be waiting for them.
+func (q *RunQueue) Suspend() {
+	defer trace.Trace("")()
+
+	q.Lock()
+	var dropped []*functions.FnRun
+	pending := q.pending[:0]
+	for _, run := range q.pending {
+		if q.resumed[run.Name] {
+			dropped = append(dropped, run)
+			delete(q.resumed, run.Name)
+			continue
+		}
+		pending = append(pending, run)
+	}
+	q.pending = pending
+	q.Unlock()
+
+	if len(dropped) > 0 {
+		log.Infof("suspending %d resumed function runs", len(dropped))
+	}
+	for _, run := range dropped {
+		run.Done()
+	}
+}
+
+// canRun checks whether there is an execution slot for the run. Must be called with the lock held.
+func (q *RunQueue) canRun(run *functions.FnRun) bool {
+	if q.config.MaxRuns > 0 && len(q.running) >= q.config.MaxRuns {
+		return false
+	}
+	return q.config.MaxRunsPerFunction <= 0 || q.perFunction[run.FunctionName] < q.config.MaxRunsPerFunction
+}
+
+// dispatch hands over runs, for which there are execution slots, to the controller in the order they were queued
+func (q *RunQueue) dispatch() {
+	q.Lock()
+	var ready []*functions.FnRun
+	pending := q.pending[:0]
+	for _, run := range q.pending {
+		if !q.canRun(run) {
+			pending = append(pending, run)
+			continue
+		}
+		q.running[run.Name] = &runningRun{function: run.FunctionName}
+		q.perFunction[run.FunctionName]++
+		delete(q.resumed, run.Name)
+		ready = append(ready, run)
+	}
+	q.pending = pending
+	q.Unlock()
+
+	// the watcher blocks until the controller picks the run up, so it is not called with the lock held
+	for _, run := range ready {
+		q.watcher.OnAction(run)
+	}
+}
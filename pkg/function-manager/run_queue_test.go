@@ -0,0 +1,149 @@
+///////////////////////////////////////////////////////////////////////
+// Copyright (c) 2018 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+///////////////////////////////////////////////////////////////////////
+
+package functionmanager
+
+import (
+	"fmt"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/vmware/dispatch/pkg/entity-store"
+	"github.com/vmware/dispatch/pkg/functions"
+	helpers "github.com/vmware/dispatch/pkg/testing/api"
+)
+
+func makeRun(name, function string) *functions.FnRun {
+	return &functions.FnRun{
+		BaseEntity: entitystore.BaseEntity{
+			OrganizationID: FunctionManagerFlags.OrgID,
+			Name:           name,
+			Status:         entitystore.StatusINITIALIZED,
+		},
+		FunctionName: function,
+	}
+}
+
+func dispatched(watcher chan entitystore.Entity) []string {
+	var names []string
+	for len(watcher) > 0 {
+		names = append(names, (<-watcher).GetName())
+	}
+	return names
+}
+
+func TestRunQueueLimits(t *testing.T) {
+	watcher := make(chan entitystore.Entity, 10)
+	queue := NewRunQueue(&RunQueueConfig{MaxRuns: 2, MaxRunsPerFunction: 1, QueueSize: 2}, helpers.MakeEntityStore(t))
+	queue.watcher = watcher
+
+	a1, a2, b1, c1, d1 := makeRun("a1", "a"), makeRun("a2", "a"), makeRun("b1", "b"), makeRun("c1", "c"), makeRun("d1", "d")
+	require.NoError(t, queue.Push(a1))
+	require.NoError(t, queue.Push(a2))
+	assert.Equal(t, []string{"a1"}, dispatched(watcher))
+
+	require.NoError(t, queue.Push(b1))
+	assert.Equal(t, []string{"b1"}, dispatched(watcher))
+
+	require.NoError(t, queue.Push(c1))
+	assert.Equal(t, ErrRunQueueFull, queue.Push(d1))
+	assert.Empty(t, dispatched(watcher))
+
+	// a2 waits for a1, c1 is next in line
+	queue.Done(b1)
+	assert.Equal(t, []string{"c1"}, dispatched(watcher))
+	queue.Done(a1)
+	assert.Equal(t, []string{"a2"}, dispatched(watcher))
+
+	// runs which are not running are ignored
+	queue.Done(d1)
+	assert.Empty(t, dispatched(watcher))
+}
+
+func TestRunQueueUnlimited(t *testing.T) {
+	watcher := make(chan entitystore.Entity, 10)
+	queue := NewRunQueue(&RunQueueConfig{}, helpers.MakeEntityStore(t))
+	queue.watcher = watcher
+
+	for i := 0; i < 10; i++ {
+		require.NoError(t, queue.Push(makeRun(fmt.Sprintf("run%d", i), "a")))
+	}
+	assert.Len(t, dispatched(watcher), 10)
+}
+
+func TestRunQueueResume(t *testing.T) {
+	store := helpers.MakeEntityStore(t)
+	watcher := make(chan entitystore.Entity, 10)
+	queue := NewRunQueue(&RunQueueConfig{MaxRuns: 1, QueueSize: 1}, store)
+	queue.watcher = watcher
+
+	for _, name := range []string{"run1", "run2", "run3"} {
+		_, err := store.Add(makeRun(name, "a"))
+		require.NoError(t, err)
+	}
+	finished := makeRun("finished", "a")
+	finished.Status = entitystore.StatusREADY
+	_, err := store.Add(finished)
+	require.NoError(t, err)
+
+	require.NoError(t, queue.Resume(FunctionManagerFlags.OrgID))
+	names := dispatched(watcher)
+	require.Len(t, names, 1)
+
+	// resumed runs are queued regardless of the queue size
+	queue.Done(makeRun(names[0], "a"))
+	names = append(names, dispatched(watcher)...)
+	queue.Done(makeRun(names[1], "a"))
+	names = append(names, dispatched(watcher)...)
+	sort.Strings(names)
+	assert.Equal(t, []string{"run1", "run2", "run3"}, names)
+}
+
+func TestRunQueueSuspend(t *testing.T) {
+	store := helpers.MakeEntityStore(t)
+	watcher := make(chan entitystore.Entity, 10)
+	queue := NewRunQueue(&RunQueueConfig{MaxRuns: 1}, store)
+	queue.watcher = watcher
+
+	for _, name := range []string{"resumed1", "resumed2"} {
+		_, err := store.Add(makeRun(name, "a"))
+		require.NoError(t, err)
+	}
+	require.NoError(t, queue.Resume(FunctionManagerFlags.OrgID))
+	assert.Equal(t, []string{"resumed1"}, dispatched(watcher))
+
+	// runs queued or running already are not resumed again
+	require.NoError(t, queue.Resume(FunctionManagerFlags.OrgID))
+	assert.Empty(t, dispatched(watcher))
+
+	pushed := makeRun("pushed", "a")
+	pushed.Blocking = true
+	pushed.WaitChan = make(chan struct{})
+	_, err := store.Add(pushed)
+	require.NoError(t, err)
+	require.NoError(t, queue.Push(pushed))
+	assert.Empty(t, dispatched(watcher))
+
+	// the resumed runs are left to the next leader, the pushed run is still executed by this replica
+	queue.Suspend()
+	queue.Done(makeRun("resumed1", "a"))
+	assert.Equal(t, []string{"pushed"}, dispatched(watcher))
+
+	returned := make(chan struct{})
+	go func() {
+		pushed.Wait()
+		close(returned)
+	}()
+	pushed.Done()
+	select {
+	case <-returned:
+	case <-time.After(time.Second):
+		t.Fatal("the blocking run did not return")
+	}
+}
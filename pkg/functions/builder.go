@@ -22,6 +22,12 @@ import (
 	"github.com/vmware/dispatch/pkg/trace"
 )
 
+// Labels of the function images, by which they are collected
+const (
+	LabelFunctionID = "io.dispatch.function-id"
+	LabelFaaS       = "io.dispatch.faas"
+)
+
 // DockerImageBuilder builds function images
 type DockerImageBuilder struct {
 	imageRegistry       string
@@ -64,7 +70,8 @@ func (ib *DockerImageBuilder) BuildImage(faas, fnID string, exec *Exec) (string,
 		return "", errors.Wrap(err, "failed to write dockerfile")
 	}
 
-	err = images.BuildAndPushFromDir(ib.docker, tmpDir, name, ib.registryAuth)
+	labels := map[string]string{LabelFunctionID: fnID, LabelFaaS: faas}
+	err = images.BuildAndPushFromDir(ib.docker, tmpDir, name, ib.registryAuth, labels)
 	return name, err
 }
 
@@ -16,8 +16,9 @@ import (
 
 // Function context constants
 const (
-	LogsKey  = "logs"
-	EventKey = "event"
+	LogsKey        = "logs"
+	EventKey       = "event"
+	HTTPRequestKey = "httpRequest"
 )
 
 // Logs returns the logs as a list of strings
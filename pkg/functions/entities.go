@@ -8,6 +8,9 @@ package functions
 // NO TESTS
 
 import (
+	"fmt"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/go-openapi/spec"
@@ -25,6 +28,125 @@ type Function struct {
 	ImageName string   `json:"image"`
 	Schema    *Schema  `json:"schema,omitempty"`
 	Secrets   []string `json:"secrets,omitempty"`
+	// Timeout is the default run timeout in seconds, 0 means no timeout
+	Timeout int64 `json:"timeout,omitempty"`
+	// Environment are the environment variables of the function
+	Environment map[string]string `json:"environment,omitempty"`
+	Limits      *Limits           `json:"limits,omitempty"`
+	Scaling     *Scaling          `json:"scaling,omitempty"`
+	// LatestVersion is the number of the last published version, 0 if none
+	LatestVersion int64 `json:"latestVersion,omitempty"`
+	// Aliases are the named references to versions, by name
+	Aliases map[string]*Alias `json:"aliases,omitempty"`
+	// FunctionImageID is the ID of the image built for the function and deployed in the FaaS
+	FunctionImageID string `json:"functionImageID,omitempty"`
+}
+
+// FunctionVersion is an immutable version of a function, published from its code at the time. Each version is
+// deployed as a FaaS function of its own.
+type FunctionVersion struct {
+	entitystore.BaseEntity
+	FunctionName string   `json:"functionName"`
+	Number       int64    `json:"number"`
+	Code         string   `json:"code"`
+	Main         string   `json:"main"`
+	ImageName    string   `json:"image"`
+	Schema       *Schema  `json:"schema,omitempty"`
+	Secrets      []string `json:"secrets,omitempty"`
+	Timeout      int64    `json:"timeout,omitempty"`
+
+	Environment map[string]string `json:"environment,omitempty"`
+	Limits      *Limits           `json:"limits,omitempty"`
+	Scaling     *Scaling          `json:"scaling,omitempty"`
+	// FunctionImageID is the ID of the image built for the version and deployed in the FaaS
+	FunctionImageID string `json:"functionImageID,omitempty"`
+}
+
+// Function returns the function of the version, as deployed in the FaaS
+func (v *FunctionVersion) Function() *Function {
+	f := &Function{
+		BaseEntity: v.BaseEntity,
+		Code:       v.Code,
+		Main:       v.Main,
+		ImageName:  v.ImageName,
+		Schema:     v.Schema,
+		Secrets:    v.Secrets,
+		Timeout:    v.Timeout,
+
+		Environment: v.Environment,
+		Limits:      v.Limits,
+		Scaling:     v.Scaling,
+
+		FunctionImageID: v.FunctionImageID,
+	}
+	f.Name = v.FunctionName
+	return f
+}
+
+// Limits are the resource limits of each instance of a function, in the Kubernetes quantity format, e.g. 128Mi
+type Limits struct {
+	Memory string `json:"memory,omitempty"`
+	CPU    string `json:"cpu,omitempty"`
+}
+
+// Scaling are the scaling settings of a function, 0 means the default of the FaaS
+type Scaling struct {
+	MinReplicas int64 `json:"minReplicas,omitempty"`
+	MaxReplicas int64 `json:"maxReplicas,omitempty"`
+	// Concurrency is the maximum number of runs executed at the same time by an instance
+	Concurrency int64 `json:"concurrency,omitempty"`
+}
+
+// Alias routes the runs of a function to its versions, in proportion to their weights
+type Alias struct {
+	Routes []Route `json:"routes"`
+	// PreviousRoutes are the routes before the alias was last moved
+	PreviousRoutes []Route `json:"previousRoutes,omitempty"`
+}
+
+// Route is a version of a function, and the weight of the runs routed to it
+type Route struct {
+	Version int64 `json:"version"`
+	Weight  int64 `json:"weight"`
+}
+
+// PickVersion returns the version of a run, n is a random number in [0, total weight)
+func (a *Alias) PickVersion(n int64) int64 {
+	for _, r := range a.Routes {
+		if n < r.Weight {
+			return r.Version
+		}
+		n -= r.Weight
+	}
+	return a.Routes[len(a.Routes)-1].Version
+}
+
+// TotalWeight returns the sum of the weights of the routes
+func (a *Alias) TotalWeight() int64 {
+	var total int64
+	for _, r := range a.Routes {
+		total += r.Weight
+	}
+	return total
+}
+
+// VersionName returns the entity name of a version of a function. Entity names cannot contain ':', versions are
+// stored as e.g. hello-v2, which does not clash with function names as versions are a separate entity type.
+func VersionName(functionName string, version int64) string {
+	return fmt.Sprintf("%s-v%d", functionName, version)
+}
+
+// ParseFunctionName splits a function name qualified by a version or an alias, e.g. hello:2 or hello:prod. The
+// version is 0 if the name is not qualified by a version.
+func ParseFunctionName(name string) (functionName string, version int64, alias string) {
+	parts := strings.SplitN(name, ":", 2)
+	if len(parts) == 1 {
+		return name, 0, ""
+	}
+	if v, err := strconv.ParseInt(parts[1], 10, 64); err == nil {
+		return parts[0], v, ""
+	}
+	return parts[0], 0, parts[1]
 }
 
 // Schema struct stores input and output validation schemas
@@ -33,18 +155,34 @@ type Schema struct {
 	Out *spec.Schema `json:"out,omitempty"`
 }
 
+// HTTPRequest is the HTTP request of an API, which triggered a function run
+type HTTPRequest struct {
+	Headers    map[string][]string `json:"headers,omitempty"`
+	Host       string              `json:"host,omitempty"`
+	Method     string              `json:"method,omitempty"`
+	Params     map[string]string   `json:"params,omitempty"`
+	Path       string              `json:"path,omitempty"`
+	Query      map[string][]string `json:"query,omitempty"`
+	RemoteAddr string              `json:"remoteAddr,omitempty"`
+}
+
 // FnRun struct represents single function run
 type FnRun struct {
 	entitystore.BaseEntity
-	FunctionName string             `json:"functionName"`
-	FunctionID   string             `json:"functionID"`
-	Blocking     bool               `json:"blocking"`
-	Input        interface{}        `json:"input,omitempty"`
-	Output       interface{}        `json:"output,omitempty"`
-	Secrets      []string           `json:"secrets,omitempty"`
-	Event        *events.CloudEvent `json:"event,omitempty"`
-	Logs         []string           `json:"logs,omitempty"`
-	FinishedTime time.Time          `json:"finishedTime,omitempty"`
+	FunctionName    string             `json:"functionName"`
+	FunctionID      string             `json:"functionID"`
+	FunctionVersion int64              `json:"functionVersion,omitempty"`
+	Blocking        bool               `json:"blocking"`
+	Timeout         int64              `json:"timeout,omitempty"`
+	Input           interface{}        `json:"input,omitempty"`
+	Output          interface{}        `json:"output,omitempty"`
+	Secrets         []string           `json:"secrets,omitempty"`
+	Event           *events.CloudEvent `json:"event,omitempty"`
+	HTTPRequest     *HTTPRequest       `json:"httpRequest,omitempty"`
+	Logs            []string           `json:"logs,omitempty"`
+	FinishedTime    time.Time          `json:"finishedTime,omitempty"`
+	// Cancelled records the cancellation of the run, which may be executed by another replica
+	Cancelled bool `json:"cancelled,omitempty"`
 
 	WaitChan chan struct{} `json:"-"`
 }
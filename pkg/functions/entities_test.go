@@ -7,6 +7,8 @@ package functions
 
 import (
 	"testing"
+
+	"github.com/stretchr/testify/assert"
 )
 
 func TestFnRun_doneNoPanicByDefault(t *testing.T) {
@@ -24,3 +26,29 @@ func TestFnRun_waitDone(t *testing.T) {
 	}()
 	f.Wait()
 }
+
+func TestParseFunctionName(t *testing.T) {
+	name, version, alias := ParseFunctionName("hello")
+	assert.Equal(t, "hello", name)
+	assert.EqualValues(t, 0, version)
+	assert.Empty(t, alias)
+
+	name, version, alias = ParseFunctionName("hello:2")
+	assert.Equal(t, "hello", name)
+	assert.EqualValues(t, 2, version)
+	assert.Empty(t, alias)
+
+	name, version, alias = ParseFunctionName("hello:prod")
+	assert.Equal(t, "hello", name)
+	assert.EqualValues(t, 0, version)
+	assert.Equal(t, "prod", alias)
+}
+
+func TestAlias_PickVersion(t *testing.T) {
+	a := &Alias{Routes: []Route{{Version: 1, Weight: 90}, {Version: 2, Weight: 0}, {Version: 3, Weight: 10}}}
+	assert.EqualValues(t, 100, a.TotalWeight())
+	assert.EqualValues(t, 1, a.PickVersion(0))
+	assert.EqualValues(t, 1, a.PickVersion(89))
+	assert.EqualValues(t, 3, a.PickVersion(90))
+	assert.EqualValues(t, 3, a.PickVersion(99))
+}
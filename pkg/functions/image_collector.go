@@ -0,0 +1,90 @@
+///////////////////////////////////////////////////////////////////////
+// Copyright (c) 2018 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+///////////////////////////////////////////////////////////////////////
+
+package functions
+
+// NO TESTS
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/filters"
+	docker "github.com/docker/docker/client"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/vmware/dispatch/pkg/images"
+	"github.com/vmware/dispatch/pkg/trace"
+)
+
+// DockerImageCollector collects the function images of the local docker daemon, where they are built, by their labels
+type DockerImageCollector struct {
+	registryAuth     string
+	registryInsecure bool
+
+	docker     docker.ImageAPIClient
+	httpClient *http.Client
+}
+
+// NewDockerImageCollector is the constructor for the DockerImageCollector
+func NewDockerImageCollector(registryAuth string, registryInsecure bool, docker docker.ImageAPIClient) *DockerImageCollector {
+	return &DockerImageCollector{
+		registryAuth:     registryAuth,
+		registryInsecure: registryInsecure,
+		docker:           docker,
+		httpClient:       &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// ListImages returns the images built for functions
+func (c *DockerImageCollector) ListImages() ([]*FunctionImage, error) {
+	defer trace.Trace("")()
+
+	args := filters.NewArgs()
+	args.Add("label", LabelFunctionID)
+	summaries, err := c.docker.ImageList(context.Background(), types.ImageListOptions{Filters: args})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list function images")
+	}
+	var result []*FunctionImage
+	for _, s := range summaries {
+		result = append(result, &FunctionImage{
+			ID:         s.ID,
+			FunctionID: s.Labels[LabelFunctionID],
+			FaaS:       s.Labels[LabelFaaS],
+			Names:      append(append([]string{}, s.RepoTags...), s.RepoDigests...),
+			Created:    time.Unix(s.Created, 0),
+			Size:       s.Size,
+		})
+	}
+	return result, nil
+}
+
+// RemoveImage removes an image from the registry, by the digests it was pushed with, and from the docker daemon
+func (c *DockerImageCollector) RemoveImage(image *FunctionImage) error {
+	defer trace.Tracef("image: %s", image.ID)()
+
+	for _, name := range image.Names {
+		if !strings.Contains(name, "@") {
+			continue
+		}
+		err := images.DeleteFromRegistry(c.httpClient, name, c.registryAuth, c.registryInsecure)
+		if err == images.ErrRegistryNotSupported {
+			log.Warnf("Image %s is only removed locally: %s", name, err)
+			continue
+		}
+		if err != nil {
+			return err
+		}
+	}
+	if _, err := c.docker.ImageRemove(context.Background(), image.ID, types.ImageRemoveOptions{Force: true, PruneChildren: true}); err != nil {
+		return errors.Wrapf(err, "failed to remove image %s", image.ID)
+	}
+	return nil
+}
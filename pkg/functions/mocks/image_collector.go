@@ -0,0 +1,48 @@
+// Code generated by mockery v1.0.0
+
+package mocks
+
+import functions "github.com/vmware/dispatch/pkg/functions"
+import mock "github.com/stretchr/testify/mock"
+
+// ImageCollector is an autogenerated mock type for the ImageCollector type
+type ImageCollector struct {
+	mock.Mock
+}
+
+// ListImages provides a mock function with given fields:
+func (_m *ImageCollector) ListImages() ([]*functions.FunctionImage, error) {
+	ret := _m.Called()
+
+	var r0 []*functions.FunctionImage
+	if rf, ok := ret.Get(0).(func() []*functions.FunctionImage); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*functions.FunctionImage)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func() error); ok {
+		r1 = rf()
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// RemoveImage provides a mock function with given fields: image
+func (_m *ImageCollector) RemoveImage(image *functions.FunctionImage) error {
+	ret := _m.Called(image)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(*functions.FunctionImage) error); ok {
+		r0 = rf(image)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
@@ -12,6 +12,7 @@ package noop
 
 import (
 	"bytes"
+	"context"
 	"encoding/base64"
 	"fmt"
 	"io"
@@ -85,7 +86,7 @@ const xStderrHeader = "X-Stderr"
 
 // GetRunnable returns a functions.Runnable
 func (d *noopDriver) GetRunnable(e *functions.FunctionExecution) functions.Runnable {
-	return func(ctx functions.Context, in interface{}) (interface{}, error) {
+	return func(ctx context.Context, fctx functions.Context, in interface{}) (interface{}, error) {
 		defer trace.Trace("noop.run." + e.FunctionID)()
 		return nil, nil
 	}
@@ -7,6 +7,7 @@ package openfaas
 
 import (
 	"bytes"
+	"context"
 	"encoding/base64"
 	"encoding/json"
 	"fmt"
@@ -101,6 +102,51 @@ func kubeClientConfig(kubeConfPath string) (*rest.Config, error) {
 	return rest.InClusterConfig()
 }
 
+// createFunctionRequest adds the labels and resource limits of newer OpenFaaS gateways to the vendored request
+type createFunctionRequest struct {
+	requests.CreateFunctionRequest
+	Labels map[string]string  `json:"labels,omitempty"`
+	Limits *functionResources `json:"limits,omitempty"`
+}
+
+type functionResources struct {
+	Memory string `json:"memory,omitempty"`
+	CPU    string `json:"cpu,omitempty"`
+}
+
+// createRequest maps the settings of a function onto the OpenFaaS request. Replicas are set by labels read by the
+// gateway, and the concurrency by the max_inflight variable read by the watchdog.
+func createRequest(f *functions.Function, image string, exec *functions.Exec) *createFunctionRequest {
+	req := &createFunctionRequest{
+		CreateFunctionRequest: requests.CreateFunctionRequest{
+			Image:       image,
+			Network:     "func_functions",
+			Service:     getID(f.ID),
+			EnvVars:     map[string]string{},
+			Constraints: []string{},
+		},
+	}
+	for k, v := range exec.Environment {
+		req.EnvVars[k] = v
+	}
+	if exec.Limits != nil {
+		req.Limits = &functionResources{Memory: exec.Limits.Memory, CPU: exec.Limits.CPU}
+	}
+	if s := exec.Scaling; s != nil {
+		req.Labels = map[string]string{}
+		if s.MinReplicas > 0 {
+			req.Labels["com.openfaas.scale.min"] = fmt.Sprint(s.MinReplicas)
+		}
+		if s.MaxReplicas > 0 {
+			req.Labels["com.openfaas.scale.max"] = fmt.Sprint(s.MaxReplicas)
+		}
+		if s.Concurrency > 0 {
+			req.EnvVars["max_inflight"] = fmt.Sprint(s.Concurrency)
+		}
+	}
+	return req
+}
+
 func (d *ofDriver) Create(f *functions.Function, exec *functions.Exec) error {
 	defer trace.Trace("openfaas.Create." + f.ID)()
 
@@ -110,15 +156,9 @@ func (d *ofDriver) Create(f *functions.Function, exec *functions.Exec) error {
 		return errors.Wrapf(err, "Error building image for function '%s'", f.ID)
 	}
 
-	req := requests.CreateFunctionRequest{
-		Image:       image,
-		Network:     "func_functions",
-		Service:     getID(f.ID),
-		EnvVars:     map[string]string{},
-		Constraints: []string{},
-	}
+	req := createRequest(f, image, exec)
 
-	reqBytes, _ := json.Marshal(&req)
+	reqBytes, _ := json.Marshal(req)
 	res, err := d.httpClient.Post(d.gateway+"/system/functions", jsonContentType, bytes.NewReader(reqBytes))
 	if err != nil {
 		return errors.Wrapf(err, "Error deploying function '%s'", f.ID)
@@ -189,12 +229,17 @@ type ctxAndIn struct {
 const xStderrHeader = "X-Stderr"
 
 func (d *ofDriver) GetRunnable(e *functions.FunctionExecution) functions.Runnable {
-	return func(ctx functions.Context, in interface{}) (interface{}, error) {
+	return func(ctx context.Context, fctx functions.Context, in interface{}) (interface{}, error) {
 		defer trace.Trace("openfaas.run." + e.FunctionID)()
 
-		bytesIn, _ := json.Marshal(ctxAndIn{Context: ctx, Input: in})
+		bytesIn, _ := json.Marshal(ctxAndIn{Context: fctx, Input: in})
 		postURL := d.gateway + "/function/" + getID(e.FunctionID)
-		res, err := d.httpClient.Post(postURL, jsonContentType, bytes.NewReader(bytesIn))
+		req, err := http.NewRequest("POST", postURL, bytes.NewReader(bytesIn))
+		if err != nil {
+			return nil, errors.Wrapf(err, "error creating request to OpenFaaS on %s", d.gateway)
+		}
+		req.Header.Set("Content-Type", jsonContentType)
+		res, err := d.httpClient.Do(req.WithContext(ctx))
 		if err != nil {
 			log.Errorf("Error when sending POST request to %s: %+v", postURL, err)
 			return nil, errors.Wrapf(err, "request to OpenFaaS on %s failed", d.gateway)
@@ -204,7 +249,7 @@ func (d *ofDriver) GetRunnable(e *functions.FunctionExecution) functions.Runnabl
 		log.Debugf("openfaas.run.%s: status code: %v", e.FunctionID, res.StatusCode)
 		switch res.StatusCode {
 		case 200:
-			ctx.ReadLogs(logsReader(res))
+			fctx.ReadLogs(logsReader(res))
 			resBytes, err := ioutil.ReadAll(res.Body)
 			if err != nil {
 				return nil, errors.Errorf("cannot read result from OpenFaaS on URL: %s %s", d.gateway, err)
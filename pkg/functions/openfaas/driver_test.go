@@ -74,7 +74,7 @@ func TestOfDriver_GetRunnable(t *testing.T) {
 
 	f := d.GetRunnable(&functions.FunctionExecution{FunctionID: "deadbeef"})
 	ctx := functions.Context{}
-	r, err := f(ctx, map[string]interface{}{"name": "Me", "place": "Here"})
+	r, err := f(context.Background(), ctx, map[string]interface{}{"name": "Me", "place": "Here"})
 
 	assert.NoError(t, err)
 	assert.Equal(t, map[string]interface{}{"myField": "Hello, Me from Here"}, r)
@@ -130,3 +130,23 @@ func TestOfDriver_Delete(t *testing.T) {
 	err := d.Delete(&f)
 	assert.NoError(t, err)
 }
+
+func TestCreateRequest(t *testing.T) {
+	f := &functions.Function{BaseEntity: entitystore.BaseEntity{ID: "deadbeef"}}
+
+	req := createRequest(f, "test/image", &functions.Exec{})
+	assert.Equal(t, "test/image", req.Image)
+	assert.Equal(t, getID("deadbeef"), req.Service)
+	assert.Empty(t, req.EnvVars)
+	assert.Nil(t, req.Limits)
+	assert.Nil(t, req.Labels)
+
+	req = createRequest(f, "test/image", &functions.Exec{
+		Environment: map[string]string{"DEBUG": "true"},
+		Limits:      &functions.Limits{Memory: "128Mi", CPU: "500m"},
+		Scaling:     &functions.Scaling{MinReplicas: 1, MaxReplicas: 5, Concurrency: 10},
+	})
+	assert.Equal(t, map[string]string{"DEBUG": "true", "max_inflight": "10"}, req.EnvVars)
+	assert.Equal(t, &functionResources{Memory: "128Mi", CPU: "500m"}, req.Limits)
+	assert.Equal(t, map[string]string{"com.openfaas.scale.min": "1", "com.openfaas.scale.max": "5"}, req.Labels)
+}
@@ -6,8 +6,13 @@
 package openwhisk
 
 import (
+	"context"
+
 	"github.com/apache/incubator-openwhisk-client-go/whisk"
 	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/api/resource"
+
 	"github.com/vmware/dispatch/pkg/functions"
 )
 
@@ -42,6 +47,17 @@ func New(config *Config) (functions.FaaSDriver, error) {
 }
 
 func (d *wskDriver) Create(f *functions.Function, exec *functions.Exec) error {
+	action, err := newAction(f, exec)
+	if err != nil {
+		return err
+	}
+	_, _, err = d.client.Actions.Insert(action, true)
+	return err
+}
+
+// newAction maps the settings of a function onto the OpenWhisk action. OpenWhisk only limits the memory of actions,
+// in megabytes, and manages their containers itself.
+func newAction(f *functions.Function, exec *functions.Exec) (*whisk.Action, error) {
 	action := &whisk.Action{
 		Name: f.ID,
 		Exec: &whisk.Exec{
@@ -51,8 +67,18 @@ func (d *wskDriver) Create(f *functions.Function, exec *functions.Exec) error {
 			Kind:  "blackbox",
 		},
 	}
-	_, _, err := d.client.Actions.Insert(action, true)
-	return err
+	if exec.Limits != nil && exec.Limits.Memory != "" {
+		q, err := resource.ParseQuantity(exec.Limits.Memory)
+		if err != nil {
+			return nil, errors.Wrap(err, "invalid memory limit")
+		}
+		memory := int(q.Value() / (1024 * 1024))
+		action.Limits = &whisk.Limits{Memory: &memory}
+	}
+	if len(exec.Environment) > 0 || (exec.Limits != nil && exec.Limits.CPU != "") || exec.Scaling != nil {
+		log.Warnf("OpenWhisk does not support the environment variables, the cpu limit and the scaling of function %s", f.ID)
+	}
+	return action, nil
 }
 
 func (d *wskDriver) Delete(f *functions.Function) error {
@@ -66,11 +92,25 @@ type ctxAndIn struct {
 }
 
 func (d *wskDriver) GetRunnable(e *functions.FunctionExecution) functions.Runnable {
-	return func(ctx functions.Context, in interface{}) (interface{}, error) {
-		result, _, err := d.client.Actions.Invoke(e.FunctionID, ctxAndIn{Context: ctx, Input: in}, true, true)
-		if err != nil {
-			return nil, err // TODO err should be JSON-serializable and usable (e.g. invalid arg vs runtime error)
+	return func(ctx context.Context, fctx functions.Context, in interface{}) (interface{}, error) {
+		type invokeResult struct {
+			result map[string]interface{}
+			err    error
+		}
+		// The client does not support cancellation, so the blocking invocation is abandoned when ctx is done
+		done := make(chan invokeResult, 1)
+		go func() {
+			result, _, err := d.client.Actions.Invoke(e.FunctionID, ctxAndIn{Context: fctx, Input: in}, true, true)
+			done <- invokeResult{result, err}
+		}()
+		select {
+		case r := <-done:
+			if r.err != nil {
+				return nil, r.err // TODO err should be JSON-serializable and usable (e.g. invalid arg vs runtime error)
+			}
+			return r.result, nil
+		case <-ctx.Done():
+			return nil, errors.Wrapf(ctx.Err(), "abandoned invocation of action %s", e.FunctionID)
 		}
-		return result, nil
 	}
 }
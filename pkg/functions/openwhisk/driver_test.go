@@ -5,6 +5,7 @@
 package openwhisk
 
 import (
+	"context"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -30,7 +31,7 @@ func init() {
 func TestWskDriver_GetRunnable(t *testing.T) {
 	dev.EnsureLocal(t)
 	f := driver.GetRunnable(&functions.FunctionExecution{FunctionID: "deadbeef"})
-	r, err := f(functions.Context{}, map[string]interface{}{})
+	r, err := f(context.Background(), functions.Context{}, map[string]interface{}{})
 
 	assert.NoError(t, err)
 	assert.Equal(t, map[string]interface{}{"myField": "Hello, Noone from Nowhere"}, r)
@@ -74,3 +75,19 @@ function main(ctx, params) {
 	})
 	assert.NoError(t, err)
 }
+
+func TestNewAction(t *testing.T) {
+	f := &functions.Function{BaseEntity: entitystore.BaseEntity{ID: "deadbeef"}}
+
+	action, err := newAction(f, &functions.Exec{Image: "test/image"})
+	assert.NoError(t, err)
+	assert.Equal(t, "deadbeef", action.Name)
+	assert.Nil(t, action.Limits)
+
+	action, err = newAction(f, &functions.Exec{Image: "test/image", Limits: &functions.Limits{Memory: "256Mi"}})
+	assert.NoError(t, err)
+	assert.Equal(t, 256, *action.Limits.Memory)
+
+	_, err = newAction(f, &functions.Exec{Limits: &functions.Limits{Memory: "lots"}})
+	assert.Error(t, err)
+}
@@ -6,8 +6,10 @@
 package riff
 
 import (
+	"context"
 	"encoding/json"
 	"net/http"
+	"sort"
 
 	"github.com/bsm/sarama-cluster"
 	docker "github.com/docker/docker/client"
@@ -19,6 +21,7 @@ import (
 	log "github.com/sirupsen/logrus"
 	kapi "k8s.io/api/core/v1"
 	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/clientcmd"
@@ -122,17 +125,15 @@ func (d *riffDriver) Create(f *functions.Function, exec *functions.Exec) error {
 			Name: fnName,
 		},
 	}
+	spec, err := functionSpec(fnName, image, exec)
+	if err != nil {
+		return errors.Wrapf(err, "Error creating function '%s'", f.ID)
+	}
 	function := &v1.Function{
 		ObjectMeta: metav1.ObjectMeta{
 			Name: fnName,
 		},
-		Spec: v1.FunctionSpec{
-			Protocol: "http",
-			Input:    fnName,
-			Container: kapi.Container{
-				Image: image,
-			},
-		},
+		Spec: *spec,
 	}
 
 	if _, err := d.topics.Create(topic); err != nil {
@@ -145,11 +146,64 @@ func (d *riffDriver) Create(f *functions.Function, exec *functions.Exec) error {
 		if !kerrors.IsAlreadyExists(err) {
 			return errors.Wrapf(err, "error creating function '%s'", fnName)
 		}
+		// the function is updated, e.g. with new environment variables
+		existing, err := d.functions.Get(fnName, metav1.GetOptions{})
+		if err != nil {
+			return errors.Wrapf(err, "error reading function '%s'", fnName)
+		}
+		existing.Spec = *spec
+		if _, err := d.functions.Update(existing); err != nil {
+			return errors.Wrapf(err, "error updating function '%s'", fnName)
+		}
 	}
 
 	return nil
 }
 
+// functionSpec maps the settings of a function onto the riff function. riff scales functions from 0 replicas and
+// sets the concurrency itself, so only the maximum number of replicas is passed.
+func functionSpec(fnName, image string, exec *functions.Exec) (*v1.FunctionSpec, error) {
+	spec := &v1.FunctionSpec{
+		Protocol: "http",
+		Input:    fnName,
+		Container: kapi.Container{
+			Image: image,
+		},
+	}
+	var names []string
+	for name := range exec.Environment {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		spec.Container.Env = append(spec.Container.Env, kapi.EnvVar{Name: name, Value: exec.Environment[name]})
+	}
+	if l := exec.Limits; l != nil {
+		limits := kapi.ResourceList{}
+		for name, value := range map[kapi.ResourceName]string{kapi.ResourceMemory: l.Memory, kapi.ResourceCPU: l.CPU} {
+			if value == "" {
+				continue
+			}
+			q, err := resource.ParseQuantity(value)
+			if err != nil {
+				return nil, errors.Wrapf(err, "invalid %s limit", name)
+			}
+			limits[name] = q
+		}
+		spec.Container.Resources.Limits = limits
+	}
+	if s := exec.Scaling; s != nil {
+		if s.MaxReplicas > 0 {
+			maxReplicas := int32(s.MaxReplicas)
+			spec.MaxReplicas = &maxReplicas
+		}
+		if s.MinReplicas > 0 || s.Concurrency > 0 {
+			log.Warnf("riff does not support the minimum replicas and the concurrency of function %s", fnName)
+		}
+	}
+	return spec, nil
+}
+
 func (d *riffDriver) Delete(f *functions.Function) error {
 	defer trace.Tracef("riff.Delete.%s", f.ID)()
 
@@ -176,15 +230,15 @@ type ctxAndPld struct {
 }
 
 func (d *riffDriver) GetRunnable(e *functions.FunctionExecution) functions.Runnable {
-	return func(ctx functions.Context, in interface{}) (interface{}, error) {
+	return func(ctx context.Context, fctx functions.Context, in interface{}) (interface{}, error) {
 		defer trace.Tracef("riff.run.%s", e.FunctionID)()
 
-		bytesIn, _ := json.Marshal(ctxAndPld{Context: ctx, Payload: in})
+		bytesIn, _ := json.Marshal(ctxAndPld{Context: fctx, Payload: in})
 		topic := fnID(e.FunctionID)
 
 		log.Debugf("Posting to topic '%s': '%s'", topic, string(bytesIn))
 
-		resBytes, err := d.requester.Request(topic, e.RunID, bytesIn)
+		resBytes, err := d.requester.Request(ctx, topic, e.RunID, bytesIn)
 		if err != nil {
 			return nil, errors.Wrapf(err, "riff: error invoking function: '%s', runID: '%s'", e.FunctionID, e.RunID)
 		}
@@ -193,7 +247,7 @@ func (d *riffDriver) GetRunnable(e *functions.FunctionExecution) functions.Runna
 		if err := json.Unmarshal(resBytes, &out); err != nil {
 			return nil, errors.Errorf("cannot JSON-parse result from riff: %s %s", err, string(resBytes))
 		}
-		ctx.AddLogs(out.Context.Logs())
+		fctx.AddLogs(out.Context.Logs())
 		return out.Payload, nil
 
 	}
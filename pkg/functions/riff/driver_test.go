@@ -81,7 +81,7 @@ func TestDriver_GetRunnable(t *testing.T) {
 
 	f := d.GetRunnable(&functions.FunctionExecution{FunctionID: funID})
 	ctx := functions.Context{}
-	r, err := f(ctx, map[string]interface{}{"name": "Noone", "place": "Braavos"})
+	r, err := f(context.Background(), ctx, map[string]interface{}{"name": "Noone", "place": "Braavos"})
 
 	require.NoError(t, err)
 	assert.Equal(t, map[string]interface{}{"greeting": "Hello, Noone from Braavos"}, r)
@@ -135,3 +135,26 @@ func TestDriver_Delete(t *testing.T) {
 	err := d.Delete(&f)
 	assert.NoError(t, err)
 }
+
+func TestFunctionSpec(t *testing.T) {
+	spec, err := functionSpec(funName, "test/image", &functions.Exec{})
+	require.NoError(t, err)
+	assert.Equal(t, "test/image", spec.Container.Image)
+	assert.Empty(t, spec.Container.Env)
+	assert.Nil(t, spec.MaxReplicas)
+
+	spec, err = functionSpec(funName, "test/image", &functions.Exec{
+		Environment: map[string]string{"DEBUG": "true", "LEVEL": "1"},
+		Limits:      &functions.Limits{Memory: "128Mi"},
+		Scaling:     &functions.Scaling{MaxReplicas: 5},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "DEBUG", spec.Container.Env[0].Name)
+	assert.Equal(t, "LEVEL", spec.Container.Env[1].Name)
+	assert.Equal(t, "128Mi", spec.Container.Resources.Limits.Memory().String())
+	assert.Len(t, spec.Container.Resources.Limits, 1)
+	assert.EqualValues(t, 5, *spec.MaxReplicas)
+
+	_, err = functionSpec(funName, "test/image", &functions.Exec{Limits: &functions.Limits{CPU: "lots"}})
+	assert.Error(t, err)
+}
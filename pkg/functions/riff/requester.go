@@ -6,6 +6,7 @@
 package riff
 
 import (
+	"context"
 	"sync"
 	"time"
 
@@ -123,7 +124,7 @@ func (r requester) makeHeaders(runID string) message.Headers {
 	}
 }
 
-func (r *requester) Request(topic string, reqID string, payload []byte) ([]byte, error) {
+func (r *requester) Request(ctx context.Context, topic string, reqID string, payload []byte) ([]byte, error) {
 	resultChan := make(chan message.Message)
 	r.returns.Put(reqID, resultChan)
 
@@ -132,11 +133,15 @@ func (r *requester) Request(topic string, reqID string, payload []byte) ([]byte,
 	}
 
 	timer := time.NewTimer(r.timeout)
+	defer timer.Stop()
 	select {
 	case msg := <-resultChan:
 		return msg.Payload(), nil
 	case <-timer.C:
 		r.returns.Remove(reqID)
 		return nil, errors.Errorf("timeout getting response from function, reqID: %s", reqID)
+	case <-ctx.Done():
+		r.returns.Remove(reqID)
+		return nil, errors.Wrapf(ctx.Err(), "abandoned waiting for response from function, reqID: %s", reqID)
 	}
 }
@@ -6,6 +6,8 @@
 package runner
 
 import (
+	"context"
+
 	"github.com/vmware/dispatch/pkg/functions"
 )
 
@@ -25,13 +27,13 @@ func New(config *Config) functions.Runner {
 	return &impl{*config}
 }
 
-func (r *impl) Run(fn *functions.FunctionExecution, in interface{}) (interface{}, error) {
+func (r *impl) Run(ctx context.Context, fn *functions.FunctionExecution, in interface{}) (interface{}, error) {
 	f := r.Faas.GetRunnable(fn)
 	m := Compose(
 		r.Validator.GetMiddleware(fn.Schemas),
 		r.SecretInjector.GetMiddleware(fn.Secrets, fn.Cookie),
 	)
-	return m(f)(fn.Context, in)
+	return m(f)(ctx, fn.Context, in)
 }
 
 // Compose applies middleware so that:
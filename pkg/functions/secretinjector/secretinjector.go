@@ -39,14 +39,14 @@ func (err *injectorError) AsUserErrorObject() interface{} {
 	return err
 }
 
-func (injector *secretInjector) getSecrets(secretNames []string, cookie string) (map[string]interface{}, error) {
+func (injector *secretInjector) getSecrets(ctx context.Context, secretNames []string, cookie string) (map[string]interface{}, error) {
 
 	secrets := make(map[string]interface{})
 	apiKeyAuth := apiclient.APIKeyAuth("cookie", "header", cookie)
 	for _, name := range secretNames {
 		resp, err := injector.client.Secret.GetSecret(&secret.GetSecretParams{
 			SecretName: name,
-			Context:    context.Background(),
+			Context:    ctx,
 		}, apiKeyAuth)
 		if err != nil {
 			return secrets, errors.Wrapf(err, "failed to get secrets from secret store")
@@ -67,14 +67,14 @@ func (injector *secretInjector) getSecrets(secretNames []string, cookie string)
 
 func (injector *secretInjector) GetMiddleware(secretNames []string, cookie string) functions.Middleware {
 	return func(f functions.Runnable) functions.Runnable {
-		return func(ctx functions.Context, in interface{}) (interface{}, error) {
-			secrets, err := injector.getSecrets(secretNames, cookie)
+		return func(ctx context.Context, fctx functions.Context, in interface{}) (interface{}, error) {
+			secrets, err := injector.getSecrets(ctx, secretNames, cookie)
 			if err != nil {
 				log.Errorf("error when get secrets from secret store %+v", err)
 				return nil, &injectorError{errors.Wrap(err, "error when retrieving secrets from secret store")}
 			}
-			ctx["secrets"] = secrets
-			out, err := f(ctx, in)
+			fctx["secrets"] = secrets
+			out, err := f(ctx, fctx, in)
 			if err != nil {
 				return nil, err
 			}
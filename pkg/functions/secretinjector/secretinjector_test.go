@@ -5,6 +5,7 @@
 package secretinjector
 
 import (
+	"context"
 	"testing"
 
 	"github.com/go-openapi/strfmt"
@@ -42,12 +43,12 @@ func TestImpl_GetMiddleware(t *testing.T) {
 
 	cookie := "testCookie"
 
-	printSecretsFn := func(ctx functions.Context, _ interface{}) (interface{}, error) {
-		return ctx["secrets"], nil
+	printSecretsFn := func(_ context.Context, fctx functions.Context, _ interface{}) (interface{}, error) {
+		return fctx["secrets"], nil
 	}
 
 	ctx := functions.Context{"secrets": expectedSecrets}
-	output, err := injector.GetMiddleware([]string{expectedSecretName}, cookie)(printSecretsFn)(ctx, nil)
+	output, err := injector.GetMiddleware([]string{expectedSecretName}, cookie)(printSecretsFn)(context.Background(), ctx, nil)
 	assert.NoError(t, err)
 	assert.Equal(t, expectedOutput, output)
 }
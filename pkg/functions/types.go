@@ -5,13 +5,18 @@
 
 package functions
 
+import (
+	"context"
+	"time"
+)
+
 // NO TESTS
 
 // Context provides function context
 type Context map[string]interface{}
 
-// Runnable is a runnable representation of a function
-type Runnable func(ctx Context, in interface{}) (interface{}, error)
+// Runnable is a runnable representation of a function. The function execution is abandoned when ctx is done.
+type Runnable func(ctx context.Context, fctx Context, in interface{}) (interface{}, error)
 
 // Middleware allows injecting extra steps for each function execution
 type Middleware func(f Runnable) Runnable
@@ -28,6 +33,12 @@ type Exec struct {
 	Language string
 	// Name is the function's name
 	Name string
+	// Environment are the function's environment variables
+	Environment map[string]string
+	// Limits are the resource limits of each function instance, nil means the FaaS defaults
+	Limits *Limits
+	// Scaling are the function's scaling settings, nil means the FaaS defaults
+	Scaling *Scaling
 }
 
 // Schemas represent function validation schemas
@@ -76,9 +87,32 @@ type ImageBuilder interface {
 	BuildImage(faas, fnID string, e *Exec) (string, error)
 }
 
+// FunctionImage is a docker image built for a function
+type FunctionImage struct {
+	// ID is the docker image ID
+	ID string
+	// FunctionID is the ID of the function, or function version, the image was built for
+	FunctionID string
+	FaaS       string
+	// Names are the tags and digests of the image
+	Names   []string
+	Created time.Time
+	Size    int64
+}
+
+//go:generate mockery -name ImageCollector -case underscore -dir .
+
+// ImageCollector lists and removes the images built for functions, so that superseded images do not pile up.
+type ImageCollector interface {
+	// ListImages returns the images built for functions
+	ListImages() ([]*FunctionImage, error)
+	// RemoveImage removes an image, both locally and from the docker registry
+	RemoveImage(image *FunctionImage) error
+}
+
 // Runner knows how to execute a function
 type Runner interface {
-	Run(fn *FunctionExecution, in interface{}) (interface{}, error)
+	Run(ctx context.Context, fn *FunctionExecution, in interface{}) (interface{}, error)
 }
 
 // Validator validates function input/output
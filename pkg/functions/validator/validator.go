@@ -6,6 +6,8 @@
 package validator
 
 import (
+	"context"
+
 	"github.com/go-openapi/spec"
 	"github.com/go-openapi/strfmt"
 	"github.com/go-openapi/validate"
@@ -48,7 +50,7 @@ func (err *outputError) AsFunctionErrorObject() interface{} {
 
 func (*schemaValidator) GetMiddleware(schemas *functions.Schemas) functions.Middleware {
 	return func(f functions.Runnable) functions.Runnable {
-		return func(ctx functions.Context, input interface{}) (interface{}, error) {
+		return func(ctx context.Context, fctx functions.Context, input interface{}) (interface{}, error) {
 			if schema, ok := schemas.SchemaIn.(*spec.Schema); ok {
 				if schema != nil {
 					if err := validate.AgainstSchema(schema, input, strfmt.Default); err != nil {
@@ -58,7 +60,7 @@ func (*schemaValidator) GetMiddleware(schemas *functions.Schemas) functions.Midd
 			} else {
 				log.Warnf("Unknown schema impl: %v", schema)
 			}
-			output, err := f(ctx, input)
+			output, err := f(ctx, fctx, input)
 			if err != nil {
 				return nil, err
 			}
@@ -15,14 +15,17 @@ import (
 	"github.com/vmware/dispatch/pkg/trace"
 )
 
-// NewIdentityController creates a new controller to manage the reconciliation of policy entities
-func NewIdentityController(store entitystore.EntityStore, enforcer *casbin.SyncedEnforcer) controller.Controller {
+// NewIdentityController creates a new controller to manage the reconciliation of policy entities. elector may be nil,
+// without leader election.
+func NewIdentityController(store entitystore.EntityStore, enforcer *casbin.SyncedEnforcer, elector controller.LeaderElector) controller.Controller {
 	defer trace.Trace("")()
 
 	c := controller.NewController(controller.Options{
 		OrganizationID: IdentityManagerFlags.OrgID,
 		ResyncPeriod:   time.Duration(IdentityManagerFlags.ResyncPeriod) * time.Second,
 		Workers:        5, // TODO: make this configurable
+		Store:          store,
+		Elector:        elector,
 	})
 
 	c.AddEntityHandler(&policyEntityHandler{store: store, enforcer: enforcer})
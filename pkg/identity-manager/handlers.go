@@ -25,6 +25,7 @@ import (
 	"github.com/vmware/dispatch/pkg/identity-manager/gen/restapi/operations"
 	policyOperations "github.com/vmware/dispatch/pkg/identity-manager/gen/restapi/operations/policy"
 	"github.com/vmware/dispatch/pkg/trace"
+	"github.com/vmware/dispatch/pkg/utils"
 )
 
 // IdentityManagerFlags are configuration flags for the identity manager
@@ -32,8 +33,8 @@ var IdentityManagerFlags = struct {
 	CookieName          string `long:"cookie-name" description:"The cookie name used to identify users" default:"_oauth2_proxy"`
 	SkipAuth            bool   `long:"skip-auth" description:"Skips authorization, not to be used in production env"`
 	EnableBootstrapMode bool   `long:"enable-bootstrap-mode" description:"Enabled bootstrap mode"`
-	DbFile              string `long:"db-file" description:"Backend DB URL/Path" default:"./db.bolt"`
-	DbBackend           string `long:"db-backend" description:"Backend DB Name" default:"boltdb"`
+	DbFile              string `long:"db-file" description:"Backend DB URL/Path (comma separated endpoints for etcd and consul)" default:"./db.bolt"`
+	DbBackend           string `long:"db-backend" description:"Backend DB Name (boltdb, postgres, etcd or consul)" default:"boltdb"`
 	DbUser              string `long:"db-username" description:"Backend DB Username" default:"dispatch"`
 	DbPassword          string `long:"db-password" description:"Backend DB Password" default:"dispatch"`
 	DbDatabase          string `long:"db-database" description:"Backend DB Name" default:"dispatch"`
@@ -254,7 +255,15 @@ func (h *Handlers) getPolicies(params policyOperations.GetPoliciesParams, princi
 	opts := entitystore.Options{
 		Filter: entitystore.FilterExists(),
 	}
-	err := h.store.List(IdentityManagerFlags.OrgID, opts, &policies)
+	if err := utils.ParsePage(&opts, params.Limit, params.Continue, params.SortBy); err != nil {
+		log.Error(err)
+		return policyOperations.NewGetPoliciesDefault(http.StatusBadRequest).WithPayload(
+			&models.Error{
+				Code:    http.StatusBadRequest,
+				Message: swag.String(err.Error()),
+			})
+	}
+	next, err := utils.ListPage(h.store, IdentityManagerFlags.OrgID, opts, &policies)
 	if err != nil {
 		log.Errorf("store error when listing policies: %+v", err)
 		return policyOperations.NewGetPoliciesInternalServerError().WithPayload(
@@ -267,7 +276,7 @@ func (h *Handlers) getPolicies(params policyOperations.GetPoliciesParams, princi
 	for _, policy := range policies {
 		policyModels = append(policyModels, policyEntityToModel(policy))
 	}
-	return policyOperations.NewGetPoliciesOK().WithPayload(policyModels)
+	return policyOperations.NewGetPoliciesOK().WithXContinue(next).WithPayload(policyModels)
 }
 
 func (h *Handlers) getPolicy(params policyOperations.GetPolicyParams, principal interface{}) middleware.Responder {
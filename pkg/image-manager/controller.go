@@ -21,6 +21,7 @@ import (
 type ControllerConfig struct {
 	ResyncPeriod   time.Duration
 	OrganizationID string
+	Elector        controller.LeaderElector
 }
 
 type baseImageEntityHandler struct {
@@ -167,6 +168,8 @@ func NewController(config *ControllerConfig, store entitystore.EntityStore, base
 		OrganizationID: ImageManagerFlags.OrgID,
 		ResyncPeriod:   config.ResyncPeriod,
 		Workers:        10, // want more functions concurrently? add more workers // TODO configure workers
+		Store:          store,
+		Elector:        config.Elector,
 	})
 
 	c.AddEntityHandler(&baseImageEntityHandler{Store: store, Builder: baseImageBuilder})
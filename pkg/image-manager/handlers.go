@@ -28,8 +28,8 @@ import (
 // ImageManagerFlags are configuration flags for the image manager
 var ImageManagerFlags = struct {
 	Config       string `long:"config" description:"Path to Config file" default:"./config.dev.json"`
-	DbFile       string `long:"db-file" description:"Backend DB URL/Path" default:"./db.bolt"`
-	DbBackend    string `long:"db-backend" description:"Backend DB Name" default:"boltdb"`
+	DbFile       string `long:"db-file" description:"Backend DB URL/Path (comma separated endpoints for etcd and consul)" default:"./db.bolt"`
+	DbBackend    string `long:"db-backend" description:"Backend DB Name (boltdb, postgres, etcd or consul)" default:"boltdb"`
 	DbUser       string `long:"db-username" description:"Backend DB Username" default:"dispatch"`
 	DbPassword   string `long:"db-password" description:"Backend DB Password" default:"dispatch"`
 	DbDatabase   string `long:"db-database" description:"Backend DB Name" default:"dispatch"`
@@ -266,7 +266,15 @@ func (h *Handlers) getBaseImages(params baseimage.GetBaseImagesParams, principal
 	opts := entitystore.Options{
 		Filter: entitystore.FilterExists(),
 	}
-	err = h.Store.List(ImageManagerFlags.OrgID, opts, &images)
+	if err := utils.ParsePage(&opts, params.Limit, params.Continue, params.SortBy); err != nil {
+		log.Error(err)
+		return baseimage.NewGetBaseImagesDefault(http.StatusBadRequest).WithPayload(
+			&models.Error{
+				Code:    http.StatusBadRequest,
+				Message: swag.String(err.Error()),
+			})
+	}
+	next, err := utils.ListPage(h.Store, ImageManagerFlags.OrgID, opts, &images)
 	if err != nil {
 		log.Errorf("store error when listing base images: %+v", err)
 		return baseimage.NewGetBaseImagesDefault(http.StatusInternalServerError).WithPayload(
@@ -279,7 +287,7 @@ func (h *Handlers) getBaseImages(params baseimage.GetBaseImagesParams, principal
 	for _, image := range images {
 		imageModels = append(imageModels, baseImageEntityToModel(image))
 	}
-	return baseimage.NewGetBaseImagesOK().WithPayload(imageModels)
+	return baseimage.NewGetBaseImagesOK().WithXContinue(next).WithPayload(imageModels)
 }
 
 func (h *Handlers) updateBaseImageByName(params baseimage.UpdateBaseImageByNameParams, principal interface{}) middleware.Responder {
@@ -417,6 +425,9 @@ func (h *Handlers) getImages(params image.GetImagesParams, principal interface{}
 		Filter: entitystore.FilterExists(),
 	}
 	opts.Filter, err = utils.ParseTags(opts.Filter, params.Tags)
+	if err == nil {
+		err = utils.ParsePage(&opts, params.Limit, params.Continue, params.SortBy)
+	}
 	if err != nil {
 		log.Errorf(err.Error())
 		return image.NewGetImagesBadRequest().WithPayload(
@@ -426,7 +437,7 @@ func (h *Handlers) getImages(params image.GetImagesParams, principal interface{}
 			})
 	}
 
-	err = h.Store.List(ImageManagerFlags.OrgID, opts, &images)
+	next, err := utils.ListPage(h.Store, ImageManagerFlags.OrgID, opts, &images)
 	if err != nil {
 		log.Errorf("store error when listing images: %+v", err)
 		return image.NewGetImagesDefault(http.StatusInternalServerError).WithPayload(
@@ -440,7 +451,7 @@ func (h *Handlers) getImages(params image.GetImagesParams, principal interface{}
 		imageModels = append(imageModels, imageEntityToModel(image))
 	}
 
-	return image.NewGetImagesOK().WithPayload(imageModels)
+	return image.NewGetImagesOK().WithXContinue(next).WithPayload(imageModels)
 }
 
 func (h *Handlers) updateImageByName(params image.UpdateImageByNameParams, principal interface{}) middleware.Responder {
@@ -284,7 +284,7 @@ func (b *ImageBuilder) imageCreate(image *Image, baseImage *BaseImage) error {
 	}
 
 	dockerURL := strings.Join([]string{b.registryHost, image.GetID() + ":latest"}, "/")
-	err = images.BuildAndPushFromDir(b.dockerClient, tmpDir, dockerURL, b.registryAuth)
+	err = images.BuildAndPushFromDir(b.dockerClient, tmpDir, dockerURL, b.registryAuth, nil)
 	if err != nil {
 		return err
 	}
@@ -49,8 +49,8 @@ func DockerError(r io.ReadCloser, err error) error {
 	return nil
 }
 
-// BuildAndPushFromDir will tar up a docker image, build it, and push it
-func BuildAndPushFromDir(client docker.ImageAPIClient, dir, name, registryAuth string) error {
+// BuildAndPushFromDir will tar up a docker image, build it with the labels, and push it
+func BuildAndPushFromDir(client docker.ImageAPIClient, dir, name, registryAuth string, labels map[string]string) error {
 	files, _ := ioutil.ReadDir(dir)
 	for _, f := range files {
 		log.Debugf("Packing %s", f.Name())
@@ -66,6 +66,7 @@ func BuildAndPushFromDir(client docker.ImageAPIClient, dir, name, registryAuth s
 	log.Debugf("Building image %s from tarball", name)
 	r, err := client.ImageBuild(context.Background(), tarBall, types.ImageBuildOptions{
 		Tags:           []string{name},
+		Labels:         labels,
 		SuppressOutput: true,
 	})
 	if err != nil {
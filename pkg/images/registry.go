@@ -0,0 +1,86 @@
+///////////////////////////////////////////////////////////////////////
+// Copyright (c) 2018 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+///////////////////////////////////////////////////////////////////////
+
+package images
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/vmware/dispatch/pkg/trace"
+)
+
+// ErrRegistryNotSupported is returned for images which cannot be deleted through the registry API, i.e. on Docker Hub
+var ErrRegistryNotSupported = errors.New("deleting images is not supported by the registry")
+
+// parseDigestReference splits an image reference by digest, e.g. registry.local:5000/func/hello@sha256:abc
+func parseDigestReference(ref string) (host, repository, digest string, err error) {
+	parts := strings.SplitN(ref, "@", 2)
+	if len(parts) != 2 {
+		return "", "", "", errors.Errorf("image reference %s has no digest", ref)
+	}
+	path := strings.SplitN(parts[0], "/", 2)
+	// the first component is a registry host if it has a domain or a port, as in docker
+	if len(path) != 2 || (!strings.ContainsAny(path[0], ".:") && path[0] != "localhost") || path[0] == "docker.io" {
+		return "", "", "", ErrRegistryNotSupported
+	}
+	return path[0], path[1], parts[1], nil
+}
+
+// registryCredentials decodes the registry auth, in the format of the X-Registry-Auth docker header
+func registryCredentials(registryAuth string) (username, password string) {
+	decoded, err := base64.URLEncoding.DecodeString(registryAuth)
+	if err != nil {
+		if decoded, err = base64.StdEncoding.DecodeString(registryAuth); err != nil {
+			return "", ""
+		}
+	}
+	auth := struct {
+		Username string `json:"username"`
+		Password string `json:"password"`
+	}{}
+	if err := json.Unmarshal(decoded, &auth); err != nil {
+		return "", ""
+	}
+	return auth.Username, auth.Password
+}
+
+// DeleteFromRegistry deletes an image from its registry, given the digest reference it was pushed with, through the
+// registry API v2. The registry must allow deletions. Images which are not in the registry anymore are ignored.
+func DeleteFromRegistry(client *http.Client, ref, registryAuth string, insecure bool) error {
+	defer trace.Tracef("image: %s", ref)()
+
+	host, repository, digest, err := parseDigestReference(ref)
+	if err != nil {
+		return err
+	}
+	scheme := "https"
+	if insecure {
+		scheme = "http"
+	}
+	req, err := http.NewRequest("DELETE", fmt.Sprintf("%s://%s/v2/%s/manifests/%s", scheme, host, repository, digest), nil)
+	if err != nil {
+		return errors.Wrapf(err, "failed to create the request to delete image %s", ref)
+	}
+	if username, password := registryCredentials(registryAuth); username != "" {
+		req.SetBasicAuth(username, password)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return errors.Wrapf(err, "failed to delete image %s", ref)
+	}
+	defer resp.Body.Close()
+	switch resp.StatusCode {
+	case http.StatusOK, http.StatusAccepted, http.StatusNotFound:
+		return nil
+	default:
+		return errors.Errorf("registry returned unexpected status %d when deleting image %s", resp.StatusCode, ref)
+	}
+}
@@ -0,0 +1,57 @@
+///////////////////////////////////////////////////////////////////////
+// Copyright (c) 2018 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+///////////////////////////////////////////////////////////////////////
+
+package images
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseDigestReference(t *testing.T) {
+	host, repository, digest, err := parseDigestReference("registry.local:5000/func/hello@sha256:abc")
+	assert.Nil(t, err)
+	assert.Equal(t, "registry.local:5000", host)
+	assert.Equal(t, "func/hello", repository)
+	assert.Equal(t, "sha256:abc", digest)
+
+	_, _, _, err = parseDigestReference("vmware/hello@sha256:abc")
+	assert.Equal(t, ErrRegistryNotSupported, err)
+	_, _, _, err = parseDigestReference("registry.local:5000/func/hello:latest")
+	assert.Error(t, err)
+}
+
+func TestRegistryCredentials(t *testing.T) {
+	// {"username":"user","password":"secret"}
+	username, password := registryCredentials("eyJ1c2VybmFtZSI6InVzZXIiLCJwYXNzd29yZCI6InNlY3JldCJ9")
+	assert.Equal(t, "user", username)
+	assert.Equal(t, "secret", password)
+
+	username, _ = registryCredentials("not auth")
+	assert.Empty(t, username)
+}
+
+func TestDeleteFromRegistry(t *testing.T) {
+	var deleted []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "DELETE", r.Method)
+		deleted = append(deleted, r.URL.Path)
+		if strings.HasSuffix(r.URL.Path, "sha256:denied") {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+	host := strings.TrimPrefix(server.URL, "http://")
+
+	assert.Nil(t, DeleteFromRegistry(server.Client(), host+"/func/hello@sha256:abc", "", true))
+	assert.Equal(t, []string{"/v2/func/hello/manifests/sha256:abc"}, deleted)
+	assert.Error(t, DeleteFromRegistry(server.Client(), host+"/func/hello@sha256:denied", "", true))
+}
@@ -4,6 +4,7 @@ package mocks
 
 import entitystore "github.com/vmware/dispatch/pkg/entity-store"
 import mock "github.com/stretchr/testify/mock"
+import reflect "reflect"
 
 // EntityStore is an autogenerated mock type for the EntityStore type
 type EntityStore struct {
@@ -98,3 +99,26 @@ func (_m *EntityStore) Update(lastRevision uint64, entity entitystore.Entity) (i
 func (_m *EntityStore) UpdateWithError(e entitystore.Entity, err error) {
 	_m.Called(e, err)
 }
+
+// Watch provides a mock function with given fields: organizationID, entityType, filter, stopCh
+func (_m *EntityStore) Watch(organizationID string, entityType reflect.Type, filter entitystore.Filter, stopCh <-chan struct{}) (<-chan entitystore.Event, error) {
+	ret := _m.Called(organizationID, entityType, filter, stopCh)
+
+	var r0 <-chan entitystore.Event
+	if rf, ok := ret.Get(0).(func(string, reflect.Type, entitystore.Filter, <-chan struct{}) <-chan entitystore.Event); ok {
+		r0 = rf(organizationID, entityType, filter, stopCh)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(<-chan entitystore.Event)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string, reflect.Type, entitystore.Filter, <-chan struct{}) error); ok {
+		r1 = rf(organizationID, entityType, filter, stopCh)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
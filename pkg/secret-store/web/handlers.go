@@ -35,8 +35,8 @@ var SecretStoreFlags = struct {
 	K8sConfig      string `long:"kubeconfig" description:"Path to kubernetes config file"`
 	K8sNamespace   string `long:"namespace" description:"Kubernetes namespace" default:"default"`
 	OrganizationID string `long:"organization" description:"Organization ID" default:"vmware"`
-	DbFile         string `long:"db-file" description:"Backend DB URL/Path" default:"./db.bolt"`
-	DbBackend      string `long:"db-backend" description:"Backend DB Name" default:"boltdb"`
+	DbFile         string `long:"db-file" description:"Backend DB URL/Path (comma separated endpoints for etcd and consul)" default:"./db.bolt"`
+	DbBackend      string `long:"db-backend" description:"Backend DB Name (boltdb, postgres, etcd or consul)" default:"boltdb"`
 	DbUser         string `long:"db-username" description:"Backend DB Username" default:"dispatch"`
 	DbPassword     string `long:"db-password" description:"Backend DB Password" default:"dispatch"`
 	DbDatabase     string `long:"db-database" description:"Backend DB Name" default:"dispatch"`
@@ -0,0 +1,164 @@
+///////////////////////////////////////////////////////////////////////
+// Copyright (c) 2018 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+///////////////////////////////////////////////////////////////////////
+
+package kv
+
+// NO TESTS
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+type consulEntry struct {
+	Key         string
+	Value       []byte
+	CreateIndex uint64
+	ModifyIndex uint64
+}
+
+type consulTxnOp struct {
+	KV *struct {
+		Verb  string
+		Key   string
+		Value []byte
+		Index uint64
+	}
+}
+
+// consul is a fake of the key/value HTTP API of Consul, supporting the kv (with blocking queries) and txn (cas and
+// delete-cas) calls
+type consul struct {
+	sync.Mutex
+	index   uint64
+	entries map[string]*consulEntry
+}
+
+// NewConsulServer starts a fake Consul server, which must be closed
+func NewConsulServer() *httptest.Server {
+	// the index of Consul starts at 1, blocking queries on index 0 return right away
+	c := &consul{index: 1, entries: map[string]*consulEntry{}}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v1/kv/", c.handleKV)
+	mux.HandleFunc("/v1/txn", c.handleTxn)
+	return httptest.NewServer(mux)
+}
+
+func (c *consul) handleKV(w http.ResponseWriter, r *http.Request) {
+	key := strings.TrimPrefix(r.URL.Path, "/v1/kv/")
+	_, recurse := r.URL.Query()["recurse"]
+
+	if index, err := strconv.ParseUint(r.URL.Query().Get("index"), 10, 64); err == nil && r.Method == http.MethodGet {
+		c.wait(r, index)
+	}
+
+	c.Lock()
+	defer c.Unlock()
+	w.Header().Set("X-Consul-Index", strconv.FormatUint(c.index, 10))
+	switch r.Method {
+	case http.MethodGet:
+		entries := c.find(key, recurse)
+		if len(entries) == 0 {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		json.NewEncoder(w).Encode(entries)
+	case http.MethodPut:
+		value, _ := ioutil.ReadAll(r.Body)
+		c.index++
+		c.set(key, value)
+		json.NewEncoder(w).Encode(true)
+	case http.MethodDelete:
+		c.index++
+		for _, e := range c.find(key, recurse) {
+			delete(c.entries, e.Key)
+		}
+		json.NewEncoder(w).Encode(true)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func (c *consul) handleTxn(w http.ResponseWriter, r *http.Request) {
+	var ops []*consulTxnOp
+	if err := json.NewDecoder(r.Body).Decode(&ops); err != nil || len(ops) != 1 || ops[0].KV == nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	op := ops[0].KV
+
+	c.Lock()
+	defer c.Unlock()
+	var index uint64
+	if e, ok := c.entries[op.Key]; ok {
+		index = e.ModifyIndex
+	}
+	if (op.Verb != "cas" && op.Verb != "delete-cas") || index != op.Index {
+		w.WriteHeader(http.StatusConflict)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"Errors": []map[string]string{{"What": "failed to set key " + op.Key}},
+		})
+		return
+	}
+	c.index++
+	var results []map[string]*consulEntry
+	if op.Verb == "cas" {
+		e := *c.set(op.Key, op.Value)
+		// values are not returned by transactions
+		e.Value = nil
+		results = append(results, map[string]*consulEntry{"KV": &e})
+	} else {
+		delete(c.entries, op.Key)
+	}
+	json.NewEncoder(w).Encode(map[string]interface{}{"Results": results})
+}
+
+// wait implements blocking queries, it returns when the index of the store is past index, or the request is cancelled
+func (c *consul) wait(r *http.Request, index uint64) {
+	for {
+		c.Lock()
+		current := c.index
+		c.Unlock()
+		if current > index {
+			return
+		}
+		select {
+		case <-r.Context().Done():
+			return
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+}
+
+// find returns the entry of a key, or the entries of a prefix with recurse. Must be called with the lock held.
+func (c *consul) find(key string, recurse bool) []*consulEntry {
+	var entries []*consulEntry
+	for k, e := range c.entries {
+		if k == key || recurse && strings.HasPrefix(k, key) {
+			entries = append(entries, e)
+		}
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Key < entries[j].Key })
+	return entries
+}
+
+// set sets a key at the current index. Must be called with the lock held.
+func (c *consul) set(key string, value []byte) *consulEntry {
+	e, ok := c.entries[key]
+	if !ok {
+		e = &consulEntry{Key: key, CreateIndex: c.index}
+		c.entries[key] = e
+	}
+	e.Value = value
+	e.ModifyIndex = c.index
+	return e
+}
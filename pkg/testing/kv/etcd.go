@@ -0,0 +1,266 @@
+///////////////////////////////////////////////////////////////////////
+// Copyright (c) 2018 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+///////////////////////////////////////////////////////////////////////
+
+// Package kv provides in-process fakes of the key/value APIs of etcd and Consul, to test the entity store backends
+// without a cluster
+package kv
+
+// NO TESTS
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+type etcdKeyValue struct {
+	Key            []byte `json:"key"`
+	Value          []byte `json:"value"`
+	CreateRevision int64  `json:"create_revision,string"`
+	ModRevision    int64  `json:"mod_revision,string"`
+}
+
+type etcdHeader struct {
+	Revision int64 `json:"revision,string"`
+}
+
+type etcdRange struct {
+	Key      []byte `json:"key"`
+	RangeEnd []byte `json:"range_end"`
+	KeysOnly bool   `json:"keys_only"`
+}
+
+type etcdPut struct {
+	Key   []byte `json:"key"`
+	Value []byte `json:"value"`
+}
+
+type etcdCompare struct {
+	Target         string `json:"target"`
+	Result         string `json:"result"`
+	Key            []byte `json:"key"`
+	ModRevision    int64  `json:"mod_revision,string"`
+	CreateRevision int64  `json:"create_revision,string"`
+}
+
+type etcdOp struct {
+	RequestPut         *etcdPut   `json:"request_put"`
+	RequestDeleteRange *etcdRange `json:"request_delete_range"`
+}
+
+type etcdTxn struct {
+	Compare []*etcdCompare `json:"compare"`
+	Success []*etcdOp      `json:"success"`
+	Failure []*etcdOp      `json:"failure"`
+}
+
+// etcd is a fake of the etcd v3 JSON gateway, supporting the range, put, deleterange, txn (MOD and CREATE
+// comparisons) and watch calls
+type etcd struct {
+	sync.Mutex
+	revision int64
+	kvs      map[string]*etcdKeyValue
+}
+
+// NewEtcdServer starts a fake etcd server, which must be closed
+func NewEtcdServer() *httptest.Server {
+	e := &etcd{kvs: map[string]*etcdKeyValue{}}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v3/kv/range", e.handle(func(body []byte) (interface{}, error) {
+		var req etcdRange
+		if err := json.Unmarshal(body, &req); err != nil {
+			return nil, err
+		}
+		kvs := e.rangeKvs(&req)
+		if req.KeysOnly {
+			for i, kv := range kvs {
+				kvs[i] = &etcdKeyValue{Key: kv.Key, CreateRevision: kv.CreateRevision, ModRevision: kv.ModRevision}
+			}
+		}
+		return map[string]interface{}{"header": etcdHeader{e.revision}, "kvs": kvs}, nil
+	}))
+	mux.HandleFunc("/v3/kv/put", e.handle(func(body []byte) (interface{}, error) {
+		var req etcdPut
+		if err := json.Unmarshal(body, &req); err != nil {
+			return nil, err
+		}
+		e.revision++
+		e.put(&req)
+		return map[string]interface{}{"header": etcdHeader{e.revision}}, nil
+	}))
+	mux.HandleFunc("/v3/kv/deleterange", e.handle(func(body []byte) (interface{}, error) {
+		var req etcdRange
+		if err := json.Unmarshal(body, &req); err != nil {
+			return nil, err
+		}
+		deleted := e.deleteRange(&req)
+		return map[string]interface{}{"header": etcdHeader{e.revision}, "deleted": strconv.FormatInt(deleted, 10)}, nil
+	}))
+	mux.HandleFunc("/v3/kv/txn", e.handle(func(body []byte) (interface{}, error) {
+		var req etcdTxn
+		if err := json.Unmarshal(body, &req); err != nil {
+			return nil, err
+		}
+		succeeded := true
+		for _, c := range req.Compare {
+			succeeded = succeeded && e.compare(c)
+		}
+		ops := req.Success
+		if !succeeded {
+			ops = req.Failure
+		}
+		if len(ops) > 0 {
+			e.revision++
+		}
+		for _, op := range ops {
+			if op.RequestPut != nil {
+				e.put(op.RequestPut)
+			}
+			if op.RequestDeleteRange != nil {
+				e.deleteRange(op.RequestDeleteRange)
+			}
+		}
+		return map[string]interface{}{"header": etcdHeader{e.revision}, "succeeded": succeeded}, nil
+	}))
+	mux.HandleFunc("/v3/watch", e.watch)
+	return httptest.NewServer(mux)
+}
+
+// watch streams a watch response, with a single event, for each revision which changed the watched range
+func (e *etcd) watch(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		CreateRequest *etcdRange `json:"create_request"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.CreateRequest == nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	flusher, _ := w.(http.Flusher)
+	send := func(result map[string]interface{}) {
+		json.NewEncoder(w).Encode(map[string]interface{}{"result": result})
+		if flusher != nil {
+			flusher.Flush()
+		}
+	}
+
+	e.Lock()
+	snapshot := e.revisions(req.CreateRequest)
+	revision := e.revision
+	e.Unlock()
+	send(map[string]interface{}{"header": etcdHeader{revision}, "created": true})
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-time.After(10 * time.Millisecond):
+		}
+		e.Lock()
+		current := e.revisions(req.CreateRequest)
+		revision = e.revision
+		e.Unlock()
+		if reflect.DeepEqual(snapshot, current) {
+			continue
+		}
+		snapshot = current
+		send(map[string]interface{}{
+			"header": etcdHeader{revision},
+			"events": []map[string]interface{}{{"kv": map[string]interface{}{"key": req.CreateRequest.Key}}},
+		})
+	}
+}
+
+// revisions returns the mod revisions of the keys of a range. Must be called with the lock held.
+func (e *etcd) revisions(r *etcdRange) map[string]int64 {
+	revisions := make(map[string]int64)
+	for _, kv := range e.rangeKvs(r) {
+		revisions[string(kv.Key)] = kv.ModRevision
+	}
+	return revisions
+}
+
+func (e *etcd) handle(f func(body []byte) (interface{}, error)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var body bytes.Buffer
+		body.ReadFrom(r.Body)
+
+		e.Lock()
+		resp, err := f(body.Bytes())
+		e.Unlock()
+
+		w.Header().Set("Content-Type", "application/json")
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+			return
+		}
+		json.NewEncoder(w).Encode(resp)
+	}
+}
+
+// rangeKvs returns the key/value pairs of a range, sorted by key. Must be called with the lock held.
+func (e *etcd) rangeKvs(r *etcdRange) []*etcdKeyValue {
+	var kvs []*etcdKeyValue
+	for key, kv := range e.kvs {
+		if inRange([]byte(key), r) {
+			kvs = append(kvs, kv)
+		}
+	}
+	sort.Slice(kvs, func(i, j int) bool { return bytes.Compare(kvs[i].Key, kvs[j].Key) < 0 })
+	return kvs
+}
+
+func inRange(key []byte, r *etcdRange) bool {
+	if len(r.RangeEnd) == 0 {
+		return bytes.Equal(key, r.Key)
+	}
+	if bytes.Compare(key, r.Key) < 0 {
+		return false
+	}
+	// a range end of \0 means all keys from the key on
+	return bytes.Equal(r.RangeEnd, []byte{0}) || bytes.Compare(key, r.RangeEnd) < 0
+}
+
+// put sets a key at the current revision. Must be called with the lock held.
+func (e *etcd) put(p *etcdPut) {
+	kv, ok := e.kvs[string(p.Key)]
+	if !ok {
+		kv = &etcdKeyValue{Key: p.Key, CreateRevision: e.revision}
+		e.kvs[string(p.Key)] = kv
+	}
+	kv.Value = p.Value
+	kv.ModRevision = e.revision
+}
+
+// deleteRange deletes the keys of a range. Must be called with the lock held.
+func (e *etcd) deleteRange(r *etcdRange) int64 {
+	var deleted int64
+	for _, kv := range e.rangeKvs(r) {
+		delete(e.kvs, string(kv.Key))
+		deleted++
+	}
+	return deleted
+}
+
+// compare evaluates a comparison of a transaction, only equality is supported. Must be called with the lock held.
+func (e *etcd) compare(c *etcdCompare) bool {
+	var createRevision, modRevision int64
+	if kv, ok := e.kvs[string(c.Key)]; ok {
+		createRevision, modRevision = kv.CreateRevision, kv.ModRevision
+	}
+	switch c.Target {
+	case "CREATE":
+		return c.Result == "EQUAL" && createRevision == c.CreateRevision
+	case "MOD":
+		return c.Result == "EQUAL" && modRevision == c.ModRevision
+	}
+	return false
+}
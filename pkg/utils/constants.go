@@ -8,12 +8,18 @@ package utils
 // APIKind a constant representing the kind of the API model
 const APIKind = "API"
 
+// ConsumerKind a constant representing the kind of the API Consumer model
+const ConsumerKind = "Consumer"
+
 // ApplicationKind a constant to represent the kind of the Application model
 const ApplicationKind = "Application"
 
 // DriverKind a constant representing the kind of the Driver API model
 const DriverKind = "Driver"
 
+// DriverTypeKind a constant representing the kind of the Driver Type API model
+const DriverTypeKind = "DriverType"
+
 // SubscriptionKind a constant representing the kind of the Subscription API model
 const SubscriptionKind = "Subscription"
 
@@ -0,0 +1,41 @@
+///////////////////////////////////////////////////////////////////////
+// Copyright (c) 2018 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+///////////////////////////////////////////////////////////////////////
+
+package utils
+
+import (
+	"github.com/pkg/errors"
+
+	es "github.com/vmware/dispatch/pkg/entity-store"
+)
+
+// ParsePage sets the page requested with the limit, continue and sortBy query parameters of a list endpoint to the
+// list options
+func ParsePage(opts *es.Options, limit *int64, continueToken *string, sortBy []string) error {
+	if limit != nil {
+		if *limit < 0 {
+			return errors.Errorf("invalid limit %d", *limit)
+		}
+		opts.Limit = int(*limit)
+	}
+	if continueToken != nil {
+		opts.Continue = *continueToken
+	}
+	sortKeys, err := es.ParseSortKeys(sortBy)
+	if err != nil {
+		return err
+	}
+	opts.SortBy = sortKeys
+	return nil
+}
+
+// ListPage lists the page of entities selected by opts, returning the token of the following page for the
+// X-Continue header
+func ListPage(store es.EntityStore, organizationID string, opts es.Options, entities interface{}) (string, error) {
+	if err := store.List(organizationID, opts, entities); err != nil {
+		return "", err
+	}
+	return es.ContinueToken(opts, entities)
+}
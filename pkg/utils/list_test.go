@@ -0,0 +1,32 @@
+///////////////////////////////////////////////////////////////////////
+// Copyright (c) 2018 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+///////////////////////////////////////////////////////////////////////
+
+package utils
+
+import (
+	"testing"
+
+	"github.com/go-openapi/swag"
+	"github.com/stretchr/testify/assert"
+
+	es "github.com/vmware/dispatch/pkg/entity-store"
+)
+
+func TestParsePage(t *testing.T) {
+	var opts es.Options
+	assert.NoError(t, ParsePage(&opts, nil, nil, nil))
+	assert.Equal(t, es.Options{}, opts)
+
+	assert.NoError(t, ParsePage(&opts, swag.Int64(10), swag.String("token"), []string{"-createdTime", "tags.role"}))
+	assert.Equal(t, 10, opts.Limit)
+	assert.Equal(t, "token", opts.Continue)
+	assert.Equal(t, []es.SortKey{
+		{Scope: es.FilterScopeField, Subject: "CreatedTime", Descending: true},
+		{Scope: es.FilterScopeTag, Subject: "role"},
+	}, opts.SortBy)
+
+	assert.Error(t, ParsePage(&opts, swag.Int64(-1), nil, nil))
+	assert.Error(t, ParsePage(&opts, nil, nil, []string{"tags."}))
+}